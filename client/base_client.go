@@ -1,13 +1,17 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"iter"
 	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 	"github.com/Moonlight-Companies/gomodbus/logging"
 	"github.com/Moonlight-Companies/gomodbus/protocol"
 	"github.com/Moonlight-Companies/gomodbus/transport"
+	"github.com/Moonlight-Companies/gomodbus/values"
 )
 
 // BaseClient provides common functionality for all Modbus clients.
@@ -17,6 +21,80 @@ type BaseClient struct {
 	transport common.Transport
 	protocol  common.Protocol
 	unitID    common.UnitID
+
+	// When set, exception 0x01 for optional functions is returned as a
+	// common.NotSupportedError
+	notSupportedErrors bool
+
+	// Wire-level device quirks, see WithQuirks
+	quirks common.Quirks
+
+	// Deviations from the specification, see WithConformanceReport
+	conformance *conformanceRecorder
+
+	// Capabilities found by ProbeCapabilities
+	capabilities *capabilityCache
+
+	// Lifecycle events for Events(), shared between clones
+	events *common.EventStream
+
+	// Connection state callbacks, see WithOnConnected and WithOnDisconnected
+	hooks *connectionHooks
+
+	// Request policies, see WithRequestTimeout, WithRetry, WithRateLimit,
+	// WithFastLane and WithConcurrencyLimiter
+	requestTimeout time.Duration
+	retry          RetryPolicy
+	limiter        *rateLimiter
+	fastLane       *fastLane
+	concurrency    *ConcurrencyLimiter
+
+	// Chunk sizes of bulk operations, see WithAdaptiveChunking
+	chunks         *chunkLimits
+	adaptiveChunks bool
+
+	// Device naming for logs, errors and events, see WithDeviceRegistry
+	devices       *common.DeviceRegistry
+	deviceAddress string
+
+	// Names of the device-specific exception status bits
+	statusLabels common.ExceptionStatusLabels
+
+	// Default order of multi-register values, see WithWordOrder
+	wordOrder values.Order
+
+	// Named tags, see WithTagMap
+	tags *TagMap
+
+	// Writes to unit 0 are broadcast, see WithBroadcast
+	broadcast  bool
+	turnaround time.Duration
+
+	// Request counts, see RequestStats
+	stats *requestStats
+
+	// Set on clients made with ForUnit, which use the connection of the
+	// client they were made from without owning it
+	sharedConnection bool
+
+	// Wrappers of every request attempt, see WithInterceptor
+	interceptors []Interceptor
+
+	// Advisory exclusive access to the device, see WithOwnershipLease
+	lease *ownershipLease
+
+	// Write journal, see WithWriteJournal
+	journal *WriteJournal
+
+	// Device checks required before requests, see WithReadinessGate and
+	// WithEndpointChangeConfirmation
+	readiness    *readinessGate
+	endpointGate *readinessGate
+
+	// The server's host:port, labeling log fields, errors and events; the
+	// logger given with WithLogger is kept to relabel clones
+	endpoint    string
+	plainLogger common.LoggerInterface
 }
 
 // Option is a function that configures a BaseClient
@@ -25,6 +103,7 @@ type Option func(*BaseClient)
 // WithLogger sets the logger for the client
 func WithLogger(logger common.LoggerInterface) Option {
 	return func(c *BaseClient) {
+		c.plainLogger = logger
 		c.logger = logger
 
 		// Propagate logger to transport and protocol if possible
@@ -51,19 +130,82 @@ func WithProtocol(protocol common.Protocol) Option {
 	}
 }
 
+// WithNotSupportedErrors makes calls to optional functions (Read Exception
+// Status 0x07, Read Device Identification 0x2B) return a
+// common.NotSupportedError when the device answers with exception 0x01
+// (Illegal Function). The error matches common.ErrNotSupportedByDevice with
+// errors.Is and still unwraps to the original common.ModbusError, so feature
+// probing code can test for a single condition.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.1
+func WithNotSupportedErrors() Option {
+	return func(c *BaseClient) {
+		c.notSupportedErrors = true
+	}
+}
+
+// WithDeviceRegistry names the device in log fields, errors and events using
+// registry, looking it up by address and the client's unit ID. Errors
+// returned by requests are wrapped in a common.DeviceError.
+func WithDeviceRegistry(registry *common.DeviceRegistry, address string) Option {
+	return func(c *BaseClient) {
+		c.devices = registry
+		c.deviceAddress = address
+	}
+}
+
+// WithExceptionStatusLabels names the device-specific bits (0-7) of the
+// exception status, for ReadLabeledExceptionStatus
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.7 (Read Exception Status)
+func WithExceptionStatusLabels(labels map[int]string) Option {
+	return func(c *BaseClient) {
+		c.statusLabels = labels
+	}
+}
+
+// WithEndpoint sets the server address, "host:port", that labels the
+// client's log fields, errors and events as "host:port:unit". It defaults to
+// the address of a transport implementing common.Endpointer, such as
+// transport.TCPTransport.
+func WithEndpoint(address string) Option {
+	return func(c *BaseClient) {
+		c.endpoint = address
+	}
+}
+
+// optionalFunctions are the function codes a conforming device may legitimately
+// not implement
+var optionalFunctions = map[common.FunctionCode]bool{
+	common.FuncReadExceptionStatus:      true,
+	common.FuncReadDeviceIdentification: true,
+}
+
 // NewBaseClient creates a new BaseClient.
 func NewBaseClient(transport common.Transport, options ...Option) *BaseClient {
+	logger := logging.NewLogger()
 	client := &BaseClient{
-		logger:    logging.NewLogger(),
+		logger:      logger,
+		plainLogger: logger,
 		transport: transport,
 		protocol:  protocol.NewProtocolHandler(),
 		unitID:    0, // Default unit ID
+
+		capabilities:   &capabilityCache{},
+		chunks:         &chunkLimits{limits: make(map[common.FunctionCode]int)},
+		events:         &common.EventStream{},
+		hooks:          &connectionHooks{},
+		stats:          &requestStats{},
+		requestTimeout: defaultRequestTimeout,
+	}
+	if endpointer, ok := transport.(common.Endpointer); ok {
+		client.endpoint = endpointer.Endpoint()
 	}
 
 	// Apply options
 	for _, option := range options {
 		option(client)
 	}
+	client.labelLogger()
+	client.watchConnection()
 
 	return client
 }
@@ -71,24 +213,97 @@ func NewBaseClient(transport common.Transport, options ...Option) *BaseClient {
 // WithLogger returns a new client with the given logger
 func (c *BaseClient) WithLogger(logger common.LoggerInterface) common.Client {
 	// Create a copy of the client with the new logger
-	return NewBaseClient(
-		c.transport,
-		WithLogger(logger),
-		WithUnitID(c.unitID),
-		WithProtocol(c.protocol),
-	)
+	return c.clone(WithLogger(logger))
+}
+
+// clone returns a copy of the client with the given options applied,
+// preserving every other setting
+func (c *BaseClient) clone(options ...Option) *BaseClient {
+	client := *c
+	for _, option := range options {
+		option(&client)
+	}
+	client.labelLogger()
+	return &client
+}
+
+// Endpoint returns the label of the device the client talks to,
+// "host:port:unit", or "" when the server address is unknown (see
+// WithEndpoint)
+func (c *BaseClient) Endpoint() string {
+	if c.endpoint == "" {
+		return ""
+	}
+	return common.EndpointLabel(c.endpoint, c.unitID)
+}
+
+// labelLogger adds the endpoint label to the client's log fields
+func (c *BaseClient) labelLogger() {
+	c.logger = c.plainLogger
+	if endpoint := c.Endpoint(); endpoint != "" {
+		c.logger = c.plainLogger.WithFields(map[string]interface{}{"endpoint": endpoint})
+	}
 }
 
-// Connect establishes a connection to the Modbus server.
+// Connect establishes a connection to the Modbus server. On a client made
+// with ForUnit it only checks that the shared connection is up.
 func (c *BaseClient) Connect(ctx context.Context) error {
+	if c.sharedConnection {
+		if !c.transport.IsConnected() {
+			return common.WithEndpoint(fmt.Errorf("shared connection is down, connect the client ForUnit was called on: %w", common.ErrNotConnected), c.Endpoint())
+		}
+		return nil
+	}
 	c.logger.Info(ctx, "Connecting to Modbus server with unit ID %d", c.unitID)
-	return c.transport.Connect(ctx)
+	if err := c.lease.acquire(ctx, c.logger, true); err != nil {
+		return err
+	}
+	if err := c.transport.Connect(ctx); err != nil {
+		c.lease.release()
+		return common.WithEndpoint(err, c.Endpoint())
+	}
+	c.readiness.reset()
+	c.events.Emit(common.Event{Type: common.EventConnected, Device: c.DeviceName(), Endpoint: c.Endpoint(),
+		CorrelationID: common.CorrelationID(ctx)})
+	c.hooks.up(ctx)
+	return nil
 }
 
-// Disconnect closes the connection to the Modbus server.
+// Disconnect closes the connection to the Modbus server. It does nothing on
+// a client made with ForUnit, whose connection is shared with other units.
 func (c *BaseClient) Disconnect(ctx context.Context) error {
+	if c.sharedConnection {
+		return nil
+	}
 	c.logger.Info(ctx, "Disconnecting from Modbus server")
-	return c.transport.Disconnect(ctx)
+	err := c.transport.Disconnect(ctx)
+	c.lease.release()
+	c.readiness.reset()
+	c.events.Emit(common.Event{Type: common.EventDisconnected, Device: c.DeviceName(), Endpoint: c.Endpoint(),
+		CorrelationID: common.CorrelationID(ctx)})
+	c.hooks.down(ctx, nil)
+	return err
+}
+
+// Events returns a channel of lifecycle events: EventConnected and
+// EventDisconnected for Connect and Disconnect, EventDisconnected when a
+// transport.TCPTransport loses its connection, EventRequestFailed for every
+// request ending in an exception or transport error, and, for clients created
+// with NewTCPClientFromTransport, EventDisconnected and EventReconnected when
+// the transport loses its connection and establishes a new one.
+// The channel is shared by clones of the client. Events are only collected
+// once Events has been called, and are dropped rather than blocking requests
+// when the channel is full.
+func (c *BaseClient) Events() <-chan common.Event {
+	return c.events.Channel()
+}
+
+// AllEvents returns an iterator over the events of Events, ending when ctx
+// is done or the loop breaks:
+//
+//	for event := range client.AllEvents(ctx) { ... }
+func (c *BaseClient) AllEvents(ctx context.Context) iter.Seq[common.Event] {
+	return c.events.All(ctx)
 }
 
 // IsConnected returns true if the client is connected to the server.
@@ -101,6 +316,25 @@ func (c *BaseClient) Send(ctx context.Context, functionCode common.FunctionCode,
 	if !c.IsConnected() {
 		return nil, common.ErrNotConnected
 	}
+	if err := c.lease.acquire(ctx, c.logger, false); err != nil {
+		return nil, err
+	}
+	if err := c.lease.allow(functionCode); err != nil {
+		return nil, err
+	}
+	if err := c.readiness.wait(ctx, c); err != nil {
+		c.logger.Error(ctx, "Not sending request: %v", err)
+		return nil, common.WithEndpoint(err, c.Endpoint())
+	}
+	if err := c.confirmEndpoint(ctx, functionCode); err != nil {
+		c.logger.Error(ctx, "Not sending write to a changed endpoint: %v", err)
+		return nil, common.WithEndpoint(err, c.Endpoint())
+	}
+	if c.broadcasting() && !broadcastable(functionCode) {
+		err := fmt.Errorf("function %s: %w", functionCode, common.ErrBroadcastRead)
+		c.logger.Error(ctx, "Not sending request: %v", err)
+		return nil, common.WithEndpoint(err, c.Endpoint())
+	}
 
 	// Create the request
 	request := transport.NewRequest(c.unitID, functionCode, data)
@@ -109,140 +343,279 @@ func (c *BaseClient) Send(ctx context.Context, functionCode common.FunctionCode,
 	var cancel context.CancelFunc
 	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
 		// Apply a default timeout if no deadline specified
-		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
 		defer cancel()
 	}
 
-	c.logger.Debug(ctx, "Sending request: function=%s, data=%v", functionCode, data)
+	logger := c.logger
+	device := c.DeviceName()
+	if device != "" {
+		logger = logger.WithFields(map[string]interface{}{"device": device})
+	}
+
+	logger.Debug(ctx, "Sending request: function=%s, data=%v", functionCode, data)
+
+	// Journal writes before sending them, see WithWriteJournal
+	record, err := c.beginJournal(ctx, device, request)
+	if err != nil {
+		logger.Error(ctx, "Not sending request: %v", err)
+		return nil, c.requestFailed(ctx, functionCode, device, err)
+	}
 
 	// Send the request and get the response
-	response, err := c.transport.Send(ctx, request)
+	response, err := c.transmit(ctx, request, record.attempt)
+	record.finish(ctx, logger, response, err)
 	if err != nil {
-		c.logger.Error(ctx, "Error sending request: %v", err)
-		return nil, err
+		// The transport may reconnect, possibly to another device
+		c.readiness.reset()
+		c.conformance.failed(request, err)
+		logger.Error(ctx, "Error sending request: %v", err)
+		return nil, c.requestFailed(ctx, functionCode, device, err)
 	}
+	c.conformance.check(request, response)
 
 	// Check for Modbus exception
 	if response.IsException() {
-		c.logger.Warn(ctx, "Received exception response: function=%s, exception=%d",
+		logger.Warn(ctx, "Received exception response: function=%s, exception=%d",
 			response.GetPDU().FunctionCode, response.GetException())
-		return nil, response.ToError()
+		err := c.exceptionError(functionCode, response)
+		return nil, c.requestFailed(ctx, functionCode, device, err)
 	}
 
-	c.logger.Debug(ctx, "Received successful response: function=%s", response.GetPDU().FunctionCode)
+	logger.Debug(ctx, "Received successful response: function=%s", response.GetPDU().FunctionCode)
 	return response, nil
 }
 
+// DeviceName returns the name of the device from the registry set with
+// WithDeviceRegistry, "address/unit" if it has none, or "" without a registry
+func (c *BaseClient) DeviceName() string {
+	if c.devices == nil {
+		return ""
+	}
+	return c.devices.Name(c.deviceAddress, c.unitID)
+}
+
+// requestFailed emits EventRequestFailed and returns err, annotated with the
+// endpoint label and the device name when they are known
+func (c *BaseClient) requestFailed(ctx context.Context, functionCode common.FunctionCode, device string, err error) error {
+	err = common.WithEndpoint(err, c.Endpoint())
+	if device != "" {
+		err = &common.DeviceError{Device: device, Err: err}
+	}
+	c.events.Emit(common.Event{
+		Type:          common.EventRequestFailed,
+		Device:        device,
+		Endpoint:      c.Endpoint(),
+		CorrelationID: common.CorrelationID(ctx),
+		UnitID:        c.unitID,
+		FunctionCode:  functionCode,
+		Err:           err,
+	})
+	return err
+}
+
+// exceptionError converts an exception response to an error, normalizing
+// unsupported optional functions when WithNotSupportedErrors is set
+func (c *BaseClient) exceptionError(functionCode common.FunctionCode, response common.Response) error {
+	err := response.ToError()
+	if !c.notSupportedErrors || !optionalFunctions[functionCode] ||
+		response.GetException() != common.ExceptionFunctionCodeNotSupported {
+		return err
+	}
+
+	modbusErr, ok := err.(*common.ModbusError)
+	if !ok {
+		return err
+	}
+	return &common.NotSupportedError{FunctionCode: functionCode, Exception: modbusErr}
+}
+
 // ReadCoils reads coils from the server.
 func (c *BaseClient) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
-	c.logger.Debug(ctx, "Reading %d coils from address %d", quantity, address)
+	values := make([]common.CoilValue, quantity)
+	if err := c.ReadCoilsInto(ctx, address, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ReadCoilsInto reads len(dst) coils starting at address into dst,
+// so that polling loops can reuse one slice instead of allocating per read
+func (c *BaseClient) ReadCoilsInto(ctx context.Context, address common.Address, dst []common.CoilValue) error {
+	c.logger.Debug(ctx, "Reading %d coils from address %d", len(dst), address)
+	if len(dst) > int(common.MaxCoilCount) {
+		return fmt.Errorf("reading %d coils: %w", len(dst), common.ErrInvalidQuantity)
+	}
 
 	// Generate the request data
-	requestData, err := c.protocol.GenerateReadCoilsRequest(address, quantity)
+	requestData, err := c.protocol.GenerateReadCoilsRequest(address, common.Quantity(len(dst)))
 	if err != nil {
 		c.logger.Error(ctx, "Error generating read coils request: %v", err)
-		return nil, err
+		return err
 	}
 
 	// Send the request
 	response, err := c.Send(ctx, common.FuncReadCoils, requestData)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Parse the response
-	values, err := c.protocol.ParseReadCoilsResponse(response.GetPDU().Data, quantity)
+	if buffered, ok := c.protocol.(common.BufferedProtocol); ok {
+		err = buffered.ParseReadCoilsResponseInto(response.GetPDU().Data, dst)
+	} else {
+		var values []common.CoilValue
+		values, err = c.protocol.ParseReadCoilsResponse(response.GetPDU().Data, common.Quantity(len(dst)))
+		copy(dst, values)
+	}
 	if err != nil {
 		c.logger.Error(ctx, "Error parsing read coils response: %v", err)
-		return nil, err
+		return err
 	}
 
-	c.logger.Debug(ctx, "Read %d coils successfully", len(values))
-	return values, nil
+	c.logger.Debug(ctx, "Read %d coils successfully", len(dst))
+	return nil
 }
 
 // ReadDiscreteInputs reads discrete inputs from the server.
 func (c *BaseClient) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
-	c.logger.Debug(ctx, "Reading %d discrete inputs from address %d", quantity, address)
+	values := make([]common.DiscreteInputValue, quantity)
+	if err := c.ReadDiscreteInputsInto(ctx, address, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ReadDiscreteInputsInto reads len(dst) discrete inputs starting at address into dst,
+// so that polling loops can reuse one slice instead of allocating per read
+func (c *BaseClient) ReadDiscreteInputsInto(ctx context.Context, address common.Address, dst []common.DiscreteInputValue) error {
+	c.logger.Debug(ctx, "Reading %d discrete inputs from address %d", len(dst), address)
+	if len(dst) > int(common.MaxCoilCount) {
+		return fmt.Errorf("reading %d discrete inputs: %w", len(dst), common.ErrInvalidQuantity)
+	}
 
 	// Generate the request data
-	requestData, err := c.protocol.GenerateReadDiscreteInputsRequest(address, quantity)
+	requestData, err := c.protocol.GenerateReadDiscreteInputsRequest(address, common.Quantity(len(dst)))
 	if err != nil {
 		c.logger.Error(ctx, "Error generating read discrete inputs request: %v", err)
-		return nil, err
+		return err
 	}
 
 	// Send the request
 	response, err := c.Send(ctx, common.FuncReadDiscreteInputs, requestData)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Parse the response
-	values, err := c.protocol.ParseReadDiscreteInputsResponse(response.GetPDU().Data, quantity)
+	if buffered, ok := c.protocol.(common.BufferedProtocol); ok {
+		err = buffered.ParseReadDiscreteInputsResponseInto(response.GetPDU().Data, dst)
+	} else {
+		var values []common.DiscreteInputValue
+		values, err = c.protocol.ParseReadDiscreteInputsResponse(response.GetPDU().Data, common.Quantity(len(dst)))
+		copy(dst, values)
+	}
 	if err != nil {
 		c.logger.Error(ctx, "Error parsing read discrete inputs response: %v", err)
-		return nil, err
+		return err
 	}
 
-	c.logger.Debug(ctx, "Read %d discrete inputs successfully", len(values))
-	return values, nil
+	c.logger.Debug(ctx, "Read %d discrete inputs successfully", len(dst))
+	return nil
 }
 
 // ReadHoldingRegisters reads holding registers from the server.
 func (c *BaseClient) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
-	c.logger.Debug(ctx, "Reading %d holding registers from address %d", quantity, address)
+	values := make([]common.RegisterValue, quantity)
+	if err := c.ReadHoldingRegistersInto(ctx, address, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ReadHoldingRegistersInto reads len(dst) holding registers starting at address into dst,
+// so that polling loops can reuse one slice instead of allocating per read
+func (c *BaseClient) ReadHoldingRegistersInto(ctx context.Context, address common.Address, dst []common.RegisterValue) error {
+	c.logger.Debug(ctx, "Reading %d holding registers from address %d", len(dst), address)
+	if len(dst) > int(common.MaxRegisterCount) {
+		return fmt.Errorf("reading %d holding registers: %w", len(dst), common.ErrInvalidQuantity)
+	}
 
 	// Generate the request data
-	requestData, err := c.protocol.GenerateReadHoldingRegistersRequest(address, quantity)
+	requestData, err := c.protocol.GenerateReadHoldingRegistersRequest(address, common.Quantity(len(dst)))
 	if err != nil {
 		c.logger.Error(ctx, "Error generating read holding registers request: %v", err)
-		return nil, err
+		return err
 	}
 
 	// Send the request
 	response, err := c.Send(ctx, common.FuncReadHoldingRegisters, requestData)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Parse the response
-	values, err := c.protocol.ParseReadHoldingRegistersResponse(response.GetPDU().Data, quantity)
+	if buffered, ok := c.protocol.(common.BufferedProtocol); ok {
+		err = buffered.ParseReadHoldingRegistersResponseInto(response.GetPDU().Data, dst)
+	} else {
+		var values []common.RegisterValue
+		values, err = c.protocol.ParseReadHoldingRegistersResponse(response.GetPDU().Data, common.Quantity(len(dst)))
+		copy(dst, values)
+	}
 	if err != nil {
 		c.logger.Error(ctx, "Error parsing read holding registers response: %v", err)
-		return nil, err
+		return err
 	}
 
-	c.logger.Debug(ctx, "Read %d holding registers successfully", len(values))
-	return values, nil
+	c.logger.Debug(ctx, "Read %d holding registers successfully", len(dst))
+	return nil
 }
 
 // ReadInputRegisters reads input registers from the server.
 func (c *BaseClient) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
-	c.logger.Debug(ctx, "Reading %d input registers from address %d", quantity, address)
+	values := make([]common.InputRegisterValue, quantity)
+	if err := c.ReadInputRegistersInto(ctx, address, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ReadInputRegistersInto reads len(dst) input registers starting at address into dst,
+// so that polling loops can reuse one slice instead of allocating per read
+func (c *BaseClient) ReadInputRegistersInto(ctx context.Context, address common.Address, dst []common.InputRegisterValue) error {
+	c.logger.Debug(ctx, "Reading %d input registers from address %d", len(dst), address)
+	if len(dst) > int(common.MaxRegisterCount) {
+		return fmt.Errorf("reading %d input registers: %w", len(dst), common.ErrInvalidQuantity)
+	}
 
 	// Generate the request data
-	requestData, err := c.protocol.GenerateReadInputRegistersRequest(address, quantity)
+	requestData, err := c.protocol.GenerateReadInputRegistersRequest(address, common.Quantity(len(dst)))
 	if err != nil {
 		c.logger.Error(ctx, "Error generating read input registers request: %v", err)
-		return nil, err
+		return err
 	}
 
 	// Send the request
-	response, err := c.Send(ctx, common.FuncReadInputRegisters, requestData)
+	response, err := c.Send(ctx, c.inputRegistersFunction(), requestData)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Parse the response
-	values, err := c.protocol.ParseReadInputRegistersResponse(response.GetPDU().Data, quantity)
+	if buffered, ok := c.protocol.(common.BufferedProtocol); ok {
+		err = buffered.ParseReadInputRegistersResponseInto(response.GetPDU().Data, dst)
+	} else {
+		var values []common.InputRegisterValue
+		values, err = c.protocol.ParseReadInputRegistersResponse(response.GetPDU().Data, common.Quantity(len(dst)))
+		copy(dst, values)
+	}
 	if err != nil {
 		c.logger.Error(ctx, "Error parsing read input registers response: %v", err)
-		return nil, err
+		return err
 	}
 
-	c.logger.Debug(ctx, "Read %d input registers successfully", len(values))
-	return values, nil
+	c.logger.Debug(ctx, "Read %d input registers successfully", len(dst))
+	return nil
 }
 
 // WriteSingleCoil writes a single coil to the server.
@@ -357,6 +730,105 @@ func (c *BaseClient) WriteMultipleRegisters(ctx context.Context, address common.
 	return nil
 }
 
+// ReadFileRecord reads groups of registers from the device's files, such as
+// recipe storage, in one request; the records are returned in the order of
+// requests.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+func (c *BaseClient) ReadFileRecord(ctx context.Context, requests []common.FileRecordRequest) ([]common.FileRecord, error) {
+	c.logger.Info(ctx, "Reading %d file records", len(requests))
+
+	// Generate the request data
+	requestData, err := c.protocol.GenerateReadFileRecordRequest(requests)
+	if err != nil {
+		c.logger.Error(ctx, "Error generating read file record request: %v", err)
+		return nil, err
+	}
+
+	// Send the request
+	response, err := c.Send(ctx, common.FuncReadFileRecord, requestData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the response
+	records, err := c.protocol.ParseReadFileRecordResponse(response.GetPDU().Data, requests)
+	if err != nil {
+		c.logger.Error(ctx, "Error parsing read file record response: %v", err)
+		return nil, err
+	}
+
+	c.logger.Debug(ctx, "Read %d file records successfully", len(records))
+	return records, nil
+}
+
+// WriteFileRecord writes groups of registers to the device's files in one
+// request.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Write File Record)
+func (c *BaseClient) WriteFileRecord(ctx context.Context, records []common.FileRecord) error {
+	c.logger.Info(ctx, "Writing %d file records", len(records))
+
+	// Generate the request data
+	requestData, err := c.protocol.GenerateWriteFileRecordRequest(records)
+	if err != nil {
+		c.logger.Error(ctx, "Error generating write file record request: %v", err)
+		return err
+	}
+
+	// Send the request
+	response, err := c.Send(ctx, common.FuncWriteFileRecord, requestData)
+	if err != nil {
+		return err
+	}
+
+	// Parse the response, which echoes the request
+	if _, err := c.protocol.ParseWriteFileRecordResponse(response.GetPDU().Data); err != nil {
+		c.logger.Error(ctx, "Error parsing write file record response: %v", err)
+		return err
+	}
+	if !bytes.Equal(response.GetPDU().Data, requestData) {
+		return fmt.Errorf("write file record response % X does not echo the request: %w",
+			response.GetPDU().Data, common.ErrInvalidResponseFormat)
+	}
+
+	c.logger.Debug(ctx, "Wrote %d file records successfully", len(records))
+	return nil
+}
+
+// MaskWriteRegister modifies bits of a holding register atomically: the
+// device sets it to (current AND andMask) OR (orMask AND NOT andMask), so
+// bits are changed without a read-modify-write racing other masters.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16 (Mask Write Register)
+func (c *BaseClient) MaskWriteRegister(ctx context.Context, address common.Address, andMask, orMask uint16) error {
+	c.logger.Info(ctx, "Mask writing register at address %d with AND 0x%04X, OR 0x%04X", address, andMask, orMask)
+
+	// Generate the request data
+	requestData, err := c.protocol.GenerateMaskWriteRegisterRequest(address, andMask, orMask)
+	if err != nil {
+		c.logger.Error(ctx, "Error generating mask write register request: %v", err)
+		return err
+	}
+
+	// Send the request
+	response, err := c.Send(ctx, common.FuncMaskWriteRegister, requestData)
+	if err != nil {
+		return err
+	}
+
+	// Parse the response, which echoes the request
+	echoAddress, echoAnd, echoOr, err := c.protocol.ParseMaskWriteRegisterResponse(response.GetPDU().Data)
+	if err != nil {
+		c.logger.Error(ctx, "Error parsing mask write register response: %v", err)
+		return err
+	}
+	if echoAddress != address || echoAnd != andMask || echoOr != orMask {
+		return fmt.Errorf("mask write register response % X does not echo the request: %w",
+			response.GetPDU().Data, common.ErrInvalidResponseFormat)
+	}
+
+	c.logger.Debug(ctx, "Mask wrote register %d successfully", address)
+	return nil
+}
+
 // ReadWriteMultipleRegisters reads and writes multiple registers to the server.
 func (c *BaseClient) ReadWriteMultipleRegisters(ctx context.Context, readAddress common.Address, readQuantity common.Quantity, writeAddress common.Address, writeValues []common.RegisterValue) ([]common.RegisterValue, error) {
 	c.logger.Debug(ctx, "Reading %d registers from %d and writing %d registers to %d",
@@ -386,6 +858,36 @@ func (c *BaseClient) ReadWriteMultipleRegisters(ctx context.Context, readAddress
 	return values, nil
 }
 
+// ReadFIFOQueue reads the values queued at pointerAddress, oldest first; the
+// device does not remove them from the queue.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18 (Read FIFO Queue)
+func (c *BaseClient) ReadFIFOQueue(ctx context.Context, pointerAddress common.Address) ([]common.RegisterValue, error) {
+	c.logger.Info(ctx, "Reading FIFO queue at address %d", pointerAddress)
+
+	// Generate the request data
+	requestData, err := c.protocol.GenerateReadFIFOQueueRequest(pointerAddress)
+	if err != nil {
+		c.logger.Error(ctx, "Error generating read FIFO queue request: %v", err)
+		return nil, err
+	}
+
+	// Send the request
+	response, err := c.Send(ctx, common.FuncReadFIFOQueue, requestData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the response
+	values, err := c.protocol.ParseReadFIFOQueueResponse(response.GetPDU().Data)
+	if err != nil {
+		c.logger.Error(ctx, "Error parsing read FIFO queue response: %v", err)
+		return nil, err
+	}
+
+	c.logger.Debug(ctx, "Read %d queued values successfully", len(values))
+	return values, nil
+}
+
 // ReadExceptionStatus reads the exception status from the server.
 func (c *BaseClient) ReadExceptionStatus(ctx context.Context) (common.ExceptionStatus, error) {
 	c.logger.Info(ctx, "Reading exception status")
@@ -414,6 +916,17 @@ func (c *BaseClient) ReadExceptionStatus(ctx context.Context) (common.ExceptionS
 	return status, nil
 }
 
+// ReadLabeledExceptionStatus reads the exception status with the bit labels
+// set by WithExceptionStatusLabels, so bits can be tested with IsSet(name)
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.7 (Read Exception Status)
+func (c *BaseClient) ReadLabeledExceptionStatus(ctx context.Context) (common.LabeledExceptionStatus, error) {
+	status, err := c.ReadExceptionStatus(ctx)
+	if err != nil {
+		return common.LabeledExceptionStatus{}, err
+	}
+	return common.LabeledExceptionStatus{Status: status, Labels: c.statusLabels}, nil
+}
+
 // ReadDeviceIdentification reads device identification data from the server.
 // The readDeviceIDCode specifies which identification data to read:
 //   - ReadDeviceIDBasic: Basic device identification (stream access)
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
@@ -349,4 +350,163 @@ func TestBaseClient_WriteSingleCoil(t *testing.T) {
 	// if reqValue != common.CoilOffU16 {
 	//    t.Errorf("Request value for false: expected 0x0000, got 0x%04X", reqValue)
 	// }
-}
\ No newline at end of file
+}
+
+func TestBaseClient_MaskWriteRegister(t *testing.T) {
+	transport := test.NewMockTransport()
+	client := NewBaseClient(transport)
+	ctx := context.Background()
+	transport.Connect(ctx)
+	client.Connect(ctx)
+
+	// The example of the specification, answered with an echo
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16
+	request := []byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x25}
+	transport.QueueResponse(test.NewMockResponse(1, 1, common.FuncMaskWriteRegister, request))
+	if err := client.MaskWriteRegister(ctx, 4, 0x00F2, 0x0025); err != nil {
+		t.Fatalf("MaskWriteRegister returned error: %v", err)
+	}
+	requests := transport.GetRequests()
+	if len(requests) != 1 || requests[0].GetPDU().FunctionCode != common.FuncMaskWriteRegister ||
+		!bytes.Equal(requests[0].GetPDU().Data, request) {
+		t.Fatalf("Expected a mask write register request % X, got %v", request, requests)
+	}
+
+	// A response that does not echo the masks is rejected
+	transport.QueueResponse(test.NewMockResponse(2, 1, common.FuncMaskWriteRegister, []byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x00}))
+	if err := client.MaskWriteRegister(ctx, 4, 0x00F2, 0x0025); !errors.Is(err, common.ErrInvalidResponseFormat) {
+		t.Errorf("Expected ErrInvalidResponseFormat, got %v", err)
+	}
+}
+
+func TestBaseClient_ReadFIFOQueue(t *testing.T) {
+	transport := test.NewMockTransport()
+	client := NewBaseClient(transport)
+	ctx := context.Background()
+	transport.Connect(ctx)
+	client.Connect(ctx)
+
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+	transport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadFIFOQueue, []byte{0x00, 0x06, 0x00, 0x02, 0x01, 0xB8, 0x12, 0x84}))
+	values, err := client.ReadFIFOQueue(ctx, 1246)
+	if err != nil || len(values) != 2 || values[0] != 440 || values[1] != 4740 {
+		t.Fatalf("Expected [440 4740], got %v (%v)", values, err)
+	}
+	requests := transport.GetRequests()
+	if len(requests) != 1 || requests[0].GetPDU().FunctionCode != common.FuncReadFIFOQueue ||
+		!bytes.Equal(requests[0].GetPDU().Data, []byte{0x04, 0xDE}) {
+		t.Errorf("Expected a read FIFO queue request for 0x04DE, got %v", requests)
+	}
+}
+
+func TestBaseClient_FileRecord(t *testing.T) {
+	transport := test.NewMockTransport()
+	client := NewBaseClient(transport)
+	ctx := context.Background()
+	transport.Connect(ctx)
+	client.Connect(ctx)
+
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+	transport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadFileRecord,
+		[]byte{0x0C, 0x05, 0x06, 0x0D, 0xFE, 0x00, 0x20, 0x05, 0x06, 0x33, 0xCD, 0x00, 0x40}))
+	records, err := client.ReadFileRecord(ctx, []common.FileRecordRequest{
+		{FileNumber: 4, RecordNumber: 1, Length: 2},
+		{FileNumber: 3, RecordNumber: 9, Length: 2},
+	})
+	if err != nil || len(records) != 2 || records[0].FileNumber != 4 || records[1].RecordNumber != 9 ||
+		records[0].Values[0] != 0x0DFE || records[1].Values[1] != 0x0040 {
+		t.Fatalf("Expected the records of files 4 and 3, got %v (%v)", records, err)
+	}
+
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+	request := []byte{0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D}
+	transport.QueueResponse(test.NewMockResponse(2, 1, common.FuncWriteFileRecord, request))
+	written := []common.FileRecord{{FileNumber: 4, RecordNumber: 7, Values: []common.RegisterValue{0x06AF, 0x04BE, 0x100D}}}
+	if err := client.WriteFileRecord(ctx, written); err != nil {
+		t.Fatalf("Expected the write to succeed, got %v", err)
+	}
+	requests := transport.GetRequests()
+	if len(requests) != 2 || !bytes.Equal(requests[1].GetPDU().Data, request) {
+		t.Errorf("Expected a write file record request % X, got %v", request, requests)
+	}
+
+	// A response that does not echo the request is refused
+	changed := bytes.Clone(request)
+	changed[len(changed)-1]++
+	transport.QueueResponse(test.NewMockResponse(3, 1, common.FuncWriteFileRecord, changed))
+	if err := client.WriteFileRecord(ctx, written); !errors.Is(err, common.ErrInvalidResponseFormat) {
+		t.Errorf("Expected ErrInvalidResponseFormat, got %v", err)
+	}
+}
+
+func TestBaseClient_WithNotSupportedErrors(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithNotSupportedErrors())
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	illegalFunction := []byte{byte(common.ExceptionFunctionCodeNotSupported)}
+
+	// Optional function rejected with exception 0x01 is normalized
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadExceptionStatus|0x80, illegalFunction))
+	_, err := client.ReadExceptionStatus(ctx)
+	if !errors.Is(err, common.ErrNotSupportedByDevice) {
+		t.Fatalf("Expected ErrNotSupportedByDevice, got %v", err)
+	}
+	if !common.IsFunctionNotSupportedError(err) {
+		t.Errorf("Expected wrapped exception to remain visible, got %v", err)
+	}
+	var notSupported *common.NotSupportedError
+	if !errors.As(err, &notSupported) || notSupported.FunctionCode != common.FuncReadExceptionStatus {
+		t.Errorf("Expected NotSupportedError for FC 0x07, got %v", err)
+	}
+
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadDeviceIdentification|0x80, illegalFunction))
+	_, err = client.ReadDeviceIdentification(ctx, common.ReadDeviceIDBasic, 0)
+	if !common.IsNotSupportedByDeviceError(err) {
+		t.Errorf("Expected ErrNotSupportedByDevice for FC 0x2B, got %v", err)
+	}
+
+	// Other exceptions on optional functions are left untouched
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadExceptionStatus|0x80,
+		[]byte{byte(common.ExceptionServerDeviceBusy)}))
+	_, err = client.ReadExceptionStatus(ctx)
+	if common.IsNotSupportedByDeviceError(err) || !common.IsServerDeviceBusyError(err) {
+		t.Errorf("Expected plain busy exception, got %v", err)
+	}
+
+	// Mandatory functions are left untouched
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadCoils|0x80, illegalFunction))
+	_, err = client.ReadCoils(ctx, 0, 1)
+	if common.IsNotSupportedByDeviceError(err) || !common.IsFunctionNotSupportedError(err) {
+		t.Errorf("Expected plain illegal function exception, got %v", err)
+	}
+
+	// The setting survives cloning
+	cloned := client.WithLogger(logging.NewNoopLogger()).(*BaseClient)
+	if !cloned.notSupportedErrors {
+		t.Error("Expected WithLogger to preserve WithNotSupportedErrors")
+	}
+}
+
+func TestBaseClient_ReadLabeledExceptionStatus(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithExceptionStatusLabels(map[int]string{0: "Overtemp", 3: "Door open"}))
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadExceptionStatus, []byte{0x21}))
+	status, err := client.ReadLabeledExceptionStatus(ctx)
+	if err != nil {
+		t.Fatalf("ReadLabeledExceptionStatus failed: %v", err)
+	}
+	if !status.IsSet("Overtemp") || status.IsSet("Door open") || status.IsSet("Unknown") {
+		t.Errorf("Unexpected bits in %s", status)
+	}
+	if s := status.String(); s != "ExceptionStatus(Overtemp, bit 5)" {
+		t.Errorf("Unexpected string %q", s)
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// WithBroadcast makes a client with unit ID common.BroadcastUnitID (0)
+// broadcast its writes: every device on the line carries them out and none
+// answers, so the client sends each write without waiting for a response and
+// returns once turnaround has passed, giving the devices time to process it
+// before the next request. Reads fail with common.ErrBroadcastRead. Without
+// this option unit 0 is an ordinary address, which Modbus TCP devices answer.
+// The transport must implement common.Broadcaster, as transport.TCPTransport
+// does; broadcasts are meant for serial lines, such as behind an RTU over TCP
+// converter.
+// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.1 (Protocol description)
+func WithBroadcast(turnaround time.Duration) Option {
+	return func(c *BaseClient) {
+		c.broadcast = true
+		c.turnaround = turnaround
+	}
+}
+
+// broadcasting reports whether the client broadcasts its requests
+func (c *BaseClient) broadcasting() bool {
+	return c.broadcast && c.unitID == common.BroadcastUnitID
+}
+
+// broadcastable reports whether a function can be broadcast: only writes,
+// whose outcome the client knows without a response
+func broadcastable(functionCode common.FunctionCode) bool {
+	_, ok := broadcastEcho(functionCode, nil)
+	return ok
+}
+
+// broadcastEcho returns the response data a device would have answered a
+// write with, which repeats the request data or its first four bytes
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.5, 6.6, 6.11-6.16
+func broadcastEcho(functionCode common.FunctionCode, data []byte) ([]byte, bool) {
+	switch functionCode {
+	case common.FuncWriteSingleCoil, common.FuncWriteSingleRegister,
+		common.FuncMaskWriteRegister, common.FuncWriteFileRecord:
+		return data, true
+	case common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters:
+		return data[:min(len(data), 4)], true
+	}
+	return nil, false
+}
+
+// sendBroadcast broadcasts a write and returns the response its device would
+// have sent, so the write methods need not tell broadcasts apart
+func (c *BaseClient) sendBroadcast(ctx context.Context, request common.Request) (common.Response, error) {
+	pdu := request.GetPDU()
+	echo, ok := broadcastEcho(pdu.FunctionCode, pdu.Data)
+	if !ok {
+		return nil, fmt.Errorf("function %s: %w", pdu.FunctionCode, common.ErrBroadcastRead)
+	}
+	broadcaster, ok := c.transport.(common.Broadcaster)
+	if !ok {
+		return nil, fmt.Errorf("transport %T cannot broadcast: %w", c.transport, errors.ErrUnsupported)
+	}
+	if err := broadcaster.Broadcast(ctx, request, c.turnaround); err != nil {
+		return nil, err
+	}
+	return transport.NewResponse(request.GetTransactionID(), request.GetUnitID(), pdu.FunctionCode, echo), nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_WithBroadcast(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	answered := 0
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		answered++
+		pdu := req.GetPDU()
+		return test.NewMockResponse(1, req.GetUnitID(), pdu.FunctionCode, pdu.Data[0:4]), nil
+	})
+	ctx := context.Background()
+	client := NewBaseClient(mockTransport, WithUnitID(common.BroadcastUnitID), WithBroadcast(0))
+	client.Connect(ctx)
+
+	// Writes are broadcast and succeed without a response
+	if err := client.WriteSingleRegister(ctx, 10, 7); err != nil {
+		t.Errorf("WriteSingleRegister failed: %v", err)
+	}
+	if err := client.WriteMultipleRegisters(ctx, 20, []common.RegisterValue{1, 2}); err != nil {
+		t.Errorf("WriteMultipleRegisters failed: %v", err)
+	}
+	if err := client.WriteMultipleCoils(ctx, 0, []common.CoilValue{true, false, true}); err != nil {
+		t.Errorf("WriteMultipleCoils failed: %v", err)
+	}
+	if answered != 0 || len(mockTransport.GetRequests()) != 3 {
+		t.Errorf("Expected 3 broadcasts and no answered requests, got %d requests, %d answered", len(mockTransport.GetRequests()), answered)
+	}
+
+	// Nothing answers a broadcast read
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); !errors.Is(err, common.ErrBroadcastRead) {
+		t.Errorf("Expected ErrBroadcastRead, got %v", err)
+	}
+	if len(mockTransport.GetRequests()) != 3 {
+		t.Error("Expected the read not to be sent")
+	}
+
+	// Other units, and unit 0 without the option, are ordinary requests
+	if err := client.clone(WithUnitID(5)).WriteSingleRegister(ctx, 10, 7); err != nil || answered != 1 {
+		t.Errorf("Expected an answered write to unit 5, got %v", err)
+	}
+	if err := NewBaseClient(mockTransport).WriteSingleRegister(ctx, 10, 7); err != nil || answered != 2 {
+		t.Errorf("Expected an answered write to unit 0 without WithBroadcast, got %v", err)
+	}
+}
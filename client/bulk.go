@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ErrBulkVerify is reported when the read-back of a strict bulk write does
+// not match the values written, for example because the device clamped a
+// value or another master wrote to the range concurrently
+var ErrBulkVerify = errors.New("bulk write read-back does not match the values written")
+
+// BulkWriteError reports a bulk write that failed part way. Written is the
+// number of values written before the failure, in address order. With
+// WithBulkStrict, RolledBack tells whether the original values were
+// restored, and RollbackErr why not.
+type BulkWriteError struct {
+	Address     common.Address
+	Written     int
+	RolledBack  bool
+	RollbackErr error
+	Err         error
+}
+
+// Error implements the error interface
+func (e *BulkWriteError) Error() string {
+	s := fmt.Sprintf("bulk write at %d failed after %d values: %v", e.Address, e.Written, e.Err)
+	if e.RollbackErr != nil {
+		s += fmt.Sprintf(" (rollback failed: %v)", e.RollbackErr)
+	} else if e.RolledBack {
+		s += " (rolled back)"
+	}
+	return s
+}
+
+// Unwrap returns the cause of the failure
+func (e *BulkWriteError) Unwrap() error {
+	return e.Err
+}
+
+// BulkOption is a function that configures a bulk write
+type BulkOption func(*bulkConfig)
+
+// bulkConfig holds the settings applied by BulkOptions
+type bulkConfig struct {
+	strict bool
+}
+
+// WithBulkStrict gives a bulk write all-or-nothing semantics as far as Modbus
+// allows: the original values are read before writing, the result is read
+// back and compared after writing, and if any chunk fails or the read-back
+// differs the original values are written back. Other masters writing the
+// same range concurrently can still interleave with the chunks.
+func WithBulkStrict() BulkOption {
+	return func(c *bulkConfig) {
+		c.strict = true
+	}
+}
+
+// bulkTable is the read and write access to one table used by bulkWrite
+type bulkTable[T comparable] struct {
+	client        *BaseClient
+	maxWrite      int
+	maxRead       int
+	writeFunction common.FunctionCode
+	readFunction  common.FunctionCode
+	write         func(ctx context.Context, address common.Address, values []T) error
+	read          func(ctx context.Context, address common.Address, quantity common.Quantity) ([]T, error)
+
+	// Single value writes, used when the device lacks writeFunction
+	singleFunction common.FunctionCode
+	writeSingle    func(ctx context.Context, address common.Address, value T) error
+}
+
+// forDevice returns the table writing one value per request when probed
+// capabilities (see ProbeCapabilities) show the device lacks the multiple
+// write function
+func (t bulkTable[T]) forDevice() bulkTable[T] {
+	caps, probed := t.client.Capabilities()
+	if !probed || caps.Supports(t.writeFunction) {
+		return t
+	}
+	t.maxWrite = 1
+	t.writeFunction = t.singleFunction
+	writeSingle := t.writeSingle
+	t.write = func(ctx context.Context, address common.Address, values []T) error {
+		return writeSingle(ctx, address, values[0])
+	}
+	return t
+}
+
+// readAll reads quantity values starting at address in chunks of at most
+// maxRead
+func (t bulkTable[T]) readAll(ctx context.Context, address common.Address, quantity int) ([]T, error) {
+	values := make([]T, 0, quantity)
+	_, err := t.client.chunked(t.readFunction, t.maxRead, quantity, func(offset, n int) error {
+		chunk, err := t.read(ctx, address+common.Address(offset), common.Quantity(n))
+		values = append(values, chunk...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// writeAll writes values starting at address in chunks of at most maxWrite,
+// in address order, and returns the number of values written
+func (t bulkTable[T]) writeAll(ctx context.Context, address common.Address, values []T) (int, error) {
+	return t.client.chunked(t.writeFunction, t.maxWrite, len(values), func(offset, n int) error {
+		return t.write(ctx, address+common.Address(offset), values[offset:offset+n])
+	})
+}
+
+// bulkWrite writes values of any length to the table, split into chunks the
+// spec allows
+func bulkWrite[T comparable](ctx context.Context, t bulkTable[T], address common.Address, values []T, options []BulkOption) error {
+	cfg := bulkConfig{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if int(address)+len(values) > 0x10000 {
+		return fmt.Errorf("bulk write of %d values at %d: %w", len(values), address, common.ErrInvalidQuantity)
+	}
+	t = t.forDevice()
+
+	var original []T
+	if cfg.strict {
+		var err error
+		if original, err = t.readAll(ctx, address, len(values)); err != nil {
+			return &BulkWriteError{Address: address, Err: fmt.Errorf("reading original values: %w", err)}
+		}
+	}
+
+	written, err := t.writeAll(ctx, address, values)
+	if err == nil && cfg.strict {
+		var readBack []T
+		readBack, err = t.readAll(ctx, address, len(values))
+		if err == nil {
+			for i := range values {
+				if readBack[i] != values[i] {
+					err = fmt.Errorf("%w: address %d", ErrBulkVerify, int(address)+i)
+					break
+				}
+			}
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	bulkErr := &BulkWriteError{Address: address, Written: written, Err: err}
+	if cfg.strict && written > 0 {
+		if _, rollbackErr := t.writeAll(ctx, address, original[:written]); rollbackErr != nil {
+			bulkErr.RollbackErr = rollbackErr
+		} else {
+			bulkErr.RolledBack = true
+		}
+	}
+	return bulkErr
+}
+
+// WriteMultipleCoilsBulk writes any number of coils starting at address,
+// split into Write Multiple Coils requests of at most MaxWriteCoilCount
+// coils sent in address order, or of Write Single Coil requests if probed
+// capabilities show the device lacks Write Multiple Coils. A failure stops
+// the write; the error is a *BulkWriteError telling how many coils were
+// written.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.11 (Write Multiple Coils)
+func (c *BaseClient) WriteMultipleCoilsBulk(ctx context.Context, address common.Address, values []common.CoilValue, options ...BulkOption) error {
+	return bulkWrite(ctx, bulkTable[common.CoilValue]{
+		client:        c,
+		maxWrite:      common.MaxWriteCoilCount,
+		maxRead:       common.MaxCoilCount,
+		writeFunction: common.FuncWriteMultipleCoils,
+		readFunction:  common.FuncReadCoils,
+		write:         c.WriteMultipleCoils,
+		read:          c.ReadCoils,
+
+		singleFunction: common.FuncWriteSingleCoil,
+		writeSingle:    c.WriteSingleCoil,
+	}, address, values, options)
+}
+
+// WriteMultipleRegistersBulk writes any number of holding registers starting
+// at address, split into Write Multiple Registers requests of at most
+// MaxWriteRegisterCount registers sent in address order, or of Write Single
+// Register requests if probed capabilities show the device lacks Write
+// Multiple Registers. A failure stops the write; the error is a
+// *BulkWriteError telling how many registers were written.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteMultipleRegistersBulk(ctx context.Context, address common.Address, values []common.RegisterValue, options ...BulkOption) error {
+	return bulkWrite(ctx, bulkTable[common.RegisterValue]{
+		client:        c,
+		maxWrite:      common.MaxWriteRegisterCount,
+		maxRead:       common.MaxRegisterCount,
+		writeFunction: common.FuncWriteMultipleRegisters,
+		readFunction:  common.FuncReadHoldingRegisters,
+		write:         c.WriteMultipleRegisters,
+		read:          c.ReadHoldingRegisters,
+
+		singleFunction: common.FuncWriteSingleRegister,
+		writeSingle:    c.WriteSingleRegister,
+	}, address, values, options)
+}
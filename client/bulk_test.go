@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// coilDevice answers coil reads and writes from coils, failing writes that
+// start at failAt and inverting coil stuck on every read
+func coilDevice(coils []bool, failAt, stuck int, writes *[]int) func(common.Request) (common.Response, error) {
+	return func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		address := int(binary.BigEndian.Uint16(pdu.Data[0:2]))
+		quantity := int(binary.BigEndian.Uint16(pdu.Data[2:4]))
+
+		switch pdu.FunctionCode {
+		case common.FuncReadCoils:
+			data := make([]byte, 1+(quantity+7)/8)
+			data[0] = byte(len(data) - 1)
+			for i := 0; i < quantity; i++ {
+				value := coils[address+i]
+				if address+i == stuck {
+					value = !value
+				}
+				if value {
+					data[1+i/8] |= 1 << (i % 8)
+				}
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, data), nil
+		case common.FuncWriteMultipleCoils:
+			*writes = append(*writes, address)
+			if address == failAt {
+				return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionServerDeviceFailure)}), nil
+			}
+			for i := 0; i < quantity; i++ {
+				coils[address+i] = pdu.Data[5+i/8]&(1<<(i%8)) != 0
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data[0:4]), nil
+		}
+		return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionFunctionCodeNotSupported)}), nil
+	}
+}
+
+func allOn(n int) []bool {
+	values := make([]bool, n)
+	for i := range values {
+		values[i] = true
+	}
+	return values
+}
+
+func TestBaseClient_WriteMultipleCoilsBulk(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	coils := make([]bool, 10000)
+	var writes []int
+	mockTransport.SetHandler(coilDevice(coils, -1, -1, &writes))
+
+	if err := client.WriteMultipleCoilsBulk(ctx, 100, allOn(5000)); err != nil {
+		t.Fatalf("WriteMultipleCoilsBulk failed: %v", err)
+	}
+	if len(writes) != 3 || writes[0] != 100 || writes[1] != 100+1968 || writes[2] != 100+2*1968 {
+		t.Errorf("Expected 3 chunks in address order, got %v", writes)
+	}
+	if coils[99] || !coils[100] || !coils[5099] || coils[5100] {
+		t.Error("Expected exactly coils 100-5099 to be set")
+	}
+
+	if err := client.WriteMultipleCoilsBulk(ctx, 65000, allOn(1000)); !errors.Is(err, common.ErrInvalidQuantity) {
+		t.Errorf("Expected ErrInvalidQuantity past the address space, got %v", err)
+	}
+}
+
+func TestBaseClient_WriteMultipleCoilsBulkFailure(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	// Without strict mode the first chunk stays written
+	coils := make([]bool, 10000)
+	var writes []int
+	mockTransport.SetHandler(coilDevice(coils, 1968, -1, &writes))
+	err := client.WriteMultipleCoilsBulk(ctx, 0, allOn(3000))
+	var bulkErr *BulkWriteError
+	if !errors.As(err, &bulkErr) || bulkErr.Written != 1968 || bulkErr.RolledBack {
+		t.Fatalf("Expected a failure after 1968 coils, got %v", err)
+	}
+	if !common.IsServerDeviceFailureError(err) {
+		t.Errorf("Expected the exception to be visible, got %v", err)
+	}
+	if !coils[0] || coils[1968] {
+		t.Error("Expected only the first chunk to be written")
+	}
+
+	// Strict mode restores the first chunk
+	coils = make([]bool, 10000)
+	coils[5] = true
+	mockTransport.SetHandler(coilDevice(coils, 1968, -1, &writes))
+	err = client.WriteMultipleCoilsBulk(ctx, 0, allOn(3000), WithBulkStrict())
+	if !errors.As(err, &bulkErr) || !bulkErr.RolledBack {
+		t.Fatalf("Expected a rolled back failure, got %v", err)
+	}
+	if coils[0] || !coils[5] || coils[1967] {
+		t.Error("Expected the original coils to be restored")
+	}
+
+	// Strict mode verifies the read-back
+	coils = make([]bool, 10000)
+	mockTransport.SetHandler(coilDevice(coils, -1, 42, &writes))
+	err = client.WriteMultipleCoilsBulk(ctx, 0, allOn(100), WithBulkStrict())
+	if !errors.Is(err, ErrBulkVerify) || !errors.As(err, &bulkErr) || !bulkErr.RolledBack {
+		t.Fatalf("Expected a rolled back verification failure, got %v", err)
+	}
+	if coils[0] {
+		t.Error("Expected the original coils to be restored after the failed verification")
+	}
+}
+
+func TestBaseClient_WriteMultipleRegistersBulk(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+
+	values := make([]common.RegisterValue, 300)
+	for i := range values {
+		values[i] = uint16(i)
+	}
+	if err := client.WriteMultipleRegistersBulk(ctx, 10, values, WithBulkStrict()); err != nil {
+		t.Fatalf("WriteMultipleRegistersBulk failed: %v", err)
+	}
+	writes := 0
+	for _, fc := range requests {
+		if fc == common.FuncWriteMultipleRegisters {
+			writes++
+		}
+	}
+	if writes != 3 {
+		t.Errorf("Expected 3 chunks of at most 123 registers, got %d", writes)
+	}
+	if registers[10] != 0 || registers[309] != 299 {
+		t.Error("Unexpected register contents")
+	}
+}
+
+func TestBaseClient_WriteMultipleRegistersBulk_SingleWriteFallback(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	// The device implements reads and Write Single Register only
+	registers := make([]uint16, 100)
+	var functions []common.FunctionCode
+	var writes []int
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		functions = append(functions, pdu.FunctionCode)
+		switch pdu.FunctionCode {
+		case common.FuncReadHoldingRegisters:
+			address := int(binary.BigEndian.Uint16(pdu.Data[0:2]))
+			quantity := int(binary.BigEndian.Uint16(pdu.Data[2:4]))
+			data := []byte{byte(quantity * 2)}
+			for _, value := range registers[address : address+quantity] {
+				data = binary.BigEndian.AppendUint16(data, value)
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, data), nil
+		case common.FuncWriteSingleRegister:
+			address := int(binary.BigEndian.Uint16(pdu.Data[0:2]))
+			writes = append(writes, address)
+			registers[address] = binary.BigEndian.Uint16(pdu.Data[2:4])
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data), nil
+		}
+		return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionFunctionCodeNotSupported)}), nil
+	})
+
+	if _, err := client.ProbeCapabilities(ctx); err != nil {
+		t.Fatalf("ProbeCapabilities failed: %v", err)
+	}
+	functions = nil
+
+	values := []common.RegisterValue{1, 2, 3, 4, 5}
+	if err := client.WriteMultipleRegistersBulk(ctx, 10, values, WithBulkStrict()); err != nil {
+		t.Fatalf("WriteMultipleRegistersBulk failed: %v", err)
+	}
+	for _, fc := range functions {
+		if fc == common.FuncWriteMultipleRegisters {
+			t.Fatalf("Expected no Write Multiple Registers requests after probing, got %v", functions)
+		}
+	}
+	if want := []int{10, 11, 12, 13, 14}; !slices.Equal(writes, want) {
+		t.Errorf("Expected single writes in address order %v, got %v", want, writes)
+	}
+	if !slices.Equal(registers[10:15], values) {
+		t.Errorf("Expected registers %v, got %v", values, registers[10:15])
+	}
+}
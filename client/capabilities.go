@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Capabilities is a bitmap of the function codes a device was found to
+// support. The zero value reports no support for any function code.
+type Capabilities struct {
+	bits [4]uint64
+}
+
+// Supports reports whether the function code is supported
+func (c Capabilities) Supports(functionCode common.FunctionCode) bool {
+	return c.bits[functionCode/64]&(1<<(functionCode%64)) != 0
+}
+
+// FunctionCodes returns the supported function codes in ascending order
+func (c Capabilities) FunctionCodes() []common.FunctionCode {
+	var codes []common.FunctionCode
+	for fc := 0; fc < 256; fc++ {
+		if c.Supports(common.FunctionCode(fc)) {
+			codes = append(codes, common.FunctionCode(fc))
+		}
+	}
+	return codes
+}
+
+// String returns the supported function codes, e.g. "[ReadCoils ReadHoldingRegisters]"
+func (c Capabilities) String() string {
+	names := make([]string, 0)
+	for _, fc := range c.FunctionCodes() {
+		names = append(names, fc.String())
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// set marks the function code as supported
+func (c *Capabilities) set(functionCode common.FunctionCode) {
+	c.bits[functionCode/64] |= 1 << (functionCode % 64)
+}
+
+// capabilityCache holds the result of the last successful probe. It is
+// shared between clones of a client since they talk to the same device.
+type capabilityCache struct {
+	mu     sync.RWMutex
+	caps   Capabilities
+	probed bool
+}
+
+// capabilityProbes maps each probed function code to a request that cannot
+// change device state. Reads use address 0 and quantity 1; writes use an
+// invalid quantity or value, which a device implementing the function rejects
+// with exception 0x03 (Illegal Data Value) rather than 0x01 (Illegal
+// Function), without writing anything. Mask write has no invalid value, so
+// its probe is one byte short, which is rejected the same way: even a mask
+// leaving the register unchanged is a read-modify-write on many devices.
+// Write Single Register (0x06) has no harmless form and is not probed.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Responses)
+var capabilityProbes = map[common.FunctionCode][]byte{
+	common.FuncReadCoils:                  {0x00, 0x00, 0x00, 0x01},
+	common.FuncReadDiscreteInputs:         {0x00, 0x00, 0x00, 0x01},
+	common.FuncReadHoldingRegisters:       {0x00, 0x00, 0x00, 0x01},
+	common.FuncReadInputRegisters:         {0x00, 0x00, 0x00, 0x01},
+	common.FuncWriteSingleCoil:            {0x00, 0x00, 0x12, 0x34},
+	common.FuncReadExceptionStatus:        {},
+	common.FuncWriteMultipleCoils:         {0x00, 0x00, 0x00, 0x00, 0x00},
+	common.FuncWriteMultipleRegisters:     {0x00, 0x00, 0x00, 0x00, 0x00},
+	common.FuncReadFileRecord:             {0x07, common.FileRecordReferenceType, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01},
+	common.FuncMaskWriteRegister:          {0x00, 0x00, 0xFF, 0xFF, 0x00},
+	common.FuncReadWriteMultipleRegisters: {0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00},
+	common.FuncReadFIFOQueue:              {0x00, 0x00},
+	common.FuncReadDeviceIdentification:   {byte(common.MEIReadDeviceID), byte(common.ReadDeviceIDBasicStream), 0x00},
+}
+
+// ProbeCapabilities tests which function codes the device supports and
+// caches the result on the client. Each function code is probed with a
+// request that cannot change device state. A function counts as supported
+// when the device answers normally or with any exception other than 0x01
+// (Illegal Function).
+//
+// Probing stops at the first error that is not an exception response (for
+// example a timeout); in that case the cache is left unchanged.
+func (c *BaseClient) ProbeCapabilities(ctx context.Context) (Capabilities, error) {
+	var caps Capabilities
+
+	for fc := 0; fc < 256; fc++ {
+		data, ok := capabilityProbes[common.FunctionCode(fc)]
+		if !ok {
+			continue
+		}
+
+		_, err := c.Send(ctx, common.FunctionCode(fc), data)
+		switch {
+		case err == nil:
+			caps.set(common.FunctionCode(fc))
+		case common.IsFunctionNotSupportedError(err):
+			c.logger.Debug(ctx, "Probe: function %s not supported", common.FunctionCode(fc))
+		case common.IsModbusError(err):
+			caps.set(common.FunctionCode(fc))
+		default:
+			return Capabilities{}, fmt.Errorf("probing function %s: %w", common.FunctionCode(fc), err)
+		}
+	}
+
+	c.capabilities.mu.Lock()
+	c.capabilities.caps = caps
+	c.capabilities.probed = true
+	c.capabilities.mu.Unlock()
+
+	c.logger.Info(ctx, "Probed device capabilities: %s", caps)
+	return caps, nil
+}
+
+// Capabilities returns the capabilities found by the last successful
+// ProbeCapabilities call. The second result is false if the device has
+// not been probed yet.
+func (c *BaseClient) Capabilities() (Capabilities, bool) {
+	c.capabilities.mu.RLock()
+	defer c.capabilities.mu.RUnlock()
+	return c.capabilities.caps, c.capabilities.probed
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_ProbeCapabilities(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+
+	// The device implements reads of holding registers and rejects invalid
+	// write-multiple-registers and mask write requests; everything else is
+	// Illegal Function
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		fc := req.GetPDU().FunctionCode
+		switch fc {
+		case common.FuncReadHoldingRegisters:
+			return test.NewMockResponse(1, 1, fc, []byte{0x02, 0x00, 0x00}), nil
+		case common.FuncWriteMultipleRegisters:
+			return test.NewMockResponse(1, 1, fc|0x80, []byte{byte(common.ExceptionInvalidDataValue)}), nil
+		case common.FuncMaskWriteRegister:
+			// The probe must not be a valid write
+			if len(req.GetPDU().Data) == 6 {
+				t.Error("Expected the mask write probe to be malformed")
+			}
+			return test.NewMockResponse(1, 1, fc|0x80, []byte{byte(common.ExceptionInvalidDataValue)}), nil
+		}
+		return test.NewMockResponse(1, 1, fc|0x80, []byte{byte(common.ExceptionFunctionCodeNotSupported)}), nil
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	if _, probed := client.Capabilities(); probed {
+		t.Fatal("Expected no cached capabilities before probing")
+	}
+
+	caps, err := client.ProbeCapabilities(ctx)
+	if err != nil {
+		t.Fatalf("ProbeCapabilities failed: %v", err)
+	}
+
+	expected := []common.FunctionCode{common.FuncReadHoldingRegisters, common.FuncWriteMultipleRegisters, common.FuncMaskWriteRegister}
+	if codes := caps.FunctionCodes(); !slices.Equal(codes, expected) {
+		t.Errorf("Expected %v, got %v", expected, codes)
+	}
+	if caps.Supports(common.FuncWriteMultipleCoils) {
+		t.Error("Expected write multiple coils to be unsupported")
+	}
+
+	// The result is cached, also on clones of the client
+	cached, probed := client.WithLogger(client.logger).(*BaseClient).Capabilities()
+	if !probed || cached != caps {
+		t.Errorf("Expected cached capabilities %s, got %s (probed=%t)", caps, cached, probed)
+	}
+
+	// A transport failure aborts the probe and keeps the previous result
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		return nil, common.ErrTimeout
+	})
+	if _, err := client.ProbeCapabilities(ctx); !errors.Is(err, common.ErrTimeout) {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	if cached, _ := client.Capabilities(); cached != caps {
+		t.Errorf("Expected cache to be unchanged, got %s", cached)
+	}
+}
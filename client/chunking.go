@@ -0,0 +1,152 @@
+package client
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// chunkLimits holds the largest request size, per function code, known to
+// work with the device. It is shared between clones of a client since they
+// talk to the same device.
+type chunkLimits struct {
+	mu     sync.Mutex
+	limits map[common.FunctionCode]int
+}
+
+// WithAdaptiveChunking makes bulk operations retry a chunk the device
+// rejects with exception 0x02 (Illegal Data Address) or 0x03 (Illegal Data
+// Value) in halves, down to single values, and remember the size that
+// worked for later operations. Many devices accept fewer values per request
+// than the spec allows; ChunkLimits reports what was learned so it can be
+// saved, see Config.SetChunkLimits.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (MODBUS Exception Responses)
+func WithAdaptiveChunking() Option {
+	return func(c *BaseClient) {
+		c.adaptiveChunks = true
+	}
+}
+
+// WithChunkLimits caps the number of values per request of bulk operations,
+// per function code, for example with limits learned by a previous run.
+// Limits above the spec maximum are ignored.
+func WithChunkLimits(limits map[common.FunctionCode]int) Option {
+	return func(c *BaseClient) {
+		c.chunks.mu.Lock()
+		defer c.chunks.mu.Unlock()
+		for fc, limit := range limits {
+			if limit > 0 {
+				c.chunks.limits[fc] = limit
+			}
+		}
+	}
+}
+
+// ChunkLimits returns the chunk size limits in effect, configured with
+// WithChunkLimits or learned with WithAdaptiveChunking
+func (c *BaseClient) ChunkLimits() map[common.FunctionCode]int {
+	c.chunks.mu.Lock()
+	defer c.chunks.mu.Unlock()
+	return maps.Clone(c.chunks.limits)
+}
+
+// chunkLimit returns the chunk size to use for functionCode, at most max
+func (c *BaseClient) chunkLimit(functionCode common.FunctionCode, max int) int {
+	c.chunks.mu.Lock()
+	defer c.chunks.mu.Unlock()
+	if limit, ok := c.chunks.limits[functionCode]; ok && limit < max {
+		return limit
+	}
+	return max
+}
+
+// learnChunkLimit records size as the largest working chunk for functionCode
+func (c *BaseClient) learnChunkLimit(functionCode common.FunctionCode, size int) {
+	c.chunks.mu.Lock()
+	defer c.chunks.mu.Unlock()
+	if limit, ok := c.chunks.limits[functionCode]; !ok || size < limit {
+		c.chunks.limits[functionCode] = size
+	}
+}
+
+// chunkRejected reports whether err is an exception a device may return for
+// a request with more values than it accepts
+func chunkRejected(err error) bool {
+	return common.IsExceptionError(err, common.ExceptionDataAddressNotAvailable) ||
+		common.IsExceptionError(err, common.ExceptionInvalidDataValue)
+}
+
+// chunked runs op over quantity values starting at offset 0 in chunks of at
+// most max values, or the limit for functionCode if lower. op is called with
+// the offset and size of each chunk, in order. With WithAdaptiveChunking, a
+// rejected chunk is retried in halves, and the working size remembered once
+// it served the whole rejected chunk. It returns the number of values
+// processed.
+func (c *BaseClient) chunked(functionCode common.FunctionCode, max, quantity int, op func(offset, n int) error) (int, error) {
+	size := c.chunkLimit(functionCode, max)
+	for offset := 0; offset < quantity; {
+		n := min(size, quantity-offset)
+		err := op(offset, n)
+		if err != nil && c.adaptiveChunks && n > 1 && chunkRejected(err) {
+			// A rejection persisting down to single values is an address
+			// hole rather than a size limit, so nothing is learned from it
+			var served int
+			if served, size, err = splitChunk(offset, n, op); err != nil {
+				return offset + served, err
+			}
+			c.learnChunkLimit(functionCode, size)
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += n
+	}
+	return quantity, nil
+}
+
+// splitChunk retries the rejected chunk of n values at offset in halves,
+// halving again on each rejection. It returns the number of values served
+// and the chunk size that served them.
+func splitChunk(offset, n int, op func(offset, n int) error) (int, int, error) {
+	size := n / 2
+	for served := 0; served < n; {
+		m := min(size, n-served)
+		if err := op(offset+served, m); err != nil {
+			if m > 1 && chunkRejected(err) {
+				size = m / 2
+				continue
+			}
+			return served, size, err
+		}
+		served += m
+	}
+	return n, size, nil
+}
+
+// SetChunkLimits stores chunk size limits, such as those returned by
+// BaseClient.ChunkLimits, in the configuration so they survive restarts
+func (cfg *Config) SetChunkLimits(limits map[common.FunctionCode]int) {
+	cfg.ChunkLimits = make(map[string]int, len(limits))
+	for fc, limit := range limits {
+		cfg.ChunkLimits[fmt.Sprintf("0x%02X", byte(fc))] = limit
+	}
+}
+
+// chunkLimits returns the configured chunk size limits by function code
+func (cfg *Config) chunkLimits() (map[common.FunctionCode]int, error) {
+	limits := make(map[common.FunctionCode]int, len(cfg.ChunkLimits))
+	for key, limit := range cfg.ChunkLimits {
+		fc, err := strconv.ParseUint(key, 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not a function code such as \"0x03\"", key)
+		}
+		if limit < 1 {
+			return nil, fmt.Errorf("limit for %s must be positive", key)
+		}
+		limits[common.FunctionCode(fc)] = limit
+	}
+	return limits, nil
+}
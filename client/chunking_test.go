@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// limitedDevice holds registers and, like many real devices, rejects reads of
+// more than 50 registers with exception 0x02 and writes of more than 40 with
+// exception 0x03. It counts rejected requests.
+func limitedDevice(registers []uint16, rejected *int) func(common.Request) (common.Response, error) {
+	return func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		address := int(binary.BigEndian.Uint16(pdu.Data[0:2]))
+		quantity := int(binary.BigEndian.Uint16(pdu.Data[2:4]))
+		reject := func(exception common.ExceptionCode) (common.Response, error) {
+			*rejected++
+			return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(exception)}), nil
+		}
+
+		switch pdu.FunctionCode {
+		case common.FuncReadHoldingRegisters:
+			if quantity > 50 {
+				return reject(common.ExceptionDataAddressNotAvailable)
+			}
+			data := []byte{byte(2 * quantity)}
+			for i := 0; i < quantity; i++ {
+				data = binary.BigEndian.AppendUint16(data, registers[address+i])
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, data), nil
+		case common.FuncWriteMultipleRegisters:
+			if quantity > 40 {
+				return reject(common.ExceptionInvalidDataValue)
+			}
+			for i := 0; i < quantity; i++ {
+				registers[address+i] = binary.BigEndian.Uint16(pdu.Data[5+2*i:])
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data[0:4]), nil
+		}
+		return reject(common.ExceptionFunctionCodeNotSupported)
+	}
+}
+
+func TestBaseClient_AdaptiveChunking(t *testing.T) {
+	ctx := context.Background()
+	registers := make([]uint16, 1000)
+	var rejected int
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(limitedDevice(registers, &rejected))
+
+	values := make([]common.RegisterValue, 300)
+	for i := range values {
+		values[i] = common.RegisterValue(i + 1)
+	}
+
+	// Without adaptive chunking the first chunk fails
+	client := NewBaseClient(mockTransport)
+	client.Connect(ctx)
+	if err := client.WriteMultipleRegistersBulk(ctx, 0, values); !common.IsExceptionError(err, common.ExceptionInvalidDataValue) {
+		t.Fatalf("Expected exception 0x03, got %v", err)
+	}
+
+	client = NewBaseClient(mockTransport, WithAdaptiveChunking())
+	client.Connect(ctx)
+	if err := client.WriteMultipleRegistersBulk(ctx, 0, values, WithBulkStrict()); err != nil {
+		t.Fatalf("WriteMultipleRegistersBulk failed: %v", err)
+	}
+	for i, v := range values {
+		if registers[i] != uint16(v) {
+			t.Fatalf("Register %d: expected %d, got %d", i, v, registers[i])
+		}
+	}
+
+	// 123 -> 61 -> 30 for writes, 125 -> 62 -> 31 for reads
+	want := map[common.FunctionCode]int{
+		common.FuncWriteMultipleRegisters: 30,
+		common.FuncReadHoldingRegisters:   31,
+	}
+	if got := client.ChunkLimits(); !maps.Equal(got, want) {
+		t.Errorf("Expected learned limits %v, got %v", want, got)
+	}
+
+	// Later operations, also on clones, start at the learned size
+	rejected = 0
+	clone := client.WithLogger(client.logger).(*BaseClient)
+	if err := clone.WriteMultipleRegistersBulk(ctx, 500, values, WithBulkStrict()); err != nil {
+		t.Fatalf("WriteMultipleRegistersBulk failed: %v", err)
+	}
+	if rejected != 0 {
+		t.Errorf("Expected no rejected requests once limits are learned, got %d", rejected)
+	}
+
+	// A rejection that persists down to single values is returned
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		return test.NewMockResponse(1, 1, req.GetPDU().FunctionCode|0x80, []byte{byte(common.ExceptionDataAddressNotAvailable)}), nil
+	})
+	var bulkErr *BulkWriteError
+	if err := client.WriteMultipleRegistersBulk(ctx, 0, values[:5]); !errors.As(err, &bulkErr) || bulkErr.Written != 0 {
+		t.Errorf("Expected a BulkWriteError with nothing written, got %v", err)
+	}
+	if got := client.ChunkLimits()[common.FuncWriteMultipleRegisters]; got != 30 {
+		t.Errorf("Expected a failing write not to change the learned limit, got %d", got)
+	}
+}
+
+func TestBaseClient_AdaptiveChunking_AddressHole(t *testing.T) {
+	ctx := context.Background()
+
+	// The device holds registers 0-99 and accepts any request size within them
+	registers := make([]uint16, 100)
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		address := int(binary.BigEndian.Uint16(pdu.Data[0:2]))
+		quantity := int(binary.BigEndian.Uint16(pdu.Data[2:4]))
+		if address+quantity > len(registers) {
+			return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionDataAddressNotAvailable)}), nil
+		}
+		for i := 0; i < quantity; i++ {
+			registers[address+i] = binary.BigEndian.Uint16(pdu.Data[5+2*i:])
+		}
+		return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data[0:4]), nil
+	})
+
+	client := NewBaseClient(mockTransport, WithAdaptiveChunking())
+	client.Connect(ctx)
+	var bulkErr *BulkWriteError
+	err := client.WriteMultipleRegistersBulk(ctx, 0, make([]common.RegisterValue, 120))
+	if !errors.As(err, &bulkErr) || bulkErr.Written != 100 {
+		t.Fatalf("Expected a BulkWriteError after 100 registers, got %v", err)
+	}
+	if !common.IsExceptionError(err, common.ExceptionDataAddressNotAvailable) {
+		t.Errorf("Expected exception 0x02, got %v", err)
+	}
+	if got := client.ChunkLimits(); len(got) != 0 {
+		t.Errorf("Expected an address hole not to be learned as a limit, got %v", got)
+	}
+}
+
+func TestConfig_ChunkLimits(t *testing.T) {
+	cfg := &Config{Endpoint: "127.0.0.1", AdaptiveChunking: true}
+	cfg.SetChunkLimits(map[common.FunctionCode]int{common.FuncReadHoldingRegisters: 31})
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	parsed, err := ParseConfig(data, "client.json")
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	client, err := NewFromConfig(parsed)
+	if err != nil {
+		t.Fatalf("NewFromConfig failed: %v", err)
+	}
+	defer client.Close()
+
+	if !client.adaptiveChunks || client.ChunkLimits()[common.FuncReadHoldingRegisters] != 31 {
+		t.Errorf("Expected adaptive chunking with a 31 register read limit, got %t %v", client.adaptiveChunks, client.ChunkLimits())
+	}
+
+	cfg.ChunkLimits = map[string]int{"holding": 10}
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected an invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+var (
+	// ErrWriteSuperseded is reported for a coalesced write replaced by a
+	// later value for the same address before it was sent
+	ErrWriteSuperseded = errors.New("write superseded by a later value")
+
+	// ErrWriterClosed is reported for writes submitted to, or still pending
+	// in, a closed CoalescingWriter
+	ErrWriterClosed = errors.New("coalescing writer closed")
+)
+
+// PendingWrite is the outcome of a write submitted to a CoalescingWriter
+type PendingWrite struct {
+	done chan struct{}
+	err  error
+}
+
+// newPendingWrite creates an unfinished PendingWrite
+func newPendingWrite() *PendingWrite {
+	return &PendingWrite{done: make(chan struct{})}
+}
+
+// finish records the outcome; it must be called exactly once
+func (p *PendingWrite) finish(err error) {
+	p.err = err
+	close(p.done)
+}
+
+// Done is closed once the write has been sent, superseded or abandoned
+func (p *PendingWrite) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the outcome once Done is closed: nil if the value was written,
+// ErrWriteSuperseded if a later value replaced it, or the write error
+func (p *PendingWrite) Err() error {
+	<-p.done
+	return p.err
+}
+
+// Wait blocks until the write has an outcome or ctx is done
+func (p *PendingWrite) Wait(ctx context.Context) error {
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeKey identifies a coil or holding register
+type writeKey struct {
+	coil    bool
+	address common.Address
+}
+
+// queuedWrite is the latest value waiting to be written to an address
+type queuedWrite struct {
+	register common.RegisterValue
+	coil     common.CoilValue
+	pending  *PendingWrite
+}
+
+// CoalescingWriter throttles single register and coil writes to a maximum
+// rate. Only the latest value waiting for each address is kept: a value
+// replaced before it was sent completes with ErrWriteSuperseded. This suits
+// applications that write a setpoint faster than the device accepts writes,
+// such as a value following a slider.
+//
+// Addresses are written in the order they first became pending, one write
+// per interval. Close stops the writer after flushing what is pending.
+type CoalescingWriter struct {
+	client   common.Client
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[writeKey]*queuedWrite
+	order   []writeKey // addresses with a pending value, oldest first
+	closed  bool
+
+	wake       chan struct{}      // signals the flush loop that work arrived
+	ctx        context.Context    // context of the writes, canceled on abort
+	cancel     context.CancelFunc // aborts the flush loop
+	done       chan struct{}      // closed when the flush loop exits
+	superseded atomic.Uint64
+}
+
+// NewCoalescingWriter creates a writer that sends at most maxRate writes per
+// second through client; with a maxRate of 0 or less writes are only
+// coalesced while the previous one is in flight. It starts a goroutine that
+// runs until Close.
+func NewCoalescingWriter(client common.Client, maxRate float64) *CoalescingWriter {
+	var interval time.Duration
+	if maxRate > 0 {
+		interval = time.Duration(float64(time.Second) / maxRate)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &CoalescingWriter{
+		client:   client,
+		interval: interval,
+		pending:  make(map[writeKey]*queuedWrite),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// WriteRegister queues a Write Single Register of value to address,
+// superseding any value still pending for it
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.6 (Write Single Register)
+func (w *CoalescingWriter) WriteRegister(address common.Address, value common.RegisterValue) *PendingWrite {
+	return w.submit(writeKey{address: address}, &queuedWrite{register: value})
+}
+
+// WriteCoil queues a Write Single Coil of value to address, superseding any
+// value still pending for it
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.5 (Write Single Coil)
+func (w *CoalescingWriter) WriteCoil(address common.Address, value common.CoilValue) *PendingWrite {
+	return w.submit(writeKey{coil: true, address: address}, &queuedWrite{coil: value})
+}
+
+// Superseded returns the number of writes replaced before they were sent
+func (w *CoalescingWriter) Superseded() uint64 {
+	return w.superseded.Load()
+}
+
+// submit queues a write, replacing the pending value for the same key
+func (w *CoalescingWriter) submit(key writeKey, q *queuedWrite) *PendingWrite {
+	q.pending = newPendingWrite()
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		q.pending.finish(ErrWriterClosed)
+		return q.pending
+	}
+	if old, ok := w.pending[key]; ok {
+		// Keep the address's place in line; only the value changes
+		w.superseded.Add(1)
+		old.pending.finish(ErrWriteSuperseded)
+	} else {
+		w.order = append(w.order, key)
+	}
+	w.pending[key] = q
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return q.pending
+}
+
+// next removes and returns the oldest pending write. ok is false when there
+// is none; stop is true when there is none and the writer is closed.
+func (w *CoalescingWriter) next() (key writeKey, q *queuedWrite, ok, stop bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.order) == 0 {
+		return key, nil, false, w.closed
+	}
+	key, w.order = w.order[0], w.order[1:]
+	q = w.pending[key]
+	delete(w.pending, key)
+	return key, q, true, false
+}
+
+// run sends pending writes, one per interval, until closed and drained or
+// aborted
+func (w *CoalescingWriter) run() {
+	defer close(w.done)
+
+	for {
+		key, q, ok, stop := w.next()
+		if stop {
+			return
+		}
+		if !ok {
+			select {
+			case <-w.wake:
+				continue
+			case <-w.ctx.Done():
+				return
+			}
+		}
+
+		var err error
+		if key.coil {
+			err = w.client.WriteSingleCoil(w.ctx, key.address, q.coil)
+		} else {
+			err = w.client.WriteSingleRegister(w.ctx, key.address, q.register)
+		}
+		q.pending.finish(err)
+
+		if err := sleepContext(w.ctx, w.interval); err != nil {
+			return
+		}
+	}
+}
+
+// Close stops accepting writes and waits for the pending ones to be sent at
+// the configured rate. If ctx ends first, the writes still pending complete
+// with ErrWriterClosed and Close returns ctx.Err().
+func (w *CoalescingWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-w.done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.cancel()
+	<-w.done
+
+	w.mu.Lock()
+	for _, q := range w.pending {
+		q.pending.finish(ErrWriterClosed)
+	}
+	w.pending = make(map[writeKey]*queuedWrite)
+	w.order = nil
+	w.mu.Unlock()
+	return ctx.Err()
+}
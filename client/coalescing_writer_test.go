@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestCoalescingWriter(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+
+	var mu sync.Mutex
+	written := map[uint16][]uint16{}
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		mu.Lock()
+		address := binary.BigEndian.Uint16(pdu.Data[0:2])
+		written[address] = append(written[address], binary.BigEndian.Uint16(pdu.Data[2:4]))
+		mu.Unlock()
+		return test.NewMockResponse(1, 0, pdu.FunctionCode, pdu.Data), nil
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	writer := NewCoalescingWriter(client, 20)
+
+	// The first write goes out at once; the rest arrive within one interval
+	var results []*PendingWrite
+	for v := common.RegisterValue(1); v <= 5; v++ {
+		results = append(results, writer.WriteRegister(10, v))
+	}
+	other := writer.WriteRegister(11, 0xAAAA)
+
+	if err := results[4].Wait(ctx); err != nil {
+		t.Fatalf("Expected the last write to succeed, got %v", err)
+	}
+	if err := other.Wait(ctx); err != nil {
+		t.Fatalf("Expected the write to another address to succeed, got %v", err)
+	}
+
+	superseded := 0
+	for _, r := range results[:4] {
+		if errors.Is(r.Err(), ErrWriteSuperseded) {
+			superseded++
+		} else if r.Err() != nil {
+			t.Errorf("Unexpected write error: %v", r.Err())
+		}
+	}
+
+	mu.Lock()
+	values := written[10]
+	mu.Unlock()
+	if len(values) > 2 || values[len(values)-1] != 5 {
+		t.Errorf("Expected at most 2 writes ending with 5, got %v", values)
+	}
+	if superseded != 5-len(values) || writer.Superseded() != uint64(superseded) {
+		t.Errorf("Expected %d superseded writes, got %d (counter %d)", 5-len(values), superseded, writer.Superseded())
+	}
+
+	if err := writer.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := writer.WriteRegister(10, 6).Err(); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected ErrWriterClosed after Close, got %v", err)
+	}
+}
+
+func TestCoalescingWriter_CloseAbandonsPending(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		return test.NewMockResponse(1, 0, req.GetPDU().FunctionCode, req.GetPDU().Data), nil
+	})
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// One write per second: the second address cannot be flushed in time
+	writer := NewCoalescingWriter(client, 1)
+	first := writer.WriteCoil(0, true)
+	second := writer.WriteCoil(1, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := writer.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to time out, got %v", err)
+	}
+	if err := first.Err(); err != nil {
+		t.Errorf("Expected the first write to succeed, got %v", err)
+	}
+	if err := second.Err(); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected the second write to be abandoned, got %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+	"github.com/Moonlight-Companies/gomodbus/values"
+)
+
+// ErrInvalidConfig is matched by every error returned for an invalid client
+// configuration
+var ErrInvalidConfig = errors.New("invalid client configuration")
+
+// Config describes a client so that it can be constructed from a
+// configuration file. The JSON form is:
+//
+//	{
+//	  "endpoint": "10.0.0.5:502",
+//	  "transport": "tcp",
+//	  "unit_id": 1,
+//	  "connect_timeout": "5s",
+//	  "request_timeout": "2s",
+//	  "reconnect": true,
+//	  "unit_id_tolerance": false,
+//	  "not_supported_errors": true,
+//	  "retry": {"max_retries": 3, "backoff": "100ms", "max_backoff": "1s"},
+//	  "rate_limit": 20,
+//	  "adaptive_chunking": true,
+//	  "chunk_limits": {"0x03": 60, "0x10": 60},
+//	  "quirks": ["jbus", "lenient-byte-count"],
+//	  "word_order": "cdab",
+//	  "broadcast_turnaround": "100ms"
+//	}
+//
+// Only endpoint is required. Unknown keys are rejected.
+type Config struct {
+	// Endpoint is "host" or "host:port"; the port defaults to 502
+	Endpoint string `json:"endpoint"`
+
+	// Transport is the transport type; only "tcp" (the default) is supported
+	Transport string `json:"transport,omitempty"`
+
+	// UnitID is the unit addressed by the client (default 1)
+	UnitID *uint8 `json:"unit_id,omitempty"`
+
+	// ConnectTimeout bounds connection attempts (default 30s)
+	ConnectTimeout ConfigDuration `json:"connect_timeout,omitempty"`
+
+	// RequestTimeout bounds requests whose context has no deadline (default 30s)
+	RequestTimeout ConfigDuration `json:"request_timeout,omitempty"`
+
+	// Reconnect connects lazily and re-creates the connection after
+	// failures instead of requiring Connect
+	Reconnect bool `json:"reconnect,omitempty"`
+
+	// UnitIDTolerance accepts responses whose unit ID differs from the request
+	UnitIDTolerance bool `json:"unit_id_tolerance,omitempty"`
+
+	// NotSupportedErrors enables WithNotSupportedErrors
+	NotSupportedErrors bool `json:"not_supported_errors,omitempty"`
+
+	// Retry, if set, enables WithRetry
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// RateLimit, if positive, caps requests per second (WithRateLimit)
+	RateLimit float64 `json:"rate_limit,omitempty"`
+
+	// AdaptiveChunking enables WithAdaptiveChunking
+	AdaptiveChunking bool `json:"adaptive_chunking,omitempty"`
+
+	// ChunkLimits caps the values per request of bulk operations, keyed by
+	// function code such as "0x03" (WithChunkLimits)
+	ChunkLimits map[string]int `json:"chunk_limits,omitempty"`
+
+	// Quirks names device quirk flags and profiles such as "jbus"
+	// (WithQuirks, see common.ParseQuirks)
+	Quirks []string `json:"quirks,omitempty"`
+
+	// WordOrder is the order of multi-register values: be, le, cdab or
+	// badc (WithWordOrder)
+	WordOrder string `json:"word_order,omitempty"`
+
+	// BroadcastTurnaround makes writes to unit 0 broadcasts, returning
+	// after this delay (WithBroadcast)
+	BroadcastTurnaround *ConfigDuration `json:"broadcast_turnaround,omitempty"`
+}
+
+// RetryConfig is the configuration form of RetryPolicy
+type RetryConfig struct {
+	MaxRetries int            `json:"max_retries"`
+	Backoff    ConfigDuration `json:"backoff,omitempty"`
+	MaxBackoff ConfigDuration `json:"max_backoff,omitempty"`
+}
+
+// ConfigDuration is a time.Duration written as a string such as "250ms"
+type ConfigDuration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *ConfigDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("expected a duration string such as \"2s\", got %s", data)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = ConfigDuration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d ConfigDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ConfigError reports an invalid configuration. Line and Column locate the
+// problem in the source file and are 0 when unknown, for example for a
+// Config built in code.
+type ConfigError struct {
+	File   string // source file, if loaded from one
+	Line   int
+	Column int
+	Field  string // JSON key of the invalid setting, if known
+	Err    error
+}
+
+// Error formats the error as file:line:column: field: message
+func (e *ConfigError) Error() string {
+	var b bytes.Buffer
+	if e.File != "" {
+		b.WriteString(e.File)
+	} else {
+		b.WriteString("config")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", e.Line, e.Column)
+	}
+	b.WriteString(": ")
+	if e.Field != "" {
+		b.WriteString(e.Field + ": ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidConfig
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// position converts a byte offset in data to a 1-based line and column
+func position(data []byte, offset int64) (line, column int) {
+	offset = min(max(offset, 0), int64(len(data)))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// keyPosition returns the position of the first occurrence of a JSON key,
+// or 0, 0 if it does not appear
+func keyPosition(data []byte, key string) (line, column int) {
+	i := bytes.Index(data, []byte(strconv.Quote(key)))
+	if i < 0 {
+		return 0, 0
+	}
+	return position(data, int64(i))
+}
+
+// ParseConfig parses and validates a JSON client configuration. file names
+// the source in error messages and may be empty.
+func ParseConfig(data []byte, file string) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		cerr := &ConfigError{File: file, Err: err}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			// Offset is just past the offending byte
+			cerr.Line, cerr.Column = position(data, syntaxErr.Offset-1)
+		case errors.As(err, &typeErr):
+			cerr.Field = typeErr.Field
+			cerr.Line, cerr.Column = keyPosition(data, lastKey(typeErr.Field))
+			cerr.Err = fmt.Errorf("expected %s, got JSON %s", typeErr.Type, typeErr.Value)
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			// Unknown fields carry no offset, only the key
+			key, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+			cerr.Line, cerr.Column = keyPosition(data, key)
+		default:
+			// Errors from ConfigDuration carry no offset either
+			cerr.Field = invalidDuration(data)
+			cerr.Line, cerr.Column = keyPosition(data, lastKey(cerr.Field))
+		}
+		return nil, cerr
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		line, column := position(data, dec.InputOffset())
+		return nil, &ConfigError{File: file, Line: line, Column: column, Err: errors.New("unexpected data after the configuration object")}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var cerr *ConfigError
+		if errors.As(err, &cerr) {
+			cerr.File = file
+			cerr.Line, cerr.Column = keyPosition(data, lastKey(cerr.Field))
+		}
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// durationFields are the keys holding a ConfigDuration
+var durationFields = []string{"connect_timeout", "request_timeout", "broadcast_turnaround", "retry.backoff", "retry.max_backoff"}
+
+// invalidDuration returns the first duration field of a configuration that
+// does not parse, or "" if there is none
+func invalidDuration(data []byte) string {
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(data, &raw) != nil {
+		return ""
+	}
+	var retry map[string]json.RawMessage
+	json.Unmarshal(raw["retry"], &retry)
+
+	for _, field := range durationFields {
+		value, ok := raw[field]
+		if rest, nested := strings.CutPrefix(field, "retry."); nested {
+			value, ok = retry[rest]
+		}
+		var d ConfigDuration
+		if ok && d.UnmarshalJSON(value) != nil {
+			return field
+		}
+	}
+	return ""
+}
+
+// lastKey returns the last element of a dotted JSON field path
+func lastKey(field string) string {
+	if i := strings.LastIndexByte(field, '.'); i >= 0 {
+		return field[i+1:]
+	}
+	return field
+}
+
+// LoadConfig reads and validates a JSON client configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data, path)
+}
+
+// Validate checks the configuration, returning a *ConfigError naming the
+// first invalid setting
+func (cfg *Config) Validate() error {
+	invalid := func(field string, format string, args ...any) error {
+		return &ConfigError{Field: field, Err: fmt.Errorf(format, args...)}
+	}
+
+	if cfg.Endpoint == "" {
+		return invalid("endpoint", "is required")
+	}
+	if _, _, err := cfg.hostPort(); err != nil {
+		return invalid("endpoint", "%v", err)
+	}
+	switch cfg.Transport {
+	case "", "tcp":
+	case "rtu", "ascii", "tls":
+		return invalid("transport", "%q is not supported by this client yet; use \"tcp\"", cfg.Transport)
+	default:
+		return invalid("transport", "unknown transport %q; expected \"tcp\"", cfg.Transport)
+	}
+	if cfg.UnitID != nil && *cfg.UnitID > 247 && *cfg.UnitID != 255 {
+		// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+		return invalid("unit_id", "%d is reserved; use 0-247 or 255", *cfg.UnitID)
+	}
+	if cfg.ConnectTimeout < 0 {
+		return invalid("connect_timeout", "must not be negative")
+	}
+	if cfg.RequestTimeout < 0 {
+		return invalid("request_timeout", "must not be negative")
+	}
+	if cfg.Retry != nil {
+		if cfg.Retry.MaxRetries < 0 {
+			return invalid("retry.max_retries", "must not be negative")
+		}
+		if cfg.Retry.Backoff < 0 {
+			return invalid("retry.backoff", "must not be negative")
+		}
+		if cfg.Retry.MaxBackoff < 0 {
+			return invalid("retry.max_backoff", "must not be negative")
+		}
+	}
+	if cfg.RateLimit < 0 {
+		return invalid("rate_limit", "must not be negative")
+	}
+	if _, err := cfg.chunkLimits(); err != nil {
+		return invalid("chunk_limits", "%v", err)
+	}
+	if _, err := common.ParseQuirks(cfg.Quirks...); err != nil {
+		return invalid("quirks", "%v", err)
+	}
+	if err := values.Order(cfg.WordOrder).Validate(); err != nil {
+		return invalid("word_order", "%v", err)
+	}
+	if cfg.BroadcastTurnaround != nil && *cfg.BroadcastTurnaround < 0 {
+		return invalid("broadcast_turnaround", "must not be negative")
+	}
+	return nil
+}
+
+// hostPort splits the endpoint, defaulting the port to 502
+func (cfg *Config) hostPort() (string, int, error) {
+	host, portText, err := net.SplitHostPort(cfg.Endpoint)
+	if err != nil {
+		// No port given
+		return cfg.Endpoint, common.DefaultTCPPort, nil
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("missing host in %q", cfg.Endpoint)
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port %q", portText)
+	}
+	return host, port, nil
+}
+
+// NewFromConfig creates a client from a configuration. Extra options, for
+// example WithTCPLogger, are applied after those derived from cfg.
+// Clients configured with reconnect connect on first use; others must be
+// connected with Connect.
+func NewFromConfig(cfg *Config, options ...TCPOption) (*TCPClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	host, port, _ := cfg.hostPort()
+
+	tcpOptions := []transport.TCPTransportOption{transport.WithPort(port)}
+	if cfg.ConnectTimeout > 0 {
+		tcpOptions = append(tcpOptions, transport.WithTimeoutOption(time.Duration(cfg.ConnectTimeout)))
+	}
+	if cfg.UnitIDTolerance {
+		tcpOptions = append(tcpOptions, transport.WithUnitIDTolerance())
+	}
+
+	unitID := common.UnitID(1)
+	if cfg.UnitID != nil {
+		unitID = common.UnitID(*cfg.UnitID)
+	}
+	baseOptions := []Option{WithUnitID(unitID)}
+	if cfg.RequestTimeout > 0 {
+		baseOptions = append(baseOptions, WithRequestTimeout(time.Duration(cfg.RequestTimeout)))
+	}
+	if cfg.NotSupportedErrors {
+		baseOptions = append(baseOptions, WithNotSupportedErrors())
+	}
+	if cfg.Retry != nil {
+		baseOptions = append(baseOptions, WithRetry(RetryPolicy{
+			MaxRetries: cfg.Retry.MaxRetries,
+			Backoff:    time.Duration(cfg.Retry.Backoff),
+			MaxBackoff: time.Duration(cfg.Retry.MaxBackoff),
+		}))
+	}
+	if cfg.RateLimit > 0 {
+		baseOptions = append(baseOptions, WithRateLimit(cfg.RateLimit))
+	}
+	if cfg.AdaptiveChunking {
+		baseOptions = append(baseOptions, WithAdaptiveChunking())
+	}
+	if limits, _ := cfg.chunkLimits(); len(limits) > 0 {
+		baseOptions = append(baseOptions, WithChunkLimits(limits))
+	}
+	if quirks, _ := common.ParseQuirks(cfg.Quirks...); quirks != 0 {
+		baseOptions = append(baseOptions, WithQuirks(quirks))
+	}
+	if cfg.WordOrder != "" {
+		baseOptions = append(baseOptions, WithWordOrder(values.Order(cfg.WordOrder)))
+	}
+	if cfg.BroadcastTurnaround != nil {
+		baseOptions = append(baseOptions, WithBroadcast(time.Duration(*cfg.BroadcastTurnaround)))
+	}
+	options = append([]TCPOption{WithTCPBaseOptions(baseOptions...)}, options...)
+
+	if cfg.Reconnect {
+		return NewTCPClientFromTransport(NewReconnectingTransport(host, nil, nil, tcpOptions), options...), nil
+	}
+	return NewTCPClient(host, tcpOptions...).WithOptions(options...), nil
+}
+
+// NewFromConfigFile loads a JSON configuration file and creates a client
+// from it, see NewFromConfig
+func NewFromConfigFile(path string, options ...TCPOption) (*TCPClient, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromConfig(cfg, options...)
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/values"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`{
+  "endpoint": "10.0.0.5:1502",
+  "unit_id": 7,
+  "request_timeout": "2s",
+  "reconnect": true,
+  "retry": {"max_retries": 3, "backoff": "100ms"},
+  "rate_limit": 20,
+  "word_order": "cdab",
+  "broadcast_turnaround": "100ms"
+}`)
+
+	cfg, err := ParseConfig(data, "client.json")
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if host, port, _ := cfg.hostPort(); host != "10.0.0.5" || port != 1502 {
+		t.Errorf("Expected 10.0.0.5:1502, got %s:%d", host, port)
+	}
+	if time.Duration(cfg.Retry.Backoff) != 100*time.Millisecond {
+		t.Errorf("Expected 100ms backoff, got %v", time.Duration(cfg.Retry.Backoff))
+	}
+
+	client, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig failed: %v", err)
+	}
+	defer client.Close()
+
+	if client.unitID != 7 {
+		t.Errorf("Expected unit ID 7, got %d", client.unitID)
+	}
+	if client.requestTimeout != 2*time.Second {
+		t.Errorf("Expected 2s request timeout, got %v", client.requestTimeout)
+	}
+	if client.retry.MaxRetries != 3 || client.limiter == nil {
+		t.Errorf("Expected retry and rate limit to be configured")
+	}
+	if client.WordOrder() != values.WordSwap {
+		t.Errorf("Expected word order cdab, got %q", client.WordOrder())
+	}
+	if !client.broadcast || client.turnaround != 100*time.Millisecond {
+		t.Errorf("Expected broadcasts with a 100ms turnaround, got %v, %v", client.broadcast, client.turnaround)
+	}
+	if client.clientTransport == nil {
+		t.Errorf("Expected a reconnecting transport")
+	}
+}
+
+func TestParseConfig_ErrorPositions(t *testing.T) {
+	cases := []struct {
+		name  string
+		data  string
+		where string // expected file:line:column prefix
+		text  string // expected in the message
+	}{
+		{"syntax", "{\n  \"endpoint\": \"a\",\n  \"unit_id\": ,\n}", "c.json:3:14", "invalid character"},
+		{"unknown key", "{\n  \"endpoint\": \"a\",\n  \"tags\": {}\n}", "c.json:3:", "unknown field"},
+		{"type", "{\n  \"endpoint\": \"a\",\n  \"unit_id\": 300\n}", "c.json:3:3", "unit_id"},
+		{"duration", "{\n  \"endpoint\": \"a\",\n  \"request_timeout\": 5\n}", "c.json:3:", "duration string"},
+		{"validation", "{\n  \"endpoint\": \"a\",\n  \"transport\": \"rtu\"\n}", "c.json:3:3", "transport: \"rtu\" is not supported"},
+		{"nested", "{\n  \"endpoint\": \"a\",\n  \"retry\": {\"max_retries\": -1}\n}", "c.json:3:13", "retry.max_retries"},
+		{"word order", "{\n  \"endpoint\": \"a\",\n  \"word_order\": \"dcab\"\n}", "c.json:3:3", "word_order"},
+		{"broadcast turnaround", "{\n  \"endpoint\": \"a\",\n  \"broadcast_turnaround\": \"-1s\"\n}", "c.json:3:3", "broadcast_turnaround"},
+		{"broadcast turnaround duration", "{\n  \"endpoint\": \"a\",\n  \"broadcast_turnaround\": \"fast\"\n}", "c.json:3:", "broadcast_turnaround: "},
+		{"missing endpoint", "{}", "c.json", "endpoint: is required"},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseConfig([]byte(tc.data), "c.json")
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", tc.name, err)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tc.where) || !strings.Contains(err.Error(), tc.text) {
+			t.Errorf("%s: expected %q at %s, got %q", tc.name, tc.text, tc.where, err)
+		}
+	}
+}
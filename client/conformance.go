@@ -0,0 +1,283 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// conformanceExamples is the number of recent deviations a report keeps
+const conformanceExamples = 32
+
+// DeviationKind classifies a deviation from the specification seen in a
+// device's responses
+type DeviationKind int
+
+const (
+	// DeviationFrameLength is a response too short or too long for the
+	// request, such as a read response missing registers
+	DeviationFrameLength DeviationKind = iota
+
+	// DeviationByteCount is a read response whose byte count field
+	// disagrees with the data that follows it
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Byte count)
+	DeviationByteCount
+
+	// DeviationWrongEcho is a write response not echoing the request
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.5, 6.6, 6.11, 6.12 and 6.16
+	DeviationWrongEcho
+
+	// DeviationUnitID is a response whose unit ID differs from the request
+	// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+	DeviationUnitID
+
+	// DeviationTimeout is a request the device did not answer in time
+	DeviationTimeout
+
+	// numDeviationKinds is the number of deviation kinds
+	numDeviationKinds
+)
+
+// String returns the name of the deviation kind
+func (k DeviationKind) String() string {
+	switch k {
+	case DeviationFrameLength:
+		return "frame_length"
+	case DeviationByteCount:
+		return "byte_count"
+	case DeviationWrongEcho:
+		return "wrong_echo"
+	case DeviationUnitID:
+		return "unit_id"
+	case DeviationTimeout:
+		return "timeout"
+	default:
+		return fmt.Sprintf("DeviationKind(%d)", int(k))
+	}
+}
+
+// Deviation is one deviation seen in a response
+type Deviation struct {
+	Kind         DeviationKind
+	FunctionCode common.FunctionCode
+	Detail       string
+	At           time.Time
+}
+
+// ConformanceReport summarizes how a device deviates from the
+// specification, see WithConformanceReport
+type ConformanceReport struct {
+	Device     string
+	Requests   uint64                   // requests checked
+	Deviations map[DeviationKind]uint64 // only non-zero entries
+	Recent     []Deviation              // the latest deviations, oldest first
+
+	// LateResponses is the number of responses arriving after their request
+	// timed out, counted by the TCP transport of clients made with
+	// NewTCPClient
+	LateResponses uint64
+
+	// SuggestedQuirks are the quirks that would tolerate the deviations seen,
+	// and Suggestions describes these and other remedies
+	SuggestedQuirks common.Quirks
+	Suggestions     []string
+}
+
+// Conforming reports whether no deviation was seen
+func (r ConformanceReport) Conforming() bool {
+	return len(r.Deviations) == 0 && r.LateResponses == 0
+}
+
+// String returns a human-readable summary of the report
+func (r ConformanceReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d requests", r.Device, r.Requests)
+	if r.Conforming() {
+		b.WriteString(", conforming")
+		return b.String()
+	}
+	for kind := range numDeviationKinds {
+		if n := r.Deviations[kind]; n > 0 {
+			fmt.Fprintf(&b, ", %s: %d", kind, n)
+		}
+	}
+	if r.LateResponses > 0 {
+		fmt.Fprintf(&b, ", late responses: %d", r.LateResponses)
+	}
+	for _, suggestion := range r.Suggestions {
+		fmt.Fprintf(&b, "\n  - %s", suggestion)
+	}
+	return b.String()
+}
+
+// conformanceRecorder collects the deviations of a device
+type conformanceRecorder struct {
+	mu       sync.Mutex
+	requests uint64
+	counts   [numDeviationKinds]uint64
+	recent   []Deviation
+
+	// lenientReads counts the byte count deviations whose data still held
+	// every value requested, which QuirkLenientByteCount would tolerate
+	lenientReads uint64
+}
+
+// WithConformanceReport checks every response against its request and
+// records the deviations from the specification the device makes, whether
+// the client tolerated them, through quirks or WithUnitIDTolerance, or
+// rejected them. ConformanceReport summarizes them and suggests the quirks
+// to enable, to qualify third-party devices before deployment.
+func WithConformanceReport() Option {
+	return func(c *BaseClient) {
+		c.conformance = &conformanceRecorder{}
+	}
+}
+
+// ConformanceReport returns the deviations recorded since the client was
+// created with WithConformanceReport, or an empty report without it
+func (c *BaseClient) ConformanceReport() ConformanceReport {
+	report := ConformanceReport{Device: c.DeviceName(), Deviations: make(map[DeviationKind]uint64)}
+	if report.Device == "" {
+		report.Device = c.Endpoint()
+	}
+	if counter, ok := c.transport.(interface{ LateResponses() uint64 }); ok {
+		report.LateResponses = counter.LateResponses()
+	}
+
+	r := c.conformance
+	if r == nil {
+		return report
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report.Requests = r.requests
+	for kind, n := range r.counts {
+		if n > 0 {
+			report.Deviations[DeviationKind(kind)] = n
+		}
+	}
+	report.Recent = append([]Deviation(nil), r.recent...)
+
+	if n := r.counts[DeviationByteCount]; n > 0 && n == r.lenientReads && !c.quirks.Has(common.QuirkLenientByteCount) {
+		report.SuggestedQuirks |= common.QuirkLenientByteCount
+		report.Suggestions = append(report.Suggestions,
+			fmt.Sprintf("enable quirk %s: %d read responses had a wrong byte count", common.QuirkLenientByteCount, n))
+	}
+	if n := r.counts[DeviationUnitID]; n > 0 {
+		report.Suggestions = append(report.Suggestions,
+			fmt.Sprintf("use transport.WithUnitIDTolerance if the device is behind a gateway rewriting unit IDs: %d responses had another unit ID", n))
+	}
+	if n := r.counts[DeviationWrongEcho]; n > 0 {
+		report.Suggestions = append(report.Suggestions,
+			fmt.Sprintf("verify writes by reading back: %d write responses did not echo the request", n))
+	}
+	if report.LateResponses > 0 {
+		report.Suggestions = append(report.Suggestions,
+			fmt.Sprintf("raise the request timeout with WithRequestTimeout: %d responses arrived after their request timed out", report.LateResponses))
+	}
+	return report
+}
+
+// record adds a deviation
+func (r *conformanceRecorder) record(kind DeviationKind, functionCode common.FunctionCode, detail string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[kind]++
+	if len(r.recent) == conformanceExamples {
+		r.recent = append(r.recent[:0], r.recent[1:]...)
+	}
+	r.recent = append(r.recent, Deviation{Kind: kind, FunctionCode: functionCode, Detail: detail, At: time.Now()})
+}
+
+// failed records a request that failed without a usable response
+func (r *conformanceRecorder) failed(request common.Request, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.requests++
+	r.mu.Unlock()
+
+	functionCode := request.GetPDU().FunctionCode
+	var mismatch *common.UnitIDMismatchError
+	switch {
+	case errors.As(err, &mismatch):
+		r.record(DeviationUnitID, functionCode, mismatch.Error())
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, common.ErrTimeout):
+		r.record(DeviationTimeout, functionCode, err.Error())
+	}
+}
+
+// check records the deviations of a response from its request, before the
+// response is parsed. Exception responses are not checked.
+func (r *conformanceRecorder) check(request common.Request, response common.Response) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.requests++
+	r.mu.Unlock()
+	if response.IsException() {
+		return
+	}
+
+	functionCode := request.GetPDU().FunctionCode
+	if expected, got := request.GetUnitID(), response.GetUnitID(); got != expected {
+		r.record(DeviationUnitID, functionCode, fmt.Sprintf("unit ID %d, expected %d", got, expected))
+	}
+
+	req, data := request.GetPDU().Data, response.GetPDU().Data
+	switch functionCode {
+	case common.FuncReadCoils, common.FuncReadDiscreteInputs:
+		if len(req) >= 4 {
+			r.checkRead(functionCode, data, (int(binary.BigEndian.Uint16(req[2:4]))+7)/8)
+		}
+	case common.FuncReadHoldingRegisters, common.FuncReadInputRegisters, common.FuncReadWriteMultipleRegisters:
+		if len(req) >= 4 {
+			r.checkRead(functionCode, data, 2*int(binary.BigEndian.Uint16(req[2:4])))
+		}
+	case common.FuncWriteSingleCoil, common.FuncWriteSingleRegister, common.FuncMaskWriteRegister:
+		r.checkEcho(functionCode, data, req)
+	case common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters:
+		if len(req) >= 4 {
+			r.checkEcho(functionCode, data, req[:4])
+		}
+	}
+}
+
+// checkRead checks a read response, which should hold a byte count and
+// expected bytes of data
+func (r *conformanceRecorder) checkRead(functionCode common.FunctionCode, data []byte, expected int) {
+	if len(data) == 0 {
+		r.record(DeviationFrameLength, functionCode, "empty response")
+		return
+	}
+	if byteCount := int(data[0]); byteCount != len(data)-1 {
+		r.record(DeviationByteCount, functionCode, fmt.Sprintf("byte count %d with %d bytes of data", byteCount, len(data)-1))
+		if len(data)-1 >= expected {
+			r.mu.Lock()
+			r.lenientReads++
+			r.mu.Unlock()
+		}
+	}
+	if len(data)-1 != expected {
+		r.record(DeviationFrameLength, functionCode, fmt.Sprintf("%d bytes of data, expected %d", len(data)-1, expected))
+	}
+}
+
+// checkEcho checks a write response, which should echo the request
+func (r *conformanceRecorder) checkEcho(functionCode common.FunctionCode, data, echo []byte) {
+	switch {
+	case len(data) != len(echo):
+		r.record(DeviationFrameLength, functionCode, fmt.Sprintf("%d bytes of data, expected %d", len(data), len(echo)))
+	case !bytes.Equal(data, echo):
+		r.record(DeviationWrongEcho, functionCode, fmt.Sprintf("echo % X, expected % X", data, echo))
+	}
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_ConformanceReport(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithUnitID(1), WithConformanceReport(), WithQuirks(common.QuirkLenientByteCount))
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// A conforming read
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadHoldingRegisters, []byte{0x02, 0x00, 0x01}))
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if report := client.ConformanceReport(); !report.Conforming() || report.Requests != 1 {
+		t.Errorf("Expected a conforming report, got %+v", report)
+	}
+
+	// A wrong byte count, tolerated by the quirk
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadHoldingRegisters, []byte{0x04, 0x00, 0x01}))
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); err != nil {
+		t.Fatalf("Read with a wrong byte count failed: %v", err)
+	}
+
+	// A write echoing another value, and one with another unit ID
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncWriteSingleRegister, []byte{0x00, 0x05, 0x00, 0x00}))
+	if err := client.WriteSingleRegister(ctx, 5, 1); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	mockTransport.QueueResponse(test.NewMockResponse(1, 9, common.FuncWriteSingleRegister, []byte{0x00, 0x05, 0x00, 0x01}))
+	if err := client.WriteSingleRegister(ctx, 5, 1); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// A short read, rejected
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadInputRegisters, []byte{0x04, 0x00, 0x01}))
+	if _, err := client.ReadInputRegisters(ctx, 0, 2); err == nil {
+		t.Fatal("Expected the short read to fail")
+	}
+
+	// Exceptions are not deviations
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadCoils|0x80, []byte{0x02}))
+	client.ReadCoils(ctx, 0, 1)
+
+	report := client.ConformanceReport()
+	expected := map[DeviationKind]uint64{
+		DeviationByteCount:   2,
+		DeviationFrameLength: 1,
+		DeviationWrongEcho:   1,
+		DeviationUnitID:      1,
+	}
+	if report.Requests != 6 || len(report.Deviations) != len(expected) {
+		t.Fatalf("Expected 6 requests and deviations %v, got %+v", expected, report)
+	}
+	for kind, n := range expected {
+		if report.Deviations[kind] != n {
+			t.Errorf("Expected %d %s deviations, got %d", n, kind, report.Deviations[kind])
+		}
+	}
+	if len(report.Recent) != 5 || report.Recent[0].Kind != DeviationByteCount || report.Recent[0].FunctionCode != common.FuncReadHoldingRegisters {
+		t.Errorf("Unexpected recent deviations: %+v", report.Recent)
+	}
+
+	// The short read keeps the lenient quirk from covering all byte counts,
+	// and the quirk is already set anyway
+	if report.SuggestedQuirks != 0 {
+		t.Errorf("Expected no suggested quirks, got %s", report.SuggestedQuirks)
+	}
+	if s := report.String(); !strings.Contains(s, "wrong_echo: 1") || !strings.Contains(s, "WithUnitIDTolerance") {
+		t.Errorf("Unexpected report:\n%s", s)
+	}
+}
+
+func TestConformanceReport_SuggestsLenientByteCount(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithUnitID(1), WithConformanceReport())
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// Rejected without the quirk, but all the data is there
+	mockTransport.QueueResponse(test.NewMockResponse(1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x01}))
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); err == nil {
+		t.Fatal("Expected the wrong byte count to fail without the quirk")
+	}
+	if report := client.ConformanceReport(); report.SuggestedQuirks != common.QuirkLenientByteCount {
+		t.Errorf("Expected the lenient byte count quirk to be suggested, got %+v", report)
+	}
+
+	if report := NewBaseClient(mockTransport).ConformanceReport(); report.Requests != 0 || !report.Conforming() {
+		t.Errorf("Expected an empty report without WithConformanceReport, got %+v", report)
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// connectionLostNotifier is implemented by transports that report a failed
+// connection, such as transport.TCPTransport
+type connectionLostNotifier interface {
+	OnConnectionLost(fn func(err error))
+}
+
+// connectionHooks are the callbacks of WithOnConnected and
+// WithOnDisconnected, shared between clones. Each change of state is
+// reported once, however it was noticed.
+type connectionHooks struct {
+	mu             sync.Mutex
+	connected      bool
+	onConnected    func(ctx context.Context)
+	onDisconnected func(ctx context.Context, err error)
+}
+
+// up reports a connection established
+func (h *connectionHooks) up(ctx context.Context) {
+	h.mu.Lock()
+	changed := !h.connected
+	h.connected = true
+	fn := h.onConnected
+	h.mu.Unlock()
+	if changed && fn != nil {
+		fn(ctx)
+	}
+}
+
+// down reports a connection closed, with a nil err, or lost
+func (h *connectionHooks) down(ctx context.Context, err error) {
+	h.mu.Lock()
+	changed := h.connected
+	h.connected = false
+	fn := h.onDisconnected
+	h.mu.Unlock()
+	if changed && fn != nil {
+		fn(ctx, err)
+	}
+}
+
+// WithOnConnected calls fn when the client connects, and when the transport
+// of a client created with NewTCPClientFromTransport reconnects after losing
+// its connection. Unlike the TransportOption WithOnConnect it works with
+// every transport. The callback is shared by clones of the client.
+func WithOnConnected(fn func(ctx context.Context)) Option {
+	return func(c *BaseClient) {
+		c.hooks.mu.Lock()
+		c.hooks.onConnected = fn
+		c.hooks.mu.Unlock()
+	}
+}
+
+// WithOnDisconnected calls fn when the client disconnects, with a nil err,
+// and as soon as the transport loses its connection, with the cause, so
+// applications can alert or pause polling without waiting for the next
+// request to fail. Transports that do not report a lost connection, such as
+// those of NewTCPClientFromTransport, report it when a request fails. fn
+// runs on the transport's goroutine when the connection is lost, so it must
+// return quickly. The callback is shared by clones of the client.
+func WithOnDisconnected(fn func(ctx context.Context, err error)) Option {
+	return func(c *BaseClient) {
+		c.hooks.mu.Lock()
+		c.hooks.onDisconnected = fn
+		c.hooks.mu.Unlock()
+	}
+}
+
+// watchConnection subscribes to the transport's reports of a lost
+// connection, emitting EventDisconnected and calling WithOnDisconnected
+func (c *BaseClient) watchConnection() {
+	notifier, ok := c.transport.(connectionLostNotifier)
+	if !ok {
+		return
+	}
+	logger, events, hooks := c.logger, c.events, c.hooks
+	device, endpoint := c.DeviceName(), c.Endpoint()
+	notifier.OnConnectionLost(func(err error) {
+		ctx := context.Background()
+		logger.Warn(ctx, "Connection lost: %v", err)
+		events.Emit(common.Event{Type: common.EventDisconnected, Device: device, Endpoint: endpoint, Err: err})
+		hooks.down(ctx, err)
+	})
+}
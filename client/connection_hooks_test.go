@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+func TestTCPClient_ConnectionHooks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	connected := make(chan struct{}, 2)
+	disconnected := make(chan error, 2)
+	client := NewTCPClient("127.0.0.1",
+		transport.WithPort(listener.Addr().(*net.TCPAddr).Port),
+		transport.WithTransportLogger(logging.NewNoopLogger()),
+	).WithOptions(WithTCPBaseOptions(
+		WithOnConnected(func(ctx context.Context) { connected <- struct{}{} }),
+		WithOnDisconnected(func(ctx context.Context, err error) { disconnected <- err }),
+	))
+	events := client.Events()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	<-connected
+
+	// The server going away is reported without sending a request
+	(<-accepted).Close()
+	select {
+	case err := <-disconnected:
+		if err == nil {
+			t.Error("Expected the cause of the lost connection")
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected the lost connection to be reported")
+	}
+	for event := range events {
+		if event.Type == common.EventDisconnected {
+			if event.Err == nil {
+				t.Errorf("Expected the cause in the event, got %s", event)
+			}
+			break
+		}
+	}
+
+	// Disconnecting afterwards does not report it again
+	client.Disconnect(ctx)
+	select {
+	case err := <-disconnected:
+		t.Errorf("Unexpected second report: %v", err)
+	default:
+	}
+}
+
+func TestTCPClientFromTransport_ConnectionHooks(t *testing.T) {
+	ctx := context.Background()
+	first, second := test.NewMockTransport(), test.NewMockTransport()
+	first.Connect(ctx)
+	second.Connect(ctx)
+	first.QueueError(common.ErrTimeout)
+	second.QueueResponse(test.NewMockResponse(1, 1, common.FuncWriteSingleRegister, []byte{0x00, 0x01, 0x00, 0x02}))
+
+	var states []string
+	client := NewTCPClientFromTransport(&sequenceTransport{conns: []common.Transport{first, second}},
+		WithTCPBaseOptions(
+			WithOnConnected(func(ctx context.Context) { states = append(states, "up") }),
+			WithOnDisconnected(func(ctx context.Context, err error) {
+				if !errors.Is(err, common.ErrTimeout) {
+					t.Errorf("Expected the timeout as the cause, got %v", err)
+				}
+				states = append(states, "down")
+			}),
+		))
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	client.WriteSingleRegister(ctx, 1, 2)
+	if err := client.WriteSingleRegister(ctx, 1, 2); err != nil {
+		t.Fatalf("Expected the write to succeed after reconnecting, got %v", err)
+	}
+	if got := len(states); got != 3 || states[0] != "up" || states[1] != "down" || states[2] != "up" {
+		t.Errorf("Expected up, down, up, got %v", states)
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestCounter_Rollover(t *testing.T) {
+	counter := NewCounter(16, WithCounterMaxStep(1000))
+
+	steps := []struct {
+		raw   uint64
+		delta uint64
+		total uint64
+	}{
+		{65000, 0, 65000},
+		{65500, 500, 65500},
+		{200, 236, 65736}, // wrapped past 0xFFFF
+		{300, 100, 65836},
+		{10, 10, 65846},      // a 65246 step exceeds the max step: device reset
+		{70000, 4454, 70300}, // raw values are masked to 16 bits (70000 -> 4464)
+	}
+	for i, step := range steps {
+		delta, total := counter.Update(step.raw)
+		if delta != step.delta || total != step.total {
+			t.Errorf("step %d: expected delta %d total %d, got %d and %d", i, step.delta, step.total, delta, total)
+		}
+	}
+	if counter.Wraps() != 1 || counter.Resets() != 1 {
+		t.Errorf("Expected 1 wrap and 1 reset, got %d and %d", counter.Wraps(), counter.Resets())
+	}
+}
+
+func TestCounter_64Bit(t *testing.T) {
+	counter := NewCounter(64)
+	counter.Update(^uint64(0) - 1)
+	if delta, _ := counter.Update(3); delta != 5 {
+		t.Errorf("Expected a 64-bit wrap to add 5, got %d", delta)
+	}
+}
+
+func TestCounterReader(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// A 32-bit counter with the low word first
+	reader, err := client.NewCounterReader(100, 32, WithCounterWordOrder("cdab"))
+	if err != nil {
+		t.Fatalf("NewCounterReader failed: %v", err)
+	}
+
+	registers[100], registers[101] = 0xFFFF, 0xFFFF // 0xFFFFFFFF
+	if _, total, err := reader.Poll(ctx); err != nil || total != 0xFFFFFFFF {
+		t.Fatalf("Expected total 0xFFFFFFFF, got %d (%v)", total, err)
+	}
+
+	registers[100], registers[101] = 0x0004, 0x0000 // wrapped to 4
+	delta, total, err := reader.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if delta != 5 || total != 0x100000004 {
+		t.Errorf("Expected delta 5 and total 0x100000004, got %d and 0x%X", delta, total)
+	}
+
+	if _, err := client.NewCounterReader(0, 24); err == nil {
+		t.Error("Expected an error for a 24-bit counter")
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// DefaultDeviceIDConcurrency is the number of specific-object reads
+// ReadDeviceIDObjects keeps in flight when no concurrency is given.
+const DefaultDeviceIDConcurrency = 4
+
+// DeviceIDObjectResults is the aggregated outcome of ReadDeviceIDObjects.
+type DeviceIDObjectResults struct {
+	// Objects holds every object the device returned, keyed by object ID.
+	Objects map[common.DeviceIDObjectCode]common.DeviceIDObject
+
+	// Unsupported lists the object IDs the device does not implement, in
+	// the order they were requested: those rejected with exception 0x01,
+	// 0x02 or 0x03, or missing from the response.
+	Unsupported []common.DeviceIDObjectCode
+
+	// Errors holds the other failures (timeouts, transport errors, malformed
+	// responses, and exceptions such as 0x04 Server Device Failure or 0x06
+	// Server Device Busy), keyed by object ID.
+	Errors map[common.DeviceIDObjectCode]error
+}
+
+// ReadDeviceIDObjects reads each of the given objects individually using
+// ReadDeviceIDSpecificObject, keeping at most concurrency requests in flight.
+// A concurrency of zero or less uses DefaultDeviceIDConcurrency.
+//
+// Objects the device does not implement are answered with exception 0x02
+// (Illegal Data Address), 0x01 or 0x03, or a response without the object;
+// those are recorded in Unsupported rather than treated as failures,
+// so a single missing object does not hide the rest. The returned error is
+// only non-nil when ctx is canceled.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Read Device Identification - individual access)
+func ReadDeviceIDObjects(ctx context.Context, c common.Client, objectIDs []common.DeviceIDObjectCode, concurrency int) (*DeviceIDObjectResults, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultDeviceIDConcurrency
+	}
+
+	type outcome struct {
+		object      *common.DeviceIDObject
+		unsupported bool
+		err         error
+	}
+	outcomes := make([]outcome, len(objectIDs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+issue:
+	for i, objectID := range objectIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break issue
+		}
+
+		wg.Add(1)
+		go func(i int, objectID common.DeviceIDObjectCode) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			identity, err := c.ReadDeviceIdentification(ctx, common.ReadDeviceIDSpecificObject, objectID)
+			switch {
+			case objectUnsupported(err):
+				outcomes[i].unsupported = true
+			case err != nil:
+				outcomes[i].err = err
+			default:
+				outcomes[i].object = identity.GetObject(objectID)
+				outcomes[i].unsupported = outcomes[i].object == nil
+			}
+		}(i, objectID)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := &DeviceIDObjectResults{
+		Objects: make(map[common.DeviceIDObjectCode]common.DeviceIDObject),
+		Errors:  make(map[common.DeviceIDObjectCode]error),
+	}
+	for i, objectID := range objectIDs {
+		switch o := outcomes[i]; {
+		case o.object != nil:
+			results.Objects[objectID] = *o.object
+		case o.unsupported:
+			results.Unsupported = append(results.Unsupported, objectID)
+		default:
+			results.Errors[objectID] = o.err
+		}
+	}
+	return results, nil
+}
+
+// objectUnsupported reports whether err is an exception a device answers
+// for an object it does not implement, rather than a failure such as a busy
+// device
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (MODBUS Exception Responses)
+func objectUnsupported(err error) bool {
+	return common.IsExceptionError(err, common.ExceptionDataAddressNotAvailable) ||
+		common.IsExceptionError(err, common.ExceptionFunctionCodeNotSupported) ||
+		common.IsExceptionError(err, common.ExceptionInvalidDataValue)
+}
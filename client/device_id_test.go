@@ -2,7 +2,9 @@ package client
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 	"github.com/Moonlight-Companies/gomodbus/common/test"
@@ -68,4 +70,94 @@ func TestBaseClient_ReadDeviceIdentification(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error with invalid code, got nil")
 	}
-}
\ No newline at end of file
+}
+
+func TestReadDeviceIDObjects(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithProtocol(protocol.NewProtocolHandler()))
+
+	values := map[common.DeviceIDObjectCode]string{
+		common.DeviceIDVendorName:  "Acme Inc.",
+		common.DeviceIDProductCode: "ABC123",
+		common.DeviceIDProductName: "Widget",
+	}
+
+	// Answer each specific-object request based on the requested object ID,
+	// holding it long enough for the other worker's request to overlap
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		objectID := common.DeviceIDObjectCode(req.GetPDU().Data[2])
+		switch objectID {
+		case common.DeviceIDModelName:
+			return nil, common.ErrTimeout
+		case common.DeviceIDVendorURL:
+			return test.NewMockResponse(1, 1, common.FuncReadDeviceIdentification|0x80,
+				[]byte{byte(common.ExceptionDataAddressNotAvailable)}), nil
+		case common.DeviceIDUserAppName:
+			return test.NewMockResponse(1, 1, common.FuncReadDeviceIdentification|0x80,
+				[]byte{byte(common.ExceptionServerDeviceBusy)}), nil
+		}
+
+		value := values[objectID]
+		data := []byte{byte(common.MEIReadDeviceID), byte(common.ReadDeviceIDSpecificObject), 0x81, 0x00, 0x00, 0x01,
+			byte(objectID), byte(len(value))}
+		data = append(data, value...)
+		return test.NewMockResponse(1, 1, common.FuncReadDeviceIdentification, data), nil
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	objectIDs := []common.DeviceIDObjectCode{
+		common.DeviceIDVendorName,
+		common.DeviceIDProductCode,
+		common.DeviceIDVendorURL,
+		common.DeviceIDProductName,
+		common.DeviceIDModelName,
+		common.DeviceIDUserAppName,
+	}
+	results, err := ReadDeviceIDObjects(ctx, client, objectIDs, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(results.Objects) != 3 {
+		t.Fatalf("Expected 3 objects, got %d", len(results.Objects))
+	}
+	for id, value := range values {
+		if results.Objects[id].Value != value {
+			t.Errorf("Object 0x%02X: expected %q, got %q", byte(id), value, results.Objects[id].Value)
+		}
+	}
+
+	if len(results.Unsupported) != 1 || results.Unsupported[0] != common.DeviceIDVendorURL {
+		t.Errorf("Expected VendorURL to be unsupported, got %v", results.Unsupported)
+	}
+	if err := results.Errors[common.DeviceIDModelName]; err != common.ErrTimeout {
+		t.Errorf("Expected timeout for ModelName, got %v", err)
+	}
+	// A busy device is a failure, not a missing object
+	if err := results.Errors[common.DeviceIDUserAppName]; !common.IsExceptionError(err, common.ExceptionServerDeviceBusy) {
+		t.Errorf("Expected exception 0x06 for UserApplicationName, got %v", err)
+	}
+	if len(mockTransport.GetRequests()) != len(objectIDs) {
+		t.Errorf("Expected %d requests, got %d", len(objectIDs), len(mockTransport.GetRequests()))
+	}
+	if maxInFlight < 2 {
+		t.Errorf("Expected concurrent requests, at most %d were in flight", maxInFlight)
+	}
+}
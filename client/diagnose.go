@@ -0,0 +1,184 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// splitTarget splits a "host" or "host:port" target, port 502 by default
+func splitTarget(target string) (string, int, error) {
+	host, port := target, common.DefaultTCPPort
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return "", 0, fmt.Errorf("invalid port in target %q", target)
+		}
+		host, port = h, n
+	}
+	return host, port, nil
+}
+
+// DiagnosticStep is one operation of a diagnostic run
+type DiagnosticStep struct {
+	Name    string         `json:"name"`
+	Latency ConfigDuration `json:"latency"`
+	Error   string         `json:"error,omitempty"`
+	Result  any            `json:"result,omitempty"`
+
+	// Exchanges holds the frames of the step in the capture format, so a
+	// report can also be replayed with a ReplayTransport
+	Exchanges []CapturedExchange `json:"exchanges,omitempty"`
+}
+
+// DiagnosticReport is the outcome of Diagnose, meant to be attached to
+// support tickets as JSON
+type DiagnosticReport struct {
+	Target   string           `json:"target"`
+	UnitID   common.UnitID    `json:"unit"`
+	Started  time.Time        `json:"started"`
+	Duration ConfigDuration   `json:"duration"`
+	Steps    []DiagnosticStep `json:"steps"`
+}
+
+// OK reports whether every step succeeded
+func (r *DiagnosticReport) OK() bool {
+	for _, step := range r.Steps {
+		if step.Error != "" {
+			return false
+		}
+	}
+	return len(r.Steps) > 0
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *DiagnosticReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// DiagnoseOption is a function that configures Diagnose
+type DiagnoseOption func(*diagnoseConfig)
+
+// diagnoseConfig holds the settings applied by DiagnoseOptions
+type diagnoseConfig struct {
+	unitID   common.UnitID
+	address  common.Address
+	quantity common.Quantity
+	logger   common.LoggerInterface
+}
+
+// WithDiagnoseUnitID sets the unit ID addressed by the diagnostic requests
+func WithDiagnoseUnitID(unitID common.UnitID) DiagnoseOption {
+	return func(c *diagnoseConfig) {
+		c.unitID = unitID
+	}
+}
+
+// WithDiagnoseRange sets the range read from each table (default address 0,
+// quantity 1)
+func WithDiagnoseRange(address common.Address, quantity common.Quantity) DiagnoseOption {
+	return func(c *diagnoseConfig) {
+		c.address = address
+		c.quantity = quantity
+	}
+}
+
+// WithDiagnoseLogger sets the logger of the diagnostic client
+func WithDiagnoseLogger(logger common.LoggerInterface) DiagnoseOption {
+	return func(c *diagnoseConfig) {
+		c.logger = logger
+	}
+}
+
+// Diagnose connects to target ("host" or "host:port", port 502 by default),
+// reads the basic device identification and a range of each of the four
+// tables, and reports the latency, outcome and frames of every step. Steps
+// fail independently; only connecting is a prerequisite for the others. The
+// error is only set when target is malformed.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (Function Code Descriptions)
+func Diagnose(ctx context.Context, target string, options ...DiagnoseOption) (*DiagnosticReport, error) {
+	cfg := diagnoseConfig{quantity: 1, logger: logging.NewNoopLogger()}
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	host, port, err := splitTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	tcp := transport.NewTCPTransport(host, transport.WithPort(port), transport.WithTransportLogger(cfg.logger))
+	var frames bytes.Buffer
+	capture := NewCaptureTransport(tcp, &frames)
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	c := NewBaseClient(capture, WithUnitID(cfg.unitID), WithLogger(cfg.logger), WithEndpoint(address))
+
+	report := &DiagnosticReport{
+		Target:  address,
+		UnitID:  cfg.unitID,
+		Started: time.Now(),
+	}
+	run := func(name string, fn func() (any, error)) bool {
+		frames.Reset()
+		start := time.Now()
+		result, err := fn()
+		step := DiagnosticStep{Name: name, Latency: ConfigDuration(time.Since(start)), Result: result}
+		if err != nil {
+			step.Error = err.Error()
+			step.Result = nil
+		}
+
+		decoder := json.NewDecoder(&frames)
+		for {
+			var exchange CapturedExchange
+			if decoder.Decode(&exchange) != nil {
+				break
+			}
+			step.Exchanges = append(step.Exchanges, exchange)
+		}
+		report.Steps = append(report.Steps, step)
+		return err == nil
+	}
+
+	if run("connect", func() (any, error) { return nil, c.Connect(ctx) }) {
+		defer c.Disconnect(context.Background())
+
+		run("device_identification", func() (any, error) {
+			id, err := c.ReadDeviceIdentification(ctx, common.ReadDeviceIDBasic, 0)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{
+				"vendor":   id.GetVendorName(),
+				"product":  id.GetProductCode(),
+				"revision": id.GetRevision(),
+			}, nil
+		})
+		run("read_coils", func() (any, error) {
+			return c.ReadCoils(ctx, cfg.address, cfg.quantity)
+		})
+		run("read_discrete_inputs", func() (any, error) {
+			return c.ReadDiscreteInputs(ctx, cfg.address, cfg.quantity)
+		})
+		run("read_holding_registers", func() (any, error) {
+			return c.ReadHoldingRegisters(ctx, cfg.address, cfg.quantity)
+		})
+		run("read_input_registers", func() (any, error) {
+			return c.ReadInputRegisters(ctx, cfg.address, cfg.quantity)
+		})
+	}
+
+	report.Duration = ConfigDuration(time.Since(report.Started))
+	return report, nil
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+// sequenceTransport hands out a new connection after every Reset
+type sequenceTransport struct {
+	conns []common.Transport
+	next  int
+}
+
+func (s *sequenceTransport) Conn(ctx context.Context) (common.Transport, error) {
+	if s.next >= len(s.conns) {
+		return nil, errors.New("no more connections")
+	}
+	return s.conns[s.next], nil
+}
+
+func (s *sequenceTransport) Reset(stale common.Transport) error {
+	if s.next < len(s.conns) && s.conns[s.next] == stale {
+		s.next++
+	}
+	return nil
+}
+
+func (s *sequenceTransport) Close() error {
+	return nil
+}
+
+// expectEvents reads the given event types from the channel, in order
+func expectEvents(t *testing.T, events <-chan common.Event, expected ...common.EventType) []common.Event {
+	t.Helper()
+
+	var received []common.Event
+	for _, eventType := range expected {
+		select {
+		case event := <-events:
+			if event.Type != eventType {
+				t.Fatalf("Expected %s, got %s", eventType, event)
+			}
+			received = append(received, event)
+		default:
+			t.Fatalf("Expected %s, got no event", eventType)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("Unexpected event %s", event)
+	default:
+	}
+	return received
+}
+
+func TestBaseClient_Events(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithUnitID(7))
+	events := client.Events()
+	ctx := context.Background()
+
+	client.Connect(ctx)
+	mockTransport.QueueResponse(test.NewMockResponse(1, 7, common.FuncReadCoils|0x80, []byte{byte(common.ExceptionDataAddressNotAvailable)}))
+	client.ReadCoils(ctx, 0, 1)
+	mockTransport.QueueError(common.ErrTimeout)
+	client.ReadCoils(ctx, 0, 1)
+	client.Disconnect(ctx)
+
+	received := expectEvents(t, events,
+		common.EventConnected,
+		common.EventRequestFailed,
+		common.EventRequestFailed,
+		common.EventDisconnected,
+	)
+	if failed := received[1]; failed.UnitID != 7 || failed.FunctionCode != common.FuncReadCoils || !common.IsDataAddressNotAvailableError(failed.Err) {
+		t.Errorf("Unexpected exception event %s", failed)
+	}
+	if !errors.Is(received[2].Err, common.ErrTimeout) {
+		t.Errorf("Expected the timeout in the event, got %s", received[2])
+	}
+}
+
+func TestTCPClient_EventsReconnected(t *testing.T) {
+	ctx := context.Background()
+	first, second := test.NewMockTransport(), test.NewMockTransport()
+	first.Connect(ctx)
+	second.Connect(ctx)
+	first.QueueError(common.ErrTimeout)
+	second.QueueResponse(test.NewMockResponse(1, 1, common.FuncWriteSingleRegister, []byte{0x00, 0x01, 0x00, 0x02}))
+
+	client := NewTCPClientFromTransport(&sequenceTransport{conns: []common.Transport{first, second}})
+	events := client.Events()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if err := client.WriteSingleRegister(ctx, 1, 2); !errors.Is(err, common.ErrTimeout) {
+		t.Fatalf("Expected timeout, got %v", err)
+	}
+	if err := client.WriteSingleRegister(ctx, 1, 2); err != nil {
+		t.Fatalf("Expected the write to succeed after reconnecting, got %v", err)
+	}
+
+	expectEvents(t, events,
+		common.EventConnected,
+		common.EventDisconnected,
+		common.EventRequestFailed,
+		common.EventReconnected,
+	)
+}
+
+func TestBaseClient_DeviceRegistry(t *testing.T) {
+	registry := common.NewDeviceRegistry()
+	registry.Register("10.0.0.5:502", 7, "boiler-1")
+
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithUnitID(7), WithDeviceRegistry(registry, "10.0.0.5:502"))
+	events := client.Events()
+	ctx := context.Background()
+
+	client.Connect(ctx)
+	mockTransport.QueueError(common.ErrTimeout)
+	_, err := client.ReadCoils(ctx, 0, 1)
+
+	var deviceErr *common.DeviceError
+	if !errors.As(err, &deviceErr) || deviceErr.Device != "boiler-1" {
+		t.Fatalf("Expected the error to name boiler-1, got %v", err)
+	}
+	if !errors.Is(err, common.ErrTimeout) {
+		t.Errorf("Expected the timeout to be visible through the device error, got %v", err)
+	}
+
+	received := expectEvents(t, events, common.EventConnected, common.EventRequestFailed)
+	for _, event := range received {
+		if event.Device != "boiler-1" {
+			t.Errorf("Expected the event to name boiler-1, got %s", event)
+		}
+	}
+
+	// Another unit at the same address falls back to address/unit
+	if name := client.clone(WithUnitID(8)).DeviceName(); name != "10.0.0.5:502/8" {
+		t.Errorf("Expected 10.0.0.5:502/8, got %q", name)
+	}
+}
+
+func TestBaseClient_Endpoint(t *testing.T) {
+	var logs bytes.Buffer
+	client := NewBaseClient(test.NewMockTransport(),
+		WithUnitID(7),
+		WithEndpoint("10.0.0.5:502"),
+		WithLogger(logging.NewLogger(logging.WithWriter(&logs), logging.WithLevel(common.LevelInfo))))
+	mockTransport := client.transport.(*test.MockTransport) // WithLogger copies the mock
+	events := client.Events()
+	ctx := context.Background()
+
+	if got := client.Endpoint(); got != "10.0.0.5:502:7" {
+		t.Errorf("Expected endpoint 10.0.0.5:502:7, got %q", got)
+	}
+	if got := client.clone(WithUnitID(9)).Endpoint(); got != "10.0.0.5:502:9" {
+		t.Errorf("Expected a clone for unit 9 to be relabeled, got %q", got)
+	}
+
+	client.Connect(ctx)
+	// An error labeled by the transport is relabeled with the unit
+	mockTransport.QueueError(&common.EndpointError{Endpoint: "10.0.0.5:502", Err: common.ErrTimeout})
+	_, err := client.ReadCoils(ctx, 0, 1)
+
+	var labeled *common.EndpointError
+	if !errors.As(err, &labeled) || labeled.Endpoint != "10.0.0.5:502:7" || !errors.Is(err, common.ErrTimeout) {
+		t.Errorf("Expected a timeout labeled 10.0.0.5:502:7, got %v", err)
+	}
+	if err.Error() != "10.0.0.5:502:7: "+common.ErrTimeout.Error() {
+		t.Errorf("Expected a single label, got %q", err)
+	}
+
+	received := expectEvents(t, events, common.EventConnected, common.EventRequestFailed)
+	for _, event := range received {
+		if event.Endpoint != "10.0.0.5:502:7" {
+			t.Errorf("Expected event %s to carry the endpoint", event)
+		}
+	}
+	if !strings.Contains(logs.String(), "10.0.0.5:502:7") {
+		t.Errorf("Expected the endpoint in the log fields, got %s", logs.String())
+	}
+}
+
+func TestBaseClient_CorrelationID(t *testing.T) {
+	var logs bytes.Buffer
+	client := NewBaseClient(test.NewMockTransport(),
+		WithLogger(logging.NewLogger(logging.WithWriter(&logs), logging.WithLevel(common.LevelDebug))))
+	mockTransport := client.transport.(*test.MockTransport)
+	events := client.Events()
+	ctx := common.WithCorrelationID(context.Background(), "req-42")
+
+	client.Connect(ctx)
+	mockTransport.QueueError(common.ErrTimeout)
+	client.ReadCoils(ctx, 0, 1)
+
+	received := expectEvents(t, events, common.EventConnected, common.EventRequestFailed)
+	for _, event := range received {
+		if event.CorrelationID != "req-42" {
+			t.Errorf("Expected event %s to carry the correlation ID", event)
+		}
+	}
+	if !strings.Contains(logs.String(), `correlation_id="req-42"`) {
+		t.Errorf("Expected the correlation ID in the logs, got %s", logs.String())
+	}
+}
+
+func TestBaseClient_AllEvents(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := client.AllEvents(ctx)
+	client.Connect(ctx)
+	client.Disconnect(ctx)
+
+	var types []common.EventType
+	for event := range events {
+		types = append(types, event.Type)
+		if len(types) == 2 {
+			break
+		}
+	}
+	if len(types) != 2 || types[0] != common.EventConnected || types[1] != common.EventDisconnected {
+		t.Errorf("Expected Connected then Disconnected, got %v", types)
+	}
+
+	// A done context ends the loop
+	cancel()
+	for event := range events {
+		t.Errorf("Unexpected event %s", event)
+	}
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// fastLane is the reserved request budget of the reads designated with
+// WithFastLane
+type fastLane struct {
+	limiter *rateLimiter
+	ranges  []Range
+}
+
+// readTables maps the read function codes to the table they read
+var readTables = map[common.FunctionCode]common.Table{
+	common.FuncReadCoils:            common.TableCoils,
+	common.FuncReadDiscreteInputs:   common.TableDiscreteInputs,
+	common.FuncReadHoldingRegisters: common.TableHoldingRegisters,
+	common.FuncReadInputRegisters:   common.TableInputRegisters,
+}
+
+// WithFastLane keeps safety-relevant status fresh while bulk polling
+// saturates the device. Read Exception Status requests, reads lying
+// entirely within one of ranges (alarm coil blocks, watchdog registers) and
+// single register writes into them (StartWatchdog heartbeats) bypass the
+// WithRateLimit limit and are spaced by a reserved budget of perSecond of
+// their own instead, so they never queue behind bulk reads.
+// Keep the budget small: the device sees both rates added up. The fast lane
+// is shared by clones of the client. A rate of 0 or less removes it.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.7 (Read Exception Status)
+func WithFastLane(perSecond float64, ranges ...Range) Option {
+	return func(c *BaseClient) {
+		if perSecond <= 0 {
+			c.fastLane = nil
+			return
+		}
+		c.fastLane = &fastLane{
+			limiter: &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)},
+			ranges:  ranges,
+		}
+	}
+}
+
+// matches reports whether the request is designated for the fast lane
+func (l *fastLane) matches(pdu *common.PDU) bool {
+	if pdu.FunctionCode == common.FuncReadExceptionStatus {
+		return true
+	}
+	var table common.Table
+	var address, quantity int
+	switch readTable, ok := readTables[pdu.FunctionCode]; {
+	case ok && len(pdu.Data) >= 4:
+		table = readTable
+		address = int(binary.BigEndian.Uint16(pdu.Data[0:2]))
+		quantity = int(binary.BigEndian.Uint16(pdu.Data[2:4]))
+	case pdu.FunctionCode == common.FuncWriteSingleRegister && len(pdu.Data) >= 2:
+		table = common.TableHoldingRegisters
+		address, quantity = int(binary.BigEndian.Uint16(pdu.Data[0:2])), 1
+	default:
+		return false
+	}
+	for _, r := range l.ranges {
+		if r.Table == table && address >= int(r.Address) && address+quantity <= int(r.Address)+int(r.Quantity) {
+			return true
+		}
+	}
+	return false
+}
+
+// limiterFor returns the rate limiter spacing request: the fast lane's for
+// designated reads, the client's otherwise
+func (c *BaseClient) limiterFor(request common.Request) *rateLimiter {
+	if c.fastLane != nil && c.fastLane.matches(request.GetPDU()) {
+		return c.fastLane.limiter
+	}
+	return c.limiter
+}
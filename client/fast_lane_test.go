@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_FastLane(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		fc := req.GetPDU().FunctionCode
+		switch fc {
+		case common.FuncReadCoils:
+			return test.NewMockResponse(1, 0, fc, []byte{1, 0x05}), nil
+		case common.FuncReadExceptionStatus:
+			return test.NewMockResponse(1, 0, fc, []byte{0x01}), nil
+		}
+		return test.NewMockResponse(1, 0, fc, []byte{2, 0x00, 0x2A}), nil
+	})
+	client := NewBaseClient(mockTransport,
+		WithRateLimit(10),
+		WithFastLane(100, Range{Table: common.TableCoils, Address: 100, Quantity: 8}))
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// Saturate the 10/s limit with bulk reads
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.ReadHoldingRegisters(ctx, 0, 1)
+		}()
+	}
+	defer wg.Wait()
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := client.ReadCoils(ctx, 100, 8); err != nil {
+		t.Fatalf("Fast lane read failed: %v", err)
+	}
+	if _, err := client.ReadExceptionStatus(ctx); err != nil {
+		t.Fatalf("Exception status read failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected fast lane reads to bypass the bulk queue, took %v", elapsed)
+	}
+
+	// A read reaching outside the designated range queues with the bulk reads
+	start = time.Now()
+	if _, err := client.ReadCoils(ctx, 104, 8); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("Expected the read to wait for the rate limit, took %v", elapsed)
+	}
+}
+
+func TestFastLane_MatchesHeartbeatWrites(t *testing.T) {
+	lane := &fastLane{ranges: []Range{{Table: common.TableHoldingRegisters, Address: 40, Quantity: 1}}}
+	for _, tc := range []struct {
+		pdu  common.PDU
+		want bool
+	}{
+		{common.PDU{FunctionCode: common.FuncWriteSingleRegister, Data: []byte{0x00, 0x28, 0x00, 0x01}}, true},
+		{common.PDU{FunctionCode: common.FuncWriteSingleRegister, Data: []byte{0x00, 0x29, 0x00, 0x01}}, false},
+		{common.PDU{FunctionCode: common.FuncWriteSingleCoil, Data: []byte{0x00, 0x28, 0xFF, 0x00}}, false},
+		{common.PDU{FunctionCode: common.FuncReadHoldingRegisters, Data: []byte{0x00, 0x28, 0x00, 0x01}}, true},
+	} {
+		if got := lane.matches(&tc.pdu); got != tc.want {
+			t.Errorf("%s % X: expected %v, got %v", tc.pdu.FunctionCode, tc.pdu.Data, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestRegisterField_DecodeEncode(t *testing.T) {
+	mode := RegisterField{Name: "Mode", Mask: 0x0F, Shift: 8}
+	offset := RegisterField{Name: "Offset", Mask: 0xFF, Signed: true}
+
+	if got := mode.Decode(0x1A34); got != 0x0A {
+		t.Errorf("Expected mode 10, got %d", got)
+	}
+	if got := offset.Decode(0x12FE); got != -2 {
+		t.Errorf("Expected offset -2, got %d", got)
+	}
+
+	updated, err := mode.Encode(0x1A34, 3)
+	if err != nil || updated != 0x1334 {
+		t.Errorf("Expected 0x1334, got 0x%04X (%v)", updated, err)
+	}
+	updated, err = offset.Encode(0x1200, -128)
+	if err != nil || updated != 0x1280 {
+		t.Errorf("Expected 0x1280, got 0x%04X (%v)", updated, err)
+	}
+
+	for _, tc := range []struct {
+		field RegisterField
+		value int
+	}{
+		{mode, 16},
+		{mode, -1},
+		{offset, 128},
+		{RegisterField{Name: "Wide", Mask: 0xFF, Shift: 12}, 1},
+	} {
+		if _, err := tc.field.Encode(0, tc.value); !errors.Is(err, common.ErrInvalidValue) {
+			t.Errorf("%s=%d: expected ErrInvalidValue, got %v", tc.field.Name, tc.value, err)
+		}
+	}
+}
+
+// fieldDevice emulates one holding register at address 0. It implements
+// Mask Write Register only when maskWrite is set; interfere, if set, is
+// called on every read to emulate another master.
+type fieldDevice struct {
+	register  uint16
+	maskWrite bool
+	interfere func(reads int) uint16
+	reads     int
+	functions []common.FunctionCode
+}
+
+func (d *fieldDevice) handle(req common.Request) (common.Response, error) {
+	pdu := req.GetPDU()
+	d.functions = append(d.functions, pdu.FunctionCode)
+	switch {
+	case pdu.FunctionCode == common.FuncReadHoldingRegisters:
+		d.reads++
+		if d.interfere != nil {
+			d.register = d.interfere(d.reads)
+		}
+		return test.NewMockResponse(1, 1, pdu.FunctionCode, []byte{0x02, byte(d.register >> 8), byte(d.register)}), nil
+	case pdu.FunctionCode == common.FuncWriteSingleRegister:
+		d.register = binary.BigEndian.Uint16(pdu.Data[2:4])
+		return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data), nil
+	case pdu.FunctionCode == common.FuncMaskWriteRegister && d.maskWrite:
+		if len(pdu.Data) != 6 {
+			return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionInvalidDataValue)}), nil
+		}
+		and, or := binary.BigEndian.Uint16(pdu.Data[2:4]), binary.BigEndian.Uint16(pdu.Data[4:6])
+		d.register = d.register&and | or&^and
+		return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data), nil
+	}
+	return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionFunctionCodeNotSupported)}), nil
+}
+
+func TestBaseClient_SetField(t *testing.T) {
+	mode := RegisterField{Name: "Mode", Mask: 0x0F, Shift: 8}
+	ctx := context.Background()
+
+	t.Run("read-modify-write", func(t *testing.T) {
+		device := &fieldDevice{register: 0x1A34}
+		mockTransport := test.NewMockTransport()
+		mockTransport.SetHandler(device.handle)
+		client := NewBaseClient(mockTransport)
+		client.Connect(ctx)
+
+		if err := client.SetField(ctx, mode, 5); err != nil {
+			t.Fatalf("SetField failed: %v", err)
+		}
+		if device.register != 0x1534 {
+			t.Errorf("Expected 0x1534, got 0x%04X", device.register)
+		}
+		got, err := client.GetField(ctx, mode)
+		if err != nil || got != 5 {
+			t.Errorf("Expected mode 5, got %d (%v)", got, err)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		// Another master changes the low byte between the two reads
+		device := &fieldDevice{register: 0x1A34, interfere: func(reads int) uint16 { return 0x1A00 + uint16(reads) }}
+		mockTransport := test.NewMockTransport()
+		mockTransport.SetHandler(device.handle)
+		client := NewBaseClient(mockTransport)
+		client.Connect(ctx)
+
+		if err := client.SetField(ctx, mode, 5); !errors.Is(err, ErrFieldConflict) {
+			t.Fatalf("Expected ErrFieldConflict, got %v", err)
+		}
+		if device.register != 0x1A02 {
+			t.Errorf("Expected the register to be left alone, got 0x%04X", device.register)
+		}
+	})
+
+	t.Run("mask write", func(t *testing.T) {
+		device := &fieldDevice{register: 0x1A34, maskWrite: true}
+		mockTransport := test.NewMockTransport()
+		mockTransport.SetHandler(device.handle)
+		client := NewBaseClient(mockTransport)
+		client.Connect(ctx)
+		if _, err := client.ProbeCapabilities(ctx); err != nil {
+			t.Fatalf("ProbeCapabilities failed: %v", err)
+		}
+
+		device.functions = nil
+		if err := client.SetField(ctx, mode, 5); err != nil {
+			t.Fatalf("SetField failed: %v", err)
+		}
+		if device.register != 0x1534 {
+			t.Errorf("Expected 0x1534, got 0x%04X", device.register)
+		}
+		if len(device.functions) != 1 || device.functions[0] != common.FuncMaskWriteRegister {
+			t.Errorf("Expected a single Mask Write Register request, got %v", device.functions)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Future is a request sent with Go. Its outcome is available from Wait once
+// Done is closed.
+type Future struct {
+	cancel   context.CancelFunc
+	done     chan struct{}
+	response common.Response
+	err      error
+}
+
+// Go sends a request like Send without waiting for the response, and
+// returns a Future to collect it or to cancel this one request without
+// cancelling ctx. The Future must be waited for or cancelled to release its
+// resources.
+func (c *BaseClient) Go(ctx context.Context, functionCode common.FunctionCode, data []byte) *Future {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		defer cancel()
+		f.response, f.err = c.Send(ctx, functionCode, data)
+	}()
+	return f
+}
+
+// Cancel aborts the request. A request still queued is never written and
+// no retries are sent; for a request already written, the transaction ID is
+// released at once and a late response is discarded. Wait then returns
+// context.Canceled, unless the response arrived first. Cancel does not
+// affect other requests and may be called more than once.
+func (f *Future) Cancel() {
+	f.cancel()
+}
+
+// Done returns a channel closed when the request has completed or was
+// cancelled
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the request completes and returns its outcome
+func (f *Future) Wait() (common.Response, error) {
+	<-f.done
+	return f.response, f.err
+}
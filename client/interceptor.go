@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Invoker sends one attempt of a request and returns its response
+type Invoker func(ctx context.Context, request common.Request) (common.Response, error)
+
+// Interceptor wraps every attempt of every request a client sends, retries
+// included. It may inspect or replace the request before calling next, which
+// sends it on, and inspect or replace the response and error next returns,
+// for example to add tracing spans, record latency histograms or simulate
+// failures in tests. Returning without calling next answers the request
+// without sending it; a nil response without an error fails the attempt
+// with common.ErrNoResponse. Errors returned are subject to the retry policy
+// like transport errors.
+type Interceptor func(ctx context.Context, request common.Request, next Invoker) (common.Response, error)
+
+// WithInterceptor adds interceptors to the client. The first interceptor
+// added is the outermost: it sees the request first and the response last.
+func WithInterceptor(interceptors ...Interceptor) Option {
+	return func(c *BaseClient) {
+		c.interceptors = append(slices.Clip(c.interceptors), interceptors...)
+	}
+}
+
+// invoke sends an attempt through the interceptors to invoker
+func (c *BaseClient) invoke(ctx context.Context, request common.Request, invoker Invoker) (common.Response, error) {
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.interceptors[i], invoker
+		invoker = func(ctx context.Context, request common.Request) (common.Response, error) {
+			return interceptor(ctx, request, next)
+		}
+	}
+	response, err := invoker(ctx, request)
+	if response == nil && err == nil {
+		err = common.ErrNoResponse
+	}
+	return response, err
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+func TestBaseClient_Interceptors(t *testing.T) {
+	registers := map[uint16]uint16{10: 0x1234, 20: 0x5678}
+	var requests []common.FunctionCode
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+
+	var calls []string
+	trace := func(name string) Interceptor {
+		return func(ctx context.Context, request common.Request, next Invoker) (common.Response, error) {
+			calls = append(calls, name+" request")
+			response, err := next(ctx, request)
+			calls = append(calls, name+" response")
+			return response, err
+		}
+	}
+	// Reads are redirected from address 10 to 20
+	redirect := func(ctx context.Context, request common.Request, next Invoker) (common.Response, error) {
+		pdu := request.GetPDU()
+		data := slices.Clone(pdu.Data)
+		if binary.BigEndian.Uint16(data) == 10 {
+			binary.BigEndian.PutUint16(data, 20)
+		}
+		return next(ctx, transport.NewRequest(request.GetUnitID(), pdu.FunctionCode, data))
+	}
+
+	client := NewBaseClient(mockTransport, WithInterceptor(trace("outer"), trace("inner")), WithInterceptor(redirect))
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	values, err := client.ReadHoldingRegisters(ctx, 10, 1)
+	if err != nil {
+		t.Fatalf("ReadHoldingRegisters failed: %v", err)
+	}
+	if values[0] != 0x5678 {
+		t.Errorf("Expected the redirected register 0x5678, got %04X", values[0])
+	}
+	want := []string{"outer request", "inner request", "inner response", "outer response"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestBaseClient_InterceptorFailures(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(map[uint16]uint16{}, &requests))
+
+	// The first attempt fails without being sent and is retried
+	attempts := 0
+	flaky := func(ctx context.Context, request common.Request, next Invoker) (common.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, common.ErrTimeout
+		}
+		return next(ctx, request)
+	}
+	client := NewBaseClient(mockTransport, WithInterceptor(flaky), WithRetry(RetryPolicy{MaxRetries: 1}))
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if attempts != 2 || len(requests) != 1 {
+		t.Errorf("Expected 2 attempts and 1 request sent, got %d and %d", attempts, len(requests))
+	}
+	if stats := client.RequestStats(); stats.Requests != 2 || stats.Timeouts != 1 {
+		t.Errorf("Expected 2 attempts with 1 timeout in the stats, got %+v", stats)
+	}
+
+	// Clones made with options keep the interceptors of their original
+	failing := client.clone(WithInterceptor(func(ctx context.Context, request common.Request, next Invoker) (common.Response, error) {
+		return nil, common.ErrNotConnected
+	}))
+	if _, err := failing.ReadHoldingRegisters(ctx, 0, 1); !errors.Is(err, common.ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected, got %v", err)
+	}
+	if len(client.interceptors) != 1 || len(failing.interceptors) != 2 {
+		t.Errorf("Expected 1 and 2 interceptors, got %d and %d", len(client.interceptors), len(failing.interceptors))
+	}
+
+	// An interceptor answering with neither a response nor an error fails
+	// the attempt instead of panicking
+	silent := client.clone(WithInterceptor(func(ctx context.Context, request common.Request, next Invoker) (common.Response, error) {
+		return nil, nil
+	}))
+	if _, err := silent.ReadHoldingRegisters(ctx, 0, 1); !errors.Is(err, common.ErrNoResponse) {
+		t.Errorf("Expected ErrNoResponse, got %v", err)
+	}
+}
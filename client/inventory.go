@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// InventoryRecord is the normalized inventory entry of one device: its
+// identification objects, the function codes it supports and a fingerprint
+// of its register map
+type InventoryRecord struct {
+	Target              string        `json:"target"`
+	UnitID              common.UnitID `json:"unit"`
+	Device              string        `json:"device,omitempty"`
+	VendorName          string        `json:"vendor_name,omitempty"`
+	ProductCode         string        `json:"product_code,omitempty"`
+	Revision            string        `json:"revision,omitempty"`
+	VendorURL           string        `json:"vendor_url,omitempty"`
+	ProductName         string        `json:"product_name,omitempty"`
+	ModelName           string        `json:"model_name,omitempty"`
+	UserApplicationName string        `json:"user_application_name,omitempty"`
+
+	// FunctionCodes lists the supported function codes, e.g. "0x03"
+	FunctionCodes []string `json:"function_codes,omitempty"`
+
+	// Fingerprint identifies which of the fingerprinted ranges the device
+	// serves and which exception it answers for the others; devices with
+	// the same register map share it whatever their current values. It is
+	// empty when no ranges were fingerprinted.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	Scanned time.Time `json:"scanned"`
+
+	// Error is set when the device could not be inventoried completely
+	Error string `json:"error,omitempty"`
+}
+
+// CollectInventory reads the device's identification (regular objects,
+// falling back to basic), probes its capabilities with ProbeCapabilities,
+// and fingerprints the register map over ranges. Steps fail independently;
+// the record's Error joins their errors. Target is the client's device
+// registry address, if any.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Read Device Identification)
+func (c *BaseClient) CollectInventory(ctx context.Context, ranges []Range) InventoryRecord {
+	record := InventoryRecord{
+		Target:  c.deviceAddress,
+		UnitID:  c.unitID,
+		Device:  c.DeviceName(),
+		Scanned: time.Now(),
+	}
+	var errs []error
+
+	id, err := c.ReadDeviceIdentification(ctx, common.ReadDeviceIDRegular, 0)
+	if common.IsModbusError(err) {
+		id, err = c.ReadDeviceIdentification(ctx, common.ReadDeviceIDBasic, 0)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("device identification: %w", err))
+	} else {
+		record.VendorName = id.GetVendorName()
+		record.ProductCode = id.GetProductCode()
+		record.Revision = id.GetRevision()
+		record.VendorURL = id.GetVendorURL()
+		record.ProductName = id.GetProductName()
+		record.ModelName = id.GetModelName()
+		record.UserApplicationName = id.GetUserApplicationName()
+	}
+
+	if caps, err := c.ProbeCapabilities(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("capabilities: %w", err))
+	} else {
+		for _, fc := range caps.FunctionCodes() {
+			record.FunctionCodes = append(record.FunctionCodes, fmt.Sprintf("0x%02X", byte(fc)))
+		}
+	}
+
+	if len(ranges) > 0 {
+		fingerprint, err := c.registerMapFingerprint(ctx, ranges)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fingerprint: %w", err))
+		}
+		record.Fingerprint = fingerprint
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// registerMapFingerprint hashes, for each range, whether the device served
+// it or the exception it answered. A transport error leaves no fingerprint.
+func (c *BaseClient) registerMapFingerprint(ctx context.Context, ranges []Range) (string, error) {
+	snapshot, err := c.ReadSnapshot(ctx, ranges)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, result := range snapshot.Results {
+		outcome := "ok"
+		var modbusErr *common.ModbusError
+		switch {
+		case result.Err == nil:
+		case errors.As(result.Err, &modbusErr):
+			outcome = fmt.Sprintf("0x%02X", byte(modbusErr.ExceptionCode))
+		default:
+			return "", result.Err
+		}
+		fmt.Fprintf(hash, "%s %d %d %s\n", result.Table, result.Address, result.Quantity, outcome)
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8]), nil
+}
+
+// InventoryTarget is one device of a fleet sweep
+type InventoryTarget struct {
+	Target string // "host" or "host:port", port 502 by default
+	UnitID common.UnitID
+}
+
+// InventoryOption is a function that configures SweepInventory
+type InventoryOption func(*inventoryConfig)
+
+// inventoryConfig holds the settings applied by InventoryOptions
+type inventoryConfig struct {
+	ranges      []Range
+	concurrency int
+	registry    *common.DeviceRegistry
+	logger      common.LoggerInterface
+}
+
+// WithInventoryFingerprint sets the ranges probed for the register map
+// fingerprint. Without it no fingerprint is taken.
+func WithInventoryFingerprint(ranges []Range) InventoryOption {
+	return func(c *inventoryConfig) {
+		c.ranges = ranges
+	}
+}
+
+// WithInventoryConcurrency sets how many devices are inventoried at once
+// (default 1)
+func WithInventoryConcurrency(n int) InventoryOption {
+	return func(c *inventoryConfig) {
+		c.concurrency = max(n, 1)
+	}
+}
+
+// WithInventoryDeviceRegistry names the devices of the sweep using registry
+func WithInventoryDeviceRegistry(registry *common.DeviceRegistry) InventoryOption {
+	return func(c *inventoryConfig) {
+		c.registry = registry
+	}
+}
+
+// WithInventoryLogger sets the logger of the sweep's clients
+func WithInventoryLogger(logger common.LoggerInterface) InventoryOption {
+	return func(c *inventoryConfig) {
+		c.logger = logger
+	}
+}
+
+// SweepInventory connects to every target and collects its inventory record
+// with CollectInventory. Records are in the order of targets; a device that
+// cannot be reached has a record with only Error set.
+func SweepInventory(ctx context.Context, targets []InventoryTarget, options ...InventoryOption) []InventoryRecord {
+	cfg := inventoryConfig{concurrency: 1, logger: logging.NewNoopLogger()}
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	records := make([]InventoryRecord, len(targets))
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			records[i] = cfg.collect(ctx, target)
+		}()
+	}
+	wg.Wait()
+	return records
+}
+
+// collect inventories one target over a connection of its own
+func (cfg *inventoryConfig) collect(ctx context.Context, target InventoryTarget) InventoryRecord {
+	record := InventoryRecord{Target: target.Target, UnitID: target.UnitID, Scanned: time.Now()}
+	host, port, err := splitTarget(target.Target)
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	record.Target = address
+
+	options := []Option{WithUnitID(target.UnitID), WithLogger(cfg.logger)}
+	if cfg.registry != nil {
+		options = append(options, WithDeviceRegistry(cfg.registry, address))
+	}
+	tcp := transport.NewTCPTransport(host, transport.WithPort(port), transport.WithTransportLogger(cfg.logger))
+	c := NewBaseClient(tcp, options...)
+	if err := c.Connect(ctx); err != nil {
+		record.Error = fmt.Sprintf("connect: %v", err)
+		return record
+	}
+	defer c.Disconnect(context.Background())
+
+	record = c.CollectInventory(ctx, cfg.ranges)
+	record.Target = address
+	return record
+}
+
+// InventoryEncoder writes inventory records in an exchange format, for
+// example for import into a configuration management database
+type InventoryEncoder interface {
+	EncodeInventory(w io.Writer, records []InventoryRecord) error
+}
+
+// JSONInventory encodes an inventory as an indented JSON array
+type JSONInventory struct{}
+
+// EncodeInventory implements InventoryEncoder
+func (JSONInventory) EncodeInventory(w io.Writer, records []InventoryRecord) error {
+	if records == nil {
+		records = []InventoryRecord{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// CSVInventory encodes an inventory as CSV with a header row, using the JSON
+// field names as columns. Function codes are separated by spaces.
+type CSVInventory struct{}
+
+// inventoryColumns are the CSV columns written by CSVInventory
+var inventoryColumns = []string{
+	"target", "unit", "device", "vendor_name", "product_code", "revision", "vendor_url",
+	"product_name", "model_name", "user_application_name", "function_codes", "fingerprint",
+	"scanned", "error",
+}
+
+// EncodeInventory implements InventoryEncoder
+func (CSVInventory) EncodeInventory(w io.Writer, records []InventoryRecord) error {
+	writer := csv.NewWriter(w)
+	writer.Write(inventoryColumns)
+	for _, r := range records {
+		writer.Write([]string{
+			r.Target, strconv.Itoa(int(r.UnitID)), r.Device, r.VendorName, r.ProductCode, r.Revision, r.VendorURL,
+			r.ProductName, r.ModelName, r.UserApplicationName, strings.Join(r.FunctionCodes, " "), r.Fingerprint,
+			r.Scanned.UTC().Format(time.RFC3339), r.Error,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
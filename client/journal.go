@@ -0,0 +1,301 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ErrJournal is returned for a write that was not sent because it could not
+// be recorded in the write journal
+var ErrJournal = errors.New("write journal")
+
+// JournalOutcome is the state of a journaled write
+type JournalOutcome string
+
+const (
+	// JournalPending is a write sent but not yet answered. A write still
+	// pending when the journal is reopened was interrupted by a crash.
+	JournalPending JournalOutcome = "pending"
+
+	// JournalAcknowledged is a write the device answered normally
+	JournalAcknowledged JournalOutcome = "acknowledged"
+
+	// JournalException is a write the device answered with an exception
+	JournalException JournalOutcome = "exception"
+
+	// JournalFailed is a write that got no answer, after all retries; the
+	// device may or may not have applied it
+	JournalFailed JournalOutcome = "failed"
+
+	// JournalReplayed is an unacknowledged write sent again with
+	// ReplayJournal; ReplayedAs is the ID of the new entry
+	JournalReplayed JournalOutcome = "replayed"
+)
+
+// JournalAttempt is one attempt of a journaled write
+type JournalAttempt struct {
+	Time      time.Time            `json:"time"`
+	Exception common.ExceptionCode `json:"exception,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// JournalEntry is one write recorded by a WriteJournal
+type JournalEntry struct {
+	ID           uint64              `json:"id"`
+	Time         time.Time           `json:"time"`
+	Device       string              `json:"device,omitempty"`
+	UnitID       common.UnitID       `json:"unit"`
+	FunctionCode common.FunctionCode `json:"fc"`
+	Request      string              `json:"request"` // hex-encoded request PDU data
+	Outcome      JournalOutcome      `json:"outcome"`
+	Attempts     []JournalAttempt    `json:"attempts,omitempty"`
+	Error        string              `json:"error,omitempty"`
+	ReplayOf     uint64              `json:"replay_of,omitempty"`
+	ReplayedAs   uint64              `json:"replayed_as,omitempty"`
+}
+
+// Unacknowledged reports whether the device may not have applied the write:
+// it is pending or failed, and was not replayed
+func (e JournalEntry) Unacknowledged() bool {
+	return e.Outcome == JournalPending || e.Outcome == JournalFailed
+}
+
+// WriteJournal is an append-only file recording every write a client
+// attempts, with its outcome and retry history. A write is recorded as
+// pending and synced to disk before it is sent, then recorded again with
+// its outcome, so writes interrupted by a crash can be found with
+// Unacknowledged and sent again with ReplayJournal. The file holds one
+// JSON-encoded JournalEntry per line; the last line for an ID is current.
+// A journal can be shared by several clients.
+type WriteJournal struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	nextID uint64
+}
+
+// OpenWriteJournal opens the journal at path, creating it if needed
+func OpenWriteJournal(path string) (*WriteJournal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	j := &WriteJournal{path: path, file: file, nextID: 1}
+	entries, err := j.read()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	for _, entry := range entries {
+		j.nextID = max(j.nextID, entry.ID+1)
+	}
+	return j, nil
+}
+
+// Close closes the journal file
+func (j *WriteJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// Entries returns the current state of the journaled writes matching
+// match, in the order they were sent. A nil match returns all writes.
+func (j *WriteJournal) Entries(match func(JournalEntry) bool) ([]JournalEntry, error) {
+	j.mu.Lock()
+	entries, err := j.read()
+	j.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := entries[:0]
+	for _, entry := range entries {
+		if match == nil || match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, nil
+}
+
+// Unacknowledged returns the writes the device may not have applied, in the
+// order they were sent
+func (j *WriteJournal) Unacknowledged() ([]JournalEntry, error) {
+	return j.Entries(JournalEntry.Unacknowledged)
+}
+
+// read folds the journal file into the current state of each entry. A
+// truncated last line, as left by a crash, is ignored. j.mu must be held.
+func (j *WriteJournal) read() ([]JournalEntry, error) {
+	file, err := os.Open(j.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	latest := make(map[uint64]JournalEntry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		latest[entry.ID] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	entries := make([]JournalEntry, 0, len(latest))
+	for _, entry := range latest {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].ID < entries[b].ID })
+	return entries, nil
+}
+
+// append writes entries to the journal and syncs it. j.mu must be held.
+func (j *WriteJournal) append(entries ...JournalEntry) error {
+	var line []byte
+	for _, entry := range entries {
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		line = append(append(line, encoded...), '\n')
+	}
+	if _, err := j.file.Write(line); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// journalRecord tracks one journaled write while it is sent
+type journalRecord struct {
+	journal *WriteJournal
+	entry   JournalEntry
+}
+
+// begin records a write as pending before it is sent. When it replays
+// original, the original is marked replayed in the same write.
+func (j *WriteJournal) begin(device string, request common.Request, original *JournalEntry) (*journalRecord, error) {
+	pdu := request.GetPDU()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	record := &journalRecord{journal: j, entry: JournalEntry{
+		ID:           j.nextID,
+		Time:         time.Now(),
+		Device:       device,
+		UnitID:       request.GetUnitID(),
+		FunctionCode: pdu.FunctionCode,
+		Request:      hex.EncodeToString(pdu.Data),
+		Outcome:      JournalPending,
+	}}
+	entries := []JournalEntry{record.entry}
+	if original != nil {
+		record.entry.ReplayOf = original.ID
+		entries[0].ReplayOf = original.ID
+		replayed := *original
+		replayed.Outcome = JournalReplayed
+		replayed.ReplayedAs = record.entry.ID
+		entries = append(entries, replayed)
+	}
+	if err := j.append(entries...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJournal, err)
+	}
+	j.nextID++
+	return record, nil
+}
+
+// attempt records the outcome of one attempt
+func (r *journalRecord) attempt(response common.Response, err error) {
+	if r == nil {
+		return
+	}
+	attempt := JournalAttempt{Time: time.Now()}
+	if err != nil {
+		attempt.Error = err.Error()
+	} else if response.IsException() {
+		attempt.Exception = response.GetException()
+	}
+	r.entry.Attempts = append(r.entry.Attempts, attempt)
+}
+
+// finish records the final outcome of the write. Failing to record it does
+// not fail the write, which has already been sent.
+func (r *journalRecord) finish(ctx context.Context, logger common.LoggerInterface, response common.Response, err error) {
+	if r == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		r.entry.Outcome = JournalFailed
+		r.entry.Error = err.Error()
+	case response.IsException():
+		r.entry.Outcome = JournalException
+		r.entry.Error = response.ToError().Error()
+	default:
+		r.entry.Outcome = JournalAcknowledged
+	}
+
+	r.journal.mu.Lock()
+	defer r.journal.mu.Unlock()
+	if err := r.journal.append(r.entry); err != nil {
+		logger.Error(ctx, "Failed to journal the outcome of write %d: %v", r.entry.ID, err)
+	}
+}
+
+// WithWriteJournal records every write request the client sends (functions
+// 0x05, 0x06, 0x0F, 0x10, 0x16 and 0x17) in journal. A write that cannot be
+// recorded is not sent and fails with ErrJournal.
+func WithWriteJournal(journal *WriteJournal) Option {
+	return func(c *BaseClient) {
+		c.journal = journal
+	}
+}
+
+// replayKey is the context key for the journal entry a request replays
+type replayKey struct{}
+
+// ReplayJournal sends the journaled writes in entries again, in order,
+// addressed to each entry's unit ID, typically those returned by
+// Unacknowledged after a crash. Each replay is journaled as a new entry
+// with ReplayOf set, and the original is marked JournalReplayed, so a write
+// is not replayed twice. Replaying stops at the first write that fails; the
+// number of writes replayed successfully is returned.
+func (c *BaseClient) ReplayJournal(ctx context.Context, entries []JournalEntry) (int, error) {
+	for i, entry := range entries {
+		data, err := hex.DecodeString(entry.Request)
+		if err != nil {
+			return i, fmt.Errorf("journal entry %d: %w", entry.ID, err)
+		}
+		client := c.clone(WithUnitID(entry.UnitID))
+		if _, err := client.Send(context.WithValue(ctx, replayKey{}, &entry), entry.FunctionCode, data); err != nil {
+			return i, fmt.Errorf("replaying journal entry %d: %w", entry.ID, err)
+		}
+	}
+	return len(entries), nil
+}
+
+// beginJournal records request in the client's write journal, if it has
+// one and the request is a write
+func (c *BaseClient) beginJournal(ctx context.Context, device string, request common.Request) (*journalRecord, error) {
+	if c.journal == nil || !request.GetPDU().FunctionCode.IsWrite() {
+		return nil, nil
+	}
+	original, _ := ctx.Value(replayKey{}).(*JournalEntry)
+	return c.journal.begin(device, request, original)
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+func TestWriteJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writes.jsonl")
+	journal, err := OpenWriteJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport,
+		WithWriteJournal(journal),
+		WithRetry(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}))
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// Acknowledged after a retry
+	mockTransport.QueueError(common.ErrTransactionTimeout)
+	mockTransport.QueueResponse(test.NewMockResponse(1, 0, common.FuncWriteSingleRegister, []byte{0x00, 0x05, 0x00, 0x07}))
+	if err := client.WriteSingleRegister(ctx, 5, 7); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Failed after all retries
+	mockTransport.QueueError(common.ErrTransactionTimeout)
+	mockTransport.QueueError(common.ErrTransactionTimeout)
+	if err := client.WriteSingleRegister(ctx, 6, 8); err == nil {
+		t.Fatal("Expected the write to fail")
+	}
+
+	// Reads are not journaled
+	mockTransport.QueueResponse(test.NewMockResponse(1, 0, common.FuncReadHoldingRegisters, []byte{0x02, 0x00, 0x01}))
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	// A crash between sending and recording the outcome leaves a pending write
+	request := transport.NewRequest(3, common.FuncWriteSingleCoil, []byte{0x00, 0x01, 0xFF, 0x00})
+	if _, err := journal.begin("", request, nil); err != nil {
+		t.Fatalf("Failed to journal: %v", err)
+	}
+	journal.Close()
+
+	journal, err = OpenWriteJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen journal: %v", err)
+	}
+	defer journal.Close()
+
+	entries, err := journal.Entries(nil)
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %+v", entries)
+	}
+	if e := entries[0]; e.ID != 1 || e.Outcome != JournalAcknowledged || len(e.Attempts) != 2 ||
+		e.Attempts[0].Error == "" || e.Request != "00050007" {
+		t.Errorf("Unexpected acknowledged entry %+v", e)
+	}
+	if e := entries[1]; e.Outcome != JournalFailed || len(e.Attempts) != 2 || e.Error == "" {
+		t.Errorf("Unexpected failed entry %+v", e)
+	}
+
+	unacknowledged, err := journal.Unacknowledged()
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if len(unacknowledged) != 2 || unacknowledged[0].ID != 2 || unacknowledged[1].ID != 3 ||
+		unacknowledged[1].Outcome != JournalPending || unacknowledged[1].UnitID != 3 {
+		t.Fatalf("Unexpected unacknowledged writes %+v", unacknowledged)
+	}
+
+	// Replay after the restart, with the reopened journal
+	mockTransport.Clear()
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		return test.NewMockResponse(1, req.GetUnitID(), req.GetPDU().FunctionCode, req.GetPDU().Data), nil
+	})
+	client = NewBaseClient(mockTransport, WithWriteJournal(journal))
+	n, err := client.ReplayJournal(ctx, unacknowledged)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 writes replayed, got %d: %v", n, err)
+	}
+	requests := mockTransport.GetRequests()
+	if len(requests) != 2 || requests[1].GetUnitID() != 3 || requests[1].GetPDU().FunctionCode != common.FuncWriteSingleCoil {
+		t.Errorf("Unexpected replayed requests %v", requests)
+	}
+
+	if unacknowledged, _ := journal.Unacknowledged(); len(unacknowledged) != 0 {
+		t.Errorf("Expected no unacknowledged writes after replay, got %+v", unacknowledged)
+	}
+	entries, _ = journal.Entries(func(e JournalEntry) bool { return e.ReplayOf != 0 })
+	if len(entries) != 2 || entries[0].ID != 4 || entries[0].ReplayOf != 2 || entries[1].ReplayOf != 3 ||
+		entries[0].Outcome != JournalAcknowledged {
+		t.Errorf("Unexpected replay entries %+v", entries)
+	}
+	if replayed, _ := journal.Entries(func(e JournalEntry) bool { return e.ID == 2 }); replayed[0].Outcome != JournalReplayed || replayed[0].ReplayedAs != 4 {
+		t.Errorf("Expected entry 2 to be marked replayed, got %+v", replayed)
+	}
+}
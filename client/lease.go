@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+var (
+	// ErrDeviceOwned is returned when another process holds the ownership
+	// lease of the device, see WithOwnershipLease
+	ErrDeviceOwned = errors.New("device is owned by another process")
+
+	// ErrReadOnly is returned for writes by a client that went read-only
+	// because another process owns the device
+	ErrReadOnly = errors.New("client is read-only: device is owned by another process")
+
+	// errLeaseHeld is returned by lockFile when another process holds the lock
+	errLeaseHeld = errors.New("lease held")
+)
+
+// LeaseMode selects what a client does when another process holds the
+// ownership lease of its device
+type LeaseMode int
+
+const (
+	// LeaseRefuse fails Connect and every request with ErrDeviceOwned
+	LeaseRefuse LeaseMode = iota
+
+	// LeaseReadOnly connects but fails writes with ErrReadOnly
+	LeaseReadOnly
+)
+
+// LeasePath returns the default lease file for a device address such as
+// "10.0.0.5:502", in the system temporary directory
+func LeasePath(address string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == ':' || r == '/' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, address)
+	return filepath.Join(os.TempDir(), "gomodbus-"+name+".lock")
+}
+
+// ownershipLease is an advisory lock on a lease file, shared between clones
+// of a client
+type ownershipLease struct {
+	path string
+	mode LeaseMode
+
+	mu       sync.Mutex
+	file     *os.File // the held lock, nil when not held
+	tried    bool     // acquire was attempted since the last release
+	readOnly bool     // another process held the lease on the last attempt
+}
+
+// WithOwnershipLease makes the client take an advisory lease on the device
+// before using it, so that processes on one host do not open competing
+// connections to a single-connection PLC or a serial line. The lease is a
+// lock on the file at path (see LeasePath) taken on Connect, or on the first
+// request for clients that connect lazily, and released on Disconnect. On
+// Unix the lock is released by the kernel if the process dies; elsewhere the
+// file itself is the lease and a crashed owner leaves it behind. When another
+// process holds the lease, mode selects whether the client refuses to work
+// or goes read-only; the lease is tried again on every Connect.
+func WithOwnershipLease(path string, mode LeaseMode) Option {
+	return func(c *BaseClient) {
+		c.lease = &ownershipLease{path: path, mode: mode}
+	}
+}
+
+// WithTCPOwnershipLease takes an ownership lease on the transport's
+// host:port, at LeasePath of that address, see WithOwnershipLease
+func WithTCPOwnershipLease(mode LeaseMode) TCPOption {
+	return func(c *TCPClient) {
+		var address string
+		if c.tcpTransport != nil {
+			address = c.tcpTransport.Address()
+		}
+		c.BaseClient = c.BaseClient.clone(WithOwnershipLease(LeasePath(address), mode))
+	}
+}
+
+// ReadOnly reports whether the client went read-only because another
+// process holds its ownership lease
+func (c *BaseClient) ReadOnly() bool {
+	if c.lease == nil {
+		return false
+	}
+	c.lease.mu.Lock()
+	defer c.lease.mu.Unlock()
+	return c.lease.readOnly
+}
+
+// acquire takes the lease if it is not held. With retry unset, a failed
+// attempt is not repeated until the next release.
+func (l *ownershipLease) acquire(ctx context.Context, logger common.LoggerInterface, retry bool) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil && (retry || !l.tried) {
+		l.tried = true
+		file, err := lockFile(l.path)
+		switch {
+		case err == nil:
+			l.file = file
+			l.readOnly = false
+		case errors.Is(err, errLeaseHeld):
+			l.readOnly = true
+			if l.mode == LeaseReadOnly {
+				logger.Warn(ctx, "Device is owned by another process (%s); continuing read-only", l.path)
+			}
+		default:
+			return fmt.Errorf("ownership lease %s: %w", l.path, err)
+		}
+	}
+
+	if l.readOnly && l.mode == LeaseRefuse {
+		owner := ""
+		if pid, err := os.ReadFile(l.path); err == nil && len(pid) > 0 {
+			owner = " (pid " + strings.TrimSpace(string(pid)) + ")"
+		}
+		return fmt.Errorf("%s%s: %w", l.path, owner, ErrDeviceOwned)
+	}
+	return nil
+}
+
+// allow returns ErrReadOnly for writes while another process owns the device
+func (l *ownershipLease) allow(functionCode common.FunctionCode) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.readOnly && functionCode.IsWrite() {
+		return fmt.Errorf("function %s: %w", functionCode, ErrReadOnly)
+	}
+	return nil
+}
+
+// release gives up the lease
+func (l *ownershipLease) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		unlockFile(l.file)
+		l.file = nil
+	}
+	l.tried = false
+	l.readOnly = false
+}
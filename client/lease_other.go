@@ -0,0 +1,30 @@
+//go:build !unix
+
+package client
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+// lockFile creates path exclusively and records the process ID in it. The
+// file is the lease, so an owner that crashes leaves it behind until it is
+// removed by hand.
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, errLeaseHeld
+	}
+	if err != nil {
+		return nil, err
+	}
+	file.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	return file, nil
+}
+
+// unlockFile releases a lease taken by lockFile by removing the file
+func unlockFile(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_OwnershipLease(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "plc.lock")
+
+	newClient := func(mode LeaseMode) *BaseClient {
+		mockTransport := test.NewMockTransport()
+		mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+			pdu := req.GetPDU()
+			if pdu.FunctionCode == common.FuncReadHoldingRegisters {
+				return test.NewMockResponse(1, 1, pdu.FunctionCode, []byte{0x02, 0x00, 0x2A}), nil
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data), nil
+		})
+		return NewBaseClient(mockTransport, WithOwnershipLease(path, mode))
+	}
+
+	owner := newClient(LeaseRefuse)
+	if err := owner.Connect(ctx); err != nil {
+		t.Fatalf("Owner failed to connect: %v", err)
+	}
+	if err := owner.WriteSingleRegister(ctx, 0, 1); err != nil {
+		t.Fatalf("Owner failed to write: %v", err)
+	}
+
+	// A second client refuses to connect while the lease is held
+	refused := newClient(LeaseRefuse)
+	if err := refused.Connect(ctx); !errors.Is(err, ErrDeviceOwned) {
+		t.Fatalf("Expected ErrDeviceOwned, got %v", err)
+	}
+	if refused.IsConnected() {
+		t.Error("Expected the refused client not to connect")
+	}
+
+	// A read-only client connects, reads, and fails writes
+	reader := newClient(LeaseReadOnly)
+	if err := reader.Connect(ctx); err != nil {
+		t.Fatalf("Read-only client failed to connect: %v", err)
+	}
+	if !reader.ReadOnly() {
+		t.Error("Expected the client to be read-only")
+	}
+	if _, err := reader.ReadHoldingRegisters(ctx, 0, 1); err != nil {
+		t.Errorf("Expected reads to work, got %v", err)
+	}
+	if err := reader.WriteSingleRegister(ctx, 0, 1); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	// Once the owner disconnects, the next Connect takes the lease
+	owner.Disconnect(ctx)
+	reader.Disconnect(ctx)
+	if err := reader.Connect(ctx); err != nil {
+		t.Fatalf("Failed to reconnect: %v", err)
+	}
+	if reader.ReadOnly() {
+		t.Error("Expected the client to own the device after reconnecting")
+	}
+	if err := reader.WriteSingleRegister(ctx, 0, 1); err != nil {
+		t.Errorf("Expected writes to work, got %v", err)
+	}
+	if err := refused.Connect(ctx); !errors.Is(err, ErrDeviceOwned) {
+		t.Errorf("Expected ErrDeviceOwned, got %v", err)
+	}
+}
+
+func TestLeasePath(t *testing.T) {
+	if got := filepath.Base(LeasePath("10.0.0.5:502")); got != "gomodbus-10.0.0.5_502.lock" {
+		t.Errorf("Unexpected lease file name %q", got)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package client
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on path, which the kernel releases when
+// the process exits, and records the process ID in the file
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errLeaseHeld
+		}
+		return nil, err
+	}
+	file.Truncate(0)
+	file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return file, nil
+}
+
+// unlockFile releases a lock taken by lockFile. The file is left in place:
+// removing it could race with another process locking it.
+func unlockFile(file *os.File) {
+	file.Truncate(0)
+	file.Close()
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// defaultRequestTimeout bounds requests whose context has no deadline
+const defaultRequestTimeout = 30 * time.Second
+
+// RetryPolicy controls how BaseClient retries failed requests.
+// Requests are retried after transport errors (including timeouts) and
+// after exception 0x06 (Server Device Busy); other exceptions are final.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (MODBUS Exception Responses)
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+
+	// Backoff is the delay before the first retry; it doubles for each
+	// further retry
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between retries; 0 means no cap
+	MaxBackoff time.Duration
+}
+
+// delay returns the backoff before the given retry (0-based)
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < retry && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// retryable reports whether an attempt that ended with response and err
+// should be retried
+func retryable(ctx context.Context, response common.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, common.ErrNotConnected)
+	}
+	return response.IsException() && response.GetException() == common.ExceptionServerDeviceBusy
+}
+
+// WithRetry retries failed requests according to policy
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *BaseClient) {
+		c.retry = policy
+	}
+}
+
+// WithRequestTimeout sets the timeout applied to requests whose context has
+// no deadline (default 30s). It covers all attempts of a retried request.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *BaseClient) {
+		c.requestTimeout = timeout
+	}
+}
+
+// WithRateLimit spaces requests, including retries, at least 1/perSecond
+// apart. The limit is shared by clones of the client. A rate of 0 or less
+// removes the limit.
+func WithRateLimit(perSecond float64) Option {
+	return func(c *BaseClient) {
+		if perSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+	}
+}
+
+// rateLimiter hands out send slots at a fixed interval
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the caller's slot arrives or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleepContext(ctx, time.Until(slot))
+}
+
+// ConcurrencyLimiter caps the requests outstanding to one physical device.
+// Attach the same limiter to every client talking to the device, for example
+// pooled connections or clients of several gateways, with
+// WithConcurrencyLimiter; the cap then holds however many sockets exist.
+type ConcurrencyLimiter struct {
+	slots chan struct{}
+}
+
+// NewConcurrencyLimiter creates a limiter allowing at most n outstanding
+// requests. An n below 1 is treated as 1.
+func NewConcurrencyLimiter(n int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{slots: make(chan struct{}, max(n, 1))}
+}
+
+// Acquire blocks until a request slot is free or ctx is done
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken with Acquire
+func (l *ConcurrencyLimiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// InFlight returns the number of requests currently holding a slot
+func (l *ConcurrencyLimiter) InFlight() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.slots)
+}
+
+// Max returns the number of requests allowed to be outstanding at once
+func (l *ConcurrencyLimiter) Max() int {
+	return cap(l.slots)
+}
+
+// WithConcurrencyLimiter makes the client hold a slot of limiter while each
+// request, including each retry, is outstanding. Share one limiter between
+// all clients of a device. A nil limiter removes the limit.
+func WithConcurrencyLimiter(limiter *ConcurrencyLimiter) Option {
+	return func(c *BaseClient) {
+		c.concurrency = limiter
+	}
+}
+
+// send sends one attempt of the request while holding a concurrency slot
+func (c *BaseClient) send(ctx context.Context, request common.Request) (common.Response, error) {
+	if err := c.concurrency.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.concurrency.Release()
+
+	start := time.Now()
+	invoker := c.transport.Send
+	if c.broadcasting() {
+		invoker = c.sendBroadcast
+	}
+	response, err := c.invoke(ctx, request, invoker)
+	c.stats.record(response, err, time.Since(start))
+	return response, err
+}
+
+// sleepContext sleeps for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// transmit sends the request, applying the rate limit and retry policy, and
+// reports the outcome of every attempt to attempted
+func (c *BaseClient) transmit(ctx context.Context, request common.Request, attempted func(common.Response, error)) (common.Response, error) {
+	limiter := c.limiterFor(request)
+	for retry := 0; ; retry++ {
+		if err := limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		response, err := c.send(ctx, request)
+		attempted(response, err)
+		if retry >= c.retry.MaxRetries || !retryable(ctx, response, err) {
+			return response, err
+		}
+
+		c.logger.Warn(ctx, "Retrying function %s (retry %d of %d)", request.GetPDU().FunctionCode, retry+1, c.retry.MaxRetries)
+		if err := sleepContext(ctx, c.retry.delay(retry)); err != nil {
+			return nil, err
+		}
+	}
+}
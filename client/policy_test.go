@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_Retry(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithRetry(RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}))
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// A timeout, then Server Device Busy, then success
+	mockTransport.QueueError(common.ErrTransactionTimeout)
+	mockTransport.QueueResponse(test.NewMockResponse(1, 0, common.FuncReadHoldingRegisters|0x80, []byte{byte(common.ExceptionServerDeviceBusy)}))
+	mockTransport.QueueResponse(test.NewMockResponse(1, 0, common.FuncReadHoldingRegisters, []byte{0x02, 0x12, 0x34}))
+
+	values, err := client.ReadHoldingRegisters(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("Expected the retried read to succeed, got %v", err)
+	}
+	if values[0] != 0x1234 {
+		t.Errorf("Expected 0x1234, got 0x%04X", values[0])
+	}
+	if n := len(mockTransport.GetRequests()); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	// Other exceptions are not retried
+	mockTransport.Clear()
+	mockTransport.QueueResponse(test.NewMockResponse(1, 0, common.FuncReadHoldingRegisters|0x80, []byte{byte(common.ExceptionDataAddressNotAvailable)}))
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); !common.IsDataAddressNotAvailableError(err) {
+		t.Errorf("Expected an illegal data address error, got %v", err)
+	}
+	if n := len(mockTransport.GetRequests()); n != 1 {
+		t.Errorf("Expected 1 attempt, got %d", n)
+	}
+}
+
+func TestBaseClient_RateLimit(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithRateLimit(100))
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		return test.NewMockResponse(1, 0, common.FuncWriteSingleRegister, req.GetPDU().Data), nil
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := client.WriteSingleRegister(ctx, 0, 1); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	// Six requests at 100/s take at least five intervals
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected requests to be spaced 10ms apart, took %v", elapsed)
+	}
+}
+
+func TestBaseClient_ConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2)
+	var inFlight, peak atomic.Int32
+
+	// Three clients on separate connections to the same device
+	ctx := context.Background()
+	var clients []*BaseClient
+	for i := 0; i < 3; i++ {
+		mockTransport := test.NewMockTransport()
+		mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+			n := inFlight.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+			inFlight.Add(-1)
+			return test.NewMockResponse(1, 0, common.FuncWriteSingleRegister, req.GetPDU().Data), nil
+		})
+		client := NewBaseClient(mockTransport, WithConcurrencyLimiter(limiter))
+		if err := client.Connect(ctx); err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		clients = append(clients, client)
+	}
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := client.WriteSingleRegister(ctx, 0, 1); err != nil {
+					t.Errorf("Write failed: %v", err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("Expected at most 2 requests in flight, saw %d", got)
+	}
+	if limiter.InFlight() != 0 {
+		t.Errorf("Expected all slots released, %d held", limiter.InFlight())
+	}
+
+	// A caller waiting for a slot gives up with its context
+	limiter.Acquire(ctx)
+	limiter.Acquire(ctx)
+	defer limiter.Release()
+	defer limiter.Release()
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := clients[0].WriteSingleRegister(waitCtx, 0, 1); err == nil {
+		t.Error("Expected the write to fail while the limiter is full")
+	}
+}
@@ -0,0 +1,338 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Poll is a range of one table read periodically by a Poller
+type Poll struct {
+	// Name identifies the poll in its changes; it defaults to
+	// "table@address"
+	Name string
+
+	Table    common.Table
+	Address  common.Address
+	Quantity common.Quantity
+
+	// Interval is the time between the starts of two reads
+	Interval time.Duration
+
+	// Timeout bounds each read, by default the interval
+	Timeout time.Duration
+}
+
+// validate checks the poll and fills in its defaults
+func (p *Poll) validate() error {
+	limit := int(common.MaxRegisterCount)
+	switch p.Table {
+	case common.TableCoils, common.TableDiscreteInputs:
+		limit = int(common.MaxCoilCount)
+	case common.TableHoldingRegisters, common.TableInputRegisters:
+	default:
+		return fmt.Errorf("poll %q of %s: %w", p.Name, p.Table, common.ErrInvalidValue)
+	}
+	if p.Quantity == 0 || int(p.Quantity) > limit || int(p.Address)+int(p.Quantity) > 0x10000 {
+		return fmt.Errorf("poll %q of %d values at %d: %w", p.Name, p.Quantity, p.Address, common.ErrInvalidQuantity)
+	}
+	if p.Interval <= 0 {
+		return fmt.Errorf("poll %q: interval must be positive: %w", p.Name, common.ErrInvalidValue)
+	}
+	if p.Name == "" {
+		p.Name = fmt.Sprintf("%s@%d", p.Table, p.Address)
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = p.Interval
+	}
+	return nil
+}
+
+// PollChange reports new values of a poll. Registers holds the values of
+// register tables and Bits those of coils and discrete inputs; Changed lists
+// the indices that differ from the previous read, and is nil for the first
+// read and the first read after a failure, which are reported in full.
+// A failed read is reported with Err set and no values.
+type PollChange struct {
+	Poll      string
+	Table     common.Table
+	Address   common.Address
+	Registers []common.RegisterValue
+	Bits      []bool
+	Changed   []int
+	Time      time.Time
+	Err       error
+}
+
+// PollerOption is a function that configures StartPoller
+type PollerOption func(*Poller)
+
+// WithPollerCallback delivers changes to fn instead of the Changes channel.
+// fn is called from the goroutine of the poll that changed, so changes of
+// different polls may be delivered concurrently.
+func WithPollerCallback(fn func(PollChange)) PollerOption {
+	return func(p *Poller) {
+		p.callback = fn
+	}
+}
+
+// WithPollerJitter varies each wait between reads randomly by up to
+// fraction (0 to 1) of the interval, so polls of many devices started
+// together do not stay in lockstep
+func WithPollerJitter(fraction float64) PollerOption {
+	return func(p *Poller) {
+		p.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// Poller reads ranges periodically and reports their changes, see
+// StartPoller
+type Poller struct {
+	client   *BaseClient
+	callback func(PollChange)
+	jitter   float64
+	changes  chan PollChange
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{} // closed when not paused
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartPoller reads every poll on its own interval until ctx is done or Stop
+// is called, reporting the first values of each poll and then only the
+// reads that changed something, through Changes or WithPollerCallback. A
+// change not yet received holds up further reads of its poll. Reads of
+// different polls run concurrently over the client's connection.
+func (c *BaseClient) StartPoller(ctx context.Context, polls []Poll, options ...PollerOption) (*Poller, error) {
+	polls = append([]Poll(nil), polls...)
+	for i := range polls {
+		if err := polls[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Poller{
+		client:  c,
+		resumed: make(chan struct{}),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	close(p.resumed)
+	for _, option := range options {
+		option(p)
+	}
+	if p.callback == nil {
+		p.changes = make(chan PollChange, len(polls))
+	}
+
+	var wg sync.WaitGroup
+	for _, poll := range polls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx, poll)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		if p.changes != nil {
+			close(p.changes)
+		}
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Changes returns the channel of changes, which is closed once the poller
+// has stopped. It is nil with WithPollerCallback.
+func (p *Poller) Changes() <-chan PollChange {
+	return p.changes
+}
+
+// All returns an iterator over the changes of Changes, for use with
+// range-over-func, ending when the poller stops, ctx is done or the loop
+// breaks:
+//
+//	for change := range poller.All(ctx) { ... }
+//
+// With WithPollerCallback there is no channel and it yields nothing.
+func (p *Poller) All(ctx context.Context) iter.Seq[PollChange] {
+	return func(yield func(PollChange) bool) {
+		if p.changes == nil {
+			return
+		}
+		for {
+			select {
+			case change, ok := <-p.changes:
+				if !ok || !yield(change) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Stop stops the poller and waits for reads in flight
+func (p *Poller) Stop() {
+	p.cancel()
+	<-p.done
+}
+
+// Done is closed when the poller has stopped
+func (p *Poller) Done() <-chan struct{} {
+	return p.done
+}
+
+// Pause stops reading until Resume; reads in flight complete
+func (p *Poller) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused {
+		p.paused = true
+		p.resumed = make(chan struct{})
+	}
+}
+
+// Resume restarts reading after Pause, each poll at once
+func (p *Poller) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		p.paused = false
+		close(p.resumed)
+	}
+}
+
+// Paused reports whether the poller is paused
+func (p *Poller) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// awaitResumed waits while the poller is paused, and reports whether it is
+// still running
+func (p *Poller) awaitResumed(ctx context.Context) bool {
+	p.mu.Lock()
+	resumed := p.resumed
+	p.mu.Unlock()
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// wait returns the time until the next read of a poll, with jitter
+func (p *Poller) wait(interval time.Duration) time.Duration {
+	if p.jitter == 0 {
+		return interval
+	}
+	return time.Duration(float64(interval) * (1 + p.jitter*(2*rand.Float64()-1)))
+}
+
+// run reads one poll until ctx is done. Bit images are compared packed with
+// a common.BitDiffer, registers one by one.
+func (p *Poller) run(ctx context.Context, poll Poll) {
+	var registers []common.RegisterValue
+	var bits common.BitDiffer
+	reported := false
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if !p.awaitResumed(ctx) {
+			return
+		}
+		started := time.Now()
+
+		readCtx, cancel := context.WithTimeout(ctx, poll.Timeout)
+		change := PollChange{Poll: poll.Name, Table: poll.Table, Address: poll.Address}
+		switch poll.Table {
+		case common.TableCoils:
+			change.Bits, change.Err = p.client.ReadCoils(readCtx, poll.Address, poll.Quantity)
+		case common.TableDiscreteInputs:
+			change.Bits, change.Err = p.client.ReadDiscreteInputs(readCtx, poll.Address, poll.Quantity)
+		case common.TableHoldingRegisters:
+			change.Registers, change.Err = p.client.ReadHoldingRegisters(readCtx, poll.Address, poll.Quantity)
+		case common.TableInputRegisters:
+			change.Registers, change.Err = p.client.ReadInputRegisters(readCtx, poll.Address, poll.Quantity)
+		}
+		cancel()
+		if ctx.Err() != nil {
+			return // stopped, not failed
+		}
+		change.Time = time.Now()
+
+		report := true
+		switch {
+		case change.Err != nil:
+			p.client.logger.Warn(ctx, "Poll %s failed: %v", poll.Name, change.Err)
+			registers, reported = nil, false
+			bits.Reset()
+		case reported && change.Bits != nil:
+			// The differ reuses its result, which the change outlives
+			if changed := bits.Update(change.Bits); len(changed) > 0 {
+				change.Changed = slices.Clone(changed)
+			}
+			report = len(change.Changed) > 0
+		case reported:
+			change.Changed = changedIndices(registers, change.Registers)
+			registers = change.Registers
+			report = len(change.Changed) > 0
+		default:
+			registers, reported = change.Registers, true
+			if change.Bits != nil {
+				bits.Update(change.Bits)
+			}
+		}
+		if report && !p.deliver(ctx, change) {
+			return
+		}
+
+		timer.Reset(max(p.wait(poll.Interval)-time.Since(started), 0))
+	}
+}
+
+// deliver reports a change, and whether the poller is still running
+func (p *Poller) deliver(ctx context.Context, change PollChange) bool {
+	if p.callback != nil {
+		p.callback(change)
+		return true
+	}
+	select {
+	case p.changes <- change:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// changedIndices returns the indices at which two reads of a register range
+// differ
+func changedIndices(previous, current []common.RegisterValue) []int {
+	var changed []int
+	for i := range min(len(previous), len(current)) {
+		if previous[i] != current[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
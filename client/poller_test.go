@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// pollDevice serves holding registers and coils that tests change while a
+// poller reads them
+type pollDevice struct {
+	mu        sync.Mutex
+	registers map[uint16]uint16
+	fail      bool
+}
+
+func (d *pollDevice) set(address, value uint16, fail bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.registers[address] = value
+	d.fail = fail
+}
+
+func (d *pollDevice) handle(req common.Request) (common.Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pdu := req.GetPDU()
+	if d.fail {
+		return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionServerDeviceFailure)}), nil
+	}
+	address := uint16(pdu.Data[0])<<8 | uint16(pdu.Data[1])
+	quantity := uint16(pdu.Data[2])<<8 | uint16(pdu.Data[3])
+	if pdu.FunctionCode == common.FuncReadCoils {
+		data := make([]byte, 1+(quantity+7)/8)
+		data[0] = byte(len(data) - 1)
+		for i := range quantity {
+			if d.registers[address+i] != 0 {
+				data[1+i/8] |= 1 << (i % 8)
+			}
+		}
+		return test.NewMockResponse(1, 1, pdu.FunctionCode, data), nil
+	}
+	data := []byte{byte(2 * quantity)}
+	for i := range quantity {
+		value := d.registers[address+i]
+		data = append(data, byte(value>>8), byte(value))
+	}
+	return test.NewMockResponse(1, 1, pdu.FunctionCode, data), nil
+}
+
+// nextChange waits for a change of the poller
+func nextChange(t *testing.T, changes <-chan PollChange) PollChange {
+	t.Helper()
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a change")
+		return PollChange{}
+	}
+}
+
+func TestPoller(t *testing.T) {
+	device := &pollDevice{registers: map[uint16]uint16{10: 1, 11: 2}}
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(device.handle)
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	poller, err := client.StartPoller(ctx, []Poll{
+		{Name: "setpoints", Table: common.TableHoldingRegisters, Address: 10, Quantity: 2, Interval: 5 * time.Millisecond},
+	}, WithPollerJitter(0.5))
+	if err != nil {
+		t.Fatalf("StartPoller failed: %v", err)
+	}
+	defer poller.Stop()
+
+	// The first read is reported in full
+	change := nextChange(t, poller.Changes())
+	if change.Poll != "setpoints" || !slices.Equal(change.Registers, []common.RegisterValue{1, 2}) || change.Changed != nil {
+		t.Fatalf("Expected the first values in full, got %+v", change)
+	}
+
+	// Then only changes, with the indices that changed
+	device.set(11, 7, false)
+	change = nextChange(t, poller.Changes())
+	if !slices.Equal(change.Registers, []common.RegisterValue{1, 7}) || !slices.Equal(change.Changed, []int{1}) {
+		t.Errorf("Expected register 11 to change to 7, got %+v", change)
+	}
+
+	// A failure is reported, and the values again in full once it clears
+	device.set(11, 7, true)
+	if change = nextChange(t, poller.Changes()); !common.IsServerDeviceFailureError(change.Err) {
+		t.Errorf("Expected the failure to be reported, got %+v", change)
+	}
+	device.set(11, 7, false)
+	for change.Err != nil {
+		change = nextChange(t, poller.Changes())
+	}
+	if !slices.Equal(change.Registers, []common.RegisterValue{1, 7}) || change.Changed != nil {
+		t.Errorf("Expected the values in full after the failure, got %+v", change)
+	}
+
+	// Nothing is read while paused
+	poller.Pause()
+	time.Sleep(20 * time.Millisecond) // let a read in flight complete
+	before := len(mockTransport.GetRequests())
+	device.set(10, 3, false)
+	time.Sleep(30 * time.Millisecond)
+	if after := len(mockTransport.GetRequests()); after != before || !poller.Paused() {
+		t.Errorf("Expected no reads while paused, got %d", after-before)
+	}
+	select {
+	case change := <-poller.Changes():
+		t.Errorf("Unexpected change while paused: %+v", change)
+	default:
+	}
+	poller.Resume()
+	if change = nextChange(t, poller.Changes()); !slices.Equal(change.Changed, []int{0}) {
+		t.Errorf("Expected register 10 to change after resuming, got %+v", change)
+	}
+
+	poller.Stop()
+	if _, open := <-poller.Changes(); open {
+		t.Error("Expected the channel to be closed after Stop")
+	}
+}
+
+func TestPoller_Callback(t *testing.T) {
+	device := &pollDevice{registers: map[uint16]uint16{0: 1}}
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(device.handle)
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	changes := make(chan PollChange, 10)
+	poller, err := client.StartPoller(ctx, []Poll{
+		{Table: common.TableCoils, Address: 0, Quantity: 3, Interval: 5 * time.Millisecond},
+	}, WithPollerCallback(func(change PollChange) { changes <- change }))
+	if err != nil {
+		t.Fatalf("StartPoller failed: %v", err)
+	}
+	defer poller.Stop()
+	if poller.Changes() != nil {
+		t.Error("Expected no channel with a callback")
+	}
+
+	change := nextChange(t, changes)
+	if change.Poll != "Coils@0" || !slices.Equal(change.Bits, []bool{true, false, false}) {
+		t.Fatalf("Expected the coils in full, got %+v", change)
+	}
+	device.set(2, 1, false)
+	if change = nextChange(t, changes); !slices.Equal(change.Changed, []int{2}) {
+		t.Errorf("Expected coil 2 to change, got %+v", change)
+	}
+}
+
+func TestPoller_All(t *testing.T) {
+	device := &pollDevice{registers: map[uint16]uint16{}}
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(device.handle)
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	poller, err := client.StartPoller(ctx, []Poll{
+		{Table: common.TableCoils, Address: 0, Quantity: common.MaxCoilCount, Interval: 5 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("StartPoller failed: %v", err)
+	}
+
+	var changes []PollChange
+	for change := range poller.All(ctx) {
+		changes = append(changes, change)
+		if len(changes) == 2 {
+			break
+		}
+		device.set(5, 1, false)
+		device.set(1999, 1, false)
+	}
+	if len(changes[0].Bits) != common.MaxCoilCount || changes[0].Changed != nil {
+		t.Errorf("Expected the coils in full first, got %d bits changed at %v", len(changes[0].Bits), changes[0].Changed)
+	}
+	if !slices.Equal(changes[1].Changed, []int{5, 1999}) {
+		t.Errorf("Expected coils 5 and 1999 to change, got %v", changes[1].Changed)
+	}
+
+	// The iterator ends with the poller, after a change still buffered
+	poller.Stop()
+	for range poller.All(ctx) {
+	}
+}
+
+func TestStartPoller_Invalid(t *testing.T) {
+	client := NewBaseClient(test.NewMockTransport())
+	ctx := context.Background()
+	for _, tc := range []struct {
+		poll Poll
+		err  error
+	}{
+		{Poll{Table: common.TableHoldingRegisters, Quantity: 126, Interval: time.Second}, common.ErrInvalidQuantity},
+		{Poll{Table: common.TableCoils, Address: 0xFFFF, Quantity: 2, Interval: time.Second}, common.ErrInvalidQuantity},
+		{Poll{Table: common.TableHoldingRegisters, Quantity: 1}, common.ErrInvalidValue},
+		{Poll{Table: common.TableAll, Quantity: 1, Interval: time.Second}, common.ErrInvalidValue},
+	} {
+		if _, err := client.StartPoller(ctx, []Poll{tc.poll}); !errors.Is(err, tc.err) {
+			t.Errorf("%+v: expected %v, got %v", tc.poll, tc.err, err)
+		}
+	}
+}
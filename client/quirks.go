@@ -0,0 +1,34 @@
+package client
+
+import (
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/protocol"
+)
+
+// WithQuirks works around the wire-level quirks of a legacy or
+// non-conforming device, see common.Quirks, so that the register map can be
+// used as documented. Addressing and byte count quirks are handled by the
+// default protocol handler; a protocol set later with WithProtocol replaces
+// it and must handle them itself.
+func WithQuirks(quirks common.Quirks) Option {
+	return func(c *BaseClient) {
+		c.quirks = quirks
+		if handler, ok := c.protocol.(*protocol.ProtocolHandler); ok {
+			c.protocol = handler.WithQuirks(quirks)
+		}
+	}
+}
+
+// Quirks returns the device quirks set with WithQuirks
+func (c *BaseClient) Quirks() common.Quirks {
+	return c.quirks
+}
+
+// inputRegistersFunction returns the function code reading input registers,
+// 0x03 for devices with common.QuirkInputRegistersAsHolding
+func (c *BaseClient) inputRegistersFunction() common.FunctionCode {
+	if c.quirks.Has(common.QuirkInputRegistersAsHolding) {
+		return common.FuncReadHoldingRegisters
+	}
+	return common.FuncReadInputRegisters
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_Quirks(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport,
+		WithQuirks(common.QuirkOneBasedAddressing|common.QuirkInputRegistersAsHolding))
+	var function common.FunctionCode
+	var address uint16
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		function, address = pdu.FunctionCode, binary.BigEndian.Uint16(pdu.Data[0:2])
+		return test.NewMockResponse(1, 0, pdu.FunctionCode, []byte{2, 0x01, 0x2C}), nil
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	values, err := client.ReadInputRegisters(ctx, 29, 1)
+	if err != nil {
+		t.Fatalf("ReadInputRegisters failed: %v", err)
+	}
+	if function != common.FuncReadHoldingRegisters || address != 30 {
+		t.Errorf("Expected function 0x03 at wire address 30, got %s at %d", function, address)
+	}
+	if values[0] != 300 {
+		t.Errorf("Expected 300, got %d", values[0])
+	}
+}
+
+func TestNewFromConfig_Quirks(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"endpoint": "127.0.0.1", "quirks": ["jbus", "lenient-byte-count"]}`), "")
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	client, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig failed: %v", err)
+	}
+	if want := common.QuirkOneBasedAddressing | common.QuirkLenientByteCount; client.Quirks() != want {
+		t.Errorf("Expected quirks %s, got %s", want, client.Quirks())
+	}
+
+	if _, err := ParseConfig([]byte(`{"endpoint": "127.0.0.1", "quirks": ["modbus-plus"]}`), ""); err == nil {
+		t.Error("Expected an unknown quirk to be rejected")
+	}
+}
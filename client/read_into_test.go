@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/protocol"
+)
+
+// plainProtocol hides the BufferedProtocol methods of the protocol handler
+type plainProtocol struct {
+	common.Protocol
+}
+
+func TestBaseClient_ReadInto(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options []Option
+	}{
+		{"buffered protocol", nil},
+		{"plain protocol", []Option{WithProtocol(plainProtocol{protocol.NewProtocolHandler()})}},
+	} {
+		mockTransport := test.NewMockTransport()
+		mockTransport.SetHandler(inputRegisterDevice(0x10000))
+		client := NewBaseClient(mockTransport, tc.options...)
+		ctx := context.Background()
+		client.Connect(ctx)
+
+		// One slice serves every poll
+		dst := make([]common.InputRegisterValue, 3)
+		for _, address := range []common.Address{10, 20} {
+			if err := client.ReadInputRegistersInto(ctx, address, dst); err != nil {
+				t.Fatalf("%s: ReadInputRegistersInto failed: %v", tc.name, err)
+			}
+			want := []common.InputRegisterValue{common.InputRegisterValue(address), common.InputRegisterValue(address + 1), common.InputRegisterValue(address + 2)}
+			if !slices.Equal(dst, want) {
+				t.Errorf("%s: expected %v, got %v", tc.name, want, dst)
+			}
+		}
+
+		if err := client.ReadInputRegistersInto(ctx, 0, make([]common.InputRegisterValue, 200)); !errors.Is(err, common.ErrInvalidQuantity) {
+			t.Errorf("%s: expected ErrInvalidQuantity for 200 registers, got %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ErrDeviceMismatch is returned by a client with a readiness gate when the
+// device does not pass the readiness checks, for example because a cabling
+// or DNS change pointed the client at another device
+var ErrDeviceMismatch = errors.New("device mismatch")
+
+// ReadinessCheck verifies that the client talks to the expected device. It
+// returns an error matching ErrDeviceMismatch when the device answers but is
+// not the expected one, and other errors when it cannot tell.
+type ReadinessCheck func(ctx context.Context, c *BaseClient) error
+
+// ExpectDeviceIdentity checks the device's basic identification objects.
+// Empty strings are not checked.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Read Device Identification)
+func ExpectDeviceIdentity(vendorName, productCode string) ReadinessCheck {
+	return func(ctx context.Context, c *BaseClient) error {
+		id, err := c.ReadDeviceIdentification(ctx, common.ReadDeviceIDBasic, 0)
+		if err != nil {
+			return err
+		}
+		if vendorName != "" && id.GetVendorName() != vendorName {
+			return fmt.Errorf("%w: vendor %q, expected %q", ErrDeviceMismatch, id.GetVendorName(), vendorName)
+		}
+		if productCode != "" && id.GetProductCode() != productCode {
+			return fmt.Errorf("%w: product code %q, expected %q", ErrDeviceMismatch, id.GetProductCode(), productCode)
+		}
+		return nil
+	}
+}
+
+// ExpectRegister checks that a holding or input register holds a magic
+// value, such as a device type or site code
+func ExpectRegister(table common.Table, address common.Address, value common.RegisterValue) ReadinessCheck {
+	return func(ctx context.Context, c *BaseClient) error {
+		var values []common.RegisterValue
+		var err error
+		switch table {
+		case common.TableHoldingRegisters:
+			values, err = c.ReadHoldingRegisters(ctx, address, 1)
+		case common.TableInputRegisters:
+			values, err = c.ReadInputRegisters(ctx, address, 1)
+		default:
+			return fmt.Errorf("readiness check of %s: only register tables can be checked", table)
+		}
+		if err != nil {
+			return err
+		}
+		if values[0] != value {
+			return fmt.Errorf("%w: %s %d is 0x%04X, expected 0x%04X", ErrDeviceMismatch, table, address, values[0], value)
+		}
+		return nil
+	}
+}
+
+// readinessGate holds requests until the readiness checks pass
+type readinessGate struct {
+	checks []ReadinessCheck
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// WithReadinessGate refuses requests until checks pass against the device.
+// The checks run before the first request after Connect, and again after a
+// transport error, since a new connection may reach another device. While
+// they fail, requests fail with their error, which matches ErrDeviceMismatch
+// when the device answered but is not the expected one. The gate is shared
+// by clones of the client.
+func WithReadinessGate(checks ...ReadinessCheck) Option {
+	return func(c *BaseClient) {
+		if len(checks) == 0 {
+			c.readiness = nil
+			return
+		}
+		c.readiness = &readinessGate{checks: checks}
+	}
+}
+
+// WithEndpointChangeConfirmation holds writes after the transport of a
+// client created with NewTCPClientFromTransport reconnected to a different
+// address, for example after a DNS failover, until checks pass against the
+// device now reached. Reads go through, so the checks can use them. The
+// reconnect is reported as EventEndpointChanged either way. The gate is
+// shared by clones of the client.
+func WithEndpointChangeConfirmation(checks ...ReadinessCheck) Option {
+	return func(c *BaseClient) {
+		c.endpointGate = &readinessGate{checks: checks, ready: true}
+		if bridge, ok := c.transport.(*transportBridge); ok {
+			bridge.onEndpointChange = c.endpointGate.reset
+		}
+	}
+}
+
+// confirmEndpoint holds a write until the endpoint change gate is open
+func (c *BaseClient) confirmEndpoint(ctx context.Context, functionCode common.FunctionCode) error {
+	if c.endpointGate == nil || !functionCode.IsWrite() {
+		return nil
+	}
+	// Let the transport reconnect first, so that a changed endpoint is
+	// noticed before the write is sent; a failure surfaces when sending
+	if bridge, ok := c.transport.(*transportBridge); ok {
+		bridge.Connect(ctx)
+	}
+	return c.endpointGate.wait(ctx, c)
+}
+
+// wait runs the checks unless they passed on the current connection. Probes
+// are serialized so concurrent requests wait for a single probe.
+func (g *readinessGate) wait(ctx context.Context, c *BaseClient) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ready {
+		return nil
+	}
+
+	// The probe's own requests bypass the gates
+	probe := c.clone(func(c *BaseClient) { c.readiness, c.endpointGate = nil, nil })
+	for _, check := range g.checks {
+		if err := check(ctx, probe); err != nil {
+			if errors.Is(err, ErrDeviceMismatch) {
+				return err
+			}
+			return fmt.Errorf("readiness check: %w", err)
+		}
+	}
+	c.logger.Info(ctx, "Device passed the readiness checks")
+	g.ready = true
+	return nil
+}
+
+// reset makes the checks run again before the next request
+func (g *readinessGate) reset() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.ready = false
+	g.mu.Unlock()
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_ReadinessGate(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport,
+		WithReadinessGate(ExpectRegister(common.TableHoldingRegisters, 9000, 0xBEEF)))
+
+	magic := []byte{2, 0xBE, 0xEF}
+	var fail error
+	var writes int
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		if fail != nil {
+			return nil, fail
+		}
+		if pdu.FunctionCode == common.FuncReadHoldingRegisters {
+			return test.NewMockResponse(1, 0, pdu.FunctionCode, magic), nil
+		}
+		writes++
+		return test.NewMockResponse(1, 0, pdu.FunctionCode, pdu.Data), nil
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// The probe runs once before the first request
+	if err := client.WriteSingleRegister(ctx, 1, 7); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := client.WriteSingleRegister(ctx, 1, 8); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := len(mockTransport.GetRequests()); got != 3 {
+		t.Errorf("Expected one probe and two writes, got %d requests", got)
+	}
+
+	// After a transport error the device is probed again, and another
+	// device is refused
+	fail = common.ErrTimeout
+	client.WriteSingleRegister(ctx, 1, 9)
+	fail = nil
+	magic = []byte{2, 0xDE, 0xAD}
+	err := client.WriteSingleRegister(ctx, 1, 10)
+	if !errors.Is(err, ErrDeviceMismatch) {
+		t.Errorf("Expected ErrDeviceMismatch, got %v", err)
+	}
+	if writes != 2 {
+		t.Errorf("Expected no write to reach the wrong device, got %d writes", writes)
+	}
+
+	// Reconnecting to the right device lifts the gate
+	magic = []byte{2, 0xBE, 0xEF}
+	client.Disconnect(ctx)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to reconnect: %v", err)
+	}
+	if err := client.WriteSingleRegister(ctx, 1, 11); err != nil {
+		t.Errorf("Expected the write to pass the gate, got %v", err)
+	}
+}
+
+// addressedTransport is a mock connection with a resolved address
+type addressedTransport struct {
+	*test.MockTransport
+	remote string
+}
+
+func (t *addressedTransport) RemoteAddr() string { return t.remote }
+
+// failoverTransport hands out its connections in turn, moving to the next
+// one on Reset, like a reconnecting transport whose DNS record changed
+type failoverTransport struct {
+	mu    sync.Mutex
+	conns []common.Transport
+	next  int
+}
+
+func (f *failoverTransport) Conn(ctx context.Context) (common.Transport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.conns[f.next], nil
+}
+
+func (f *failoverTransport) Reset(stale common.Transport) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.conns[f.next] == stale {
+		f.next++
+	}
+	return nil
+}
+
+func (f *failoverTransport) Close() error { return nil }
+
+func TestTCPClient_EndpointChangeConfirmation(t *testing.T) {
+	// device answers reads of the magic register with magic and echoes
+	// writes, or fails while down is set
+	var down atomic.Bool
+	var writes atomic.Int32
+	device := func(remote string, magic byte) *addressedTransport {
+		mock := test.NewMockTransport()
+		mock.Connect(context.Background())
+		mock.SetHandler(func(req common.Request) (common.Response, error) {
+			pdu := req.GetPDU()
+			if down.Load() {
+				return nil, common.ErrTimeout
+			}
+			if pdu.FunctionCode == common.FuncReadHoldingRegisters {
+				return test.NewMockResponse(1, 0, pdu.FunctionCode, []byte{2, 0x00, magic}), nil
+			}
+			writes.Add(1)
+			return test.NewMockResponse(1, 0, pdu.FunctionCode, pdu.Data), nil
+		})
+		return &addressedTransport{MockTransport: mock, remote: remote}
+	}
+	failover := &failoverTransport{conns: []common.Transport{device("10.0.0.5:502", 0x01), device("10.0.0.6:502", 0x02)}}
+	client := NewTCPClientFromTransport(failover, WithTCPBaseOptions(
+		WithEndpointChangeConfirmation(ExpectRegister(common.TableHoldingRegisters, 0, 0x0001))))
+	events := client.Events()
+	ctx := context.Background()
+
+	if err := client.WriteSingleRegister(ctx, 1, 1); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// The connection is lost and DNS now points at another device
+	down.Store(true)
+	client.WriteSingleRegister(ctx, 1, 2)
+	down.Store(false)
+
+	err := client.WriteSingleRegister(ctx, 1, 3)
+	if !errors.Is(err, ErrDeviceMismatch) {
+		t.Errorf("Expected ErrDeviceMismatch after the endpoint changed, got %v", err)
+	}
+	if got := writes.Load(); got != 1 {
+		t.Errorf("Expected no write to reach the new device, got %d writes", got)
+	}
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 1); err != nil {
+		t.Errorf("Expected reads to go through, got %v", err)
+	}
+
+	for {
+		select {
+		case event := <-events:
+			if event.Type != common.EventEndpointChanged {
+				continue
+			}
+			if event.RemoteAddr != "10.0.0.6:502" || event.Detail != "was 10.0.0.5:502" {
+				t.Errorf("Unexpected event %s", event)
+			}
+			return
+		default:
+			t.Fatal("Expected EventEndpointChanged")
+		}
+	}
+}
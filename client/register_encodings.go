@@ -0,0 +1,161 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// DecoderFunc decodes a value from the registers of a field, given in
+// big-endian order (the tag's order is already undone)
+type DecoderFunc func(registers []common.RegisterValue) (float64, error)
+
+// EncoderFunc encodes value into the registers of a field, in big-endian
+// order. It fails with common.ErrInvalidValue for values the encoding cannot
+// represent.
+type EncoderFunc func(value float64, registers []common.RegisterValue) error
+
+// RegisterEncoding is a numeric encoding for struct tags, such as the packed
+// BCD of legacy meters. Fields using it may have any integer or float type.
+type RegisterEncoding struct {
+	Words  int
+	Decode DecoderFunc
+	Encode EncoderFunc
+}
+
+// registerEncodings holds the encodings usable as struct tag types
+var registerEncodings sync.Map // map[string]RegisterEncoding
+
+func init() {
+	for words, suffix := range map[int]string{1: "16", 2: "32"} {
+		registerEncodings.Store("bcd"+suffix, RegisterEncoding{Words: words, Decode: decodeBCD, Encode: encodeBCD})
+		registerEncodings.Store("sm"+suffix, RegisterEncoding{Words: words, Decode: decodeSignMagnitude, Encode: encodeSignMagnitude})
+	}
+	registerEncodings.Store("mod10k", RegisterEncoding{Words: 2, Decode: decodeMod10k, Encode: encodeMod10k})
+}
+
+// RegisterCodec adds an encoding usable as a struct tag type by ReadInto and
+// WriteFrom, so vendor formats can be declared in tags rather than decoded
+// by the application. Built in are bcd16 and bcd32 (packed BCD, four digits
+// per register), sm16 and sm32 (sign and magnitude, the sign in the top bit)
+// and mod10k (two registers, high*10000 + low). The name must not be taken.
+func RegisterCodec(name string, encoding RegisterEncoding) error {
+	if _, builtin := registerTypes[name]; builtin {
+		return fmt.Errorf("register type %q is built in", name)
+	}
+	if _, ok := parseStringType(name); ok {
+		return fmt.Errorf("register type %q is built in", name)
+	}
+	if encoding.Words < 1 || encoding.Words > 4 || encoding.Decode == nil || encoding.Encode == nil {
+		return fmt.Errorf("register encoding %q needs 1 to 4 words and both functions", name)
+	}
+	if _, loaded := registerEncodings.LoadOrStore(name, encoding); loaded {
+		return fmt.Errorf("register encoding %q already registered", name)
+	}
+	return nil
+}
+
+// lookupEncoding returns the registered encoding of a tag type
+func lookupEncoding(name string) (RegisterEncoding, bool) {
+	encoding, ok := registerEncodings.Load(name)
+	if !ok {
+		return RegisterEncoding{}, false
+	}
+	return encoding.(RegisterEncoding), true
+}
+
+// decodeBCD decodes packed BCD, the most significant digit first
+func decodeBCD(registers []common.RegisterValue) (float64, error) {
+	var value float64
+	for _, register := range registers {
+		for shift := 12; shift >= 0; shift -= 4 {
+			digit := (register >> shift) & 0xF
+			if digit > 9 {
+				return 0, fmt.Errorf("invalid BCD digit 0x%X in 0x%04X: %w", digit, register, common.ErrInvalidValue)
+			}
+			value = value*10 + float64(digit)
+		}
+	}
+	return value, nil
+}
+
+// encodeBCD encodes a non-negative integer as packed BCD
+func encodeBCD(value float64, registers []common.RegisterValue) error {
+	limit := math.Pow(10, float64(4*len(registers)))
+	if value < 0 || value >= limit || value != math.Trunc(value) {
+		return fmt.Errorf("%w: %v is not representable in %d BCD digits", common.ErrInvalidValue, value, 4*len(registers))
+	}
+	n := uint64(value)
+	for i := len(registers) - 1; i >= 0; i-- {
+		var register common.RegisterValue
+		for shift := 0; shift < 16; shift += 4 {
+			register |= common.RegisterValue(n%10) << shift
+			n /= 10
+		}
+		registers[i] = register
+	}
+	return nil
+}
+
+// registersUint joins big-endian registers into an integer
+func registersUint(registers []common.RegisterValue) uint64 {
+	var n uint64
+	for _, register := range registers {
+		n = n<<16 | uint64(register)
+	}
+	return n
+}
+
+// putRegistersUint splits an integer into big-endian registers
+func putRegistersUint(n uint64, registers []common.RegisterValue) {
+	for i := len(registers) - 1; i >= 0; i-- {
+		registers[i] = common.RegisterValue(n)
+		n >>= 16
+	}
+}
+
+// decodeSignMagnitude decodes a sign bit followed by the magnitude
+func decodeSignMagnitude(registers []common.RegisterValue) (float64, error) {
+	bits := 16 * len(registers)
+	n := registersUint(registers)
+	magnitude := float64(n &^ (1 << (bits - 1)))
+	if n>>(bits-1) != 0 {
+		return -magnitude, nil
+	}
+	return magnitude, nil
+}
+
+// encodeSignMagnitude encodes an integer as a sign bit and magnitude
+func encodeSignMagnitude(value float64, registers []common.RegisterValue) error {
+	bits := 16 * len(registers)
+	magnitude := math.Abs(value)
+	if magnitude >= math.Pow(2, float64(bits-1)) || value != math.Trunc(value) {
+		return fmt.Errorf("%w: %v is not representable in %d-bit sign and magnitude", common.ErrInvalidValue, value, bits)
+	}
+	n := uint64(magnitude)
+	if math.Signbit(value) && magnitude != 0 {
+		n |= 1 << (bits - 1)
+	}
+	putRegistersUint(n, registers)
+	return nil
+}
+
+// decodeMod10k decodes high*10000 + low
+func decodeMod10k(registers []common.RegisterValue) (float64, error) {
+	if registers[0] > 9999 || registers[1] > 9999 {
+		return 0, fmt.Errorf("invalid Mod10000 registers %d, %d: %w", registers[0], registers[1], common.ErrInvalidValue)
+	}
+	return float64(registers[0])*10000 + float64(registers[1]), nil
+}
+
+// encodeMod10k encodes a non-negative integer below 10^8 as high*10000 + low
+func encodeMod10k(value float64, registers []common.RegisterValue) error {
+	if value < 0 || value >= 1e8 || value != math.Trunc(value) {
+		return fmt.Errorf("%w: %v is not representable in Mod10000", common.ErrInvalidValue, value)
+	}
+	n := uint32(value)
+	registers[0], registers[1] = common.RegisterValue(n/10000), common.RegisterValue(n%10000)
+	return nil
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestRegisterEncodings(t *testing.T) {
+	cases := []struct {
+		name      string
+		value     float64
+		registers []common.RegisterValue
+	}{
+		{"bcd16", 1234, []common.RegisterValue{0x1234}},
+		{"bcd32", 12345678, []common.RegisterValue{0x1234, 0x5678}},
+		{"sm16", -5, []common.RegisterValue{0x8005}},
+		{"sm32", 70000, []common.RegisterValue{0x0001, 0x1170}},
+		{"mod10k", 12345678, []common.RegisterValue{1234, 5678}},
+	}
+	for _, tc := range cases {
+		encoding, ok := lookupEncoding(tc.name)
+		if !ok {
+			t.Fatalf("%s: not registered", tc.name)
+		}
+		registers := make([]common.RegisterValue, encoding.Words)
+		if err := encoding.Encode(tc.value, registers); err != nil {
+			t.Errorf("%s: encode failed: %v", tc.name, err)
+		} else if !slices.Equal(registers, tc.registers) {
+			t.Errorf("%s: expected % X, got % X", tc.name, tc.registers, registers)
+		}
+		if value, err := encoding.Decode(tc.registers); err != nil || value != tc.value {
+			t.Errorf("%s: expected %v, got %v (%v)", tc.name, tc.value, value, err)
+		}
+	}
+
+	bcd, _ := lookupEncoding("bcd16")
+	if _, err := bcd.Decode([]common.RegisterValue{0x12A4}); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected an invalid BCD digit to fail, got %v", err)
+	}
+	if err := bcd.Encode(10000, make([]common.RegisterValue, 1)); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected 10000 not to fit in bcd16, got %v", err)
+	}
+
+	if err := RegisterCodec("bcd16", bcd); err == nil {
+		t.Error("Expected a taken name to be refused")
+	}
+	if err := RegisterCodec("uint16", bcd); err == nil {
+		t.Error("Expected a built-in type name to be refused")
+	}
+}
+
+type legacyMeter struct {
+	Energy  uint32  `modbus:"0,bcd32,cdab"`
+	Power   int     `modbus:"2,sm16"`
+	Total   float64 `modbus:"3,mod10k"`
+	Tenths  int16   `modbus:"5,tenths"`
+	Unitary uint16  `modbus:"6"`
+}
+
+func TestBaseClient_ReadIntoRegisterCodec(t *testing.T) {
+	// A vendor format storing a value in tenths, registered once per process
+	tenths := RegisterEncoding{
+		Words: 1,
+		Decode: func(registers []common.RegisterValue) (float64, error) {
+			return float64(int16(registers[0])) / 10, nil
+		},
+		Encode: func(value float64, registers []common.RegisterValue) error {
+			registers[0] = common.RegisterValue(int16(value * 10))
+			return nil
+		},
+	}
+	if _, ok := lookupEncoding("tenths"); !ok {
+		if err := RegisterCodec("tenths", tenths); err != nil {
+			t.Fatalf("RegisterCodec failed: %v", err)
+		}
+	}
+
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	in := legacyMeter{Energy: 12345678, Power: -300, Total: 99990000, Tenths: 4, Unitary: 1}
+	if err := client.WriteFrom(ctx, 0, &in); err != nil {
+		t.Fatalf("WriteFrom failed: %v", err)
+	}
+	if registers[0] != 0x5678 || registers[1] != 0x1234 || registers[2] != 0x812C || registers[5] != 40 {
+		t.Errorf("Unexpected registers %v", registers)
+	}
+	var out legacyMeter
+	if err := client.ReadInto(ctx, 0, &out); err != nil || out != in {
+		t.Errorf("Expected %+v, got %+v (%v)", in, out, err)
+	}
+
+	// The tenths codec yields 0.5, which an int16 field cannot hold
+	registers[5] = 5
+	if err := client.ReadInto(ctx, 0, &out); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected a fractional value to fail for an integer field, got %v", err)
+	}
+
+	in.Energy = 100000000
+	if err := client.WriteFrom(ctx, 0, &in); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected a value beyond 8 BCD digits to fail, got %v", err)
+	}
+}
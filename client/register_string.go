@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// StringEncoding describes how a string, such as a device name or serial
+// number, is stored across consecutive registers
+type StringEncoding struct {
+	// Registers is the number of registers of the string, including the
+	// length register of a length-prefixed string
+	Registers int
+
+	// UTF16 stores one UTF-16 code unit per register instead of two bytes
+	UTF16 bool
+
+	// SwapBytes stores the second byte of each pair in the high byte of
+	// the register, as some devices do. Ignored for UTF-16.
+	SwapBytes bool
+
+	// LengthPrefixed stores the length, in bytes or UTF-16 code units, in
+	// the first register. Otherwise the string ends at the first NUL or at
+	// the end of its registers.
+	LengthPrefixed bool
+
+	// Padding fills the registers after the string, NUL by default. A
+	// non-NUL padding, typically a space, is trimmed from the end when
+	// decoding.
+	Padding byte
+}
+
+// capacity returns the number of bytes or code units the registers hold
+func (e StringEncoding) capacity() int {
+	registers := e.Registers
+	if e.LengthPrefixed {
+		registers--
+	}
+	if e.UTF16 {
+		return registers
+	}
+	return 2 * registers
+}
+
+// validate checks that the encoding has room for a string
+func (e StringEncoding) validate() error {
+	if e.capacity() < 1 {
+		return fmt.Errorf("string of %d registers: %w", e.Registers, common.ErrInvalidQuantity)
+	}
+	return nil
+}
+
+// DecodeString decodes a string from registers, which must hold at least
+// enc.Registers values
+func DecodeString(registers []common.RegisterValue, enc StringEncoding) (string, error) {
+	if err := enc.validate(); err != nil {
+		return "", err
+	}
+	if len(registers) < enc.Registers {
+		return "", fmt.Errorf("string of %d registers decoded from %d: %w", enc.Registers, len(registers), common.ErrInvalidQuantity)
+	}
+	registers = registers[:enc.Registers]
+
+	length := enc.capacity()
+	if enc.LengthPrefixed {
+		if int(registers[0]) > length {
+			return "", fmt.Errorf("string length %d exceeds the %d available: %w", registers[0], length, common.ErrInvalidValue)
+		}
+		length = int(registers[0])
+		registers = registers[1:]
+	}
+
+	units := make([]uint16, 0, length)
+	if enc.UTF16 {
+		units = append(units, registers[:length]...)
+	} else {
+		for _, register := range registers {
+			high, low := uint16(register>>8), uint16(register&0xFF)
+			if enc.SwapBytes {
+				high, low = low, high
+			}
+			units = append(units, high, low)
+		}
+		units = units[:length]
+	}
+
+	if !enc.LengthPrefixed {
+		for i, unit := range units {
+			if unit == 0 {
+				units = units[:i]
+				break
+			}
+		}
+		for enc.Padding != 0 && len(units) > 0 && units[len(units)-1] == uint16(enc.Padding) {
+			units = units[:len(units)-1]
+		}
+	}
+
+	if enc.UTF16 {
+		return string(utf16.Decode(units)), nil
+	}
+	b := make([]byte, len(units))
+	for i, unit := range units {
+		b[i] = byte(unit)
+	}
+	return string(b), nil
+}
+
+// EncodeString encodes s into enc.Registers registers. Without UTF16 the
+// bytes of s are stored as they are, so non-ASCII text is stored as UTF-8.
+func EncodeString(s string, enc StringEncoding) ([]common.RegisterValue, error) {
+	if err := enc.validate(); err != nil {
+		return nil, err
+	}
+
+	var units []uint16
+	if enc.UTF16 {
+		units = utf16.Encode([]rune(s))
+	} else {
+		units = make([]uint16, len(s))
+		for i := 0; i < len(s); i++ {
+			units[i] = uint16(s[i])
+		}
+	}
+	if len(units) > enc.capacity() {
+		return nil, fmt.Errorf("string %q does not fit in %d registers: %w", s, enc.Registers, common.ErrInvalidValue)
+	}
+
+	registers := make([]common.RegisterValue, 0, enc.Registers)
+	if enc.LengthPrefixed {
+		registers = append(registers, common.RegisterValue(len(units)))
+	}
+	for len(units) < enc.capacity() {
+		units = append(units, uint16(enc.Padding))
+	}
+	if enc.UTF16 {
+		return append(registers, units...), nil
+	}
+	for i := 0; i < len(units); i += 2 {
+		high, low := units[i], units[i+1]
+		if enc.SwapBytes {
+			high, low = low, high
+		}
+		registers = append(registers, common.RegisterValue(high<<8|low))
+	}
+	return registers, nil
+}
+
+// ReadString reads a string stored in holding registers starting at address
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
+func (c *BaseClient) ReadString(ctx context.Context, address common.Address, enc StringEncoding) (string, error) {
+	if err := enc.validate(); err != nil {
+		return "", err
+	}
+	if enc.Registers > int(common.MaxRegisterCount) {
+		return "", fmt.Errorf("string of %d registers: %w", enc.Registers, common.ErrInvalidQuantity)
+	}
+	registers, err := c.ReadHoldingRegisters(ctx, address, common.Quantity(enc.Registers))
+	if err != nil {
+		return "", err
+	}
+	return DecodeString(registers, enc)
+}
+
+// WriteString writes s to holding registers starting at address, padding
+// the unused registers
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteString(ctx context.Context, address common.Address, s string, enc StringEncoding) error {
+	registers, err := EncodeString(s, enc)
+	if err != nil {
+		return err
+	}
+	return c.WriteMultipleRegisters(ctx, address, registers)
+}
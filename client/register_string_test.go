@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestEncodeDecodeString(t *testing.T) {
+	cases := []struct {
+		name      string
+		s         string
+		enc       StringEncoding
+		registers []common.RegisterValue
+	}{
+		{"nul padded", "ABC", StringEncoding{Registers: 3},
+			[]common.RegisterValue{0x4142, 0x4300, 0x0000}},
+		{"swapped bytes", "ABC", StringEncoding{Registers: 2, SwapBytes: true},
+			[]common.RegisterValue{0x4241, 0x0043}},
+		{"space padded", "AB", StringEncoding{Registers: 2, Padding: ' '},
+			[]common.RegisterValue{0x4142, 0x2020}},
+		{"length prefixed", "AB\x00C", StringEncoding{Registers: 4, LengthPrefixed: true},
+			[]common.RegisterValue{4, 0x4142, 0x0043, 0x0000}},
+		{"utf16", "Zähler", StringEncoding{Registers: 8, UTF16: true},
+			[]common.RegisterValue{'Z', 'ä', 'h', 'l', 'e', 'r', 0, 0}},
+		{"full", "ABCD", StringEncoding{Registers: 2},
+			[]common.RegisterValue{0x4142, 0x4344}},
+	}
+
+	for _, tc := range cases {
+		registers, err := EncodeString(tc.s, tc.enc)
+		if err != nil || !slices.Equal(registers, tc.registers) {
+			t.Errorf("%s: expected % X, got % X (%v)", tc.name, tc.registers, registers, err)
+		}
+		s, err := DecodeString(tc.registers, tc.enc)
+		if err != nil || s != tc.s {
+			t.Errorf("%s: expected %q, got %q (%v)", tc.name, tc.s, s, err)
+		}
+	}
+
+	if _, err := EncodeString("ABCDE", StringEncoding{Registers: 2}); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected a too long string to fail, got %v", err)
+	}
+	if _, err := DecodeString([]common.RegisterValue{3, 0x4142}, StringEncoding{Registers: 2, LengthPrefixed: true}); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected a length beyond the registers to fail, got %v", err)
+	}
+	if _, err := DecodeString(nil, StringEncoding{}); !errors.Is(err, common.ErrInvalidQuantity) {
+		t.Errorf("Expected an empty encoding to fail, got %v", err)
+	}
+}
+
+type deviceNameplate struct {
+	Serial string `modbus:"0,string:4,badc"`
+	Name   string `modbus:"4,utf16:3"`
+	Model  uint16 `modbus:"7"`
+}
+
+func TestBaseClient_ReadWriteString(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	enc := StringEncoding{Registers: 8, Padding: ' '}
+	if err := client.WriteString(ctx, 200, "SN-1234", enc); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	if registers[200] != 0x534E || registers[207] != 0x2020 {
+		t.Errorf("Expected space padded ASCII, got %04X..%04X", registers[200], registers[207])
+	}
+	s, err := client.ReadString(ctx, 200, enc)
+	if err != nil || s != "SN-1234" {
+		t.Errorf("Expected SN-1234, got %q (%v)", s, err)
+	}
+
+	in := deviceNameplate{Serial: "AB12", Name: "Pümp", Model: 7}
+	if err := client.WriteFrom(ctx, 300, &in); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected a name longer than its registers to fail, got %v", err)
+	}
+	in.Name = "Püm"
+	if err := client.WriteFrom(ctx, 300, &in); err != nil {
+		t.Fatalf("WriteFrom failed: %v", err)
+	}
+	if registers[300] != 0x4241 || registers[305] != 'ü' {
+		t.Errorf("Expected swapped ASCII and UTF-16, got %04X %04X", registers[300], registers[305])
+	}
+	var out deviceNameplate
+	if err := client.ReadInto(ctx, 300, &out); err != nil || out != in {
+		t.Errorf("Expected %+v, got %+v (%v)", in, out, err)
+	}
+}
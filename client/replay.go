@@ -0,0 +1,408 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// ErrReplayMismatch is returned by a ReplayTransport when a request has no
+// matching exchange in the captured session
+var ErrReplayMismatch = errors.New("no matching exchange in captured session")
+
+// CapturedExchange is one request and its outcome in a captured session.
+// A capture file holds one JSON-encoded exchange per line.
+type CapturedExchange struct {
+	Time         time.Time           `json:"time"`
+	UnitID       common.UnitID       `json:"unit"`
+	FunctionCode common.FunctionCode `json:"fc"`
+	Request      string              `json:"request"` // hex-encoded request PDU data
+
+	// The response PDU; ResponseFunctionCode has the exception bit set for
+	// exception responses
+	ResponseFunctionCode common.FunctionCode `json:"response_fc,omitempty"`
+	Response             string              `json:"response,omitempty"` // hex-encoded response PDU data
+
+	// Error is set instead of the response when the transport failed
+	Error string `json:"error,omitempty"`
+}
+
+// CaptureTransport wraps a transport and writes every exchange to a capture
+// file that a ReplayTransport can serve later, so a session recorded in the
+// field can be reproduced locally:
+//
+//	f, _ := os.Create("session.jsonl")
+//	capture := client.NewCaptureTransport(transport.NewTCPTransport(host), f)
+//	c := client.NewBaseClient(capture)
+type CaptureTransport struct {
+	inner common.Transport
+	out   *captureWriter
+}
+
+// captureWriter writes exchanges to a capture file. It is shared by copies
+// of a CaptureTransport so their lines do not interleave.
+type captureWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewCaptureTransport creates a transport that sends requests through inner
+// and writes each exchange to w
+func NewCaptureTransport(inner common.Transport, w io.Writer) *CaptureTransport {
+	return &CaptureTransport{inner: inner, out: &captureWriter{encoder: json.NewEncoder(w)}}
+}
+
+// Connect connects the wrapped transport
+func (c *CaptureTransport) Connect(ctx context.Context) error {
+	return c.inner.Connect(ctx)
+}
+
+// Disconnect disconnects the wrapped transport
+func (c *CaptureTransport) Disconnect(ctx context.Context) error {
+	return c.inner.Disconnect(ctx)
+}
+
+// IsConnected reports whether the wrapped transport is connected
+func (c *CaptureTransport) IsConnected() bool {
+	return c.inner.IsConnected()
+}
+
+// Send sends the request through the wrapped transport and records the exchange.
+// Failing to write the capture does not fail the request.
+func (c *CaptureTransport) Send(ctx context.Context, request common.Request) (common.Response, error) {
+	response, err := c.inner.Send(ctx, request)
+
+	exchange := CapturedExchange{
+		Time:         time.Now(),
+		UnitID:       request.GetUnitID(),
+		FunctionCode: request.GetPDU().FunctionCode,
+		Request:      hex.EncodeToString(request.GetPDU().Data),
+	}
+	switch {
+	case err != nil:
+		exchange.Error = err.Error()
+	case response != nil:
+		exchange.ResponseFunctionCode = response.GetPDU().FunctionCode
+		exchange.Response = hex.EncodeToString(response.GetPDU().Data)
+	}
+
+	c.out.mu.Lock()
+	c.out.encoder.Encode(exchange)
+	c.out.mu.Unlock()
+
+	return response, err
+}
+
+// WithLogger sets the logger of the wrapped transport. The copy writes to the
+// same capture file.
+func (c *CaptureTransport) WithLogger(logger common.LoggerInterface) common.Transport {
+	return &CaptureTransport{inner: c.inner.WithLogger(logger), out: c.out}
+}
+
+// ReplayStrictness controls how a ReplayTransport matches requests to
+// captured exchanges
+type ReplayStrictness int
+
+const (
+	// ReplayInOrder serves exchanges strictly in capture order; each request
+	// must equal the next captured request (unit ID, function code and data)
+	ReplayInOrder ReplayStrictness = iota
+
+	// ReplayExact serves the first unused exchange whose request equals the
+	// request, in any order
+	ReplayExact
+
+	// ReplayRange serves the first unused exchange with the same function
+	// code, address and quantity, ignoring the unit ID and written values
+	ReplayRange
+)
+
+// String returns the name of the strictness level
+func (s ReplayStrictness) String() string {
+	switch s {
+	case ReplayInOrder:
+		return "in-order"
+	case ReplayExact:
+		return "exact"
+	case ReplayRange:
+		return "range"
+	default:
+		return fmt.Sprintf("ReplayStrictness(%d)", int(s))
+	}
+}
+
+// replayExchange is a parsed captured exchange
+type replayExchange struct {
+	line     int
+	unitID   common.UnitID
+	request  common.PDU
+	response common.PDU
+	err      string
+	used     bool
+}
+
+// ReplayTransport implements common.Transport by serving the responses of a
+// captured session instead of talking to a device, so application code can be
+// run unchanged against a capture from the field:
+//
+//	f, _ := os.Open("session.jsonl")
+//	replay, err := client.NewReplayTransport(f, client.WithReplayStrictness(client.ReplayRange))
+//	c := client.NewBaseClient(replay)
+//
+// Exception responses are replayed as captured and surface as *common.ModbusError;
+// captured transport failures are replayed as errors, wrapping the matching
+// sentinel (for example common.ErrTimeout) when there is one.
+// Requests without a match fail with ErrReplayMismatch.
+type ReplayTransport struct {
+	strictness ReplayStrictness
+	reuse      bool
+	logger     common.LoggerInterface
+
+	mu        sync.Mutex
+	exchanges []*replayExchange
+	next      int // next exchange for ReplayInOrder
+	connected bool
+}
+
+// ReplayOption is a function that configures a ReplayTransport
+type ReplayOption func(*ReplayTransport)
+
+// WithReplayStrictness sets how requests are matched (default ReplayInOrder)
+func WithReplayStrictness(strictness ReplayStrictness) ReplayOption {
+	return func(r *ReplayTransport) {
+		r.strictness = strictness
+	}
+}
+
+// WithReplayReuse lets a request be served again by the last matching
+// exchange once every matching exchange was used, which keeps polling loops
+// running past the end of the capture. It has no effect with ReplayInOrder.
+func WithReplayReuse() ReplayOption {
+	return func(r *ReplayTransport) {
+		r.reuse = true
+	}
+}
+
+// WithReplayLogger sets the logger for the replay transport
+func WithReplayLogger(logger common.LoggerInterface) ReplayOption {
+	return func(r *ReplayTransport) {
+		r.logger = logger
+	}
+}
+
+// NewReplayTransport reads a capture written by CaptureTransport
+func NewReplayTransport(capture io.Reader, options ...ReplayOption) (*ReplayTransport, error) {
+	r := &ReplayTransport{logger: logging.NewLogger()}
+	for _, option := range options {
+		option(r)
+	}
+
+	scanner := bufio.NewScanner(capture)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var exchange CapturedExchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("capture line %d: %w", line, err)
+		}
+		request, err := hex.DecodeString(exchange.Request)
+		if err != nil {
+			return nil, fmt.Errorf("capture line %d: request: %w", line, err)
+		}
+		response, err := hex.DecodeString(exchange.Response)
+		if err != nil {
+			return nil, fmt.Errorf("capture line %d: response: %w", line, err)
+		}
+
+		r.exchanges = append(r.exchanges, &replayExchange{
+			line:     line,
+			unitID:   exchange.UnitID,
+			request:  common.PDU{FunctionCode: exchange.FunctionCode, Data: request},
+			response: common.PDU{FunctionCode: exchange.ResponseFunctionCode, Data: response},
+			err:      exchange.Error,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading capture: %w", err)
+	}
+	return r, nil
+}
+
+// Connect marks the transport as connected
+func (r *ReplayTransport) Connect(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connected = true
+	return nil
+}
+
+// Disconnect marks the transport as disconnected
+func (r *ReplayTransport) Disconnect(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connected = false
+	return nil
+}
+
+// IsConnected reports whether Connect was called
+func (r *ReplayTransport) IsConnected() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.connected
+}
+
+// WithLogger sets the logger for the transport
+func (r *ReplayTransport) WithLogger(logger common.LoggerInterface) common.Transport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logger = logger
+	return r
+}
+
+// Remaining returns the number of captured exchanges not served yet
+func (r *ReplayTransport) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := 0
+	for _, exchange := range r.exchanges {
+		if !exchange.used {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// Send serves the captured outcome of the matching exchange
+func (r *ReplayTransport) Send(ctx context.Context, request common.Request) (common.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	exchange := r.match(request)
+	if exchange != nil {
+		exchange.used = true
+	}
+	r.mu.Unlock()
+
+	if exchange == nil {
+		r.logger.Warn(ctx, "Replay: no %s match for %s % X", r.strictness, request.GetPDU().FunctionCode, request.GetPDU().Data)
+		return nil, fmt.Errorf("%s request %s % X: %w", r.strictness, request.GetPDU().FunctionCode, request.GetPDU().Data, ErrReplayMismatch)
+	}
+	r.logger.Debug(ctx, "Replay: serving capture line %d for %s", exchange.line, request.GetPDU().FunctionCode)
+
+	if exchange.err != "" {
+		return nil, replayError(exchange.err)
+	}
+	return transport.NewResponse(
+		request.GetTransactionID(),
+		request.GetUnitID(),
+		exchange.response.FunctionCode,
+		exchange.response.Data,
+	), nil
+}
+
+// match returns the exchange serving the request, or nil; r.mu must be held
+func (r *ReplayTransport) match(request common.Request) *replayExchange {
+	if r.strictness == ReplayInOrder {
+		if r.next >= len(r.exchanges) {
+			return nil
+		}
+		exchange := r.exchanges[r.next]
+		if !r.matches(exchange, request) {
+			return nil
+		}
+		r.next++
+		return exchange
+	}
+
+	var last *replayExchange
+	for _, exchange := range r.exchanges {
+		if !r.matches(exchange, request) {
+			continue
+		}
+		if !exchange.used {
+			return exchange
+		}
+		last = exchange
+	}
+	if r.reuse {
+		return last
+	}
+	return nil
+}
+
+// matches reports whether the exchange matches the request at the configured strictness
+func (r *ReplayTransport) matches(exchange *replayExchange, request common.Request) bool {
+	pdu := request.GetPDU()
+	if exchange.request.FunctionCode != pdu.FunctionCode {
+		return false
+	}
+	if r.strictness == ReplayRange {
+		return rangeKey(exchange.request) == rangeKey(*pdu)
+	}
+	return exchange.unitID == request.GetUnitID() && string(exchange.request.Data) == string(pdu.Data)
+}
+
+// rangeKey returns the part of a request identifying the accessed range:
+// address and quantity for the standard data access functions, the whole
+// request data otherwise. Single writes are identified by address only.
+func rangeKey(pdu common.PDU) string {
+	switch pdu.FunctionCode {
+	case common.FuncWriteSingleCoil, common.FuncWriteSingleRegister:
+		if len(pdu.Data) >= 2 {
+			return string(pdu.Data[:2])
+		}
+	case common.FuncReadCoils, common.FuncReadDiscreteInputs, common.FuncReadHoldingRegisters,
+		common.FuncReadInputRegisters, common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters:
+		if len(pdu.Data) >= 4 {
+			return string(pdu.Data[:4])
+		}
+	case common.FuncReadWriteMultipleRegisters:
+		if len(pdu.Data) >= 8 {
+			return string(pdu.Data[:8])
+		}
+	}
+	return string(pdu.Data)
+}
+
+// replaySentinels are the errors a captured transport failure is mapped back to
+var replaySentinels = []error{
+	common.ErrTimeout,
+	common.ErrTransactionTimeout,
+	common.ErrNoResponse,
+	common.ErrNotConnected,
+	common.ErrTransportClosing,
+	common.ErrInvalidResponseFormat,
+	common.ErrInvalidResponseLength,
+	io.EOF,
+	context.DeadlineExceeded,
+}
+
+// replayError recreates a captured error, wrapping the sentinel it was built from
+func replayError(message string) error {
+	for _, sentinel := range replaySentinels {
+		if message == sentinel.Error() {
+			return sentinel
+		}
+		if prefix, ok := strings.CutSuffix(message, ": "+sentinel.Error()); ok {
+			return fmt.Errorf("%s: %w", prefix, sentinel)
+		}
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+// captureSession records a short session against a mock device
+func captureSession(t *testing.T) []byte {
+	t.Helper()
+
+	mockTransport := test.NewMockTransport()
+	registers := map[uint16]uint16{10: 0x1234, 11: 0x5678}
+	var requests []common.FunctionCode
+	device := registerDevice(registers, &requests)
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		if req.GetPDU().FunctionCode == common.FuncReadCoils {
+			return nil, common.ErrTimeout
+		}
+		return device(req)
+	})
+
+	var capture bytes.Buffer
+	client := NewBaseClient(NewCaptureTransport(mockTransport, &capture))
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	client.ReadHoldingRegisters(ctx, 10, 2)
+	client.WriteMultipleRegisters(ctx, 10, []common.RegisterValue{0xAAAA, 0xBBBB})
+	client.ReadHoldingRegisters(ctx, 10, 2)
+	client.ReadInputRegisters(ctx, 0, 1) // exception: not implemented by the mock device
+	client.ReadCoils(ctx, 0, 1)          // transport failure
+
+	return capture.Bytes()
+}
+
+func TestCaptureTransport_WithLoggerSharesCapture(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(registerDevice(map[uint16]uint16{0: 1}, new([]common.FunctionCode)))
+
+	var capture bytes.Buffer
+	original := NewCaptureTransport(mockTransport, &capture)
+	copied := original.WithLogger(logging.NewNoopLogger())
+	if copied.(*CaptureTransport).out != original.out {
+		t.Fatal("Expected the copy to share the capture writer and its lock")
+	}
+	ctx := context.Background()
+	original.Connect(ctx)
+
+	// Exchanges recorded through both copies at once are whole lines
+	var wg sync.WaitGroup
+	for _, transport := range []common.Transport{original, copied} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				transport.Send(ctx, test.NewMockRequest(1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	replay, err := NewReplayTransport(&capture)
+	if err != nil {
+		t.Fatalf("Expected a readable capture, got %v", err)
+	}
+	if replay.Remaining() != 100 {
+		t.Errorf("Expected 100 captured exchanges, got %d", replay.Remaining())
+	}
+}
+
+func TestReplayTransport_InOrder(t *testing.T) {
+	capture := captureSession(t)
+	if lines := strings.Count(string(capture), "\n"); lines != 5 {
+		t.Fatalf("Expected 5 captured exchanges, got %d:\n%s", lines, capture)
+	}
+
+	replay, err := NewReplayTransport(bytes.NewReader(capture))
+	if err != nil {
+		t.Fatalf("NewReplayTransport failed: %v", err)
+	}
+	client := NewBaseClient(replay)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	values, err := client.ReadHoldingRegisters(ctx, 10, 2)
+	if err != nil || values[0] != 0x1234 || values[1] != 0x5678 {
+		t.Fatalf("Expected captured values [1234 5678], got %04X (err=%v)", values, err)
+	}
+	if err := client.WriteMultipleRegisters(ctx, 10, []common.RegisterValue{0xAAAA, 0xBBBB}); err != nil {
+		t.Fatalf("WriteMultipleRegisters failed: %v", err)
+	}
+	values, err = client.ReadHoldingRegisters(ctx, 10, 2)
+	if err != nil || values[0] != 0xAAAA {
+		t.Fatalf("Expected values after the write, got %04X (err=%v)", values, err)
+	}
+	if _, err := client.ReadInputRegisters(ctx, 0, 1); !common.IsFunctionNotSupportedError(err) {
+		t.Errorf("Expected the captured exception, got %v", err)
+	}
+	if _, err := client.ReadCoils(ctx, 0, 1); !errors.Is(err, common.ErrTimeout) {
+		t.Errorf("Expected the captured timeout, got %v", err)
+	}
+	if replay.Remaining() != 0 {
+		t.Errorf("Expected the whole session to be replayed, %d left", replay.Remaining())
+	}
+
+	// The session is exhausted
+	if _, err := client.ReadHoldingRegisters(ctx, 10, 2); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("Expected ErrReplayMismatch, got %v", err)
+	}
+}
+
+func TestReplayTransport_Strictness(t *testing.T) {
+	capture := captureSession(t)
+	ctx := context.Background()
+
+	// In order, the write cannot come first
+	replay, _ := NewReplayTransport(bytes.NewReader(capture))
+	client := NewBaseClient(replay)
+	client.Connect(ctx)
+	if err := client.WriteMultipleRegisters(ctx, 10, []common.RegisterValue{0xAAAA, 0xBBBB}); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("Expected ErrReplayMismatch out of order, got %v", err)
+	}
+
+	// Exact matching accepts any order but not different values
+	replay, _ = NewReplayTransport(bytes.NewReader(capture), WithReplayStrictness(ReplayExact))
+	client = NewBaseClient(replay)
+	client.Connect(ctx)
+	if err := client.WriteMultipleRegisters(ctx, 10, []common.RegisterValue{0xAAAA, 0xBBBB}); err != nil {
+		t.Errorf("Expected exact match in any order, got %v", err)
+	}
+	if err := client.WriteMultipleRegisters(ctx, 10, []common.RegisterValue{0x0001, 0x0002}); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("Expected ErrReplayMismatch for different values, got %v", err)
+	}
+
+	// Range matching ignores written values; with reuse, reads keep being served
+	replay, _ = NewReplayTransport(bytes.NewReader(capture), WithReplayStrictness(ReplayRange), WithReplayReuse())
+	client = NewBaseClient(replay)
+	client.Connect(ctx)
+	if err := client.WriteMultipleRegisters(ctx, 10, []common.RegisterValue{0x0001, 0x0002}); err != nil {
+		t.Errorf("Expected range match, got %v", err)
+	}
+	var last []common.RegisterValue
+	for i := 0; i < 4; i++ {
+		values, err := client.ReadHoldingRegisters(ctx, 10, 2)
+		if err != nil {
+			t.Fatalf("Read %d failed: %v", i, err)
+		}
+		last = values
+	}
+	if last[0] != 0xAAAA {
+		t.Errorf("Expected reuse of the last captured read, got %04X", last)
+	}
+	if _, err := client.ReadHoldingRegisters(ctx, 11, 2); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("Expected ErrReplayMismatch for a different range, got %v", err)
+	}
+}
+
+func TestNewReplayTransport_InvalidCapture(t *testing.T) {
+	if _, err := NewReplayTransport(strings.NewReader("{\"fc\":3,\"request\":\"zz\"}\n")); err == nil {
+		t.Error("Expected an error for invalid hex")
+	}
+	if _, err := NewReplayTransport(strings.NewReader("not json\n")); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Range is a span of one data table read as part of a snapshot
+type Range struct {
+	Table    common.Table
+	Address  common.Address
+	Quantity common.Quantity
+}
+
+// RangeResult is the outcome of reading one Range of a snapshot. Bits holds
+// coils and discrete inputs, Registers holds holding and input registers.
+type RangeResult struct {
+	Range
+	Bits      []bool
+	Registers []common.RegisterValue
+	Err       error
+
+	// Time is when the response for this range arrived
+	Time time.Time
+}
+
+// Snapshot is a near-consistent view of several ranges, read with requests
+// sent back to back. Results are in the order of the requested ranges.
+type Snapshot struct {
+	// Start is when the first request was sent, End when the last response
+	// arrived; the values were sampled somewhere within this window
+	Start   time.Time
+	End     time.Time
+	Results []RangeResult
+}
+
+// Spread returns the window during which the values were sampled
+func (s *Snapshot) Spread() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Err returns the errors of the ranges that failed, joined, or nil if every
+// range was read
+func (s *Snapshot) Err() error {
+	var errs []error
+	for _, result := range s.Results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReadSnapshot reads several disjoint ranges as close together in time as
+// the transport allows: all requests are issued at once, so a pipelining
+// transport such as TCP has them in flight back to back. Each range reports
+// its own status, and a failed range does not prevent the others from being
+// read. The error is only set for ranges that do not name exactly one table;
+// then nothing is sent.
+//
+// Modbus has no multi-range read, so the view is not atomic: the device may
+// update values between the requests. Snapshot.Spread bounds the window.
+func (c *BaseClient) ReadSnapshot(ctx context.Context, ranges []Range) (*Snapshot, error) {
+	for i, r := range ranges {
+		switch r.Table {
+		case common.TableCoils, common.TableDiscreteInputs, common.TableHoldingRegisters, common.TableInputRegisters:
+		default:
+			return nil, fmt.Errorf("range %d: table must be exactly one of the four tables, got %s", i, r.Table)
+		}
+	}
+
+	snapshot := &Snapshot{Results: make([]RangeResult, len(ranges))}
+	var wg sync.WaitGroup
+	snapshot.Start = time.Now()
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(result *RangeResult) {
+			defer wg.Done()
+			result.Range = r
+			switch r.Table {
+			case common.TableCoils:
+				result.Bits, result.Err = c.ReadCoils(ctx, r.Address, r.Quantity)
+			case common.TableDiscreteInputs:
+				result.Bits, result.Err = c.ReadDiscreteInputs(ctx, r.Address, r.Quantity)
+			case common.TableHoldingRegisters:
+				result.Registers, result.Err = c.ReadHoldingRegisters(ctx, r.Address, r.Quantity)
+			case common.TableInputRegisters:
+				result.Registers, result.Err = c.ReadInputRegisters(ctx, r.Address, r.Quantity)
+			}
+			result.Time = time.Now()
+		}(&snapshot.Results[i])
+	}
+	wg.Wait()
+
+	snapshot.End = snapshot.Start
+	for _, result := range snapshot.Results {
+		if result.Time.After(snapshot.End) {
+			snapshot.End = result.Time
+		}
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_ReadSnapshot(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	registers := map[uint16]uint16{0: 10, 1: 11, 100: 200, 101: 201, 102: 202}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+
+	snapshot, err := client.ReadSnapshot(ctx, []Range{
+		{Table: common.TableHoldingRegisters, Address: 100, Quantity: 3},
+		{Table: common.TableCoils, Address: 0, Quantity: 8}, // not supported by the device
+		{Table: common.TableHoldingRegisters, Address: 0, Quantity: 2},
+	})
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	if len(requests) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(requests))
+	}
+
+	results := snapshot.Results
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || len(results[0].Registers) != 3 || results[0].Registers[2] != 202 {
+		t.Errorf("Unexpected first range %+v", results[0])
+	}
+	if !common.IsFunctionNotSupportedError(results[1].Err) {
+		t.Errorf("Expected the coil range to fail with Illegal Function, got %v", results[1].Err)
+	}
+	if results[2].Err != nil || results[2].Registers[0] != 10 || results[2].Registers[1] != 11 {
+		t.Errorf("Unexpected third range %+v", results[2])
+	}
+	if !common.IsFunctionNotSupportedError(snapshot.Err()) {
+		t.Errorf("Expected the snapshot error to carry the failed range, got %v", snapshot.Err())
+	}
+	if snapshot.End.Before(snapshot.Start) || results[0].Time.Before(snapshot.Start) || results[0].Time.After(snapshot.End) {
+		t.Errorf("Inconsistent snapshot times %v..%v", snapshot.Start, snapshot.End)
+	}
+
+	if _, err := client.ReadSnapshot(ctx, []Range{{Table: common.TableAll, Quantity: 1}}); err == nil {
+		t.Error("Expected an error for a range spanning several tables")
+	}
+}
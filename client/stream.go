@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// streamWindow is the number of chunk requests a stream keeps outstanding
+const streamWindow = 4
+
+// RegisterChunk is one chunk of a streamed register read. Err is set on the
+// last chunk of a stream that failed, and Values is then empty.
+type RegisterChunk struct {
+	Address common.Address
+	Values  []common.RegisterValue
+	Err     error
+}
+
+// ReadInputRegistersStream reads total input registers starting at address
+// in requests of chunk registers, and delivers each chunk on the returned
+// channel as it arrives, in address order. Up to four requests are kept
+// outstanding so a pipelining transport stays busy, while only the chunks
+// not yet consumed are held in memory; this suits dumps of large areas such
+// as waveform buffers. The channel is closed after the last chunk, after a
+// chunk carrying an error, or when ctx is done. Callers that stop reading
+// early must cancel ctx.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.4 (Read Input Registers)
+func (c *BaseClient) ReadInputRegistersStream(ctx context.Context, address common.Address, total int, chunk common.Quantity) <-chan RegisterChunk {
+	out := make(chan RegisterChunk, 1)
+	if chunk == 0 || chunk > common.MaxRegisterCount || total < 0 || int(address)+total > 0x10000 {
+		out <- RegisterChunk{Address: address, Err: fmt.Errorf("stream of %d registers in chunks of %d at %d: %w",
+			total, chunk, address, common.ErrInvalidQuantity)}
+		close(out)
+		return out
+	}
+
+	go func() {
+		defer close(out)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// pending holds the outstanding requests in address order; the one
+		// being awaited below counts toward the window
+		pending := make(chan chan RegisterChunk, streamWindow-1)
+		go func() {
+			defer close(pending)
+			for offset := 0; offset < total; offset += int(chunk) {
+				start := address + common.Address(offset)
+				quantity := common.Quantity(min(int(chunk), total-offset))
+				result := make(chan RegisterChunk, 1)
+				select {
+				case pending <- result:
+				case <-ctx.Done():
+					return
+				}
+				go func() {
+					values, err := c.ReadInputRegisters(ctx, start, quantity)
+					result <- RegisterChunk{Address: start, Values: values, Err: err}
+				}()
+			}
+		}()
+
+		for result := range pending {
+			var r RegisterChunk
+			select {
+			case r = <-result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+			if r.Err != nil {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// InputRegisterChunks is ReadInputRegistersStream as an iterator for use
+// with range-over-func. Breaking out of the loop cancels the outstanding
+// requests, so callers need no context of their own to stop early:
+//
+//	for chunk := range client.InputRegisterChunks(ctx, 0, 10000, 125) {
+//		if chunk.Err != nil { ... }
+//	}
+func (c *BaseClient) InputRegisterChunks(ctx context.Context, address common.Address, total int, chunk common.Quantity) iter.Seq[RegisterChunk] {
+	return func(yield func(RegisterChunk) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		for r := range c.ReadInputRegistersStream(ctx, address, total, chunk) {
+			if !yield(r) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// inputRegisterDevice answers Read Input Registers with each register holding
+// its own address, and with exception 0x02 from failAt on
+func inputRegisterDevice(failAt int) func(req common.Request) (common.Response, error) {
+	return func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		address := int(binary.BigEndian.Uint16(pdu.Data[0:2]))
+		quantity := int(binary.BigEndian.Uint16(pdu.Data[2:4]))
+		if address+quantity > failAt {
+			return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionDataAddressNotAvailable)}), nil
+		}
+		data := []byte{byte(2 * quantity)}
+		for i := 0; i < quantity; i++ {
+			data = binary.BigEndian.AppendUint16(data, uint16(address+i))
+		}
+		return test.NewMockResponse(1, 1, pdu.FunctionCode, data), nil
+	}
+}
+
+func TestBaseClient_ReadInputRegistersStream(t *testing.T) {
+	ctx := context.Background()
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(inputRegisterDevice(0x10000))
+	client := NewBaseClient(mockTransport)
+	client.Connect(ctx)
+
+	next := 100
+	chunks := 0
+	for chunk := range client.ReadInputRegistersStream(ctx, 100, 1005, 100) {
+		if chunk.Err != nil {
+			t.Fatalf("Chunk at %d failed: %v", chunk.Address, chunk.Err)
+		}
+		if int(chunk.Address) != next {
+			t.Fatalf("Expected chunk at %d, got %d", next, chunk.Address)
+		}
+		for i, v := range chunk.Values {
+			if int(v) != next+i {
+				t.Fatalf("Register %d: expected %d, got %d", next+i, next+i, v)
+			}
+		}
+		next += len(chunk.Values)
+		chunks++
+	}
+	if next != 1105 || chunks != 11 {
+		t.Errorf("Expected 1005 registers in 11 chunks, got %d in %d", next-100, chunks)
+	}
+}
+
+func TestBaseClient_ReadInputRegistersStream_Errors(t *testing.T) {
+	ctx := context.Background()
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(inputRegisterDevice(500))
+	client := NewBaseClient(mockTransport)
+	client.Connect(ctx)
+
+	// The stream ends with the failing chunk
+	var last RegisterChunk
+	good := 0
+	for chunk := range client.ReadInputRegistersStream(ctx, 0, 1000, 100) {
+		if chunk.Err == nil {
+			good++
+		}
+		last = chunk
+	}
+	if good != 5 || last.Address != 500 || !common.IsExceptionError(last.Err, common.ExceptionDataAddressNotAvailable) {
+		t.Errorf("Expected 5 chunks then exception 0x02 at 500, got %d chunks then %v at %d", good, last.Err, last.Address)
+	}
+
+	// Invalid arguments are reported on the channel
+	for _, chunkSize := range []common.Quantity{0, common.MaxRegisterCount + 1} {
+		chunk := <-client.ReadInputRegistersStream(ctx, 0, 10, chunkSize)
+		if !errors.Is(chunk.Err, common.ErrInvalidQuantity) {
+			t.Errorf("Chunk size %d: expected ErrInvalidQuantity, got %v", chunkSize, chunk.Err)
+		}
+	}
+
+	// Cancelling stops the stream
+	ctx, cancel := context.WithCancel(ctx)
+	stream := client.ReadInputRegistersStream(ctx, 0, 400, 10)
+	<-stream
+	cancel()
+	for range stream {
+	}
+}
+
+func TestBaseClient_InputRegisterChunks(t *testing.T) {
+	ctx := context.Background()
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(inputRegisterDevice(0x10000))
+	client := NewBaseClient(mockTransport)
+	client.Connect(ctx)
+
+	// Breaking out of the loop stops the stream without a cancel of our own
+	read := 0
+	for chunk := range client.InputRegisterChunks(ctx, 0, 10000, 10) {
+		if chunk.Err != nil {
+			t.Fatalf("Chunk at %d failed: %v", chunk.Address, chunk.Err)
+		}
+		read += len(chunk.Values)
+		if read >= 30 {
+			break
+		}
+	}
+	if read != 30 {
+		t.Errorf("Expected 30 registers before breaking, got %d", read)
+	}
+}
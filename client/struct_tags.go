@@ -0,0 +1,604 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/values"
+)
+
+// registerField describes one struct field mapped onto a table by a
+// `modbus:"offset,type,order"` or `modbus:"table,addr=N,type=T,order=O"` tag
+type registerField struct {
+	index  int          // field index in the struct
+	name   string       // field name, for errors
+	table  common.Table // holding registers unless the tag names another
+	offset int          // register or bit offset from the base address
+	kind   reflect.Kind
+	words  int               // number of registers the value spans
+	order  string            // be, le, cdab, badc, or "" for the client's
+	str    *StringEncoding   // set for string fields
+	codec  *RegisterEncoding // set for fields of a RegisterCodec type
+}
+
+// registerLayout is the parsed tag layout of a struct type
+type registerLayout struct {
+	fields []registerField // sorted by table and offset
+	reads  []registerRead  // the fewest reads covering every field
+}
+
+// registerRead is one read request of a layout: count registers or bits of
+// a table from offset, holding fields
+type registerRead struct {
+	table  common.Table
+	offset int
+	count  int
+	fields []registerField
+}
+
+// registerTypes maps tag type names to the field kind and register count
+var registerTypes = map[string]struct {
+	kind  reflect.Kind
+	words int
+}{
+	"bool":    {reflect.Bool, 1},
+	"uint16":  {reflect.Uint16, 1},
+	"int16":   {reflect.Int16, 1},
+	"uint32":  {reflect.Uint32, 2},
+	"int32":   {reflect.Int32, 2},
+	"float32": {reflect.Float32, 2},
+	"uint64":  {reflect.Uint64, 4},
+	"int64":   {reflect.Int64, 4},
+	"float64": {reflect.Float64, 4},
+}
+
+// registerLayouts caches parsed layouts by struct type
+var registerLayouts sync.Map // map[reflect.Type]*registerLayout
+
+// layoutOf returns the parsed register layout of a struct type
+func layoutOf(t reflect.Type) (*registerLayout, error) {
+	if cached, ok := registerLayouts.Load(t); ok {
+		return cached.(*registerLayout), nil
+	}
+
+	layout := &registerLayout{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("modbus")
+		if !ok || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("modbus tag on unexported field %s", sf.Name)
+		}
+
+		field, err := parseRegisterTag(sf, tag)
+		if err != nil {
+			return nil, err
+		}
+		field.index = i
+		layout.fields = append(layout.fields, field)
+	}
+	if len(layout.fields) == 0 {
+		return nil, fmt.Errorf("%s has no fields with modbus tags", t)
+	}
+
+	slices.SortFunc(layout.fields, func(a, b registerField) int {
+		if a.table != b.table {
+			return int(a.table) - int(b.table)
+		}
+		return a.offset - b.offset
+	})
+	for i, f := range layout.fields {
+		if i > 0 && f.table == layout.fields[i-1].table && f.offset < layout.fields[i-1].offset+layout.fields[i-1].words {
+			return nil, fmt.Errorf("fields %s and %s overlap", layout.fields[i-1].name, f.name)
+		}
+		if err := layout.add(f); err != nil {
+			return nil, err
+		}
+	}
+
+	registerLayouts.Store(t, layout)
+	return layout, nil
+}
+
+// add adds a field, sorted after the others, to the last read when that read
+// stays within one request, or to a new read
+func (l *registerLayout) add(f registerField) error {
+	limit := int(common.MaxRegisterCount)
+	if f.table == common.TableCoils || f.table == common.TableDiscreteInputs {
+		limit = int(common.MaxCoilCount)
+	}
+	if f.words > limit {
+		return fmt.Errorf("field %s spans %d registers, more than one read allows: %w", f.name, f.words, common.ErrInvalidQuantity)
+	}
+	if n := len(l.reads); n > 0 {
+		last := &l.reads[n-1]
+		if last.table == f.table && f.offset+f.words-last.offset <= limit {
+			last.count = max(last.count, f.offset+f.words-last.offset)
+			last.fields = append(last.fields, f)
+			return nil
+		}
+	}
+	l.reads = append(l.reads, registerRead{table: f.table, offset: f.offset, count: f.words, fields: []registerField{f}})
+	return nil
+}
+
+// parseRegisterTag parses "offset[,type[,order]]" or the keyed form
+// "table,addr=N[,type=T][,order=O]". The type defaults to the field's own
+// type and the order to the client's, see withOrder.
+func parseRegisterTag(sf reflect.StructField, tag string) (registerField, error) {
+	if strings.Contains(tag, "=") {
+		return parseKeyedTag(sf, tag)
+	}
+	parts := strings.Split(tag, ",")
+	field := registerField{name: sf.Name, table: common.TableHoldingRegisters, kind: sf.Type.Kind()}
+
+	offset, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 16)
+	if err != nil {
+		return field, fmt.Errorf("field %s: invalid register offset %q", sf.Name, parts[0])
+	}
+	field.offset = int(offset)
+
+	typeName := sf.Type.Kind().String()
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		typeName = strings.TrimSpace(parts[1])
+	}
+	if enc, ok := parseStringType(typeName); ok {
+		return parseStringTag(sf, field, enc, parts)
+	}
+	if codec, ok := lookupEncoding(typeName); ok {
+		if !numericKind(field.kind) {
+			return field, fmt.Errorf("field %s: register type %s needs a numeric field, not %s", sf.Name, typeName, sf.Type)
+		}
+		field.words = codec.Words
+		field.codec = &codec
+	} else {
+		rt, ok := registerTypes[typeName]
+		if !ok {
+			return field, fmt.Errorf("field %s: unsupported register type %q", sf.Name, typeName)
+		}
+		if rt.kind != field.kind {
+			return field, fmt.Errorf("field %s: register type %s does not match field type %s", sf.Name, typeName, sf.Type)
+		}
+		field.words = rt.words
+	}
+
+	if len(parts) > 2 {
+		field.order = strings.ToLower(strings.TrimSpace(parts[2]))
+		switch field.order {
+		case "be", "le", "cdab", "badc":
+		default:
+			return field, fmt.Errorf("field %s: unsupported word order %q", sf.Name, parts[2])
+		}
+	}
+	if len(parts) > 3 {
+		return field, fmt.Errorf("field %s: too many options in modbus tag %q", sf.Name, tag)
+	}
+	return field, nil
+}
+
+// parseKeyedTag parses "table,addr=N[,type=T][,order=O]", the table being
+// holding, input, coil or discrete. Register fields are then parsed as the
+// positional form; coils and discrete inputs need bool fields.
+func parseKeyedTag(sf reflect.StructField, tag string) (registerField, error) {
+	parts := strings.Split(tag, ",")
+	field := registerField{name: sf.Name, kind: sf.Type.Kind()}
+	tableName := strings.ToLower(strings.TrimSpace(parts[0]))
+	table, ok := tagTables[tableName]
+	if !ok || tableName == "" {
+		return field, fmt.Errorf("field %s: unknown table %q in modbus tag %q", sf.Name, parts[0], tag)
+	}
+
+	options := map[string]string{}
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || (key != "addr" && key != "type" && key != "order") {
+			return field, fmt.Errorf("field %s: unknown option %q in modbus tag %q", sf.Name, part, tag)
+		}
+		if _, dup := options[key]; dup {
+			return field, fmt.Errorf("field %s: option %s repeated in modbus tag %q", sf.Name, key, tag)
+		}
+		options[key] = strings.TrimSpace(value)
+	}
+	addr, ok := options["addr"]
+	if !ok {
+		return field, fmt.Errorf("field %s: modbus tag %q has no addr", sf.Name, tag)
+	}
+
+	if table == common.TableCoils || table == common.TableDiscreteInputs {
+		offset, err := strconv.ParseUint(addr, 10, 16)
+		if err != nil {
+			return field, fmt.Errorf("field %s: invalid address %q", sf.Name, addr)
+		}
+		if field.kind != reflect.Bool || (options["type"] != "" && options["type"] != "bool") || options["order"] != "" {
+			return field, fmt.Errorf("field %s: %s need bool fields without type or order, got %s in modbus tag %q", sf.Name, table, sf.Type, tag)
+		}
+		field.table = table
+		field.offset = int(offset)
+		field.words = 1
+		return field, nil
+	}
+
+	positional := addr + "," + options["type"]
+	if order, ok := options["order"]; ok {
+		positional += "," + order
+	}
+	field, err := parseRegisterTag(sf, positional)
+	field.table = table
+	return field, err
+}
+
+// parseStringType parses the string register types "string:N" (bytes, two
+// per register) and "utf16:N" (one UTF-16 code unit per register) of N
+// registers
+func parseStringType(typeName string) (StringEncoding, bool) {
+	for prefix, utf16 := range map[string]bool{"string:": false, "utf16:": true} {
+		if n, ok := strings.CutPrefix(typeName, prefix); ok {
+			registers, err := strconv.Atoi(n)
+			if err != nil || registers < 1 {
+				return StringEncoding{}, false
+			}
+			return StringEncoding{Registers: registers, UTF16: utf16}, true
+		}
+	}
+	return StringEncoding{}, false
+}
+
+// parseStringTag completes a string field; the order is be or badc (bytes
+// swapped within each register)
+func parseStringTag(sf reflect.StructField, field registerField, enc StringEncoding, parts []string) (registerField, error) {
+	if field.kind != reflect.String {
+		return field, fmt.Errorf("field %s: register type %s does not match field type %s", sf.Name, parts[1], sf.Type)
+	}
+	if len(parts) > 2 {
+		field.order = strings.ToLower(strings.TrimSpace(parts[2]))
+		switch field.order {
+		case "be":
+		case "badc":
+			enc.SwapBytes = true
+		default:
+			return field, fmt.Errorf("field %s: unsupported string byte order %q", sf.Name, parts[2])
+		}
+	}
+	if len(parts) > 3 {
+		return field, fmt.Errorf("field %s: too many options in modbus tag %q", sf.Name, strings.Join(parts, ","))
+	}
+	field.words = enc.Registers
+	field.str = &enc
+	return field, nil
+}
+
+// structLayout validates that v is a non-nil pointer to a struct and returns
+// the struct value and its layout
+func structLayout(v any) (reflect.Value, *registerLayout, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, fmt.Errorf("expected a non-nil pointer to a struct, got %T", v)
+	}
+	layout, err := layoutOf(rv.Elem().Type())
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return rv.Elem(), layout, nil
+}
+
+// withOrder returns the field with the client's word order when its tag
+// gives none. Single registers are always big-endian.
+func (f registerField) withOrder(c *BaseClient) registerField {
+	if f.order == "" && f.words > 1 {
+		f.order = string(c.wordOrder)
+	}
+	return f
+}
+
+// reorder converts between big-endian (ABCD) byte order and the given order,
+// one of the values.Order names
+func reorder(b []byte, order string) {
+	values.Order(order).Reorder(b)
+}
+
+// numericKind reports whether a field of the kind can hold a RegisterCodec
+// value
+func numericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// decodeCodecField sets a field of a RegisterCodec type from its registers,
+// given in big-endian order
+func decodeCodecField(fv reflect.Value, f registerField, b []byte) error {
+	registers := make([]common.RegisterValue, f.words)
+	for i := range registers {
+		registers[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	value, err := f.codec.Decode(registers)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", f.name, err)
+	}
+
+	var fits bool
+	switch {
+	case fv.CanInt():
+		if fits = value == math.Trunc(value) && !fv.OverflowInt(int64(value)); fits {
+			fv.SetInt(int64(value))
+		}
+	case fv.CanUint():
+		if fits = value >= 0 && value == math.Trunc(value) && !fv.OverflowUint(uint64(value)); fits {
+			fv.SetUint(uint64(value))
+		}
+	default:
+		if fits = !fv.OverflowFloat(value); fits {
+			fv.SetFloat(value)
+		}
+	}
+	if !fits {
+		return fmt.Errorf("field %s: %v does not fit in %s: %w", f.name, value, fv.Type(), common.ErrInvalidValue)
+	}
+	return nil
+}
+
+// decodeField sets the field from its registers
+func decodeField(fv reflect.Value, f registerField, registers []common.RegisterValue) error {
+	if f.str != nil {
+		s, err := DecodeString(registers[f.offset:], *f.str)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+		fv.SetString(s)
+		return nil
+	}
+	b := make([]byte, 2*f.words)
+	for i := 0; i < f.words; i++ {
+		binary.BigEndian.PutUint16(b[2*i:], registers[f.offset+i])
+	}
+	reorder(b, f.order)
+	if f.codec != nil {
+		return decodeCodecField(fv, f, b)
+	}
+
+	switch f.kind {
+	case reflect.Bool:
+		fv.SetBool(binary.BigEndian.Uint16(b) != 0)
+	case reflect.Uint16:
+		fv.SetUint(uint64(binary.BigEndian.Uint16(b)))
+	case reflect.Int16:
+		fv.SetInt(int64(int16(binary.BigEndian.Uint16(b))))
+	case reflect.Uint32:
+		fv.SetUint(uint64(binary.BigEndian.Uint32(b)))
+	case reflect.Int32:
+		fv.SetInt(int64(int32(binary.BigEndian.Uint32(b))))
+	case reflect.Float32:
+		fv.SetFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(b))))
+	case reflect.Uint64:
+		fv.SetUint(binary.BigEndian.Uint64(b))
+	case reflect.Int64:
+		fv.SetInt(int64(binary.BigEndian.Uint64(b)))
+	case reflect.Float64:
+		fv.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(b)))
+	}
+	return nil
+}
+
+// encodeCodecField returns the registers holding the value of a field of a
+// RegisterCodec type
+func encodeCodecField(fv reflect.Value, f registerField) ([]common.RegisterValue, error) {
+	var value float64
+	switch {
+	case fv.CanInt():
+		value = float64(fv.Int())
+	case fv.CanUint():
+		value = float64(fv.Uint())
+	default:
+		value = fv.Float()
+	}
+	registers := make([]common.RegisterValue, f.words)
+	if err := f.codec.Encode(value, registers); err != nil {
+		return nil, fmt.Errorf("field %s: %w", f.name, err)
+	}
+
+	b := make([]byte, 2*f.words)
+	for i, register := range registers {
+		binary.BigEndian.PutUint16(b[2*i:], register)
+	}
+	reorder(b, f.order)
+	for i := range registers {
+		registers[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return registers, nil
+}
+
+// encodeField returns the registers holding the field value
+func encodeField(fv reflect.Value, f registerField) ([]common.RegisterValue, error) {
+	if f.str != nil {
+		registers, err := EncodeString(fv.String(), *f.str)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+		return registers, nil
+	}
+	if f.codec != nil {
+		return encodeCodecField(fv, f)
+	}
+	b := make([]byte, 2*f.words)
+	switch f.kind {
+	case reflect.Bool:
+		if fv.Bool() {
+			binary.BigEndian.PutUint16(b, 1)
+		}
+	case reflect.Uint16:
+		binary.BigEndian.PutUint16(b, uint16(fv.Uint()))
+	case reflect.Int16:
+		binary.BigEndian.PutUint16(b, uint16(fv.Int()))
+	case reflect.Uint32:
+		binary.BigEndian.PutUint32(b, uint32(fv.Uint()))
+	case reflect.Int32:
+		binary.BigEndian.PutUint32(b, uint32(fv.Int()))
+	case reflect.Float32:
+		binary.BigEndian.PutUint32(b, math.Float32bits(float32(fv.Float())))
+	case reflect.Uint64:
+		binary.BigEndian.PutUint64(b, fv.Uint())
+	case reflect.Int64:
+		binary.BigEndian.PutUint64(b, uint64(fv.Int()))
+	case reflect.Float64:
+		binary.BigEndian.PutUint64(b, math.Float64bits(fv.Float()))
+	}
+	reorder(b, f.order)
+
+	registers := make([]common.RegisterValue, f.words)
+	for i := range registers {
+		registers[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return registers, nil
+}
+
+// ReadInto reads the values described by the `modbus` struct tags of v,
+// which must be a pointer to a struct, and decodes each tagged field:
+//
+//	var meter struct {
+//		Volts   float32 `modbus:"0,float32,be"`
+//		Amps    float32 `modbus:"2,float32,cdab"`
+//		Status  uint16  `modbus:"4"`
+//		Running bool    `modbus:"5"`
+//	}
+//	err := client.ReadInto(ctx, 100, &meter)
+//
+// The tag is "offset[,type[,order]]": the holding register offset from
+// address, the register type (bool, uint16, int16, uint32, int32, float32,
+// uint64, int64, float64; defaults to the field type, which must match) and
+// the order of multi-register values (be, le, cdab or badc; default that of
+// WithWordOrder, be unless set). Numeric fields
+// may also take a legacy encoding added with RegisterCodec, such as bcd32.
+// String fields
+// take the type string:N, N registers of two bytes each, or utf16:N, N
+// registers of one UTF-16 code unit each, with order be or badc (bytes
+// swapped); they are NUL-padded and end at the first NUL, see
+// StringEncoding. Untagged fields and fields tagged "-" are ignored.
+//
+// The keyed form "table,addr=N[,type=T][,order=O]" also maps fields onto
+// the other tables: holding, input (input registers), coil or discrete
+// (discrete inputs, like coils read into bool fields). addr is again an
+// offset from address, so a struct of device addresses is read with
+// address 0:
+//
+//	var pump struct {
+//		Speed   float32 `modbus:"holding,addr=100,type=float32,order=cdab"`
+//		Flow    uint16  `modbus:"input,addr=30"`
+//		Running bool    `modbus:"coil,addr=7"`
+//	}
+//	err := client.ReadInto(ctx, 0, &pump)
+//
+// Fields of a table are read together, gaps included, in as few requests
+// as the read limits allow; no field may exceed one read.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.1-6.4
+func (c *BaseClient) ReadInto(ctx context.Context, address common.Address, v any) error {
+	sv, layout, err := structLayout(v)
+	if err != nil {
+		return err
+	}
+
+	for _, read := range layout.reads {
+		if int(address)+read.offset+read.count > 0x10000 {
+			return fmt.Errorf("%s field %s past the end of the table: %w", sv.Type(), read.fields[len(read.fields)-1].name, common.ErrInvalidAddress)
+		}
+		start, quantity := address+common.Address(read.offset), common.Quantity(read.count)
+		var bits []bool
+		var registers []common.RegisterValue
+		switch read.table {
+		case common.TableCoils:
+			bits, err = c.ReadCoils(ctx, start, quantity)
+		case common.TableDiscreteInputs:
+			bits, err = c.ReadDiscreteInputs(ctx, start, quantity)
+		case common.TableInputRegisters:
+			registers, err = c.ReadInputRegisters(ctx, start, quantity)
+		default:
+			registers, err = c.ReadHoldingRegisters(ctx, start, quantity)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, f := range read.fields {
+			f.offset -= read.offset
+			if bits != nil {
+				sv.Field(f.index).SetBool(bits[f.offset])
+				continue
+			}
+			if err := decodeField(sv.Field(f.index), f.withOrder(c), registers); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteFrom writes the holding register and coil fields of v, which must be
+// a pointer to a struct tagged as described for ReadInto, starting at
+// address. Input register and discrete input fields are read-only and
+// skipped. Registers and coils not covered by a field are left untouched:
+// each run of adjacent fields is written with one Write Multiple Registers
+// or Write Multiple Coils request.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.11 and 6.12
+func (c *BaseClient) WriteFrom(ctx context.Context, address common.Address, v any) error {
+	sv, layout, err := structLayout(v)
+	if err != nil {
+		return err
+	}
+
+	var run []common.RegisterValue
+	var bits []bool
+	runStart := 0
+	flush := func() error {
+		var err error
+		switch {
+		case len(run) > 0:
+			err = c.WriteMultipleRegisters(ctx, address+common.Address(runStart), run)
+		case len(bits) > 0:
+			err = c.WriteMultipleCoils(ctx, address+common.Address(runStart), bits)
+		}
+		run, bits = nil, nil
+		return err
+	}
+
+	for _, f := range layout.fields {
+		switch f.table {
+		case common.TableCoils:
+			contiguous := len(bits) > 0 && runStart+len(bits) == f.offset
+			if !contiguous || len(bits) >= int(common.MaxWriteCoilCount) {
+				if err := flush(); err != nil {
+					return err
+				}
+				runStart = f.offset
+			}
+			bits = append(bits, sv.Field(f.index).Bool())
+		case common.TableHoldingRegisters:
+			contiguous := len(run) > 0 && runStart+len(run) == f.offset
+			if !contiguous || len(run)+f.words > int(common.MaxWriteRegisterCount) {
+				if err := flush(); err != nil {
+					return err
+				}
+				runStart = f.offset
+			}
+			registers, err := encodeField(sv.Field(f.index), f.withOrder(c))
+			if err != nil {
+				return err
+			}
+			run = append(run, registers...)
+		}
+	}
+	return flush()
+}
@@ -0,0 +1,258 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// registerDevice serves Read Holding Registers, Write Single Register and
+// Write Multiple Registers from a register map and counts the requests it
+// receives, one request at a time
+func registerDevice(registers map[uint16]uint16, requests *[]common.FunctionCode) func(common.Request) (common.Response, error) {
+	var mu sync.Mutex
+	return func(req common.Request) (common.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		pdu := req.GetPDU()
+		*requests = append(*requests, pdu.FunctionCode)
+		address := binary.BigEndian.Uint16(pdu.Data[0:2])
+		quantity := binary.BigEndian.Uint16(pdu.Data[2:4])
+
+		switch pdu.FunctionCode {
+		case common.FuncReadHoldingRegisters:
+			data := make([]byte, 1+2*quantity)
+			data[0] = byte(2 * quantity)
+			for i := uint16(0); i < quantity; i++ {
+				binary.BigEndian.PutUint16(data[1+2*i:], registers[address+i])
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, data), nil
+		case common.FuncWriteMultipleRegisters:
+			for i := uint16(0); i < quantity; i++ {
+				registers[address+i] = binary.BigEndian.Uint16(pdu.Data[5+2*i:])
+			}
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data[0:4]), nil
+		case common.FuncWriteSingleRegister:
+			registers[address] = quantity
+			return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data[0:4]), nil
+		}
+		return test.NewMockResponse(1, 1, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionFunctionCodeNotSupported)}), nil
+	}
+}
+
+type meterReading struct {
+	Volts   float32 `modbus:"0,float32,be"`
+	Amps    float32 `modbus:"2,float32,cdab"`
+	Status  uint16  `modbus:"4"`
+	Running bool    `modbus:"5"`
+	Energy  uint64  `modbus:"10,uint64,le"`
+	Offset  int16   `modbus:"14,int16"`
+	Label   string
+	Ignored uint16 `modbus:"-"`
+}
+
+func TestBaseClient_ReadIntoWriteFrom(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	in := meterReading{
+		Volts:   230.5,
+		Amps:    -1.25,
+		Status:  0xBEEF,
+		Running: true,
+		Energy:  0x0102030405060708,
+		Offset:  -2,
+		Label:   "not written",
+	}
+	if err := client.WriteFrom(ctx, 100, &in); err != nil {
+		t.Fatalf("WriteFrom failed: %v", err)
+	}
+
+	// Registers 106-109 lie between fields and must not be written
+	if len(requests) != 2 {
+		t.Errorf("Expected 2 write requests, got %d", len(requests))
+	}
+	for addr := uint16(106); addr < 110; addr++ {
+		if _, ok := registers[addr]; ok {
+			t.Errorf("Register %d between fields was written", addr)
+		}
+	}
+
+	volts := math.Float32bits(230.5)
+	if registers[100] != uint16(volts>>16) || registers[101] != uint16(volts) {
+		t.Errorf("Expected big-endian float at 100, got %04X %04X", registers[100], registers[101])
+	}
+	amps := math.Float32bits(-1.25)
+	if registers[102] != uint16(amps) || registers[103] != uint16(amps>>16) {
+		t.Errorf("Expected word-swapped float at 102, got %04X %04X", registers[102], registers[103])
+	}
+	if registers[110] != 0x0807 || registers[113] != 0x0201 {
+		t.Errorf("Expected little-endian uint64 at 110, got %04X..%04X", registers[110], registers[113])
+	}
+
+	requests = nil
+	var out meterReading
+	if err := client.ReadInto(ctx, 100, &out); err != nil {
+		t.Fatalf("ReadInto failed: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected a single bulk read, got %d requests", len(requests))
+	}
+
+	in.Label = ""
+	if out != in {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+}
+
+func TestBaseClient_ReadIntoInvalidTags(t *testing.T) {
+	client := NewBaseClient(test.NewMockTransport())
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		v    any
+	}{
+		{"not a pointer", meterReading{}},
+		{"no tags", &struct{ A uint16 }{}},
+		{"type mismatch", &struct {
+			A uint16 `modbus:"0,float32"`
+		}{}},
+		{"unknown order", &struct {
+			A uint32 `modbus:"0,uint32,xyz"`
+		}{}},
+		{"overlap", &struct {
+			A uint32 `modbus:"0"`
+			B uint16 `modbus:"1"`
+		}{}},
+		{"unsupported type", &struct {
+			A string `modbus:"0"`
+		}{}},
+		{"string without length", &struct {
+			A string `modbus:"0,string"`
+		}{}},
+		{"string order", &struct {
+			A string `modbus:"0,string:2,cdab"`
+		}{}},
+		{"too large", &struct {
+			A string `modbus:"0,string:126"`
+		}{}},
+		{"past the table", &struct {
+			A uint32 `modbus:"65535"`
+		}{}},
+		{"unknown table", &struct {
+			A uint16 `modbus:"fifo,addr=1"`
+		}{}},
+		{"no addr", &struct {
+			A uint16 `modbus:"holding,type=uint16"`
+		}{}},
+		{"unknown option", &struct {
+			A uint16 `modbus:"holding,addr=1,scale=2"`
+		}{}},
+		{"coil type", &struct {
+			A uint16 `modbus:"coil,addr=1"`
+		}{}},
+		{"keyed type mismatch", &struct {
+			A uint16 `modbus:"input,addr=1,type=float32"`
+		}{}},
+	}
+
+	for _, tc := range cases {
+		if err := client.ReadInto(ctx, 0, tc.v); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+type pumpStatus struct {
+	Speed   float32 `modbus:"holding,addr=100,type=float32,order=cdab"`
+	Mode    uint16  `modbus:"holding,addr=102"`
+	Far     int16   `modbus:"holding,addr=400"`
+	Flow    uint16  `modbus:"input,addr=30"`
+	Running bool    `modbus:"coil,addr=7"`
+	Valve   bool    `modbus:"coil,addr=9"`
+	Legacy  uint16  `modbus:"103"`
+}
+
+func TestBaseClient_ReadIntoKeyedTags(t *testing.T) {
+	speed := math.Float32bits(12.5)
+	device := &pollDevice{registers: map[uint16]uint16{
+		100: uint16(speed), 101: uint16(speed >> 16), 102: 3, 103: 4, 400: 0xFFFE, 30: 77, 7: 1,
+	}}
+	var requests []common.FunctionCode
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		requests = append(requests, req.GetPDU().FunctionCode)
+		return device.handle(req)
+	})
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	var pump pumpStatus
+	if err := client.ReadInto(ctx, 0, &pump); err != nil {
+		t.Fatalf("ReadInto failed: %v", err)
+	}
+	want := pumpStatus{Speed: 12.5, Mode: 3, Far: -2, Flow: 77, Running: true, Legacy: 4}
+	if pump != want {
+		t.Errorf("Expected %+v, got %+v", want, pump)
+	}
+
+	// One read per table, and another for holding registers out of reach of
+	// the first
+	slices.Sort(requests)
+	wantRequests := []common.FunctionCode{common.FuncReadCoils, common.FuncReadHoldingRegisters, common.FuncReadHoldingRegisters, common.FuncReadInputRegisters}
+	if !slices.Equal(requests, wantRequests) {
+		t.Errorf("Expected requests %v, got %v", wantRequests, requests)
+	}
+}
+
+func TestBaseClient_WriteFromKeyedTags(t *testing.T) {
+	type write struct {
+		function common.FunctionCode
+		address  uint16
+		quantity uint16
+	}
+	var writes []write
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		writes = append(writes, write{pdu.FunctionCode, binary.BigEndian.Uint16(pdu.Data[0:2]), binary.BigEndian.Uint16(pdu.Data[2:4])})
+		return test.NewMockResponse(1, 1, pdu.FunctionCode, pdu.Data[0:4]), nil
+	})
+	client := NewBaseClient(mockTransport)
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	// Adjacent registers share a write; the input register is skipped
+	if err := client.WriteFrom(ctx, 0, &pumpStatus{Running: true}); err != nil {
+		t.Fatalf("WriteFrom failed: %v", err)
+	}
+	want := []write{
+		{common.FuncWriteMultipleCoils, 7, 1},
+		{common.FuncWriteMultipleCoils, 9, 1},
+		{common.FuncWriteMultipleRegisters, 100, 4},
+		{common.FuncWriteMultipleRegisters, 400, 1},
+	}
+	slices.SortFunc(writes, func(a, b write) int {
+		return int(a.function)*0x10000 + int(a.address) - int(b.function)*0x10000 - int(b.address)
+	})
+	if !slices.Equal(writes, want) {
+		t.Errorf("Expected writes %v, got %v", want, writes)
+	}
+}
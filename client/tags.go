@@ -0,0 +1,540 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/values"
+)
+
+// ErrUnknownTag is returned for tag names missing from the client's TagMap
+var ErrUnknownTag = errors.New("unknown tag")
+
+// Tag names a value of a device's register map, such as a pump speed held
+// as a float32 in holding registers 2100-2101
+type Tag struct {
+	Name    string
+	Table   common.Table
+	Address common.Address
+
+	// Type is a struct tag register type (see ReadInto): bool, uint16,
+	// int16, uint32, int32, float32, uint64, int64, float64, string:N,
+	// utf16:N or a RegisterCodec name. Coils and discrete inputs are bool,
+	// the default; registers default to uint16.
+	Type string
+
+	// Order is the order of multi-register values, by default the
+	// client's (see WithWordOrder)
+	Order values.Order
+
+	// Scale and Offset convert numeric values to engineering units:
+	// value = raw*Scale + Offset. Scaled tags read as float64. A zero Scale
+	// is 1.
+	Scale  float64
+	Offset float64
+
+	// Unit is the engineering unit, for display
+	Unit string
+}
+
+// scaled reports whether the tag converts its raw value
+func (t Tag) scaled() bool {
+	return (t.Scale != 0 && t.Scale != 1) || t.Offset != 0
+}
+
+// tagDef is a validated tag with the layout of its registers
+type tagDef struct {
+	Tag
+	field  registerField
+	goType reflect.Type // type of the raw value
+}
+
+// TagMap is a validated set of tags, see NewTagMap and WithTagMap
+type TagMap struct {
+	tags   []tagDef
+	byName map[string]int
+}
+
+// kindTypes maps the kinds of register types to their Go types
+var kindTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeFor[bool](),
+	reflect.Uint16:  reflect.TypeFor[uint16](),
+	reflect.Int16:   reflect.TypeFor[int16](),
+	reflect.Uint32:  reflect.TypeFor[uint32](),
+	reflect.Int32:   reflect.TypeFor[int32](),
+	reflect.Float32: reflect.TypeFor[float32](),
+	reflect.Uint64:  reflect.TypeFor[uint64](),
+	reflect.Int64:   reflect.TypeFor[int64](),
+	reflect.Float64: reflect.TypeFor[float64](),
+}
+
+// NewTagMap validates tags: names must be unique, each tag must name one
+// table and a type fitting it, and its registers must fit in the table
+func NewTagMap(tags ...Tag) (*TagMap, error) {
+	m := &TagMap{byName: make(map[string]int, len(tags))}
+	for _, tag := range tags {
+		def, err := newTagDef(tag)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m.byName[tag.Name]; ok {
+			return nil, fmt.Errorf("tag %q defined twice: %w", tag.Name, common.ErrInvalidValue)
+		}
+		m.byName[tag.Name] = len(m.tags)
+		m.tags = append(m.tags, def)
+	}
+	return m, nil
+}
+
+// newTagDef validates a tag and lays out its registers
+func newTagDef(tag Tag) (tagDef, error) {
+	def := tagDef{Tag: tag}
+	if tag.Name == "" {
+		return def, fmt.Errorf("tag at %s %d has no name: %w", tag.Table, tag.Address, common.ErrInvalidValue)
+	}
+	if err := tag.Order.Validate(); err != nil {
+		return def, fmt.Errorf("tag %q: %w", tag.Name, err)
+	}
+
+	switch tag.Table {
+	case common.TableCoils, common.TableDiscreteInputs:
+		if tag.Type != "" && tag.Type != "bool" {
+			return def, fmt.Errorf("tag %q: %s hold bool values, not %s: %w", tag.Name, tag.Table, tag.Type, common.ErrInvalidValue)
+		}
+		def.Type = "bool"
+		def.goType = kindTypes[reflect.Bool]
+		def.field = registerField{name: tag.Name, kind: reflect.Bool, words: 1}
+	case common.TableHoldingRegisters, common.TableInputRegisters:
+		if def.Type == "" {
+			def.Type = "uint16"
+		}
+		if rt, ok := registerTypes[def.Type]; ok {
+			def.goType = kindTypes[rt.kind]
+		} else if _, ok := parseStringType(def.Type); ok {
+			def.goType = reflect.TypeFor[string]()
+		} else if _, ok := lookupEncoding(def.Type); ok {
+			def.goType = reflect.TypeFor[float64]()
+		} else {
+			return def, fmt.Errorf("tag %q: unsupported register type %q: %w", tag.Name, def.Type, common.ErrInvalidValue)
+		}
+		spec := "0," + def.Type
+		if tag.Order != "" {
+			spec += "," + string(tag.Order)
+		}
+		field, err := parseRegisterTag(reflect.StructField{Name: tag.Name, Type: def.goType}, spec)
+		if err != nil {
+			return def, fmt.Errorf("%w: %w", common.ErrInvalidValue, err)
+		}
+		def.field = field
+	default:
+		return def, fmt.Errorf("tag %q: table must be exactly one of the four tables, got %s: %w", tag.Name, tag.Table, common.ErrInvalidValue)
+	}
+
+	if tag.scaled() && !numericKind(def.goType.Kind()) {
+		return def, fmt.Errorf("tag %q: only numeric tags can be scaled: %w", tag.Name, common.ErrInvalidValue)
+	}
+	if int(tag.Address)+def.field.words > 0x10000 {
+		return def, fmt.Errorf("tag %q of %d registers at %d: %w", tag.Name, def.field.words, tag.Address, common.ErrInvalidAddress)
+	}
+	return def, nil
+}
+
+// Tags returns the tags of the map, in the order they were given, with
+// their default types filled in
+func (m *TagMap) Tags() []Tag {
+	tags := make([]Tag, len(m.tags))
+	for i, def := range m.tags {
+		tags[i] = def.Tag
+	}
+	return tags
+}
+
+// Lookup returns the tag with the given name
+func (m *TagMap) Lookup(name string) (Tag, bool) {
+	i, ok := m.byName[name]
+	if !ok {
+		return Tag{}, false
+	}
+	return m.tags[i].Tag, true
+}
+
+// decode returns the value of a tag from its registers, or from its bit
+func (def *tagDef) decode(c *BaseClient, registers []common.RegisterValue, bit bool) (any, error) {
+	if def.goType.Kind() == reflect.Bool && (def.Table == common.TableCoils || def.Table == common.TableDiscreteInputs) {
+		return bit, nil
+	}
+	fv := reflect.New(def.goType).Elem()
+	if err := decodeField(fv, def.field.withOrder(c), registers); err != nil {
+		return nil, err
+	}
+	if !def.scaled() {
+		return fv.Interface(), nil
+	}
+	var raw float64
+	switch {
+	case fv.CanInt():
+		raw = float64(fv.Int())
+	case fv.CanUint():
+		raw = float64(fv.Uint())
+	default:
+		raw = fv.Float()
+	}
+	scale := def.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return raw*scale + def.Offset, nil
+}
+
+// encode returns the registers of a tag holding value
+func (def *tagDef) encode(c *BaseClient, value any) ([]common.RegisterValue, error) {
+	fv := reflect.New(def.goType).Elem()
+	rv := reflect.ValueOf(value)
+	invalid := func() error {
+		return fmt.Errorf("tag %q of type %s cannot hold %v (%T): %w", def.Name, def.Type, value, value, common.ErrInvalidValue)
+	}
+	switch kind := def.goType.Kind(); {
+	case kind == reflect.Bool || kind == reflect.String:
+		if !rv.IsValid() || rv.Kind() != kind {
+			return nil, invalid()
+		}
+		fv.Set(rv.Convert(def.goType))
+	case !rv.IsValid() || !(rv.CanInt() || rv.CanUint() || rv.CanFloat()):
+		return nil, invalid()
+	case !def.scaled() && rv.CanInt() && fv.CanInt():
+		if fv.OverflowInt(rv.Int()) {
+			return nil, invalid()
+		}
+		fv.SetInt(rv.Int())
+	case !def.scaled() && rv.CanUint() && fv.CanUint():
+		if fv.OverflowUint(rv.Uint()) {
+			return nil, invalid()
+		}
+		fv.SetUint(rv.Uint())
+	default:
+		var x float64
+		switch {
+		case rv.CanInt():
+			x = float64(rv.Int())
+		case rv.CanUint():
+			x = float64(rv.Uint())
+		default:
+			x = rv.Float()
+		}
+		if def.scaled() {
+			scale := def.Scale
+			if scale == 0 {
+				scale = 1
+			}
+			x = (x - def.Offset) / scale
+		}
+		if !setNumber(fv, x) {
+			return nil, invalid()
+		}
+	}
+	return encodeField(fv, def.field.withOrder(c))
+}
+
+// setNumber sets a numeric value from x, rounding it for integer kinds, and
+// reports whether it fits
+func setNumber(fv reflect.Value, x float64) bool {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return fv.CanFloat()
+	}
+	switch {
+	case fv.CanInt():
+		x = math.Round(x)
+		if x < math.MinInt64 || x >= math.MaxInt64 || fv.OverflowInt(int64(x)) {
+			return false
+		}
+		fv.SetInt(int64(x))
+	case fv.CanUint():
+		x = math.Round(x)
+		if x < 0 || x >= math.MaxUint64 || fv.OverflowUint(uint64(x)) {
+			return false
+		}
+		fv.SetUint(uint64(x))
+	default:
+		if fv.OverflowFloat(x) {
+			return false
+		}
+		fv.SetFloat(x)
+	}
+	return true
+}
+
+// WithTagMap gives the client a register map of named tags for ReadTag,
+// WriteTag and ReadAllTags
+func WithTagMap(tags *TagMap) Option {
+	return func(c *BaseClient) {
+		c.tags = tags
+	}
+}
+
+// tag returns the definition of a named tag
+func (c *BaseClient) tag(name string) (*tagDef, error) {
+	if c.tags == nil {
+		return nil, fmt.Errorf("%w %q: the client has no tag map", ErrUnknownTag, name)
+	}
+	i, ok := c.tags.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTag, name)
+	}
+	return &c.tags.tags[i], nil
+}
+
+// ReadTag reads a tag of the client's TagMap. Coils and discrete inputs
+// read as bool and strings as string. Scaled numbers read as float64 in
+// engineering units, others as the Go type of their register type, or
+// float64 for RegisterCodec types.
+func (c *BaseClient) ReadTag(ctx context.Context, name string) (any, error) {
+	def, err := c.tag(name)
+	if err != nil {
+		return nil, err
+	}
+	quantity := common.Quantity(def.field.words)
+	switch def.Table {
+	case common.TableCoils:
+		bits, err := c.ReadCoils(ctx, def.Address, 1)
+		if err != nil {
+			return nil, err
+		}
+		return def.decode(c, nil, bits[0])
+	case common.TableDiscreteInputs:
+		bits, err := c.ReadDiscreteInputs(ctx, def.Address, 1)
+		if err != nil {
+			return nil, err
+		}
+		return def.decode(c, nil, bits[0])
+	case common.TableInputRegisters:
+		registers, err := c.ReadInputRegisters(ctx, def.Address, quantity)
+		if err != nil {
+			return nil, err
+		}
+		return def.decode(c, registers, false)
+	default:
+		registers, err := c.ReadHoldingRegisters(ctx, def.Address, quantity)
+		if err != nil {
+			return nil, err
+		}
+		return def.decode(c, registers, false)
+	}
+}
+
+// WriteTag writes a tag of the client's TagMap: a bool to a coil, a string
+// to string registers, or any Go number to numeric registers, converted
+// from engineering units for scaled tags and rounded for integer types.
+// Values the type cannot hold fail with common.ErrInvalidValue, as do
+// writes to discrete inputs and input registers.
+func (c *BaseClient) WriteTag(ctx context.Context, name string, value any) error {
+	def, err := c.tag(name)
+	if err != nil {
+		return err
+	}
+	switch def.Table {
+	case common.TableCoils:
+		on, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("coil tag %q cannot hold %v (%T): %w", name, value, value, common.ErrInvalidValue)
+		}
+		return c.WriteSingleCoil(ctx, def.Address, on)
+	case common.TableHoldingRegisters:
+		registers, err := def.encode(c, value)
+		if err != nil {
+			return err
+		}
+		if len(registers) == 1 {
+			return c.WriteSingleRegister(ctx, def.Address, registers[0])
+		}
+		return c.WriteMultipleRegisters(ctx, def.Address, registers)
+	default:
+		return fmt.Errorf("tag %q is in read-only %s: %w", name, def.Table, common.ErrInvalidValue)
+	}
+}
+
+// ReadAllTags reads every tag of the client's TagMap, as ReadTag would,
+// merging tags of adjacent or overlapping addresses of a table into as few
+// requests as the function limits allow. Tags whose request failed are
+// left out of the result and their errors joined.
+func (c *BaseClient) ReadAllTags(ctx context.Context) (map[string]any, error) {
+	if c.tags == nil {
+		return nil, fmt.Errorf("%w: the client has no tag map", ErrUnknownTag)
+	}
+	defs := make([]*tagDef, len(c.tags.tags))
+	for i := range c.tags.tags {
+		defs[i] = &c.tags.tags[i]
+	}
+	slices.SortStableFunc(defs, func(a, b *tagDef) int {
+		if a.Table != b.Table {
+			return int(a.Table) - int(b.Table)
+		}
+		return int(a.Address) - int(b.Address)
+	})
+
+	result := make(map[string]any, len(defs))
+	var errs []error
+	for len(defs) > 0 {
+		block, end := c.tagBlock(defs)
+		start := defs[0].Address
+		quantity := common.Quantity(end - int(start))
+		var bits []bool
+		var registers []common.RegisterValue
+		var err error
+		switch defs[0].Table {
+		case common.TableCoils:
+			bits, err = c.ReadCoils(ctx, start, quantity)
+		case common.TableDiscreteInputs:
+			bits, err = c.ReadDiscreteInputs(ctx, start, quantity)
+		case common.TableHoldingRegisters:
+			registers, err = c.ReadHoldingRegisters(ctx, start, quantity)
+		case common.TableInputRegisters:
+			registers, err = c.ReadInputRegisters(ctx, start, quantity)
+		}
+		for _, def := range block {
+			if err != nil {
+				errs = append(errs, fmt.Errorf("tag %q: %w", def.Name, err))
+				continue
+			}
+			offset := int(def.Address - start)
+			var value any
+			var derr error
+			if bits != nil {
+				value, derr = def.decode(c, nil, bits[offset])
+			} else {
+				value, derr = def.decode(c, registers[offset:], false)
+			}
+			if derr != nil {
+				errs = append(errs, fmt.Errorf("tag %q: %w", def.Name, derr))
+				continue
+			}
+			result[def.Name] = value
+		}
+		defs = defs[len(block):]
+	}
+	return result, errors.Join(errs...)
+}
+
+// tagBlock returns the leading tags of defs, sorted by table and address,
+// that one request can read, and the end of their span
+func (c *BaseClient) tagBlock(defs []*tagDef) ([]*tagDef, int) {
+	first := defs[0]
+	limit := int(common.MaxRegisterCount)
+	if first.Table == common.TableCoils || first.Table == common.TableDiscreteInputs {
+		limit = int(common.MaxCoilCount)
+	}
+	end := int(first.Address) + first.field.words
+	n := 1
+	for ; n < len(defs); n++ {
+		def := defs[n]
+		defEnd := int(def.Address) + def.field.words
+		if def.Table != first.Table || int(def.Address) > end || max(end, defEnd)-int(first.Address) > limit {
+			break
+		}
+		end = max(end, defEnd)
+	}
+	return defs[:n], end
+}
+
+// tagConfig is the JSON form of a Tag
+type tagConfig struct {
+	Name    string  `json:"name"`
+	Table   string  `json:"table,omitempty"`
+	Address uint16  `json:"address"`
+	Type    string  `json:"type,omitempty"`
+	Order   string  `json:"order,omitempty"`
+	Scale   float64 `json:"scale,omitempty"`
+	Offset  float64 `json:"offset,omitempty"`
+	Unit    string  `json:"unit,omitempty"`
+}
+
+// tagTables maps the table names of tag files to tables
+var tagTables = map[string]common.Table{
+	"":                  common.TableHoldingRegisters,
+	"holding":           common.TableHoldingRegisters,
+	"holding_registers": common.TableHoldingRegisters,
+	"input":             common.TableInputRegisters,
+	"input_registers":   common.TableInputRegisters,
+	"coil":              common.TableCoils,
+	"coils":             common.TableCoils,
+	"discrete":          common.TableDiscreteInputs,
+	"discrete_inputs":   common.TableDiscreteInputs,
+}
+
+// ParseTagMap parses a JSON array of tags and validates it as NewTagMap
+// does. Each tag is an object:
+//
+//	{"name": "PumpSpeed", "table": "holding", "address": 2100,
+//	 "type": "float32", "order": "cdab", "scale": 0.1, "unit": "rpm"}
+//
+// The table is holding (the default), input, coil or discrete. file names
+// the source in error messages, which are *ConfigError, and may be empty.
+func ParseTagMap(data []byte, file string) (*TagMap, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	fail := func(offset int64, field string, err error) error {
+		line, column := position(data, offset)
+		return &ConfigError{File: file, Line: line, Column: column, Field: field, Err: err}
+	}
+
+	if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+		return nil, fail(0, "", errors.New("expected a JSON array of tags"))
+	}
+	var tags []Tag
+	for i := 0; dec.More(); i++ {
+		offset := dec.InputOffset()
+		for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,", data[offset]) >= 0 {
+			offset++ // to the start of the tag
+		}
+		var cfg tagConfig
+		if err := dec.Decode(&cfg); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				offset = syntaxErr.Offset - 1
+			}
+			return nil, fail(offset, fmt.Sprintf("tags[%d]", i), err)
+		}
+		table, ok := tagTables[strings.ToLower(cfg.Table)]
+		if !ok {
+			return nil, fail(offset, fmt.Sprintf("tags[%d].table", i), fmt.Errorf("unknown table %q", cfg.Table))
+		}
+		tag := Tag{
+			Name: cfg.Name, Table: table, Address: common.Address(cfg.Address), Type: cfg.Type,
+			Order: values.Order(strings.ToLower(cfg.Order)), Scale: cfg.Scale, Offset: cfg.Offset, Unit: cfg.Unit,
+		}
+		if _, err := newTagDef(tag); err != nil {
+			return nil, fail(offset, fmt.Sprintf("tags[%d]", i), err)
+		}
+		tags = append(tags, tag)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fail(dec.InputOffset(), "", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fail(dec.InputOffset(), "", errors.New("unexpected data after the tag array"))
+	}
+
+	m, err := NewTagMap(tags...)
+	if err != nil {
+		return nil, &ConfigError{File: file, Err: err}
+	}
+	return m, nil
+}
+
+// LoadTagMap reads a JSON tag file, see ParseTagMap
+func LoadTagMap(path string) (*TagMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTagMap(data, path)
+}
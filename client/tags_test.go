@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/values"
+)
+
+func TestBaseClient_Tags(t *testing.T) {
+	tags, err := NewTagMap(
+		Tag{Name: "PumpSpeed", Table: common.TableHoldingRegisters, Address: 2100, Type: "float32", Order: values.WordSwap, Scale: 0.1, Unit: "rpm"},
+		Tag{Name: "Setpoint", Table: common.TableHoldingRegisters, Address: 2102, Type: "int16", Scale: 0.5, Offset: -10},
+		Tag{Name: "Mode", Table: common.TableHoldingRegisters, Address: 2103},
+		Tag{Name: "Serial", Table: common.TableHoldingRegisters, Address: 2104, Type: "string:2"},
+		Tag{Name: "Running", Table: common.TableCoils, Address: 7},
+	)
+	if err != nil {
+		t.Fatalf("NewTagMap failed: %v", err)
+	}
+	if tag, ok := tags.Lookup("PumpSpeed"); !ok || tag.Unit != "rpm" {
+		t.Errorf("Expected the PumpSpeed tag, got %+v", tag)
+	}
+
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithTagMap(tags))
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	// Scaled values are written and read in engineering units
+	if err := client.WriteTag(ctx, "PumpSpeed", 1500); err != nil {
+		t.Fatalf("WriteTag failed: %v", err)
+	}
+	if registers[2100] != 0x6000 || registers[2101] != 0x466A {
+		t.Errorf("Expected CDAB registers of 15000.0, got %04X %04X", registers[2100], registers[2101])
+	}
+	if v, err := client.ReadTag(ctx, "PumpSpeed"); err != nil || v != 1500.0 {
+		t.Errorf("Expected 1500, got %v (%v)", v, err)
+	}
+	if err := client.WriteTag(ctx, "Setpoint", 20.2); err != nil {
+		t.Fatalf("WriteTag failed: %v", err)
+	}
+	if registers[2102] != 60 {
+		t.Errorf("Expected raw setpoint 60, got %d", registers[2102])
+	}
+	if err := client.WriteTag(ctx, "Mode", uint8(3)); err != nil {
+		t.Fatalf("WriteTag failed: %v", err)
+	}
+	if err := client.WriteTag(ctx, "Serial", "AB1"); err != nil {
+		t.Fatalf("WriteTag failed: %v", err)
+	}
+	if v, err := client.ReadTag(ctx, "Mode"); err != nil || v != uint16(3) {
+		t.Errorf("Expected uint16 3, got %v (%T, %v)", v, v, err)
+	}
+
+	// Values the type cannot hold fail without a request
+	sent := len(requests)
+	for _, value := range []any{70000, -1, "3", true, math.NaN()} {
+		if err := client.WriteTag(ctx, "Mode", value); !errors.Is(err, common.ErrInvalidValue) {
+			t.Errorf("Expected ErrInvalidValue writing %v, got %v", value, err)
+		}
+	}
+	if err := client.WriteTag(ctx, "Running", 1); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue writing a number to a coil, got %v", err)
+	}
+	if _, err := client.ReadTag(ctx, "Missing"); !errors.Is(err, ErrUnknownTag) {
+		t.Errorf("Expected ErrUnknownTag, got %v", err)
+	}
+	if len(requests) != sent {
+		t.Errorf("Expected no requests for invalid writes, got %v", requests[sent:])
+	}
+
+	// Adjacent registers are read together; the failed coil read is joined
+	all, err := client.ReadAllTags(ctx)
+	if err == nil {
+		t.Error("Expected the coil read to fail")
+	}
+	want := map[string]any{"PumpSpeed": 1500.0, "Setpoint": 20.0, "Mode": uint16(3), "Serial": "AB1"}
+	for name, value := range want {
+		if all[name] != value {
+			t.Errorf("Expected %s = %v, got %v (%T)", name, value, all[name], all[name])
+		}
+	}
+	if _, ok := all["Running"]; ok {
+		t.Error("Expected no value for the failed coil tag")
+	}
+	if got := requests[len(requests)-2:]; !slices.Equal(got, []common.FunctionCode{common.FuncReadCoils, common.FuncReadHoldingRegisters}) {
+		t.Errorf("Expected one coil and one register read, got %v", got)
+	}
+}
+
+func TestNewTagMap_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []Tag
+	}{
+		{"no name", []Tag{{Table: common.TableHoldingRegisters}}},
+		{"duplicate", []Tag{{Name: "A", Table: common.TableCoils}, {Name: "A", Table: common.TableCoils, Address: 1}}},
+		{"no table", []Tag{{Name: "A"}}},
+		{"two tables", []Tag{{Name: "A", Table: common.TableCoils | common.TableHoldingRegisters}}},
+		{"coil type", []Tag{{Name: "A", Table: common.TableCoils, Type: "uint16"}}},
+		{"unknown type", []Tag{{Name: "A", Table: common.TableInputRegisters, Type: "uint24"}}},
+		{"unknown order", []Tag{{Name: "A", Table: common.TableInputRegisters, Type: "uint32", Order: "dcba"}}},
+		{"scaled string", []Tag{{Name: "A", Table: common.TableHoldingRegisters, Type: "string:4", Scale: 2}}},
+		{"past the table", []Tag{{Name: "A", Table: common.TableHoldingRegisters, Address: 0xFFFF, Type: "float32"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTagMap(tt.tags...); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestParseTagMap(t *testing.T) {
+	tags, err := ParseTagMap([]byte(`[
+  {"name": "PumpSpeed", "address": 2100, "type": "float32", "order": "CDAB", "scale": 0.1, "unit": "rpm"},
+  {"name": "Alarm", "table": "discrete", "address": 4}
+]`), "tags.json")
+	if err != nil {
+		t.Fatalf("ParseTagMap failed: %v", err)
+	}
+	want := []Tag{
+		{Name: "PumpSpeed", Table: common.TableHoldingRegisters, Address: 2100, Type: "float32", Order: values.WordSwap, Scale: 0.1, Unit: "rpm"},
+		{Name: "Alarm", Table: common.TableDiscreteInputs, Address: 4, Type: "bool"},
+	}
+	if got := tags.Tags(); !slices.Equal(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	_, err = ParseTagMap([]byte("[\n  {\"name\": \"A\"},\n  {\"name\": \"B\", \"type\": \"uint24\"}\n]"), "tags.json")
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Line != 3 || configErr.Field != "tags[1]" {
+		t.Errorf("Expected a ConfigError at line 3 for tags[1], got %v", err)
+	}
+	if _, err := ParseTagMap([]byte(`{"name": "A"}`), ""); err == nil {
+		t.Error("Expected an error for an object")
+	}
+	if _, err := ParseTagMap([]byte(`[{"name": "A", "table": "fifo"}]`), ""); err == nil {
+		t.Error("Expected an error for an unknown table")
+	}
+}
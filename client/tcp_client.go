@@ -27,12 +27,27 @@ func WithTCPLogger(logger common.LoggerInterface) TCPOption {
 // WithTCPUnitID sets the unit ID for the TCP client
 func WithTCPUnitID(unitID common.UnitID) TCPOption {
 	return func(c *TCPClient) {
-		c.BaseClient = NewBaseClient(
-			c.BaseClient.transport,
-			WithUnitID(unitID),
-			WithLogger(c.BaseClient.logger),
-			WithProtocol(c.BaseClient.protocol),
-		)
+		c.BaseClient = c.BaseClient.clone(WithUnitID(unitID))
+	}
+}
+
+// WithTCPBaseOptions applies BaseClient options (for example
+// WithNotSupportedErrors) to the TCP client
+func WithTCPBaseOptions(options ...Option) TCPOption {
+	return func(c *TCPClient) {
+		c.BaseClient = c.BaseClient.clone(options...)
+	}
+}
+
+// WithTCPDeviceRegistry names the device in log fields, errors and events
+// using registry, looked up by the transport's host:port and the unit ID
+func WithTCPDeviceRegistry(registry *common.DeviceRegistry) TCPOption {
+	return func(c *TCPClient) {
+		var address string
+		if c.tcpTransport != nil {
+			address = c.tcpTransport.Address()
+		}
+		c.BaseClient = c.BaseClient.clone(WithDeviceRegistry(registry, address))
 	}
 }
 
@@ -78,6 +93,8 @@ func (c *TCPClient) WithLogger(logger common.LoggerInterface) common.Client {
 func NewTCPClientFromTransport(t Transport, options ...TCPOption) *TCPClient {
 	bridge := newTransportBridge(t)
 	baseClient := NewBaseClient(bridge)
+	bridge.events = baseClient.events
+	bridge.hooks = baseClient.hooks
 
 	client := &TCPClient{
 		BaseClient:      baseClient,
@@ -100,6 +117,25 @@ func (c *TCPClient) Close() error {
 	return nil
 }
 
+// PendingTransactions returns the number of requests awaiting a response.
+// It is always 0 for clients created via NewTCPClientFromTransport.
+func (c *TCPClient) PendingTransactions() int {
+	if c.tcpTransport == nil {
+		return 0
+	}
+	return c.tcpTransport.PendingTransactions()
+}
+
+// TransportStats returns request latency and socket statistics of the
+// underlying TCP transport. It is empty for clients created via
+// NewTCPClientFromTransport.
+func (c *TCPClient) TransportStats() transport.Stats {
+	if c.tcpTransport == nil {
+		return transport.Stats{}
+	}
+	return c.tcpTransport.Stats()
+}
+
 // FromReaderWriter creates a new client that reads from the given reader and writes to the given writer
 // This is useful for testing or for using custom transports
 func FromReaderWriter(reader io.Reader, writer io.Writer) *TCPClient {
@@ -2,7 +2,10 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 	"github.com/Moonlight-Companies/gomodbus/logging"
@@ -52,14 +55,71 @@ type transportBridge struct {
 	ct     Transport
 	logger common.LoggerInterface
 	mu     sync.Mutex
+
+	// Lifecycle events of the owning client, and the connection last used
+	// and its resolved address, to report reconnects and endpoint changes
+	events     *common.EventStream
+	last       common.Transport
+	lastRemote string
+
+	// Called when a reconnect reached a different address, see
+	// WithEndpointChangeConfirmation
+	onEndpointChange func()
+
+	// Connection state callbacks of the owning client
+	hooks *connectionHooks
+}
+
+// remoteAddresser is implemented by transports that know the resolved
+// address of their connection, such as transport.TCPTransport
+type remoteAddresser interface {
+	RemoteAddr() string
 }
 
 // Connect establishes a connection by calling Conn on the underlying Transport.
 func (b *transportBridge) Connect(ctx context.Context) error {
-	_, err := b.ct.Conn(ctx)
+	conn, err := b.ct.Conn(ctx)
+	if err == nil {
+		b.track(conn)
+	}
 	return err
 }
 
+// track records the connection in use and emits EventReconnected when it
+// replaced a connection that was lost, and EventEndpointChanged when the new
+// connection reached a different address
+func (b *transportBridge) track(conn common.Transport) {
+	b.mu.Lock()
+	if conn == b.last {
+		b.mu.Unlock()
+		return
+	}
+	var remote string
+	if addresser, ok := conn.(remoteAddresser); ok {
+		remote = addresser.RemoteAddr()
+	}
+	reconnected := b.last != nil
+	previous := b.lastRemote
+	b.last, b.lastRemote = conn, remote
+	b.mu.Unlock()
+
+	if reconnected && b.events != nil {
+		b.events.Emit(common.Event{Type: common.EventReconnected, RemoteAddr: remote})
+	}
+	if reconnected && b.hooks != nil {
+		b.hooks.up(context.Background())
+	}
+	if previous != "" && remote != "" && remote != previous {
+		b.logger.Warn(context.Background(), "Reconnected to %s, previously %s", remote, previous)
+		if b.onEndpointChange != nil {
+			b.onEndpointChange()
+		}
+		if b.events != nil {
+			b.events.Emit(common.Event{Type: common.EventEndpointChanged, RemoteAddr: remote, Detail: "was " + previous})
+		}
+	}
+}
+
 // Disconnect permanently closes the underlying Transport.
 func (b *transportBridge) Disconnect(ctx context.Context) error {
 	return b.ct.Close()
@@ -80,24 +140,73 @@ func (b *transportBridge) Send(ctx context.Context, request common.Request) (com
 	if err != nil {
 		return nil, err
 	}
+	b.track(conn)
 
 	resp, err := conn.Send(ctx, request)
 	if err != nil && !common.IsModbusError(err) {
-		b.mu.Lock()
-		resetErr := b.ct.Reset(conn)
-		b.mu.Unlock()
-		if resetErr != nil {
-			b.logger.Error(ctx, "Failed to reset transport: %v", resetErr)
-		}
+		b.lost(ctx, conn, err)
 	}
 	return resp, err
 }
 
+// Broadcast obtains the current transport via Conn and broadcasts the
+// request through it, if it implements common.Broadcaster
+func (b *transportBridge) Broadcast(ctx context.Context, request common.Request, turnaround time.Duration) error {
+	conn, err := b.ct.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	b.track(conn)
+
+	broadcaster, ok := conn.(common.Broadcaster)
+	if !ok {
+		return fmt.Errorf("transport %T cannot broadcast: %w", conn, errors.ErrUnsupported)
+	}
+	if err := broadcaster.Broadcast(ctx, request, turnaround); err != nil {
+		b.lost(ctx, conn, err)
+		return err
+	}
+	return nil
+}
+
+// lost resets the transport after err broke conn, and reports the
+// disconnection
+func (b *transportBridge) lost(ctx context.Context, conn common.Transport, err error) {
+	b.mu.Lock()
+	resetErr := b.ct.Reset(conn)
+	b.mu.Unlock()
+	if resetErr != nil {
+		b.logger.Error(ctx, "Failed to reset transport: %v", resetErr)
+	}
+	if b.events != nil {
+		b.events.Emit(common.Event{Type: common.EventDisconnected, Err: err})
+	}
+	if b.hooks != nil {
+		b.hooks.down(ctx, err)
+	}
+}
+
+// Endpoint returns the server address of the wrapped Transport, if it
+// implements common.Endpointer
+func (b *transportBridge) Endpoint() string {
+	if endpointer, ok := b.ct.(common.Endpointer); ok {
+		return endpointer.Endpoint()
+	}
+	return ""
+}
+
 // WithLogger returns a new transportBridge with the given logger.
 func (b *transportBridge) WithLogger(logger common.LoggerInterface) common.Transport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return &transportBridge{
-		ct:     b.ct,
-		logger: logger,
+		ct:               b.ct,
+		logger:           logger,
+		events:           b.events,
+		last:             b.last,
+		lastRemote:       b.lastRemote,
+		onEndpointChange: b.onEndpointChange,
+		hooks:            b.hooks,
 	}
 }
 
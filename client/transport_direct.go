@@ -12,11 +12,12 @@ import (
 
 // directTransport connects once and does not reconnect on failure.
 type directTransport struct {
-	mu     sync.Mutex
-	conn   common.Transport
-	closed bool
-	cfg    transportConfig
-	logger common.LoggerInterface
+	mu       sync.Mutex
+	conn     common.Transport
+	closed   bool
+	cfg      transportConfig
+	logger   common.LoggerInterface
+	endpoint string
 }
 
 // NewDirectTransport creates a transport that connects immediately and returns
@@ -39,9 +40,10 @@ func NewDirectTransport(ctx context.Context, host string, logger common.LoggerIn
 	}
 
 	dt := &directTransport{
-		conn:   tcpTransport,
-		cfg:    cfg,
-		logger: logger,
+		conn:     tcpTransport,
+		cfg:      cfg,
+		logger:   logger,
+		endpoint: tcpTransport.Endpoint(),
 	}
 
 	if cfg.onConnect != nil {
@@ -51,6 +53,11 @@ func NewDirectTransport(ctx context.Context, host string, logger common.LoggerIn
 	return dt, nil
 }
 
+// Endpoint returns the server address as host:port
+func (d *directTransport) Endpoint() string {
+	return d.endpoint
+}
+
 // Conn returns the pre-created transport or an error if the transport is closed
 // or has been reset.
 func (d *directTransport) Conn(ctx context.Context) (common.Transport, error) {
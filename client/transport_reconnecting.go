@@ -13,10 +13,11 @@ import (
 // reconnectingTransport creates connections lazily and re-creates them after
 // failures. It uses an RWMutex double-check locking pattern for efficiency.
 type reconnectingTransport struct {
-	host    string
-	tcpOpts []transport.TCPTransportOption
-	logger  common.LoggerInterface
-	cfg     transportConfig
+	host     string
+	endpoint string
+	tcpOpts  []transport.TCPTransportOption
+	logger   common.LoggerInterface
+	cfg      transportConfig
 
 	mu     sync.RWMutex
 	conn   common.Transport
@@ -25,6 +26,8 @@ type reconnectingTransport struct {
 
 // NewReconnectingTransport creates a transport that connects lazily and
 // reconnects on failure. The constructor never fails and never connects.
+// Every connection resolves host again, so a changed DNS record takes effect
+// on the next reconnect; clients report it as EventEndpointChanged.
 func NewReconnectingTransport(host string, logger common.LoggerInterface, transportOpts []TransportOption, tcpOpts []transport.TCPTransportOption) *reconnectingTransport {
 	if logger == nil {
 		logger = logging.NewLogger()
@@ -36,13 +39,19 @@ func NewReconnectingTransport(host string, logger common.LoggerInterface, transp
 	}
 
 	return &reconnectingTransport{
-		host:    host,
-		tcpOpts: tcpOpts,
-		logger:  logger,
-		cfg:     cfg,
+		host:     host,
+		endpoint: transport.NewTCPTransport(host, tcpOpts...).Endpoint(), // the port may come from tcpOpts
+		tcpOpts:  tcpOpts,
+		logger:   logger,
+		cfg:      cfg,
 	}
 }
 
+// Endpoint returns the server address as host:port
+func (r *reconnectingTransport) Endpoint() string {
+	return r.endpoint
+}
+
 // Conn returns the current transport or creates a new one if needed.
 func (r *reconnectingTransport) Conn(ctx context.Context) (common.Transport, error) {
 	// Fast path: read lock
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ForUnit returns a client for another unit behind the same connection, such
+// as one of the serial devices behind a Modbus TCP to RTU gateway. Requests
+// of all units share the transport, which matches responses by transaction
+// ID, and settings such as the rate limit; options, for example
+// WithRequestTimeout for a slow device, apply to the new client only. What
+// the client learns about its device (capabilities, chunk sizes) and its
+// RequestStats are its own. The connection stays owned by c: Disconnect on
+// the new client does nothing, and Connect only checks that the shared
+// connection is up.
+// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+func (c *BaseClient) ForUnit(unitID common.UnitID, options ...Option) *BaseClient {
+	own := func(u *BaseClient) {
+		u.capabilities = &capabilityCache{}
+		u.chunks = &chunkLimits{limits: c.ChunkLimits()}
+		u.stats = &requestStats{}
+		u.sharedConnection = true
+	}
+	return c.clone(append([]Option{WithUnitID(unitID), own}, options...)...)
+}
+
+// ForUnit returns a client for another unit sharing the connection of c, see
+// BaseClient.ForUnit. Closing or disconnecting it leaves the shared
+// connection open; close c when done with every unit.
+func (c *TCPClient) ForUnit(unitID common.UnitID, options ...Option) *TCPClient {
+	return &TCPClient{BaseClient: c.BaseClient.ForUnit(unitID, options...), tcpTransport: c.tcpTransport}
+}
+
+// RequestStats counts the request attempts of a client, retries included,
+// to tell the units behind a shared connection apart (see ForUnit)
+type RequestStats struct {
+	UnitID common.UnitID
+
+	Requests   uint64 // attempts sent
+	Responses  uint64 // normal responses
+	Exceptions uint64 // exception responses
+	Errors     uint64 // attempts without a response
+	Timeouts   uint64 // of Errors, those that timed out
+
+	// Latency of answered attempts
+	LastLatency    time.Duration
+	AverageLatency time.Duration
+	MaxLatency     time.Duration
+}
+
+// requestStats accumulates request outcomes for RequestStats
+type requestStats struct {
+	mu    sync.Mutex
+	stats RequestStats
+	total time.Duration
+}
+
+// record records an attempt that ended with response and err after latency
+func (s *requestStats) record(response common.Response, err error, latency time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Requests++
+	switch {
+	case err != nil:
+		s.stats.Errors++
+		if errors.Is(err, common.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			s.stats.Timeouts++
+		}
+		return
+	case response.IsException():
+		s.stats.Exceptions++
+	default:
+		s.stats.Responses++
+	}
+	s.total += latency
+	s.stats.LastLatency = latency
+	s.stats.MaxLatency = max(s.stats.MaxLatency, latency)
+	s.stats.AverageLatency = s.total / time.Duration(s.stats.Responses+s.stats.Exceptions)
+}
+
+// RequestStats returns the request counts of the client. Clones share them,
+// except those made with ForUnit.
+func (c *BaseClient) RequestStats() RequestStats {
+	if c.stats == nil {
+		return RequestStats{UnitID: c.unitID}
+	}
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	stats := c.stats.stats
+	stats.UnitID = c.unitID
+	return stats
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+func TestBaseClient_ForUnit(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		pdu := req.GetPDU()
+		switch req.GetUnitID() {
+		case 5:
+			return test.NewMockResponse(req.GetTransactionID(), 5, pdu.FunctionCode, []byte{0x02, 0x00, 0x05}), nil
+		case 6:
+			return test.NewMockResponse(req.GetTransactionID(), 6, pdu.FunctionCode|0x80, []byte{byte(common.ExceptionDataAddressNotAvailable)}), nil
+		}
+		return nil, common.ErrTimeout
+	})
+	gateway := NewBaseClient(mockTransport, WithRequestTimeout(time.Second))
+	ctx := context.Background()
+	gateway.Connect(ctx)
+
+	meter := gateway.ForUnit(5)
+	drive := gateway.ForUnit(6, WithRequestTimeout(100*time.Millisecond))
+	silent := gateway.ForUnit(7)
+
+	if values, err := meter.ReadHoldingRegisters(ctx, 0, 1); err != nil || values[0] != 5 {
+		t.Errorf("Expected unit 5 to answer 5, got %v (%v)", values, err)
+	}
+	if _, err := drive.ReadHoldingRegisters(ctx, 0, 1); !common.IsModbusError(err) {
+		t.Errorf("Expected an exception from unit 6, got %v", err)
+	}
+	if _, err := silent.ReadHoldingRegisters(ctx, 0, 1); !errors.Is(err, common.ErrTimeout) {
+		t.Errorf("Expected a timeout from unit 7, got %v", err)
+	}
+
+	// Each unit keeps its own statistics and timeout on the shared transport
+	if s := meter.RequestStats(); s.UnitID != 5 || s.Requests != 1 || s.Responses != 1 {
+		t.Errorf("Unexpected unit 5 stats: %+v", s)
+	}
+	if s := drive.RequestStats(); s.UnitID != 6 || s.Requests != 1 || s.Exceptions != 1 {
+		t.Errorf("Unexpected unit 6 stats: %+v", s)
+	}
+	if s := silent.RequestStats(); s.Errors != 1 || s.Timeouts != 1 {
+		t.Errorf("Unexpected unit 7 stats: %+v", s)
+	}
+	if s := gateway.RequestStats(); s.Requests != 0 {
+		t.Errorf("Expected no requests counted for the gateway client, got %+v", s)
+	}
+	if drive.requestTimeout != 100*time.Millisecond || meter.requestTimeout != time.Second {
+		t.Errorf("Expected per-unit timeouts, got %v and %v", drive.requestTimeout, meter.requestTimeout)
+	}
+	if len(mockTransport.GetRequests()) != 3 {
+		t.Errorf("Expected all units to use the shared transport, got %d requests", len(mockTransport.GetRequests()))
+	}
+
+	// Unit clients do not own the shared connection
+	if err := meter.Disconnect(ctx); err != nil || !gateway.IsConnected() {
+		t.Errorf("Expected Disconnect on a unit client to leave the connection up, got %v", err)
+	}
+	if err := meter.Connect(ctx); err != nil {
+		t.Errorf("Expected Connect on a unit client to succeed while connected, got %v", err)
+	}
+	gateway.Disconnect(ctx)
+	if err := meter.Connect(ctx); !errors.Is(err, common.ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected from a unit client once the gateway disconnected, got %v", err)
+	}
+}
+
+func TestTCPClient_ForUnit(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	gateway := NewTCPClient("127.0.0.1", transport.WithPort(listener.Addr().(*net.TCPAddr).Port))
+	unit := gateway.ForUnit(9)
+	if unit.tcpTransport != gateway.tcpTransport || unit.transport != gateway.transport {
+		t.Error("Expected the unit client to share the gateway's transport")
+	}
+	if unit.unitID != 9 || gateway.unitID != 0 {
+		t.Errorf("Expected unit IDs 9 and 0, got %d and %d", unit.unitID, gateway.unitID)
+	}
+
+	ctx := context.Background()
+	if err := gateway.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer gateway.Disconnect(ctx)
+	unit.Disconnect(ctx)
+	if err := unit.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if !gateway.IsConnected() || !unit.IsConnected() {
+		t.Error("Expected the gateway client to stay connected after closing a unit client")
+	}
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/values"
+)
+
+// WithWordOrder sets the order of the words and bytes of multi-register
+// values for the typed helpers, such as ReadFloat32, given an empty order,
+// struct tags without one (ReadInto, WriteFrom) and counter readers. Set it
+// per device, for example values.WordSwap for a meter storing the low word
+// first, so call sites need no conversions. The default is big-endian.
+func WithWordOrder(order values.Order) Option {
+	return func(c *BaseClient) {
+		c.wordOrder = order
+	}
+}
+
+// WordOrder returns the order set with WithWordOrder
+func (c *BaseClient) WordOrder() values.Order {
+	return c.wordOrder
+}
+
+// orderOf returns order, or the client's when it is empty
+func (c *BaseClient) orderOf(order values.Order) values.Order {
+	if order == "" {
+		return c.wordOrder
+	}
+	return order
+}
+
+// readValue reads the holding registers of one value and decodes them. The
+// Read and Write helpers take the order of the value, or "" for the
+// client's (see WithWordOrder).
+func readValue[T any](ctx context.Context, c *BaseClient, address common.Address, words int, order values.Order,
+	decode func([]common.RegisterValue, values.Order) (T, error)) (T, error) {
+	var zero T
+	order = c.orderOf(order)
+	if err := order.Validate(); err != nil {
+		return zero, err
+	}
+	registers, err := c.ReadHoldingRegisters(ctx, address, common.Quantity(words))
+	if err != nil {
+		return zero, err
+	}
+	return decode(registers, order)
+}
+
+// writeValue encodes a value and writes it in one Write Multiple Registers
+// request, so the device never sees half of it
+func writeValue(ctx context.Context, c *BaseClient, address common.Address, registers []common.RegisterValue, err error) error {
+	if err != nil {
+		return err
+	}
+	return c.WriteMultipleRegisters(ctx, address, registers)
+}
+
+// ReadUint32 reads an unsigned 32-bit integer from the two holding registers
+// at address, stored in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
+func (c *BaseClient) ReadUint32(ctx context.Context, address common.Address, order values.Order) (uint32, error) {
+	return readValue(ctx, c, address, 2, order, values.Uint32)
+}
+
+// ReadInt32 reads a signed 32-bit integer from the two holding registers at
+// address, stored in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
+func (c *BaseClient) ReadInt32(ctx context.Context, address common.Address, order values.Order) (int32, error) {
+	return readValue(ctx, c, address, 2, order, values.Int32)
+}
+
+// ReadFloat32 reads an IEEE 754 single precision float from the two holding
+// registers at address, stored in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
+func (c *BaseClient) ReadFloat32(ctx context.Context, address common.Address, order values.Order) (float32, error) {
+	return readValue(ctx, c, address, 2, order, values.Float32)
+}
+
+// ReadUint64 reads an unsigned 64-bit integer from the four holding
+// registers at address, stored in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
+func (c *BaseClient) ReadUint64(ctx context.Context, address common.Address, order values.Order) (uint64, error) {
+	return readValue(ctx, c, address, 4, order, values.Uint64)
+}
+
+// ReadInt64 reads a signed 64-bit integer from the four holding registers at
+// address, stored in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
+func (c *BaseClient) ReadInt64(ctx context.Context, address common.Address, order values.Order) (int64, error) {
+	return readValue(ctx, c, address, 4, order, values.Int64)
+}
+
+// ReadFloat64 reads an IEEE 754 double precision float from the four holding
+// registers at address, stored in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
+func (c *BaseClient) ReadFloat64(ctx context.Context, address common.Address, order values.Order) (float64, error) {
+	return readValue(ctx, c, address, 4, order, values.Float64)
+}
+
+// WriteUint32 writes an unsigned 32-bit integer to the two holding registers
+// at address, in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteUint32(ctx context.Context, address common.Address, value uint32, order values.Order) error {
+	registers, err := values.FromUint32(value, c.orderOf(order))
+	return writeValue(ctx, c, address, registers, err)
+}
+
+// WriteInt32 writes a signed 32-bit integer to the two holding registers at
+// address, in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteInt32(ctx context.Context, address common.Address, value int32, order values.Order) error {
+	registers, err := values.FromInt32(value, c.orderOf(order))
+	return writeValue(ctx, c, address, registers, err)
+}
+
+// WriteFloat32 writes an IEEE 754 single precision float to the two holding
+// registers at address, in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteFloat32(ctx context.Context, address common.Address, value float32, order values.Order) error {
+	registers, err := values.FromFloat32(value, c.orderOf(order))
+	return writeValue(ctx, c, address, registers, err)
+}
+
+// WriteUint64 writes an unsigned 64-bit integer to the four holding
+// registers at address, in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteUint64(ctx context.Context, address common.Address, value uint64, order values.Order) error {
+	registers, err := values.FromUint64(value, c.orderOf(order))
+	return writeValue(ctx, c, address, registers, err)
+}
+
+// WriteInt64 writes a signed 64-bit integer to the four holding registers at
+// address, in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteInt64(ctx context.Context, address common.Address, value int64, order values.Order) error {
+	registers, err := values.FromInt64(value, c.orderOf(order))
+	return writeValue(ctx, c, address, registers, err)
+}
+
+// WriteFloat64 writes an IEEE 754 double precision float to the four holding
+// registers at address, in the given order, or the client's if empty
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func (c *BaseClient) WriteFloat64(ctx context.Context, address common.Address, value float64, order values.Order) error {
+	registers, err := values.FromFloat64(value, c.orderOf(order))
+	return writeValue(ctx, c, address, registers, err)
+}
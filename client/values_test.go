@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+	"github.com/Moonlight-Companies/gomodbus/values"
+)
+
+func TestBaseClient_TypedValues(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport)
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	if err := client.WriteFloat32(ctx, 100, 10, values.WordSwap); err != nil {
+		t.Fatalf("WriteFloat32 failed: %v", err)
+	}
+	if registers[100] != 0x0000 || registers[101] != 0x4120 {
+		t.Errorf("Expected CDAB registers 0000 4120, got %04X %04X", registers[100], registers[101])
+	}
+	if v, err := client.ReadFloat32(ctx, 100, values.WordSwap); err != nil || v != 10 {
+		t.Errorf("Expected 10, got %v (%v)", v, err)
+	}
+
+	if err := client.WriteInt64(ctx, 200, -2, values.LittleEndian); err != nil {
+		t.Fatalf("WriteInt64 failed: %v", err)
+	}
+	if v, err := client.ReadInt64(ctx, 200, values.LittleEndian); err != nil || v != -2 {
+		t.Errorf("Expected -2, got %v (%v)", v, err)
+	}
+	if v, err := client.ReadUint32(ctx, 202, values.BigEndian); err != nil || v != 0xFFFFFFFF {
+		t.Errorf("Expected the high words of -2, got 0x%X (%v)", v, err)
+	}
+
+	// Each value is one read or one write
+	if len(requests) != 5 || requests[0] != common.FuncWriteMultipleRegisters || requests[1] != common.FuncReadHoldingRegisters {
+		t.Errorf("Expected one request per value, got %v", requests)
+	}
+
+	// An unknown order fails without a request
+	if _, err := client.ReadFloat64(ctx, 0, "dcba"); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+	if err := client.WriteUint64(ctx, 0, 1, "dcba"); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+	if len(requests) != 5 {
+		t.Errorf("Expected no requests for an unknown order, got %v", requests[5:])
+	}
+}
+
+func TestBaseClient_WithWordOrder(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithWordOrder(values.LittleEndian))
+	registers := map[uint16]uint16{}
+	var requests []common.FunctionCode
+	mockTransport.SetHandler(registerDevice(registers, &requests))
+	ctx := context.Background()
+	client.Connect(ctx)
+
+	// An empty order is the client's, an explicit one overrides it
+	if err := client.WriteUint32(ctx, 0, 0x11223344, ""); err != nil {
+		t.Fatalf("WriteUint32 failed: %v", err)
+	}
+	if registers[0] != 0x4433 || registers[1] != 0x2211 {
+		t.Errorf("Expected DCBA registers 4433 2211, got %04X %04X", registers[0], registers[1])
+	}
+	if v, err := client.ReadUint32(ctx, 0, values.BigEndian); err != nil || v != 0x44332211 {
+		t.Errorf("Expected 0x44332211 read big-endian, got 0x%X (%v)", v, err)
+	}
+
+	// Struct tags without an order use the client's; single registers stay
+	// big-endian
+	var device struct {
+		Total  uint32 `modbus:"0"`
+		Raw    uint32 `modbus:"2,uint32,be"`
+		Status uint16 `modbus:"4"`
+	}
+	registers[2], registers[3], registers[4] = 0x0001, 0x0002, 0x0102
+	if err := client.ReadInto(ctx, 0, &device); err != nil {
+		t.Fatalf("ReadInto failed: %v", err)
+	}
+	if device.Total != 0x11223344 || device.Raw != 0x00010002 || device.Status != 0x0102 {
+		t.Errorf("Expected 11223344, 00010002, 0102, got %X, %08X, %04X", device.Total, device.Raw, device.Status)
+	}
+
+	// Clones keep the order
+	if clone := client.clone(); clone.WordOrder() != values.LittleEndian {
+		t.Errorf("Expected the clone to keep the order, got %q", clone.WordOrder())
+	}
+
+	// Counter readers default to it too
+	reader, err := client.NewCounterReader(0, 32)
+	if err != nil {
+		t.Fatalf("NewCounterReader failed: %v", err)
+	}
+	if _, total, err := reader.Poll(ctx); err != nil || total != 0x11223344 {
+		t.Errorf("Expected the counter read little-endian, got 0x%X (%v)", total, err)
+	}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Default settings of WatchRegisters
+const (
+	DefaultWatchWait         = 10 * time.Second
+	DefaultWatchPollInterval = time.Second
+)
+
+// RegisterChange is the value of watched registers after a change. Err is
+// set on the last change of a watch that failed, and Values is then empty.
+type RegisterChange struct {
+	Address common.Address
+	Values  []common.RegisterValue
+	Err     error
+}
+
+// WatchOption is a function that configures WatchRegisters
+type WatchOption func(*watchConfig)
+
+// watchConfig holds the settings applied by WatchOptions
+type watchConfig struct {
+	wait     time.Duration
+	interval time.Duration
+}
+
+// WithWatchWait sets how long the server holds a watch request open when
+// nothing changes (default 10s, at most 65s). The request timeout is added
+// to it for the response to arrive.
+func WithWatchWait(wait time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.wait = min(max(wait, time.Millisecond), time.Duration(0xFFFF)*time.Millisecond)
+	}
+}
+
+// WithWatchPollInterval sets how often a device not supporting watches is
+// read instead (default 1s)
+func WithWatchPollInterval(interval time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WatchRegisters reports changes of quantity holding or input registers
+// starting at address, with the current values first. Against a server
+// with server.WithChangeNotifications the registers are watched with
+// common.FuncWatchRegisters, a long-poll the server answers once they
+// change, so an idle range costs one request per wait instead of one per
+// poll. Devices answering the watch with Illegal Function, as third-party
+// devices do, are polled with normal reads instead. Ranges are watched
+// independently, each by its own iterator, and share the connection with
+// other requests. The iterator ends after a change carrying an error, when
+// ctx is done or when the loop breaks.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 5 (Function Code Categories, user defined)
+func (c *BaseClient) WatchRegisters(ctx context.Context, table common.Table, address common.Address, quantity common.Quantity, options ...WatchOption) iter.Seq[RegisterChange] {
+	cfg := watchConfig{wait: DefaultWatchWait, interval: DefaultWatchPollInterval}
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	return func(yield func(RegisterChange) bool) {
+		var read func(context.Context, common.Address, common.Quantity) ([]common.RegisterValue, error)
+		var readFunction common.FunctionCode
+		switch table {
+		case common.TableHoldingRegisters:
+			read, readFunction = c.ReadHoldingRegisters, common.FuncReadHoldingRegisters
+		case common.TableInputRegisters:
+			read, readFunction = c.ReadInputRegisters, common.FuncReadInputRegisters
+		default:
+			yield(RegisterChange{Address: address, Err: fmt.Errorf("watching %s: %w", table, common.ErrInvalidValue)})
+			return
+		}
+		if quantity == 0 || quantity > common.MaxWatchRegisterCount || int(address)+int(quantity) > 0x10000 {
+			yield(RegisterChange{Address: address, Err: fmt.Errorf("watch of %d registers at %d: %w",
+				quantity, address, common.ErrInvalidQuantity)})
+			return
+		}
+
+		polling := false
+		values, err := read(ctx, address, quantity)
+		for ctx.Err() == nil {
+			if !yield(RegisterChange{Address: address, Values: values, Err: err}) || err != nil {
+				return
+			}
+
+			// Wait for the next change
+			var next []common.RegisterValue
+			for ctx.Err() == nil && err == nil && (next == nil || slices.Equal(next, values)) {
+				if polling {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(cfg.interval):
+					}
+					next, err = read(ctx, address, quantity)
+					continue
+				}
+				next, err = c.watchRegisters(ctx, readFunction, address, values, cfg.wait)
+				if common.IsFunctionNotSupportedError(err) {
+					c.logger.Info(ctx, "Device does not support watches, polling registers %d-%d every %v",
+						address, int(address)+int(quantity)-1, cfg.interval)
+					polling, next, err = true, nil, nil
+				}
+			}
+			values = next
+		}
+	}
+}
+
+// watchRegisters sends one watch request and returns the values it is
+// answered with
+func (c *BaseClient) watchRegisters(ctx context.Context, readFunction common.FunctionCode, address common.Address,
+	lastSeen []common.RegisterValue, wait time.Duration) ([]common.RegisterValue, error) {
+	data := make([]byte, 7, 7+2*len(lastSeen))
+	data[0] = byte(readFunction)
+	binary.BigEndian.PutUint16(data[1:3], uint16(address))
+	binary.BigEndian.PutUint16(data[3:5], uint16(len(lastSeen)))
+	binary.BigEndian.PutUint16(data[5:7], uint16(wait/time.Millisecond))
+	for _, value := range lastSeen {
+		data = binary.BigEndian.AppendUint16(data, value)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait+c.requestTimeout)
+	defer cancel()
+	response, err := c.Send(ctx, common.FuncWatchRegisters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Byte count (1 byte) and the values, as in a read response
+	pdu := response.GetPDU().Data
+	if len(pdu) != 1+2*len(lastSeen) || int(pdu[0]) != 2*len(lastSeen) {
+		return nil, fmt.Errorf("watch response of %d bytes for %d registers: %w",
+			len(pdu), len(lastSeen), common.ErrInvalidResponseLength)
+	}
+	values := make([]common.RegisterValue, len(lastSeen))
+	for i := range values {
+		values[i] = binary.BigEndian.Uint16(pdu[1+2*i:])
+	}
+	return values, nil
+}
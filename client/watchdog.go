@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// WatchdogPattern returns the value written by the n-th heartbeat of a
+// watchdog, counting from 0
+type WatchdogPattern func(beat uint64) common.RegisterValue
+
+var (
+	// WatchdogToggle alternates 0 and 1
+	WatchdogToggle WatchdogPattern = func(beat uint64) common.RegisterValue {
+		return common.RegisterValue(beat % 2)
+	}
+
+	// WatchdogCounter counts 0, 1, 2, ... wrapping after 65535
+	WatchdogCounter WatchdogPattern = func(beat uint64) common.RegisterValue {
+		return common.RegisterValue(beat)
+	}
+)
+
+// WatchdogConstant writes value on every beat, for devices that reset their
+// timer on any write
+func WatchdogConstant(value common.RegisterValue) WatchdogPattern {
+	return func(uint64) common.RegisterValue {
+		return value
+	}
+}
+
+// WatchdogMiss describes a heartbeat that was not written
+type WatchdogMiss struct {
+	Beat        uint64 // index of the beat, counting from 0
+	Value       common.RegisterValue
+	Consecutive uint64 // missed beats in a row, including this one
+	Err         error
+}
+
+// WatchdogStats are the counters of a watchdog
+type WatchdogStats struct {
+	Beats       uint64 // heartbeats written
+	Missed      uint64 // heartbeats that failed
+	Consecutive uint64 // heartbeats failed since the last one written
+	LastBeat    time.Time
+	LastErr     error
+}
+
+// WatchdogOption is a function that configures StartWatchdog
+type WatchdogOption func(*Watchdog)
+
+// WithWatchdogOnMiss calls fn for every missed heartbeat, from the
+// watchdog's goroutine
+func WithWatchdogOnMiss(fn func(WatchdogMiss)) WatchdogOption {
+	return func(w *Watchdog) {
+		w.onMiss = fn
+	}
+}
+
+// Watchdog writes a heartbeat register periodically, see StartWatchdog
+type Watchdog struct {
+	client   *BaseClient
+	address  common.Address
+	pattern  WatchdogPattern
+	interval time.Duration
+	onMiss   func(WatchdogMiss)
+
+	mu    sync.Mutex
+	stats WatchdogStats
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartWatchdog writes pattern to the holding register at address every
+// interval, for devices that fail safe when their master stops writing a
+// heartbeat. Each write must complete within interval; a failed write is a
+// missed beat, reported with EventWatchdogMissed, WithWatchdogOnMiss and
+// Stats, and the pattern still advances so the next beat changes the value.
+// The watchdog survives connection loss: a disconnected client is
+// reconnected before the next beat. It runs until ctx is done or Stop is
+// called. Designate the register with WithFastLane so heartbeats do not
+// queue behind rate-limited polling. The interval must be positive.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.6 (Write Single Register)
+func (c *BaseClient) StartWatchdog(ctx context.Context, address common.Address, pattern WatchdogPattern, interval time.Duration, options ...WatchdogOption) (*Watchdog, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("watchdog at %d: interval must be positive: %w", address, common.ErrInvalidValue)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &Watchdog{
+		client:   c,
+		address:  address,
+		pattern:  pattern,
+		interval: interval,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(w)
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+// Stop stops the watchdog and waits for a heartbeat in flight
+func (w *Watchdog) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+// Done is closed when the watchdog has stopped
+func (w *Watchdog) Done() <-chan struct{} {
+	return w.done
+}
+
+// Stats returns the counters of the watchdog
+func (w *Watchdog) Stats() WatchdogStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// run writes the heartbeats until ctx is done
+func (w *Watchdog) run(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for beat := uint64(0); ; beat++ {
+		w.beat(ctx, beat)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// beat writes one heartbeat and records the outcome
+func (w *Watchdog) beat(ctx context.Context, beat uint64) {
+	c := w.client
+	value := w.pattern(beat)
+	writeCtx, cancel := context.WithTimeout(ctx, w.interval)
+	defer cancel()
+
+	var err error
+	if !c.IsConnected() {
+		c.logger.Warn(ctx, "Watchdog reconnecting before beat %d", beat)
+		err = c.Connect(writeCtx)
+	}
+	if err == nil {
+		err = c.WriteSingleRegister(writeCtx, w.address, value)
+	}
+	if ctx.Err() != nil {
+		return // stopped, not missed
+	}
+
+	w.mu.Lock()
+	if err == nil {
+		w.stats.Beats++
+		w.stats.Consecutive = 0
+		w.stats.LastBeat = time.Now()
+		w.mu.Unlock()
+		return
+	}
+	w.stats.Missed++
+	w.stats.Consecutive++
+	w.stats.LastErr = err
+	miss := WatchdogMiss{Beat: beat, Value: value, Consecutive: w.stats.Consecutive, Err: err}
+	w.mu.Unlock()
+
+	c.logger.Error(ctx, "Watchdog missed beat %d at address %d (%d in a row): %v", beat, w.address, miss.Consecutive, err)
+	c.events.Emit(common.Event{
+		Type:     common.EventWatchdogMissed,
+		Device:   c.DeviceName(),
+		Endpoint: c.Endpoint(),
+		Detail:   fmt.Sprintf("address=%d beat=%d consecutive=%d", w.address, beat, miss.Consecutive),
+		Err:      err,
+	})
+	if w.onMiss != nil {
+		w.onMiss(miss)
+	}
+}
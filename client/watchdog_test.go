@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestBaseClient_Watchdog(t *testing.T) {
+	mockTransport := test.NewMockTransport()
+	client := NewBaseClient(mockTransport, WithUnitID(1))
+
+	var mu sync.Mutex
+	var written []common.RegisterValue
+	mockTransport.SetHandler(func(req common.Request) (common.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		data := req.GetPDU().Data
+		value := binary.BigEndian.Uint16(data[2:4])
+		written = append(written, value)
+		if len(written) == 3 {
+			return nil, errors.New("connection reset")
+		}
+		return test.NewMockResponse(1, 1, common.FuncWriteSingleRegister, data), nil
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	events := client.Events()
+
+	misses := make(chan WatchdogMiss, 10)
+	if _, err := client.StartWatchdog(ctx, 40, WatchdogCounter, 0); !errors.Is(err, common.ErrInvalidValue) {
+		t.Fatalf("Expected ErrInvalidValue for a zero interval, got %v", err)
+	}
+	w, err := client.StartWatchdog(ctx, 40, WatchdogCounter, 5*time.Millisecond,
+		WithWatchdogOnMiss(func(m WatchdogMiss) { misses <- m }))
+	if err != nil {
+		t.Fatalf("StartWatchdog failed: %v", err)
+	}
+
+	miss := <-misses
+	if miss.Beat != 2 || miss.Value != 2 || miss.Consecutive != 1 || miss.Err == nil {
+		t.Errorf("Unexpected miss: %+v", miss)
+	}
+	for deadline := time.Now().Add(5 * time.Second); w.Stats().Beats < 4; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the watchdog to keep beating after a miss, got %+v", w.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	w.Stop()
+	select {
+	case <-w.Done():
+	default:
+		t.Error("Expected Done to be closed after Stop")
+	}
+
+	stats := w.Stats()
+	if stats.Missed != 1 || stats.Consecutive != 0 || stats.LastErr == nil || stats.LastBeat.IsZero() {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	mu.Lock()
+	if !slices.Equal(written[:5], []common.RegisterValue{0, 1, 2, 3, 4}) {
+		t.Errorf("Expected the counter to advance past the miss, wrote %v", written)
+	}
+	mu.Unlock()
+
+	for event := range events {
+		if event.Type == common.EventWatchdogMissed {
+			if event.Err == nil || event.Detail != "address=40 beat=2 consecutive=1" {
+				t.Errorf("Unexpected event: %v", event)
+			}
+			break
+		}
+	}
+}
+
+func TestWatchdogPatterns(t *testing.T) {
+	for name, tc := range map[string]struct {
+		pattern WatchdogPattern
+		want    []common.RegisterValue
+	}{
+		"toggle":   {WatchdogToggle, []common.RegisterValue{0, 1, 0, 1}},
+		"counter":  {WatchdogCounter, []common.RegisterValue{0, 1, 2, 3}},
+		"constant": {WatchdogConstant(0x55AA), []common.RegisterValue{0x55AA, 0x55AA, 0x55AA, 0x55AA}},
+	} {
+		var got []common.RegisterValue
+		for beat := uint64(0); beat < 4; beat++ {
+			got = append(got, tc.pattern(beat))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, got)
+		}
+	}
+	if WatchdogCounter(65536) != 0 {
+		t.Error("Expected the counter to wrap")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"sync"
@@ -23,10 +24,51 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	names := flag.String("names", "", "file of device names, lines of \"address unit name\"")
+	diagnose := flag.Bool("diagnose", false, "run a diagnostic of host[:port] and print a JSON report")
+	status := flag.Bool("status", false, "read and print the exception status")
+	statusLabels := flag.String("status-labels", "", "names of the exception status bits, e.g. \"0=Overtemp,3=Door open\"")
+	flag.Parse()
+
+	labels, err := common.ParseExceptionStatusLabels(*statusLabels)
+	if err != nil {
+		fmt.Printf("Invalid -status-labels: %v\n", err)
+		return
+	}
+
 	// Get server host from command line or use default
 	host := "localhost"
-	if len(os.Args) > 1 {
-		host = os.Args[1]
+	if flag.NArg() > 0 {
+		host = flag.Arg(0)
+	}
+
+	if *diagnose {
+		report, err := client.Diagnose(ctx, host, client.WithDiagnoseUnitID(1))
+		if err != nil {
+			fmt.Printf("Failed to run diagnostic: %v\n", err)
+			os.Exit(2)
+		}
+		report.WriteJSON(os.Stdout)
+		if !report.OK() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load device names, if given, to label output and logs
+	registry := common.NewDeviceRegistry()
+	if *names != "" {
+		f, err := os.Open(*names)
+		if err != nil {
+			fmt.Printf("Failed to open device names: %v\n", err)
+			return
+		}
+		registry, err = common.ParseDeviceNames(f)
+		f.Close()
+		if err != nil {
+			fmt.Printf("Failed to read device names: %v\n", err)
+			return
+		}
 	}
 
 	// Create a TCP client with options
@@ -38,17 +80,28 @@ func main() {
 	).WithOptions(
 		client.WithTCPUnitID(1),
 		client.WithTCPLogger(logger),
+		client.WithTCPDeviceRegistry(registry),
+		client.WithTCPBaseOptions(client.WithExceptionStatusLabels(labels)),
 	)
 
 	// Connect to the server
-	err := modbusClient.Connect(ctx)
+	err = modbusClient.Connect(ctx)
 	if err != nil {
 		fmt.Printf("Failed to connect: %v\n", err)
 		return
 	}
 	defer modbusClient.Disconnect(context.Background())
 
-	fmt.Println("Connected to Modbus server")
+	fmt.Printf("Connected to Modbus server %s\n", modbusClient.DeviceName())
+
+	if *status {
+		exceptionStatus, err := modbusClient.ReadLabeledExceptionStatus(ctx)
+		if err != nil {
+			fmt.Printf("Failed to read exception status: %v\n", err)
+		} else {
+			fmt.Println(exceptionStatus)
+		}
+	}
 
 	// Use a wait group to wait for all goroutines to complete
 	var wg sync.WaitGroup
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Moonlight-Companies/gomodbus/ports"
+)
+
+func main() {
+	// Parse command line flags
+	usbOnly := flag.Bool("usb", false, "Only list USB serial adapters")
+	vidpid := flag.String("id", "", "Only list USB adapters matching VID[:PID] in hex, e.g. 0403:6001")
+	flag.Parse()
+
+	// Enumerate the serial ports
+	available, err := ports.List()
+	if err != nil {
+		fmt.Println("Error listing serial ports:", err)
+		os.Exit(1)
+	}
+
+	// Apply the filters
+	if *vidpid != "" {
+		vid, pid, err := parseVIDPID(*vidpid)
+		if err != nil {
+			fmt.Println("Invalid -id:", err)
+			os.Exit(1)
+		}
+		available = ports.FilterUSB(available, vid, pid)
+	} else if *usbOnly {
+		var usb []ports.PortInfo
+		for _, p := range available {
+			if p.IsUSB {
+				usb = append(usb, p)
+			}
+		}
+		available = usb
+	}
+
+	if len(available) == 0 {
+		fmt.Println("No serial ports found")
+		return
+	}
+
+	for i, p := range available {
+		fmt.Printf("%2d) %s\n", i+1, p)
+		if p.Manufacturer != "" {
+			fmt.Printf("    Manufacturer: %s\n", p.Manufacturer)
+		}
+	}
+}
+
+// parseVIDPID parses "VID" or "VID:PID" in hex
+func parseVIDPID(s string) (uint16, uint16, error) {
+	vidStr, pidStr, _ := strings.Cut(s, ":")
+
+	vid, err := strconv.ParseUint(vidStr, 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var pid uint64
+	if pidStr != "" {
+		if pid, err = strconv.ParseUint(pidStr, 16, 16); err != nil {
+			return 0, 0, err
+		}
+	}
+	return uint16(vid), uint16(pid), nil
+}
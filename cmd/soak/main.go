@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/harness"
+)
+
+func main() {
+	// Parse command line flags
+	transactions := flag.Int("n", 1000000, "Number of transactions to run")
+	concurrency := flag.Int("c", harness.DefaultSoakConcurrency, "Number of concurrent workers")
+	resetEvery := flag.Int("reset-every", 50000, "Disconnect and reconnect the client after this many transactions (0 to disable)")
+	slack := flag.Int("goroutine-slack", harness.DefaultSoakGoroutineSlack, "Goroutines tolerated above the baseline after the run")
+	maxHeap := flag.Uint64("max-heap-growth", harness.DefaultSoakMaxHeapGrowth, "Bytes the live heap may grow over the run")
+	flag.Parse()
+
+	// Stop early on interrupt; the run still reports what it has done
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	lb, err := harness.NewLoopback(nil)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	defer lb.Stop()
+
+	fmt.Printf("Soaking %d transactions with %d workers on port %d\n", *transactions, *concurrency, lb.Port)
+
+	report, err := harness.RunSoak(ctx, lb, harness.SoakConfig{
+		Transactions:   *transactions,
+		Concurrency:    *concurrency,
+		ResetEvery:     *resetEvery,
+		GoroutineSlack: *slack,
+		MaxHeapGrowth:  *maxHeap,
+		Progress: func(r harness.SoakReport) {
+			rate := float64(r.Transactions) / r.Duration.Seconds()
+			fmt.Printf("  %d/%d transactions, %d errors, %d resets, %.0f tx/s\n",
+				r.Transactions, *transactions, r.Errors, r.Resets, rate)
+		},
+	})
+
+	fmt.Println(report)
+	if err != nil {
+		fmt.Println("FAIL:", err)
+		lb.Stop()
+		os.Exit(1)
+	}
+	fmt.Printf("PASS (%v)\n", report.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// PackBits packs values into dst, eight per byte with the lowest index in
+// the least significant bit, the layout of coils and discrete inputs on the
+// wire. dst is reused when large enough.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.1 (Read Coils)
+func PackBits(dst []byte, values []bool) []byte {
+	n := (len(values) + 7) / 8
+	if cap(dst) < n {
+		dst = make([]byte, n)
+	}
+	dst = dst[:n]
+	clear(dst)
+	for i, value := range values {
+		if value {
+			dst[i/8] |= 1 << (i % 8)
+		}
+	}
+	return dst
+}
+
+// ChangedBits appends to dst the indices, in increasing order, of the bits
+// that differ between the packed images a and b within the first quantity
+// bits. Bits beyond the shorter image count as zero. The images are compared
+// eight bytes at a time, so unchanged stretches cost one XOR per 64 bits.
+func ChangedBits(dst []int, a, b []byte, quantity int) []int {
+	n := (quantity + 7) / 8
+	a, b = padBits(a, n), padBits(b, n)
+
+	i := 0
+	for ; i+8 <= n; i += 8 {
+		x := binary.LittleEndian.Uint64(a[i:]) ^ binary.LittleEndian.Uint64(b[i:])
+		for x != 0 {
+			dst = append(dst, i*8+bits.TrailingZeros64(x))
+			x &= x - 1
+		}
+	}
+	for ; i < n; i++ {
+		x := a[i] ^ b[i]
+		for x != 0 {
+			dst = append(dst, i*8+bits.TrailingZeros8(x))
+			x &= x - 1
+		}
+	}
+
+	// Drop padding bits of the last byte
+	for len(dst) > 0 && dst[len(dst)-1] >= quantity {
+		dst = dst[:len(dst)-1]
+	}
+	return dst
+}
+
+// padBits returns image extended with zero bytes to n bytes
+func padBits(image []byte, n int) []byte {
+	if len(image) >= n {
+		return image
+	}
+	padded := make([]byte, n)
+	copy(padded, image)
+	return padded
+}
+
+// BitDiffer detects changes between successive reads of the same range of
+// coils or discrete inputs, for polling large images at high frequency. It
+// keeps the previous image packed and reuses its buffers, so an update
+// allocates nothing once warmed up. A BitDiffer is not safe for concurrent
+// use.
+type BitDiffer struct {
+	previous []byte
+	current  []byte
+	changed  []int
+	quantity int
+	primed   bool
+}
+
+// Update records values as the latest read and returns the indices that
+// changed since the previous one. The first update, and any update whose
+// length differs from the previous one, reports every index as changed. The
+// returned slice is only valid until the next update.
+func (d *BitDiffer) Update(values []bool) []int {
+	d.current = PackBits(d.current, values)
+	d.changed = d.changed[:0]
+	if !d.primed || d.quantity != len(values) {
+		for i := range values {
+			d.changed = append(d.changed, i)
+		}
+	} else {
+		d.changed = ChangedBits(d.changed, d.previous, d.current, len(values))
+	}
+	d.previous, d.current = d.current, d.previous
+	d.quantity = len(values)
+	d.primed = true
+	return d.changed
+}
+
+// Reset forgets the previous image, so the next update reports every index
+func (d *BitDiffer) Reset() {
+	d.primed = false
+}
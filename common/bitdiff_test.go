@@ -0,0 +1,144 @@
+package common
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestPackBits(t *testing.T) {
+	packed := PackBits(nil, []bool{true, false, true, true, false, false, true, true, true, false})
+	// Same layout as the Read Coils example of the spec: LSB first
+	if !slices.Equal(packed, []byte{0xCD, 0x01}) {
+		t.Errorf("Expected CD 01, got % X", packed)
+	}
+
+	// Buffers are reused and cleared
+	packed = PackBits(packed, []bool{false, true})
+	if !slices.Equal(packed, []byte{0x02}) {
+		t.Errorf("Expected 02, got % X", packed)
+	}
+}
+
+func TestChangedBits(t *testing.T) {
+	a := make([]byte, 20)
+	b := make([]byte, 20)
+	b[0] = 0x81  // bits 0 and 7
+	b[9] = 0x10  // bit 76
+	b[19] = 0x0F // bits 152-155, 155 is beyond the quantity
+
+	got := ChangedBits(nil, a, b, 155)
+	want := []int{0, 7, 76, 152, 153, 154}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// A shorter image counts as zero
+	if got := ChangedBits(nil, a[:1], b, 80); !slices.Equal(got, []int{0, 7, 76}) {
+		t.Errorf("Expected [0 7 76], got %v", got)
+	}
+}
+
+func TestBitDiffer(t *testing.T) {
+	var d BitDiffer
+	values := make([]bool, 2000)
+
+	if got := d.Update(values); len(got) != 2000 {
+		t.Fatalf("Expected the first update to report all 2000 coils, got %d", len(got))
+	}
+	if got := d.Update(values); len(got) != 0 {
+		t.Fatalf("Expected no changes, got %v", got)
+	}
+
+	next := slices.Clone(values)
+	next[3], next[64], next[1999] = true, true, true
+	if got := d.Update(next); !slices.Equal(got, []int{3, 64, 1999}) {
+		t.Errorf("Expected [3 64 1999], got %v", got)
+	}
+	if got := d.Update(values); !slices.Equal(got, []int{3, 64, 1999}) {
+		t.Errorf("Expected the same coils to change back, got %v", got)
+	}
+
+	// Random images agree with a per-bit comparison
+	rng := rand.New(rand.NewSource(1))
+	previous := values
+	for round := 0; round < 50; round++ {
+		current := make([]bool, len(previous))
+		for i := range current {
+			current[i] = rng.Intn(4) == 0
+		}
+		var want []int
+		for i := range current {
+			if current[i] != previous[i] {
+				want = append(want, i)
+			}
+		}
+		if got := d.Update(current); !slices.Equal(got, want) {
+			t.Fatalf("Round %d: expected %v, got %v", round, want, got)
+		}
+		previous = current
+	}
+
+	d.Reset()
+	if got := d.Update(previous); len(got) != 2000 {
+		t.Errorf("Expected all coils after Reset, got %d", len(got))
+	}
+}
+
+// coilImages returns two 2000-coil images differing in a few coils
+func coilImages() ([]bool, []bool) {
+	a := make([]bool, 2000)
+	b := make([]bool, 2000)
+	for i := range a {
+		a[i] = i%3 == 0
+		b[i] = a[i]
+	}
+	b[10], b[1000], b[1500] = !b[10], !b[1000], !b[1500]
+	return a, b
+}
+
+func BenchmarkBitDiffer_Update(b *testing.B) {
+	x, y := coilImages()
+	var d BitDiffer
+	d.Update(x)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%2 == 0 {
+			d.Update(y)
+		} else {
+			d.Update(x)
+		}
+	}
+}
+
+func BenchmarkChangedBits(b *testing.B) {
+	x, y := coilImages()
+	px, py := PackBits(nil, x), PackBits(nil, y)
+	dst := make([]int, 0, 16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = ChangedBits(dst[:0], px, py, len(x))
+	}
+}
+
+// BenchmarkMapCompare is the per-bit map comparison BitDiffer replaces
+func BenchmarkMapCompare(b *testing.B) {
+	x, y := coilImages()
+	previous := make(map[int]bool, len(x))
+	for i, v := range x {
+		previous[i] = v
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var changed []int
+		for j, v := range y {
+			if previous[j] != v {
+				changed = append(changed, j)
+			}
+		}
+		_ = changed
+	}
+}
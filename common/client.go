@@ -53,6 +53,19 @@ type Client interface {
 	// The values are the values to write.
 	WriteMultipleRegisters(ctx context.Context, address Address, values []RegisterValue) error
 
+	// ReadFileRecord reads groups of registers from files of the server.
+	// The requests are the groups to read, answered in the same order.
+	ReadFileRecord(ctx context.Context, requests []FileRecordRequest) ([]FileRecord, error)
+
+	// WriteFileRecord writes groups of registers to files of the server.
+	// The records are the groups to write.
+	WriteFileRecord(ctx context.Context, records []FileRecord) error
+
+	// MaskWriteRegister modifies bits of a holding register atomically.
+	// The address is the address of the register to modify.
+	// The server sets the register to (current AND andMask) OR (orMask AND NOT andMask).
+	MaskWriteRegister(ctx context.Context, address Address, andMask, orMask uint16) error
+
 	// ReadWriteMultipleRegisters reads and writes multiple registers to the server.
 	// The readAddress is the starting address of the registers to read.
 	// The readQuantity is the number of registers to read.
@@ -60,6 +73,11 @@ type Client interface {
 	// The writeValues are the values to write.
 	ReadWriteMultipleRegisters(ctx context.Context, readAddress Address, readQuantity Quantity, writeAddress Address, writeValues []RegisterValue) ([]RegisterValue, error)
 
+	// ReadFIFOQueue reads the contents of a first-in-first-out queue of registers.
+	// The pointerAddress is the address of the queue.
+	// Returns the queued values, at most MaxFIFOCount of them.
+	ReadFIFOQueue(ctx context.Context, pointerAddress Address) ([]RegisterValue, error)
+
 	// ReadExceptionStatus reads the exception status from the server.
 	// Returns the exception status as a typed value.
 	ReadExceptionStatus(ctx context.Context) (ExceptionStatus, error)
@@ -160,6 +178,36 @@ type Protocol interface {
 	// Returns the starting address, quantity written, and any error.
 	ParseWriteMultipleRegistersResponse(data []byte) (Address, Quantity, error)
 
+	// GenerateReadFileRecordRequest generates a request PDU data to read file records.
+	// The returned byte slice contains only the PDU data (excluding function code).
+	// This is used to construct the full Modbus request.
+	GenerateReadFileRecordRequest(requests []FileRecordRequest) ([]byte, error)
+
+	// ParseReadFileRecordResponse parses a response PDU data from a read file record request.
+	// The data parameter contains the PDU data (excluding function code).
+	// Returns one record per request, in the order of the requests.
+	ParseReadFileRecordResponse(data []byte, requests []FileRecordRequest) ([]FileRecord, error)
+
+	// GenerateWriteFileRecordRequest generates a request PDU data to write file records.
+	// The returned byte slice contains only the PDU data (excluding function code).
+	// This is used to construct the full Modbus request.
+	GenerateWriteFileRecordRequest(records []FileRecord) ([]byte, error)
+
+	// ParseWriteFileRecordResponse parses a response PDU data from a write file record request.
+	// The data parameter contains the PDU data (excluding function code).
+	// Returns the records echoed by the server.
+	ParseWriteFileRecordResponse(data []byte) ([]FileRecord, error)
+
+	// GenerateMaskWriteRegisterRequest generates a request PDU data to modify bits of a register.
+	// The returned byte slice contains only the PDU data (excluding function code).
+	// This is used to construct the full Modbus request.
+	GenerateMaskWriteRegisterRequest(address Address, andMask, orMask uint16) ([]byte, error)
+
+	// ParseMaskWriteRegisterResponse parses a response PDU data from a mask write register request.
+	// The data parameter contains the PDU data (excluding function code).
+	// Returns the register address, AND mask, OR mask, and any error.
+	ParseMaskWriteRegisterResponse(data []byte) (Address, uint16, uint16, error)
+
 	// GenerateReadWriteMultipleRegistersRequest generates a request PDU data to read and write multiple registers.
 	// The returned byte slice contains only the PDU data (excluding function code).
 	// This is used to construct the full Modbus request.
@@ -170,6 +218,16 @@ type Protocol interface {
 	// Returns the read register values as a slice of uint16.
 	ParseReadWriteMultipleRegistersResponse(data []byte, readQuantity Quantity) ([]RegisterValue, error)
 
+	// GenerateReadFIFOQueueRequest generates a request PDU data to read a FIFO queue.
+	// The returned byte slice contains only the PDU data (excluding function code).
+	// This is used to construct the full Modbus request.
+	GenerateReadFIFOQueueRequest(pointerAddress Address) ([]byte, error)
+
+	// ParseReadFIFOQueueResponse parses a response PDU data from a read FIFO queue request.
+	// The data parameter contains the PDU data (excluding function code).
+	// Returns the queued register values.
+	ParseReadFIFOQueueResponse(data []byte) ([]RegisterValue, error)
+
 	// GenerateReadExceptionStatusRequest generates a request PDU data to read the exception status.
 	// The returned byte slice contains only the PDU data (excluding function code).
 	// This is used to construct the full Modbus request.
@@ -192,4 +250,14 @@ type Protocol interface {
 
 	// WithLogger sets the logger for the protocol and returns a new Protocol instance.
 	WithLogger(logger LoggerInterface) Protocol
-}
\ No newline at end of file
+}
+
+// BufferedProtocol is implemented by protocols that can decode read
+// responses into slices provided by the caller, so that polling loops need
+// not allocate. The quantity read is the length of dst.
+type BufferedProtocol interface {
+	ParseReadCoilsResponseInto(data []byte, dst []CoilValue) error
+	ParseReadDiscreteInputsResponseInto(data []byte, dst []DiscreteInputValue) error
+	ParseReadHoldingRegistersResponseInto(data []byte, dst []RegisterValue) error
+	ParseReadInputRegistersResponseInto(data []byte, dst []InputRegisterValue) error
+}
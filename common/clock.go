@@ -0,0 +1,27 @@
+package common
+
+import "time"
+
+// Clock tells the time and waits for it. Components that measure timeouts
+// take a Clock so tests can substitute a manual one and run without real
+// waits.
+type Clock interface {
+	Now() time.Time
+
+	// After returns a channel receiving the time once d has passed on the
+	// clock, like time.After
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock backed by time.Now
+type SystemClock struct{}
+
+// Now returns the current wall-clock time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for d of wall-clock time with time.After
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,25 @@
+package common
+
+import "context"
+
+// correlationIDKey is the context key for the correlation ID of an operation
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying id, a request or trace ID
+// chosen by the caller. The loggers of this module add it to every line
+// logged for the operation as the correlation_id field, and clients and
+// servers copy it into the events they emit, so one operation can be
+// followed across services.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID stored by WithCorrelationID, or
+// "" if ctx carries none
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
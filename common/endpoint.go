@@ -0,0 +1,47 @@
+package common
+
+import "strconv"
+
+// Endpointer is implemented by transports that know the endpoint they talk
+// to, as "host:port"
+type Endpointer interface {
+	Endpoint() string
+}
+
+// EndpointLabel returns the label of the device with unitID at address
+// ("host:port"), in the form "host:port:unit"
+func EndpointLabel(address string, unitID UnitID) string {
+	return address + ":" + strconv.Itoa(int(unitID))
+}
+
+// EndpointError annotates an error with the endpoint it came from,
+// "host:port" for transports and "host:port:unit" for clients. It unwraps
+// to the original error, so errors.Is and errors.As and the Is*Error
+// helpers see through it.
+type EndpointError struct {
+	Endpoint string
+	Err      error
+}
+
+// Error implements the error interface
+func (e *EndpointError) Error() string {
+	return e.Endpoint + ": " + e.Err.Error()
+}
+
+// Unwrap returns the original error
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+// WithEndpoint annotates err with endpoint, replacing the label of an
+// EndpointError err already is, so an error passed up from a transport to a
+// client is labeled once. A nil err or empty endpoint leaves err unchanged.
+func WithEndpoint(err error, endpoint string) error {
+	if err == nil || endpoint == "" {
+		return err
+	}
+	if labeled, ok := err.(*EndpointError); ok {
+		err = labeled.Err
+	}
+	return &EndpointError{Endpoint: endpoint, Err: err}
+}
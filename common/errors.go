@@ -55,6 +55,22 @@ var (
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Responses)
 	ErrServerDeviceFailure = errors.New("server device failure") // Related to exception code 0x04
 	ErrNoResponse          = errors.New("no response from server")
+
+	// Capability errors
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.1 (ILLEGAL FUNCTION)
+	ErrNotSupportedByDevice = errors.New("not supported by device") // Optional function rejected with exception 0x01
+
+	// Response validation errors
+	// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+	ErrUnitIDMismatch = errors.New("response unit ID does not match request")
+
+	// Broadcast errors
+	// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.2 (MODBUS Addressing rules)
+	ErrBroadcastRead = errors.New("broadcast requests cannot read") // Devices never answer broadcasts
+
+	// Request validation errors
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (MODBUS Function Codes)
+	ErrMalformedRequest = errors.New("malformed request") // Length, byte count or quantity outside the spec
 )
 
 // ModbusError represents an error from a Modbus exception response
@@ -75,25 +91,126 @@ func (e *ModbusError) Error() string {
 		e.FunctionCode, e.ExceptionCode, GetExceptionString(e.ExceptionCode))
 }
 
-// IsModbusError checks if an error is a ModbusError
+// IsModbusError checks if an error is, or wraps, a ModbusError
 func IsModbusError(err error) bool {
-	_, ok := err.(*ModbusError)
-	return ok
+	var modbusErr *ModbusError
+	return errors.As(err, &modbusErr)
 }
 
-// IsExceptionError checks if an error is a specific Modbus exception
+// IsExceptionError checks if an error is, or wraps, a specific Modbus exception
 func IsExceptionError(err error, exceptionCode ExceptionCode) bool {
-	if modbusErr, ok := err.(*ModbusError); ok {
+	var modbusErr *ModbusError
+	if errors.As(err, &modbusErr) {
 		return modbusErr.ExceptionCode == exceptionCode
 	}
 	return false
 }
 
 // IsFunctionNotSupportedError checks if an error is due to a function not being supported
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.1
 func IsFunctionNotSupportedError(err error) bool {
 	return IsExceptionError(err, ExceptionFunctionCodeNotSupported)
 }
 
+// IsDataAddressNotAvailableError checks if an error is due to an unavailable data address
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.2
+func IsDataAddressNotAvailableError(err error) bool {
+	return IsExceptionError(err, ExceptionDataAddressNotAvailable)
+}
+
+// IsInvalidDataValueError checks if an error is due to an invalid data value
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.3
+func IsInvalidDataValueError(err error) bool {
+	return IsExceptionError(err, ExceptionInvalidDataValue)
+}
+
+// IsServerDeviceFailureError checks if an error is due to a server device failure
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.4
+func IsServerDeviceFailureError(err error) bool {
+	return IsExceptionError(err, ExceptionServerDeviceFailure)
+}
+
+// IsAcknowledgeError checks if an error is an acknowledge exception (long-running request accepted)
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.5
+func IsAcknowledgeError(err error) bool {
+	return IsExceptionError(err, ExceptionAcknowledge)
+}
+
+// IsServerDeviceBusyError checks if an error is due to the server being busy
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.6
+func IsServerDeviceBusyError(err error) bool {
+	return IsExceptionError(err, ExceptionServerDeviceBusy)
+}
+
+// IsMemoryParityError checks if an error is due to a memory parity error
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.8
+func IsMemoryParityError(err error) bool {
+	return IsExceptionError(err, ExceptionMemoryParityError)
+}
+
+// IsGatewayPathUnavailableError checks if an error is due to an unavailable gateway path
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.9
+func IsGatewayPathUnavailableError(err error) bool {
+	return IsExceptionError(err, ExceptionGatewayPathUnavailable)
+}
+
+// IsGatewayTargetNoResponseError checks if an error is due to a gateway target failing to respond
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.10
+func IsGatewayTargetNoResponseError(err error) bool {
+	return IsExceptionError(err, ExceptionGatewayTargetNoResponse)
+}
+
+// NotSupportedError is returned instead of a bare ModbusError when a device
+// rejects an optional function with exception 0x01 and the client was asked
+// to normalize such responses. It matches ErrNotSupportedByDevice with
+// errors.Is and unwraps to the original ModbusError.
+type NotSupportedError struct {
+	FunctionCode FunctionCode // Function code of the request (without the exception bit)
+	Exception    *ModbusError // Exception response returned by the device
+}
+
+// Error implements the error interface
+func (e *NotSupportedError) Error() string {
+	return fmt.Sprintf("modbus: function %s %s: %v", e.FunctionCode, ErrNotSupportedByDevice, e.Exception)
+}
+
+// Is reports whether target is ErrNotSupportedByDevice
+func (e *NotSupportedError) Is(target error) bool {
+	return target == ErrNotSupportedByDevice
+}
+
+// Unwrap returns the underlying exception
+func (e *NotSupportedError) Unwrap() error {
+	return e.Exception
+}
+
+// IsNotSupportedByDeviceError checks if an error reports an optional function
+// the device does not implement
+func IsNotSupportedByDeviceError(err error) bool {
+	return errors.Is(err, ErrNotSupportedByDevice)
+}
+
+// UnitIDMismatchError is returned when a response carries a different unit ID
+// than its request. The server is required to echo the unit ID, so a mismatch
+// usually means requests are crossed behind a gateway. It matches
+// ErrUnitIDMismatch with errors.Is.
+// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+type UnitIDMismatchError struct {
+	TransactionID TransactionID
+	Expected      UnitID // Unit ID of the request
+	Received      UnitID // Unit ID of the response
+}
+
+// Error implements the error interface
+func (e *UnitIDMismatchError) Error() string {
+	return fmt.Sprintf("modbus: transaction %d: %v: expected %d, got %d", e.TransactionID, ErrUnitIDMismatch, e.Expected, e.Received)
+}
+
+// Is reports whether target is ErrUnitIDMismatch
+func (e *UnitIDMismatchError) Is(target error) bool {
+	return target == ErrUnitIDMismatch
+}
+
 // NewModbusError creates a new ModbusError
 func NewModbusError(functionCode FunctionCode, exceptionCode ExceptionCode) *ModbusError {
 	return &ModbusError{
@@ -0,0 +1,205 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies a connection or session lifecycle event
+type EventType int
+
+const (
+	// EventConnected is emitted when a connection is established
+	EventConnected EventType = iota + 1
+
+	// EventDisconnected is emitted when a connection is closed or lost;
+	// Err holds the cause when the connection was lost
+	EventDisconnected
+
+	// EventRequestFailed is emitted when a request ends in an exception
+	// response or a transport error; Err holds the error
+	EventRequestFailed
+
+	// EventReconnected is emitted when a client transport established a new
+	// connection after losing the previous one
+	EventReconnected
+
+	// EventProtocolViolation is emitted by servers when a client sends a
+	// malformed frame; Err is a *ProtocolViolationError
+	EventProtocolViolation
+
+	// EventValueForced is emitted by a forcing overlay when an operator
+	// forces a value; Detail describes the force
+	EventValueForced
+
+	// EventForceCleared is emitted by a forcing overlay when a force is
+	// removed; Detail describes the force removed
+	EventForceCleared
+
+	// EventEndpointChanged is emitted by a client transport that
+	// reconnected to a different address than before, for example after a
+	// DNS failover; RemoteAddr is the new address and Detail the old one
+	EventEndpointChanged
+
+	// EventWatchdogMissed is emitted by a client watchdog when a heartbeat
+	// write failed; Detail describes the beat and Err holds the error
+	EventWatchdogMissed
+)
+
+// String returns the name of the event type
+func (t EventType) String() string {
+	switch t {
+	case EventConnected:
+		return "Connected"
+	case EventDisconnected:
+		return "Disconnected"
+	case EventRequestFailed:
+		return "RequestFailed"
+	case EventReconnected:
+		return "Reconnected"
+	case EventProtocolViolation:
+		return "ProtocolViolation"
+	case EventValueForced:
+		return "ValueForced"
+	case EventForceCleared:
+		return "ForceCleared"
+	case EventEndpointChanged:
+		return "EndpointChanged"
+	case EventWatchdogMissed:
+		return "WatchdogMissed"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event is a lifecycle event emitted by clients and servers
+type Event struct {
+	Type EventType
+	Time time.Time
+
+	// RemoteAddr is the peer of the connection, when known
+	RemoteAddr string
+
+	// Device is the name of the device from a DeviceRegistry, when known
+	Device string
+
+	// Endpoint is the client's endpoint label, "host:port:unit", when known
+	Endpoint string
+
+	// CorrelationID is the correlation ID of the operation that caused the
+	// event, see WithCorrelationID
+	CorrelationID string
+
+	// UnitID and FunctionCode identify the request of EventRequestFailed
+	UnitID       UnitID
+	FunctionCode FunctionCode
+
+	// Detail describes the request fields behind a server-side
+	// EventRequestFailed, such as "address=100 quantity=5", or the value of
+	// EventValueForced and EventForceCleared
+	Detail string
+
+	// Err is the cause of EventDisconnected, EventRequestFailed,
+	// EventProtocolViolation and EventWatchdogMissed, if any
+	Err error
+}
+
+// String returns a short description of the event
+func (e Event) String() string {
+	s := e.Type.String()
+	if e.RemoteAddr != "" {
+		s += " " + e.RemoteAddr
+	}
+	if e.Device != "" {
+		s += " " + e.Device
+	}
+	if e.Endpoint != "" {
+		s += " " + e.Endpoint
+	}
+	if e.Type == EventRequestFailed {
+		s += fmt.Sprintf(" unit=%d function=%s", e.UnitID, e.FunctionCode)
+		if e.Detail != "" {
+			s += " " + e.Detail
+		}
+	} else if e.Detail != "" {
+		s += " " + e.Detail
+	}
+	if e.CorrelationID != "" {
+		s += " correlation_id=" + e.CorrelationID
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// DefaultEventBuffer is the capacity of the channel returned by EventStream.Channel
+const DefaultEventBuffer = 64
+
+// EventStream delivers events to a buffered channel for select-based
+// supervision loops. The channel is created by the first call to Channel;
+// events emitted before that are discarded, so an unused stream costs
+// nothing. Emit never blocks: when the channel is full the event is dropped
+// and counted. The zero value is ready to use.
+type EventStream struct {
+	mu      sync.Mutex
+	ch      chan Event
+	dropped atomic.Uint64
+}
+
+// Channel returns the event channel, creating it on first use
+func (s *EventStream) Channel() <-chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan Event, DefaultEventBuffer)
+	}
+	return s.ch
+}
+
+// Emit delivers an event if the channel exists, stamping Time if unset
+func (s *EventStream) Emit(event Event) {
+	s.mu.Lock()
+	ch := s.ch
+	s.mu.Unlock()
+	if ch == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case ch <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// All returns an iterator over the events of the channel, for use with
+// range-over-func. It ends when ctx is done or the loop breaks; events left
+// in the channel stay there for the next consumer.
+func (s *EventStream) All(ctx context.Context) iter.Seq[Event] {
+	ch := s.Channel()
+	return func(yield func(Event) bool) {
+		for {
+			select {
+			case event := <-ch:
+				if !yield(event) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Dropped returns the number of events discarded because the channel was full
+func (s *EventStream) Dropped() uint64 {
+	return s.dropped.Load()
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bit reports whether bit i (0-7) of the exception status is set
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.7 (Read Exception Status)
+func (s ExceptionStatus) Bit(i int) bool {
+	return i >= 0 && i < 8 && s&(1<<i) != 0
+}
+
+// ExceptionStatusLabels names the bits of the exception status. Their meaning
+// is device specific: the spec only defines the status as eight coils.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.7 (Read Exception Status)
+type ExceptionStatusLabels map[int]string
+
+// ParseExceptionStatusLabels parses labels of the form "0=Overtemp,3=Door open"
+func ParseExceptionStatusLabels(s string) (ExceptionStatusLabels, error) {
+	labels := make(ExceptionStatusLabels)
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		bit, name, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected bit=name, got %q", part)
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(bit))
+		if err != nil || i < 0 || i > 7 {
+			return nil, fmt.Errorf("exception status bit must be 0-7, got %q", bit)
+		}
+		labels[i] = strings.TrimSpace(name)
+	}
+	return labels, nil
+}
+
+// LabeledExceptionStatus is an exception status read with bit labels, so its
+// bits can be tested and printed by name
+type LabeledExceptionStatus struct {
+	Status ExceptionStatus
+	Labels ExceptionStatusLabels
+}
+
+// IsSet reports whether the bit labeled name is set. Unknown names are
+// never set.
+func (s LabeledExceptionStatus) IsSet(name string) bool {
+	for i, label := range s.Labels {
+		if label == name {
+			return s.Status.Bit(i)
+		}
+	}
+	return false
+}
+
+// Set returns the names of the set bits in bit order; unlabeled bits are
+// named "bit N"
+func (s LabeledExceptionStatus) Set() []string {
+	var names []string
+	for i := 0; i < 8; i++ {
+		if !s.Status.Bit(i) {
+			continue
+		}
+		if label, ok := s.Labels[i]; ok {
+			names = append(names, label)
+		} else {
+			names = append(names, fmt.Sprintf("bit %d", i))
+		}
+	}
+	return names
+}
+
+// String returns the set bits by name, e.g. "ExceptionStatus(Overtemp, bit 5)"
+func (s LabeledExceptionStatus) String() string {
+	names := s.Set()
+	if len(names) == 0 {
+		return "ExceptionStatus(None)"
+	}
+	return "ExceptionStatus(" + strings.Join(names, ", ") + ")"
+}
+
+// String returns the labels in bit order, in the form accepted by
+// ParseExceptionStatusLabels
+func (l ExceptionStatusLabels) String() string {
+	bits := make([]int, 0, len(l))
+	for i := range l {
+		bits = append(bits, i)
+	}
+	sort.Ints(bits)
+
+	parts := make([]string, 0, len(bits))
+	for _, i := range bits {
+		parts = append(parts, fmt.Sprintf("%d=%s", i, l[i]))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,30 @@
+package common
+
+import "testing"
+
+func TestParseExceptionStatusLabels(t *testing.T) {
+	labels, err := ParseExceptionStatusLabels("0=Overtemp, 3=Door open,")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if labels[0] != "Overtemp" || labels[3] != "Door open" || len(labels) != 2 {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+	if s := labels.String(); s != "0=Overtemp,3=Door open" {
+		t.Errorf("Unexpected string %q", s)
+	}
+
+	for _, input := range []string{"Overtemp", "8=High", "x=Low"} {
+		if _, err := ParseExceptionStatusLabels(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+
+	status := LabeledExceptionStatus{Status: 0x08, Labels: labels}
+	if !status.IsSet("Door open") || status.IsSet("Overtemp") {
+		t.Errorf("Unexpected bits in %s", status)
+	}
+	if (LabeledExceptionStatus{}).String() != "ExceptionStatus(None)" {
+		t.Error("Expected an empty status to print as None")
+	}
+}
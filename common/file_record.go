@@ -0,0 +1,53 @@
+package common
+
+import "context"
+
+// File record limits
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.14 and 6.15
+const (
+	// FileRecordReferenceType is the only reference type of a sub-request
+	FileRecordReferenceType = 0x06
+
+	// MaxFileRecordNumber is the last record of a file; each file holds
+	// 10000 records of one register each
+	MaxFileRecordNumber = 9999
+
+	// MaxFileRecordByteCount bounds the byte count of Read File Record
+	// requests and responses
+	MaxFileRecordByteCount = 0xF5
+
+	// MaxWriteFileRecordByteCount bounds the data length of Write File
+	// Record requests and responses
+	MaxWriteFileRecordByteCount = 0xFB
+)
+
+// FileRecordRequest is a sub-request of Read File Record (0x14): Length
+// registers of FileNumber starting at record RecordNumber
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+type FileRecordRequest struct {
+	FileNumber   uint16
+	RecordNumber uint16
+	Length       Quantity
+}
+
+// FileRecord is a group of registers of a file starting at RecordNumber:
+// the result of a Read File Record sub-request, or a sub-request of Write
+// File Record (0x15)
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Write File Record)
+type FileRecord struct {
+	FileNumber   uint16
+	RecordNumber uint16
+	Values       []RegisterValue
+}
+
+// FileRecordStore is implemented by data stores with extended file memory,
+// such as recipe storage, served through Read File Record (0x14) and Write
+// File Record (0x15). The server answers both functions with exception 0x01
+// (Illegal Function) for stores that do not implement it.
+type FileRecordStore interface {
+	// ReadFileRecord returns length registers of a file starting at record
+	ReadFileRecord(ctx context.Context, fileNumber, recordNumber uint16, length Quantity) ([]RegisterValue, error)
+
+	// WriteFileRecord writes values to a file starting at record
+	WriteFileRecord(ctx context.Context, fileNumber, recordNumber uint16, values []RegisterValue) error
+}
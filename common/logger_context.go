@@ -0,0 +1,27 @@
+package common
+
+import "context"
+
+// loggerKey is the context key for the logger of an operation
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying logger, for example one
+// with the trace ID of a request among its fields. Loggers created with
+// logging.NewContextLogger log the operation's lines to it instead of their
+// own, so a per-request logger follows the request through the client,
+// transport, protocol and server layers.
+func ContextWithLogger(ctx context.Context, logger LoggerInterface) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the logger stored by ContextWithLogger, or
+// fallback if ctx carries none
+func LoggerFromContext(ctx context.Context, fallback LoggerInterface) LoggerInterface {
+	if ctx == nil {
+		return fallback
+	}
+	if logger, ok := ctx.Value(loggerKey{}).(LoggerInterface); ok && logger != nil {
+		return logger
+	}
+	return fallback
+}
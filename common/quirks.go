@@ -0,0 +1,94 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quirks is a set of wire-level deviations from the Modbus specification of
+// a device, such as a legacy Jbus device, that the client works around
+// transparently so that application code can use the register map as
+// documented
+type Quirks uint32
+
+const (
+	// QuirkOneBasedAddressing is set for devices numbering their registers
+	// from 1, as Jbus devices do: register N of the map is address N+1 on
+	// the wire. Addresses are shifted in requests and in echoed responses.
+	QuirkOneBasedAddressing Quirks = 1 << iota
+
+	// QuirkInputRegistersAsHolding is set for devices serving their input
+	// registers with function 0x03 only; ReadInputRegisters sends 0x03
+	// instead of 0x04.
+	QuirkInputRegistersAsHolding
+
+	// QuirkLenientByteCount is set for devices sending a wrong byte count in
+	// read responses; the byte count is ignored and the values are taken
+	// from the data that follows it, as long as there is enough of it.
+	// Extra bytes after the objects of a Read Device Identification
+	// response are ignored too.
+	QuirkLenientByteCount
+)
+
+// quirkNames are the names of the quirk flags, used in configuration files
+var quirkNames = []struct {
+	quirk Quirks
+	name  string
+}{
+	{QuirkOneBasedAddressing, "one-based-addressing"},
+	{QuirkInputRegistersAsHolding, "input-registers-as-holding"},
+	{QuirkLenientByteCount, "lenient-byte-count"},
+}
+
+// QuirkProfiles are named sets of quirks for families of devices, accepted
+// by ParseQuirks. Applications may add their own profiles at init time.
+var QuirkProfiles = map[string]Quirks{
+	"jbus": QuirkOneBasedAddressing,
+}
+
+// Has reports whether all quirks of q are set
+func (q Quirks) Has(quirk Quirks) bool {
+	return q&quirk == quirk
+}
+
+// String returns the names of the quirks set, separated by commas
+func (q Quirks) String() string {
+	var names []string
+	for _, n := range quirkNames {
+		if q.Has(n.quirk) {
+			names = append(names, n.name)
+			q &^= n.quirk
+		}
+	}
+	if q != 0 {
+		names = append(names, fmt.Sprintf("0x%X", uint32(q)))
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseQuirks combines quirk flags and profiles given by name, such as
+// "jbus" or "lenient-byte-count"
+func ParseQuirks(names ...string) (Quirks, error) {
+	var quirks Quirks
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if profile, ok := QuirkProfiles[name]; ok {
+			quirks |= profile
+			continue
+		}
+		found := false
+		for _, n := range quirkNames {
+			if n.name == name {
+				quirks |= n.quirk
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown quirk or profile %q", name)
+		}
+	}
+	return quirks, nil
+}
@@ -0,0 +1,135 @@
+package common
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// deviceKey identifies a device by the address it is reached at and its
+// unit ID; unit 0 with anyUnit set matches every unit at the address
+type deviceKey struct {
+	address string
+	unitID  UnitID
+	anyUnit bool
+}
+
+// DeviceRegistry maps devices, identified by address and unit ID, to
+// human-friendly names for log fields, errors, events and CLI output.
+// Addresses are "host" or "host:port" as given to the client; a name
+// registered for the host alone applies to every port. It is safe for
+// concurrent use.
+type DeviceRegistry struct {
+	mu    sync.RWMutex
+	names map[deviceKey]string
+}
+
+// NewDeviceRegistry creates an empty registry
+func NewDeviceRegistry() *DeviceRegistry {
+	return &DeviceRegistry{names: make(map[deviceKey]string)}
+}
+
+// Register names the device with the given unit ID at address
+func (r *DeviceRegistry) Register(address string, unitID UnitID, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[deviceKey{address: address, unitID: unitID}] = name
+}
+
+// RegisterAddress names every unit at address, for example a device that
+// answers on any unit ID. Names registered for a specific unit take
+// precedence.
+func (r *DeviceRegistry) RegisterAddress(address string, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[deviceKey{address: address, anyUnit: true}] = name
+}
+
+// Lookup returns the name registered for the device, trying the exact
+// address before its host part, and a specific unit before the whole address
+func (r *DeviceRegistry) Lookup(address string, unitID UnitID) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	addresses := []string{address}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		addresses = append(addresses, host)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, a := range addresses {
+		if name, ok := r.names[deviceKey{address: a, unitID: unitID}]; ok {
+			return name, true
+		}
+		if name, ok := r.names[deviceKey{address: a, anyUnit: true}]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Name returns the registered name of the device, or "address/unit" when it
+// has none. A nil registry names every device that way.
+func (r *DeviceRegistry) Name(address string, unitID UnitID) string {
+	if name, ok := r.Lookup(address, unitID); ok {
+		return name
+	}
+	return fmt.Sprintf("%s/%d", address, unitID)
+}
+
+// ParseDeviceNames reads a registry from lines of the form
+//
+//	address unit name
+//
+// where unit is a unit ID or * for every unit and name is the rest of the
+// line. Blank lines and lines starting with # are ignored.
+func ParseDeviceNames(r io.Reader) (*DeviceRegistry, error) {
+	registry := NewDeviceRegistry()
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected \"address unit name\", got %q", line, text)
+		}
+		name := strings.Join(fields[2:], " ")
+		if fields[1] == "*" {
+			registry.RegisterAddress(fields[0], name)
+			continue
+		}
+		unitID, err := strconv.ParseUint(fields[1], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid unit ID %q", line, fields[1])
+		}
+		registry.Register(fields[0], UnitID(unitID), name)
+	}
+	return registry, scanner.Err()
+}
+
+// DeviceError annotates an error with the name of the device it came from.
+// It unwraps to the original error, so errors.Is and errors.As and the
+// Is*Error helpers see through it.
+type DeviceError struct {
+	Device string
+	Err    error
+}
+
+// Error implements the error interface
+func (e *DeviceError) Error() string {
+	return e.Device + ": " + e.Err.Error()
+}
+
+// Unwrap returns the original error
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
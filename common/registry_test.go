@@ -0,0 +1,73 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeviceRegistry_Lookup(t *testing.T) {
+	registry := NewDeviceRegistry()
+	registry.Register("10.0.0.5:502", 1, "boiler-1")
+	registry.Register("10.0.0.5", 2, "boiler-2")
+	registry.RegisterAddress("10.0.0.6", "chiller")
+
+	tests := []struct {
+		address string
+		unitID  UnitID
+		want    string
+	}{
+		{"10.0.0.5:502", 1, "boiler-1"},
+		{"10.0.0.5:502", 2, "boiler-2"}, // host entry applies to every port
+		{"10.0.0.5:1502", 1, "10.0.0.5:1502/1"},
+		{"10.0.0.6:502", 9, "chiller"}, // any unit
+		{"10.0.0.7:502", 1, "10.0.0.7:502/1"},
+	}
+	for _, tt := range tests {
+		if got := registry.Name(tt.address, tt.unitID); got != tt.want {
+			t.Errorf("Name(%q, %d) = %q, want %q", tt.address, tt.unitID, got, tt.want)
+		}
+	}
+
+	var nilRegistry *DeviceRegistry
+	if _, ok := nilRegistry.Lookup("10.0.0.5:502", 1); ok {
+		t.Error("Expected a nil registry to have no names")
+	}
+}
+
+func TestParseDeviceNames(t *testing.T) {
+	registry, err := ParseDeviceNames(strings.NewReader(`
+# plant floor
+10.0.0.5:502 1 Boiler 1
+10.0.0.6     *  Chiller
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if got := registry.Name("10.0.0.5:502", 1); got != "Boiler 1" {
+		t.Errorf("Expected Boiler 1, got %q", got)
+	}
+	if got := registry.Name("10.0.0.6:502", 4); got != "Chiller" {
+		t.Errorf("Expected Chiller, got %q", got)
+	}
+
+	for _, input := range []string{"10.0.0.5 1", "10.0.0.5 300 Boiler"} {
+		if _, err := ParseDeviceNames(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestDeviceError(t *testing.T) {
+	err := &DeviceError{Device: "boiler-1", Err: NewModbusError(FuncReadCoils, ExceptionDataAddressNotAvailable)}
+	if !strings.HasPrefix(err.Error(), "boiler-1: ") {
+		t.Errorf("Expected the device name in %q", err.Error())
+	}
+	if !IsDataAddressNotAvailableError(err) {
+		t.Error("Expected the exception to be visible through DeviceError")
+	}
+	var modbusErr *ModbusError
+	if !errors.As(err, &modbusErr) {
+		t.Error("Expected DeviceError to unwrap to the ModbusError")
+	}
+}
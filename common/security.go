@@ -0,0 +1,32 @@
+package common
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+// RoleOID identifies the X.509 v3 extension carrying the role of a
+// Modbus/TCP Security client, which servers use to authorize its requests
+// Ref: MB-TCP-Security-v21_2018-07-24.pdf (Role-Based Client Authorization)
+var RoleOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 50316, 802, 1}
+
+// CertificateRole returns the role in cert's role extension, an ASN.1
+// UTF8String, or "" if it has none
+func CertificateRole(cert *x509.Certificate) (string, error) {
+	for _, extension := range cert.Extensions {
+		if !extension.Id.Equal(RoleOID) {
+			continue
+		}
+		var role string
+		rest, err := asn1.UnmarshalWithParams(extension.Value, &role, "utf8")
+		if err != nil {
+			return "", fmt.Errorf("%w: role extension: %v", ErrInvalidValue, err)
+		}
+		if len(rest) > 0 {
+			return "", fmt.Errorf("%w: role extension has trailing data", ErrInvalidValue)
+		}
+		return role, nil
+	}
+	return "", nil
+}
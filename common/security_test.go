@@ -0,0 +1,59 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// certificateWith returns a self-signed certificate with the given extensions
+func certificateWith(t *testing.T, extensions ...pkix.Extension) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		Subject:         pkix.Name{CommonName: "client"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: extensions,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestCertificateRole(t *testing.T) {
+	value, err := asn1.MarshalWithParams("Operator", "utf8")
+	if err != nil {
+		t.Fatalf("Failed to marshal role: %v", err)
+	}
+	if role, err := CertificateRole(certificateWith(t, pkix.Extension{Id: RoleOID, Value: value})); err != nil || role != "Operator" {
+		t.Errorf("Expected the Operator role, got %q, %v", role, err)
+	}
+
+	if role, err := CertificateRole(certificateWith(t)); err != nil || role != "" {
+		t.Errorf("Expected no role, got %q, %v", role, err)
+	}
+
+	// The role must be a UTF8String
+	value, _ = asn1.Marshal(42)
+	if _, err := CertificateRole(certificateWith(t, pkix.Extension{Id: RoleOID, Value: value})); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected a malformed role to fail, got %v", err)
+	}
+}
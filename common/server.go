@@ -42,6 +42,39 @@ type DataStore interface {
 	WriteMultipleRegisters(ctx context.Context, address Address, values []RegisterValue) error
 }
 
+// RangeValidator is implemented by data stores with a bounded address space.
+// Before a write, the server calls ValidateRange for the whole request so a
+// request touching any invalid address is rejected with exception 0x02
+// (Illegal Data Address) before anything is written. Implementations return
+// ErrInvalidAddress for ranges they do not serve.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.2 (ILLEGAL DATA ADDRESS)
+type RangeValidator interface {
+	ValidateRange(ctx context.Context, table Table, address Address, quantity Quantity) error
+}
+
+// TransactionalDataStore is implemented by data stores that can apply a group
+// of operations all-or-nothing. The server commits each write request (and
+// the write and read of Read/Write Multiple Registers) through Atomically.
+type TransactionalDataStore interface {
+	DataStore
+
+	// Atomically calls fn with a view of the store. Writes made through tx
+	// are visible to reads made through tx. No other caller observes the
+	// store while fn runs, and all writes are discarded if fn returns an error.
+	Atomically(ctx context.Context, fn func(tx DataStore) error) error
+}
+
+// FIFOQueueStore is implemented by data stores that serve Read FIFO Queue
+// (0x18) themselves. For other stores the server reads the queue from
+// holding registers: the register at the pointer address holds the count of
+// queued values, which follow it.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18 (Read FIFO Queue)
+type FIFOQueueStore interface {
+	// ReadFIFOQueue returns the values queued at pointerAddress, oldest
+	// first, without removing them
+	ReadFIFOQueue(ctx context.Context, pointerAddress Address) ([]RegisterValue, error)
+}
+
 // Server is the interface that all Modbus servers must implement
 type Server interface {
 	// Start starts the server
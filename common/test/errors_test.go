@@ -0,0 +1,59 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestExceptionErrorHelpers(t *testing.T) {
+	helpers := []struct {
+		code  common.ExceptionCode
+		check func(error) bool
+	}{
+		{common.ExceptionFunctionCodeNotSupported, common.IsFunctionNotSupportedError},
+		{common.ExceptionDataAddressNotAvailable, common.IsDataAddressNotAvailableError},
+		{common.ExceptionInvalidDataValue, common.IsInvalidDataValueError},
+		{common.ExceptionServerDeviceFailure, common.IsServerDeviceFailureError},
+		{common.ExceptionAcknowledge, common.IsAcknowledgeError},
+		{common.ExceptionServerDeviceBusy, common.IsServerDeviceBusyError},
+		{common.ExceptionMemoryParityError, common.IsMemoryParityError},
+		{common.ExceptionGatewayPathUnavailable, common.IsGatewayPathUnavailableError},
+		{common.ExceptionGatewayTargetNoResponse, common.IsGatewayTargetNoResponseError},
+	}
+
+	for _, h := range helpers {
+		for _, other := range helpers {
+			err := common.NewModbusError(common.FuncReadCoils|0x80, other.code)
+			if got := h.check(err); got != (h.code == other.code) {
+				t.Errorf("Helper for %#x on exception %#x: got %t", h.code, other.code, got)
+			}
+
+			// Wrapped exceptions are still recognized
+			wrapped := fmt.Errorf("read failed: %w", err)
+			if got := h.check(wrapped); got != (h.code == other.code) {
+				t.Errorf("Helper for %#x on wrapped exception %#x: got %t", h.code, other.code, got)
+			}
+		}
+
+		if h.check(common.ErrTimeout) {
+			t.Errorf("Helper for %#x matched a non-exception error", h.code)
+		}
+	}
+}
+
+func TestNotSupportedError(t *testing.T) {
+	exception := common.NewModbusError(common.FuncReadExceptionStatus|0x80, common.ExceptionFunctionCodeNotSupported)
+	err := error(&common.NotSupportedError{FunctionCode: common.FuncReadExceptionStatus, Exception: exception})
+
+	if !common.IsNotSupportedByDeviceError(err) {
+		t.Error("Expected NotSupportedError to match ErrNotSupportedByDevice")
+	}
+	if !common.IsModbusError(err) || !common.IsFunctionNotSupportedError(err) {
+		t.Error("Expected NotSupportedError to unwrap to the exception")
+	}
+	if common.IsNotSupportedByDeviceError(exception) {
+		t.Error("Expected a bare exception not to match ErrNotSupportedByDevice")
+	}
+}
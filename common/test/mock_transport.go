@@ -3,6 +3,7 @@ package test
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 )
@@ -14,6 +15,7 @@ type MockTransport struct {
 	responseQueue []common.Response
 	requests      []common.Request
 	errorQueue    []error
+	handler       func(common.Request) (common.Response, error)
 	logger        common.LoggerInterface
 }
 
@@ -54,16 +56,24 @@ func (t *MockTransport) IsConnected() bool {
 // Send sends a request and returns a response
 func (t *MockTransport) Send(ctx context.Context, request common.Request) (common.Response, error) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
 
 	// Check if connected
 	if !t.connected {
+		t.mu.Unlock()
 		return nil, common.ErrNotConnected
 	}
 
 	// Record the request
 	t.requests = append(t.requests, request)
 
+	// A handler, when set, answers every request. It is called without the
+	// lock so concurrent requests are handled concurrently.
+	if handler := t.handler; handler != nil {
+		t.mu.Unlock()
+		return handler(request)
+	}
+	defer t.mu.Unlock()
+
 	// Return the next queued response or error
 	if len(t.errorQueue) > 0 {
 		err := t.errorQueue[0]
@@ -80,6 +90,19 @@ func (t *MockTransport) Send(ctx context.Context, request common.Request) (commo
 	return nil, common.ErrNoResponse
 }
 
+// Broadcast records a request sent without a response, implementing
+// common.Broadcaster; the handler and queues are not consulted
+func (t *MockTransport) Broadcast(ctx context.Context, request common.Request, turnaround time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.connected {
+		return common.ErrNotConnected
+	}
+	t.requests = append(t.requests, request)
+	return nil
+}
+
 // QueueResponse adds a response to the queue
 func (t *MockTransport) QueueResponse(response common.Response) {
 	t.mu.Lock()
@@ -94,6 +117,15 @@ func (t *MockTransport) QueueError(err error) {
 	t.errorQueue = append(t.errorQueue, err)
 }
 
+// SetHandler sets a function that answers every request instead of the
+// queues. Useful when requests are sent concurrently and responses must
+// match the request content; the handler may be called concurrently.
+func (t *MockTransport) SetHandler(handler func(common.Request) (common.Response, error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = handler
+}
+
 // GetRequests returns the received requests
 func (t *MockTransport) GetRequests() []common.Request {
 	t.mu.Lock()
@@ -120,6 +152,7 @@ func (t *MockTransport) WithLogger(logger common.LoggerInterface) common.Transpo
 		responseQueue: t.responseQueue,
 		requests:      t.requests,
 		errorQueue:    t.errorQueue,
+		handler:       t.handler,
 		logger:        logger,
 	}
 
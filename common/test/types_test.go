@@ -106,7 +106,10 @@ func TestFunctionCodeString(t *testing.T) {
 		{common.FuncReadExceptionStatus, "ReadExceptionStatus"},
 		{common.FuncWriteMultipleCoils, "WriteMultipleCoils"},
 		{common.FuncWriteMultipleRegisters, "WriteMultipleRegisters"},
+		{common.FuncReadFileRecord, "ReadFileRecord"},
+		{common.FuncWriteFileRecord, "WriteFileRecord"},
 		{common.FuncReadWriteMultipleRegisters, "ReadWriteMultipleRegisters"},
+		{common.FuncReadFIFOQueue, "ReadFIFOQueue"},
 		{common.FunctionCode(0x7F), "Unknown(0x7F)"}, // Unknown function code (not an exception)
 	}
 
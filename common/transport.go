@@ -1,9 +1,34 @@
 package common
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Transport is an abstraction for the underlying transport mechanism.
 // It provides a common interface for TCP, RTU, etc.
+//
+// Custom transports can be used by clients in place of the built-in ones
+// when they follow these rules, which the transport/transporttest
+// conformance suite checks:
+//
+//   - Connect on a connected transport returns nil or an error matching
+//     ErrAlreadyConnected and leaves the connection usable. Disconnect on a
+//     transport that is not connected returns nil. A disconnected transport
+//     can be connected again.
+//   - Send is safe for concurrent use; every caller gets the response to its
+//     own request, even when requests are pipelined.
+//   - Send on a transport that is not connected fails at once with an error
+//     matching ErrNotConnected.
+//   - Send returns when ctx is done, with an error matching ctx.Err() (or
+//     ErrTimeout for a deadline), and a late response to that request is
+//     discarded.
+//   - Exception responses are returned as a Response with IsException set,
+//     not as an error; errors are reserved for requests that got no valid
+//     response.
+//
+// Errors may be wrapped, for example in an EndpointError; callers test them
+// with errors.Is.
 type Transport interface {
 	// Connect establishes a connection.
 	Connect(ctx context.Context) error
@@ -16,3 +41,17 @@ type Transport interface {
 	// WithLogger sets the logger for the transport.
 	WithLogger(logger LoggerInterface) Transport
 }
+
+// BroadcastUnitID addresses every device on a serial line. Devices carry out
+// write requests sent to it without answering them.
+// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.2 (MODBUS Addressing rules)
+const BroadcastUnitID UnitID = 0
+
+// Broadcaster is implemented by transports that can send requests no device
+// answers, such as writes to BroadcastUnitID
+type Broadcaster interface {
+	// Broadcast sends a request without waiting for a response and returns
+	// once it is written and turnaround has passed; nothing else is sent in
+	// the meantime, so devices have processed the request before the next.
+	Broadcast(ctx context.Context, request Request, turnaround time.Duration) error
+}
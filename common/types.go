@@ -1,6 +1,9 @@
 package common
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // TransactionID is a unique identifier for a transaction
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (MBAP Header), Field 1
@@ -59,6 +62,24 @@ type ReadDeviceIDCode byte
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Read Device Identification)
 type DeviceIDObjectCode byte
 
+// Table identifies one of the four primary tables of the Modbus data model.
+// The values are bit flags, so several tables can be combined into a set,
+// e.g. TableHoldingRegisters|TableInputRegisters.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (MODBUS Data model)
+type Table byte
+
+// Primary tables
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3
+const (
+	TableCoils            Table = 1 << iota // Read-write single bits
+	TableDiscreteInputs                     // Read-only single bits
+	TableHoldingRegisters                   // Read-write 16-bit words
+	TableInputRegisters                     // Read-only 16-bit words
+
+	// TableAll is the set of all four tables
+	TableAll = TableCoils | TableDiscreteInputs | TableHoldingRegisters | TableInputRegisters
+)
+
 // Function codes as defined by the Modbus specification
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (Function Codes)
 const (
@@ -72,9 +93,18 @@ const (
 	FuncReadExceptionStatus        FunctionCode = 0x07 // Ref: Section 6.7
 	FuncWriteMultipleCoils         FunctionCode = 0x0F // Ref: Section 6.11
 	FuncWriteMultipleRegisters     FunctionCode = 0x10 // Ref: Section 6.12
+	FuncReadFileRecord             FunctionCode = 0x14 // Ref: Section 6.14
+	FuncWriteFileRecord            FunctionCode = 0x15 // Ref: Section 6.15
+	FuncMaskWriteRegister          FunctionCode = 0x16 // Ref: Section 6.16
 	FuncReadWriteMultipleRegisters FunctionCode = 0x17 // Ref: Section 6.17
+	FuncReadFIFOQueue              FunctionCode = 0x18 // Ref: Section 6.18
 	FuncReadDeviceIdentification   FunctionCode = 0x2B // MEI Transport, Ref: Section 6.21
 
+	// FuncWatchRegisters is a gomodbus extension in the user-defined range
+	// (65-72): a long-poll read answered once the registers differ from the
+	// values the client last saw. Ref: Section 5 (Function Code Categories)
+	FuncWatchRegisters FunctionCode = 0x41
+
 	// Exception codes
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Codes)
 	ExceptionFunctionCodeNotSupported ExceptionCode = 0x01 // Ref: Section 7.1
@@ -230,10 +260,20 @@ func (f FunctionCode) String() string {
 		return "WriteMultipleCoils"
 	case FuncWriteMultipleRegisters:
 		return "WriteMultipleRegisters"
+	case FuncReadFileRecord:
+		return "ReadFileRecord"
+	case FuncWriteFileRecord:
+		return "WriteFileRecord"
+	case FuncMaskWriteRegister:
+		return "MaskWriteRegister"
 	case FuncReadWriteMultipleRegisters:
 		return "ReadWriteMultipleRegisters"
+	case FuncReadFIFOQueue:
+		return "ReadFIFOQueue"
 	case FuncReadDeviceIdentification:
 		return "ReadDeviceIdentification"
+	case FuncWatchRegisters:
+		return "WatchRegisters"
 	default:
 		// If it's an exception response
 		if IsException(byte(f)) {
@@ -244,6 +284,45 @@ func (f FunctionCode) String() string {
 	}
 }
 
+// IsWrite reports whether the function code changes device data, including
+// Read/Write Multiple Registers and Mask Write Register
+func (f FunctionCode) IsWrite() bool {
+	switch f {
+	case FuncWriteSingleCoil, FuncWriteSingleRegister,
+		FuncWriteMultipleCoils, FuncWriteMultipleRegisters,
+		FuncMaskWriteRegister, FuncReadWriteMultipleRegisters, FuncWriteFileRecord:
+		return true
+	}
+	return false
+}
+
+// String returns the string representation of a Table or set of tables
+func (t Table) String() string {
+	switch t {
+	case TableCoils:
+		return "Coils"
+	case TableDiscreteInputs:
+		return "DiscreteInputs"
+	case TableHoldingRegisters:
+		return "HoldingRegisters"
+	case TableInputRegisters:
+		return "InputRegisters"
+	case TableAll:
+		return "All"
+	}
+
+	var names []string
+	for _, table := range []Table{TableCoils, TableDiscreteInputs, TableHoldingRegisters, TableInputRegisters} {
+		if t&table != 0 {
+			names = append(names, table.String())
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("Table(0x%02X)", byte(t))
+	}
+	return strings.Join(names, "|")
+}
+
 func (e ExceptionCode) String() string {
 	switch e {
 	case ExceptionFunctionCodeNotSupported:
@@ -346,6 +425,7 @@ const (
 	MaxPDULength    = 253 // Maximum PDU length
 	MaxADULength    = 260 // Maximum ADU length (TCP with header)
 	DefaultTCPPort  = 502 // Default Modbus TCP port
+	DefaultTLSPort  = 802 // Default Modbus/TCP Security port
 
 	// Data sizes
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (Data Encoding)
@@ -365,6 +445,8 @@ const (
 	MaxWriteRegisterCount   = 123  // Maximum number of registers in Write Multiple Registers (0x007B), Ref: Section 6.12
 	MaxReadWriteReadCount   = 125  // Maximum number of registers to read in Read/Write Multiple (0x007D), Ref: Section 6.17
 	MaxReadWriteWriteCount  = 121  // Maximum number of registers to write in Read/Write Multiple (0x0079), Ref: Section 6.17
+	MaxFIFOCount            = 31   // Maximum number of queued registers in Read FIFO Queue, Ref: Section 6.18
+	MaxWatchRegisterCount   = 122  // Maximum number of registers in a FuncWatchRegisters request, whose last-seen values fill the PDU
 
 	// Coil Values as defined in the Modbus specification
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.5 (Write Single Coil)
@@ -0,0 +1,27 @@
+package common
+
+import "testing"
+
+func TestFunctionCode_IsWrite(t *testing.T) {
+	writes := map[FunctionCode]bool{
+		FuncReadCoils:                  false,
+		FuncReadHoldingRegisters:       false,
+		FuncReadExceptionStatus:        false,
+		FuncReadFileRecord:             false,
+		FuncReadFIFOQueue:              false,
+		FuncReadDeviceIdentification:   false,
+		FuncWatchRegisters:             false,
+		FuncWriteSingleCoil:            true,
+		FuncWriteSingleRegister:        true,
+		FuncWriteMultipleCoils:         true,
+		FuncWriteMultipleRegisters:     true,
+		FuncWriteFileRecord:            true,
+		FuncMaskWriteRegister:          true,
+		FuncReadWriteMultipleRegisters: true,
+	}
+	for functionCode, want := range writes {
+		if got := functionCode.IsWrite(); got != want {
+			t.Errorf("%s: expected IsWrite %v, got %v", functionCode, want, got)
+		}
+	}
+}
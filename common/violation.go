@@ -0,0 +1,102 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrProtocolViolation matches every *ProtocolViolationError with errors.Is
+var ErrProtocolViolation = errors.New("protocol violation")
+
+// ViolationKind classifies a malformed frame
+type ViolationKind int
+
+const (
+	// ViolationProtocolID is an MBAP header whose protocol identifier is not 0
+	// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (MBAP Header)
+	ViolationProtocolID ViolationKind = iota
+
+	// ViolationLength is an MBAP length field too short to hold a function
+	// code or too long for a PDU
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (Protocol description)
+	ViolationLength
+
+	// ViolationByteCount is a request whose byte count field disagrees with
+	// the data that follows it
+	ViolationByteCount
+
+	// numViolationKinds is the number of violation kinds
+	numViolationKinds
+)
+
+// ViolationKinds lists every violation kind
+func ViolationKinds() []ViolationKind {
+	kinds := make([]ViolationKind, numViolationKinds)
+	for i := range kinds {
+		kinds[i] = ViolationKind(i)
+	}
+	return kinds
+}
+
+// String returns the name of the violation kind
+func (k ViolationKind) String() string {
+	switch k {
+	case ViolationProtocolID:
+		return "protocol_id"
+	case ViolationLength:
+		return "length"
+	case ViolationByteCount:
+		return "byte_count"
+	default:
+		return fmt.Sprintf("ViolationKind(%d)", int(k))
+	}
+}
+
+// ProtocolViolationError describes a malformed frame received from a peer
+type ProtocolViolationError struct {
+	Kind ViolationKind
+
+	// Header is the raw MBAP header of the frame
+	Header []byte
+
+	// Detail describes the offending field, such as "protocol ID 1"
+	Detail string
+}
+
+// Error implements the error interface
+func (e *ProtocolViolationError) Error() string {
+	return fmt.Sprintf("protocol violation (%s): %s, header % X", e.Kind, e.Detail, e.Header)
+}
+
+// Is makes the error match ErrProtocolViolation
+func (e *ProtocolViolationError) Is(target error) bool {
+	return target == ErrProtocolViolation
+}
+
+// OversizedFramePolicy is what a transport or server does with a frame whose
+// MBAP length field announces more than a PDU (common.MaxPDULength), which
+// no valid frame does
+// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (MBAP Header)
+type OversizedFramePolicy int
+
+const (
+	// OversizedFrameDrop skips the frame's body without buffering it and
+	// keeps the connection
+	OversizedFrameDrop OversizedFramePolicy = iota
+
+	// OversizedFrameClose closes the connection, for peers whose framing
+	// can no longer be trusted
+	OversizedFrameClose
+)
+
+// String returns the name of the policy
+func (p OversizedFramePolicy) String() string {
+	switch p {
+	case OversizedFrameDrop:
+		return "drop"
+	case OversizedFrameClose:
+		return "close"
+	default:
+		return fmt.Sprintf("OversizedFramePolicy(%d)", int(p))
+	}
+}
@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/Moonlight-Companies/gomodbus/cmd/args"
+	"github.com/Moonlight-Companies/gomodbus/client"
+	"github.com/Moonlight-Companies/gomodbus/examples/args"
 	"github.com/Moonlight-Companies/gomodbus/common"
 )
 
@@ -89,4 +90,35 @@ func main() {
 	} else {
 		fmt.Println("\nExtended device identification not supported")
 	}
-}
\ No newline at end of file
+
+	// Devices that support individual access can be queried object by object
+	if identity.ConformityLevel&0x80 == 0 {
+		return
+	}
+
+	fmt.Println("\nReading regular objects individually...")
+	objectIDs := []common.DeviceIDObjectCode{
+		common.DeviceIDVendorName,
+		common.DeviceIDProductCode,
+		common.DeviceIDMajorMinorRevision,
+		common.DeviceIDVendorURL,
+		common.DeviceIDProductName,
+		common.DeviceIDModelName,
+		common.DeviceIDUserAppName,
+	}
+	results, err := client.ReadDeviceIDObjects(ctx, modbusClient, objectIDs, client.DefaultDeviceIDConcurrency)
+	if err != nil {
+		fmt.Println("Error reading individual objects:", err)
+		os.Exit(1)
+	}
+
+	for _, id := range objectIDs {
+		if obj, ok := results.Objects[id]; ok {
+			fmt.Printf("Object 0x%02X:    %s\n", byte(id), obj.Value)
+		} else if err, ok := results.Errors[id]; ok {
+			fmt.Printf("Object 0x%02X:    error: %v\n", byte(id), err)
+		} else {
+			fmt.Printf("Object 0x%02X:    not supported\n", byte(id))
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/Moonlight-Companies/gomodbus/cmd/args"
+	"github.com/Moonlight-Companies/gomodbus/examples/args"
 )
 
 func main() {
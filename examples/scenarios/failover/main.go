@@ -0,0 +1,162 @@
+// Redundancy failover: polls a pair of redundant devices, reading from the
+// active one and switching to the other when it stops answering. Exception
+// responses mean the device is alive and do not trigger a switch.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/client"
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// endpoint is one of the redundant devices
+type endpoint struct {
+	name   string
+	client common.Client
+}
+
+// config holds the scenario settings
+type config struct {
+	address  common.Address
+	quantity common.Quantity
+	interval time.Duration
+	cycles   int // 0 polls until the context is canceled
+}
+
+// poller reads from the active endpoint and fails over between endpoints
+type poller struct {
+	endpoints []endpoint
+	active    int
+	out       io.Writer
+}
+
+// read reads the configured range, trying each endpoint once starting with
+// the active one. It returns the values and the name of the endpoint that
+// served them.
+func (p *poller) read(ctx context.Context, cfg config) ([]common.RegisterValue, string, error) {
+	var lastErr error
+	for range p.endpoints {
+		ep := p.endpoints[p.active]
+		if !ep.client.IsConnected() {
+			if err := ep.client.Connect(ctx); err != nil {
+				lastErr = err
+				p.failover(ep, err)
+				continue
+			}
+		}
+
+		values, err := ep.client.ReadHoldingRegisters(ctx, cfg.address, cfg.quantity)
+		if err == nil || common.IsModbusError(err) {
+			return values, ep.name, err
+		}
+		lastErr = err
+		ep.client.Disconnect(ctx)
+		p.failover(ep, err)
+	}
+	return nil, "", fmt.Errorf("all endpoints failed: %w", lastErr)
+}
+
+// failover makes the next endpoint active
+func (p *poller) failover(from endpoint, err error) {
+	p.active = (p.active + 1) % len(p.endpoints)
+	fmt.Fprintf(p.out, "%s failed (%v), switching to %s\n", from.name, err, p.endpoints[p.active].name)
+}
+
+// run polls the endpoints and writes one line per cycle to out
+func run(ctx context.Context, endpoints []endpoint, cfg config, out io.Writer) error {
+	p := &poller{endpoints: endpoints, out: out}
+
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+
+	for cycle := 1; cfg.cycles == 0 || cycle <= cfg.cycles; cycle++ {
+		values, name, err := p.read(ctx, cfg)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			fmt.Fprintf(out, "cycle %d: %v\n", cycle, err)
+		default:
+			fmt.Fprintf(out, "cycle %d: %s %v\n", cycle, name, values)
+		}
+
+		if cfg.cycles != 0 && cycle == cfg.cycles {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// newEndpoint creates a client for host:port
+func newEndpoint(address string, unitID int, timeout time.Duration, logger common.LoggerInterface) (endpoint, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return endpoint{}, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return endpoint{}, fmt.Errorf("invalid port in %q: %w", address, err)
+	}
+
+	c := client.NewTCPClient(host,
+		transport.WithPort(port),
+		transport.WithTimeoutOption(timeout),
+		transport.WithTransportLogger(logger),
+	).WithOptions(
+		client.WithTCPLogger(logger),
+		client.WithTCPUnitID(common.UnitID(unitID)),
+	)
+	return endpoint{name: address, client: c}, nil
+}
+
+func main() {
+	primary := flag.String("primary", "127.0.0.1:502", "Primary device host:port")
+	backup := flag.String("backup", "127.0.0.1:5020", "Backup device host:port")
+	unitID := flag.Int("unit", 1, "Modbus unit ID (slave ID)")
+	timeout := flag.Duration("timeout", 2*time.Second, "Timeout for Modbus operations")
+	address := flag.Int("address", 0, "First holding register to poll")
+	quantity := flag.Int("quantity", 4, "Number of holding registers to poll")
+	interval := flag.Duration("interval", time.Second, "Poll interval")
+	flag.Parse()
+
+	logger := logging.NewLogger(logging.WithLevel(common.LevelWarn))
+	var endpoints []endpoint
+	for _, addr := range []string{*primary, *backup} {
+		ep, err := newEndpoint(addr, *unitID, *timeout, logger)
+		if err != nil {
+			fmt.Println("Invalid endpoint:", err)
+			os.Exit(1)
+		}
+		endpoints = append(endpoints, ep)
+		defer ep.client.Disconnect(context.Background())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg := config{
+		address:  common.Address(*address),
+		quantity: common.Quantity(*quantity),
+		interval: *interval,
+	}
+	if err := run(ctx, endpoints, cfg, os.Stdout); err != nil && err != context.Canceled {
+		fmt.Println("Polling failed:", err)
+		os.Exit(1)
+	}
+}
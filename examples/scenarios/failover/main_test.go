@@ -0,0 +1,49 @@
+//go:build examples
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/harness"
+	"github.com/Moonlight-Companies/gomodbus/harness/harnesstest"
+	"github.com/Moonlight-Companies/gomodbus/server"
+)
+
+func TestFailover(t *testing.T) {
+	primary, _ := harnesstest.StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(0, 1)
+	}, harness.WithTimeout(500*time.Millisecond))
+	backup, _ := harnesstest.StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(0, 2)
+	}, harness.WithTimeout(500*time.Millisecond))
+
+	endpoints := []endpoint{
+		{name: "primary", client: primary.Client},
+		{name: "backup", client: backup.Client},
+	}
+	cfg := config{address: 0, quantity: 1, interval: 10 * time.Millisecond, cycles: 1}
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := run(ctx, endpoints, cfg, &out); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "primary [1]") {
+		t.Fatalf("Expected the primary to serve the first cycle:\n%s", out.String())
+	}
+
+	// Take the primary down; the next cycle must be served by the backup
+	primary.Stop()
+	out.Reset()
+	if err := run(ctx, endpoints, cfg, &out); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "switching to backup") || !strings.Contains(out.String(), "backup [2]") {
+		t.Errorf("Expected failover to the backup:\n%s", out.String())
+	}
+}
@@ -0,0 +1,75 @@
+// Gateway: a Modbus TCP server forwarding every request to an upstream
+// device through a client, so several masters can share one device
+// connection. Upstream exception responses are passed through unchanged;
+// when the upstream device cannot be reached the gateway answers with
+// Gateway Target Device Failed To Respond (0x0B).
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/client"
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/server"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// run serves the gateway on listener until ctx is canceled, forwarding to upstream
+func run(ctx context.Context, listener net.Listener, upstream server.GatewayTarget, logger common.LoggerInterface) error {
+	srv := server.NewTCPServer("",
+		server.WithServerListener(listener),
+		server.WithServerLogger(logger),
+		server.WithServerGateway(server.NewGateway(server.WithGatewayDefault(upstream))),
+	)
+	if err := srv.Start(ctx); err != nil {
+		return err
+	}
+	defer srv.Stop(context.Background())
+
+	<-ctx.Done()
+	return nil
+}
+
+func main() {
+	port := flag.Int("port", 5020, "TCP port the gateway listens on")
+	upstreamIP := flag.String("upstream-ip", "127.0.0.1", "Upstream device IP address")
+	upstreamPort := flag.Int("upstream-port", 502, "Upstream device port")
+	unitID := flag.Int("upstream-unit", 1, "Upstream device unit ID")
+	timeout := flag.Duration("timeout", 5*time.Second, "Upstream request timeout")
+	flag.Parse()
+
+	logger := logging.NewLogger()
+	upstream := client.NewTCPClientFromTransport(
+		client.NewReconnectingTransport(*upstreamIP, logger, nil,
+			[]transport.TCPTransportOption{
+				transport.WithPort(*upstreamPort),
+				transport.WithTimeoutOption(*timeout),
+				transport.WithTransportLogger(logger),
+			}),
+		client.WithTCPLogger(logger),
+		client.WithTCPUnitID(common.UnitID(*unitID)),
+	)
+	defer upstream.Disconnect(context.Background())
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
+	if err != nil {
+		fmt.Println("Failed to listen:", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Gateway listening on port %d, forwarding to %s:%d\n", *port, *upstreamIP, *upstreamPort)
+	if err := run(ctx, listener, upstream, logger); err != nil {
+		fmt.Println("Gateway failed:", err)
+		os.Exit(1)
+	}
+}
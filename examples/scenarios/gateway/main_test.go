@@ -0,0 +1,70 @@
+//go:build examples
+
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/client"
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/harness"
+	"github.com/Moonlight-Companies/gomodbus/harness/harnesstest"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/server"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+func TestGateway(t *testing.T) {
+	// The loopback pair provides the upstream device and the gateway's upstream client
+	store := server.NewMemoryStore(server.WithMemoryStoreRange(common.TableAll, 0, 99))
+	store.SetHoldingRegister(10, 0xBEEF)
+	device, _ := harnesstest.StartLoopback(t, nil, harness.WithServerOptions(server.WithServerDataStore(store)))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, listener, device.Client, logging.NewNoopLogger()) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	master := client.NewTCPClient("127.0.0.1",
+		transport.WithPort(listener.Addr().(*net.TCPAddr).Port),
+		transport.WithTimeoutOption(2*time.Second),
+		transport.WithTransportLogger(logging.NewNoopLogger()),
+	).WithOptions(client.WithTCPLogger(logging.NewNoopLogger()))
+	if err := master.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect to gateway: %v", err)
+	}
+	defer master.Disconnect(context.Background())
+
+	if err := master.WriteSingleRegister(ctx, 11, 0x1234); err != nil {
+		t.Fatalf("WriteSingleRegister through gateway returned error: %v", err)
+	}
+	values, err := master.ReadHoldingRegisters(ctx, 10, 2)
+	if err != nil {
+		t.Fatalf("ReadHoldingRegisters through gateway returned error: %v", err)
+	}
+	if values[0] != 0xBEEF || values[1] != 0x1234 {
+		t.Errorf("Expected [0xBEEF 0x1234], got %04X", values)
+	}
+
+	// Upstream exceptions are passed through
+	if _, err := master.ReadHoldingRegisters(ctx, 99, 2); !common.IsDataAddressNotAvailableError(err) {
+		t.Errorf("Expected upstream Illegal Data Address, got %v", err)
+	}
+
+	// An unreachable upstream is reported as a gateway exception
+	device.Server.Stop(context.Background())
+	device.Client.Disconnect(context.Background())
+	if _, err := master.ReadHoldingRegisters(ctx, 10, 1); !common.IsGatewayTargetNoResponseError(err) {
+		t.Errorf("Expected Gateway Target Device Failed To Respond, got %v", err)
+	}
+}
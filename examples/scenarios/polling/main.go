@@ -0,0 +1,102 @@
+// Polling dashboard: reads a block of holding registers on a fixed interval
+// and prints them as a table, marking the registers that changed since the
+// previous cycle.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/examples/args"
+)
+
+// config holds the scenario settings
+type config struct {
+	address  common.Address
+	quantity common.Quantity
+	interval time.Duration
+	cycles   int // 0 polls until the context is canceled
+}
+
+func main() {
+	address := flag.Int("address", 0, "First holding register to poll")
+	quantity := flag.Int("quantity", 10, "Number of holding registers to poll")
+	interval := flag.Duration("interval", time.Second, "Poll interval")
+	cycles := flag.Int("cycles", 0, "Number of poll cycles (0 = until interrupted)")
+
+	// Parse command-line arguments
+	modbusArgs := args.ParseArgs()
+
+	// Create a Modbus client
+	modbusClient := modbusArgs.CreateClient()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := modbusClient.Connect(ctx); err != nil {
+		fmt.Println("Failed to connect to Modbus server:", err)
+		os.Exit(1)
+	}
+	defer modbusClient.Disconnect(context.Background())
+
+	cfg := config{
+		address:  common.Address(*address),
+		quantity: common.Quantity(*quantity),
+		interval: *interval,
+		cycles:   *cycles,
+	}
+	if err := run(ctx, modbusClient, cfg, os.Stdout); err != nil && err != context.Canceled {
+		fmt.Println("Polling failed:", err)
+		os.Exit(1)
+	}
+}
+
+// run polls the configured range and writes one table per cycle to out.
+// A failed read is reported and polling continues with the next cycle.
+func run(ctx context.Context, c common.Client, cfg config, out io.Writer) error {
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+
+	var previous []common.RegisterValue
+	for cycle := 1; cfg.cycles == 0 || cycle <= cfg.cycles; cycle++ {
+		values, err := c.ReadHoldingRegisters(ctx, cfg.address, cfg.quantity)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(out, "cycle %d: read failed: %v\n", cycle, err)
+		} else {
+			printTable(out, cycle, cfg.address, values, previous)
+			previous = values
+		}
+
+		if cfg.cycles != 0 && cycle == cfg.cycles {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// printTable writes the values of one cycle, marking changed registers with '*'
+func printTable(out io.Writer, cycle int, address common.Address, values, previous []common.RegisterValue) {
+	fmt.Fprintf(out, "cycle %d at %s\n", cycle, time.Now().Format(time.TimeOnly))
+	fmt.Fprintf(out, "  %-8s %-8s %-6s\n", "address", "value", "hex")
+	for i, value := range values {
+		marker := " "
+		if previous != nil && previous[i] != value {
+			marker = "*"
+		}
+		fmt.Fprintf(out, "%s %-8d %-8d 0x%04X\n", marker, int(address)+i, value, value)
+	}
+}
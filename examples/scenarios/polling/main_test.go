@@ -0,0 +1,40 @@
+//go:build examples
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/harness/harnesstest"
+	"github.com/Moonlight-Companies/gomodbus/server"
+)
+
+func TestPollingDashboard(t *testing.T) {
+	lb, _ := harnesstest.StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(0, 100)
+		store.SetHoldingRegister(1, 200)
+	})
+
+	// Change a register between the first and second cycle
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		lb.Store.SetHoldingRegister(1, 201)
+	}()
+
+	var out bytes.Buffer
+	cfg := config{address: 0, quantity: 2, interval: 60 * time.Millisecond, cycles: 2}
+	if err := run(context.Background(), lb.Client, cfg, &out); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	if got := strings.Count(out.String(), "cycle "); got != 2 {
+		t.Fatalf("Expected 2 cycles, got %d:\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), "* 1        201") {
+		t.Errorf("Expected register 1 to be marked as changed:\n%s", out.String())
+	}
+}
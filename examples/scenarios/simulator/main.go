@@ -0,0 +1,196 @@
+// Device simulator with fault injection: serves a MemoryStore whose input
+// registers drift like live sensor readings, and answers a configurable
+// share of requests with exceptions or after a delay, to exercise client
+// retry and error handling against a misbehaving device.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/server"
+)
+
+// faults configures the misbehavior of the simulated device
+type faults struct {
+	// busyRate is the share of requests answered with Server Device Busy (0x06)
+	busyRate float64
+
+	// failureRate is the share of requests answered with Server Device Failure (0x04)
+	failureRate float64
+
+	// latency delays every request
+	latency time.Duration
+}
+
+// faultStore wraps a DataStore and injects the configured faults
+type faultStore struct {
+	common.DataStore
+	faults faults
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// inject waits for the configured latency and returns an injected exception, if any
+func (s *faultStore) inject(ctx context.Context) error {
+	if s.faults.latency > 0 {
+		select {
+		case <-time.After(s.faults.latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	roll := s.rng.Float64()
+	s.mu.Unlock()
+
+	switch {
+	case roll < s.faults.busyRate:
+		return &common.ModbusError{ExceptionCode: common.ExceptionServerDeviceBusy}
+	case roll < s.faults.busyRate+s.faults.failureRate:
+		return &common.ModbusError{ExceptionCode: common.ExceptionServerDeviceFailure}
+	}
+	return nil
+}
+
+func (s *faultStore) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	if err := s.inject(ctx); err != nil {
+		return nil, err
+	}
+	return s.DataStore.ReadCoils(ctx, address, quantity)
+}
+
+func (s *faultStore) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	if err := s.inject(ctx); err != nil {
+		return nil, err
+	}
+	return s.DataStore.ReadDiscreteInputs(ctx, address, quantity)
+}
+
+func (s *faultStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	if err := s.inject(ctx); err != nil {
+		return nil, err
+	}
+	return s.DataStore.ReadHoldingRegisters(ctx, address, quantity)
+}
+
+func (s *faultStore) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	if err := s.inject(ctx); err != nil {
+		return nil, err
+	}
+	return s.DataStore.ReadInputRegisters(ctx, address, quantity)
+}
+
+func (s *faultStore) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	if err := s.inject(ctx); err != nil {
+		return err
+	}
+	return s.DataStore.WriteSingleCoil(ctx, address, value)
+}
+
+func (s *faultStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	if err := s.inject(ctx); err != nil {
+		return err
+	}
+	return s.DataStore.WriteSingleRegister(ctx, address, value)
+}
+
+func (s *faultStore) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	if err := s.inject(ctx); err != nil {
+		return err
+	}
+	return s.DataStore.WriteMultipleCoils(ctx, address, values)
+}
+
+func (s *faultStore) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	if err := s.inject(ctx); err != nil {
+		return err
+	}
+	return s.DataStore.WriteMultipleRegisters(ctx, address, values)
+}
+
+// sensorCount is the number of simulated sensors, at input registers 0..sensorCount-1
+const sensorCount = 8
+
+// drift moves every sensor reading by a small random step each interval
+// until ctx is canceled
+func drift(ctx context.Context, store *server.MemoryStore, interval time.Duration, rng *rand.Rand) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for addr := common.Address(0); addr < sensorCount; addr++ {
+			value, _ := store.GetInputRegister(addr)
+			store.SetInputRegister(addr, value+common.InputRegisterValue(rng.IntN(5)))
+		}
+	}
+}
+
+// run serves the simulated device on listener until ctx is canceled
+func run(ctx context.Context, listener net.Listener, f faults, driftInterval time.Duration, seed uint64, logger common.LoggerInterface) error {
+	store := server.NewMemoryStore()
+	for addr := common.Address(0); addr < sensorCount; addr++ {
+		store.SetInputRegister(addr, 1000)
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+	srv := server.NewTCPServer("",
+		server.WithServerListener(listener),
+		server.WithServerLogger(logger),
+		server.WithServerDataStore(&faultStore{DataStore: store, faults: f, rng: rng}),
+	)
+	if err := srv.Start(ctx); err != nil {
+		return err
+	}
+	defer srv.Stop(context.Background())
+
+	if driftInterval > 0 {
+		go drift(ctx, store, driftInterval, rand.New(rand.NewPCG(seed+1, seed+1)))
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func main() {
+	port := flag.Int("port", 5020, "TCP port to listen on")
+	busyRate := flag.Float64("busy-rate", 0.05, "Share of requests answered with Server Device Busy")
+	failureRate := flag.Float64("failure-rate", 0.01, "Share of requests answered with Server Device Failure")
+	latency := flag.Duration("latency", 0, "Delay added to every request")
+	driftInterval := flag.Duration("drift", time.Second, "Sensor drift interval (0 = static)")
+	seed := flag.Uint64("seed", uint64(time.Now().UnixNano()), "Random seed")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
+	if err != nil {
+		fmt.Println("Failed to listen:", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Simulating device on port %d (busy %.0f%%, failure %.0f%%, latency %s)\n",
+		*port, *busyRate*100, *failureRate*100, *latency)
+	f := faults{busyRate: *busyRate, failureRate: *failureRate, latency: *latency}
+	if err := run(ctx, listener, f, *driftInterval, *seed, logging.NewLogger()); err != nil {
+		fmt.Println("Simulator failed:", err)
+		os.Exit(1)
+	}
+}
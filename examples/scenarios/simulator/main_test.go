@@ -0,0 +1,92 @@
+//go:build examples
+
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/client"
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// startSimulator runs the simulator on an ephemeral port and returns a connected client
+func startSimulator(t *testing.T, f faults, driftInterval time.Duration) *client.TCPClient {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, listener, f, driftInterval, 1, logging.NewNoopLogger()) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	c := client.NewTCPClient("127.0.0.1",
+		transport.WithPort(listener.Addr().(*net.TCPAddr).Port),
+		transport.WithTimeoutOption(2*time.Second),
+		transport.WithTransportLogger(logging.NewNoopLogger()),
+	).WithOptions(client.WithTCPLogger(logging.NewNoopLogger()))
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { c.Disconnect(context.Background()) })
+	return c
+}
+
+func TestSimulator_Drift(t *testing.T) {
+	c := startSimulator(t, faults{}, 10*time.Millisecond)
+	ctx := context.Background()
+
+	first, err := c.ReadInputRegisters(ctx, 0, sensorCount)
+	if err != nil {
+		t.Fatalf("ReadInputRegisters returned error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		values, err := c.ReadInputRegisters(ctx, 0, sensorCount)
+		if err != nil {
+			t.Fatalf("ReadInputRegisters returned error: %v", err)
+		}
+		for i := range values {
+			if values[i] != first[i] {
+				return
+			}
+		}
+	}
+	t.Error("Expected sensor readings to drift")
+}
+
+func TestSimulator_Faults(t *testing.T) {
+	ctx := context.Background()
+
+	busy := startSimulator(t, faults{busyRate: 1}, 0)
+	if _, err := busy.ReadHoldingRegisters(ctx, 0, 1); !common.IsServerDeviceBusyError(err) {
+		t.Errorf("Expected Server Device Busy, got %v", err)
+	}
+
+	failing := startSimulator(t, faults{failureRate: 1}, 0)
+	if err := failing.WriteSingleCoil(ctx, 0, true); !common.IsServerDeviceFailureError(err) {
+		t.Errorf("Expected Server Device Failure, got %v", err)
+	}
+
+	slow := startSimulator(t, faults{latency: 50 * time.Millisecond}, 0)
+	start := time.Now()
+	if _, err := slow.ReadCoils(ctx, 0, 1); err != nil {
+		t.Fatalf("ReadCoils returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the response to be delayed, took %s", elapsed)
+	}
+}
@@ -0,0 +1,170 @@
+// Package harness pairs a Modbus TCP server with a connected client over the
+// loopback interface, for use in examples, integration tests and soak tools.
+// It does not depend on package testing, so commands can link it; the
+// helpers taking a testing.TB are in package harnesstest.
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/client"
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/server"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// DefaultTimeout is the client request timeout used by NewLoopback
+const DefaultTimeout = 5 * time.Second
+
+// Loopback is a running server and a client connected to it
+type Loopback struct {
+	// Server is the running server
+	Server *server.TCPServer
+
+	// Store is the memory store backing the server
+	Store *server.MemoryStore
+
+	// Client is connected to Server
+	Client *client.TCPClient
+
+	// Port is the ephemeral port the server listens on
+	Port int
+
+	stopOnce sync.Once
+}
+
+// config holds the settings applied by NewLoopback
+type config struct {
+	logger           common.LoggerInterface
+	unitID           common.UnitID
+	timeout          time.Duration
+	serverOptions    []server.TCPServerOption
+	clientOptions    []client.TCPOption
+	transportOptions []transport.TCPTransportOption
+}
+
+// Option is a function that configures NewLoopback
+type Option func(*config)
+
+// WithLogger sets the logger used by both the server and the client.
+// By default both are silent.
+func WithLogger(logger common.LoggerInterface) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithUnitID sets the unit ID the client addresses (default 1)
+func WithUnitID(unitID common.UnitID) Option {
+	return func(c *config) {
+		c.unitID = unitID
+	}
+}
+
+// WithTimeout sets the client request timeout (default DefaultTimeout)
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithServerOptions adds options applied to the server after the defaults
+func WithServerOptions(options ...server.TCPServerOption) Option {
+	return func(c *config) {
+		c.serverOptions = append(c.serverOptions, options...)
+	}
+}
+
+// WithClientOptions adds options applied to the client after the defaults
+func WithClientOptions(options ...client.TCPOption) Option {
+	return func(c *config) {
+		c.clientOptions = append(c.clientOptions, options...)
+	}
+}
+
+// WithTransportOptions adds options applied to the client transport after the defaults
+func WithTransportOptions(options ...transport.TCPTransportOption) Option {
+	return func(c *config) {
+		c.transportOptions = append(c.transportOptions, options...)
+	}
+}
+
+// NewLoopback starts a TCPServer backed by a MemoryStore on an ephemeral
+// loopback port and returns a client already connected to it.
+// storeSetup, if not nil, is called to pre-load the store before the server
+// starts. The caller must call Stop; tests can use harnesstest.StartLoopback,
+// which registers it with t.Cleanup.
+func NewLoopback(storeSetup func(*server.MemoryStore), options ...Option) (*Loopback, error) {
+	cfg := &config{
+		logger:  logging.NewNoopLogger(),
+		unitID:  1,
+		timeout: DefaultTimeout,
+	}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	store := server.NewMemoryStore()
+	if storeSetup != nil {
+		storeSetup(store)
+	}
+
+	// Binding the listener up front means the port is known and accepting
+	// before the client dials, so no polling or sleeps are needed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("harness: failed to create listener: %w", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	serverOptions := append([]server.TCPServerOption{
+		server.WithServerListener(listener),
+		server.WithServerLogger(cfg.logger),
+		server.WithServerDataStore(store),
+	}, cfg.serverOptions...)
+	srv := server.NewTCPServer("127.0.0.1", serverOptions...)
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("harness: failed to start server: %w", err)
+	}
+
+	transportOptions := append([]transport.TCPTransportOption{
+		transport.WithPort(port),
+		transport.WithTimeoutOption(cfg.timeout),
+		transport.WithTransportLogger(cfg.logger),
+	}, cfg.transportOptions...)
+	clientOptions := append([]client.TCPOption{
+		client.WithTCPUnitID(cfg.unitID),
+		client.WithTCPLogger(cfg.logger),
+	}, cfg.clientOptions...)
+	modbusClient := client.NewTCPClient("127.0.0.1", transportOptions...).WithOptions(clientOptions...)
+
+	if err := modbusClient.Connect(ctx); err != nil {
+		srv.Stop(ctx)
+		return nil, fmt.Errorf("harness: failed to connect client: %w", err)
+	}
+
+	return &Loopback{
+		Server: srv,
+		Store:  store,
+		Client: modbusClient,
+		Port:   port,
+	}, nil
+}
+
+// Stop disconnects the client and stops the server. It is safe to call more
+// than once.
+func (lb *Loopback) Stop() {
+	lb.stopOnce.Do(func() {
+		ctx := context.Background()
+		lb.Client.Disconnect(ctx)
+		lb.Server.Stop(ctx)
+	})
+}
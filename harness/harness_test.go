@@ -0,0 +1,80 @@
+package harness
+
+import (
+	"context"
+	"go/build"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/server"
+)
+
+// newLoopback calls NewLoopback, failing the test on error
+func newLoopback(t *testing.T, storeSetup func(*server.MemoryStore), options ...Option) *Loopback {
+	t.Helper()
+	lb, err := NewLoopback(storeSetup, options...)
+	if err != nil {
+		t.Fatalf("NewLoopback failed: %v", err)
+	}
+	t.Cleanup(lb.Stop)
+	return lb
+}
+
+func TestNewLoopback(t *testing.T) {
+	lb := newLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(10, 0xBEEF)
+	})
+
+	if !lb.Client.IsConnected() {
+		t.Fatal("Expected client to be connected")
+	}
+
+	ctx := context.Background()
+	values, err := lb.Client.ReadHoldingRegisters(ctx, 10, 1)
+	if err != nil {
+		t.Fatalf("ReadHoldingRegisters failed: %v", err)
+	}
+	if values[0] != 0xBEEF {
+		t.Errorf("Expected 0xBEEF, got 0x%04X", values[0])
+	}
+
+	if err := lb.Client.WriteSingleRegister(ctx, 11, 0x1234); err != nil {
+		t.Fatalf("WriteSingleRegister failed: %v", err)
+	}
+	if v, _ := lb.Store.GetHoldingRegister(11); v != 0x1234 {
+		t.Errorf("Expected store to hold 0x1234, got 0x%04X", v)
+	}
+
+	// Stop is idempotent
+	lb.Stop()
+	lb.Stop()
+	if lb.Server.IsRunning() {
+		t.Error("Expected server to be stopped")
+	}
+}
+
+func TestNewLoopback_UnitID(t *testing.T) {
+	recorder := server.NewRecorder()
+	lb := newLoopback(t, nil, WithUnitID(7), WithServerOptions(server.WithServerRecorder(recorder)))
+
+	if _, err := lb.Client.ReadCoils(context.Background(), 0, 1); err != nil {
+		t.Fatalf("ReadCoils failed: %v", err)
+	}
+
+	requests := recorder.Requests()
+	if len(requests) != 1 || requests[0].UnitID != common.UnitID(7) {
+		t.Errorf("Expected one request to unit 7, got %+v", requests)
+	}
+}
+
+// Commands such as cmd/soak link the package, so it must not pull in testing
+func TestHarness_NoTestingImport(t *testing.T) {
+	pkg, err := build.ImportDir(".", 0)
+	if err != nil {
+		t.Fatalf("Failed to read the package: %v", err)
+	}
+	if slices.Contains(pkg.Imports, "testing") {
+		t.Error("Expected package harness not to import testing")
+	}
+}
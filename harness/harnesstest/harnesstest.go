@@ -0,0 +1,62 @@
+// Package harnesstest wraps package harness for tests: setup failures and
+// leaks are reported through a testing.TB, and loopback pairs are stopped
+// with t.Cleanup.
+package harnesstest
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/harness"
+	"github.com/Moonlight-Companies/gomodbus/server"
+)
+
+// StartLoopback starts a loopback pair with harness.NewLoopback. The returned
+// cleanup function disconnects the client and stops the server; it is also
+// registered with t.Cleanup, so calling it is optional and calling it more
+// than once is safe.
+// Setup failures are reported with t.Fatalf.
+func StartLoopback(t testing.TB, storeSetup func(*server.MemoryStore), options ...harness.Option) (*harness.Loopback, func()) {
+	t.Helper()
+
+	lb, err := harness.NewLoopback(storeSetup, options...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	t.Cleanup(lb.Stop)
+	return lb, lb.Stop
+}
+
+// Soak starts a loopback pair and runs harness.RunSoak against it, failing
+// the test on any leak or request error
+func Soak(t testing.TB, cfg harness.SoakConfig, options ...harness.Option) harness.SoakReport {
+	t.Helper()
+
+	lb, _ := StartLoopback(t, nil, options...)
+	report, err := harness.RunSoak(context.Background(), lb, cfg)
+	t.Logf("soak: %v", report)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return report
+}
+
+// CheckGoroutines records the current goroutine count and returns a function
+// that fails the test if the count has not returned to it (within slack)
+// after harness.DefaultSoakSettleTimeout. Typical use:
+//
+//	defer harnesstest.CheckGoroutines(t, 0)()
+func CheckGoroutines(t testing.TB, slack int) func() {
+	t.Helper()
+
+	baseline := runtime.NumGoroutine()
+	return func() {
+		t.Helper()
+		if n := harness.SettleGoroutines(baseline+slack, harness.DefaultSoakSettleTimeout); n > baseline+slack {
+			buf := make([]byte, 1<<16)
+			buf = buf[:runtime.Stack(buf, true)]
+			t.Errorf("goroutine leak: %d goroutines, baseline %d\n%s", n, baseline, buf)
+		}
+	}
+}
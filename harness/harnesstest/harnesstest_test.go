@@ -0,0 +1,50 @@
+package harnesstest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/harness"
+	"github.com/Moonlight-Companies/gomodbus/server"
+)
+
+func TestStartLoopback(t *testing.T) {
+	lb, cleanup := StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(10, 0xBEEF)
+	})
+
+	values, err := lb.Client.ReadHoldingRegisters(context.Background(), 10, 1)
+	if err != nil {
+		t.Fatalf("ReadHoldingRegisters failed: %v", err)
+	}
+	if values[0] != 0xBEEF {
+		t.Errorf("Expected 0xBEEF, got 0x%04X", values[0])
+	}
+
+	// Cleanup is idempotent and also runs via t.Cleanup
+	cleanup()
+	cleanup()
+	if lb.Server.IsRunning() {
+		t.Error("Expected server to be stopped after cleanup")
+	}
+}
+
+func TestSoak(t *testing.T) {
+	transactions := 20000
+	if testing.Short() {
+		transactions = 2000
+	}
+
+	report := Soak(t, harness.SoakConfig{
+		Transactions: transactions,
+		Concurrency:  4,
+		ResetEvery:   transactions / 10,
+	})
+
+	if report.Transactions != transactions {
+		t.Errorf("Expected %d transactions, got %d", transactions, report.Transactions)
+	}
+	if report.Resets != 9 {
+		t.Errorf("Expected 9 resets, got %d", report.Resets)
+	}
+}
@@ -0,0 +1,258 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ErrLeak is returned by RunSoak when goroutines, pending transactions or
+// heap usage do not return to their baseline
+var ErrLeak = errors.New("soak: resource leak detected")
+
+// Default soak limits, used for zero SoakConfig fields
+const (
+	DefaultSoakTransactions   = 100000
+	DefaultSoakConcurrency    = 4
+	DefaultSoakGoroutineSlack = 2
+	DefaultSoakMaxHeapGrowth  = 16 << 20
+	DefaultSoakSettleTimeout  = 2 * time.Second
+)
+
+// SoakConfig controls RunSoak
+type SoakConfig struct {
+	// Transactions is the total number of requests to send
+	Transactions int
+
+	// Concurrency is the number of workers sending requests in parallel
+	Concurrency int
+
+	// ResetEvery disconnects and reconnects the client after this many
+	// transactions, exercising the transport's reconnect path. 0 disables it.
+	ResetEvery int
+
+	// GoroutineSlack is how many goroutines above the baseline are tolerated
+	// once the run has settled
+	GoroutineSlack int
+
+	// MaxHeapGrowth is how many bytes the live heap may grow over the run
+	MaxHeapGrowth uint64
+
+	// SettleTimeout bounds the wait for goroutines to exit at the end of the run
+	SettleTimeout time.Duration
+
+	// Progress, if not nil, is called with the running totals after each round
+	Progress func(SoakReport)
+}
+
+// SoakReport summarizes a soak run
+type SoakReport struct {
+	Transactions int           // requests sent
+	Errors       int           // requests that failed or read back a wrong value
+	Resets       int           // client reconnects
+	Duration     time.Duration // wall time of the run
+
+	BaselineGoroutines int // goroutines before the first request
+	PeakGoroutines     int // highest goroutine count sampled during the run
+	FinalGoroutines    int // goroutines after the run settled
+
+	PeakPending int   // most transactions awaiting a response at once
+	HeapGrowth  int64 // live heap bytes after the run minus before it
+}
+
+// String formats the report on one line
+func (r SoakReport) String() string {
+	return fmt.Sprintf("%d transactions (%d errors, %d resets) in %v; goroutines %d -> %d (peak %d); peak pending %d; heap %+d bytes",
+		r.Transactions, r.Errors, r.Resets, r.Duration.Round(time.Millisecond),
+		r.BaselineGoroutines, r.FinalGoroutines, r.PeakGoroutines, r.PeakPending, r.HeapGrowth)
+}
+
+// liveHeap returns the heap in use after a full collection
+func liveHeap() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// SettleGoroutines waits up to timeout for the goroutine count to drop to
+// limit and returns the last count observed
+func SettleGoroutines(limit int, timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= limit || time.Now().After(deadline) {
+			return n
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// RunSoak sends cfg.Transactions requests through lb.Client and checks that
+// the transport does not leak. Each worker writes a holding register at its
+// own address and reads it back. Between rounds of cfg.ResetEvery
+// transactions the client is disconnected and reconnected.
+//
+// The run fails with ErrLeak if transactions remain pending once a round is
+// idle, if goroutines do not return to the baseline measured before the first
+// request (plus cfg.GoroutineSlack), or if the live heap grows by more than
+// cfg.MaxHeapGrowth. It fails with a plain error if any request fails.
+// The report is returned in every case.
+func RunSoak(ctx context.Context, lb *Loopback, cfg SoakConfig) (SoakReport, error) {
+	if cfg.Transactions <= 0 {
+		cfg.Transactions = DefaultSoakTransactions
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultSoakConcurrency
+	}
+	if cfg.GoroutineSlack <= 0 {
+		cfg.GoroutineSlack = DefaultSoakGoroutineSlack
+	}
+	if cfg.MaxHeapGrowth == 0 {
+		cfg.MaxHeapGrowth = DefaultSoakMaxHeapGrowth
+	}
+	if cfg.SettleTimeout <= 0 {
+		cfg.SettleTimeout = DefaultSoakSettleTimeout
+	}
+	round := cfg.ResetEvery
+	if round <= 0 {
+		round = cfg.Transactions
+	}
+
+	var report SoakReport
+	heapBefore := liveHeap()
+	report.BaselineGoroutines = runtime.NumGoroutine()
+	report.PeakGoroutines = report.BaselineGoroutines
+	start := time.Now()
+
+	// Sample goroutines and pending transactions while requests are in flight
+	var peakGoroutines, peakPending atomic.Int64
+	samplerDone := make(chan struct{})
+	samplerStopped := make(chan struct{})
+	go func() {
+		defer close(samplerStopped)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-samplerDone:
+				return
+			case <-ticker.C:
+				storeMax(&peakGoroutines, int64(runtime.NumGoroutine()))
+				storeMax(&peakPending, int64(lb.Client.PendingTransactions()))
+			}
+		}
+	}()
+
+	var errCount atomic.Int64
+	var firstErr error
+	var firstErrOnce sync.Once
+	fail := func(err error) {
+		errCount.Add(1)
+		firstErrOnce.Do(func() { firstErr = err })
+	}
+
+	var leakErr error
+	for sent := 0; sent < cfg.Transactions && ctx.Err() == nil && leakErr == nil; {
+		n := min(round, cfg.Transactions-sent)
+		runSoakRound(ctx, lb, cfg.Concurrency, n, fail)
+		sent += n
+		report.Transactions = sent
+
+		if pending := lb.Client.PendingTransactions(); pending != 0 {
+			leakErr = fmt.Errorf("%w: %d transactions pending after round", ErrLeak, pending)
+		}
+
+		if cfg.ResetEvery > 0 && sent < cfg.Transactions {
+			if err := lb.Client.Disconnect(ctx); err != nil {
+				fail(fmt.Errorf("disconnect: %w", err))
+			}
+			if err := lb.Client.Connect(ctx); err != nil {
+				close(samplerDone)
+				<-samplerStopped
+				return report, fmt.Errorf("soak: reconnect failed: %w", err)
+			}
+			report.Resets++
+		}
+
+		if cfg.Progress != nil {
+			progress := report
+			progress.Errors = int(errCount.Load())
+			progress.Duration = time.Since(start)
+			cfg.Progress(progress)
+		}
+	}
+
+	close(samplerDone)
+	<-samplerStopped
+
+	report.Duration = time.Since(start)
+	report.Errors = int(errCount.Load())
+	report.PeakPending = int(peakPending.Load())
+	report.PeakGoroutines = max(report.PeakGoroutines, int(peakGoroutines.Load()))
+	report.FinalGoroutines = SettleGoroutines(report.BaselineGoroutines+cfg.GoroutineSlack, cfg.SettleTimeout)
+	report.HeapGrowth = int64(liveHeap()) - int64(heapBefore)
+
+	switch {
+	case leakErr != nil:
+		return report, leakErr
+	case ctx.Err() != nil:
+		return report, ctx.Err()
+	case report.PeakPending > cfg.Concurrency:
+		return report, fmt.Errorf("%w: %d transactions pending with %d workers", ErrLeak, report.PeakPending, cfg.Concurrency)
+	case report.FinalGoroutines > report.BaselineGoroutines+cfg.GoroutineSlack:
+		return report, fmt.Errorf("%w: %d goroutines after the run, baseline %d", ErrLeak, report.FinalGoroutines, report.BaselineGoroutines)
+	case report.HeapGrowth > int64(cfg.MaxHeapGrowth):
+		return report, fmt.Errorf("%w: live heap grew by %d bytes", ErrLeak, report.HeapGrowth)
+	case report.Errors > 0:
+		return report, fmt.Errorf("soak: %d of %d transactions failed, first: %w", report.Errors, report.Transactions, firstErr)
+	}
+	return report, nil
+}
+
+// runSoakRound sends n transactions from the given number of workers. Worker w
+// alternately writes a counter to holding register w and reads it back.
+func runSoakRound(ctx context.Context, lb *Loopback, workers, n int, fail func(error)) {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(address common.Address) {
+			defer wg.Done()
+			var value common.RegisterValue
+			write := true
+			for i := next.Add(1); i <= int64(n) && ctx.Err() == nil; i = next.Add(1) {
+				if write = !write; !write {
+					value++
+					if err := lb.Client.WriteSingleRegister(ctx, address, value); err != nil {
+						fail(fmt.Errorf("write register %d: %w", address, err))
+					}
+					continue
+				}
+				values, err := lb.Client.ReadHoldingRegisters(ctx, address, 1)
+				if err != nil {
+					fail(fmt.Errorf("read register %d: %w", address, err))
+				} else if values[0] != value {
+					fail(fmt.Errorf("register %d: read back %d, wrote %d", address, values[0], value))
+				}
+			}
+		}(common.Address(w))
+	}
+	wg.Wait()
+}
+
+// storeMax raises v to n if n is larger
+func storeMax(v *atomic.Int64, n int64) {
+	for {
+		cur := v.Load()
+		if n <= cur || v.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
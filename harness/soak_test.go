@@ -0,0 +1,53 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRunSoak(t *testing.T) {
+	transactions := 2000
+	lb := newLoopback(t, nil)
+	report, err := RunSoak(context.Background(), lb, SoakConfig{
+		Transactions: transactions,
+		Concurrency:  4,
+		ResetEvery:   transactions / 10,
+	})
+	if err != nil {
+		t.Fatalf("RunSoak failed: %v", err)
+	}
+
+	if report.Transactions != transactions {
+		t.Errorf("Expected %d transactions, got %d", transactions, report.Transactions)
+	}
+	if report.Resets != 9 {
+		t.Errorf("Expected 9 resets, got %d", report.Resets)
+	}
+}
+
+func TestRunSoak_DetectsGoroutineLeak(t *testing.T) {
+	lb := newLoopback(t, nil)
+
+	// A goroutine started during the run and never stopped is a leak
+	stop := make(chan struct{})
+	defer close(stop)
+	var once bool
+	_, err := RunSoak(context.Background(), lb, SoakConfig{
+		Transactions:   200,
+		ResetEvery:     100,
+		GoroutineSlack: 1,
+		SettleTimeout:  50e6,
+		Progress: func(SoakReport) {
+			if !once {
+				once = true
+				for i := 0; i < 4; i++ {
+					go func() { <-stop }()
+				}
+			}
+		},
+	})
+	if !errors.Is(err, ErrLeak) {
+		t.Fatalf("Expected ErrLeak, got %v", err)
+	}
+}
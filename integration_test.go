@@ -1,13 +1,29 @@
 package gomodbus
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/client"
 	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/harness"
+	"github.com/Moonlight-Companies/gomodbus/harness/harnesstest"
 	"github.com/Moonlight-Companies/gomodbus/logging"
 	"github.com/Moonlight-Companies/gomodbus/server"
 	"github.com/Moonlight-Companies/gomodbus/transport"
@@ -22,63 +38,22 @@ func TestClientServerIntegration(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Create the server with a memory store
-	store := server.NewMemoryStore()
+	// Start a server with pre-loaded test data and a connected client
+	lb, cleanup := harnesstest.StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetCoil(common.Address(1000), true)
+		store.SetCoil(common.Address(1001), false)
+		store.SetCoil(common.Address(1002), true)
 
-	// Pre-load some test data
-	store.SetCoil(common.Address(1000), true)
-	store.SetCoil(common.Address(1001), false)
-	store.SetCoil(common.Address(1002), true)
+		store.SetHoldingRegister(common.Address(2000), 0x1234)
+		store.SetHoldingRegister(common.Address(2001), 0x5678)
 
-	store.SetHoldingRegister(common.Address(2000), 0x1234)
-	store.SetHoldingRegister(common.Address(2001), 0x5678)
+		store.SetInputRegister(common.Address(3000), 0xABCD)
+		store.SetInputRegister(common.Address(3001), 0xEF01)
+	}, harness.WithLogger(logger), harness.WithUnitID(1))
+	defer cleanup()
 
-	store.SetInputRegister(common.Address(3000), 0xABCD)
-	store.SetInputRegister(common.Address(3001), 0xEF01)
-
-	// Create a listener on a random free port
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("Failed to create listener: %v", err)
-	}
-
-	// Get the actual port
-	serverPort := listener.Addr().(*net.TCPAddr).Port
-
-	// Create the server
-	modbusServer := server.NewTCPServer(
-		"127.0.0.1",
-		server.WithServerListener(listener), // Use the pre-created listener
-		server.WithServerLogger(logger),
-		server.WithServerDataStore(store),
-	)
-
-	// Start the server in a goroutine
-	serverErrCh := make(chan error, 1)
-	go func() {
-		serverErrCh <- modbusServer.Start(ctx)
-	}()
-
-	// Wait briefly for the server to start
-	time.Sleep(100 * time.Millisecond)
-
-	// Create a client that connects to the server
-	modbusClient := client.NewTCPClient(
-		"127.0.0.1",
-		transport.WithPort(serverPort),
-		transport.WithTimeoutOption(5*time.Second),
-		transport.WithTransportLogger(logger),
-	).WithOptions(
-		client.WithTCPUnitID(1),
-		client.WithTCPLogger(logger),
-	)
-
-	// Connect to the server
-	err = modbusClient.Connect(ctx)
-	if err != nil {
-		t.Fatalf("Failed to connect: %v", err)
-	}
-	defer modbusClient.Disconnect(context.Background())
+	store := lb.Store
+	modbusClient := lb.Client
 
 	// Test reading coils
 	coils, err := modbusClient.ReadCoils(ctx, common.Address(1000), common.Quantity(3))
@@ -247,20 +222,419 @@ func TestClientServerIntegration(t *testing.T) {
 				addr, expected, registerValue)
 		}
 	}
+}
+
+// TestDiagnose runs the diagnostic report against a real server
+func TestDiagnose(t *testing.T) {
+	lb, cleanup := harnesstest.StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(common.Address(10), 0x1234)
+	})
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	report, err := client.Diagnose(ctx, fmt.Sprintf("127.0.0.1:%d", lb.Port), client.WithDiagnoseRange(10, 1))
+	if err != nil {
+		t.Fatalf("Diagnose failed: %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("Expected every step to succeed: %+v", report.Steps)
+	}
+
+	names := []string{"connect", "device_identification", "read_coils", "read_discrete_inputs", "read_holding_registers", "read_input_registers"}
+	if len(report.Steps) != len(names) {
+		t.Fatalf("Expected %d steps, got %d", len(names), len(report.Steps))
+	}
+	for i, step := range report.Steps {
+		if step.Name != names[i] {
+			t.Errorf("Step %d: expected %s, got %s", i, names[i], step.Name)
+		}
+		if i > 0 && len(step.Exchanges) != 1 {
+			t.Errorf("Step %s: expected one exchange, got %d", step.Name, len(step.Exchanges))
+		}
+	}
+	if exchange := report.Steps[4].Exchanges[0]; exchange.Request != "000a0001" || exchange.Response != "021234" {
+		t.Errorf("Unexpected holding register frames %+v", exchange)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded["target"] != fmt.Sprintf("127.0.0.1:%d", lb.Port) {
+		t.Errorf("Unexpected JSON report %s", buf.String())
+	}
+
+	// A refused connection is reported as a failed connect step only
+	report, _ = client.Diagnose(ctx, "127.0.0.1:1")
+	if report.OK() || len(report.Steps) != 1 || report.Steps[0].Error == "" {
+		t.Errorf("Expected only a failed connect step, got %+v", report.Steps)
+	}
+
+	if _, err := client.Diagnose(ctx, "127.0.0.1:notaport"); err == nil {
+		t.Error("Expected an error for a malformed target")
+	}
+}
+
+func TestReadinessGate(t *testing.T) {
+	for _, tc := range []struct {
+		vendor  string
+		wantErr error
+	}{
+		{"gomodbus", nil},
+		{"Other Vendor", client.ErrDeviceMismatch},
+	} {
+		lb, cleanup := harnesstest.StartLoopback(t, nil, harness.WithClientOptions(
+			client.WithTCPBaseOptions(client.WithReadinessGate(client.ExpectDeviceIdentity(tc.vendor, "GM-001")))))
+
+		err := lb.Client.WriteSingleCoil(context.Background(), 0, true)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("Vendor %q: expected %v, got %v", tc.vendor, tc.wantErr, err)
+		}
+		if coil, _ := lb.Store.GetCoil(0); coil != (tc.wantErr == nil) {
+			t.Errorf("Vendor %q: expected the write to reach the device only when it matches", tc.vendor)
+		}
+		cleanup()
+	}
+}
+
+func TestSweepInventory(t *testing.T) {
+	lb, cleanup := harnesstest.StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(common.Address(0), 0x1234)
+	})
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	target := fmt.Sprintf("127.0.0.1:%d", lb.Port)
+	records := client.SweepInventory(ctx, []client.InventoryTarget{
+		{Target: target, UnitID: 1},
+		{Target: target, UnitID: 2},
+		{Target: "127.0.0.1:1"},
+	}, client.WithInventoryConcurrency(2), client.WithInventoryFingerprint([]client.Range{
+		{Table: common.TableHoldingRegisters, Address: 0, Quantity: 4},
+		{Table: common.TableCoils, Address: 0, Quantity: 8},
+	}))
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	first, second := records[0], records[1]
+	if first.Error != "" || first.Target != target || first.UnitID != 1 || first.VendorName == "" {
+		t.Errorf("Unexpected record %+v", first)
+	}
+	if !slices.Contains(first.FunctionCodes, "0x03") {
+		t.Errorf("Expected 0x03 among the function codes, got %v", first.FunctionCodes)
+	}
+	if first.Fingerprint == "" || first.Fingerprint != second.Fingerprint {
+		t.Errorf("Expected the same register map fingerprint, got %q and %q", first.Fingerprint, second.Fingerprint)
+	}
+	if records[2].Error == "" || records[2].VendorName != "" {
+		t.Errorf("Expected an unreachable device to report only an error, got %+v", records[2])
+	}
+
+	// Values do not change the fingerprint
+	lb.Client.WriteSingleRegister(ctx, 1, 0x5678)
+	again := client.SweepInventory(ctx, []client.InventoryTarget{{Target: target, UnitID: 1}},
+		client.WithInventoryFingerprint([]client.Range{
+			{Table: common.TableHoldingRegisters, Address: 0, Quantity: 4},
+			{Table: common.TableCoils, Address: 0, Quantity: 8},
+		}))
+	if again[0].Fingerprint != first.Fingerprint {
+		t.Errorf("Expected a stable fingerprint, got %q and %q", again[0].Fingerprint, first.Fingerprint)
+	}
+
+	var buf bytes.Buffer
+	if err := (client.CSVInventory{}).EncodeInventory(&buf, records); err != nil {
+		t.Fatalf("EncodeInventory failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 4 || rows[0][0] != "target" || rows[1][0] != target || rows[1][11] != first.Fingerprint {
+		t.Errorf("Unexpected CSV inventory %v: %v", rows, err)
+	}
+
+	buf.Reset()
+	if err := (client.JSONInventory{}).EncodeInventory(&buf, records); err != nil {
+		t.Fatalf("EncodeInventory failed: %v", err)
+	}
+	var decoded []client.InventoryRecord
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 3 || decoded[0].VendorName != first.VendorName {
+		t.Errorf("Unexpected JSON inventory %s", buf.String())
+	}
+}
+
+func TestFutureCancel(t *testing.T) {
+	lb, cleanup := harnesstest.StartLoopback(t, func(store *server.MemoryStore) {
+		store.SetHoldingRegister(common.Address(0), 42)
+	}, harness.WithServerOptions(server.WithServerResponseDelay(map[common.FunctionCode]time.Duration{
+		common.FuncReadInputRegisters: 300 * time.Millisecond,
+	})))
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	slow := lb.Client.Go(ctx, common.FuncReadInputRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	slow.Cancel()
+	if _, err := slow.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected Cancel to return at once, took %v", elapsed)
+	}
+	if pending := lb.Client.PendingTransactions(); pending != 0 {
+		t.Errorf("Expected the transaction ID to be released, %d pending", pending)
+	}
+
+	// The shared context is untouched and the late response is discarded
+	values, err := lb.Client.ReadHoldingRegisters(ctx, 0, 1)
+	if err != nil || len(values) != 1 || values[0] != 42 {
+		t.Fatalf("Expected register 0 to read 42, got %v (%v)", values, err)
+	}
+
+	fast := lb.Client.Go(ctx, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+	response, err := fast.Wait()
+	if err != nil || !bytes.Equal(response.GetPDU().Data, []byte{0x02, 0x00, 0x2A}) {
+		t.Errorf("Expected a response to the future, got %v (%v)", response, err)
+	}
+}
+
+func TestApplyRecipe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The third device pins register 101, so the recipe does not verify there
+	pinned := server.NewForcedValuesOverlay(server.NewMemoryStore())
+	if err := pinned.Force(ctx, common.TableHoldingRegisters, 101, 7, "pinned"); err != nil {
+		t.Fatalf("Failed to force: %v", err)
+	}
+	var fleet []*client.BaseClient
+	var stores []common.DataStore
+	for i := 0; i < 3; i++ {
+		var options []harness.Option
+		if i == 2 {
+			options = append(options, harness.WithServerOptions(server.WithServerDataStore(pinned)))
+		}
+		lb, cleanup := harnesstest.StartLoopback(t, nil, options...)
+		defer cleanup()
+		fleet = append(fleet, lb.Client.BaseClient)
+		stores = append(stores, lb.Store)
+	}
+	stores[2] = pinned
+
+	recipe := client.Recipe{
+		{Address: 100, Values: []common.RegisterValue{1, 2, 3}},
+		{Address: 200, Values: make([]common.RegisterValue, 200)},
+	}
+	rollback := client.Recipe{{Address: 100, Values: []common.RegisterValue{0, 0, 0}}}
+	var progress []client.RecipeProgress
+	results := client.ApplyRecipe(ctx, fleet, recipe,
+		client.WithRecipeConcurrency(2),
+		client.WithRecipeVerify(),
+		client.WithRecipeRollback(rollback),
+		client.WithRecipeProgress(func(p client.RecipeProgress) { progress = append(progress, p) }))
+
+	for i, result := range results[:2] {
+		if result.Index != i || result.Err != nil || !result.Verified || result.Applied != 2 {
+			t.Errorf("Device %d: expected the recipe to apply and verify, got %+v", i, result)
+		}
+		values, _ := stores[i].ReadHoldingRegisters(ctx, 100, 3)
+		if !slices.Equal(values, []common.RegisterValue{1, 2, 3}) {
+			t.Errorf("Device %d: expected the recipe values, got %v", i, values)
+		}
+	}
+
+	failed := results[2]
+	if !errors.Is(failed.Err, client.ErrRecipeVerify) || !failed.RolledBack || failed.Verified {
+		t.Errorf("Expected the pinned device to fail verification and roll back, got %+v", failed)
+	}
+	if values, _ := stores[2].ReadHoldingRegisters(ctx, 100, 1); values[0] != 0 {
+		t.Errorf("Expected the rollback to restore register 100, got %d", values[0])
+	}
+
+	if len(progress) != 3 {
+		t.Fatalf("Expected progress after each device, got %d reports", len(progress))
+	}
+	if last := progress[2]; last.Done != 3 || last.Failed != 1 || last.Total != 3 {
+		t.Errorf("Expected 3 done and 1 failed, got %+v", last)
+	}
+}
+
+func TestWatchRegisters(t *testing.T) {
+	for _, notifications := range []bool{true, false} {
+		t.Run(fmt.Sprintf("notifications=%v", notifications), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			var options []harness.Option
+			if notifications {
+				options = append(options, harness.WithServerOptions(server.WithChangeNotifications(10*time.Millisecond)))
+			}
+			lb, cleanup := harnesstest.StartLoopback(t, nil, options...)
+			defer cleanup()
+
+			changes := make(chan client.RegisterChange)
+			go func() {
+				defer close(changes)
+				for change := range lb.Client.WatchRegisters(ctx, common.TableHoldingRegisters, 100, 2,
+					client.WithWatchWait(time.Second), client.WithWatchPollInterval(20*time.Millisecond)) {
+					changes <- change
+				}
+			}()
+			next := func(want ...common.RegisterValue) {
+				t.Helper()
+				change := <-changes
+				if change.Err != nil || !slices.Equal(change.Values, want) {
+					t.Fatalf("Expected %v, got %+v", want, change)
+				}
+			}
+
+			next(0, 0)
+			// The watch does not hold up other requests on the connection
+			if err := lb.Client.WriteSingleRegister(ctx, 101, 5); err != nil {
+				t.Fatalf("Failed to write: %v", err)
+			}
+			next(0, 5)
+			lb.Store.WriteMultipleRegisters(ctx, 100, []common.RegisterValue{1, 5})
+			next(1, 5)
+
+			reads := lb.Server.ConnectedClients()[0].FunctionCodeStats[common.FuncReadHoldingRegisters]
+			if notifications && reads != 1 {
+				t.Errorf("Expected only the initial read, got %d reads", reads)
+			}
+			if !notifications && reads < 3 {
+				t.Errorf("Expected polling reads without notifications, got %d", reads)
+			}
+
+			cancel()
+			for range changes {
+			}
+		})
+	}
+}
 
-	// Stop the server
-	err = modbusServer.Stop(ctx)
+// testAuthority issues certificates for Modbus/TCP Security tests
+type testAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestAuthority(t *testing.T) *testAuthority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testAuthority{cert: cert, key: key, pool: pool}
+}
+
+// issue returns a certificate for 127.0.0.1 carrying role, if not empty
+func (a *testAuthority) issue(t *testing.T, serial int64, role string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
-		t.Fatalf("Failed to stop server: %v", err)
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: fmt.Sprintf("device %d", serial)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if role != "" {
+		value, _ := asn1.MarshalWithParams(role, "utf8")
+		template.ExtraExtensions = []pkix.Extension{{Id: common.RoleOID, Value: value}}
 	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
 
-	// Check if there was an error starting the server
-	select {
-	case err := <-serverErrCh:
-		if err != nil && err != context.Canceled {
-			t.Fatalf("Server error: %v", err)
+func TestModbusSecurity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ca := newTestAuthority(t)
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, 2, "")},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool,
+	}
+	authorizer := server.RoleFunctions(map[string][]common.FunctionCode{
+		"viewer":   {common.FuncReadHoldingRegisters},
+		"operator": {common.FuncReadHoldingRegisters, common.FuncWriteSingleRegister},
+	})
+	connect := func(role string) *harness.Loopback {
+		t.Helper()
+		clientConfig := &tls.Config{
+			Certificates: []tls.Certificate{ca.issue(t, 3, role)},
+			RootCAs:      ca.pool,
 		}
-	default:
-		// Server is still running, this is fine
+		lb, cleanup := harnesstest.StartLoopback(t, nil,
+			harness.WithServerOptions(server.WithServerTLSConfig(serverConfig), server.WithServerAuthorizer(authorizer)),
+			harness.WithTransportOptions(transport.WithTLSConfig(clientConfig)))
+		t.Cleanup(cleanup)
+		return lb
+	}
+
+	operator := connect("operator")
+	if err := operator.Client.WriteSingleRegister(ctx, 100, 7); err != nil {
+		t.Fatalf("Expected the operator to write, got %v", err)
+	}
+	values, err := operator.Client.ReadHoldingRegisters(ctx, 100, 1)
+	if err != nil || values[0] != 7 {
+		t.Fatalf("Expected to read the written value, got %v, %v", values, err)
+	}
+	if clients := operator.Server.ConnectedClients(); len(clients) != 1 || clients[0].Role != "operator" {
+		t.Errorf("Expected the operator role, got %v", clients)
+	}
+
+	// The viewer may read but not write
+	viewer := connect("viewer")
+	if _, err := viewer.Client.ReadHoldingRegisters(ctx, 100, 1); err != nil {
+		t.Errorf("Expected the viewer to read, got %v", err)
+	}
+	if err := viewer.Client.WriteSingleRegister(ctx, 100, 8); !common.IsFunctionNotSupportedError(err) {
+		t.Errorf("Expected the viewer's write to be denied, got %v", err)
+	}
+
+	// A client without a certificate is refused
+	plain := transport.NewTCPTransport("127.0.0.1", transport.WithPort(operator.Port),
+		transport.WithTransportLogger(logging.NewNoopLogger()),
+		transport.WithTLSConfig(&tls.Config{RootCAs: ca.pool}))
+	err = plain.Connect(ctx)
+	if err == nil {
+		_, err = plain.Send(ctx, transport.NewRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x64, 0x00, 0x01}))
+		plain.Disconnect(ctx)
+	}
+	if err == nil {
+		t.Error("Expected a client without a certificate to be refused")
 	}
 }
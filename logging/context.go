@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"maps"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ContextLogger logs to the logger carried by the ctx of each call (see
+// common.ContextWithLogger), and to its fallback when there is none. Give it
+// to clients, transports and servers so per-request loggers, such as ones
+// with a trace ID, receive the lines logged for their request:
+//
+//	logger := logging.NewContextLogger(logging.NewLogger())
+//	modbusClient := client.NewTCPClient(host, transport.WithTransportLogger(logger)).
+//		WithOptions(client.WithTCPLogger(logger))
+//	ctx = common.ContextWithLogger(ctx, requestLogger)
+//	modbusClient.ReadHoldingRegisters(ctx, 0, 10)
+//
+// Fields added with WithFields, such as the endpoint or device name, are
+// added to the context's logger too. GetLevel and SetLevel apply to the
+// fallback; context loggers filter by their own level.
+type ContextLogger struct {
+	fallback common.LoggerInterface
+	fields   map[string]interface{}
+}
+
+// NewContextLogger creates a context logger logging to fallback for calls
+// whose ctx carries no logger
+func NewContextLogger(fallback common.LoggerInterface) *ContextLogger {
+	return &ContextLogger{fallback: fallback}
+}
+
+// logger returns the logger for a call with ctx
+func (l *ContextLogger) logger(ctx context.Context) common.LoggerInterface {
+	logger := common.LoggerFromContext(ctx, nil)
+	if nested, ok := logger.(*ContextLogger); ok {
+		logger = nested.fallback
+	}
+	if logger == nil {
+		return l.fallback
+	}
+	if len(l.fields) > 0 {
+		return logger.WithFields(l.fields)
+	}
+	return logger
+}
+
+// Trace logs a trace message
+func (l *ContextLogger) Trace(ctx context.Context, format string, args ...interface{}) {
+	l.logger(ctx).Trace(ctx, format, args...)
+}
+
+// Debug logs a debug message
+func (l *ContextLogger) Debug(ctx context.Context, format string, args ...interface{}) {
+	l.logger(ctx).Debug(ctx, format, args...)
+}
+
+// Info logs an info message
+func (l *ContextLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	l.logger(ctx).Info(ctx, format, args...)
+}
+
+// Warn logs a warning message
+func (l *ContextLogger) Warn(ctx context.Context, format string, args ...interface{}) {
+	l.logger(ctx).Warn(ctx, format, args...)
+}
+
+// Error logs an error message
+func (l *ContextLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	l.logger(ctx).Error(ctx, format, args...)
+}
+
+// Hexdump logs a hexdump if the chosen logger supports it
+func (l *ContextLogger) Hexdump(ctx context.Context, data []byte) {
+	if hexLogger, ok := l.logger(ctx).(common.LoggerInterfaceHexdump); ok {
+		hexLogger.Hexdump(ctx, data)
+	}
+}
+
+// WithFields returns a context logger adding fields to both loggers
+func (l *ContextLogger) WithFields(fields map[string]interface{}) common.LoggerInterface {
+	merged := maps.Clone(l.fields)
+	if merged == nil {
+		merged = make(map[string]interface{}, len(fields))
+	}
+	maps.Copy(merged, fields)
+	return &ContextLogger{fallback: l.fallback.WithFields(fields), fields: merged}
+}
+
+// GetLevel returns the level of the fallback logger
+func (l *ContextLogger) GetLevel() common.LogLevel {
+	return l.fallback.GetLevel()
+}
+
+// SetLevel sets the level of the fallback logger
+func (l *ContextLogger) SetLevel(level common.LogLevel) {
+	l.fallback.SetLevel(level)
+}
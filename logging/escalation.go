@@ -0,0 +1,204 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// EscalatingLogger wraps a logger and watches the rate of each class of
+// warning, a class being the format string together with the logger's fields
+// (such as the device name), so "timeouts to device X" is one class. While a
+// class stays below the threshold its warnings pass through unchanged. Once
+// it reaches threshold occurrences within the window, one Error summarizing
+// it is logged instead, further occurrences are only counted and summarized
+// once per window, and when the rate falls below the threshold again a
+// recovery message is logged at Info. Other levels pass through unchanged.
+//
+// Recovery is noticed on the next call to the logger or to Check, so
+// long-lived quiet programs should call Check periodically.
+type EscalatingLogger struct {
+	next  common.LoggerInterface
+	scope string // fields of the logger, part of every class key
+	state *escalationState
+}
+
+// escalationState is shared by an EscalatingLogger and the loggers derived
+// from it with WithFields
+type escalationState struct {
+	threshold int
+	window    time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	classes map[string]*warningClass
+}
+
+// warningClass tracks the recent occurrences of one class of warning
+type warningClass struct {
+	next       common.LoggerInterface
+	times      []time.Time
+	escalated  bool
+	suppressed int
+	summarized time.Time
+	last       string // latest message, for summaries
+}
+
+// NewEscalatingLogger wraps next, escalating a class of warnings to Error
+// once it occurs threshold times within window
+func NewEscalatingLogger(next common.LoggerInterface, threshold int, window time.Duration) *EscalatingLogger {
+	return &EscalatingLogger{
+		next: next,
+		state: &escalationState{
+			threshold: max(threshold, 1),
+			window:    window,
+			now:       time.Now,
+			classes:   make(map[string]*warningClass),
+		},
+	}
+}
+
+// Trace logs a trace message
+func (l *EscalatingLogger) Trace(ctx context.Context, format string, args ...interface{}) {
+	l.Check(ctx)
+	l.next.Trace(ctx, format, args...)
+}
+
+// Debug logs a debug message
+func (l *EscalatingLogger) Debug(ctx context.Context, format string, args ...interface{}) {
+	l.Check(ctx)
+	l.next.Debug(ctx, format, args...)
+}
+
+// Info logs an info message
+func (l *EscalatingLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	l.Check(ctx)
+	l.next.Info(ctx, format, args...)
+}
+
+// Warn logs a warning, or counts it when its class is escalated
+func (l *EscalatingLogger) Warn(ctx context.Context, format string, args ...interface{}) {
+	l.Check(ctx)
+	s := l.state
+	message := fmt.Sprintf(format, args...)
+
+	s.mu.Lock()
+	key := l.scope + format
+	class := s.classes[key]
+	if class == nil {
+		class = &warningClass{next: l.next}
+		s.classes[key] = class
+	}
+	now := s.now()
+	class.prune(now, s.window)
+	class.times = append(class.times, now)
+	class.last = message
+
+	switch {
+	case class.escalated:
+		class.suppressed++
+		if now.Sub(class.summarized) < s.window {
+			s.mu.Unlock()
+			return
+		}
+		count := class.suppressed
+		class.suppressed = 0
+		class.summarized = now
+		s.mu.Unlock()
+		l.next.Error(ctx, "Still failing: %d more occurrences in the last %s, latest: %s", count, s.window, message)
+	case len(class.times) >= s.threshold:
+		class.escalated = true
+		class.summarized = now
+		count := len(class.times)
+		s.mu.Unlock()
+		l.next.Error(ctx, "Escalated: %d occurrences within %s, latest: %s; further occurrences are summarized", count, s.window, message)
+	default:
+		s.mu.Unlock()
+		l.next.Warn(ctx, format, args...)
+	}
+}
+
+// Error logs an error message
+func (l *EscalatingLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	l.Check(ctx)
+	l.next.Error(ctx, format, args...)
+}
+
+// Check de-escalates the classes whose rate fell below the threshold,
+// logging a recovery message for each
+func (l *EscalatingLogger) Check(ctx context.Context) {
+	s := l.state
+	type recovery struct {
+		next       common.LoggerInterface
+		last       string
+		suppressed int
+	}
+	var recovered []recovery
+
+	s.mu.Lock()
+	now := s.now()
+	for key, class := range s.classes {
+		class.prune(now, s.window)
+		if class.escalated && len(class.times) < s.threshold {
+			recovered = append(recovered, recovery{class.next, class.last, class.suppressed})
+			class.escalated = false
+			class.suppressed = 0
+		}
+		if len(class.times) == 0 && !class.escalated {
+			delete(s.classes, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, r := range recovered {
+		r.next.Info(ctx, "Recovered: below %d occurrences per %s (%d more since the last summary), latest was: %s",
+			s.threshold, s.window, r.suppressed, r.last)
+	}
+}
+
+// WithFields returns a logger with the given fields sharing the escalation
+// state; its fields become part of the class of its warnings
+func (l *EscalatingLogger) WithFields(fields map[string]interface{}) common.LoggerInterface {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var scope strings.Builder
+	scope.WriteString(l.scope)
+	for _, k := range keys {
+		fmt.Fprintf(&scope, "%s=%v ", k, fields[k])
+	}
+	return &EscalatingLogger{next: l.next.WithFields(fields), scope: scope.String(), state: l.state}
+}
+
+// GetLevel returns the level of the wrapped logger
+func (l *EscalatingLogger) GetLevel() common.LogLevel {
+	return l.next.GetLevel()
+}
+
+// SetLevel sets the level of the wrapped logger
+func (l *EscalatingLogger) SetLevel(level common.LogLevel) {
+	l.next.SetLevel(level)
+}
+
+// Hexdump forwards to the wrapped logger if it supports hexdumps
+func (l *EscalatingLogger) Hexdump(ctx context.Context, data []byte) {
+	if hexLogger, ok := l.next.(common.LoggerInterfaceHexdump); ok {
+		hexLogger.Hexdump(ctx, data)
+	}
+}
+
+// prune drops occurrences older than window
+func (c *warningClass) prune(now time.Time, window time.Duration) {
+	i := 0
+	for i < len(c.times) && now.Sub(c.times[i]) >= window {
+		i++
+	}
+	c.times = c.times[i:]
+}
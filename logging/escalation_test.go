@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestEscalatingLogger(t *testing.T) {
+	ctx := context.Background()
+	var out strings.Builder
+	logger := NewEscalatingLogger(NewLogger(WithWriter(&out), WithLevel(common.LevelInfo)), 3, time.Minute)
+	start := time.Now()
+	now := start
+	logger.state.now = func() time.Time { return now }
+	at := func(seconds int) { now = start.Add(time.Duration(seconds) * time.Second) }
+
+	deviceA := logger.WithFields(map[string]interface{}{"device": "A"})
+	deviceB := logger.WithFields(map[string]interface{}{"device": "B"})
+
+	// lines returns the log lines written since the last call
+	lines := func() []string {
+		text := strings.TrimSpace(out.String())
+		out.Reset()
+		if text == "" {
+			return nil
+		}
+		return strings.Split(text, "\n")
+	}
+
+	for i := 0; i < 2; i++ {
+		at(i)
+		deviceA.Warn(ctx, "Timeout reading %d", i)
+	}
+	if got := lines(); len(got) != 2 || !strings.Contains(got[0], "WARN: Timeout reading 0") {
+		t.Fatalf("Expected two warnings below the threshold, got %q", got)
+	}
+
+	// The third occurrence escalates once
+	at(2)
+	deviceA.Warn(ctx, "Timeout reading %d", 2)
+	got := lines()
+	if len(got) != 1 || !strings.Contains(got[0], "ERROR: Escalated: 3 occurrences within 1m0s, latest: Timeout reading 2") ||
+		!strings.Contains(got[0], `device="A"`) {
+		t.Fatalf("Expected one escalation error for device A, got %q", got)
+	}
+
+	// The same warning for another device is its own class
+	deviceB.Warn(ctx, "Timeout reading %d", 2)
+	if got := lines(); len(got) != 1 || !strings.Contains(got[0], "WARN:") {
+		t.Errorf("Expected device B's warning to pass through, got %q", got)
+	}
+
+	// Further occurrences are summarized once per window
+	for s := 10; s <= 60; s += 10 {
+		at(s)
+		deviceA.Warn(ctx, "Timeout reading %d", s)
+	}
+	if got := lines(); len(got) != 0 {
+		t.Errorf("Expected occurrences within the window to be suppressed, got %q", got)
+	}
+	at(70)
+	deviceA.Warn(ctx, "Timeout reading %d", 70)
+	if got := lines(); len(got) != 1 || !strings.Contains(got[0], "ERROR: Still failing: 7 more occurrences") {
+		t.Errorf("Expected a summary, got %q", got)
+	}
+
+	// Once quiet, the next call reports the recovery
+	at(200)
+	logger.Info(ctx, "Poll cycle done")
+	got = lines()
+	if len(got) != 2 || !strings.Contains(got[0], "INFO: Recovered:") || !strings.Contains(got[0], `device="A"`) {
+		t.Fatalf("Expected a recovery message before the info message, got %q", got)
+	}
+	deviceA.Warn(ctx, "Timeout reading %d", 200)
+	if got := lines(); len(got) != 1 || !strings.Contains(got[0], "WARN: Timeout reading 200") {
+		t.Errorf("Expected warnings to pass through after recovery, got %q", got)
+	}
+}
@@ -227,6 +227,11 @@ func (l *Logger) log(ctx context.Context, level, format string, args ...interfac
 		entry += " " + strings.Join(fieldStrings, " ")
 	}
 
+	// Add the correlation ID of the operation, see common.WithCorrelationID
+	if id := common.CorrelationID(ctx); id != "" {
+		entry += fmt.Sprintf(" correlation_id=%q", id)
+	}
+
 	// Add a newline if not already present
 	if entry[len(entry)-1] != '\n' {
 		entry += "\n"
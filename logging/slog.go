@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// SlogLevelTrace is the slog level of Trace messages and hexdumps, below
+// slog.LevelDebug
+const SlogLevelTrace = slog.Level(-8)
+
+// SlogLogger implements common.LoggerInterface and
+// common.LoggerInterfaceHexdump on top of a *slog.Logger, so the module logs
+// through the application's structured logging. Messages are formatted with
+// fmt, fields become attributes, and the correlation ID of the ctx (see
+// common.WithCorrelationID) is added as correlation_id. The ctx is passed on
+// to the slog handler, which may read trace IDs from it.
+type SlogLogger struct {
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	level common.LogLevel
+}
+
+// NewSlogLogger creates a logger writing to logger, or to slog.Default() if
+// logger is nil. Its level is LevelTrace, leaving filtering to the slog
+// handler, until SetLevel is called.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger, level: common.LevelTrace}
+}
+
+// slogLevels maps log levels to slog levels
+var slogLevels = map[common.LogLevel]slog.Level{
+	common.LevelTrace: SlogLevelTrace,
+	common.LevelDebug: slog.LevelDebug,
+	common.LevelInfo:  slog.LevelInfo,
+	common.LevelWarn:  slog.LevelWarn,
+	common.LevelError: slog.LevelError,
+}
+
+// enabled reports whether messages of level are logged
+func (l *SlogLogger) enabled(ctx context.Context, level common.LogLevel) bool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return l.GetLevel() <= level && l.logger.Enabled(ctx, slogLevels[level])
+}
+
+// log logs a message of level with the correlation ID of ctx
+func (l *SlogLogger) log(ctx context.Context, level common.LogLevel, message string, attrs ...slog.Attr) {
+	if id := common.CorrelationID(ctx); id != "" {
+		attrs = append(attrs, slog.String("correlation_id", id))
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l.logger.LogAttrs(ctx, slogLevels[level], message, attrs...)
+}
+
+// logf formats and logs a message if its level is enabled
+func (l *SlogLogger) logf(ctx context.Context, level common.LogLevel, format string, args ...interface{}) {
+	if l.enabled(ctx, level) {
+		l.log(ctx, level, fmt.Sprintf(format, args...))
+	}
+}
+
+// Trace logs a trace message
+func (l *SlogLogger) Trace(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, common.LevelTrace, format, args...)
+}
+
+// Debug logs a debug message
+func (l *SlogLogger) Debug(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, common.LevelDebug, format, args...)
+}
+
+// Info logs an info message
+func (l *SlogLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, common.LevelInfo, format, args...)
+}
+
+// Warn logs a warning message
+func (l *SlogLogger) Warn(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, common.LevelWarn, format, args...)
+}
+
+// Error logs an error message
+func (l *SlogLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, common.LevelError, format, args...)
+}
+
+// Hexdump logs data as a hex string attribute at SlogLevelTrace
+func (l *SlogLogger) Hexdump(ctx context.Context, data []byte) {
+	if l.enabled(ctx, common.LevelTrace) {
+		l.log(ctx, common.LevelTrace, "HEXDUMP", slog.String("data", hex.EncodeToString(data)))
+	}
+}
+
+// WithFields returns a logger adding fields as attributes, in key order
+func (l *SlogLogger) WithFields(fields map[string]interface{}) common.LoggerInterface {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	args := make([]any, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, slog.Any(key, fields[key]))
+	}
+	return &SlogLogger{logger: l.logger.With(args...), level: l.GetLevel()}
+}
+
+// GetLevel returns the current log level
+func (l *SlogLogger) GetLevel() common.LogLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// SetLevel sets the log level; messages below it are dropped before they
+// reach the slog handler
+func (l *SlogLogger) SetLevel(level common.LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
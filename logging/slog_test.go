@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var out strings.Builder
+	handler := slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: SlogLevelTrace,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewSlogLogger(slog.New(handler)).WithFields(map[string]interface{}{"unit": 3, "endpoint": "plc:502"})
+
+	ctx := common.WithCorrelationID(context.Background(), "req-1")
+	logger.Warn(ctx, "Timeout reading %d registers", 10)
+	logger.(*SlogLogger).Hexdump(context.Background(), []byte{0x01, 0xAB})
+	want := `level=WARN msg="Timeout reading 10 registers" endpoint=plc:502 unit=3 correlation_id=req-1
+level=DEBUG-4 msg=HEXDUMP endpoint=plc:502 unit=3 data=01ab
+`
+	if out.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, out.String())
+	}
+
+	// The logger's level drops messages before the handler sees them
+	out.Reset()
+	logger.SetLevel(common.LevelError)
+	logger.Info(ctx, "dropped")
+	logger.Error(ctx, "kept")
+	if got := out.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "kept") {
+		t.Errorf("Expected only the error, got %q", got)
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	var fallbackOut, requestOut strings.Builder
+	fallback := NewLogger(WithWriter(&fallbackOut), WithLevel(common.LevelDebug))
+	logger := NewContextLogger(fallback).WithFields(map[string]interface{}{"device": "meter"})
+
+	ctx := context.Background()
+	logger.Info(ctx, "to the fallback")
+
+	request := NewLogger(WithWriter(&requestOut), WithLevel(common.LevelDebug), WithFields(map[string]interface{}{"trace_id": "abc"}))
+	logger.Info(common.ContextWithLogger(ctx, request), "to the request")
+
+	if got := fallbackOut.String(); !strings.Contains(got, "to the fallback") || !strings.Contains(got, `device="meter"`) || strings.Contains(got, "to the request") {
+		t.Errorf("Unexpected fallback output %q", got)
+	}
+	if got := requestOut.String(); !strings.Contains(got, "to the request") || !strings.Contains(got, `device="meter"`) || !strings.Contains(got, `trace_id="abc"`) {
+		t.Errorf("Unexpected request output %q", got)
+	}
+
+	// A context logger in the context falls back instead of recursing
+	fallbackOut.Reset()
+	logger.Debug(common.ContextWithLogger(ctx, NewContextLogger(fallback)), "nested")
+	if !strings.Contains(fallbackOut.String(), "nested") {
+		t.Errorf("Expected the nested message in the fallback, got %q", fallbackOut.String())
+	}
+	if common.LoggerFromContext(nil, fallback) != fallback {
+		t.Error("Expected the fallback for a nil context")
+	}
+}
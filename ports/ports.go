@@ -0,0 +1,79 @@
+// Package ports lists the serial ports available on the host, with USB
+// metadata where the platform exposes it, so applications can present a
+// device picker for RTU links.
+package ports
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PortInfo describes an available serial port
+type PortInfo struct {
+	// Name is the path or name used to open the port, e.g. "/dev/ttyUSB0" or "COM3"
+	Name string
+
+	// Description is a human readable description, e.g. the USB product string
+	Description string
+
+	// IsUSB is true for USB serial adapters. VID, PID, Manufacturer and
+	// SerialNumber are only set when IsUSB is true.
+	IsUSB bool
+
+	// VID is the USB vendor ID
+	VID uint16
+
+	// PID is the USB product ID
+	PID uint16
+
+	// Manufacturer is the USB manufacturer string
+	Manufacturer string
+
+	// SerialNumber is the USB serial number string
+	SerialNumber string
+}
+
+// String returns a one-line summary, e.g.
+// "/dev/ttyUSB0 (FT232R USB UART) [0403:6001 SN A50285BI]"
+func (p PortInfo) String() string {
+	s := p.Name
+	if p.Description != "" {
+		s += fmt.Sprintf(" (%s)", p.Description)
+	}
+	if p.IsUSB {
+		s += fmt.Sprintf(" [%04x:%04x", p.VID, p.PID)
+		if p.SerialNumber != "" {
+			s += " SN " + p.SerialNumber
+		}
+		s += "]"
+	}
+	return s
+}
+
+// List returns the serial ports available on the host, sorted by name.
+// On Linux ports are read from sysfs and USB adapters carry their vendor
+// and product metadata. On Windows ports are read from the registry; USB
+// adapters recorded under the USB or FTDIBUS Enum keys carry their vendor
+// and product IDs, manufacturer, serial number and device description, other
+// ports the kernel device name as description. On other Unix systems the
+// usual device nodes are listed without metadata.
+func List() ([]PortInfo, error) {
+	ports, err := list()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+	return ports, nil
+}
+
+// FilterUSB returns the ports matching the USB vendor and product ID.
+// A zero pid matches any product of the vendor.
+func FilterUSB(ports []PortInfo, vid, pid uint16) []PortInfo {
+	var matched []PortInfo
+	for _, p := range ports {
+		if p.IsUSB && p.VID == vid && (pid == 0 || p.PID == pid) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,108 @@
+//go:build linux
+
+package ports
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// list enumerates the ports registered in sysfs
+func list() ([]PortInfo, error) {
+	return listSysfs("/sys/class/tty", "/dev")
+}
+
+// listSysfs enumerates tty class entries under classDir that are backed by
+// a device, naming them relative to devDir
+func listSysfs(classDir, devDir string) ([]PortInfo, error) {
+	entries, err := os.ReadDir(classDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []PortInfo
+	for _, entry := range entries {
+		// Virtual terminals and pseudo terminals have no device link
+		devicePath, err := filepath.EvalSymlinks(filepath.Join(classDir, entry.Name(), "device"))
+		if err != nil {
+			continue
+		}
+
+		// Legacy 8250 UARTs are always registered, whether or not hardware
+		// is present, and cannot be told apart without opening them
+		driver := driverName(devicePath)
+		if driver == "serial8250" || strings.Contains(devicePath, "/serial8250") {
+			continue
+		}
+
+		port := PortInfo{
+			Name:        filepath.Join(devDir, entry.Name()),
+			Description: driver,
+		}
+		if usbDir := findUSBDevice(devicePath); usbDir != "" {
+			port.IsUSB = true
+			port.VID = readHex(filepath.Join(usbDir, "idVendor"))
+			port.PID = readHex(filepath.Join(usbDir, "idProduct"))
+			port.Manufacturer = readString(filepath.Join(usbDir, "manufacturer"))
+			port.SerialNumber = readString(filepath.Join(usbDir, "serial"))
+			if product := readString(filepath.Join(usbDir, "product")); product != "" {
+				port.Description = product
+			}
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// serialCoreDrivers are the generic drivers the serial core binds to its own
+// port and controller devices on newer kernels; the hardware driver sits on
+// an ancestor
+var serialCoreDrivers = map[string]bool{"port": true, "ctrl": true}
+
+// driverName returns the name of the hardware driver bound to the device or,
+// for serial core devices, to the nearest ancestor
+func driverName(devicePath string) string {
+	dir := devicePath
+	for i := 0; i < 3; i++ {
+		if driver, err := filepath.EvalSymlinks(filepath.Join(dir, "driver")); err == nil {
+			if name := filepath.Base(driver); !serialCoreDrivers[name] {
+				return name
+			}
+		}
+		dir = filepath.Dir(dir)
+	}
+	return ""
+}
+
+// findUSBDevice walks up from a tty device to the USB device it belongs to,
+// identified by its idVendor attribute. It returns "" for non-USB ports.
+func findUSBDevice(devicePath string) string {
+	dir := devicePath
+	for i := 0; i < 4; i++ {
+		if _, err := os.Stat(filepath.Join(dir, "idVendor")); err == nil {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+	return ""
+}
+
+// readString reads a sysfs attribute, returning "" if it does not exist
+func readString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readHex reads a hexadecimal sysfs attribute such as idVendor
+func readHex(path string) uint16 {
+	value, err := strconv.ParseUint(readString(path), 16, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(value)
+}
@@ -0,0 +1,100 @@
+//go:build linux
+
+package ports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile creates a file and its parent directories
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// symlink creates a symlink and the parent directories of its location
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListSysfs(t *testing.T) {
+	root := t.TempDir()
+	class := filepath.Join(root, "class", "tty")
+	devices := filepath.Join(root, "devices")
+	drivers := filepath.Join(root, "drivers")
+	for _, driver := range []string{"ftdi_sio", "serial8250", "pl011"} {
+		if err := os.MkdirAll(filepath.Join(drivers, driver), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// USB adapter: usb device -> interface -> ttyUSB0
+	usbDev := filepath.Join(devices, "usb1", "1-1")
+	writeFile(t, filepath.Join(usbDev, "idVendor"), "0403\n")
+	writeFile(t, filepath.Join(usbDev, "idProduct"), "6001\n")
+	writeFile(t, filepath.Join(usbDev, "manufacturer"), "FTDI\n")
+	writeFile(t, filepath.Join(usbDev, "product"), "FT232R USB UART\n")
+	writeFile(t, filepath.Join(usbDev, "serial"), "A50285BI\n")
+	usbTTY := filepath.Join(usbDev, "1-1:1.0", "ttyUSB0")
+	symlink(t, filepath.Join(drivers, "ftdi_sio"), filepath.Join(usbTTY, "driver"))
+	symlink(t, usbTTY, filepath.Join(class, "ttyUSB0", "device"))
+
+	// On-board UART
+	uart := filepath.Join(devices, "platform", "uart0")
+	symlink(t, filepath.Join(drivers, "pl011"), filepath.Join(uart, "driver"))
+	symlink(t, uart, filepath.Join(class, "ttyAMA0", "device"))
+
+	// Phantom 8250 port and a virtual terminal are skipped
+	phantom := filepath.Join(devices, "platform", "serial8250")
+	symlink(t, filepath.Join(drivers, "serial8250"), filepath.Join(phantom, "driver"))
+	symlink(t, phantom, filepath.Join(class, "ttyS0", "device"))
+	if err := os.MkdirAll(filepath.Join(class, "tty1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ports, err := listSysfs(class, "/dev")
+	if err != nil {
+		t.Fatalf("listSysfs failed: %v", err)
+	}
+	if len(ports) != 2 {
+		t.Fatalf("Expected 2 ports, got %d: %v", len(ports), ports)
+	}
+
+	byName := map[string]PortInfo{}
+	for _, p := range ports {
+		byName[p.Name] = p
+	}
+
+	usb := byName["/dev/ttyUSB0"]
+	if !usb.IsUSB || usb.VID != 0x0403 || usb.PID != 0x6001 {
+		t.Errorf("Unexpected USB metadata: %+v", usb)
+	}
+	if usb.Description != "FT232R USB UART" || usb.Manufacturer != "FTDI" || usb.SerialNumber != "A50285BI" {
+		t.Errorf("Unexpected USB strings: %+v", usb)
+	}
+	if got := usb.String(); got != "/dev/ttyUSB0 (FT232R USB UART) [0403:6001 SN A50285BI]" {
+		t.Errorf("Unexpected String(): %q", got)
+	}
+
+	onboard := byName["/dev/ttyAMA0"]
+	if onboard.IsUSB || onboard.Description != "pl011" {
+		t.Errorf("Unexpected on-board port: %+v", onboard)
+	}
+
+	if matched := FilterUSB(ports, 0x0403, 0); len(matched) != 1 || matched[0].Name != "/dev/ttyUSB0" {
+		t.Errorf("Expected FilterUSB to match ttyUSB0, got %v", matched)
+	}
+}
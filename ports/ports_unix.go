@@ -0,0 +1,28 @@
+//go:build !linux && !windows
+
+package ports
+
+import "path/filepath"
+
+// devicePatterns are the device nodes used for serial ports on BSD-derived
+// systems, including macOS
+var devicePatterns = []string{
+	"/dev/cu.*",
+	"/dev/ttyU*",
+	"/dev/cuaU*",
+}
+
+// list globs the usual serial device nodes. No metadata is available.
+func list() ([]PortInfo, error) {
+	var ports []PortInfo
+	for _, pattern := range devicePatterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range matches {
+			ports = append(ports, PortInfo{Name: name})
+		}
+	}
+	return ports, nil
+}
@@ -0,0 +1,225 @@
+//go:build windows
+
+package ports
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var procRegEnumValueW = syscall.NewLazyDLL("advapi32.dll").NewProc("RegEnumValueW")
+
+// errorNoMoreItems is ERROR_NO_MORE_ITEMS, which the syscall package does not define
+const errorNoMoreItems syscall.Errno = 259
+
+// usbBuses are the keys under HKLM\SYSTEM\CurrentControlSet\Enum holding USB
+// serial adapters: USB for CDC ACM and most vendor drivers, FTDIBUS for FTDI's
+var usbBuses = []string{"USB", "FTDIBUS"}
+
+// list reads HKLM\HARDWARE\DEVICEMAP\SERIALCOMM, which maps kernel device
+// names (e.g. \Device\VCP0) to port names (e.g. COM3), and adds the metadata
+// of the USB adapters found under the Enum keys
+func list() ([]PortInfo, error) {
+	key, err := openKey(syscall.HKEY_LOCAL_MACHINE, `HARDWARE\DEVICEMAP\SERIALCOMM`)
+	if err != nil {
+		if err == syscall.ERROR_FILE_NOT_FOUND {
+			// The key only exists while at least one port is present
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer syscall.RegCloseKey(key)
+
+	var ports []PortInfo
+	for index := uint32(0); ; index++ {
+		name := make([]uint16, 256)
+		nameLen := uint32(len(name))
+		value := make([]uint16, 256)
+		valueLen := uint32(len(value) * 2)
+		var valueType uint32
+
+		ret, _, _ := procRegEnumValueW.Call(
+			uintptr(key),
+			uintptr(index),
+			uintptr(unsafe.Pointer(&name[0])),
+			uintptr(unsafe.Pointer(&nameLen)),
+			0,
+			uintptr(unsafe.Pointer(&valueType)),
+			uintptr(unsafe.Pointer(&value[0])),
+			uintptr(unsafe.Pointer(&valueLen)),
+		)
+		if syscall.Errno(ret) == errorNoMoreItems {
+			break
+		}
+		if ret != 0 {
+			return nil, syscall.Errno(ret)
+		}
+		if valueType != syscall.REG_SZ {
+			continue
+		}
+
+		ports = append(ports, PortInfo{
+			Name:        syscall.UTF16ToString(value),
+			Description: syscall.UTF16ToString(name[:nameLen]),
+		})
+	}
+
+	// USB metadata is best effort: ports are listed without it if the Enum
+	// keys cannot be read
+	usb := usbMetadata()
+	for i, port := range ports {
+		if info, ok := usb[port.Name]; ok {
+			info.Name = port.Name
+			if info.Description == "" {
+				info.Description = port.Description
+			}
+			ports[i] = info
+		}
+	}
+	return ports, nil
+}
+
+// usbMetadata reads the USB devices under the Enum keys of usbBuses and
+// returns the metadata of those with a port name, by port name. Device keys
+// are named after the hardware ID, e.g. USB\VID_2341&PID_0043, with one
+// subkey per device instance whose Device Parameters hold the PortName.
+func usbMetadata() map[string]PortInfo {
+	metadata := make(map[string]PortInfo)
+	for _, bus := range usbBuses {
+		root, err := openKey(syscall.HKEY_LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Enum\`+bus)
+		if err != nil {
+			continue
+		}
+		for _, deviceID := range subkeys(root) {
+			vid, pid, ok := parseVIDPID(deviceID)
+			if !ok {
+				continue
+			}
+			device, err := openKey(root, deviceID)
+			if err != nil {
+				continue
+			}
+			for _, instance := range subkeys(device) {
+				if info, ok := readInstance(device, instance); ok {
+					info.VID, info.PID = vid, pid
+					if serial := deviceSerial(deviceID); serial != "" {
+						info.SerialNumber = serial
+					}
+					metadata[info.Name] = info
+				}
+			}
+			syscall.RegCloseKey(device)
+		}
+		syscall.RegCloseKey(root)
+	}
+	return metadata
+}
+
+// readInstance reads the port name and strings of a device instance
+func readInstance(device syscall.Handle, instance string) (PortInfo, bool) {
+	key, err := openKey(device, instance)
+	if err != nil {
+		return PortInfo{}, false
+	}
+	defer syscall.RegCloseKey(key)
+	params, err := openKey(key, "Device Parameters")
+	if err != nil {
+		return PortInfo{}, false
+	}
+	defer syscall.RegCloseKey(params)
+
+	info := PortInfo{
+		Name:         queryString(params, "PortName"),
+		Description:  displayString(queryString(key, "DeviceDesc")),
+		IsUSB:        true,
+		Manufacturer: displayString(queryString(key, "Mfg")),
+	}
+	// Windows generates instance IDs containing '&' for devices without a
+	// serial number; FTDIBUS instances are numbered and the serial number is
+	// in the hardware ID instead, see deviceSerial
+	if !strings.Contains(instance, "&") {
+		info.SerialNumber = instance
+	}
+	return info, info.Name != ""
+}
+
+// parseVIDPID extracts the vendor and product IDs from a hardware ID such as
+// "VID_0403&PID_6001" or "VID_0403+PID_6001+A50285BIA"
+func parseVIDPID(deviceID string) (vid, pid uint16, ok bool) {
+	upper := strings.ToUpper(deviceID)
+	v, p := strings.Index(upper, "VID_"), strings.Index(upper, "PID_")
+	if v < 0 || p < 0 || len(upper) < v+8 || len(upper) < p+8 {
+		return 0, 0, false
+	}
+	vid64, err := strconv.ParseUint(upper[v+4:v+8], 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	pid64, err := strconv.ParseUint(upper[p+4:p+8], 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint16(vid64), uint16(pid64), true
+}
+
+// deviceSerial returns the serial number FTDIBUS hardware IDs carry after
+// the product ID, e.g. "A50285BIA" (the adapter's serial number and port
+// letter), or "" for other IDs
+func deviceSerial(deviceID string) string {
+	parts := strings.Split(deviceID, "+")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+// displayString strips the "@driver.inf,%key%;" prefix of localized
+// registry strings such as "@oem12.inf,%ftdi%;FTDI"
+func displayString(s string) string {
+	if i := strings.LastIndex(s, ";"); strings.HasPrefix(s, "@") && i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// openKey opens a registry key for reading
+func openKey(parent syscall.Handle, path string) (syscall.Handle, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var key syscall.Handle
+	err = syscall.RegOpenKeyEx(parent, name, 0, syscall.KEY_READ, &key)
+	return key, err
+}
+
+// subkeys lists the names of the subkeys of a registry key
+func subkeys(key syscall.Handle) []string {
+	var names []string
+	for index := uint32(0); ; index++ {
+		name := make([]uint16, 256)
+		nameLen := uint32(len(name))
+		if err := syscall.RegEnumKeyEx(key, index, &name[0], &nameLen, nil, nil, nil, nil); err != nil {
+			return names
+		}
+		names = append(names, syscall.UTF16ToString(name[:nameLen]))
+	}
+}
+
+// queryString reads a REG_SZ value, returning "" if it does not exist
+func queryString(key syscall.Handle, name string) string {
+	valueName, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return ""
+	}
+	value := make([]uint16, 256)
+	valueLen := uint32(len(value) * 2)
+	var valueType uint32
+	err = syscall.RegQueryValueEx(key, valueName, nil, &valueType, (*byte)(unsafe.Pointer(&value[0])), &valueLen)
+	if err != nil || valueType != syscall.REG_SZ {
+		return ""
+	}
+	return syscall.UTF16ToString(value[:valueLen/2])
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package ports
+
+import "testing"
+
+func TestParseVIDPID(t *testing.T) {
+	cases := []struct {
+		deviceID string
+		vid, pid uint16
+		ok       bool
+	}{
+		{"VID_2341&PID_0043", 0x2341, 0x0043, true},
+		{"VID_0403+PID_6001+A50285BIA", 0x0403, 0x6001, true},
+		{"vid_10c4&pid_ea60&MI_00", 0x10C4, 0xEA60, true},
+		{"ROOT_HUB30", 0, 0, false},
+		{"VID_12", 0, 0, false},
+		{"VID_XYZW&PID_0001", 0, 0, false},
+	}
+	for _, tc := range cases {
+		vid, pid, ok := parseVIDPID(tc.deviceID)
+		if vid != tc.vid || pid != tc.pid || ok != tc.ok {
+			t.Errorf("%s: expected %04x:%04x %v, got %04x:%04x %v", tc.deviceID, tc.vid, tc.pid, tc.ok, vid, pid, ok)
+		}
+	}
+}
+
+func TestDeviceSerial(t *testing.T) {
+	if serial := deviceSerial("VID_0403+PID_6001+A50285BIA"); serial != "A50285BIA" {
+		t.Errorf("Expected A50285BIA, got %q", serial)
+	}
+	if serial := deviceSerial("VID_2341&PID_0043"); serial != "" {
+		t.Errorf("Expected no serial number, got %q", serial)
+	}
+}
+
+func TestDisplayString(t *testing.T) {
+	cases := map[string]string{
+		"@oem12.inf,%ftdi%;FTDI":                            "FTDI",
+		"@usbser.inf,%usbser.devicedesc%;USB Serial Device": "USB Serial Device",
+		"Arduino Uno": "Arduino Uno",
+	}
+	for input, want := range cases {
+		if got := displayString(input); got != want {
+			t.Errorf("%q: expected %q, got %q", input, want, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+func TestParseResponseInto(t *testing.T) {
+	handler := NewProtocolHandler(WithLogger(logging.NewNoopLogger()))
+
+	registers := make([]common.RegisterValue, 2)
+	data := []byte{0x04, 0x00, 0x2A, 0x12, 0x34}
+	if err := handler.ParseReadHoldingRegistersResponseInto(data, registers); err != nil {
+		t.Fatalf("ParseReadHoldingRegistersResponseInto failed: %v", err)
+	}
+	if !slices.Equal(registers, []common.RegisterValue{0x2A, 0x1234}) {
+		t.Errorf("Expected [42 4660], got %v", registers)
+	}
+
+	coils := make([]common.CoilValue, 10)
+	bits := []byte{0x02, 0x05, 0x02}
+	if err := handler.ParseReadCoilsResponseInto(bits, coils); err != nil {
+		t.Fatalf("ParseReadCoilsResponseInto failed: %v", err)
+	}
+	if !coils[0] || coils[1] || !coils[2] || !coils[9] {
+		t.Errorf("Unexpected coils %v", coils)
+	}
+
+	// The length of dst is the quantity expected
+	if err := handler.ParseReadInputRegistersResponseInto(data, make([]common.InputRegisterValue, 3)); !errors.Is(err, common.ErrInvalidResponseLength) {
+		t.Errorf("Expected a byte count mismatch, got %v", err)
+	}
+
+	// Decoding into a reused slice does not allocate
+	allocs := testing.AllocsPerRun(100, func() {
+		handler.ParseReadHoldingRegistersResponseInto(data, registers)
+		handler.ParseReadDiscreteInputsResponseInto(bits, coils)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
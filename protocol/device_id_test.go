@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
@@ -162,4 +163,46 @@ func TestParseReadDeviceIdentificationResponse(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for invalid object data, got nil")
 	}
-}
\ No newline at end of file
+}
+
+func TestParseReadDeviceIdentificationResponse_Bounds(t *testing.T) {
+	handler := NewProtocolHandler()
+
+	// response builds a response of the given objects, each of size bytes
+	response := func(sizes ...int) []byte {
+		data := []byte{byte(common.MEIReadDeviceID), byte(common.ReadDeviceIDExtendedStream), 0x83, 0x00, 0x00, byte(len(sizes))}
+		for i, size := range sizes {
+			data = append(data, byte(0x80+i), byte(size))
+			data = append(data, bytes.Repeat([]byte{'x'}, size)...)
+		}
+		return data
+	}
+
+	// The largest response: function code, 6 header bytes and 246 object bytes
+	deviceID, err := handler.ParseReadDeviceIdentificationResponse(response(120, 122))
+	if err != nil || len(deviceID.Objects) != 2 || len(deviceID.Objects[1].Value) != 122 {
+		t.Fatalf("Expected a maximum-size response to parse, got %v", err)
+	}
+
+	cases := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"exceeds the PDU", response(120, 123), common.ErrInvalidResponseLength},
+		{"more objects than bytes", append(response(), 0x00, 0x00), common.ErrInvalidResponseFormat},
+		{"object beyond the data", response(10)[:15], common.ErrInvalidResponseFormat},
+		{"trailing bytes", append(response(10), 0x00), common.ErrInvalidResponseFormat},
+	}
+	cases[1].data[5] = 2
+	for _, tc := range cases {
+		if _, err := handler.ParseReadDeviceIdentificationResponse(tc.data); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	lenient := NewProtocolHandler(WithQuirks(common.QuirkLenientByteCount))
+	if _, err := lenient.ParseReadDeviceIdentificationResponse(append(response(10), 0x00)); err != nil {
+		t.Errorf("Expected lenient parsing to ignore trailing bytes, got %v", err)
+	}
+}
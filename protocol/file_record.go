@@ -0,0 +1,206 @@
+package protocol
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// checkFileRecordRange checks that length registers starting at record fit
+// in a file
+func checkFileRecordRange(recordNumber uint16, length int) error {
+	if length == 0 {
+		return fmt.Errorf("%w: empty file record", common.ErrInvalidQuantity)
+	}
+	if int(recordNumber)+length-1 > common.MaxFileRecordNumber {
+		return fmt.Errorf("%w: records %d-%d past record %d", common.ErrInvalidAddress,
+			recordNumber, int(recordNumber)+length-1, common.MaxFileRecordNumber)
+	}
+	return nil
+}
+
+// GenerateReadFileRecordRequest generates a request to read file records
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+//
+// PDU Data:
+// Byte Count (1 byte) - 0x07 to 0xF5 - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// Per sub-request (7 bytes):
+// Reference Type (1 byte) - always 6 - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// File Number (2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// Record Number (2 bytes) - 0 to 9999 - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// Record Length (2 bytes) - in registers - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// The response must fit its byte count too, which limits the registers read.
+func (h *ProtocolHandler) GenerateReadFileRecordRequest(requests []common.FileRecordRequest) ([]byte, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Generating read file record request: %d sub-requests", len(requests))
+
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: no file records to read", common.ErrInvalidQuantity)
+	}
+
+	data := make([]byte, 1, 1+7*len(requests))
+	responseLength := 0
+	for _, request := range requests {
+		if err := checkFileRecordRange(request.RecordNumber, int(request.Length)); err != nil {
+			h.logger.Error(ctx, "Invalid file record sub-request %+v: %v", request, err)
+			return nil, err
+		}
+		// Sub-response: length, reference type and the registers
+		responseLength += 2 + int(request.Length)*2
+		data = append(data, common.FileRecordReferenceType)
+		data = binary.BigEndian.AppendUint16(data, request.FileNumber)
+		data = binary.BigEndian.AppendUint16(data, request.RecordNumber)
+		data = binary.BigEndian.AppendUint16(data, uint16(request.Length))
+	}
+	if len(data)-1 > common.MaxFileRecordByteCount || responseLength > common.MaxFileRecordByteCount {
+		h.logger.Error(ctx, "Read file record request of %d bytes with a response of %d bytes exceeds %d",
+			len(data)-1, responseLength, common.MaxFileRecordByteCount)
+		return nil, fmt.Errorf("%w: file records do not fit one request", common.ErrInvalidQuantity)
+	}
+	data[0] = byte(len(data) - 1)
+
+	h.logger.Debug(ctx, "Generated read file record request data: %v", data)
+	return data, nil
+}
+
+// ParseReadFileRecordResponse parses a response to a read file record request
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+//
+// PDU Data:
+// Resp. Data Length (1 byte) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// Per sub-response:
+// File Resp. Length (1 byte) - reference type and data - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// Reference Type (1 byte) - always 6 - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+// Record Data (N * 2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+func (h *ProtocolHandler) ParseReadFileRecordResponse(data []byte, requests []common.FileRecordRequest) ([]common.FileRecord, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Parsing read file record response: data=%v", data)
+
+	if len(data) < 1 || int(data[0]) != len(data)-1 {
+		h.logger.Error(ctx, "Invalid response length for read file record: %d bytes", len(data))
+		return nil, common.ErrInvalidResponseLength
+	}
+
+	records := make([]common.FileRecord, len(requests))
+	offset := 1
+	for i, request := range requests {
+		if len(data) < offset+2 {
+			h.logger.Error(ctx, "Read file record response ends before sub-response %d", i)
+			return nil, common.ErrInvalidResponseLength
+		}
+		length := int(data[offset])
+		if data[offset+1] != common.FileRecordReferenceType {
+			return nil, fmt.Errorf("%w: reference type %d in sub-response %d", common.ErrInvalidResponseFormat, data[offset+1], i)
+		}
+		if length != 1+int(request.Length)*2 || len(data) < offset+1+length {
+			h.logger.Error(ctx, "Invalid length %d of sub-response %d for %d registers", length, i, request.Length)
+			return nil, common.ErrInvalidResponseLength
+		}
+		values := make([]common.RegisterValue, request.Length)
+		for j := range values {
+			values[j] = binary.BigEndian.Uint16(data[offset+2+j*2:])
+		}
+		records[i] = common.FileRecord{FileNumber: request.FileNumber, RecordNumber: request.RecordNumber, Values: values}
+		offset += 1 + length
+	}
+	if offset != len(data) {
+		h.logger.Error(ctx, "Read file record response has %d bytes past the sub-responses", len(data)-offset)
+		return nil, common.ErrInvalidResponseLength
+	}
+
+	h.logger.Debug(ctx, "Parsed read file record response: %d records", len(records))
+	return records, nil
+}
+
+// GenerateWriteFileRecordRequest generates a request to write file records
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Write File Record)
+//
+// PDU Data:
+// Request Data Length (1 byte) - 0x09 to 0xFB - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+// Per sub-request:
+// Reference Type (1 byte) - always 6 - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+// File Number (2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+// Record Number (2 bytes) - 0 to 9999 - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+// Record Length (2 bytes) - N registers - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+// Record Data (N * 2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+func (h *ProtocolHandler) GenerateWriteFileRecordRequest(records []common.FileRecord) ([]byte, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Generating write file record request: %d sub-requests", len(records))
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no file records to write", common.ErrInvalidQuantity)
+	}
+
+	data := make([]byte, 1)
+	for _, record := range records {
+		if err := checkFileRecordRange(record.RecordNumber, len(record.Values)); err != nil {
+			h.logger.Error(ctx, "Invalid file record sub-request for file %d record %d: %v", record.FileNumber, record.RecordNumber, err)
+			return nil, err
+		}
+		data = append(data, common.FileRecordReferenceType)
+		data = binary.BigEndian.AppendUint16(data, record.FileNumber)
+		data = binary.BigEndian.AppendUint16(data, record.RecordNumber)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(record.Values)))
+		for _, value := range record.Values {
+			data = binary.BigEndian.AppendUint16(data, value)
+		}
+	}
+	if len(data)-1 > common.MaxWriteFileRecordByteCount {
+		h.logger.Error(ctx, "Write file record request of %d bytes exceeds %d", len(data)-1, common.MaxWriteFileRecordByteCount)
+		return nil, fmt.Errorf("%w: file records do not fit one request", common.ErrInvalidQuantity)
+	}
+	data[0] = byte(len(data) - 1)
+
+	h.logger.Debug(ctx, "Generated write file record request data: %v", data)
+	return data, nil
+}
+
+// ParseWriteFileRecordResponse parses a response to a write file record request
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Write File Record)
+// The normal response is an echo of the request.
+func (h *ProtocolHandler) ParseWriteFileRecordResponse(data []byte) ([]common.FileRecord, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Parsing write file record response: data=%v", data)
+
+	if len(data) < 1 || int(data[0]) != len(data)-1 {
+		h.logger.Error(ctx, "Invalid response length for write file record: %d bytes", len(data))
+		return nil, common.ErrInvalidResponseLength
+	}
+
+	var records []common.FileRecord
+	for offset := 1; offset < len(data); {
+		if len(data) < offset+7 {
+			h.logger.Error(ctx, "Write file record response ends inside a sub-request header")
+			return nil, common.ErrInvalidResponseLength
+		}
+		if data[offset] != common.FileRecordReferenceType {
+			return nil, fmt.Errorf("%w: reference type %d", common.ErrInvalidResponseFormat, data[offset])
+		}
+		// Check the record length against the data before allocating
+		length := int(binary.BigEndian.Uint16(data[offset+5:]))
+		fileNumber := binary.BigEndian.Uint16(data[offset+1:])
+		if len(data) < offset+7+length*2 {
+			h.logger.Error(ctx, "Write file record response ends inside the data of file %d", fileNumber)
+			return nil, common.ErrInvalidResponseLength
+		}
+		record := common.FileRecord{
+			FileNumber:   fileNumber,
+			RecordNumber: binary.BigEndian.Uint16(data[offset+3:]),
+			Values:       make([]common.RegisterValue, length),
+		}
+		offset += 7
+		for i := range record.Values {
+			record.Values[i] = binary.BigEndian.Uint16(data[offset+i*2:])
+		}
+		offset += len(record.Values) * 2
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, common.ErrInvalidResponseLength
+	}
+
+	h.logger.Debug(ctx, "Parsed write file record response: %d records", len(records))
+	return records, nil
+}
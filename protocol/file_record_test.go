@@ -0,0 +1,85 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestGenerateFileRecordRequests_Invalid(t *testing.T) {
+	handler := NewProtocolHandler()
+
+	reads := []struct {
+		name     string
+		requests []common.FileRecordRequest
+		err      error
+	}{
+		{"none", nil, common.ErrInvalidQuantity},
+		{"empty record", []common.FileRecordRequest{{FileNumber: 1, RecordNumber: 0, Length: 0}}, common.ErrInvalidQuantity},
+		{"past the last record", []common.FileRecordRequest{{FileNumber: 1, RecordNumber: 9999, Length: 2}}, common.ErrInvalidAddress},
+		{"response too long", []common.FileRecordRequest{{FileNumber: 1, RecordNumber: 0, Length: 122}}, common.ErrInvalidQuantity},
+		{"too many sub-requests", make([]common.FileRecordRequest, 36), common.ErrInvalidQuantity},
+	}
+	for i := range reads[4].requests {
+		reads[4].requests[i] = common.FileRecordRequest{FileNumber: 1, RecordNumber: uint16(i), Length: 1}
+	}
+	for _, tc := range reads {
+		if _, err := handler.GenerateReadFileRecordRequest(tc.requests); !errors.Is(err, tc.err) {
+			t.Errorf("read %s: expected %v, got %v", tc.name, tc.err, err)
+		}
+	}
+
+	// 121 registers fill the response exactly
+	if _, err := handler.GenerateReadFileRecordRequest([]common.FileRecordRequest{{FileNumber: 1, Length: 121}}); err != nil {
+		t.Errorf("Expected 121 registers to fit, got %v", err)
+	}
+
+	if _, err := handler.GenerateWriteFileRecordRequest(nil); !errors.Is(err, common.ErrInvalidQuantity) {
+		t.Errorf("write none: expected ErrInvalidQuantity, got %v", err)
+	}
+	if _, err := handler.GenerateWriteFileRecordRequest([]common.FileRecord{{FileNumber: 1, Values: make([]common.RegisterValue, 123)}}); !errors.Is(err, common.ErrInvalidQuantity) {
+		t.Errorf("write too long: expected ErrInvalidQuantity, got %v", err)
+	}
+	if _, err := handler.GenerateWriteFileRecordRequest([]common.FileRecord{{FileNumber: 1, Values: make([]common.RegisterValue, 122)}}); err != nil {
+		t.Errorf("Expected 122 registers to fit, got %v", err)
+	}
+}
+
+func TestParseFileRecordResponses_Malformed(t *testing.T) {
+	handler := NewProtocolHandler()
+	requests := []common.FileRecordRequest{{FileNumber: 4, RecordNumber: 1, Length: 1}}
+
+	reads := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, common.ErrInvalidResponseLength},
+		{"length disagrees", []byte{0x05, 0x03, 0x06, 0x00, 0x01}, common.ErrInvalidResponseLength},
+		{"wrong reference type", []byte{0x04, 0x03, 0x07, 0x00, 0x01}, common.ErrInvalidResponseFormat},
+		{"wrong record length", []byte{0x06, 0x05, 0x06, 0x00, 0x01, 0x00, 0x02}, common.ErrInvalidResponseLength},
+		{"trailing sub-response", []byte{0x08, 0x03, 0x06, 0x00, 0x01, 0x03, 0x06, 0x00, 0x02}, common.ErrInvalidResponseLength},
+	}
+	for _, tc := range reads {
+		if _, err := handler.ParseReadFileRecordResponse(tc.data, requests); !errors.Is(err, tc.err) {
+			t.Errorf("read %s: expected %v, got %v", tc.name, tc.err, err)
+		}
+	}
+
+	writes := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", []byte{0x00}, common.ErrInvalidResponseLength},
+		{"short header", []byte{0x03, 0x06, 0x00, 0x04}, common.ErrInvalidResponseLength},
+		{"short data", []byte{0x08, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x01, 0x06}, common.ErrInvalidResponseLength},
+		{"wrong reference type", []byte{0x09, 0x05, 0x00, 0x04, 0x00, 0x07, 0x00, 0x01, 0x06, 0xAF}, common.ErrInvalidResponseFormat},
+	}
+	for _, tc := range writes {
+		if _, err := handler.ParseWriteFileRecordResponse(tc.data); !errors.Is(err, tc.err) {
+			t.Errorf("write %s: expected %v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+// The fuzz targets feed the parsers the responses a hostile or buggy device
+// could send; they must fail with an error, never panic or return results
+// disagreeing with the request. Run one with, for example:
+//
+//	go test ./protocol -run '^$' -fuzz FuzzParseBitResponse
+
+// fuzzHandlers returns a strict and a lenient handler, which take different
+// paths through the byte count checks
+func fuzzHandlers() []*ProtocolHandler {
+	logger := logging.NewNoopLogger()
+	return []*ProtocolHandler{
+		NewProtocolHandler(WithLogger(logger)),
+		NewProtocolHandler(WithLogger(logger), WithQuirks(common.QuirkLenientByteCount|common.QuirkOneBasedAddressing)),
+	}
+}
+
+func FuzzValidate(f *testing.F) {
+	f.Add(byte(common.FuncReadCoils), []byte{0x00, 0x00, 0x07, 0xD0})
+	f.Add(byte(common.FuncWriteMultipleCoils), []byte{0x00, 0x13, 0x00, 0x0A, 0x02, 0xCD, 0x01})
+	f.Add(byte(common.FuncWriteMultipleRegisters), []byte{0x00, 0x01, 0x00, 0x02, 0x04, 0x00, 0x0A, 0x01, 0x02})
+	f.Add(byte(common.FuncWriteFileRecord), []byte{0x09, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x01, 0x06, 0xAF})
+	f.Add(byte(common.FuncReadWriteMultipleRegisters), []byte{0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x01, 0x02, 0x00, 0xFF})
+
+	f.Fuzz(func(t *testing.T, functionCode byte, data []byte) {
+		Validate(&common.PDU{FunctionCode: common.FunctionCode(functionCode), Data: data})
+	})
+}
+
+func FuzzParseBitResponse(f *testing.F) {
+	f.Add([]byte{0x02, 0xCD, 0x01}, uint16(10))
+	f.Add([]byte{0x01, 0xFF}, uint16(16))
+	f.Add([]byte{0xFF}, uint16(1))
+
+	f.Fuzz(func(t *testing.T, data []byte, quantity uint16) {
+		for _, handler := range fuzzHandlers() {
+			if values, err := handler.ParseReadCoilsResponse(data, common.Quantity(quantity)); err == nil && len(values) != int(quantity) {
+				t.Fatalf("ParseReadCoilsResponse returned %d values for quantity %d", len(values), quantity)
+			}
+			if values, err := handler.ParseReadDiscreteInputsResponse(data, common.Quantity(quantity)); err == nil && len(values) != int(quantity) {
+				t.Fatalf("ParseReadDiscreteInputsResponse returned %d values for quantity %d", len(values), quantity)
+			}
+			handler.ParseReadCoilsResponseInto(data, make([]common.CoilValue, quantity))
+			handler.ParseReadDiscreteInputsResponseInto(data, make([]common.DiscreteInputValue, quantity))
+		}
+	})
+}
+
+func FuzzParseRegisterResponse(f *testing.F) {
+	f.Add([]byte{0x04, 0x02, 0x2B, 0x00, 0x64}, uint16(2))
+	f.Add([]byte{0x06, 0x00, 0x01}, uint16(3))
+	f.Add([]byte{0x00}, uint16(0))
+
+	f.Fuzz(func(t *testing.T, data []byte, quantity uint16) {
+		for _, handler := range fuzzHandlers() {
+			if values, err := handler.ParseReadHoldingRegistersResponse(data, common.Quantity(quantity)); err == nil && len(values) != int(quantity) {
+				t.Fatalf("ParseReadHoldingRegistersResponse returned %d values for quantity %d", len(values), quantity)
+			}
+			if values, err := handler.ParseReadInputRegistersResponse(data, common.Quantity(quantity)); err == nil && len(values) != int(quantity) {
+				t.Fatalf("ParseReadInputRegistersResponse returned %d values for quantity %d", len(values), quantity)
+			}
+			if values, err := handler.ParseReadWriteMultipleRegistersResponse(data, common.Quantity(quantity)); err == nil && len(values) != int(quantity) {
+				t.Fatalf("ParseReadWriteMultipleRegistersResponse returned %d values for quantity %d", len(values), quantity)
+			}
+			handler.ParseReadHoldingRegistersResponseInto(data, make([]common.RegisterValue, quantity))
+			handler.ParseReadInputRegistersResponseInto(data, make([]common.InputRegisterValue, quantity))
+		}
+	})
+}
+
+func FuzzParseWriteResponse(f *testing.F) {
+	f.Add([]byte{0x00, 0xAC, 0xFF, 0x00})
+	f.Add([]byte{0x00, 0x01, 0x00, 0x03})
+	f.Add([]byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x25})
+	f.Add([]byte{0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, handler := range fuzzHandlers() {
+			handler.ParseWriteSingleCoilResponse(data)
+			handler.ParseWriteSingleRegisterResponse(data)
+			handler.ParseWriteMultipleCoilsResponse(data)
+			handler.ParseWriteMultipleRegistersResponse(data)
+			handler.ParseMaskWriteRegisterResponse(data)
+			handler.ParseReadExceptionStatusResponse(data)
+		}
+	})
+}
+
+func FuzzParseReadFIFOQueueResponse(f *testing.F) {
+	f.Add([]byte{0x00, 0x06, 0x00, 0x02, 0x01, 0xB8, 0x12, 0x84})
+	f.Add([]byte{0x00, 0x02, 0x00, 0x00})
+	f.Add([]byte{0xFF, 0xFF, 0x00, 0x1F})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, handler := range fuzzHandlers() {
+			if values, err := handler.ParseReadFIFOQueueResponse(data); err == nil && len(values) > common.MaxFIFOCount {
+				t.Fatalf("ParseReadFIFOQueueResponse returned %d values", len(values))
+			}
+		}
+	})
+}
+
+func FuzzParseReadDeviceIdentificationResponse(f *testing.F) {
+	f.Add([]byte{0x0E, 0x01, 0x01, 0x00, 0x00, 0x01, 0x00, 0x04, 'A', 'C', 'M', 'E'})
+	f.Add([]byte{0x0E, 0x01, 0x01, 0xFF, 0x02, 0x03, 0x00, 0x00, 0x01, 0xFF})
+	f.Add([]byte{0x0E, 0x04, 0x01, 0x00, 0x00, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, handler := range fuzzHandlers() {
+			if id, err := handler.ParseReadDeviceIdentificationResponse(data); err == nil && len(id.Objects) != int(id.NumberOfObjects) {
+				t.Fatalf("ParseReadDeviceIdentificationResponse returned %d of %d objects", len(id.Objects), id.NumberOfObjects)
+			}
+		}
+	})
+}
+
+func FuzzParseReadFileRecordResponse(f *testing.F) {
+	f.Add([]byte{0x0C, 0x05, 0x06, 0x0D, 0xFE, 0x00, 0x20, 0x05, 0x06, 0x33, 0xCD, 0x00, 0x40}, []byte{2, 2})
+	f.Add([]byte{0x03, 0x02, 0x06, 0x00}, []byte{0})
+
+	f.Fuzz(func(t *testing.T, data []byte, lengths []byte) {
+		requests := make([]common.FileRecordRequest, len(lengths))
+		for i, length := range lengths {
+			requests[i] = common.FileRecordRequest{FileNumber: uint16(i), Length: common.Quantity(length)}
+		}
+		for _, handler := range fuzzHandlers() {
+			records, err := handler.ParseReadFileRecordResponse(data, requests)
+			if err != nil {
+				continue
+			}
+			for i, record := range records {
+				if len(record.Values) != int(requests[i].Length) {
+					t.Fatalf("Record %d has %d values, requested %d", i, len(record.Values), requests[i].Length)
+				}
+			}
+		}
+	})
+}
+
+func FuzzParseWriteFileRecordResponse(f *testing.F) {
+	f.Add([]byte{0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D})
+	f.Add([]byte{0x07, 0x06, 0x00, 0x04, 0x00, 0x07, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, handler := range fuzzHandlers() {
+			handler.ParseWriteFileRecordResponse(data)
+		}
+	})
+}
@@ -0,0 +1,11 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/protocol/prototest"
+)
+
+func TestProtocolHandler_GoldenVectors(t *testing.T) {
+	prototest.Run(t, NewProtocolHandler())
+}
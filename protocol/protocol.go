@@ -13,6 +13,7 @@ import (
 // ProtocolHandler implements the common.Protocol interface for Modbus protocol
 type ProtocolHandler struct {
 	logger common.LoggerInterface
+	quirks common.Quirks
 }
 
 // Option is a function that configures a ProtocolHandler
@@ -25,6 +26,14 @@ func WithLogger(logger common.LoggerInterface) Option {
 	}
 }
 
+// WithQuirks works around the given device quirks in the requests
+// generated and the responses parsed
+func WithQuirks(quirks common.Quirks) Option {
+	return func(p *ProtocolHandler) {
+		p.quirks = quirks
+	}
+}
+
 // NewProtocolHandler creates a new ProtocolHandler with options
 func NewProtocolHandler(options ...Option) *ProtocolHandler {
 	handler := &ProtocolHandler{
@@ -41,7 +50,37 @@ func NewProtocolHandler(options ...Option) *ProtocolHandler {
 
 // WithLogger returns a new ProtocolHandler with the given logger
 func (h *ProtocolHandler) WithLogger(logger common.LoggerInterface) common.Protocol {
-	return NewProtocolHandler(WithLogger(logger))
+	return NewProtocolHandler(WithLogger(logger), WithQuirks(h.quirks))
+}
+
+// WithQuirks returns a new ProtocolHandler working around the given quirks
+func (h *ProtocolHandler) WithQuirks(quirks common.Quirks) *ProtocolHandler {
+	return NewProtocolHandler(WithLogger(h.logger), WithQuirks(quirks))
+}
+
+// Quirks returns the device quirks the handler works around
+func (h *ProtocolHandler) Quirks() common.Quirks {
+	return h.quirks
+}
+
+// wireAddress converts an address of the register map to the address sent
+// on the wire, see common.QuirkOneBasedAddressing
+func (h *ProtocolHandler) wireAddress(address common.Address) (common.Address, error) {
+	if !h.quirks.Has(common.QuirkOneBasedAddressing) {
+		return address, nil
+	}
+	if address == common.Address(0xFFFF) {
+		return 0, fmt.Errorf("%w: %d has no one-based equivalent", common.ErrInvalidAddress, address)
+	}
+	return address + 1, nil
+}
+
+// mapAddress converts an address echoed on the wire back to the register map
+func (h *ProtocolHandler) mapAddress(address common.Address) common.Address {
+	if h.quirks.Has(common.QuirkOneBasedAddressing) {
+		return address - 1
+	}
+	return address
 }
 
 // generateReadRequest is a helper function for generating read requests that follow the same pattern
@@ -50,6 +89,11 @@ func (h *ProtocolHandler) generateReadRequest(itemType string, address common.Ad
 	ctx := context.Background()
 	h.logger.Debug(ctx, "Generating read %s request: address=%d, quantity=%d", itemType, address, quantity)
 
+	address, err := h.wireAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
 	if quantity == 0 || quantity > maxQuantity {
 		h.logger.Error(ctx, "Invalid quantity for read %s request: %d (max %d)", itemType, quantity, maxQuantity)
 		return nil, common.ErrInvalidQuantity
@@ -66,78 +110,118 @@ func (h *ProtocolHandler) generateReadRequest(itemType string, address common.Ad
 // parseBitResponse is a helper function for parsing responses that contain bit values
 // (coils and discrete inputs)
 func (h *ProtocolHandler) parseBitResponse(itemType string, data []byte, quantity common.Quantity) ([]bool, error) {
+	values := make([]bool, quantity)
+	if err := h.parseBitResponseInto(itemType, data, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseBitResponseInto parses a bit value response into dst, whose length
+// is the quantity requested
+func (h *ProtocolHandler) parseBitResponseInto(itemType string, data []byte, dst []bool) error {
 	ctx := context.Background()
-	h.logger.Debug(ctx, "Parsing read %s response: data=%v, quantity=%d", itemType, data, quantity)
+	quantity := len(dst)
+	// Debug arguments are only formatted when enabled, so that decoding
+	// into a reused slice does not allocate
+	debug := h.logger.GetLevel() <= common.LevelDebug
+	if debug {
+		h.logger.Debug(ctx, "Parsing read %s response: data=%v, quantity=%d", itemType, data, quantity)
+	}
 
 	if len(data) == 0 {
 		h.logger.Error(ctx, "Empty response for read %s", itemType)
-		return nil, common.ErrEmptyResponse
+		return common.ErrEmptyResponse
 	}
 
 	// First byte is the byte count
 	byteCount := int(data[0])
+	if h.quirks.Has(common.QuirkLenientByteCount) {
+		byteCount = len(data) - 1
+	}
 	if len(data) != byteCount+1 {
 		h.logger.Error(ctx, "Invalid response length for read %s: expected %d, got %d",
 			itemType, byteCount+1, len(data))
-		return nil, common.ErrInvalidResponseLength
+		return common.ErrInvalidResponseLength
 	}
 
 	// Calculate the expected byte count
-	expectedByteCount := int(math.Ceil(float64(quantity) / 8.0))
-	if byteCount != expectedByteCount {
+	expectedByteCount := (quantity + 7) / 8
+	if byteCount != expectedByteCount && !(h.quirks.Has(common.QuirkLenientByteCount) && byteCount > expectedByteCount) {
 		h.logger.Error(ctx, "Invalid byte count for read %s: expected %d, got %d",
 			itemType, expectedByteCount, byteCount)
-		return nil, common.ErrInvalidResponseLength
+		return common.ErrInvalidResponseLength
 	}
 
 	// Parse the values
-	values := make([]bool, quantity)
-	for i := 0; i < int(quantity); i++ {
+	for i := 0; i < quantity; i++ {
 		byteIndex := i / 8
 		bitIndex := i % 8
 		byteValue := data[1+byteIndex]
-		values[i] = ((byteValue >> uint(bitIndex)) & 0x01) == 1
+		dst[i] = ((byteValue >> uint(bitIndex)) & 0x01) == 1
 	}
 
-	h.logger.Debug(ctx, "Parsed %d %s values", len(values), itemType)
-	return values, nil
+	if debug {
+		h.logger.Debug(ctx, "Parsed %d %s values", quantity, itemType)
+	}
+	return nil
 }
 
 // parseRegisterResponse is a helper function for parsing responses that contain register values
 // (holding registers and input registers)
 func (h *ProtocolHandler) parseRegisterResponse(itemType string, data []byte, quantity common.Quantity) ([]uint16, error) {
+	values := make([]uint16, quantity)
+	if err := h.parseRegisterResponseInto(itemType, data, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseRegisterResponseInto parses a register value response into dst, whose
+// length is the quantity requested
+func (h *ProtocolHandler) parseRegisterResponseInto(itemType string, data []byte, dst []uint16) error {
 	ctx := context.Background()
-	h.logger.Debug(ctx, "Parsing read %s response: data=%v, quantity=%d", itemType, data, quantity)
+	quantity := len(dst)
+	// Debug arguments are only formatted when enabled, so that decoding
+	// into a reused slice does not allocate
+	debug := h.logger.GetLevel() <= common.LevelDebug
+	if debug {
+		h.logger.Debug(ctx, "Parsing read %s response: data=%v, quantity=%d", itemType, data, quantity)
+	}
 
 	if len(data) == 0 {
 		h.logger.Error(ctx, "Empty response for read %s", itemType)
-		return nil, common.ErrEmptyResponse
+		return common.ErrEmptyResponse
 	}
 
 	// First byte is the byte count
 	byteCount := int(data[0])
+	if h.quirks.Has(common.QuirkLenientByteCount) {
+		byteCount = len(data) - 1
+	}
 	if len(data) != byteCount+1 {
 		h.logger.Error(ctx, "Invalid response length for read %s: expected %d, got %d",
 			itemType, byteCount+1, len(data))
-		return nil, common.ErrInvalidResponseLength
+		return common.ErrInvalidResponseLength
 	}
 
 	// Calculate the expected byte count
-	expectedByteCount := int(quantity) * 2
-	if byteCount != expectedByteCount {
+	expectedByteCount := quantity * 2
+	if byteCount != expectedByteCount && !(h.quirks.Has(common.QuirkLenientByteCount) && byteCount > expectedByteCount) {
 		h.logger.Error(ctx, "Invalid byte count for read %s: expected %d, got %d",
 			itemType, expectedByteCount, byteCount)
-		return nil, common.ErrInvalidResponseLength
+		return common.ErrInvalidResponseLength
 	}
 
 	// Parse the values
-	values := make([]uint16, quantity)
-	for i := 0; i < int(quantity); i++ {
-		values[i] = binary.BigEndian.Uint16(data[1+i*2 : 1+i*2+2])
+	for i := 0; i < quantity; i++ {
+		dst[i] = binary.BigEndian.Uint16(data[1+i*2 : 1+i*2+2])
 	}
 
-	h.logger.Debug(ctx, "Parsed %d %s values", len(values), itemType)
-	return values, nil
+	if debug {
+		h.logger.Debug(ctx, "Parsed %d %s values", quantity, itemType)
+	}
+	return nil
 }
 
 // GenerateReadCoilsRequest generates a request to read coils
@@ -173,6 +257,12 @@ func (h *ProtocolHandler) ParseReadCoilsResponse(data []byte, quantity common.Qu
 	return coilValues, nil
 }
 
+// ParseReadCoilsResponseInto parses a response to a read coils request into
+// dst, whose length is the quantity requested, without allocating
+func (h *ProtocolHandler) ParseReadCoilsResponseInto(data []byte, dst []common.CoilValue) error {
+	return h.parseBitResponseInto("coils", data, dst)
+}
+
 // GenerateReadDiscreteInputsRequest generates a request to read discrete inputs
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.2 (Read Discrete Inputs)
 func (h *ProtocolHandler) GenerateReadDiscreteInputsRequest(address common.Address, quantity common.Quantity) ([]byte, error) {
@@ -197,6 +287,12 @@ func (h *ProtocolHandler) ParseReadDiscreteInputsResponse(data []byte, quantity
 	return discreteValues, nil
 }
 
+// ParseReadDiscreteInputsResponseInto parses a response to a read discrete inputs request into
+// dst, whose length is the quantity requested, without allocating
+func (h *ProtocolHandler) ParseReadDiscreteInputsResponseInto(data []byte, dst []common.DiscreteInputValue) error {
+	return h.parseBitResponseInto("discrete inputs", data, dst)
+}
+
 // GenerateReadHoldingRegistersRequest generates a request to read holding registers
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3 (Read Holding Registers)
 func (h *ProtocolHandler) GenerateReadHoldingRegistersRequest(address common.Address, quantity common.Quantity) ([]byte, error) {
@@ -221,6 +317,12 @@ func (h *ProtocolHandler) ParseReadHoldingRegistersResponse(data []byte, quantit
 	return registerValues, nil
 }
 
+// ParseReadHoldingRegistersResponseInto parses a response to a read holding registers request into
+// dst, whose length is the quantity requested, without allocating
+func (h *ProtocolHandler) ParseReadHoldingRegistersResponseInto(data []byte, dst []common.RegisterValue) error {
+	return h.parseRegisterResponseInto("holding registers", data, dst)
+}
+
 // GenerateReadInputRegistersRequest generates a request to read input registers
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.4 (Read Input Registers)
 func (h *ProtocolHandler) GenerateReadInputRegistersRequest(address common.Address, quantity common.Quantity) ([]byte, error) {
@@ -245,6 +347,12 @@ func (h *ProtocolHandler) ParseReadInputRegistersResponse(data []byte, quantity
 	return inputValues, nil
 }
 
+// ParseReadInputRegistersResponseInto parses a response to a read input registers request into
+// dst, whose length is the quantity requested, without allocating
+func (h *ProtocolHandler) ParseReadInputRegistersResponseInto(data []byte, dst []common.InputRegisterValue) error {
+	return h.parseRegisterResponseInto("input registers", data, dst)
+}
+
 // GenerateWriteSingleCoilRequest generates a request to write a single coil
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.5 (Write Single Coil)
 //
@@ -255,6 +363,11 @@ func (h *ProtocolHandler) GenerateWriteSingleCoilRequest(address common.Address,
 	ctx := context.Background()
 	h.logger.Debug(ctx, "Generating write single coil request: address=%d, value=%t", address, value)
 
+	address, err := h.wireAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
 	data := make([]byte, 4)
 	// Write address in big-endian format (most significant byte first)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (Data Encoding)
@@ -291,7 +404,7 @@ func (h *ProtocolHandler) ParseWriteSingleCoilResponse(data []byte) (common.Addr
 
 	// Parse address from big-endian format
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (Data Encoding)
-	address := common.Address(binary.BigEndian.Uint16(data[0:2]))
+	address := h.mapAddress(common.Address(binary.BigEndian.Uint16(data[0:2])))
 	value := binary.BigEndian.Uint16(data[2:4])
 
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.5
@@ -314,6 +427,11 @@ func (h *ProtocolHandler) GenerateWriteSingleRegisterRequest(address common.Addr
 	ctx := context.Background()
 	h.logger.Debug(ctx, "Generating write single register request: address=%d, value=%d", address, value)
 
+	address, err := h.wireAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
 	data := make([]byte, 4)
 	binary.BigEndian.PutUint16(data[0:2], uint16(address))
 	binary.BigEndian.PutUint16(data[2:4], value)
@@ -332,7 +450,7 @@ func (h *ProtocolHandler) ParseWriteSingleRegisterResponse(data []byte) (common.
 		return 0, 0, common.ErrInvalidResponseLength
 	}
 
-	address := common.Address(binary.BigEndian.Uint16(data[0:2]))
+	address := h.mapAddress(common.Address(binary.BigEndian.Uint16(data[0:2])))
 	value := common.RegisterValue(binary.BigEndian.Uint16(data[2:4]))
 
 	h.logger.Debug(ctx, "Parsed write single register response: address=%d, value=%d", address, value)
@@ -353,6 +471,11 @@ func (h *ProtocolHandler) GenerateWriteMultipleCoilsRequest(address common.Addre
 	h.logger.Debug(ctx, "Generating write multiple coils request: address=%d, count=%d",
 		address, len(values))
 
+	address, err := h.wireAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(values) == 0 || len(values) > common.MaxWriteCoilCount {
 		h.logger.Error(ctx, "Invalid quantity for write multiple coils request: %d", len(values))
 		return nil, common.ErrInvalidQuantity
@@ -402,7 +525,7 @@ func (h *ProtocolHandler) ParseWriteMultipleCoilsResponse(data []byte) (common.A
 
 	// Parse address and quantity from big-endian format
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (Data Encoding)
-	address := common.Address(binary.BigEndian.Uint16(data[0:2]))
+	address := h.mapAddress(common.Address(binary.BigEndian.Uint16(data[0:2])))
 	quantity := common.Quantity(binary.BigEndian.Uint16(data[2:4]))
 
 	h.logger.Debug(ctx, "Parsed write multiple coils response: address=%d, quantity=%d", address, quantity)
@@ -415,6 +538,11 @@ func (h *ProtocolHandler) GenerateWriteMultipleRegistersRequest(address common.A
 	h.logger.Debug(ctx, "Generating write multiple registers request: address=%d, count=%d",
 		address, len(values))
 
+	address, err := h.wireAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(values) == 0 || len(values) > common.MaxWriteRegisterCount {
 		h.logger.Error(ctx, "Invalid quantity for write multiple registers request: %d", len(values))
 		return nil, common.ErrInvalidQuantity
@@ -452,13 +580,58 @@ func (h *ProtocolHandler) ParseWriteMultipleRegistersResponse(data []byte) (comm
 		return 0, 0, common.ErrInvalidResponseLength
 	}
 
-	address := common.Address(binary.BigEndian.Uint16(data[0:2]))
+	address := h.mapAddress(common.Address(binary.BigEndian.Uint16(data[0:2])))
 	quantity := common.Quantity(binary.BigEndian.Uint16(data[2:4]))
 
 	h.logger.Debug(ctx, "Parsed write multiple registers response: address=%d, quantity=%d", address, quantity)
 	return address, quantity, nil
 }
 
+// GenerateMaskWriteRegisterRequest generates a request to modify bits of a register
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16 (Mask Write Register)
+//
+// PDU Data:
+// Reference Address (2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16
+// And_Mask (2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16
+// Or_Mask (2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16
+func (h *ProtocolHandler) GenerateMaskWriteRegisterRequest(address common.Address, andMask, orMask uint16) ([]byte, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Generating mask write register request: address=%d, andMask=0x%04X, orMask=0x%04X", address, andMask, orMask)
+
+	address, err := h.wireAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 6)
+	binary.BigEndian.PutUint16(data[0:2], uint16(address))
+	binary.BigEndian.PutUint16(data[2:4], andMask)
+	binary.BigEndian.PutUint16(data[4:6], orMask)
+
+	h.logger.Debug(ctx, "Generated mask write register request data: %v", data)
+	return data, nil
+}
+
+// ParseMaskWriteRegisterResponse parses a response to a mask write register request
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16 (Mask Write Register)
+// The normal response is an echo of the request.
+func (h *ProtocolHandler) ParseMaskWriteRegisterResponse(data []byte) (common.Address, uint16, uint16, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Parsing mask write register response: data=%v", data)
+
+	if len(data) != 6 {
+		h.logger.Error(ctx, "Invalid response length for mask write register: expected 6, got %d", len(data))
+		return 0, 0, 0, common.ErrInvalidResponseLength
+	}
+
+	address := h.mapAddress(common.Address(binary.BigEndian.Uint16(data[0:2])))
+	andMask := binary.BigEndian.Uint16(data[2:4])
+	orMask := binary.BigEndian.Uint16(data[4:6])
+
+	h.logger.Debug(ctx, "Parsed mask write register response: address=%d, andMask=0x%04X, orMask=0x%04X", address, andMask, orMask)
+	return address, andMask, orMask, nil
+}
+
 // GenerateReadWriteMultipleRegistersRequest generates a request to read and write multiple registers
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.17 (Read/Write Multiple Registers)
 //
@@ -476,6 +649,15 @@ func (h *ProtocolHandler) GenerateReadWriteMultipleRegistersRequest(readAddress
 	h.logger.Debug(ctx, "Generating read/write multiple registers request: readAddress=%d, readQuantity=%d, writeAddress=%d, writeCount=%d",
 		readAddress, readQuantity, writeAddress, len(writeValues))
 
+	readAddress, err := h.wireAddress(readAddress)
+	if err != nil {
+		return nil, err
+	}
+	writeAddress, err = h.wireAddress(writeAddress)
+	if err != nil {
+		return nil, err
+	}
+
 	if readQuantity == 0 || readQuantity > common.MaxReadWriteReadCount {
 		h.logger.Error(ctx, "Invalid read quantity for read/write multiple registers request: %d", readQuantity)
 		return nil, common.ErrInvalidQuantity
@@ -527,6 +709,63 @@ func (h *ProtocolHandler) ParseReadWriteMultipleRegistersResponse(data []byte, r
 	return h.ParseReadHoldingRegistersResponse(data, readQuantity)
 }
 
+// GenerateReadFIFOQueueRequest generates a request to read a FIFO queue
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18 (Read FIFO Queue)
+//
+// PDU Data:
+// FIFO Pointer Address (2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+func (h *ProtocolHandler) GenerateReadFIFOQueueRequest(pointerAddress common.Address) ([]byte, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Generating read FIFO queue request: pointerAddress=%d", pointerAddress)
+
+	pointerAddress, err := h.wireAddress(pointerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data[0:2], uint16(pointerAddress))
+
+	h.logger.Debug(ctx, "Generated read FIFO queue request data: %v", data)
+	return data, nil
+}
+
+// ParseReadFIFOQueueResponse parses a response to a read FIFO queue request
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18 (Read FIFO Queue)
+//
+// PDU Data:
+// Byte Count (2 bytes) - bytes to follow, FIFO count included - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+// FIFO Count (2 bytes) - at most 31 - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+// FIFO Value Register (FIFO Count * 2 bytes) - Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+func (h *ProtocolHandler) ParseReadFIFOQueueResponse(data []byte) ([]common.RegisterValue, error) {
+	ctx := context.Background()
+	h.logger.Debug(ctx, "Parsing read FIFO queue response: data=%v", data)
+
+	if len(data) < 4 {
+		h.logger.Error(ctx, "Invalid response length for read FIFO queue: expected at least 4, got %d", len(data))
+		return nil, common.ErrInvalidResponseLength
+	}
+
+	byteCount := int(binary.BigEndian.Uint16(data[0:2]))
+	count := int(binary.BigEndian.Uint16(data[2:4]))
+	if count > common.MaxFIFOCount {
+		h.logger.Error(ctx, "Invalid FIFO count in response: %d", count)
+		return nil, fmt.Errorf("%w: FIFO count %d exceeds %d", common.ErrInvalidResponseFormat, count, common.MaxFIFOCount)
+	}
+	if byteCount != 2+count*2 || len(data) != 2+byteCount {
+		h.logger.Error(ctx, "Invalid byte count for read FIFO queue: byte count %d, FIFO count %d, %d bytes", byteCount, count, len(data))
+		return nil, common.ErrInvalidResponseLength
+	}
+
+	values := make([]common.RegisterValue, count)
+	for i := range values {
+		values[i] = common.RegisterValue(binary.BigEndian.Uint16(data[4+i*2:]))
+	}
+
+	h.logger.Debug(ctx, "Parsed read FIFO queue response: %d values", count)
+	return values, nil
+}
+
 // GenerateReadExceptionStatusRequest generates a request to read the exception status
 func (h *ProtocolHandler) GenerateReadExceptionStatusRequest() ([]byte, error) {
 	ctx := context.Background()
@@ -585,6 +824,19 @@ func (h *ProtocolHandler) ParseReadDeviceIdentificationResponse(data []byte) (*c
 		return nil, common.ErrInvalidResponseLength
 	}
 
+	// The response, function code included, must fit in one PDU
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (Protocol Description)
+	if 1+len(data) > common.MaxPDULength {
+		h.logger.Error(ctx, "Read device identification response of %d bytes exceeds the PDU", 1+len(data))
+		return nil, common.ErrInvalidResponseLength
+	}
+
+	// Every object takes at least its ID and length byte
+	if 6+2*int(data[5]) > len(data) {
+		h.logger.Error(ctx, "Read device identification response declares %d objects in %d bytes", data[5], len(data)-6)
+		return nil, common.ErrInvalidResponseFormat
+	}
+
 	// Check MEI Type
 	if common.MEIType(data[0]) != common.MEIReadDeviceID {
 		h.logger.Error(ctx, "Invalid MEI type: 0x%02X, expected 0x%02X", data[0], common.MEIReadDeviceID)
@@ -641,6 +893,11 @@ func (h *ProtocolHandler) ParseReadDeviceIdentificationResponse(data []byte) (*c
 		})
 	}
 
+	if offset != len(data) && !h.quirks.Has(common.QuirkLenientByteCount) {
+		h.logger.Error(ctx, "Invalid response format for read device identification: %d bytes after the objects", len(data)-offset)
+		return nil, common.ErrInvalidResponseFormat
+	}
+
 	h.logger.Debug(ctx, "Parsed read device identification response: %d objects", len(result.Objects))
 	return result, nil
 }
@@ -1,7 +1,9 @@
 package protocol
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"testing"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
@@ -296,6 +298,51 @@ func TestGenerateWriteSingleRegisterRequest(t *testing.T) {
 	}
 }
 
+func TestMaskWriteRegister(t *testing.T) {
+	handler := NewProtocolHandler()
+
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16
+	data, err := handler.GenerateMaskWriteRegisterRequest(4, 0x00F2, 0x0025)
+	expected := []byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x25}
+	if err != nil || !bytes.Equal(data, expected) {
+		t.Errorf("GenerateMaskWriteRegisterRequest: expected % X, got % X (%v)", expected, data, err)
+	}
+
+	address, andMask, orMask, err := handler.ParseMaskWriteRegisterResponse(expected)
+	if err != nil || address != 4 || andMask != 0x00F2 || orMask != 0x0025 {
+		t.Errorf("ParseMaskWriteRegisterResponse: got %d, 0x%04X, 0x%04X (%v)", address, andMask, orMask, err)
+	}
+
+	if _, _, _, err := handler.ParseMaskWriteRegisterResponse(expected[:4]); !errors.Is(err, common.ErrInvalidResponseLength) {
+		t.Errorf("ParseMaskWriteRegisterResponse with short data: expected ErrInvalidResponseLength, got %v", err)
+	}
+}
+
+func TestParseReadFIFOQueueResponse(t *testing.T) {
+	handler := NewProtocolHandler()
+
+	values, err := handler.ParseReadFIFOQueueResponse([]byte{0x00, 0x02, 0x00, 0x00})
+	if err != nil || len(values) != 0 {
+		t.Errorf("ParseReadFIFOQueueResponse for an empty queue: got %v (%v)", values, err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"short", []byte{0x00, 0x02}, common.ErrInvalidResponseLength},
+		{"byte count disagrees with FIFO count", []byte{0x00, 0x02, 0x00, 0x01, 0x00, 0x07}, common.ErrInvalidResponseLength},
+		{"missing values", []byte{0x00, 0x04, 0x00, 0x01}, common.ErrInvalidResponseLength},
+		{"FIFO count over 31", append([]byte{0x00, 0x42, 0x00, 0x20}, make([]byte, 64)...), common.ErrInvalidResponseFormat},
+	}
+	for _, tc := range tests {
+		if _, err := handler.ParseReadFIFOQueueResponse(tc.data); !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
 func TestProtocolHandler_WithLogger(t *testing.T) {
 	// Create a protocol handler with a custom logger
 	logger := logging.NewLogger()
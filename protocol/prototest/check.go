@@ -0,0 +1,245 @@
+package prototest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Run checks p against every vector of the corpus, as subtests of t
+func Run(t *testing.T, p common.Protocol) {
+	t.Helper()
+	for _, v := range Vectors() {
+		t.Run(v.Name, func(t *testing.T) {
+			if err := CheckVector(p, v); err != nil {
+				t.Errorf("%v (source: %s)", err, v.Source)
+			}
+		})
+	}
+}
+
+// CheckVector checks that p generates the vector's request from its fields
+// and parses its response back to them. For invalid vectors p must refuse
+// to generate the request. Protocols implementing common.BufferedProtocol
+// are also checked parsing into slices.
+func CheckVector(p common.Protocol, v Vector) error {
+	request, err := generate(p, v)
+	if v.Invalid {
+		if err == nil {
+			return fmt.Errorf("generated % X for invalid fields, want an error", request)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("generating request: %w", err)
+	}
+	if !bytes.Equal(request, v.Request.Data()) {
+		return fmt.Errorf("generated request % X, want % X", request, v.Request.Data())
+	}
+	if v.Response.IsException() {
+		return nil
+	}
+	if err := parse(p, v); err != nil {
+		return fmt.Errorf("parsing response %s: %w", v.Response, err)
+	}
+	return nil
+}
+
+// generate encodes the request data of a vector from its fields
+func generate(p common.Protocol, v Vector) ([]byte, error) {
+	switch fc := v.Request.FunctionCode(); fc {
+	case common.FuncReadCoils:
+		return p.GenerateReadCoilsRequest(v.Address, v.Quantity)
+	case common.FuncReadDiscreteInputs:
+		return p.GenerateReadDiscreteInputsRequest(v.Address, v.Quantity)
+	case common.FuncReadHoldingRegisters:
+		return p.GenerateReadHoldingRegistersRequest(v.Address, v.Quantity)
+	case common.FuncReadInputRegisters:
+		return p.GenerateReadInputRegistersRequest(v.Address, v.Quantity)
+	case common.FuncWriteSingleCoil:
+		if len(v.Written) != 1 {
+			return nil, errors.New("vector must write one coil")
+		}
+		return p.GenerateWriteSingleCoilRequest(v.Address, v.Written[0] != 0)
+	case common.FuncWriteSingleRegister:
+		if len(v.Written) != 1 {
+			return nil, errors.New("vector must write one register")
+		}
+		return p.GenerateWriteSingleRegisterRequest(v.Address, v.Written[0])
+	case common.FuncWriteMultipleCoils:
+		return p.GenerateWriteMultipleCoilsRequest(v.Address, Bits(v.Written))
+	case common.FuncWriteMultipleRegisters:
+		return p.GenerateWriteMultipleRegistersRequest(v.Address, v.Written)
+	case common.FuncReadFileRecord:
+		return p.GenerateReadFileRecordRequest(v.FileRecordRequests())
+	case common.FuncWriteFileRecord:
+		return p.GenerateWriteFileRecordRequest(v.FileRecords())
+	case common.FuncMaskWriteRegister:
+		return p.GenerateMaskWriteRegisterRequest(v.Address, v.AndMask, v.OrMask)
+	case common.FuncReadWriteMultipleRegisters:
+		return p.GenerateReadWriteMultipleRegistersRequest(v.Address, v.Quantity, v.WriteAddress, v.Written)
+	case common.FuncReadFIFOQueue:
+		return p.GenerateReadFIFOQueueRequest(v.Address)
+	case common.FuncReadExceptionStatus:
+		return p.GenerateReadExceptionStatusRequest()
+	case common.FuncReadDeviceIdentification:
+		return p.GenerateReadDeviceIdentificationRequest(v.DeviceIDCode, v.ObjectID)
+	default:
+		return nil, fmt.Errorf("no vector support for function %s", fc)
+	}
+}
+
+// parse decodes the response data of a vector and compares it to its fields
+func parse(p common.Protocol, v Vector) error {
+	data := v.Response.Data()
+	buffered, _ := p.(common.BufferedProtocol)
+	switch v.Response.FunctionCode() {
+	case common.FuncReadCoils:
+		values, err := p.ParseReadCoilsResponse(data, v.Quantity)
+		if err := compare(values, Bits(v.Read), err); err != nil || buffered == nil {
+			return err
+		}
+		return compareInto(Bits(v.Read), func(dst []bool) error { return buffered.ParseReadCoilsResponseInto(data, dst) })
+	case common.FuncReadDiscreteInputs:
+		values, err := p.ParseReadDiscreteInputsResponse(data, v.Quantity)
+		if err := compare(values, Bits(v.Read), err); err != nil || buffered == nil {
+			return err
+		}
+		return compareInto(Bits(v.Read), func(dst []bool) error { return buffered.ParseReadDiscreteInputsResponseInto(data, dst) })
+	case common.FuncReadHoldingRegisters:
+		values, err := p.ParseReadHoldingRegistersResponse(data, v.Quantity)
+		if err := compare(values, v.Read, err); err != nil || buffered == nil {
+			return err
+		}
+		return compareInto(v.Read, func(dst []uint16) error { return buffered.ParseReadHoldingRegistersResponseInto(data, dst) })
+	case common.FuncReadInputRegisters:
+		values, err := p.ParseReadInputRegistersResponse(data, v.Quantity)
+		if err := compare(values, v.Read, err); err != nil || buffered == nil {
+			return err
+		}
+		return compareInto(v.Read, func(dst []uint16) error { return buffered.ParseReadInputRegistersResponseInto(data, dst) })
+	case common.FuncWriteSingleCoil:
+		address, value, err := p.ParseWriteSingleCoilResponse(data)
+		return compareEcho(address, uint16(boolValue(value)), v.Address, v.Written[0], err)
+	case common.FuncWriteSingleRegister:
+		address, value, err := p.ParseWriteSingleRegisterResponse(data)
+		return compareEcho(address, value, v.Address, v.Written[0], err)
+	case common.FuncWriteMultipleCoils:
+		address, quantity, err := p.ParseWriteMultipleCoilsResponse(data)
+		return compareEcho(address, uint16(quantity), v.Address, uint16(v.Quantity), err)
+	case common.FuncWriteMultipleRegisters:
+		address, quantity, err := p.ParseWriteMultipleRegistersResponse(data)
+		return compareEcho(address, uint16(quantity), v.Address, uint16(v.Quantity), err)
+	case common.FuncReadFileRecord:
+		records, err := p.ParseReadFileRecordResponse(data, v.FileRecordRequests())
+		return compareRecords(records, v.FileRecords(), err)
+	case common.FuncWriteFileRecord:
+		records, err := p.ParseWriteFileRecordResponse(data)
+		return compareRecords(records, v.FileRecords(), err)
+	case common.FuncMaskWriteRegister:
+		address, andMask, orMask, err := p.ParseMaskWriteRegisterResponse(data)
+		if err := compareEcho(address, andMask, v.Address, v.AndMask, err); err != nil {
+			return err
+		}
+		return compareEcho(address, orMask, v.Address, v.OrMask, nil)
+	case common.FuncReadWriteMultipleRegisters:
+		values, err := p.ParseReadWriteMultipleRegistersResponse(data, v.Quantity)
+		return compare(values, v.Read, err)
+	case common.FuncReadFIFOQueue:
+		values, err := p.ParseReadFIFOQueueResponse(data)
+		return compare(values, v.Read, err)
+	case common.FuncReadExceptionStatus:
+		status, err := p.ParseReadExceptionStatusResponse(data)
+		return compare([]uint16{uint16(status)}, v.Read, err)
+	case common.FuncReadDeviceIdentification:
+		deviceID, err := p.ParseReadDeviceIdentificationResponse(data)
+		if err != nil {
+			return err
+		}
+		return compareDeviceID(deviceID, v)
+	default:
+		return fmt.Errorf("no vector support for function %s", v.Response.FunctionCode())
+	}
+}
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// compare checks decoded values against the vector's
+func compare[T comparable](got, want []T, err error) error {
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(got, want) {
+		return fmt.Errorf("decoded %v, want %v", got, want)
+	}
+	return nil
+}
+
+// compareInto checks values parsed into a slice against the vector's
+func compareInto[T comparable](want []T, parseInto func([]T) error) error {
+	dst := make([]T, len(want))
+	if err := parseInto(dst); err != nil {
+		return fmt.Errorf("parsing into a slice: %w", err)
+	}
+	if !slices.Equal(dst, want) {
+		return fmt.Errorf("parsed %v into a slice, want %v", dst, want)
+	}
+	return nil
+}
+
+// compareRecords checks decoded file records against the vector's
+func compareRecords(got, want []common.FileRecord, err error) error {
+	if err != nil {
+		return err
+	}
+	equal := func(a, b common.FileRecord) bool {
+		return a.FileNumber == b.FileNumber && a.RecordNumber == b.RecordNumber && slices.Equal(a.Values, b.Values)
+	}
+	if !slices.EqualFunc(got, want, equal) {
+		return fmt.Errorf("decoded records %v, want %v", got, want)
+	}
+	return nil
+}
+
+// compareEcho checks the address and value or quantity a write echoes
+func compareEcho(address common.Address, value uint16, wantAddress common.Address, wantValue uint16, err error) error {
+	if err != nil {
+		return err
+	}
+	if address != wantAddress || value != wantValue {
+		return fmt.Errorf("decoded echo %d/%d, want %d/%d", address, value, wantAddress, wantValue)
+	}
+	return nil
+}
+
+// compareDeviceID checks a decoded identification against the vector's
+func compareDeviceID(deviceID *common.DeviceIdentification, v Vector) error {
+	moreFollows := common.MoreFollowsNo
+	if v.MoreFollows {
+		moreFollows = common.MoreFollowsYes
+	}
+	if deviceID.ReadDeviceIDCode != v.DeviceIDCode || deviceID.ConformityLevel != v.ConformityLevel ||
+		deviceID.MoreFollows != moreFollows || deviceID.NextObjectID != v.NextObjectID {
+		return fmt.Errorf("decoded header code=%d conformity=%d more=%s next=%d, want code=%d conformity=%d more=%s next=%d",
+			deviceID.ReadDeviceIDCode, deviceID.ConformityLevel, deviceID.MoreFollows, deviceID.NextObjectID,
+			v.DeviceIDCode, v.ConformityLevel, moreFollows, v.NextObjectID)
+	}
+	objects := make(map[common.DeviceIDObjectCode]string, len(deviceID.Objects))
+	for _, object := range deviceID.Objects {
+		objects[object.ID] = object.Value
+	}
+	if !maps.Equal(objects, v.Objects) {
+		return fmt.Errorf("decoded objects %v, want %v", objects, v.Objects)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Package prototest is a corpus of golden request and response PDUs for the
+// function codes of common.Protocol, shared by the protocol and server tests.
+// Vectors come from the examples and quantity limits of the specification;
+// captures from devices go in testdata/vectors.json with the device named in
+// their source. Run checks a custom Protocol implementation against it:
+//
+//	func TestMyProtocol(t *testing.T) {
+//		prototest.Run(t, mycodec.NewProtocol())
+//	}
+package prototest
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+//go:embed testdata/vectors.json
+var corpus []byte
+
+// PDU is a protocol data unit, function code first, written in the corpus
+// as hex bytes separated by spaces
+type PDU []byte
+
+// FunctionCode returns the function code of the PDU
+func (p PDU) FunctionCode() common.FunctionCode {
+	if len(p) == 0 {
+		return 0
+	}
+	return common.FunctionCode(p[0])
+}
+
+// Data returns the PDU without its function code
+func (p PDU) Data() []byte {
+	if len(p) == 0 {
+		return nil
+	}
+	return p[1:]
+}
+
+// IsException reports whether the PDU is an exception response
+func (p PDU) IsException() bool {
+	return p.FunctionCode()&common.FunctionCode(common.ExceptionBit) != 0
+}
+
+// String returns the PDU as hex bytes
+func (p PDU) String() string {
+	return fmt.Sprintf("% X", []byte(p))
+}
+
+// UnmarshalJSON decodes hex bytes, ignoring spaces
+func (p *PDU) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		return err
+	}
+	*p = decoded
+	return nil
+}
+
+// Vector is a golden request with the response a conforming server gives,
+// and the decoded fields of both. Bit values are 0 or 1.
+type Vector struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"` // specification section or device the bytes come from
+	Request  PDU    `json:"request"`
+	Response PDU    `json:"response"`
+
+	// ClientOnly vectors have responses that depend on the server's
+	// configuration, such as its identification, so servers are not
+	// checked against them
+	ClientOnly bool `json:"client_only,omitempty"`
+
+	// Invalid vectors carry fields a Protocol must refuse to encode, and
+	// the exception a server answers their request with
+	Invalid bool `json:"invalid,omitempty"`
+
+	Address      common.Address  `json:"address"`
+	Quantity     common.Quantity `json:"quantity"`
+	WriteAddress common.Address  `json:"write_address,omitempty"` // Read/Write Multiple Registers
+	Written      []uint16        `json:"written,omitempty"`
+	Read         []uint16        `json:"read,omitempty"`
+
+	// Mask Write Register; Read is the register before the write and
+	// Written the register after it
+	AndMask uint16 `json:"and_mask,omitempty"`
+	OrMask  uint16 `json:"or_mask,omitempty"`
+
+	// Read and Write File Record; records read take their length from
+	// their values
+	Records []Record `json:"records,omitempty"`
+
+	// Read Device Identification
+	DeviceIDCode    common.ReadDeviceIDCode              `json:"device_id_code,omitempty"`
+	ObjectID        common.DeviceIDObjectCode            `json:"object_id,omitempty"`
+	ConformityLevel common.ConformityLevel               `json:"conformity_level,omitempty"`
+	MoreFollows     bool                                 `json:"more_follows,omitempty"`
+	NextObjectID    common.DeviceIDObjectCode            `json:"next_object_id,omitempty"`
+	Objects         map[common.DeviceIDObjectCode]string `json:"objects,omitempty"`
+}
+
+// Record is a group of registers of a file
+type Record struct {
+	File   uint16   `json:"file"`
+	Record uint16   `json:"record"`
+	Values []uint16 `json:"values"`
+}
+
+// FileRecordRequests returns the read sub-requests of a vector
+func (v Vector) FileRecordRequests() []common.FileRecordRequest {
+	requests := make([]common.FileRecordRequest, len(v.Records))
+	for i, r := range v.Records {
+		requests[i] = common.FileRecordRequest{FileNumber: r.File, RecordNumber: r.Record, Length: common.Quantity(len(r.Values))}
+	}
+	return requests
+}
+
+// FileRecords returns the records of a vector
+func (v Vector) FileRecords() []common.FileRecord {
+	records := make([]common.FileRecord, len(v.Records))
+	for i, r := range v.Records {
+		records[i] = common.FileRecord{FileNumber: r.File, RecordNumber: r.Record, Values: r.Values}
+	}
+	return records
+}
+
+// Bits returns values as coil or discrete input values
+func Bits(values []uint16) []bool {
+	bits := make([]bool, len(values))
+	for i, v := range values {
+		bits[i] = v != 0
+	}
+	return bits
+}
+
+var loadVectors = sync.OnceValue(func() []Vector {
+	var vectors []Vector
+	if err := json.Unmarshal(corpus, &vectors); err != nil {
+		panic(fmt.Sprintf("prototest: malformed corpus: %v", err))
+	}
+	return vectors
+})
+
+// Vectors returns the corpus
+func Vectors() []Vector {
+	return slices.Clone(loadVectors())
+}
@@ -0,0 +1,61 @@
+package prototest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/protocol"
+)
+
+// swappedProtocol encodes register values little-endian
+type swappedProtocol struct {
+	*protocol.ProtocolHandler
+}
+
+func (p swappedProtocol) ParseReadHoldingRegistersResponse(data []byte, quantity common.Quantity) ([]common.RegisterValue, error) {
+	values, err := p.ProtocolHandler.ParseReadHoldingRegistersResponse(data, quantity)
+	for i, v := range values {
+		values[i] = v>>8 | v<<8
+	}
+	return values, err
+}
+
+func TestCheckVector(t *testing.T) {
+	vectors := Vectors()
+	if len(vectors) == 0 {
+		t.Fatal("Expected a corpus")
+	}
+	functions := make(map[common.FunctionCode]bool)
+	for _, v := range vectors {
+		functions[v.Request.FunctionCode()] = true
+		if v.Source == "" {
+			t.Errorf("%s: expected a source", v.Name)
+		}
+	}
+	for _, fc := range []common.FunctionCode{
+		common.FuncReadCoils, common.FuncReadDiscreteInputs, common.FuncReadHoldingRegisters,
+		common.FuncReadInputRegisters, common.FuncWriteSingleCoil, common.FuncWriteSingleRegister,
+		common.FuncReadExceptionStatus, common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters,
+		common.FuncReadWriteMultipleRegisters, common.FuncReadDeviceIdentification,
+	} {
+		if !functions[fc] {
+			t.Errorf("Expected vectors for %s", fc)
+		}
+	}
+
+	// A protocol decoding registers the wrong way round is caught
+	broken := swappedProtocol{protocol.NewProtocolHandler()}
+	var failed int
+	for _, v := range vectors {
+		if err := CheckVector(broken, v); err != nil {
+			failed++
+			if v.Request.FunctionCode() != common.FuncReadHoldingRegisters || !strings.Contains(err.Error(), "decoded") {
+				t.Errorf("%s: unexpected error %v", v.Name, err)
+			}
+		}
+	}
+	if failed == 0 {
+		t.Error("Expected the swapped protocol to fail")
+	}
+}
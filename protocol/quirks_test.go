@@ -0,0 +1,87 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+func TestProtocolHandler_OneBasedAddressing(t *testing.T) {
+	quirks, err := common.ParseQuirks("JBUS")
+	if err != nil || quirks != common.QuirkOneBasedAddressing {
+		t.Fatalf("Expected the jbus profile to set one-based addressing, got %s, %v", quirks, err)
+	}
+	handler := NewProtocolHandler(WithLogger(logging.NewNoopLogger()), WithQuirks(quirks))
+
+	data, err := handler.GenerateReadHoldingRegistersRequest(0, 2)
+	if err != nil {
+		t.Fatalf("GenerateReadHoldingRegistersRequest failed: %v", err)
+	}
+	if got := binary.BigEndian.Uint16(data[0:2]); got != 1 {
+		t.Errorf("Expected wire address 1 for register 0, got %d", got)
+	}
+
+	data, err = handler.GenerateReadWriteMultipleRegistersRequest(9, 1, 19, []common.RegisterValue{1})
+	if err != nil {
+		t.Fatalf("GenerateReadWriteMultipleRegistersRequest failed: %v", err)
+	}
+	if read, write := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[4:6]); read != 10 || write != 20 {
+		t.Errorf("Expected wire addresses 10 and 20, got %d and %d", read, write)
+	}
+
+	// Echoed addresses are mapped back
+	address, _, err := handler.ParseWriteSingleRegisterResponse([]byte{0x00, 0x0B, 0x00, 0x01})
+	if err != nil || address != 10 {
+		t.Errorf("Expected echoed wire address 11 to be register 10, got %d, %v", address, err)
+	}
+
+	if _, err := handler.GenerateWriteSingleCoilRequest(0xFFFF, true); !errors.Is(err, common.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress for the last address, got %v", err)
+	}
+
+	// The quirks survive a logger change
+	if relogged := handler.WithLogger(logging.NewNoopLogger()).(*ProtocolHandler); relogged.Quirks() != quirks {
+		t.Errorf("Expected WithLogger to keep the quirks, got %s", relogged.Quirks())
+	}
+}
+
+func TestProtocolHandler_LenientByteCount(t *testing.T) {
+	strict := NewProtocolHandler(WithLogger(logging.NewNoopLogger()))
+	lenient := NewProtocolHandler(WithLogger(logging.NewNoopLogger()), WithQuirks(common.QuirkLenientByteCount))
+
+	// Byte count 2 for two registers, followed by four data bytes
+	response := []byte{0x02, 0x00, 0x01, 0x00, 0x02}
+	if _, err := strict.ParseReadHoldingRegistersResponse(response, 2); err == nil {
+		t.Error("Expected the strict handler to reject a wrong byte count")
+	}
+	values, err := lenient.ParseReadHoldingRegistersResponse(response, 2)
+	if err != nil || len(values) != 2 || values[1] != 2 {
+		t.Errorf("Expected [1 2], got %v, %v", values, err)
+	}
+
+	coils, err := lenient.ParseReadCoilsResponse([]byte{0x00, 0x05}, 3)
+	if err != nil || !coils[0] || coils[1] || !coils[2] {
+		t.Errorf("Expected [true false true], got %v, %v", coils, err)
+	}
+
+	// Missing data is still an error
+	if _, err := lenient.ParseReadHoldingRegistersResponse([]byte{0x04, 0x00, 0x01}, 2); err == nil {
+		t.Error("Expected short data to be rejected")
+	}
+}
+
+func TestParseQuirks(t *testing.T) {
+	quirks, err := common.ParseQuirks("lenient-byte-count", " input-registers-as-holding")
+	if err != nil {
+		t.Fatalf("ParseQuirks failed: %v", err)
+	}
+	if quirks.String() != "input-registers-as-holding,lenient-byte-count" {
+		t.Errorf("Unexpected quirks %s", quirks)
+	}
+	if _, err := common.ParseQuirks("off-by-two"); err == nil {
+		t.Error("Expected an unknown quirk to be rejected")
+	}
+}
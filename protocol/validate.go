@@ -0,0 +1,181 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Validate checks that a request PDU of a public function code is well
+// formed before any of its fields are trusted: the data length, the byte
+// count fields and the quantity limits of the specification. Values that
+// depend on the server's data, such as addresses, are not checked, nor are
+// the requests of function codes the specification leaves open. The error
+// matches common.ErrMalformedRequest; servers answer it with exception 0x03
+// (Illegal Data Value).
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (MODBUS Function Codes)
+func Validate(pdu *common.PDU) error {
+	if pdu == nil {
+		return fmt.Errorf("%w: no PDU", common.ErrMalformedRequest)
+	}
+	if 1+len(pdu.Data) > common.MaxPDULength {
+		return malformed(pdu, "%d bytes exceed the PDU", 1+len(pdu.Data))
+	}
+
+	data := pdu.Data
+	switch pdu.FunctionCode {
+	case common.FuncReadCoils, common.FuncReadDiscreteInputs:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.1 and 6.2
+		return validateRead(pdu, common.MaxCoilCount)
+
+	case common.FuncReadHoldingRegisters, common.FuncReadInputRegisters:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.3 and 6.4
+		return validateRead(pdu, common.MaxRegisterCount)
+
+	case common.FuncWriteSingleCoil:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.5
+		if err := validateLength(pdu, 4); err != nil {
+			return err
+		}
+		if value := binary.BigEndian.Uint16(data[2:4]); value != common.CoilOnU16 && value != common.CoilOffU16 {
+			return malformed(pdu, "coil value 0x%04X", value)
+		}
+
+	case common.FuncWriteSingleRegister:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.6
+		return validateLength(pdu, 4)
+
+	case common.FuncReadExceptionStatus:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.7
+		return validateLength(pdu, 0)
+
+	case common.FuncWriteMultipleCoils:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.11
+		if err := validateByteCount(pdu, 4); err != nil {
+			return err
+		}
+		quantity := int(binary.BigEndian.Uint16(data[2:4]))
+		if err := validateQuantity(pdu, quantity, common.MaxWriteCoilCount); err != nil {
+			return err
+		}
+		if byteCount := int(data[4]); byteCount != (quantity+7)/8 {
+			return malformed(pdu, "byte count %d for %d coils", byteCount, quantity)
+		}
+
+	case common.FuncWriteMultipleRegisters:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12
+		if err := validateByteCount(pdu, 4); err != nil {
+			return err
+		}
+		quantity := int(binary.BigEndian.Uint16(data[2:4]))
+		if err := validateQuantity(pdu, quantity, common.MaxWriteRegisterCount); err != nil {
+			return err
+		}
+		if byteCount := int(data[4]); byteCount != quantity*2 {
+			return malformed(pdu, "byte count %d for %d registers", byteCount, quantity)
+		}
+
+	case common.FuncReadFileRecord:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+		if err := validateByteCount(pdu, 0); err != nil {
+			return err
+		}
+		if byteCount := data[0]; byteCount < 7 || byteCount > common.MaxFileRecordByteCount || byteCount%7 != 0 {
+			return malformed(pdu, "byte count %d", byteCount)
+		}
+
+	case common.FuncWriteFileRecord:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+		if err := validateByteCount(pdu, 0); err != nil {
+			return err
+		}
+		if byteCount := data[0]; byteCount < 9 || byteCount > common.MaxWriteFileRecordByteCount {
+			return malformed(pdu, "byte count %d", byteCount)
+		}
+		// Each sub-request is a 7 byte header and its record data
+		offset := 1
+		for offset+7 <= len(data) {
+			offset += 7 + int(binary.BigEndian.Uint16(data[offset+5:]))*2
+		}
+		if offset != len(data) {
+			return malformed(pdu, "sub-requests do not fill the %d data bytes", len(data)-1)
+		}
+
+	case common.FuncMaskWriteRegister:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16
+		return validateLength(pdu, 6)
+
+	case common.FuncReadWriteMultipleRegisters:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.17
+		if err := validateByteCount(pdu, 8); err != nil {
+			return err
+		}
+		if err := validateQuantity(pdu, int(binary.BigEndian.Uint16(data[2:4])), common.MaxReadWriteReadCount); err != nil {
+			return err
+		}
+		quantity := int(binary.BigEndian.Uint16(data[6:8]))
+		if err := validateQuantity(pdu, quantity, common.MaxReadWriteWriteCount); err != nil {
+			return err
+		}
+		if byteCount := int(data[8]); byteCount != quantity*2 {
+			return malformed(pdu, "byte count %d for %d registers", byteCount, quantity)
+		}
+
+	case common.FuncReadFIFOQueue:
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+		return validateLength(pdu, 2)
+
+	case common.FuncReadDeviceIdentification:
+		// Other MEI types define their own data
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.19 and 6.21
+		if len(data) == 0 {
+			return malformed(pdu, "no MEI type")
+		}
+		if common.MEIType(data[0]) == common.MEIReadDeviceID {
+			return validateLength(pdu, 3)
+		}
+	}
+	return nil
+}
+
+// malformed returns an error matching common.ErrMalformedRequest
+func malformed(pdu *common.PDU, format string, args ...any) error {
+	return fmt.Errorf("%w: function %s: %s", common.ErrMalformedRequest, pdu.FunctionCode, fmt.Sprintf(format, args...))
+}
+
+// validateLength checks that the request data is exactly length bytes
+func validateLength(pdu *common.PDU, length int) error {
+	if len(pdu.Data) != length {
+		return malformed(pdu, "expected %d data bytes, got %d", length, len(pdu.Data))
+	}
+	return nil
+}
+
+// validateRead checks a read request of an address and a quantity
+func validateRead(pdu *common.PDU, maxQuantity int) error {
+	if err := validateLength(pdu, 4); err != nil {
+		return err
+	}
+	return validateQuantity(pdu, int(binary.BigEndian.Uint16(pdu.Data[2:4])), maxQuantity)
+}
+
+// validateQuantity checks that quantity is between 1 and maxQuantity
+func validateQuantity(pdu *common.PDU, quantity, maxQuantity int) error {
+	if quantity == 0 || quantity > maxQuantity {
+		return malformed(pdu, "quantity %d outside 1 to %d", quantity, maxQuantity)
+	}
+	return nil
+}
+
+// validateByteCount checks that the byte count field at offset matches the
+// number of bytes following it
+func validateByteCount(pdu *common.PDU, offset int) error {
+	if len(pdu.Data) <= offset {
+		return malformed(pdu, "expected a byte count at offset %d, got %d data bytes", offset, len(pdu.Data))
+	}
+	if byteCount, actual := int(pdu.Data[offset]), len(pdu.Data)-offset-1; byteCount != actual {
+		return malformed(pdu, "byte count %d with %d data bytes", byteCount, actual)
+	}
+	return nil
+}
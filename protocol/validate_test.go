@@ -0,0 +1,90 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+func TestValidate_GeneratedRequests(t *testing.T) {
+	handler := NewProtocolHandler(WithLogger(logging.NewNoopLogger()))
+	generate := func(data []byte, err error) []byte {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Failed to generate request: %v", err)
+		}
+		return data
+	}
+
+	requests := []common.PDU{
+		{FunctionCode: common.FuncReadCoils, Data: generate(handler.GenerateReadCoilsRequest(0, common.MaxCoilCount))},
+		{FunctionCode: common.FuncReadDiscreteInputs, Data: generate(handler.GenerateReadDiscreteInputsRequest(10, 1))},
+		{FunctionCode: common.FuncReadHoldingRegisters, Data: generate(handler.GenerateReadHoldingRegistersRequest(0, common.MaxRegisterCount))},
+		{FunctionCode: common.FuncReadInputRegisters, Data: generate(handler.GenerateReadInputRegistersRequest(0, 1))},
+		{FunctionCode: common.FuncWriteSingleCoil, Data: generate(handler.GenerateWriteSingleCoilRequest(5, true))},
+		{FunctionCode: common.FuncWriteSingleRegister, Data: generate(handler.GenerateWriteSingleRegisterRequest(5, 0x1234))},
+		{FunctionCode: common.FuncReadExceptionStatus, Data: generate(handler.GenerateReadExceptionStatusRequest())},
+		{FunctionCode: common.FuncWriteMultipleCoils, Data: generate(handler.GenerateWriteMultipleCoilsRequest(0, make([]common.CoilValue, 9)))},
+		{FunctionCode: common.FuncWriteMultipleRegisters, Data: generate(handler.GenerateWriteMultipleRegistersRequest(0, make([]common.RegisterValue, common.MaxWriteRegisterCount)))},
+		{FunctionCode: common.FuncReadFileRecord, Data: generate(handler.GenerateReadFileRecordRequest([]common.FileRecordRequest{
+			{FileNumber: 4, RecordNumber: 1, Length: 2},
+			{FileNumber: 3, RecordNumber: 9, Length: 2},
+		}))},
+		{FunctionCode: common.FuncWriteFileRecord, Data: generate(handler.GenerateWriteFileRecordRequest([]common.FileRecord{
+			{FileNumber: 4, RecordNumber: 7, Values: []common.RegisterValue{0x06AF, 0x04BE}},
+			{FileNumber: 5, RecordNumber: 1, Values: []common.RegisterValue{0x0001}},
+		}))},
+		{FunctionCode: common.FuncMaskWriteRegister, Data: generate(handler.GenerateMaskWriteRegisterRequest(4, 0x00F2, 0x0025))},
+		{FunctionCode: common.FuncReadWriteMultipleRegisters, Data: generate(handler.GenerateReadWriteMultipleRegistersRequest(0, 6, 14, make([]common.RegisterValue, 3)))},
+		{FunctionCode: common.FuncReadFIFOQueue, Data: generate(handler.GenerateReadFIFOQueueRequest(0x04DE))},
+		{FunctionCode: common.FuncReadDeviceIdentification, Data: generate(handler.GenerateReadDeviceIdentificationRequest(common.ReadDeviceIDBasic, 0))},
+		{FunctionCode: common.FuncWatchRegisters, Data: []byte{0x01}},
+	}
+
+	for _, pdu := range requests {
+		if err := Validate(&pdu); err != nil {
+			t.Errorf("Expected the generated %s request to be valid, got %v", pdu.FunctionCode, err)
+		}
+	}
+}
+
+func TestValidate_MalformedRequests(t *testing.T) {
+	cases := []struct {
+		name string
+		pdu  common.PDU
+	}{
+		{"short read", common.PDU{FunctionCode: common.FuncReadCoils, Data: []byte{0x00, 0x00, 0x01}}},
+		{"zero quantity", common.PDU{FunctionCode: common.FuncReadHoldingRegisters, Data: []byte{0x00, 0x00, 0x00, 0x00}}},
+		{"too many coils", common.PDU{FunctionCode: common.FuncReadDiscreteInputs, Data: []byte{0x00, 0x00, 0x07, 0xD1}}},
+		{"too many registers", common.PDU{FunctionCode: common.FuncReadInputRegisters, Data: []byte{0x00, 0x00, 0x00, 0x7E}}},
+		{"coil value", common.PDU{FunctionCode: common.FuncWriteSingleCoil, Data: []byte{0x00, 0x01, 0x12, 0x34}}},
+		{"long single register", common.PDU{FunctionCode: common.FuncWriteSingleRegister, Data: []byte{0x00, 0x01, 0x00, 0x02, 0x03}}},
+		{"exception status with data", common.PDU{FunctionCode: common.FuncReadExceptionStatus, Data: []byte{0x00}}},
+		{"coils without byte count", common.PDU{FunctionCode: common.FuncWriteMultipleCoils, Data: []byte{0x00, 0x00, 0x00, 0x08}}},
+		{"coils byte count past data", common.PDU{FunctionCode: common.FuncWriteMultipleCoils, Data: []byte{0x00, 0x00, 0x00, 0x08, 0xFF}}},
+		{"coils byte count for quantity", common.PDU{FunctionCode: common.FuncWriteMultipleCoils, Data: []byte{0x00, 0x00, 0x00, 0x08, 0x02, 0xFF, 0x00}}},
+		{"registers byte count past data", common.PDU{FunctionCode: common.FuncWriteMultipleRegisters, Data: []byte{0x00, 0x00, 0x00, 0x01, 0xF0, 0x00, 0x01}}},
+		{"registers byte count for quantity", common.PDU{FunctionCode: common.FuncWriteMultipleRegisters, Data: []byte{0x00, 0x00, 0x00, 0x02, 0x02, 0x00, 0x01}}},
+		{"too many written registers", common.PDU{FunctionCode: common.FuncWriteMultipleRegisters, Data: []byte{0x00, 0x00, 0x00, 0x7C, 0x00}}},
+		{"file record byte count", common.PDU{FunctionCode: common.FuncReadFileRecord, Data: []byte{0x08, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02}}},
+		{"file record sub-request length", common.PDU{FunctionCode: common.FuncWriteFileRecord, Data: []byte{0x09, 0x06, 0x00, 0x04, 0x00, 0x07, 0x7F, 0xFF, 0x06, 0xAF}}},
+		{"short mask write", common.PDU{FunctionCode: common.FuncMaskWriteRegister, Data: []byte{0x00, 0x04, 0x00, 0xF2}}},
+		{"read/write byte count", common.PDU{FunctionCode: common.FuncReadWriteMultipleRegisters, Data: []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x04, 0x00, 0x01, 0x00, 0x02}}},
+		{"read/write read quantity", common.PDU{FunctionCode: common.FuncReadWriteMultipleRegisters, Data: []byte{0x00, 0x00, 0x00, 0x7E, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01}}},
+		{"long FIFO", common.PDU{FunctionCode: common.FuncReadFIFOQueue, Data: []byte{0x04, 0xDE, 0x00}}},
+		{"device identification without MEI type", common.PDU{FunctionCode: common.FuncReadDeviceIdentification}},
+		{"short device identification", common.PDU{FunctionCode: common.FuncReadDeviceIdentification, Data: []byte{byte(common.MEIReadDeviceID), 0x01}}},
+		{"oversized PDU", common.PDU{FunctionCode: common.FuncWatchRegisters, Data: make([]byte, common.MaxPDULength)}},
+	}
+
+	for _, tc := range cases {
+		if err := Validate(&tc.pdu); !errors.Is(err, common.ErrMalformedRequest) {
+			t.Errorf("%s: expected ErrMalformedRequest, got %v", tc.name, err)
+		}
+	}
+	if err := Validate(nil); !errors.Is(err, common.ErrMalformedRequest) {
+		t.Errorf("Expected ErrMalformedRequest without PDU, got %v", err)
+	}
+}
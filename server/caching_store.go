@@ -0,0 +1,407 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// cacheKey identifies a cached read range
+type cacheKey struct {
+	table    common.Table
+	address  common.Address
+	quantity common.Quantity
+}
+
+// overlaps reports whether the key covers any address of the range
+func (k cacheKey) overlaps(table common.Table, address common.Address, quantity int) bool {
+	if k.table&table == 0 {
+		return false
+	}
+	start, end := int(k.address), int(k.address)+int(k.quantity)
+	return int(address) < end && int(address)+quantity > start
+}
+
+// cacheEntry is a cached read result
+type cacheEntry struct {
+	values  any
+	expires time.Time
+}
+
+// cacheFlight is a read in progress that concurrent identical reads wait on
+type cacheFlight struct {
+	done   chan struct{}
+	values any
+	err    error
+
+	// Set when the range was invalidated while the read was in flight
+	invalidated bool
+}
+
+// CachingDataStore wraps a DataStore whose reads are expensive (for example
+// a store that queries hardware) and caches read results per range for a
+// fixed TTL. Identical reads arriving while one is in flight wait for it
+// instead of reaching the backend, so bursts of identical polls from several
+// masters result in a single backend read.
+//
+// Entries are keyed by table, address and quantity, and at most
+// DefaultCacheEntries of them are kept, see WithCacheEntries. Writes go straight to
+// the wrapped store and invalidate every cached range they overlap; changes
+// made behind the store's back can be published with Invalidate or
+// InvalidateAll. Errors are never cached.
+type CachingDataStore struct {
+	store common.DataStore
+	ttl   time.Duration
+	now   func() time.Time
+
+	// Read-your-writes hold time, see WithReadYourWrites; 0 disables it
+	hold time.Duration
+
+	// Maximum number of cached ranges, see WithCacheEntries
+	maxEntries int
+
+	mu        sync.Mutex
+	entries   map[cacheKey]cacheEntry
+	nextSweep time.Time
+	inflight  map[cacheKey]*cacheFlight
+	written   map[writtenKey]writtenValue
+}
+
+// DefaultCacheEntries is the default maximum number of ranges a
+// CachingDataStore keeps
+const DefaultCacheEntries = 1024
+
+// CachingOption is a function that configures a CachingDataStore
+type CachingOption func(*CachingDataStore)
+
+// WithCacheEntries sets the maximum number of ranges kept in the cache.
+// Expired ranges are dropped first; when the cache is still full, caching a
+// new range drops an arbitrary one. This bounds the memory a master reading
+// many distinct ranges can make the cache hold.
+func WithCacheEntries(n int) CachingOption {
+	return func(c *CachingDataStore) {
+		if n > 0 {
+			c.maxEntries = n
+		}
+	}
+}
+
+// WithReadYourWrites serves values written through the cache until a backend
+// read confirms them, for at most hold. This suits backends that apply
+// writes with a delay, such as a device polled over a slow bus: without it a
+// read right after a write may return the old value. Values served this way
+// have QualityUnconfirmed until confirmed.
+func WithReadYourWrites(hold time.Duration) CachingOption {
+	return func(c *CachingDataStore) {
+		c.hold = hold
+	}
+}
+
+// NewCachingDataStore creates a caching decorator around store. Read results
+// are served from the cache for ttl after they were fetched.
+func NewCachingDataStore(store common.DataStore, ttl time.Duration, options ...CachingOption) *CachingDataStore {
+	c := &CachingDataStore{
+		store:      store,
+		ttl:        ttl,
+		now:        time.Now,
+		maxEntries: DefaultCacheEntries,
+		entries:    make(map[cacheKey]cacheEntry),
+		inflight:   make(map[cacheKey]*cacheFlight),
+		written:    make(map[writtenKey]writtenValue),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Invalidate drops every cached range of the given tables that overlaps
+// [address, address+quantity)
+func (c *CachingDataStore) Invalidate(tables common.Table, address common.Address, quantity common.Quantity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked(tables, address, int(quantity))
+}
+
+// InvalidateAll drops every cached range
+func (c *CachingDataStore) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[cacheKey]cacheEntry)
+	for _, flight := range c.inflight {
+		flight.invalidated = true
+	}
+}
+
+// invalidateLocked drops overlapping entries; c.mu must be held
+func (c *CachingDataStore) invalidateLocked(tables common.Table, address common.Address, quantity int) {
+	for key := range c.entries {
+		if key.overlaps(tables, address, quantity) {
+			delete(c.entries, key)
+		}
+	}
+	for key, flight := range c.inflight {
+		if key.overlaps(tables, address, quantity) {
+			flight.invalidated = true
+		}
+	}
+}
+
+// storeLocked caches entry under key. Expired entries are swept at most once
+// per TTL, and an arbitrary entry is dropped if the cache is still full.
+// c.mu must be held.
+func (c *CachingDataStore) storeLocked(key cacheKey, entry cacheEntry) {
+	now := c.now()
+	if !now.Before(c.nextSweep) {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(c.ttl)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = entry
+}
+
+// cachedRead serves a read from the cache, joins an identical read in
+// flight, or reads from the backend and caches the result
+func cachedRead[T comparable](c *CachingDataStore, table common.Table, address common.Address, quantity common.Quantity,
+	read func() ([]T, error)) ([]T, error) {
+	key := cacheKey{table: table, address: address, quantity: quantity}
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		if c.now().Before(entry.expires) {
+			values := overlayWritten(c, table, address, copyValues(entry.values.([]T)), false)
+			c.mu.Unlock()
+			return values, nil
+		}
+		delete(c.entries, key)
+	}
+	if flight, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		<-flight.done
+		if flight.err != nil {
+			return nil, flight.err
+		}
+		c.mu.Lock()
+		values := overlayWritten(c, table, address, copyValues(flight.values.([]T)), false)
+		c.mu.Unlock()
+		return values, nil
+	}
+	flight := &cacheFlight{done: make(chan struct{})}
+	c.inflight[key] = flight
+	c.mu.Unlock()
+
+	values, err := read()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	// A write may have invalidated the range while the read was in flight,
+	// in which case the result may already be stale
+	if err == nil && !flight.invalidated {
+		c.storeLocked(key, cacheEntry{values: values, expires: c.now().Add(c.ttl)})
+	}
+	var result []T
+	if err == nil {
+		// A fresh backend read can confirm written values, unless a write
+		// raced with it
+		result = overlayWritten(c, table, address, copyValues(values), !flight.invalidated)
+	}
+	c.mu.Unlock()
+
+	flight.values, flight.err = values, err
+	close(flight.done)
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// copyValues returns a copy so callers cannot modify cached slices
+func copyValues[T any](values []T) []T {
+	out := make([]T, len(values))
+	copy(out, values)
+	return out
+}
+
+// ReadCoils reads coil values through the cache
+func (c *CachingDataStore) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	return cachedRead(c, common.TableCoils, address, quantity, func() ([]common.CoilValue, error) {
+		return c.store.ReadCoils(ctx, address, quantity)
+	})
+}
+
+// ReadDiscreteInputs reads discrete input values through the cache
+func (c *CachingDataStore) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	return cachedRead(c, common.TableDiscreteInputs, address, quantity, func() ([]common.DiscreteInputValue, error) {
+		return c.store.ReadDiscreteInputs(ctx, address, quantity)
+	})
+}
+
+// ReadHoldingRegisters reads holding register values through the cache
+func (c *CachingDataStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	return cachedRead(c, common.TableHoldingRegisters, address, quantity, func() ([]common.RegisterValue, error) {
+		return c.store.ReadHoldingRegisters(ctx, address, quantity)
+	})
+}
+
+// ReadInputRegisters reads input register values through the cache
+func (c *CachingDataStore) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	return cachedRead(c, common.TableInputRegisters, address, quantity, func() ([]common.InputRegisterValue, error) {
+		return c.store.ReadInputRegisters(ctx, address, quantity)
+	})
+}
+
+// WriteSingleCoil writes to the wrapped store and invalidates overlapping
+// ranges, both before the write and after it so no read started before the
+// write completes is cached
+func (c *CachingDataStore) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	c.Invalidate(common.TableCoils, address, 1)
+	defer c.Invalidate(common.TableCoils, address, 1)
+	if err := c.store.WriteSingleCoil(ctx, address, value); err != nil {
+		return err
+	}
+	recordWritten(c, common.TableCoils, address, []common.CoilValue{value})
+	return nil
+}
+
+// WriteSingleRegister writes to the wrapped store and invalidates overlapping ranges
+func (c *CachingDataStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	c.Invalidate(common.TableHoldingRegisters, address, 1)
+	defer c.Invalidate(common.TableHoldingRegisters, address, 1)
+	if err := c.store.WriteSingleRegister(ctx, address, value); err != nil {
+		return err
+	}
+	recordWritten(c, common.TableHoldingRegisters, address, []common.RegisterValue{value})
+	return nil
+}
+
+// WriteMultipleCoils writes to the wrapped store and invalidates overlapping ranges
+func (c *CachingDataStore) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	c.Invalidate(common.TableCoils, address, common.Quantity(len(values)))
+	defer c.Invalidate(common.TableCoils, address, common.Quantity(len(values)))
+	if err := c.store.WriteMultipleCoils(ctx, address, values); err != nil {
+		return err
+	}
+	recordWritten(c, common.TableCoils, address, values)
+	return nil
+}
+
+// WriteMultipleRegisters writes to the wrapped store and invalidates overlapping ranges
+func (c *CachingDataStore) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	c.Invalidate(common.TableHoldingRegisters, address, common.Quantity(len(values)))
+	defer c.Invalidate(common.TableHoldingRegisters, address, common.Quantity(len(values)))
+	if err := c.store.WriteMultipleRegisters(ctx, address, values); err != nil {
+		return err
+	}
+	recordWritten(c, common.TableHoldingRegisters, address, values)
+	return nil
+}
+
+// wrapped returns the wrapped store
+func (c *CachingDataStore) wrapped() common.DataStore {
+	return c.store
+}
+
+// ValidateRange delegates to the wrapped store if it validates ranges
+func (c *CachingDataStore) ValidateRange(ctx context.Context, table common.Table, address common.Address, quantity common.Quantity) error {
+	if validator, ok := c.store.(common.RangeValidator); ok {
+		return validator.ValidateRange(ctx, table, address, quantity)
+	}
+	return nil
+}
+
+// Atomically calls fn with a view of a transaction of the wrapped store.
+// Reads through the view bypass the cache so they see the transaction's own
+// writes; the written ranges are invalidated when the transaction is over.
+// Writes are only rolled back if the wrapped store implements
+// common.TransactionalDataStore.
+func (c *CachingDataStore) Atomically(ctx context.Context, fn func(tx common.DataStore) error) error {
+	_, transactional := c.store.(common.TransactionalDataStore)
+	view := &cachingTx{cache: c}
+	err := atomicallyOn(ctx, c.store, func(tx common.DataStore) error {
+		view.tx = tx
+		return fn(view)
+	})
+
+	for _, key := range view.written {
+		c.Invalidate(key.table, key.address, key.quantity)
+	}
+	if err == nil || !transactional {
+		for _, record := range view.records {
+			record()
+		}
+	}
+	return err
+}
+
+// cachingTx is the view passed to Atomically callbacks. It remembers the
+// ranges it wrote, and the values for read-your-writes, until the
+// transaction is over.
+type cachingTx struct {
+	cache   *CachingDataStore
+	tx      common.DataStore
+	written []cacheKey
+	records []func()
+}
+
+// write invalidates a range, writes it with write and remembers it
+func (t *cachingTx) write(table common.Table, address common.Address, quantity int, write func() error, record func()) error {
+	t.cache.Invalidate(table, address, common.Quantity(quantity))
+	t.written = append(t.written, cacheKey{table: table, address: address, quantity: common.Quantity(quantity)})
+	if err := write(); err != nil {
+		return err
+	}
+	t.records = append(t.records, record)
+	return nil
+}
+
+func (t *cachingTx) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	return t.tx.ReadCoils(ctx, address, quantity)
+}
+
+func (t *cachingTx) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	return t.tx.ReadDiscreteInputs(ctx, address, quantity)
+}
+
+func (t *cachingTx) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	return t.tx.ReadHoldingRegisters(ctx, address, quantity)
+}
+
+func (t *cachingTx) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	return t.tx.ReadInputRegisters(ctx, address, quantity)
+}
+
+func (t *cachingTx) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	return t.write(common.TableCoils, address, 1,
+		func() error { return t.tx.WriteSingleCoil(ctx, address, value) },
+		func() { recordWritten(t.cache, common.TableCoils, address, []common.CoilValue{value}) })
+}
+
+func (t *cachingTx) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	return t.write(common.TableHoldingRegisters, address, 1,
+		func() error { return t.tx.WriteSingleRegister(ctx, address, value) },
+		func() { recordWritten(t.cache, common.TableHoldingRegisters, address, []common.RegisterValue{value}) })
+}
+
+func (t *cachingTx) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	return t.write(common.TableCoils, address, len(values),
+		func() error { return t.tx.WriteMultipleCoils(ctx, address, values) },
+		func() { recordWritten(t.cache, common.TableCoils, address, values) })
+}
+
+func (t *cachingTx) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	return t.write(common.TableHoldingRegisters, address, len(values),
+		func() error { return t.tx.WriteMultipleRegisters(ctx, address, values) },
+		func() { recordWritten(t.cache, common.TableHoldingRegisters, address, values) })
+}
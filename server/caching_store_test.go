@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// countingStore counts holding register reads and can hold them until released
+type countingStore struct {
+	*MemoryStore
+	reads   atomic.Int32
+	release chan struct{}
+}
+
+func (s *countingStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	s.reads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return s.MemoryStore.ReadHoldingRegisters(ctx, address, quantity)
+}
+
+func TestCachingDataStore_TTLAndInvalidation(t *testing.T) {
+	backend := &countingStore{MemoryStore: NewMemoryStore()}
+	backend.SetHoldingRegister(10, 0x1111)
+
+	now := time.Unix(1000, 0)
+	cache := NewCachingDataStore(backend, time.Second)
+	cache.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	read := func() common.RegisterValue {
+		t.Helper()
+		values, err := cache.ReadHoldingRegisters(ctx, 10, 2)
+		if err != nil {
+			t.Fatalf("ReadHoldingRegisters failed: %v", err)
+		}
+		return values[0]
+	}
+
+	read()
+	read()
+	if n := backend.reads.Load(); n != 1 {
+		t.Fatalf("Expected 1 backend read within TTL, got %d", n)
+	}
+
+	// A change behind the store's back is served stale until invalidated
+	backend.SetHoldingRegister(10, 0x2222)
+	if v := read(); v != 0x1111 {
+		t.Errorf("Expected cached 0x1111, got 0x%04X", v)
+	}
+	cache.Invalidate(common.TableHoldingRegisters, 11, 1)
+	if v := read(); v != 0x2222 {
+		t.Errorf("Expected 0x2222 after invalidating an overlapping address, got 0x%04X", v)
+	}
+
+	// Invalidating another table or range keeps the entry
+	cache.Invalidate(common.TableInputRegisters, 10, 2)
+	cache.Invalidate(common.TableHoldingRegisters, 12, 5)
+	read()
+	if n := backend.reads.Load(); n != 2 {
+		t.Errorf("Expected 2 backend reads, got %d", n)
+	}
+
+	// Writes through the cache invalidate
+	if err := cache.WriteSingleRegister(ctx, 10, 0x3333); err != nil {
+		t.Fatalf("WriteSingleRegister failed: %v", err)
+	}
+	if v := read(); v != 0x3333 {
+		t.Errorf("Expected 0x3333 after write, got 0x%04X", v)
+	}
+
+	// Entries expire after the TTL
+	now = now.Add(2 * time.Second)
+	read()
+	if n := backend.reads.Load(); n != 4 {
+		t.Errorf("Expected 4 backend reads after expiry, got %d", n)
+	}
+}
+
+func TestCachingDataStore_BoundedEntries(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cache := NewCachingDataStore(NewMemoryStore(), time.Second, WithCacheEntries(16))
+	cache.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for address := range 100 {
+		if _, err := cache.ReadHoldingRegisters(ctx, common.Address(address), 1); err != nil {
+			t.Fatalf("ReadHoldingRegisters failed: %v", err)
+		}
+	}
+	if n := len(cache.entries); n != 16 {
+		t.Errorf("Expected the cache capped at 16 entries, got %d", n)
+	}
+
+	// Expired entries are dropped on lookup and by the sweep
+	now = now.Add(2 * time.Second)
+	for key := range cache.entries {
+		if _, err := cache.ReadHoldingRegisters(ctx, key.address, key.quantity); err != nil {
+			t.Fatalf("ReadHoldingRegisters failed: %v", err)
+		}
+		break
+	}
+	if n := len(cache.entries); n != 1 {
+		t.Errorf("Expected only the fresh entry after expiry, got %d", n)
+	}
+}
+
+func TestCachingDataStore_CoalescesConcurrentReads(t *testing.T) {
+	backend := &countingStore{MemoryStore: NewMemoryStore(), release: make(chan struct{})}
+	backend.SetHoldingRegister(0, 0xBEEF)
+	cache := NewCachingDataStore(backend, time.Minute)
+
+	const pollers = 8
+	var wg sync.WaitGroup
+	results := make([]common.RegisterValue, pollers)
+	for i := 0; i < pollers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values, err := cache.ReadHoldingRegisters(context.Background(), 0, 1)
+			if err != nil {
+				t.Errorf("ReadHoldingRegisters failed: %v", err)
+				return
+			}
+			results[i] = values[0]
+		}(i)
+	}
+
+	// Wait for the first read to reach the backend, then let it finish
+	for backend.reads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+
+	if n := backend.reads.Load(); n != 1 {
+		t.Errorf("Expected 1 backend read for %d concurrent polls, got %d", pollers, n)
+	}
+	for i, v := range results {
+		if v != 0xBEEF {
+			t.Errorf("Poller %d: expected 0xBEEF, got 0x%04X", i, v)
+		}
+	}
+}
+
+// laggyStore accepts holding register writes but only applies them on apply,
+// like a device that updates its registers on its next scan
+type laggyStore struct {
+	*MemoryStore
+	mu      sync.Mutex
+	pending map[common.Address]common.RegisterValue
+}
+
+func (s *laggyStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[address] = value
+	return nil
+}
+
+func (s *laggyStore) apply() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for address, value := range s.pending {
+		s.SetHoldingRegister(address, value)
+	}
+	s.pending = make(map[common.Address]common.RegisterValue)
+}
+
+func TestCachingDataStore_ReadYourWrites(t *testing.T) {
+	backend := &laggyStore{MemoryStore: NewMemoryStore(), pending: make(map[common.Address]common.RegisterValue)}
+	backend.SetHoldingRegister(10, 0x1111)
+
+	now := time.Unix(1000, 0)
+	cache := NewCachingDataStore(backend, 0, WithReadYourWrites(5*time.Second))
+	cache.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	read := func() common.RegisterValue {
+		t.Helper()
+		values, err := cache.ReadHoldingRegisters(ctx, 9, 3)
+		if err != nil {
+			t.Fatalf("ReadHoldingRegisters failed: %v", err)
+		}
+		return values[1]
+	}
+
+	// The written value is served before the backend applies it
+	if err := cache.WriteSingleRegister(ctx, 10, 0x2222); err != nil {
+		t.Fatalf("WriteSingleRegister failed: %v", err)
+	}
+	if v := read(); v != 0x2222 {
+		t.Errorf("Expected the written 0x2222, got 0x%04X", v)
+	}
+	if q := cache.Quality(common.TableHoldingRegisters, 10); q != QualityUnconfirmed {
+		t.Errorf("Expected an unconfirmed value, got %s", q)
+	}
+	if q := cache.Quality(common.TableHoldingRegisters, 9); q != QualityGood {
+		t.Errorf("Expected other addresses to be good, got %s", q)
+	}
+
+	// The next backend read returning the value confirms it
+	backend.apply()
+	if v := read(); v != 0x2222 {
+		t.Errorf("Expected 0x2222 from the backend, got 0x%04X", v)
+	}
+	if q := cache.Quality(common.TableHoldingRegisters, 10); q != QualityGood {
+		t.Errorf("Expected the value to be confirmed, got %s", q)
+	}
+
+	// A write the backend never applies is served only for the hold time
+	cache.WriteSingleRegister(ctx, 10, 0x3333)
+	if v := read(); v != 0x3333 {
+		t.Errorf("Expected the written 0x3333, got 0x%04X", v)
+	}
+	now = now.Add(6 * time.Second)
+	if v := read(); v != 0x2222 {
+		t.Errorf("Expected the backend's 0x2222 after the hold time, got 0x%04X", v)
+	}
+	if q := cache.Quality(common.TableHoldingRegisters, 10); q != QualityGood {
+		t.Errorf("Expected good quality after the hold time, got %s", q)
+	}
+
+	// Without the option reads reflect the backend
+	plain := NewCachingDataStore(backend, 0)
+	plain.WriteSingleRegister(ctx, 10, 0x4444)
+	if values, _ := plain.ReadHoldingRegisters(ctx, 10, 1); values[0] != 0x2222 {
+		t.Errorf("Expected the backend's 0x2222 without read-your-writes, got 0x%04X", values[0])
+	}
+}
+
+func TestCachingDataStore_AtomicallyRollsBack(t *testing.T) {
+	backing := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+	backing.SetHoldingRegister(0, 7)
+	cache := NewCachingDataStore(backing, time.Hour)
+	ctx := context.Background()
+	if _, err := cache.ReadHoldingRegisters(ctx, 0, 1); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	err := cache.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, 0, []common.RegisterValue{5, 6}); err != nil {
+			return err
+		}
+		// Reads of the view see the transaction's writes, not the cache
+		if values, _ := tx.ReadHoldingRegisters(ctx, 0, 1); values[0] != 5 {
+			t.Errorf("Expected 5 inside the transaction, got %v", values)
+		}
+		return tx.WriteMultipleRegisters(ctx, 99, []common.RegisterValue{1, 2})
+	})
+	if !errors.Is(err, common.ErrInvalidAddress) {
+		t.Fatalf("Expected ErrInvalidAddress, got %v", err)
+	}
+	if value, _ := backing.GetHoldingRegister(0); value != 7 {
+		t.Errorf("Expected register 0 restored to 7, got %d", value)
+	}
+	if values, _ := cache.ReadHoldingRegisters(ctx, 0, 1); values[0] != 7 {
+		t.Errorf("Expected 7 through the cache, got %v", values)
+	}
+
+	// A committed write invalidates the cached range
+	err = cache.Atomically(ctx, func(tx common.DataStore) error {
+		return tx.WriteSingleRegister(ctx, 0, 8)
+	})
+	if err != nil {
+		t.Fatalf("Atomically returned error: %v", err)
+	}
+	if values, _ := cache.ReadHoldingRegisters(ctx, 0, 1); values[0] != 8 {
+		t.Errorf("Expected 8 through the cache, got %v", values)
+	}
+}
+
+func TestCachingDataStore_ValidateRange(t *testing.T) {
+	cache := NewCachingDataStore(NewMemoryStore(WithMemoryStoreRange(common.TableCoils, 0, 9)), time.Second)
+	ctx := context.Background()
+	if err := cache.ValidateRange(ctx, common.TableCoils, 0, 10); err != nil {
+		t.Errorf("Expected the range to be valid, got %v", err)
+	}
+	if err := cache.ValidateRange(ctx, common.TableCoils, 5, 10); !errors.Is(err, common.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress, got %v", err)
+	}
+}
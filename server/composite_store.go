@@ -0,0 +1,429 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// compositeRoute maps an address range of one or more tables, optionally
+// restricted to a single unit ID, onto a child store
+type compositeRoute struct {
+	tables  common.Table
+	start   int
+	end     int // inclusive
+	unitID  common.UnitID
+	anyUnit bool
+	store   common.DataStore
+}
+
+// matches reports whether the route serves the address of the table for the unit
+func (r *compositeRoute) matches(table common.Table, address int, unitID common.UnitID, hasUnit bool) bool {
+	if r.tables&table == 0 || address < r.start || address > r.end {
+		return false
+	}
+	return r.anyUnit || (hasUnit && r.unitID == unitID)
+}
+
+// compositeSegment is a contiguous part of a request served by one store
+type compositeSegment struct {
+	route    int
+	store    common.DataStore
+	address  common.Address
+	quantity int
+	offset   int // offset of the segment within the request
+}
+
+// CompositeDataStore implements DataStore by mapping address ranges, and
+// optionally unit IDs, onto child stores. This lets a single server stitch
+// together several backends declaratively, for example:
+//
+//	store := NewCompositeDataStore(
+//		WithRoute(common.TableAll, 0, 999, memory),
+//		WithRoute(common.TableHoldingRegisters, 1000, 1999, callbacks),
+//		WithUnitRoute(2, common.TableInputRegisters, 0, 999, unit2Inputs),
+//		WithFallbackStore(persistent),
+//	)
+//
+// Routes are matched in the order they were added and the first match wins.
+// Unit routes only match requests addressed to their unit; the unit is read
+// from the request context (see UnitIDFromContext). Child stores receive the
+// original, absolute addresses.
+//
+// A request spanning several routes is split into one call per route and the
+// results are joined. Writes spanning routes are only atomic when made
+// through Atomically, as the server does for write requests.
+// Addresses not covered by any route are served by the fallback store, or
+// rejected with ErrInvalidAddress (exception 0x02) when there is none.
+type CompositeDataStore struct {
+	routes   []compositeRoute
+	fallback common.DataStore
+
+	// The transaction of an Atomically view, nil otherwise
+	tx *compositeTx
+}
+
+// CompositeOption is a function that configures a CompositeDataStore
+type CompositeOption func(*CompositeDataStore)
+
+// WithRoute maps the inclusive address range [start, end] of the given tables
+// onto store for all unit IDs
+func WithRoute(tables common.Table, start, end common.Address, store common.DataStore) CompositeOption {
+	return func(c *CompositeDataStore) {
+		c.routes = append(c.routes, compositeRoute{
+			tables:  tables,
+			start:   int(start),
+			end:     int(end),
+			anyUnit: true,
+			store:   store,
+		})
+	}
+}
+
+// WithUnitRoute maps the inclusive address range [start, end] of the given
+// tables onto store for requests addressed to unitID only
+func WithUnitRoute(unitID common.UnitID, tables common.Table, start, end common.Address, store common.DataStore) CompositeOption {
+	return func(c *CompositeDataStore) {
+		c.routes = append(c.routes, compositeRoute{
+			tables: tables,
+			start:  int(start),
+			end:    int(end),
+			unitID: unitID,
+			store:  store,
+		})
+	}
+}
+
+// WithFallbackStore sets the store serving addresses not covered by any route
+func WithFallbackStore(store common.DataStore) CompositeOption {
+	return func(c *CompositeDataStore) {
+		c.fallback = store
+	}
+}
+
+// NewCompositeDataStore creates a new composite data store
+func NewCompositeDataStore(options ...CompositeOption) *CompositeDataStore {
+	c := &CompositeDataStore{}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// fallbackRoute is the route index resolve returns for the fallback store
+const fallbackRoute = -1
+
+// resolve returns the index of the route serving one address, or
+// fallbackRoute if no route does
+func (c *CompositeDataStore) resolve(table common.Table, address int, unitID common.UnitID, hasUnit bool) int {
+	for i := range c.routes {
+		if c.routes[i].matches(table, address, unitID, hasUnit) {
+			return i
+		}
+	}
+	return fallbackRoute
+}
+
+// segments splits a request into contiguous parts served by a single store
+func (c *CompositeDataStore) segments(ctx context.Context, table common.Table, address common.Address, quantity int) ([]compositeSegment, error) {
+	unitID, hasUnit := UnitIDFromContext(ctx)
+
+	var segments []compositeSegment
+	for i := 0; i < quantity; i++ {
+		route := c.resolve(table, int(address)+i, unitID, hasUnit)
+		if n := len(segments); n > 0 && segments[n-1].route == route {
+			segments[n-1].quantity++
+			continue
+		}
+
+		store := c.fallback
+		if route != fallbackRoute {
+			store = c.routes[route].store
+		}
+		if store == nil {
+			return nil, common.ErrInvalidAddress
+		}
+		if c.tx != nil {
+			var err error
+			if store, err = c.tx.use(store); err != nil {
+				return nil, err
+			}
+		}
+
+		segments = append(segments, compositeSegment{
+			route:    route,
+			store:    store,
+			address:  address + common.Address(i),
+			quantity: 1,
+			offset:   i,
+		})
+	}
+	return segments, nil
+}
+
+// compositeRead reads a range through the child stores and joins the results
+func compositeRead[T any](ctx context.Context, c *CompositeDataStore, table common.Table, address common.Address, quantity common.Quantity,
+	read func(common.DataStore, common.Address, common.Quantity) ([]T, error)) ([]T, error) {
+	segments, err := c.segments(ctx, table, address, int(quantity))
+	if err != nil {
+		return nil, err
+	}
+
+	// Let the child validate the quantity when it serves the whole request
+	if len(segments) == 1 {
+		return read(segments[0].store, address, quantity)
+	}
+
+	values := make([]T, 0, quantity)
+	for _, seg := range segments {
+		part, err := read(seg.store, seg.address, common.Quantity(seg.quantity))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, part...)
+	}
+	return values, nil
+}
+
+// compositeWrite writes a range through the child stores
+func compositeWrite[T any](ctx context.Context, c *CompositeDataStore, table common.Table, address common.Address, values []T,
+	write func(common.DataStore, common.Address, []T) error) error {
+	segments, err := c.segments(ctx, table, address, len(values))
+	if err != nil {
+		return err
+	}
+
+	if len(segments) == 1 {
+		return write(segments[0].store, address, values)
+	}
+
+	for _, seg := range segments {
+		if err := write(seg.store, seg.address, values[seg.offset:seg.offset+seg.quantity]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadCoils reads coil values from the child stores
+func (c *CompositeDataStore) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	if quantity == 0 || quantity > common.MaxCoilCount {
+		return nil, common.ErrInvalidQuantity
+	}
+	return compositeRead(ctx, c, common.TableCoils, address, quantity,
+		func(s common.DataStore, a common.Address, q common.Quantity) ([]common.CoilValue, error) {
+			return s.ReadCoils(ctx, a, q)
+		})
+}
+
+// ReadDiscreteInputs reads discrete input values from the child stores
+func (c *CompositeDataStore) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	if quantity == 0 || quantity > common.MaxCoilCount {
+		return nil, common.ErrInvalidQuantity
+	}
+	return compositeRead(ctx, c, common.TableDiscreteInputs, address, quantity,
+		func(s common.DataStore, a common.Address, q common.Quantity) ([]common.DiscreteInputValue, error) {
+			return s.ReadDiscreteInputs(ctx, a, q)
+		})
+}
+
+// ReadHoldingRegisters reads holding register values from the child stores
+func (c *CompositeDataStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	if quantity == 0 || quantity > common.MaxRegisterCount {
+		return nil, common.ErrInvalidQuantity
+	}
+	return compositeRead(ctx, c, common.TableHoldingRegisters, address, quantity,
+		func(s common.DataStore, a common.Address, q common.Quantity) ([]common.RegisterValue, error) {
+			return s.ReadHoldingRegisters(ctx, a, q)
+		})
+}
+
+// ReadInputRegisters reads input register values from the child stores
+func (c *CompositeDataStore) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	if quantity == 0 || quantity > common.MaxRegisterCount {
+		return nil, common.ErrInvalidQuantity
+	}
+	return compositeRead(ctx, c, common.TableInputRegisters, address, quantity,
+		func(s common.DataStore, a common.Address, q common.Quantity) ([]common.InputRegisterValue, error) {
+			return s.ReadInputRegisters(ctx, a, q)
+		})
+}
+
+// WriteSingleCoil writes a single coil value to the child store serving the address
+func (c *CompositeDataStore) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	return compositeWrite(ctx, c, common.TableCoils, address, []common.CoilValue{value},
+		func(s common.DataStore, a common.Address, v []common.CoilValue) error {
+			return s.WriteSingleCoil(ctx, a, v[0])
+		})
+}
+
+// WriteSingleRegister writes a single register value to the child store serving the address
+func (c *CompositeDataStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	return compositeWrite(ctx, c, common.TableHoldingRegisters, address, []common.RegisterValue{value},
+		func(s common.DataStore, a common.Address, v []common.RegisterValue) error {
+			return s.WriteSingleRegister(ctx, a, v[0])
+		})
+}
+
+// WriteMultipleCoils writes multiple coil values to the child stores
+func (c *CompositeDataStore) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	if len(values) == 0 || len(values) > int(common.MaxWriteCoilCount) {
+		return common.ErrInvalidQuantity
+	}
+	return compositeWrite(ctx, c, common.TableCoils, address, values,
+		func(s common.DataStore, a common.Address, v []common.CoilValue) error {
+			return s.WriteMultipleCoils(ctx, a, v)
+		})
+}
+
+// WriteMultipleRegisters writes multiple register values to the child stores
+func (c *CompositeDataStore) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	if len(values) == 0 || len(values) > int(common.MaxWriteRegisterCount) {
+		return common.ErrInvalidQuantity
+	}
+	return compositeWrite(ctx, c, common.TableHoldingRegisters, address, values,
+		func(s common.DataStore, a common.Address, v []common.RegisterValue) error {
+			return s.WriteMultipleRegisters(ctx, a, v)
+		})
+}
+
+// ValidateRange checks that every address of the range is served by a route
+// or the fallback store, and lets child stores implementing
+// common.RangeValidator check their part
+func (c *CompositeDataStore) ValidateRange(ctx context.Context, table common.Table, address common.Address, quantity common.Quantity) error {
+	segments, err := c.segments(ctx, table, address, int(quantity))
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range segments {
+		if validator, ok := seg.store.(common.RangeValidator); ok {
+			if err := validator.ValidateRange(ctx, table, seg.address, common.Quantity(seg.quantity)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// errSharedBackend fails a transaction that would need two children wrapping
+// the same transactional backend, whose lock the first transaction holds
+var errSharedBackend = errors.New("composite routes share a transactional backend")
+
+// Atomically calls fn with a view of the store that runs in a transaction of
+// each child implementing common.TransactionalDataStore that fn accesses, so
+// a write request is all or nothing as long as the children it touches are
+// transactional. Children that are not, or are not pointers and cannot be
+// told apart, are accessed directly and keep the writes made before fn
+// failed.
+//
+// Transactions are opened as fn first reaches each child: fn is rolled back
+// and run again with the new child's transaction open too, so it may run
+// more than once. A request needing two children that wrap the same backend,
+// such as an ObservableDataStore route over the fallback MemoryStore, fails
+// instead of waiting on a lock its own transaction holds; requests within
+// one of them are not affected.
+func (c *CompositeDataStore) Atomically(ctx context.Context, fn func(tx common.DataStore) error) error {
+	var children []common.DataStore
+	for {
+		tx := &compositeTx{views: make(map[storeIdentity]common.DataStore, len(children))}
+		err := tx.open(ctx, children, func() error {
+			err := fn(&CompositeDataStore{routes: c.routes, fallback: c.fallback, tx: tx})
+			if tx.missing != nil {
+				return errChildNotOpen
+			}
+			return err
+		})
+		if tx.missing == nil {
+			return err
+		}
+
+		backend, _ := identify(backendOf(tx.missing))
+		for _, child := range children {
+			if id, _ := identify(backendOf(child)); id == backend {
+				return errSharedBackend
+			}
+		}
+		children = append(children, tx.missing)
+	}
+}
+
+// storeIdentity tells child stores apart by pointer, since store values
+// may not be comparable
+type storeIdentity struct {
+	typ     reflect.Type
+	pointer uintptr
+}
+
+// identify returns the identity of a store that is a non-nil pointer
+func identify(store common.DataStore) (storeIdentity, bool) {
+	v := reflect.ValueOf(store)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return storeIdentity{}, false
+	}
+	return storeIdentity{typ: v.Type(), pointer: v.Pointer()}, true
+}
+
+// wrappingStore is implemented by the decorators of this package, whose
+// transactions are transactions of the store they wrap
+type wrappingStore interface {
+	wrapped() common.DataStore
+}
+
+// backendOf returns the innermost store of a chain of decorators
+func backendOf(store common.DataStore) common.DataStore {
+	for {
+		w, ok := store.(wrappingStore)
+		if !ok {
+			return store
+		}
+		store = w.wrapped()
+	}
+}
+
+// compositeTx is the transaction of an Atomically view
+type compositeTx struct {
+	// Transaction views of the open children
+	views map[storeIdentity]common.DataStore
+
+	// The transactional child fn reached without its transaction open
+	missing common.DataStore
+}
+
+// open opens the transactions of children, nested in order, and calls fn
+// within all of them
+func (t *compositeTx) open(ctx context.Context, children []common.DataStore, fn func() error) error {
+	if len(children) == 0 {
+		return fn()
+	}
+	return children[0].(common.TransactionalDataStore).Atomically(ctx, func(view common.DataStore) error {
+		id, _ := identify(children[0])
+		t.views[id] = view
+		return t.open(ctx, children[1:], fn)
+	})
+}
+
+// errChildNotOpen aborts fn when it reaches a child without an open
+// transaction, rolling back the others before fn runs again
+var errChildNotOpen = errors.New("composite child transaction not open")
+
+// use returns the store to access child through: the view of its open
+// transaction, or child itself when it is not transactional
+func (t *compositeTx) use(child common.DataStore) (common.DataStore, error) {
+	if _, ok := child.(common.TransactionalDataStore); !ok {
+		return child, nil
+	}
+	id, ok := identify(child)
+	if !ok {
+		return child, nil
+	}
+	if view, ok := t.views[id]; ok {
+		return view, nil
+	}
+	if t.missing == nil {
+		t.missing = child
+	}
+	return nil, errChildNotOpen
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestCompositeDataStore_Routing(t *testing.T) {
+	low := NewMemoryStore()
+	high := NewMemoryStore()
+	unit2 := NewMemoryStore()
+
+	store := NewCompositeDataStore(
+		WithUnitRoute(2, common.TableHoldingRegisters, 0, 9, unit2),
+		WithRoute(common.TableAll, 0, 9, low),
+		WithRoute(common.TableHoldingRegisters|common.TableInputRegisters, 10, 19, high),
+	)
+
+	for i := 0; i < 20; i++ {
+		low.SetHoldingRegister(common.Address(i), 0x1000+common.RegisterValue(i))
+		high.SetHoldingRegister(common.Address(i), 0x2000+common.RegisterValue(i))
+		unit2.SetHoldingRegister(common.Address(i), 0x3000+common.RegisterValue(i))
+	}
+
+	// A read spanning two routes is split and joined
+	ctx := context.Background()
+	values, err := store.ReadHoldingRegisters(ctx, 8, 4)
+	if err != nil {
+		t.Fatalf("ReadHoldingRegisters failed: %v", err)
+	}
+	expected := []common.RegisterValue{0x1008, 0x1009, 0x200A, 0x200B}
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Errorf("Index %d: expected 0x%04X, got 0x%04X", i, expected[i], values[i])
+		}
+	}
+
+	// Unit routes only apply to their unit
+	values, err = store.ReadHoldingRegisters(ContextWithUnitID(ctx, 2), 9, 2)
+	if err != nil {
+		t.Fatalf("ReadHoldingRegisters for unit 2 failed: %v", err)
+	}
+	if values[0] != 0x3009 || values[1] != 0x200A {
+		t.Errorf("Expected [0x3009 0x200A] for unit 2, got %04X", values)
+	}
+
+	// A write spanning two routes lands in both stores
+	if err := store.WriteMultipleRegisters(ctx, 9, []common.RegisterValue{0xAAAA, 0xBBBB}); err != nil {
+		t.Fatalf("WriteMultipleRegisters failed: %v", err)
+	}
+	if v, _ := low.GetHoldingRegister(9); v != 0xAAAA {
+		t.Errorf("Expected low store to hold 0xAAAA, got 0x%04X", v)
+	}
+	if v, _ := high.GetHoldingRegister(10); v != 0xBBBB {
+		t.Errorf("Expected high store to hold 0xBBBB, got 0x%04X", v)
+	}
+
+	// Coils above 9 are not routed and there is no fallback
+	if _, err := store.ReadCoils(ctx, 5, 10); !errors.Is(err, common.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress for unmapped coils, got %v", err)
+	}
+	if err := store.WriteSingleCoil(ctx, 10, true); !errors.Is(err, common.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress for unmapped coil write, got %v", err)
+	}
+}
+
+func TestCompositeDataStore_Fallback(t *testing.T) {
+	routed := NewMemoryStore()
+	fallback := NewMemoryStore()
+	store := NewCompositeDataStore(
+		WithRoute(common.TableCoils, 0, 7, routed),
+		WithFallbackStore(fallback),
+	)
+
+	ctx := context.Background()
+	if err := store.WriteMultipleCoils(ctx, 6, []common.CoilValue{true, true, true}); err != nil {
+		t.Fatalf("WriteMultipleCoils failed: %v", err)
+	}
+	if v, _ := routed.GetCoil(7); !v {
+		t.Error("Expected coil 7 in routed store")
+	}
+	if v, _ := fallback.GetCoil(8); !v {
+		t.Error("Expected coil 8 in fallback store")
+	}
+	if _, ok := routed.GetCoil(8); ok {
+		t.Error("Expected coil 8 not to reach the routed store")
+	}
+}
+
+func TestCompositeDataStore_UnmappedException(t *testing.T) {
+	memory := NewMemoryStore()
+	memory.SetHoldingRegister(0, 0x1234)
+
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerDataStore(NewCompositeDataStore(
+		WithUnitRoute(1, common.TableHoldingRegisters, 0, 99, memory),
+	)))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Unit 1 is routed
+	pdu := sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+	if pdu[0] != byte(common.FuncReadHoldingRegisters) || pdu[2] != 0x12 || pdu[3] != 0x34 {
+		t.Errorf("Unexpected response for unit 1: %x", pdu)
+	}
+
+	// Unit 2 is not, which surfaces as Illegal Data Address
+	pdu = sendRawRequest(t, conn, 2, 2, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+	if pdu[0] != byte(common.FuncReadHoldingRegisters)|common.ExceptionBit || pdu[1] != byte(common.ExceptionDataAddressNotAvailable) {
+		t.Errorf("Expected Illegal Data Address exception for unit 2, got %x", pdu)
+	}
+}
+
+func TestCompositeDataStore_AtomicallyRollsBack(t *testing.T) {
+	low := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 49))
+	high := NewMemoryStore()
+	low.SetHoldingRegister(0, 7)
+	store := NewCompositeDataStore(
+		WithRoute(common.TableHoldingRegisters, 0, 99, low),
+		WithRoute(common.TableHoldingRegisters, 100, 199, high),
+	)
+	ctx := context.Background()
+
+	err := store.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, 0, []common.RegisterValue{5, 6}); err != nil {
+			return err
+		}
+		if values, _ := tx.ReadHoldingRegisters(ctx, 0, 2); values[0] != 5 || values[1] != 6 {
+			t.Errorf("Expected [5 6] inside the transaction, got %v", values)
+		}
+		return tx.WriteMultipleRegisters(ctx, 49, []common.RegisterValue{1, 2})
+	})
+	if !errors.Is(err, common.ErrInvalidAddress) {
+		t.Fatalf("Expected ErrInvalidAddress, got %v", err)
+	}
+	if value, _ := low.GetHoldingRegister(0); value != 7 {
+		t.Errorf("Expected register 0 restored to 7, got %d", value)
+	}
+	if _, ok := low.GetHoldingRegister(1); ok {
+		t.Error("Expected register 1 to be removed again")
+	}
+
+	// Writes spanning routes are rolled back in every child
+	err = store.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, 98, []common.RegisterValue{1, 2, 3, 4}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil {
+		t.Fatal("Expected the callback's error")
+	}
+	if _, ok := high.GetHoldingRegister(100); ok {
+		t.Error("Expected register 100 to be rolled back")
+	}
+
+	err = store.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteSingleRegister(ctx, 0, 8); err != nil {
+			return err
+		}
+		return tx.WriteSingleRegister(ctx, 100, 9)
+	})
+	if err != nil {
+		t.Fatalf("Atomically returned error: %v", err)
+	}
+	if value, _ := low.GetHoldingRegister(0); value != 8 {
+		t.Errorf("Expected register 0 to be 8, got %d", value)
+	}
+	if value, _ := high.GetHoldingRegister(100); value != 9 {
+		t.Errorf("Expected register 100 to be 9, got %d", value)
+	}
+}
+
+// taggedStore is a store of a non-comparable value type
+type taggedStore struct {
+	*MemoryStore
+	tags []string
+}
+
+func TestCompositeDataStore_AtomicallySharedBackend(t *testing.T) {
+	base := NewMemoryStore()
+	store := NewCompositeDataStore(
+		WithRoute(common.TableHoldingRegisters, 0, 99, NewObservableDataStore(base)),
+		WithRoute(common.TableHoldingRegisters, 200, 299, taggedStore{MemoryStore: NewMemoryStore(), tags: []string{"x"}}),
+		WithFallbackStore(base),
+	)
+	ctx := context.Background()
+
+	atomically := func(fn func(tx common.DataStore) error) error {
+		t.Helper()
+		done := make(chan error, 1)
+		go func() { done <- store.Atomically(ctx, fn) }()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("Atomically did not return")
+			return nil
+		}
+	}
+
+	// Requests within the decorator route, the fallback or the route of a
+	// non-comparable store each need one transaction
+	for _, address := range []common.Address{10, 150, 250} {
+		err := atomically(func(tx common.DataStore) error {
+			return tx.WriteSingleRegister(ctx, address, 7)
+		})
+		if err != nil {
+			t.Errorf("Write at %d failed: %v", address, err)
+		}
+	}
+	if value, _ := base.GetHoldingRegister(10); value != 7 {
+		t.Errorf("Expected register 10 to be 7, got %d", value)
+	}
+
+	// A request spanning both children of the backend fails instead of
+	// waiting on its own lock, and leaves the store unchanged
+	err := atomically(func(tx common.DataStore) error {
+		return tx.WriteMultipleRegisters(ctx, 98, []common.RegisterValue{1, 2, 3, 4})
+	})
+	if !errors.Is(err, errSharedBackend) {
+		t.Errorf("Expected errSharedBackend, got %v", err)
+	}
+	if value, _ := base.GetHoldingRegister(98); value != 0 {
+		t.Errorf("Expected register 98 unchanged, got %d", value)
+	}
+}
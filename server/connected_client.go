@@ -5,6 +5,7 @@ import (
 	"net"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,6 +21,61 @@ type clientConn struct {
 	rxCount     atomic.Uint64
 	txCount     atomic.Uint64
 	fcCount     [256]atomic.Uint64
+	violations  atomic.Uint64
+	exceptions  atomic.Uint64
+	rxBytes     atomic.Uint64 // PDU bytes received
+	lastFC      atomic.Uint32 // function code of the latest request
+	lastActive  atomic.Int64  // server clock time of the latest request, in ns
+	busy        atomic.Bool   // a request is being processed
+	dedup       *dedupCache   // recent write responses, see WithRequestDedup
+	duplicates  atomic.Uint64 // retransmitted writes answered from dedup
+	watches     atomic.Int32  // open watches, see WithChangeNotifications
+	writeMu     sync.Mutex    // serializes responses, which watches send too
+	tls         *TLSClient    // identity of a TLS client, see WithServerTLSConfig
+}
+
+// touch records activity on the connection at now
+func (c *clientConn) touch(now time.Time) {
+	c.lastActive.Store(now.UnixNano())
+}
+
+// lastActivity returns the time of the latest request, or the connection
+// time if none was received
+func (c *clientConn) lastActivity() time.Time {
+	if ns := c.lastActive.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return c.connectedAt
+}
+
+// request counts a received request of size PDU bytes
+func (c *clientConn) request(functionCode common.FunctionCode, size int) {
+	c.rxCount.Add(1)
+	c.fcCount[functionCode].Add(1)
+	c.rxBytes.Add(uint64(size))
+	c.lastFC.Store(uint32(functionCode))
+}
+
+// snapshot returns the current state of the connection
+func (c *clientConn) snapshot() ConnectedClient {
+	client := ConnectedClient{
+		RemoteAddr:        c.remoteAddr,
+		ConnectedAt:       c.connectedAt,
+		RxTransactions:    c.rxCount.Load(),
+		TxTransactions:    c.txCount.Load(),
+		FunctionCodeStats: fcSnapshot(c),
+		Exceptions:        c.exceptions.Load(),
+		MalformedFrames:   c.violations.Load(),
+		DuplicateRequests: c.duplicates.Load(),
+	}
+	if c.tls != nil {
+		client.Role = c.tls.Role
+	}
+	if client.RxTransactions > 0 {
+		client.AvgRequestSize = float64(c.rxBytes.Load()) / float64(client.RxTransactions)
+		client.LastFunctionCode = common.FunctionCode(c.lastFC.Load())
+	}
+	return client
 }
 
 // ConnectedClient is a snapshot of a connected client's state.
@@ -40,12 +96,39 @@ type ConnectedClient struct {
 	// FunctionCodeStats is a per-function-code count of received requests.
 	// Only non-zero entries are included.
 	FunctionCodeStats map[common.FunctionCode]uint64
+
+	// Exceptions is the number of exception responses sent to this client.
+	Exceptions uint64
+
+	// MalformedFrames is the number of protocol violations, such as a bad
+	// protocol ID, length or byte count, received from this client.
+	MalformedFrames uint64
+
+	// DuplicateRequests is the number of retransmitted write requests
+	// answered from the WithRequestDedup cache instead of being applied.
+	DuplicateRequests uint64
+
+	// AvgRequestSize is the average PDU size of the received requests, in
+	// bytes. Zero until the first request.
+	AvgRequestSize float64
+
+	// LastFunctionCode is the function code of the latest request. Only
+	// meaningful when RxTransactions is non-zero.
+	LastFunctionCode common.FunctionCode
+
+	// Role is the role in the client's certificate when it connected with
+	// WithServerTLSConfig, "" otherwise.
+	Role string
 }
 
 // String returns a human-readable summary of the connected client.
 func (c ConnectedClient) String() string {
 	duration := time.Since(c.ConnectedAt).Truncate(time.Second)
-	s := fmt.Sprintf("%s | connected %s | rx: %d tx: %d", c.RemoteAddr, duration, c.RxTransactions, c.TxTransactions)
+	s := fmt.Sprintf("%s | connected %s | rx: %d tx: %d | exceptions: %d malformed: %d",
+		c.RemoteAddr, duration, c.RxTransactions, c.TxTransactions, c.Exceptions, c.MalformedFrames)
+	if c.RxTransactions > 0 {
+		s += fmt.Sprintf(" | avg request: %.1fB last: %s", c.AvgRequestSize, c.LastFunctionCode)
+	}
 	if len(c.FunctionCodeStats) > 0 {
 		// Sort by function code for deterministic output
 		codes := make([]common.FunctionCode, 0, len(c.FunctionCodeStats))
@@ -215,6 +215,55 @@ func TestWithOnClientDisconnect(t *testing.T) {
 	}
 }
 
+func TestWithOnClientsChanged(t *testing.T) {
+	changes := make(chan []ConnectedClient, 10)
+	srv := NewTCPServer("127.0.0.1",
+		WithServerPort(0),
+		WithOnClientsChanged(func(clients []ConnectedClient) {
+			changes <- clients
+		}),
+	)
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	next := func() []ConnectedClient {
+		select {
+		case clients := <-changes:
+			return clients
+		case <-time.After(5 * time.Second):
+			t.Fatal("OnClientsChanged callback was not called within timeout")
+			return nil
+		}
+	}
+
+	addr := srv.listener.Addr().String()
+	first, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer first.Close()
+	if clients := next(); len(clients) != 1 || clients[0].RemoteAddr != first.LocalAddr().String() {
+		t.Fatalf("Expected the first client, got %v", clients)
+	}
+
+	second, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if clients := next(); len(clients) != 2 || clients[1].RemoteAddr != second.LocalAddr().String() {
+		t.Fatalf("Expected both clients in connection order, got %v", clients)
+	}
+
+	second.Close()
+	if clients := next(); len(clients) != 1 || clients[0].RemoteAddr != first.LocalAddr().String() {
+		t.Fatalf("Expected the first client after the second left, got %v", clients)
+	}
+}
+
 func TestNilCallbacksDoNotPanic(t *testing.T) {
 	// Server with no callbacks set should not panic
 	srv := NewTCPServer("127.0.0.1", WithServerPort(0))
@@ -366,3 +415,67 @@ func TestTCPServer_ConnectedClients_SnapshotWithFCStats(t *testing.T) {
 		t.Errorf("Expected ReadHoldingRegisters=100, got %d", snap.FunctionCodeStats[common.FuncReadHoldingRegisters])
 	}
 }
+
+func TestConnectedClient_String_Counters(t *testing.T) {
+	client := ConnectedClient{
+		RemoteAddr:       "10.0.0.7:5020",
+		ConnectedAt:      time.Now(),
+		RxTransactions:   4,
+		TxTransactions:   4,
+		Exceptions:       3,
+		MalformedFrames:  2,
+		AvgRequestSize:   5.5,
+		LastFunctionCode: common.FuncWriteMultipleRegisters,
+	}
+
+	s := client.String()
+	for _, part := range []string{"exceptions: 3", "malformed: 2", "avg request: 5.5B", "last: WriteMultipleRegisters"} {
+		if !strings.Contains(s, part) {
+			t.Errorf("String() missing %q, got: %s", part, s)
+		}
+	}
+
+	// No averages before the first request
+	if s := (ConnectedClient{RemoteAddr: "10.0.0.1:12345", ConnectedAt: time.Now()}).String(); strings.Contains(s, "avg request") {
+		t.Errorf("String() should not show averages without requests, got: %s", s)
+	}
+}
+
+func TestTCPServer_ConnectedClients_Counters(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}) // 5 bytes
+	sendRawRequest(t, conn, 2, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x00}) // quantity 0
+	// Byte count 4 with 2 data bytes: malformed and answered with an exception
+	sendRawRequest(t, conn, 3, 1, common.FuncWriteMultipleRegisters, []byte{0x00, 0x00, 0x00, 0x01, 0x04, 0x00, 0x01}) // 8 bytes
+
+	clients := srv.ConnectedClients()
+	if len(clients) != 1 {
+		t.Fatalf("Expected 1 connected client, got %d", len(clients))
+	}
+	c := clients[0]
+	if c.Exceptions != 2 {
+		t.Errorf("Expected 2 exceptions, got %d", c.Exceptions)
+	}
+	if c.MalformedFrames != 1 {
+		t.Errorf("Expected 1 malformed frame, got %d", c.MalformedFrames)
+	}
+	if c.AvgRequestSize != 6 {
+		t.Errorf("Expected an average request size of 6 bytes, got %v", c.AvgRequestSize)
+	}
+	if c.LastFunctionCode != common.FuncWriteMultipleRegisters {
+		t.Errorf("Expected last function WriteMultipleRegisters, got %s", c.LastFunctionCode)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// unitIDKey is the context key for the unit ID of the request being served
+type unitIDKey struct{}
+
+// ContextWithUnitID returns a copy of ctx carrying the unit ID of the request
+// being served. TCPServer sets it before calling a handler, so data stores can
+// route on the addressed unit.
+func ContextWithUnitID(ctx context.Context, unitID common.UnitID) context.Context {
+	return context.WithValue(ctx, unitIDKey{}, unitID)
+}
+
+// UnitIDFromContext returns the unit ID stored by ContextWithUnitID.
+// The second result is false if ctx carries no unit ID.
+func UnitIDFromContext(ctx context.Context) (common.UnitID, bool) {
+	unitID, ok := ctx.Value(unitIDKey{}).(common.UnitID)
+	return unitID, ok
+}
+
+// remoteAddrKey is the context key for the address of the client being served
+type remoteAddrKey struct{}
+
+// ContextWithRemoteAddr returns a copy of ctx carrying the address of the
+// client whose request is being served. TCPServer sets it for every request,
+// so data stores can tell clients apart.
+func ContextWithRemoteAddr(ctx context.Context, remoteAddr string) context.Context {
+	return context.WithValue(ctx, remoteAddrKey{}, remoteAddr)
+}
+
+// RemoteAddrFromContext returns the client address stored by
+// ContextWithRemoteAddr. The second result is false if ctx carries none.
+func RemoteAddrFromContext(ctx context.Context) (string, bool) {
+	remoteAddr, ok := ctx.Value(remoteAddrKey{}).(string)
+	return remoteAddr, ok
+}
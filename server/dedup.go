@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Default settings of WithRequestDedup
+const (
+	DefaultDedupWindow = 5 * time.Second
+	DefaultDedupSize   = 16
+)
+
+// WithRequestDedup answers retransmitted write requests from a cache instead
+// of applying them again. Gateways may resend a request with the same MBAP
+// transaction ID after a timeout although the server already applied it; a
+// write request repeating the transaction ID, unit ID and PDU of one answered
+// on the same connection less than window ago gets the earlier response,
+// whether normal or exception. Each connection remembers its last size
+// writes. Reads are always processed again, as that is harmless and returns
+// fresh data. A window or size of 0 or less uses the defaults.
+// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (MBAP Header, Transaction Identifier)
+func WithRequestDedup(window time.Duration, size int) TCPServerOption {
+	return func(s *TCPServer) {
+		if window <= 0 {
+			window = DefaultDedupWindow
+		}
+		if size <= 0 {
+			size = DefaultDedupSize
+		}
+		s.dedupWindow = window
+		s.dedupSize = size
+	}
+}
+
+// dedupKey identifies a transaction on a connection
+type dedupKey struct {
+	transactionID common.TransactionID
+	unitID        common.UnitID
+}
+
+// dedupEntry is an answered write request and its response
+type dedupEntry struct {
+	key      dedupKey
+	pdu      []byte
+	response common.Response
+	at       time.Time
+}
+
+// dedupCache holds the recent write responses of one connection. It is only
+// used by the connection's goroutine, so it needs no locking.
+type dedupCache struct {
+	window  time.Duration
+	size    int
+	entries map[dedupKey]*dedupEntry
+	order   []*dedupEntry
+}
+
+// newDedupCache creates the cache of a connection, or nil when dedup is off
+func (s *TCPServer) newDedupCache() *dedupCache {
+	if s.dedupSize == 0 {
+		return nil
+	}
+	return &dedupCache{
+		window:  s.dedupWindow,
+		size:    s.dedupSize,
+		entries: make(map[dedupKey]*dedupEntry),
+	}
+}
+
+// lookup returns the cached response of a retransmitted request
+func (c *dedupCache) lookup(unitID common.UnitID, transactionID common.TransactionID, pdu []byte, now time.Time) (common.Response, bool) {
+	if c == nil || !common.FunctionCode(pdu[0]).IsWrite() {
+		return nil, false
+	}
+	c.expire(now)
+	entry, ok := c.entries[dedupKey{transactionID, unitID}]
+	if !ok || !bytes.Equal(entry.pdu, pdu) {
+		return nil, false
+	}
+	return entry.response, true
+}
+
+// store remembers the response of a write request
+func (c *dedupCache) store(unitID common.UnitID, transactionID common.TransactionID, pdu []byte, response common.Response, now time.Time) {
+	if c == nil || !common.FunctionCode(pdu[0]).IsWrite() {
+		return
+	}
+	entry := &dedupEntry{key: dedupKey{transactionID, unitID}, pdu: pdu, response: response, at: now}
+	c.entries[entry.key] = entry
+	c.order = append(c.order, entry)
+	for len(c.order) > c.size {
+		c.evict()
+	}
+}
+
+// expire drops the entries older than the window
+func (c *dedupCache) expire(now time.Time) {
+	for len(c.order) > 0 && now.Sub(c.order[0].at) >= c.window {
+		c.evict()
+	}
+}
+
+// evict drops the oldest entry. A transaction ID reused for another request
+// replaced the entry in the map already.
+func (c *dedupCache) evict() {
+	entry := c.order[0]
+	c.order[0] = nil
+	c.order = c.order[1:]
+	if c.entries[entry.key] == entry {
+		delete(c.entries, entry.key)
+	}
+}
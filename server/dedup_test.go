@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestTCPServer_RequestDedup(t *testing.T) {
+	store := NewMemoryStore()
+	clock := test.NewManualClock(time.Now())
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerDataStore(store),
+		WithServerClock(clock), WithRequestDedup(time.Second, 2))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	register := func() common.RegisterValue {
+		t.Helper()
+		values, err := store.ReadHoldingRegisters(ctx, 100, 1)
+		if err != nil {
+			t.Fatalf("Failed to read store: %v", err)
+		}
+		return values[0]
+	}
+	write := []byte{0x00, 0x64, 0x00, 0x01}
+
+	sendRawRequest(t, conn, 7, 1, common.FuncWriteSingleRegister, write)
+	store.WriteSingleRegister(ctx, 100, 5)
+
+	// The retransmission is answered without being applied
+	pdu := sendRawRequest(t, conn, 7, 1, common.FuncWriteSingleRegister, write)
+	if pdu[0] != byte(common.FuncWriteSingleRegister) {
+		t.Errorf("Expected the cached response, got % X", pdu)
+	}
+	if got := register(); got != 5 {
+		t.Errorf("Expected the retransmission not to be applied, register is %d", got)
+	}
+
+	// Another transaction ID, or the same one with another PDU, is applied
+	sendRawRequest(t, conn, 8, 1, common.FuncWriteSingleRegister, write)
+	if got := register(); got != 1 {
+		t.Errorf("Expected a new transaction to be applied, register is %d", got)
+	}
+	store.WriteSingleRegister(ctx, 100, 5)
+	sendRawRequest(t, conn, 7, 1, common.FuncWriteSingleRegister, []byte{0x00, 0x64, 0x00, 0x02})
+	if got := register(); got != 2 {
+		t.Errorf("Expected a reused transaction ID with another PDU to be applied, register is %d", got)
+	}
+
+	// A retransmission after the window is applied again
+	store.WriteSingleRegister(ctx, 100, 5)
+	clock.Advance(time.Second)
+	sendRawRequest(t, conn, 7, 1, common.FuncWriteSingleRegister, []byte{0x00, 0x64, 0x00, 0x02})
+	if got := register(); got != 2 {
+		t.Errorf("Expected a retransmission after the window to be applied, register is %d", got)
+	}
+
+	clients := srv.ConnectedClients()
+	if len(clients) != 1 || clients[0].DuplicateRequests != 1 {
+		t.Errorf("Expected 1 duplicate request, got %v", clients)
+	}
+}
+
+func TestDedupCache_Size(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithRequestDedup(time.Minute, 2))
+	cache := srv.newDedupCache()
+	now := time.Now()
+	pdu := []byte{byte(common.FuncWriteSingleCoil), 0x00, 0x01, 0xFF, 0x00}
+
+	for txID := common.TransactionID(1); txID <= 3; txID++ {
+		cache.store(1, txID, pdu, nil, now)
+	}
+	if _, ok := cache.lookup(1, 1, pdu, now); ok {
+		t.Error("Expected the oldest entry to be evicted")
+	}
+	for _, txID := range []common.TransactionID{2, 3} {
+		if _, ok := cache.lookup(1, txID, pdu, now); !ok {
+			t.Errorf("Expected transaction %d to be cached", txID)
+		}
+	}
+
+	// Reads are never cached
+	read := []byte{byte(common.FuncReadCoils), 0x00, 0x01, 0x00, 0x01}
+	cache.store(1, 4, read, nil, now)
+	if _, ok := cache.lookup(1, 4, read, now); ok {
+		t.Error("Expected reads not to be cached")
+	}
+
+	if (&TCPServer{}).newDedupCache() != nil {
+		t.Error("Expected no cache without WithRequestDedup")
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// deviceIDHeaderLength is the size of the fixed part of a Read Device
+// Identification response: function code, MEI type, ReadDeviceID code,
+// conformity level, MoreFollows, NextObjectID and number of objects
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Response PDU)
+const deviceIDHeaderLength = 7
+
+// DefaultObjectBudget is the number of bytes available to objects in one
+// Read Device Identification response PDU
+const DefaultObjectBudget = common.MaxPDULength - deviceIDHeaderLength
+
+// ObjectPage is one response's worth of device identification objects
+type ObjectPage struct {
+	Objects      []common.DeviceIDObject
+	MoreFollows  common.MoreFollows
+	NextObjectID common.DeviceIDObjectCode
+}
+
+// PaginateObjects selects the objects for one stream access response
+// (ReadDeviceID codes 0x01-0x03): objects in ID order starting at startID,
+// as many as fit in budget bytes (see DefaultObjectBudget), with MoreFollows
+// and NextObjectID set when objects remain for a follow-up request. If no
+// object has startID, the page starts at the first object, as the spec
+// requires. Custom handlers registered for function 0x2B can use it to
+// serve object sets larger than one PDU.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Read Device Identification)
+func PaginateObjects(objects []common.DeviceIDObject, startID common.DeviceIDObjectCode, budget int) (ObjectPage, error) {
+	sorted := make([]common.DeviceIDObject, len(objects))
+	copy(sorted, objects)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	start := 0
+	for i, object := range sorted {
+		if object.ID == startID {
+			start = i
+			break
+		}
+	}
+
+	page := ObjectPage{MoreFollows: common.MoreFollowsNo}
+	used := 0
+	for i := start; i < len(sorted); i++ {
+		object := sorted[i]
+		size := 2 + len(object.Value) // ID + length + value
+		if len(object.Value) > 255 || size > budget {
+			return ObjectPage{}, fmt.Errorf("device identification object 0x%02X of %d bytes does not fit in %d bytes",
+				byte(object.ID), len(object.Value), budget)
+		}
+		if used+size > budget {
+			page.MoreFollows = common.MoreFollowsYes
+			page.NextObjectID = object.ID
+			break
+		}
+		object.Length = byte(len(object.Value))
+		page.Objects = append(page.Objects, object)
+		used += size
+	}
+	return page, nil
+}
+
+// EncodeDeviceIdentification encodes the data of a Read Device
+// Identification response PDU. The objects must fit in DefaultObjectBudget
+// bytes, as those selected by PaginateObjects do.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Response PDU)
+func EncodeDeviceIdentification(deviceID *common.DeviceIdentification) []byte {
+	// Byte 0: MEI Type (0x0E)
+	// Byte 1: ReadDeviceID code
+	// Byte 2: Conformity level
+	// Byte 3: More follows (0x00 or 0xFF)
+	// Byte 4: Next object ID
+	// Byte 5: Number of objects
+	// For each object: ID, length, value
+	data := []byte{
+		byte(common.MEIReadDeviceID),
+		byte(deviceID.ReadDeviceIDCode),
+		byte(deviceID.ConformityLevel),
+		byte(deviceID.MoreFollows),
+		byte(deviceID.NextObjectID),
+		byte(len(deviceID.Objects)),
+	}
+	for _, object := range deviceID.Objects {
+		data = append(data, byte(object.ID), byte(len(object.Value)))
+		data = append(data, object.Value...)
+	}
+	return data
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestPaginateObjects(t *testing.T) {
+	objects := []common.DeviceIDObject{
+		{ID: 0x02, Value: strings.Repeat("c", 100)},
+		{ID: 0x00, Value: strings.Repeat("a", 100)},
+		{ID: 0x01, Value: strings.Repeat("b", 100)},
+		{ID: 0x80, Value: "ext"},
+	}
+
+	// Two 102-byte objects fit in the default budget, the third does not
+	page, err := PaginateObjects(objects, 0x00, DefaultObjectBudget)
+	if err != nil {
+		t.Fatalf("PaginateObjects failed: %v", err)
+	}
+	if len(page.Objects) != 2 || page.Objects[0].ID != 0x00 || page.Objects[1].ID != 0x01 {
+		t.Fatalf("Unexpected first page %+v", page.Objects)
+	}
+	if page.MoreFollows != common.MoreFollowsYes || page.NextObjectID != 0x02 {
+		t.Errorf("Expected more to follow from 0x02, got %s 0x%02X", page.MoreFollows, byte(page.NextObjectID))
+	}
+	if page.Objects[0].Length != 100 {
+		t.Errorf("Expected the length to be filled in, got %d", page.Objects[0].Length)
+	}
+
+	page, err = PaginateObjects(objects, page.NextObjectID, DefaultObjectBudget)
+	if err != nil {
+		t.Fatalf("PaginateObjects failed: %v", err)
+	}
+	if len(page.Objects) != 2 || page.Objects[1].ID != 0x80 || page.MoreFollows != common.MoreFollowsNo {
+		t.Errorf("Unexpected last page %+v", page)
+	}
+
+	// An unknown start restarts at the first object
+	page, _ = PaginateObjects(objects, 0x40, DefaultObjectBudget)
+	if page.Objects[0].ID != 0x00 {
+		t.Errorf("Expected an unknown start to restart at 0x00, got 0x%02X", byte(page.Objects[0].ID))
+	}
+
+	if _, err := PaginateObjects(objects, 0x00, 50); err == nil {
+		t.Error("Expected an error for an object larger than the budget")
+	}
+}
+
+func TestEncodeDeviceIdentification(t *testing.T) {
+	data := EncodeDeviceIdentification(&common.DeviceIdentification{
+		ReadDeviceIDCode: common.ReadDeviceIDRegularStream,
+		ConformityLevel:  common.ConformityLevelBasic,
+		MoreFollows:      common.MoreFollowsYes,
+		NextObjectID:     0x04,
+		Objects:          []common.DeviceIDObject{{ID: 0x03, Value: "url"}},
+	})
+	expected := []byte{0x0E, 0x02, byte(common.ConformityLevelBasic), 0xFF, 0x04, 0x01, 0x03, 0x03, 'u', 'r', 'l'}
+	if string(data) != string(expected) {
+		t.Errorf("Expected % X, got % X", expected, data)
+	}
+}
+
+func TestPaginateObjects_MaximumSize(t *testing.T) {
+	// Objects filling the budget exactly fit in one response of MaxPDULength
+	objects := []common.DeviceIDObject{
+		{ID: 0x80, Value: strings.Repeat("a", 120)},
+		{ID: 0x81, Value: strings.Repeat("b", DefaultObjectBudget-2*2-120)},
+	}
+	page, err := PaginateObjects(objects, 0x80, DefaultObjectBudget)
+	if err != nil || len(page.Objects) != 2 || page.MoreFollows != common.MoreFollowsNo {
+		t.Fatalf("Expected both objects in one page, got %+v (%v)", page, err)
+	}
+	data := EncodeDeviceIdentification(&common.DeviceIdentification{Objects: page.Objects})
+	if size := 1 + len(data); size != common.MaxPDULength {
+		t.Errorf("Expected a PDU of exactly %d bytes, got %d", common.MaxPDULength, size)
+	}
+
+	// One more byte moves the second object to a follow-up response
+	objects[1].Value += "b"
+	page, err = PaginateObjects(objects, 0x80, DefaultObjectBudget)
+	if err != nil || len(page.Objects) != 1 || page.MoreFollows != common.MoreFollowsYes || page.NextObjectID != 0x81 {
+		t.Errorf("Expected the second object to follow, got %+v (%v)", page, err)
+	}
+
+	// An object of 255 bytes can never fit
+	if _, err := PaginateObjects([]common.DeviceIDObject{{ID: 0x80, Value: strings.Repeat("c", 255)}}, 0x80, DefaultObjectBudget); err == nil {
+		t.Error("Expected an error for an object larger than a PDU")
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// describeRequest returns the fields of a request relevant to an exception,
+// such as "address=100 quantity=5 byteCount=10", for logs and events. Fields
+// the request is too short to contain are omitted; a request with no
+// decodable fields is described by its length.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (Function Code Descriptions)
+func describeRequest(pdu *common.PDU) string {
+	data := pdu.Data
+	var fields []string
+	word := func(name string, i int) {
+		if len(data) >= i+2 {
+			fields = append(fields, fmt.Sprintf("%s=%d", name, binary.BigEndian.Uint16(data[i:])))
+		}
+	}
+	count := func(name string, i int) {
+		if len(data) > i {
+			fields = append(fields, fmt.Sprintf("%s=%d", name, data[i]))
+		}
+	}
+
+	switch pdu.FunctionCode {
+	case common.FuncReadCoils, common.FuncReadDiscreteInputs,
+		common.FuncReadHoldingRegisters, common.FuncReadInputRegisters:
+		word("address", 0)
+		word("quantity", 2)
+	case common.FuncWriteSingleCoil, common.FuncWriteSingleRegister:
+		word("address", 0)
+		if len(data) >= 4 {
+			fields = append(fields, fmt.Sprintf("value=0x%04X", binary.BigEndian.Uint16(data[2:])))
+		}
+	case common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters:
+		word("address", 0)
+		word("quantity", 2)
+		count("byteCount", 4)
+	case common.FuncMaskWriteRegister, common.FuncReadFIFOQueue:
+		word("address", 0)
+	case common.FuncReadFileRecord, common.FuncWriteFileRecord:
+		count("byteCount", 0)
+	case common.FuncReadWriteMultipleRegisters:
+		word("readAddress", 0)
+		word("readQuantity", 2)
+		word("writeAddress", 4)
+		word("writeQuantity", 6)
+		count("byteCount", 8)
+	case common.FuncReadDeviceIdentification:
+		count("meiType", 0)
+		count("readDeviceIDCode", 1)
+		count("objectID", 2)
+	}
+
+	if len(fields) == 0 {
+		return fmt.Sprintf("length=%d", len(data))
+	}
+	return strings.Join(fields, " ")
+}
+
+// ExceptionCounts returns the number of exception responses sent, by
+// exception code, to tell apart causes such as out-of-range addresses (0x02)
+// and invalid values or quantities (0x03) when debugging an emulation.
+func (s *TCPServer) ExceptionCounts() map[common.ExceptionCode]uint64 {
+	m := &s.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[common.ExceptionCode]uint64)
+	for key, n := range m.exceptions {
+		counts[key.exception] += n
+	}
+	return counts
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestDescribeRequest(t *testing.T) {
+	cases := []struct {
+		fc       common.FunctionCode
+		data     []byte
+		expected string
+	}{
+		{common.FuncReadHoldingRegisters, []byte{0x00, 0x64, 0x00, 0x05}, "address=100 quantity=5"},
+		{common.FuncWriteSingleCoil, []byte{0x00, 0x01, 0xFF, 0x00}, "address=1 value=0xFF00"},
+		{common.FuncWriteMultipleRegisters, []byte{0x00, 0x0A, 0x00, 0x02, 0x04, 0x00, 0x01, 0x00, 0x02}, "address=10 quantity=2 byteCount=4"},
+		{common.FuncReadWriteMultipleRegisters, []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x09, 0x00, 0x02, 0x04},
+			"readAddress=0 readQuantity=1 writeAddress=9 writeQuantity=2 byteCount=4"},
+		{common.FuncReadCoils, []byte{0x00, 0x07, 0x00}, "address=7"},
+		{common.FuncReadCoils, []byte{0x00}, "length=1"},
+		{common.FunctionCode(0x41), nil, "length=0"},
+	}
+	for _, tc := range cases {
+		if got := describeRequest(&common.PDU{FunctionCode: tc.fc, Data: tc.data}); got != tc.expected {
+			t.Errorf("%s % X: expected %q, got %q", tc.fc, tc.data, tc.expected, got)
+		}
+	}
+}
+
+func TestTCPServer_ExceptionDiagnostics(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0))
+	events := srv.Events()
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0xFF, 0xF0, 0x00, 0x20}) // past the end
+	sendRawRequest(t, conn, 2, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x00}) // quantity 0
+	sendRawRequest(t, conn, 3, 1, common.FuncReadCoils, []byte{0x00, 0x00, 0x00, 0x00})
+
+	deadline := time.After(time.Second)
+	var details []string
+	for len(details) < 3 {
+		select {
+		case event := <-events:
+			if event.Type == common.EventRequestFailed {
+				details = append(details, event.Detail)
+			}
+		case <-deadline:
+			t.Fatalf("Expected 3 failed requests, got %d", len(details))
+		}
+	}
+	if details[0] != "address=65520 quantity=32" || details[1] != "address=0 quantity=0" {
+		t.Errorf("Unexpected event details %q", details)
+	}
+
+	counts := srv.ExceptionCounts()
+	if counts[common.ExceptionDataAddressNotAvailable] != 1 || counts[common.ExceptionInvalidDataValue] != 2 {
+		t.Errorf("Unexpected exception counts %v", counts)
+	}
+}
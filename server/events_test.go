@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestTCPServer_Events(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0))
+	events := srv.Events()
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	sendRawRequest(t, conn, 1, 3, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+	sendRawRequest(t, conn, 2, 3, common.FunctionCode(0x41), nil)
+	conn.Close()
+
+	expected := []common.EventType{common.EventConnected, common.EventRequestFailed, common.EventDisconnected}
+	for _, eventType := range expected {
+		select {
+		case event := <-events:
+			if event.Type != eventType {
+				t.Fatalf("Expected %s, got %s", eventType, event)
+			}
+			if event.RemoteAddr != conn.LocalAddr().String() {
+				t.Errorf("Expected remote address %s, got %s", conn.LocalAddr(), event.RemoteAddr)
+			}
+			if eventType == common.EventRequestFailed &&
+				(event.UnitID != 3 || !common.IsFunctionNotSupportedError(event.Err)) {
+				t.Errorf("Unexpected request failure event %s", event)
+			}
+			if eventType == common.EventRequestFailed && event.CorrelationID != conn.LocalAddr().String()+"#2" {
+				t.Errorf("Expected the request's correlation ID, got %q", event.CorrelationID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %s", eventType)
+		}
+	}
+}
+
+func TestTCPServer_HandlerCorrelationID(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0))
+	ids := make(chan string, 1)
+	srv.SetHandler(common.FunctionCode(0x41), func(ctx context.Context, req common.Request) (common.Response, error) {
+		ids <- common.CorrelationID(ctx)
+		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionFunctionCodeNotSupported)
+	})
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	sendRawRequest(t, conn, 9, 1, common.FunctionCode(0x41), nil)
+
+	if id := <-ids; id != conn.LocalAddr().String()+"#9" {
+		t.Errorf("Expected the handler's ctx to carry the correlation ID, got %q", id)
+	}
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ForcedValue is a value forced with ForcedValuesOverlay.Force. For coils and
+// discrete inputs Value is 1 (on) or 0 (off).
+type ForcedValue struct {
+	Table   common.Table
+	Address common.Address
+	Value   uint16
+	Reason  string
+	Since   time.Time
+}
+
+// String describes the force, for example "HoldingRegisters 100=42 (test)"
+func (f ForcedValue) String() string {
+	s := fmt.Sprintf("%s %d=%d", f.Table, f.Address, f.Value)
+	if f.Reason != "" {
+		s += " (" + f.Reason + ")"
+	}
+	return s
+}
+
+// forceKey identifies a forced address
+type forceKey struct {
+	table   common.Table
+	address common.Address
+}
+
+// ForcedValuesOverlay wraps a DataStore and lets operators force single
+// coils, discrete inputs and registers to fixed values, like the force
+// tables of PLC programming tools. Reads return the forced values whatever
+// the wrapped store holds. Writes still reach the wrapped store, so its
+// value shows again once the force is cleared, but they do not change what
+// clients read while the force is active.
+//
+// Every Force and Clear is reported on Events as EventValueForced or
+// EventForceCleared, with the correlation ID of the ctx passed, for an
+// audit trail of who forced what.
+type ForcedValuesOverlay struct {
+	store  common.DataStore
+	now    func() time.Time
+	events common.EventStream
+
+	mu     sync.RWMutex
+	forced map[forceKey]ForcedValue
+}
+
+// NewForcedValuesOverlay creates a forcing overlay around store
+func NewForcedValuesOverlay(store common.DataStore) *ForcedValuesOverlay {
+	return &ForcedValuesOverlay{
+		store:  store,
+		now:    time.Now,
+		forced: make(map[forceKey]ForcedValue),
+	}
+}
+
+// Force forces the value of one address of table, which must be a single
+// table. The address must be served by the wrapped store if it validates
+// ranges. Forcing an address again replaces the previous force.
+func (o *ForcedValuesOverlay) Force(ctx context.Context, table common.Table, address common.Address, value uint16, reason string) error {
+	switch table {
+	case common.TableCoils, common.TableDiscreteInputs:
+		if value > 1 {
+			return fmt.Errorf("%w: %d for a bit", common.ErrInvalidValue, value)
+		}
+	case common.TableHoldingRegisters, common.TableInputRegisters:
+	default:
+		return fmt.Errorf("forcing %s: a single table is required", table)
+	}
+	if err := o.ValidateRange(ctx, table, address, 1); err != nil {
+		return err
+	}
+
+	force := ForcedValue{Table: table, Address: address, Value: value, Reason: reason, Since: o.now()}
+	o.mu.Lock()
+	o.forced[forceKey{table, address}] = force
+	o.mu.Unlock()
+
+	o.events.Emit(common.Event{
+		Type:          common.EventValueForced,
+		CorrelationID: common.CorrelationID(ctx),
+		Detail:        force.String(),
+	})
+	return nil
+}
+
+// Clear removes the force of one address and reports whether there was one
+func (o *ForcedValuesOverlay) Clear(ctx context.Context, table common.Table, address common.Address) bool {
+	key := forceKey{table, address}
+	o.mu.Lock()
+	force, ok := o.forced[key]
+	delete(o.forced, key)
+	o.mu.Unlock()
+
+	if ok {
+		o.cleared(ctx, force)
+	}
+	return ok
+}
+
+// ClearAll removes every force and returns the number removed
+func (o *ForcedValuesOverlay) ClearAll(ctx context.Context) int {
+	o.mu.Lock()
+	forced := o.forced
+	o.forced = make(map[forceKey]ForcedValue)
+	o.mu.Unlock()
+
+	for _, force := range sortForces(forced) {
+		o.cleared(ctx, force)
+	}
+	return len(forced)
+}
+
+// cleared reports a removed force
+func (o *ForcedValuesOverlay) cleared(ctx context.Context, force ForcedValue) {
+	o.events.Emit(common.Event{
+		Type:          common.EventForceCleared,
+		CorrelationID: common.CorrelationID(ctx),
+		Detail:        force.String(),
+	})
+}
+
+// Forced lists the active forces ordered by table and address
+func (o *ForcedValuesOverlay) Forced() []ForcedValue {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return sortForces(o.forced)
+}
+
+// sortForces returns the forces of a map ordered by table and address
+func sortForces(forced map[forceKey]ForcedValue) []ForcedValue {
+	list := make([]ForcedValue, 0, len(forced))
+	for _, force := range forced {
+		list = append(list, force)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Table != list[b].Table {
+			return list[a].Table < list[b].Table
+		}
+		return list[a].Address < list[b].Address
+	})
+	return list
+}
+
+// Events returns a channel of EventValueForced and EventForceCleared audit
+// events. Events are only collected once Events has been called.
+func (o *ForcedValuesOverlay) Events() <-chan common.Event {
+	return o.events.Channel()
+}
+
+// AllEvents returns an iterator over the events of Events, ending when ctx
+// is done or the loop breaks
+func (o *ForcedValuesOverlay) AllEvents(ctx context.Context) iter.Seq[common.Event] {
+	return o.events.All(ctx)
+}
+
+// forcedBit and forcedWord convert a forced value to a table's value type
+func forcedBit(value uint16) bool    { return value != 0 }
+func forcedWord(value uint16) uint16 { return value }
+
+// overlayForced replaces the forced values of a read result
+func overlayForced[T any](o *ForcedValuesOverlay, table common.Table, address common.Address, values []T, convert func(uint16) T) []T {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if len(o.forced) == 0 {
+		return values
+	}
+	values = copyValues(values)
+	for i := range values {
+		if force, ok := o.forced[forceKey{table, address + common.Address(i)}]; ok {
+			values[i] = convert(force.Value)
+		}
+	}
+	return values
+}
+
+// ReadCoils reads coils from the wrapped store with forced coils applied
+func (o *ForcedValuesOverlay) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	values, err := o.store.ReadCoils(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(o, common.TableCoils, address, values, forcedBit), nil
+}
+
+// ReadDiscreteInputs reads discrete inputs from the wrapped store with forced
+// inputs applied
+func (o *ForcedValuesOverlay) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	values, err := o.store.ReadDiscreteInputs(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(o, common.TableDiscreteInputs, address, values, forcedBit), nil
+}
+
+// ReadHoldingRegisters reads holding registers from the wrapped store with
+// forced registers applied
+func (o *ForcedValuesOverlay) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	values, err := o.store.ReadHoldingRegisters(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(o, common.TableHoldingRegisters, address, values, forcedWord), nil
+}
+
+// ReadInputRegisters reads input registers from the wrapped store with forced
+// registers applied
+func (o *ForcedValuesOverlay) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	values, err := o.store.ReadInputRegisters(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(o, common.TableInputRegisters, address, values, forcedWord), nil
+}
+
+// WriteSingleCoil writes to the wrapped store
+func (o *ForcedValuesOverlay) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	return o.store.WriteSingleCoil(ctx, address, value)
+}
+
+// WriteSingleRegister writes to the wrapped store
+func (o *ForcedValuesOverlay) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	return o.store.WriteSingleRegister(ctx, address, value)
+}
+
+// WriteMultipleCoils writes to the wrapped store
+func (o *ForcedValuesOverlay) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	return o.store.WriteMultipleCoils(ctx, address, values)
+}
+
+// WriteMultipleRegisters writes to the wrapped store
+func (o *ForcedValuesOverlay) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	return o.store.WriteMultipleRegisters(ctx, address, values)
+}
+
+// wrapped returns the wrapped store
+func (o *ForcedValuesOverlay) wrapped() common.DataStore {
+	return o.store
+}
+
+// ValidateRange delegates to the wrapped store if it validates ranges
+func (o *ForcedValuesOverlay) ValidateRange(ctx context.Context, table common.Table, address common.Address, quantity common.Quantity) error {
+	if validator, ok := o.store.(common.RangeValidator); ok {
+		return validator.ValidateRange(ctx, table, address, quantity)
+	}
+	return nil
+}
+
+// Atomically calls fn with a view of a transaction of the wrapped store that
+// applies the active forces to reads. Writes are only rolled back if the
+// wrapped store implements common.TransactionalDataStore.
+func (o *ForcedValuesOverlay) Atomically(ctx context.Context, fn func(tx common.DataStore) error) error {
+	return atomicallyOn(ctx, o.store, func(tx common.DataStore) error {
+		return fn(&forcedTx{overlay: o, tx: tx})
+	})
+}
+
+// forcedTx is the view passed to Atomically callbacks: reads of the
+// transaction with the overlay's forces applied
+type forcedTx struct {
+	overlay *ForcedValuesOverlay
+	tx      common.DataStore
+}
+
+func (t *forcedTx) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	values, err := t.tx.ReadCoils(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(t.overlay, common.TableCoils, address, values, forcedBit), nil
+}
+
+func (t *forcedTx) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	values, err := t.tx.ReadDiscreteInputs(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(t.overlay, common.TableDiscreteInputs, address, values, forcedBit), nil
+}
+
+func (t *forcedTx) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	values, err := t.tx.ReadHoldingRegisters(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(t.overlay, common.TableHoldingRegisters, address, values, forcedWord), nil
+}
+
+func (t *forcedTx) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	values, err := t.tx.ReadInputRegisters(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return overlayForced(t.overlay, common.TableInputRegisters, address, values, forcedWord), nil
+}
+
+func (t *forcedTx) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	return t.tx.WriteSingleCoil(ctx, address, value)
+}
+
+func (t *forcedTx) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	return t.tx.WriteSingleRegister(ctx, address, value)
+}
+
+func (t *forcedTx) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	return t.tx.WriteMultipleCoils(ctx, address, values)
+}
+
+func (t *forcedTx) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	return t.tx.WriteMultipleRegisters(ctx, address, values)
+}
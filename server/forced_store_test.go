@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestForcedValuesOverlay(t *testing.T) {
+	backend := NewMemoryStore(WithMemoryStoreRange(common.TableAll, 0, 99))
+	backend.SetHoldingRegister(10, 1)
+	overlay := NewForcedValuesOverlay(backend)
+	events := overlay.Events()
+	ctx := common.WithCorrelationID(context.Background(), "operator-7")
+
+	if err := overlay.Force(ctx, common.TableHoldingRegisters, 11, 42, "commissioning"); err != nil {
+		t.Fatalf("Force failed: %v", err)
+	}
+	if err := overlay.Force(ctx, common.TableCoils, 3, 1, ""); err != nil {
+		t.Fatalf("Force failed: %v", err)
+	}
+
+	// Forced values mask the backend and Modbus writes
+	if err := overlay.WriteMultipleRegisters(ctx, 10, []common.RegisterValue{5, 6}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	registers, err := overlay.ReadHoldingRegisters(ctx, 10, 2)
+	if err != nil || registers[0] != 5 || registers[1] != 42 {
+		t.Errorf("Expected [5 42], got %v, %v", registers, err)
+	}
+	coils, err := overlay.ReadCoils(ctx, 2, 2)
+	if err != nil || coils[0] || !coils[1] {
+		t.Errorf("Expected [false true], got %v, %v", coils, err)
+	}
+
+	forced := overlay.Forced()
+	if len(forced) != 2 || forced[0].Table != common.TableCoils || forced[1].Value != 42 {
+		t.Errorf("Unexpected force list %v", forced)
+	}
+
+	// The backend value shows again once the force is cleared
+	if !overlay.Clear(ctx, common.TableHoldingRegisters, 11) {
+		t.Error("Expected Clear to remove the force")
+	}
+	if registers, _ := overlay.ReadHoldingRegisters(ctx, 11, 1); registers[0] != 6 {
+		t.Errorf("Expected the written value 6 after clearing, got %d", registers[0])
+	}
+	if n := overlay.ClearAll(ctx); n != 1 {
+		t.Errorf("Expected ClearAll to remove 1 force, got %d", n)
+	}
+
+	expected := []struct {
+		eventType common.EventType
+		detail    string
+	}{
+		{common.EventValueForced, "HoldingRegisters 11=42 (commissioning)"},
+		{common.EventValueForced, "Coils 3=1"},
+		{common.EventForceCleared, "HoldingRegisters 11=42 (commissioning)"},
+		{common.EventForceCleared, "Coils 3=1"},
+	}
+	for _, want := range expected {
+		event := <-events
+		if event.Type != want.eventType || event.Detail != want.detail || event.CorrelationID != "operator-7" {
+			t.Errorf("Expected %s %q by operator-7, got %s", want.eventType, want.detail, event)
+		}
+	}
+
+	// Invalid forces are rejected
+	if err := overlay.Force(ctx, common.TableCoils, 3, 2, ""); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for a bit value of 2, got %v", err)
+	}
+	if err := overlay.Force(ctx, common.TableInputRegisters, 500, 1, ""); !errors.Is(err, common.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress outside the backend's range, got %v", err)
+	}
+	if err := overlay.Force(ctx, common.TableAll, 1, 1, ""); err == nil {
+		t.Error("Expected an error forcing several tables")
+	}
+}
+
+func TestForcedValuesOverlay_AtomicallyRollsBack(t *testing.T) {
+	backing := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+	backing.SetHoldingRegister(0, 7)
+	overlay := NewForcedValuesOverlay(backing)
+	ctx := context.Background()
+	if err := overlay.Force(ctx, common.TableHoldingRegisters, 1, 42, ""); err != nil {
+		t.Fatalf("Force failed: %v", err)
+	}
+
+	err := overlay.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, 0, []common.RegisterValue{5, 6}); err != nil {
+			return err
+		}
+		// Forces apply to reads of the view
+		if values, _ := tx.ReadHoldingRegisters(ctx, 0, 2); values[0] != 5 || values[1] != 42 {
+			t.Errorf("Expected [5 42] inside the transaction, got %v", values)
+		}
+		return tx.WriteMultipleRegisters(ctx, 99, []common.RegisterValue{1, 2})
+	})
+	if !errors.Is(err, common.ErrInvalidAddress) {
+		t.Fatalf("Expected ErrInvalidAddress, got %v", err)
+	}
+	if value, _ := backing.GetHoldingRegister(0); value != 7 {
+		t.Errorf("Expected register 0 restored to 7, got %d", value)
+	}
+	if _, ok := backing.GetHoldingRegister(1); ok {
+		t.Error("Expected register 1 to be removed again")
+	}
+}
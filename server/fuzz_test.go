@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/protocol"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// FuzzDispatchRequest feeds the server the request PDUs a hostile or buggy
+// client could send: none may panic, answer with more than a PDU, or reach a
+// handler without passing protocol.Validate. Run it with:
+//
+//	go test ./server -run '^$' -fuzz FuzzDispatchRequest
+func FuzzDispatchRequest(f *testing.F) {
+	f.Add(byte(1), byte(common.FuncReadCoils), []byte{0x00, 0x00, 0x07, 0xD0})
+	f.Add(byte(1), byte(common.FuncReadHoldingRegisters), []byte{0xFF, 0xF0, 0x00, 0x7D})
+	f.Add(byte(1), byte(common.FuncWriteMultipleCoils), []byte{0x00, 0x13, 0x00, 0x0A, 0x02, 0xCD, 0x01})
+	f.Add(byte(1), byte(common.FuncWriteMultipleRegisters), []byte{0x00, 0x01, 0x00, 0x02, 0xFF, 0x00, 0x0A})
+	f.Add(byte(1), byte(common.FuncReadWriteMultipleRegisters), []byte{0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x01, 0x02, 0x00, 0xFF})
+	f.Add(byte(1), byte(common.FuncReadFileRecord), []byte{0x07, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02})
+	f.Add(byte(1), byte(common.FuncWriteFileRecord), []byte{0x09, 0x06, 0x00, 0x04, 0x00, 0x07, 0xFF, 0xFF, 0x06, 0xAF})
+	f.Add(byte(1), byte(common.FuncMaskWriteRegister), []byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x25})
+	f.Add(byte(1), byte(common.FuncReadFIFOQueue), []byte{0x04, 0xDE})
+	f.Add(byte(1), byte(common.FuncReadDeviceIdentification), []byte{0x0E, 0x01, 0x00})
+	f.Add(byte(0), byte(common.FuncWriteSingleCoil), []byte{0x00, 0x01, 0xFF, 0x00})
+
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerLogger(logging.NewNoopLogger()))
+	f.Fuzz(func(t *testing.T, unitID, functionCode byte, data []byte) {
+		request := transport.NewRequest(common.UnitID(unitID), common.FunctionCode(functionCode), data)
+		response, err := srv.dispatchRequest(context.Background(), request)
+		if err != nil {
+			var modbusErr *common.ModbusError
+			if !errors.As(err, &modbusErr) {
+				t.Fatalf("Expected a Modbus exception, got %v", err)
+			}
+			return
+		}
+		if verr := protocol.Validate(request.GetPDU()); verr != nil {
+			t.Fatalf("Malformed request answered normally: %v", verr)
+		}
+		if size := 1 + len(response.GetPDU().Data); size > common.MaxPDULength {
+			t.Fatalf("Response PDU of %d bytes", size)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// GatewayTarget is a downstream device the gateway forwards requests to. The
+// clients of package client implement it with their Send method and address
+// the device by their own unit ID.
+type GatewayTarget interface {
+	Send(ctx context.Context, functionCode common.FunctionCode, data []byte) (common.Response, error)
+}
+
+// gatewayRoute maps the inclusive unit ID range [first, last] onto targets
+type gatewayRoute struct {
+	first, last common.UnitID
+	target      func(common.UnitID) GatewayTarget
+}
+
+// Gateway bridges Modbus TCP masters to downstream devices: each request is
+// forwarded, PDU unchanged, to the target its unit ID routes to, and the
+// target's answer, exception responses included, is returned to the master.
+// Install it with WithServerGateway, for example:
+//
+//	plc := client.NewTCPClient("10.0.0.10")
+//	upstream := client.NewTCPClient("10.0.0.20") // a TCP to RTU converter
+//	gateway := NewGateway(
+//		WithGatewayUnit(1, plc),
+//		WithGatewayUnits(10, 20, func(unitID common.UnitID) GatewayTarget {
+//			return upstream.ForUnit(unitID)
+//		}),
+//	)
+//	srv := NewTCPServer("", WithServerGateway(gateway))
+//
+// Single units take precedence over ranges, and ranges are matched in the
+// order they were added. A unit ID without a route is answered with exception
+// 0x0A (Gateway Path Unavailable); a target that fails without a response,
+// for example after a timeout, with 0x0B (Gateway Target Device Failed To
+// Respond).
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Codes 0x0A, 0x0B)
+type Gateway struct {
+	routes   []gatewayRoute
+	fallback GatewayTarget
+
+	mu    sync.Mutex
+	units map[common.UnitID]GatewayTarget
+}
+
+// GatewayOption is a function that configures a Gateway
+type GatewayOption func(*Gateway)
+
+// WithGatewayUnit routes requests addressed to unitID to target
+func WithGatewayUnit(unitID common.UnitID, target GatewayTarget) GatewayOption {
+	return func(g *Gateway) {
+		g.units[unitID] = target
+	}
+}
+
+// WithGatewayUnits routes requests addressed to the unit IDs in the inclusive
+// range [first, last] to the target returned by target, which is called once
+// per unit ID on its first request. Returning nil leaves the unit without a
+// route.
+func WithGatewayUnits(first, last common.UnitID, target func(common.UnitID) GatewayTarget) GatewayOption {
+	return func(g *Gateway) {
+		g.routes = append(g.routes, gatewayRoute{first: first, last: last, target: target})
+	}
+}
+
+// WithGatewayDefault routes requests addressed to unit IDs without another
+// route to target
+func WithGatewayDefault(target GatewayTarget) GatewayOption {
+	return func(g *Gateway) {
+		g.fallback = target
+	}
+}
+
+// NewGateway creates a gateway with the given routes
+func NewGateway(options ...GatewayOption) *Gateway {
+	g := &Gateway{units: make(map[common.UnitID]GatewayTarget)}
+	for _, option := range options {
+		option(g)
+	}
+	return g
+}
+
+// target returns the target unitID routes to, or nil
+func (g *Gateway) target(unitID common.UnitID) GatewayTarget {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if target, ok := g.units[unitID]; ok {
+		return target
+	}
+	for _, route := range g.routes {
+		if unitID >= route.first && unitID <= route.last {
+			if target := route.target(unitID); target != nil {
+				g.units[unitID] = target
+				return target
+			}
+			break
+		}
+	}
+	return g.fallback
+}
+
+// Handle forwards a request to its target and returns the target's response.
+// It is a common.HandlerFunc, so a server can also forward only some function
+// codes with SetHandler.
+func (g *Gateway) Handle(ctx context.Context, req common.Request) (common.Response, error) {
+	pdu := req.GetPDU()
+	target := g.target(req.GetUnitID())
+	if target == nil {
+		return nil, common.NewModbusError(pdu.FunctionCode, common.ExceptionGatewayPathUnavailable)
+	}
+
+	response, err := target.Send(ctx, pdu.FunctionCode, pdu.Data)
+	if err != nil {
+		return nil, gatewayError(pdu.FunctionCode, err)
+	}
+	if response.IsException() {
+		return nil, common.NewModbusError(pdu.FunctionCode, response.GetException())
+	}
+	return transport.NewResponse(
+		req.GetTransactionID(),
+		req.GetUnitID(),
+		pdu.FunctionCode,
+		response.GetPDU().Data,
+	), nil
+}
+
+// gatewayError passes exceptions of the target on and maps every other
+// failure to Gateway Target Device Failed To Respond
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Code 0x0B)
+func gatewayError(functionCode common.FunctionCode, err error) error {
+	var modbusErr *common.ModbusError
+	if errors.As(err, &modbusErr) {
+		return common.NewModbusError(functionCode, modbusErr.ExceptionCode)
+	}
+	return common.NewModbusError(functionCode, common.ExceptionGatewayTargetNoResponse)
+}
+
+// WithServerGateway makes the server forward requests of every function code
+// through gateway instead of serving them from its data store. Unit policies
+// (see WithServerUnitPolicy) are still enforced before forwarding.
+func WithServerGateway(gateway *Gateway) TCPServerOption {
+	return func(s *TCPServer) {
+		s.gateway = gateway
+	}
+}
+
+// setupGatewayHandlers replaces every handler with the gateway's
+func (s *TCPServer) setupGatewayHandlers() {
+	for functionCode := common.FunctionCode(1); functionCode < common.FunctionCode(common.ExceptionBit); functionCode++ {
+		s.SetHandler(functionCode, s.gateway.Handle)
+	}
+}
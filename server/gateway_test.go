@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// gatewayDevice is a GatewayTarget answering every request with its data, or
+// failing with err
+type gatewayDevice struct {
+	err      error
+	requests []common.FunctionCode
+}
+
+func (d *gatewayDevice) Send(ctx context.Context, functionCode common.FunctionCode, data []byte) (common.Response, error) {
+	d.requests = append(d.requests, functionCode)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return test.NewMockResponse(1, 1, functionCode, data), nil
+}
+
+func TestTCPServer_Gateway(t *testing.T) {
+	plc := &gatewayDevice{}
+	rtu := &gatewayDevice{}
+	var routed []common.UnitID
+	gateway := NewGateway(
+		WithGatewayUnit(1, plc),
+		WithGatewayUnit(2, &gatewayDevice{err: common.NewModbusError(common.FuncReadCoils, common.ExceptionDataAddressNotAvailable)}),
+		WithGatewayUnit(3, &gatewayDevice{err: common.ErrTimeout}),
+		WithGatewayUnits(10, 20, func(unitID common.UnitID) GatewayTarget {
+			routed = append(routed, unitID)
+			if unitID == 20 {
+				return nil
+			}
+			return rtu
+		}),
+	)
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerGateway(gateway))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	cases := []struct {
+		name      string
+		unitID    byte
+		fc        common.FunctionCode
+		data      []byte
+		exception common.ExceptionCode // 0 for success
+	}{
+		{"mask write", 1, common.FuncMaskWriteRegister, []byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x25}, 0},
+		{"user-defined function", 1, common.FunctionCode(0x41), []byte{0x01, 0x02}, 0},
+		{"downstream exception", 2, common.FuncReadCoils, []byte{0x00, 0x00, 0x00, 0x01}, common.ExceptionDataAddressNotAvailable},
+		{"downstream timeout", 3, common.FuncReadCoils, []byte{0x00, 0x00, 0x00, 0x01}, common.ExceptionGatewayTargetNoResponse},
+		{"routed unit", 12, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}, 0},
+		{"routed unit again", 12, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}, 0},
+		{"declined unit", 20, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}, common.ExceptionGatewayPathUnavailable},
+		{"unknown unit", 30, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}, common.ExceptionGatewayPathUnavailable},
+	}
+
+	for i, tc := range cases {
+		pdu := sendRawRequest(t, conn, uint16(i+1), tc.unitID, tc.fc, tc.data)
+		if tc.exception == 0 {
+			if pdu[0] != byte(tc.fc) || !slices.Equal(pdu[1:], tc.data) {
+				t.Errorf("%s: expected the forwarded answer, got % X", tc.name, pdu)
+			}
+			continue
+		}
+		if pdu[0] != byte(tc.fc)|common.ExceptionBit || common.ExceptionCode(pdu[1]) != tc.exception {
+			t.Errorf("%s: expected exception %d, got % X", tc.name, tc.exception, pdu)
+		}
+	}
+
+	// Requests are forwarded as they are, not split into reads and writes
+	if want := []common.FunctionCode{common.FuncMaskWriteRegister, 0x41}; !slices.Equal(plc.requests, want) {
+		t.Errorf("Expected requests %v, got %v", want, plc.requests)
+	}
+	if want := []common.UnitID{12, 20}; !slices.Equal(routed, want) {
+		t.Errorf("Expected one route lookup per unit %v, got %v", want, routed)
+	}
+	if len(rtu.requests) != 2 {
+		t.Errorf("Expected 2 requests for the routed unit, got %d", len(rtu.requests))
+	}
+}
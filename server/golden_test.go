@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/protocol/prototest"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// preload sets the values a vector reads in the store
+func preload(store *MemoryStore, v prototest.Vector) {
+	if v.Request.FunctionCode() == common.FuncReadFileRecord {
+		for _, r := range v.Records {
+			store.WriteFileRecord(context.Background(), r.File, r.Record, r.Values)
+		}
+		return
+	}
+	if v.Request.FunctionCode() == common.FuncReadFIFOQueue {
+		// The queue count, then the queued values
+		store.SetHoldingRegister(v.Address, uint16(len(v.Read)))
+		for i, value := range v.Read {
+			store.SetHoldingRegister(v.Address+1+common.Address(i), value)
+		}
+		return
+	}
+	for i, value := range v.Read {
+		address := v.Address + common.Address(i)
+		switch v.Request.FunctionCode() {
+		case common.FuncReadCoils:
+			store.SetCoil(address, value != 0)
+		case common.FuncReadDiscreteInputs:
+			store.SetDiscreteInput(address, value != 0)
+		case common.FuncReadHoldingRegisters, common.FuncReadWriteMultipleRegisters, common.FuncMaskWriteRegister:
+			store.SetHoldingRegister(address, value)
+		case common.FuncReadInputRegisters:
+			store.SetInputRegister(address, value)
+		}
+	}
+}
+
+// written returns the values a vector wrote to the store
+func written(store *MemoryStore, v prototest.Vector) []uint16 {
+	address := v.Address
+	if v.Request.FunctionCode() == common.FuncReadWriteMultipleRegisters {
+		address = v.WriteAddress
+	}
+	values := make([]uint16, len(v.Written))
+	for i := range values {
+		switch v.Request.FunctionCode() {
+		case common.FuncWriteSingleCoil, common.FuncWriteMultipleCoils:
+			if coil, _ := store.GetCoil(address + common.Address(i)); coil {
+				values[i] = 1
+			}
+		default:
+			values[i], _ = store.GetHoldingRegister(address + common.Address(i))
+		}
+	}
+	return values
+}
+
+func TestTCPServer_GoldenVectors(t *testing.T) {
+	for _, v := range prototest.Vectors() {
+		if v.ClientOnly {
+			continue
+		}
+		t.Run(v.Name, func(t *testing.T) {
+			store := NewMemoryStore()
+			preload(store, v)
+			server := NewTCPServer("127.0.0.1:0")
+			server.WithDataStore(store)
+
+			request := transport.NewRequest(1, v.Request.FunctionCode(), v.Request.Data())
+			var pdu []byte
+			response, err := server.dispatchRequest(context.Background(), request)
+			var modbusErr *common.ModbusError
+			switch {
+			case errors.As(err, &modbusErr):
+				pdu = []byte{byte(modbusErr.FunctionCode) | common.ExceptionBit, byte(modbusErr.ExceptionCode)}
+			case err != nil:
+				t.Fatalf("Dispatch failed: %v", err)
+			default:
+				pdu = append([]byte{byte(response.GetPDU().FunctionCode)}, response.GetPDU().Data...)
+			}
+			if !bytes.Equal(pdu, v.Response) {
+				t.Fatalf("Expected response %s, got % X (source: %s)", v.Response, pdu, v.Source)
+			}
+			if got := written(store, v); !slices.Equal(got, v.Written) {
+				t.Errorf("Expected %v written to the store, got %v", v.Written, got)
+			}
+			if v.Request.FunctionCode() == common.FuncWriteFileRecord {
+				for _, r := range v.Records {
+					if got, _ := store.ReadFileRecord(context.Background(), r.File, r.Record, common.Quantity(len(r.Values))); !slices.Equal(got, r.Values) {
+						t.Errorf("Expected %v written to file %d record %d, got %v", r.Values, r.File, r.Record, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 )
@@ -28,18 +29,60 @@ type MemoryStore struct {
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (Input Register)
 	inputRegisters   map[common.Address]common.InputRegisterValue
 
+	// File records (extended memory) - Function codes 0x14 (read) and 0x15 (write)
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+	fileRecords      map[fileRecordKey]common.RegisterValue
+
+	// Valid address ranges per table; a table without ranges accepts every address
+	bounds           map[common.Table][]addressRange
+
 	// Mutex to protect concurrent access to maps
 	mu               sync.RWMutex
+
+	// Revision counts writes, see Revision; shared with Atomically views
+	revision         *atomic.Uint64
+}
+
+// addressRange is an inclusive range of addresses
+type addressRange struct {
+	start, end int
+}
+
+// MemoryStoreOption is a function that configures a MemoryStore
+type MemoryStoreOption func(*MemoryStore)
+
+// WithMemoryStoreRange declares [start, end] (inclusive) as valid for the
+// given tables. Once a table has at least one range, requests touching
+// addresses outside all of its ranges fail with ErrInvalidAddress, which the
+// server reports as exception 0x02. Tables without ranges accept every address.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (MODBUS Data model)
+func WithMemoryStoreRange(tables common.Table, start, end common.Address) MemoryStoreOption {
+	return func(s *MemoryStore) {
+		for _, table := range []common.Table{common.TableCoils, common.TableDiscreteInputs, common.TableHoldingRegisters, common.TableInputRegisters} {
+			if tables&table != 0 {
+				s.bounds[table] = append(s.bounds[table], addressRange{start: int(start), end: int(end)})
+			}
+		}
+	}
 }
 
 // NewMemoryStore creates a new memory-based data store
-func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{
+func NewMemoryStore(options ...MemoryStoreOption) *MemoryStore {
+	store := &MemoryStore{
 		coils:            make(map[common.Address]common.CoilValue),
 		discreteInputs:   make(map[common.Address]common.DiscreteInputValue),
 		holdingRegisters: make(map[common.Address]common.RegisterValue),
 		inputRegisters:   make(map[common.Address]common.InputRegisterValue),
+		fileRecords:      make(map[fileRecordKey]common.RegisterValue),
+		bounds:           make(map[common.Table][]addressRange),
+		revision:         &atomic.Uint64{},
+	}
+
+	for _, option := range options {
+		option(store)
 	}
+
+	return store
 }
 
 // ReadCoils reads coil values from the data store
@@ -52,6 +95,10 @@ func (s *MemoryStore) ReadCoils(ctx context.Context, address common.Address, qua
 		return nil, common.ErrInvalidQuantity
 	}
 
+	if err := s.ValidateRange(ctx, common.TableCoils, address, quantity); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -77,6 +124,10 @@ func (s *MemoryStore) ReadDiscreteInputs(ctx context.Context, address common.Add
 		return nil, common.ErrInvalidQuantity
 	}
 
+	if err := s.ValidateRange(ctx, common.TableDiscreteInputs, address, quantity); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -102,6 +153,10 @@ func (s *MemoryStore) ReadHoldingRegisters(ctx context.Context, address common.A
 		return nil, common.ErrInvalidQuantity
 	}
 
+	if err := s.ValidateRange(ctx, common.TableHoldingRegisters, address, quantity); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -127,6 +182,10 @@ func (s *MemoryStore) ReadInputRegisters(ctx context.Context, address common.Add
 		return nil, common.ErrInvalidQuantity
 	}
 
+	if err := s.ValidateRange(ctx, common.TableInputRegisters, address, quantity); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -146,10 +205,15 @@ func (s *MemoryStore) ReadInputRegisters(ctx context.Context, address common.Add
 // Implements function code 0x05 (Write Single Coil) data access
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.5 (Write Single Coil)
 func (s *MemoryStore) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	if err := s.ValidateRange(ctx, common.TableCoils, address, 1); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.coils[address] = value
+	s.revision.Add(1)
 	return nil
 }
 
@@ -157,10 +221,15 @@ func (s *MemoryStore) WriteSingleCoil(ctx context.Context, address common.Addres
 // Implements function code 0x06 (Write Single Register) data access
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.6 (Write Single Register)
 func (s *MemoryStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	if err := s.ValidateRange(ctx, common.TableHoldingRegisters, address, 1); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.holdingRegisters[address] = value
+	s.revision.Add(1)
 	return nil
 }
 
@@ -174,6 +243,10 @@ func (s *MemoryStore) WriteMultipleCoils(ctx context.Context, address common.Add
 		return common.ErrInvalidQuantity
 	}
 
+	if err := s.ValidateRange(ctx, common.TableCoils, address, common.Quantity(len(values))); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -181,6 +254,7 @@ func (s *MemoryStore) WriteMultipleCoils(ctx context.Context, address common.Add
 		addr := address + common.Address(i)
 		s.coils[addr] = value
 	}
+	s.revision.Add(1)
 
 	return nil
 }
@@ -195,6 +269,10 @@ func (s *MemoryStore) WriteMultipleRegisters(ctx context.Context, address common
 		return common.ErrInvalidQuantity
 	}
 
+	if err := s.ValidateRange(ctx, common.TableHoldingRegisters, address, common.Quantity(len(values))); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -202,7 +280,60 @@ func (s *MemoryStore) WriteMultipleRegisters(ctx context.Context, address common
 		addr := address + common.Address(i)
 		s.holdingRegisters[addr] = value
 	}
+	s.revision.Add(1)
+
+	return nil
+}
+
+// fileRecordKey identifies one record of a file
+type fileRecordKey struct {
+	file, record uint16
+}
+
+// checkFileRecords checks that length records starting at record exist
+func checkFileRecords(record uint16, length int) error {
+	if length == 0 {
+		return common.ErrInvalidQuantity
+	}
+	if int(record)+length-1 > common.MaxFileRecordNumber {
+		return common.ErrInvalidAddress
+	}
+	return nil
+}
+
+// ReadFileRecord reads length records of a file; records never written read
+// as 0. Every file has records 0 to 9999.
+// Implements function code 0x14 (Read File Record) data access
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+func (s *MemoryStore) ReadFileRecord(ctx context.Context, fileNumber, recordNumber uint16, length common.Quantity) ([]common.RegisterValue, error) {
+	if err := checkFileRecords(recordNumber, int(length)); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]common.RegisterValue, length)
+	for i := range values {
+		values[i] = s.fileRecords[fileRecordKey{file: fileNumber, record: recordNumber + uint16(i)}]
+	}
+	return values, nil
+}
+
+// WriteFileRecord writes values to the records of a file starting at recordNumber
+// Implements function code 0x15 (Write File Record) data access
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Write File Record)
+func (s *MemoryStore) WriteFileRecord(ctx context.Context, fileNumber, recordNumber uint16, values []common.RegisterValue) error {
+	if err := checkFileRecords(recordNumber, len(values)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	for i, value := range values {
+		s.fileRecords[fileRecordKey{file: fileNumber, record: recordNumber + uint16(i)}] = value
+	}
 	return nil
 }
 
@@ -221,6 +352,7 @@ func (s *MemoryStore) SetCoil(address common.Address, value common.CoilValue) {
 	defer s.mu.Unlock()
 
 	s.coils[address] = value
+	s.revision.Add(1)
 }
 
 // GetDiscreteInput gets a single discrete input value
@@ -238,6 +370,7 @@ func (s *MemoryStore) SetDiscreteInput(address common.Address, value common.Disc
 	defer s.mu.Unlock()
 
 	s.discreteInputs[address] = value
+	s.revision.Add(1)
 }
 
 // GetHoldingRegister gets a single holding register value
@@ -255,6 +388,7 @@ func (s *MemoryStore) SetHoldingRegister(address common.Address, value common.Re
 	defer s.mu.Unlock()
 
 	s.holdingRegisters[address] = value
+	s.revision.Add(1)
 }
 
 // GetInputRegister gets a single input register value
@@ -272,6 +406,7 @@ func (s *MemoryStore) SetInputRegister(address common.Address, value common.Inpu
 	defer s.mu.Unlock()
 
 	s.inputRegisters[address] = value
+	s.revision.Add(1)
 }
 
 // DumpRegisters returns a string representation of the memory store's content
@@ -310,4 +445,138 @@ func (s *MemoryStore) DumpRegisters() string {
 	}
 
 	return result
-}
\ No newline at end of file
+}
+
+// ValidateRange reports ErrInvalidAddress if any address of the range lies
+// outside the ranges configured for the table with WithMemoryStoreRange
+func (s *MemoryStore) ValidateRange(ctx context.Context, table common.Table, address common.Address, quantity common.Quantity) error {
+	if int(address)+int(quantity) > 0x10000 {
+		return common.ErrInvalidAddress
+	}
+
+	ranges := s.bounds[table]
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	for addr := int(address); addr < int(address)+int(quantity); addr++ {
+		valid := false
+		for _, r := range ranges {
+			if addr >= r.start && addr <= r.end {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return common.ErrInvalidAddress
+		}
+	}
+	return nil
+}
+
+// Atomically calls fn with a transactional view of the store while holding
+// the store's write lock. If fn returns an error, every write made through
+// the view is rolled back.
+func (s *MemoryStore) Atomically(ctx context.Context, fn func(tx common.DataStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The view shares the maps but has its own mutex, since s.mu is held
+	tx := &memoryTx{view: &MemoryStore{
+		coils:            s.coils,
+		discreteInputs:   s.discreteInputs,
+		holdingRegisters: s.holdingRegisters,
+		inputRegisters:   s.inputRegisters,
+		bounds:           s.bounds,
+		revision:         s.revision,
+	}}
+
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	return nil
+}
+
+// memoryTx is the view passed to Atomically callbacks. It records the
+// previous contents of every address it writes so they can be restored.
+type memoryTx struct {
+	view *MemoryStore
+	undo []func()
+}
+
+// saveCoils records the current state of a range of coils
+func (tx *memoryTx) saveCoils(address common.Address, quantity int) {
+	coils := tx.view.coils
+	for i := 0; i < quantity; i++ {
+		addr := address + common.Address(i)
+		old, existed := coils[addr]
+		tx.undo = append(tx.undo, func() {
+			if existed {
+				coils[addr] = old
+			} else {
+				delete(coils, addr)
+			}
+		})
+	}
+}
+
+// saveRegisters records the current state of a range of holding registers
+func (tx *memoryTx) saveRegisters(address common.Address, quantity int) {
+	registers := tx.view.holdingRegisters
+	for i := 0; i < quantity; i++ {
+		addr := address + common.Address(i)
+		old, existed := registers[addr]
+		tx.undo = append(tx.undo, func() {
+			if existed {
+				registers[addr] = old
+			} else {
+				delete(registers, addr)
+			}
+		})
+	}
+}
+
+// rollback restores every recorded address in reverse order
+func (tx *memoryTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.undo = nil
+}
+
+func (tx *memoryTx) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	return tx.view.ReadCoils(ctx, address, quantity)
+}
+
+func (tx *memoryTx) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	return tx.view.ReadDiscreteInputs(ctx, address, quantity)
+}
+
+func (tx *memoryTx) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	return tx.view.ReadHoldingRegisters(ctx, address, quantity)
+}
+
+func (tx *memoryTx) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	return tx.view.ReadInputRegisters(ctx, address, quantity)
+}
+
+func (tx *memoryTx) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	tx.saveCoils(address, 1)
+	return tx.view.WriteSingleCoil(ctx, address, value)
+}
+
+func (tx *memoryTx) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	tx.saveRegisters(address, 1)
+	return tx.view.WriteSingleRegister(ctx, address, value)
+}
+
+func (tx *memoryTx) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	tx.saveCoils(address, len(values))
+	return tx.view.WriteMultipleCoils(ctx, address, values)
+}
+
+func (tx *memoryTx) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	tx.saveRegisters(address, len(values))
+	return tx.view.WriteMultipleRegisters(ctx, address, values)
+}
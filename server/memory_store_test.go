@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
@@ -297,4 +298,81 @@ func contains(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+func TestMemoryStore_Bounds(t *testing.T) {
+	store := NewMemoryStore(
+		WithMemoryStoreRange(common.TableCoils|common.TableHoldingRegisters, 0, 9),
+		WithMemoryStoreRange(common.TableHoldingRegisters, 100, 109),
+	)
+	ctx := context.Background()
+
+	if _, err := store.ReadHoldingRegisters(ctx, 100, 10); err != nil {
+		t.Errorf("Expected read inside bounds to succeed, got %v", err)
+	}
+	if _, err := store.ReadHoldingRegisters(ctx, 5, 10); err != common.ErrInvalidAddress {
+		t.Errorf("Expected ErrInvalidAddress, got %v", err)
+	}
+	if err := store.WriteSingleCoil(ctx, 10, true); err != common.ErrInvalidAddress {
+		t.Errorf("Expected ErrInvalidAddress, got %v", err)
+	}
+
+	// Tables without ranges accept every address
+	if _, err := store.ReadInputRegisters(ctx, 5000, 10); err != nil {
+		t.Errorf("Expected unbounded table to accept the read, got %v", err)
+	}
+}
+
+func TestMemoryStore_AtomicallyRollsBack(t *testing.T) {
+	store := NewMemoryStore()
+	store.SetHoldingRegister(0, 0x1111)
+	store.SetCoil(1, true)
+	ctx := context.Background()
+
+	failure := errors.New("failure")
+	err := store.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, 0, []common.RegisterValue{0xAAAA, 0xBBBB}); err != nil {
+			return err
+		}
+		if err := tx.WriteMultipleCoils(ctx, 0, []common.CoilValue{true, false}); err != nil {
+			return err
+		}
+
+		// Writes are visible inside the transaction
+		values, err := tx.ReadHoldingRegisters(ctx, 0, 2)
+		if err != nil {
+			return err
+		}
+		if values[0] != 0xAAAA || values[1] != 0xBBBB {
+			t.Errorf("Expected staged values inside the transaction, got %v", values)
+		}
+		return failure
+	})
+	if err != failure {
+		t.Fatalf("Expected the callback error, got %v", err)
+	}
+
+	if value, _ := store.GetHoldingRegister(0); value != 0x1111 {
+		t.Errorf("Expected register 0 restored to 0x1111, got 0x%04X", value)
+	}
+	if _, ok := store.GetHoldingRegister(1); ok {
+		t.Error("Expected register 1 to be removed again")
+	}
+	if value, _ := store.GetCoil(1); !value {
+		t.Error("Expected coil 1 restored to true")
+	}
+	if _, ok := store.GetCoil(0); ok {
+		t.Error("Expected coil 0 to be removed again")
+	}
+
+	// A successful transaction keeps its writes
+	err = store.Atomically(ctx, func(tx common.DataStore) error {
+		return tx.WriteSingleRegister(ctx, 5, 0x5555)
+	})
+	if err != nil {
+		t.Fatalf("Atomically returned error: %v", err)
+	}
+	if value, _ := store.GetHoldingRegister(5); value != 0x5555 {
+		t.Errorf("Expected register 5 to be 0x5555, got 0x%04X", value)
+	}
+}
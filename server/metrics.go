@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// exceptionKey identifies a count of exception responses
+type exceptionKey struct {
+	functionCode common.FunctionCode
+	exception    common.ExceptionCode
+}
+
+// serverMetrics are the counters exposed by WithMetricsListener. They are
+// collected whether or not a listener is configured; the hot path only
+// touches atomics.
+type serverMetrics struct {
+	connections      atomic.Uint64
+	requests         [256]atomic.Uint64 // by function code
+	unitRequests     [256]atomic.Uint64 // by unit ID
+	unitExceptions   [256]atomic.Uint64 // by unit ID
+	processingErrors atomic.Uint64
+	violations       [3]atomic.Uint64 // by common.ViolationKind, one per ViolationKinds()
+
+	mu         sync.Mutex
+	exceptions map[exceptionKey]uint64
+}
+
+// request counts a received request
+func (m *serverMetrics) request(unitID common.UnitID, functionCode common.FunctionCode) {
+	m.requests[functionCode].Add(1)
+	m.unitRequests[unitID].Add(1)
+}
+
+// exception counts an exception response
+func (m *serverMetrics) exception(unitID common.UnitID, functionCode common.FunctionCode, exception common.ExceptionCode) {
+	m.unitExceptions[unitID].Add(1)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.exceptions == nil {
+		m.exceptions = make(map[exceptionKey]uint64)
+	}
+	m.exceptions[exceptionKey{functionCode, exception}]++
+}
+
+// metricsServer serves the metrics endpoint of a TCPServer
+type metricsServer struct {
+	address  string
+	listener net.Listener
+	http     *http.Server
+}
+
+// WithMetricsListener serves the server's metrics in the Prometheus text
+// format at http://addr/metrics, and nothing else, for environments where
+// only a scrape endpoint may be exposed. The listener runs between Start and
+// Stop. Use an address with port 0 and MetricsAddr to pick a free port.
+func WithMetricsListener(addr string) TCPServerOption {
+	return func(s *TCPServer) {
+		s.metricsServer = &metricsServer{address: addr}
+	}
+}
+
+// MetricsAddr returns the address the metrics listener is bound to, or ""
+// when it is not running
+func (s *TCPServer) MetricsAddr() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.metricsServer == nil || s.metricsServer.listener == nil {
+		return ""
+	}
+	return s.metricsServer.listener.Addr().String()
+}
+
+// MetricsHandler returns an http.Handler writing the server's metrics in the
+// Prometheus text format, for mounting on an existing HTTP server
+func (s *TCPServer) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.WriteMetrics(w)
+	})
+}
+
+// startMetrics starts the metrics listener, if configured. The caller holds
+// s.mutex.
+func (s *TCPServer) startMetrics() error {
+	m := s.metricsServer
+	if m == nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", m.address)
+	if err != nil {
+		return fmt.Errorf("metrics listener: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.MetricsHandler())
+	m.listener = listener
+	m.http = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go m.http.Serve(listener)
+	return nil
+}
+
+// stopMetrics stops the metrics listener, if running. The caller holds
+// s.mutex.
+func (s *TCPServer) stopMetrics(ctx context.Context) {
+	m := s.metricsServer
+	if m == nil || m.http == nil {
+		return
+	}
+	m.http.Shutdown(ctx)
+	m.http = nil
+	m.listener = nil
+}
+
+// WriteMetrics writes the server's metrics in the Prometheus text format:
+// current and total connections, requests by function code, exception
+// responses by function and exception code, requests and exceptions by unit
+// ID, requests dropped because of processing errors, and malformed frames by
+// kind
+func (s *TCPServer) WriteMetrics(w io.Writer) {
+	m := &s.metrics
+
+	s.clientsMutex.RLock()
+	current := len(s.clients)
+	s.clientsMutex.RUnlock()
+
+	fmt.Fprintf(w, "# HELP modbus_server_connections Currently connected clients.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_connections gauge\n")
+	fmt.Fprintf(w, "modbus_server_connections %d\n", current)
+
+	fmt.Fprintf(w, "# HELP modbus_server_connections_total Client connections accepted.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_connections_total counter\n")
+	fmt.Fprintf(w, "modbus_server_connections_total %d\n", m.connections.Load())
+
+	fmt.Fprintf(w, "# HELP modbus_server_requests_total Requests received, by function code.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_requests_total counter\n")
+	for fc := range m.requests {
+		if v := m.requests[fc].Load(); v > 0 {
+			fmt.Fprintf(w, "modbus_server_requests_total{function=\"0x%02X\"} %d\n", fc, v)
+		}
+	}
+
+	m.mu.Lock()
+	keys := make([]exceptionKey, 0, len(m.exceptions))
+	for key := range m.exceptions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].functionCode != keys[j].functionCode {
+			return keys[i].functionCode < keys[j].functionCode
+		}
+		return keys[i].exception < keys[j].exception
+	})
+	fmt.Fprintf(w, "# HELP modbus_server_exceptions_total Exception responses, by function and exception code.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_exceptions_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(w, "modbus_server_exceptions_total{function=\"0x%02X\",exception=\"0x%02X\"} %d\n",
+			byte(key.functionCode), byte(key.exception), m.exceptions[key])
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP modbus_server_unit_requests_total Requests received, by unit ID.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_unit_requests_total counter\n")
+	for unit := range m.unitRequests {
+		if v := m.unitRequests[unit].Load(); v > 0 {
+			fmt.Fprintf(w, "modbus_server_unit_requests_total{unit=\"%d\"} %d\n", unit, v)
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP modbus_server_unit_exceptions_total Exception responses, by unit ID.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_unit_exceptions_total counter\n")
+	for unit := range m.unitExceptions {
+		if v := m.unitExceptions[unit].Load(); v > 0 {
+			fmt.Fprintf(w, "modbus_server_unit_exceptions_total{unit=\"%d\"} %d\n", unit, v)
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP modbus_server_processing_errors_total Requests dropped, closing the connection, because of a processing error.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_processing_errors_total counter\n")
+	fmt.Fprintf(w, "modbus_server_processing_errors_total %d\n", m.processingErrors.Load())
+
+	fmt.Fprintf(w, "# HELP modbus_server_protocol_violations_total Malformed frames received, by kind.\n")
+	fmt.Fprintf(w, "# TYPE modbus_server_protocol_violations_total counter\n")
+	for _, kind := range common.ViolationKinds() {
+		fmt.Fprintf(w, "modbus_server_protocol_violations_total{kind=\"%s\"} %d\n", kind, m.violations[kind].Load())
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestTCPServer_MetricsListener(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithMetricsListener("127.0.0.1:0"))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x02})
+	sendRawRequest(t, conn, 2, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x02})
+	sendRawRequest(t, conn, 3, 5, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x00}) // quantity 0
+	sendRawRequest(t, conn, 4, 5, common.FunctionCode(0x41), nil)
+
+	resp, err := http.Get("http://" + srv.MetricsAddr() + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	for _, line := range []string{
+		"modbus_server_connections 1",
+		"modbus_server_connections_total 1",
+		`modbus_server_requests_total{function="0x03"} 3`,
+		`modbus_server_requests_total{function="0x41"} 1`,
+		`modbus_server_exceptions_total{function="0x03",exception="0x03"} 1`,
+		`modbus_server_exceptions_total{function="0x41",exception="0x01"} 1`,
+		`modbus_server_unit_requests_total{unit="1"} 2`,
+		`modbus_server_unit_requests_total{unit="5"} 2`,
+		`modbus_server_unit_exceptions_total{unit="5"} 2`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("Expected %q in metrics:\n%s", line, body)
+		}
+	}
+	if strings.Contains(string(body), `unit_exceptions_total{unit="1"}`) {
+		t.Errorf("Expected no exceptions for unit 1:\n%s", body)
+	}
+
+	// Only the metrics are exposed
+	resp, err = http.Get("http://" + srv.MetricsAddr() + "/")
+	if err != nil {
+		t.Fatalf("Failed to query the listener: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 outside /metrics, got %d", resp.StatusCode)
+	}
+
+	srv.Stop(ctx)
+	if srv.MetricsAddr() != "" {
+		t.Error("Expected the metrics listener to stop with the server")
+	}
+}
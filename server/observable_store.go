@@ -0,0 +1,323 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// StoreChange reports one coil or register written through an
+// ObservableDataStore. For coils Old and New are 1 (on) or 0 (off).
+type StoreChange struct {
+	Table   common.Table
+	Address common.Address
+	Old     uint16
+	New     uint16
+
+	// UnitID and RemoteAddr identify the request that wrote the value, when
+	// it came through a TCPServer (see UnitIDFromContext and
+	// RemoteAddrFromContext)
+	UnitID     common.UnitID
+	RemoteAddr string
+
+	// CorrelationID is the correlation ID of the write's ctx, see
+	// common.WithCorrelationID
+	CorrelationID string
+}
+
+// Changed reports whether the write changed the value
+func (c StoreChange) Changed() bool {
+	return c.Old != c.New
+}
+
+// String describes the change, for example "Coils 10: 0 -> 1 by 10.0.0.5:51000"
+func (c StoreChange) String() string {
+	s := fmt.Sprintf("%s %d: %d -> %d", c.Table, c.Address, c.Old, c.New)
+	if c.RemoteAddr != "" {
+		s += " by " + c.RemoteAddr
+	}
+	return s
+}
+
+// ObservableDataStore wraps a DataStore and reports every coil and register
+// written through it to the functions registered with OnChange, so server
+// applications can react to writes, for example start a motor when a client
+// sets coil 10, without polling their own store:
+//
+//	store := NewObservableDataStore(NewMemoryStore())
+//	store.OnChange(func(change StoreChange) {
+//		if change.Table == common.TableCoils && change.Address == 10 && change.Changed() {
+//			motor.Run(change.New == 1)
+//		}
+//	})
+//	srv := NewTCPServer("", WithServerDataStore(store))
+//
+// Each written address is reported once the wrapped store accepted the
+// write, with the value it held before, even when the write left it
+// unchanged. Writes through the store are serialized so the old values are
+// exact; they are read from the wrapped store first, so a write fails if
+// its range cannot be read. Functions are called in the writing goroutine
+// after the write completes and may write to the store themselves; slow
+// reactions belong in their own goroutine. Writes made in Atomically are
+// reported once the transaction is over, and not at all if it rolled back.
+type ObservableDataStore struct {
+	store common.DataStore
+
+	// Serializes writes with the reads of their old values
+	writeMu sync.Mutex
+
+	mu        sync.RWMutex
+	listeners []changeListener
+	next      int
+}
+
+// changeListener is a function registered with OnChange
+type changeListener struct {
+	id int
+	fn func(StoreChange)
+}
+
+// NewObservableDataStore creates an observable store around store
+func NewObservableDataStore(store common.DataStore) *ObservableDataStore {
+	return &ObservableDataStore{store: store}
+}
+
+// OnChange registers fn to be called for every written address, after the
+// functions registered before it, and returns a function removing it again
+func (o *ObservableDataStore) OnChange(fn func(StoreChange)) (remove func()) {
+	o.mu.Lock()
+	id := o.next
+	o.next++
+	o.listeners = append(o.listeners, changeListener{id: id, fn: fn})
+	o.mu.Unlock()
+
+	return func() {
+		o.mu.Lock()
+		o.listeners = slices.DeleteFunc(slices.Clone(o.listeners), func(l changeListener) bool { return l.id == id })
+		o.mu.Unlock()
+	}
+}
+
+// bitWords converts coil values to change values
+func bitWords(values []common.CoilValue) []uint16 {
+	words := make([]uint16, len(values))
+	for i, value := range values {
+		if value {
+			words[i] = 1
+		}
+	}
+	return words
+}
+
+// observeWrite writes values starting at address of table with write,
+// reading the old values with read first, and reports the changes
+func (o *ObservableDataStore) observeWrite(ctx context.Context, table common.Table, address common.Address, values []uint16,
+	read func() ([]uint16, error), write func() error) error {
+	o.writeMu.Lock()
+	changes, err := applyWrite(ctx, table, address, values, read, write)
+	o.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+	o.report(changes)
+	return nil
+}
+
+// applyWrite reads the old values with read, writes values with write and
+// returns the changes
+func applyWrite(ctx context.Context, table common.Table, address common.Address, values []uint16,
+	read func() ([]uint16, error), write func() error) ([]StoreChange, error) {
+	old, err := read()
+	if err == nil {
+		err = write()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unitID, _ := UnitIDFromContext(ctx)
+	remoteAddr, _ := RemoteAddrFromContext(ctx)
+	changes := make([]StoreChange, len(values))
+	for i, value := range values {
+		changes[i] = StoreChange{
+			Table:         table,
+			Address:       address + common.Address(i),
+			New:           value,
+			UnitID:        unitID,
+			RemoteAddr:    remoteAddr,
+			CorrelationID: common.CorrelationID(ctx),
+		}
+		if i < len(old) {
+			changes[i].Old = old[i]
+		}
+	}
+	return changes, nil
+}
+
+// report calls the registered functions for every change
+func (o *ObservableDataStore) report(changes []StoreChange) {
+	o.mu.RLock()
+	listeners := o.listeners
+	o.mu.RUnlock()
+
+	for _, change := range changes {
+		for _, listener := range listeners {
+			listener.fn(change)
+		}
+	}
+}
+
+// readCoilWords reads coils of store as change values
+func readCoilWords(ctx context.Context, store common.DataStore, address common.Address, quantity int) func() ([]uint16, error) {
+	return func() ([]uint16, error) {
+		values, err := store.ReadCoils(ctx, address, common.Quantity(quantity))
+		return bitWords(values), err
+	}
+}
+
+// readRegisterWords reads holding registers of store as change values
+func readRegisterWords(ctx context.Context, store common.DataStore, address common.Address, quantity int) func() ([]uint16, error) {
+	return func() ([]uint16, error) {
+		return store.ReadHoldingRegisters(ctx, address, common.Quantity(quantity))
+	}
+}
+
+// ReadCoils reads from the wrapped store
+func (o *ObservableDataStore) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	return o.store.ReadCoils(ctx, address, quantity)
+}
+
+// ReadDiscreteInputs reads from the wrapped store
+func (o *ObservableDataStore) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	return o.store.ReadDiscreteInputs(ctx, address, quantity)
+}
+
+// ReadHoldingRegisters reads from the wrapped store
+func (o *ObservableDataStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	return o.store.ReadHoldingRegisters(ctx, address, quantity)
+}
+
+// ReadInputRegisters reads from the wrapped store
+func (o *ObservableDataStore) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	return o.store.ReadInputRegisters(ctx, address, quantity)
+}
+
+// WriteSingleCoil writes to the wrapped store and reports the change
+func (o *ObservableDataStore) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	return o.observeWrite(ctx, common.TableCoils, address, bitWords([]common.CoilValue{value}),
+		readCoilWords(ctx, o.store, address, 1),
+		func() error { return o.store.WriteSingleCoil(ctx, address, value) })
+}
+
+// WriteSingleRegister writes to the wrapped store and reports the change
+func (o *ObservableDataStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	return o.observeWrite(ctx, common.TableHoldingRegisters, address, []uint16{value},
+		readRegisterWords(ctx, o.store, address, 1),
+		func() error { return o.store.WriteSingleRegister(ctx, address, value) })
+}
+
+// WriteMultipleCoils writes to the wrapped store and reports the changes
+func (o *ObservableDataStore) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	return o.observeWrite(ctx, common.TableCoils, address, bitWords(values),
+		readCoilWords(ctx, o.store, address, len(values)),
+		func() error { return o.store.WriteMultipleCoils(ctx, address, values) })
+}
+
+// WriteMultipleRegisters writes to the wrapped store and reports the changes
+func (o *ObservableDataStore) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	return o.observeWrite(ctx, common.TableHoldingRegisters, address, values,
+		readRegisterWords(ctx, o.store, address, len(values)),
+		func() error { return o.store.WriteMultipleRegisters(ctx, address, values) })
+}
+
+// wrapped returns the wrapped store
+func (o *ObservableDataStore) wrapped() common.DataStore {
+	return o.store
+}
+
+// ValidateRange delegates to the wrapped store if it validates ranges
+func (o *ObservableDataStore) ValidateRange(ctx context.Context, table common.Table, address common.Address, quantity common.Quantity) error {
+	if validator, ok := o.store.(common.RangeValidator); ok {
+		return validator.ValidateRange(ctx, table, address, quantity)
+	}
+	return nil
+}
+
+// Atomically calls fn with a view of a transaction of the wrapped store and
+// reports the writes made through it once fn succeeded. Writes are only
+// rolled back if the wrapped store implements common.TransactionalDataStore;
+// otherwise the writes made before fn failed are reported too.
+func (o *ObservableDataStore) Atomically(ctx context.Context, fn func(tx common.DataStore) error) error {
+	_, transactional := o.store.(common.TransactionalDataStore)
+	view := &observableTx{}
+
+	o.writeMu.Lock()
+	err := atomicallyOn(ctx, o.store, func(tx common.DataStore) error {
+		view.tx = tx
+		return fn(view)
+	})
+	o.writeMu.Unlock()
+
+	if err == nil || !transactional {
+		o.report(view.changes)
+	}
+	return err
+}
+
+// observableTx is the view passed to Atomically callbacks. It collects the
+// changes of its writes, to be reported when the transaction is over.
+type observableTx struct {
+	tx      common.DataStore
+	changes []StoreChange
+}
+
+// observe applies a write and collects its changes
+func (t *observableTx) observe(ctx context.Context, table common.Table, address common.Address, values []uint16,
+	read func() ([]uint16, error), write func() error) error {
+	changes, err := applyWrite(ctx, table, address, values, read, write)
+	t.changes = append(t.changes, changes...)
+	return err
+}
+
+func (t *observableTx) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	return t.tx.ReadCoils(ctx, address, quantity)
+}
+
+func (t *observableTx) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	return t.tx.ReadDiscreteInputs(ctx, address, quantity)
+}
+
+func (t *observableTx) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	return t.tx.ReadHoldingRegisters(ctx, address, quantity)
+}
+
+func (t *observableTx) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	return t.tx.ReadInputRegisters(ctx, address, quantity)
+}
+
+func (t *observableTx) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	return t.observe(ctx, common.TableCoils, address, bitWords([]common.CoilValue{value}),
+		readCoilWords(ctx, t.tx, address, 1),
+		func() error { return t.tx.WriteSingleCoil(ctx, address, value) })
+}
+
+func (t *observableTx) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	return t.observe(ctx, common.TableHoldingRegisters, address, []uint16{value},
+		readRegisterWords(ctx, t.tx, address, 1),
+		func() error { return t.tx.WriteSingleRegister(ctx, address, value) })
+}
+
+func (t *observableTx) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	return t.observe(ctx, common.TableCoils, address, bitWords(values),
+		readCoilWords(ctx, t.tx, address, len(values)),
+		func() error { return t.tx.WriteMultipleCoils(ctx, address, values) })
+}
+
+func (t *observableTx) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	return t.observe(ctx, common.TableHoldingRegisters, address, values,
+		readRegisterWords(ctx, t.tx, address, len(values)),
+		func() error { return t.tx.WriteMultipleRegisters(ctx, address, values) })
+}
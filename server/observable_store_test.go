@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestObservableDataStore(t *testing.T) {
+	memory := NewMemoryStore()
+	memory.SetHoldingRegister(101, 7)
+	store := NewObservableDataStore(memory)
+
+	var changes []StoreChange
+	remove := store.OnChange(func(change StoreChange) {
+		changes = append(changes, change)
+	})
+
+	ctx := common.WithCorrelationID(ContextWithRemoteAddr(ContextWithUnitID(context.Background(), 3), "10.0.0.5:51000"), "op-1")
+	if err := store.WriteMultipleRegisters(ctx, 100, []common.RegisterValue{1, 7}); err != nil {
+		t.Fatalf("WriteMultipleRegisters failed: %v", err)
+	}
+	if err := store.WriteSingleCoil(context.Background(), 10, true); err != nil {
+		t.Fatalf("WriteSingleCoil failed: %v", err)
+	}
+
+	want := []StoreChange{
+		{Table: common.TableHoldingRegisters, Address: 100, Old: 0, New: 1, UnitID: 3, RemoteAddr: "10.0.0.5:51000", CorrelationID: "op-1"},
+		{Table: common.TableHoldingRegisters, Address: 101, Old: 7, New: 7, UnitID: 3, RemoteAddr: "10.0.0.5:51000", CorrelationID: "op-1"},
+		{Table: common.TableCoils, Address: 10, Old: 0, New: 1},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("Expected changes %v, got %v", want, changes)
+	}
+	if changes[1].Changed() || !changes[2].Changed() {
+		t.Error("Expected only the unchanged register to report no change")
+	}
+	if got := changes[0].String(); got != "HoldingRegisters 100: 0 -> 1 by 10.0.0.5:51000" {
+		t.Errorf("Unexpected description %q", got)
+	}
+
+	// Failed writes and removed functions report nothing
+	changes = nil
+	limited := NewObservableDataStore(NewMemoryStore(WithMemoryStoreRange(common.TableAll, 0, 9)))
+	limited.OnChange(func(change StoreChange) { changes = append(changes, change) })
+	if err := limited.WriteSingleRegister(ctx, 10, 1); err == nil {
+		t.Error("Expected a write out of range to fail")
+	}
+	remove()
+	if err := store.WriteSingleRegister(ctx, 100, 2); err != nil {
+		t.Fatalf("WriteSingleRegister failed: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+}
+
+func TestObservableDataStore_Server(t *testing.T) {
+	store := NewObservableDataStore(NewMemoryStore())
+	var mu sync.Mutex
+	var changes []StoreChange
+	store.OnChange(func(change StoreChange) {
+		mu.Lock()
+		changes = append(changes, change)
+		mu.Unlock()
+	})
+
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerDataStore(store))
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Mask Write Register is reported as the write of its result
+	sendRawRequest(t, conn, 1, 4, common.FuncWriteSingleCoil, []byte{0x00, 0x0A, 0xFF, 0x00})
+	sendRawRequest(t, conn, 2, 4, common.FuncMaskWriteRegister, []byte{0x00, 0x05, 0x00, 0x00, 0x00, 0x25})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %v", changes)
+	}
+	if c := changes[0]; c.Table != common.TableCoils || c.Address != 10 || c.New != 1 || c.UnitID != 4 || c.RemoteAddr != conn.LocalAddr().String() {
+		t.Errorf("Unexpected coil change %+v", c)
+	}
+	if c := changes[1]; c.Table != common.TableHoldingRegisters || c.Address != 5 || c.New != 0x25 {
+		t.Errorf("Unexpected register change %+v", c)
+	}
+}
+
+func TestObservableDataStore_AtomicallyRollsBack(t *testing.T) {
+	backing := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+	backing.SetHoldingRegister(0, 7)
+	store := NewObservableDataStore(backing)
+	var changes []StoreChange
+	store.OnChange(func(change StoreChange) { changes = append(changes, change) })
+	ctx := context.Background()
+
+	err := store.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, 0, []common.RegisterValue{5, 6}); err != nil {
+			return err
+		}
+		return tx.WriteMultipleRegisters(ctx, 99, []common.RegisterValue{1, 2})
+	})
+	if !errors.Is(err, common.ErrInvalidAddress) {
+		t.Fatalf("Expected ErrInvalidAddress, got %v", err)
+	}
+	if value, _ := backing.GetHoldingRegister(0); value != 7 {
+		t.Errorf("Expected register 0 restored to 7, got %d", value)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes for a rolled back write, got %v", changes)
+	}
+
+	// Changes are reported once the transaction succeeded
+	err = store.Atomically(ctx, func(tx common.DataStore) error {
+		return tx.WriteSingleRegister(ctx, 0, 8)
+	})
+	if err != nil {
+		t.Fatalf("Atomically returned error: %v", err)
+	}
+	if want := []StoreChange{{Table: common.TableHoldingRegisters, Address: 0, Old: 7, New: 8}}; !slices.Equal(changes, want) {
+		t.Errorf("Expected %v, got %v", want, changes)
+	}
+}
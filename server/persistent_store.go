@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+// DefaultFlushInterval is how often a PersistentStore saves its snapshot
+const DefaultFlushInterval = 10 * time.Second
+
+// persistentSnapshotVersion is the version of the snapshot file format
+const persistentSnapshotVersion = 1
+
+// persistentSnapshot is the JSON snapshot file of a PersistentStore
+type persistentSnapshot struct {
+	Version          int                                     `json:"version"`
+	Coils            map[common.Address]common.CoilValue     `json:"coils,omitempty"`
+	HoldingRegisters map[common.Address]common.RegisterValue `json:"holding_registers,omitempty"`
+}
+
+// PersistentStore is a MemoryStore whose coils and holding registers survive
+// restarts, so a simulated device keeps its configuration: they are restored
+// from a JSON snapshot file when the store is created, saved to it every
+// flush interval if they changed, and saved a last time by Close. Discrete
+// inputs and input registers, which devices compute, are not saved.
+//
+//	store, err := NewPersistentStore("device.json", WithFlushInterval(time.Minute))
+//	if err != nil {
+//		return err
+//	}
+//	defer store.Close()
+//	srv := NewTCPServer("", WithServerDataStore(store))
+//
+// The snapshot is replaced atomically, so a crash while saving leaves the
+// previous one intact; writes since the last save are lost.
+type PersistentStore struct {
+	*MemoryStore
+
+	path     string
+	interval time.Duration
+	logger   common.LoggerInterface
+
+	mu    sync.Mutex // serializes saves
+	saved uint64     // revision of the last save
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// PersistentStoreOption is a function that configures a PersistentStore
+type PersistentStoreOption func(*PersistentStore)
+
+// WithFlushInterval sets how often the snapshot is saved (default
+// DefaultFlushInterval). An interval of 0 or less saves only on Flush and
+// Close.
+func WithFlushInterval(interval time.Duration) PersistentStoreOption {
+	return func(p *PersistentStore) {
+		p.interval = interval
+	}
+}
+
+// WithPersistentMemoryStore keeps the values in store, for example one with
+// address ranges and default values; the snapshot overrides the defaults
+func WithPersistentMemoryStore(store *MemoryStore) PersistentStoreOption {
+	return func(p *PersistentStore) {
+		p.MemoryStore = store
+	}
+}
+
+// WithPersistentStoreLogger sets the logger reporting failed periodic saves
+func WithPersistentStoreLogger(logger common.LoggerInterface) PersistentStoreOption {
+	return func(p *PersistentStore) {
+		p.logger = logger
+	}
+}
+
+// NewPersistentStore creates a store saving to the snapshot file at path and
+// restores the snapshot if the file exists
+func NewPersistentStore(path string, options ...PersistentStoreOption) (*PersistentStore, error) {
+	p := &PersistentStore{
+		path:     path,
+		interval: DefaultFlushInterval,
+		logger:   logging.NewLogger(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(p)
+	}
+	if p.MemoryStore == nil {
+		p.MemoryStore = NewMemoryStore()
+	}
+
+	if err := p.restore(); err != nil {
+		return nil, err
+	}
+	p.saved = p.Revision()
+
+	if p.interval > 0 {
+		go p.flushLoop()
+	} else {
+		close(p.done)
+	}
+	return p, nil
+}
+
+// wrapped returns the MemoryStore holding the values
+func (p *PersistentStore) wrapped() common.DataStore {
+	return p.MemoryStore
+}
+
+// restore loads the snapshot file into the store, if it exists
+func (p *PersistentStore) restore() error {
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+
+	var snapshot persistentSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("parsing snapshot %s: %w", p.path, err)
+	}
+	if snapshot.Version != persistentSnapshotVersion {
+		return fmt.Errorf("snapshot %s: unsupported version %d", p.path, snapshot.Version)
+	}
+
+	p.MemoryStore.mu.Lock()
+	defer p.MemoryStore.mu.Unlock()
+	for address, value := range snapshot.Coils {
+		p.coils[address] = value
+	}
+	for address, value := range snapshot.HoldingRegisters {
+		p.holdingRegisters[address] = value
+	}
+	return nil
+}
+
+// flushLoop saves the snapshot every interval until Close
+func (p *PersistentStore) flushLoop() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Flush(); err != nil {
+				p.logger.Error(context.Background(), "Failed to save snapshot: %v", err)
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Flush saves the snapshot now if the store changed since the last save
+func (p *PersistentStore) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.MemoryStore.mu.RLock()
+	revision := p.Revision()
+	if revision == p.saved {
+		p.MemoryStore.mu.RUnlock()
+		return nil
+	}
+	snapshot := persistentSnapshot{
+		Version:          persistentSnapshotVersion,
+		Coils:            make(map[common.Address]common.CoilValue, len(p.coils)),
+		HoldingRegisters: make(map[common.Address]common.RegisterValue, len(p.holdingRegisters)),
+	}
+	for address, value := range p.coils {
+		snapshot.Coils[address] = value
+	}
+	for address, value := range p.holdingRegisters {
+		snapshot.HoldingRegisters[address] = value
+	}
+	p.MemoryStore.mu.RUnlock()
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(p.path, data); err != nil {
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+	p.saved = revision
+	return nil
+}
+
+// Close stops the periodic saves and saves the snapshot a last time
+func (p *PersistentStore) Close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+	return p.Flush()
+}
+
+// writeFileAtomic replaces the file at path with data through a temporary
+// file in the same directory
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
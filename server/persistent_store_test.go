@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+func TestPersistentStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "device.json")
+	ctx := context.Background()
+
+	store, err := NewPersistentStore(path, WithFlushInterval(0))
+	if err != nil {
+		t.Fatalf("NewPersistentStore failed: %v", err)
+	}
+	if err := store.WriteMultipleRegisters(ctx, 100, []common.RegisterValue{1, 2}); err != nil {
+		t.Fatalf("WriteMultipleRegisters failed: %v", err)
+	}
+	if err := store.WriteSingleCoil(ctx, 7, true); err != nil {
+		t.Fatalf("WriteSingleCoil failed: %v", err)
+	}
+	store.SetInputRegister(5, 42)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Defaults of the wrapped store give way to the snapshot
+	defaults := NewMemoryStore()
+	defaults.SetHoldingRegister(100, 9)
+	defaults.SetHoldingRegister(200, 3)
+	restored, err := NewPersistentStore(path, WithFlushInterval(0), WithPersistentMemoryStore(defaults))
+	if err != nil {
+		t.Fatalf("NewPersistentStore failed: %v", err)
+	}
+	registers, _ := restored.ReadHoldingRegisters(ctx, 100, 2)
+	if registers[0] != 1 || registers[1] != 2 {
+		t.Errorf("Expected restored registers [1 2], got %v", registers)
+	}
+	if value, _ := restored.GetHoldingRegister(200); value != 3 {
+		t.Errorf("Expected the default register 200 = 3, got %d", value)
+	}
+	if coil, _ := restored.GetCoil(7); !coil {
+		t.Error("Expected restored coil 7 on")
+	}
+	if _, ok := restored.GetInputRegister(5); ok {
+		t.Error("Expected input registers not to be saved")
+	}
+
+	// An unchanged store is not saved again
+	os.Remove(path)
+	if err := restored.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected no snapshot for an unchanged store, got %v", err)
+	}
+}
+
+func TestPersistentStore_FlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "device.json")
+	store, err := NewPersistentStore(path, WithFlushInterval(10*time.Millisecond), WithPersistentStoreLogger(logging.NewNoopLogger()))
+	if err != nil {
+		t.Fatalf("NewPersistentStore failed: %v", err)
+	}
+	defer store.Close()
+
+	store.SetHoldingRegister(1, 0xBEEF)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a periodic save")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPersistentStore_InvalidSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"garbage.json": "not json",
+		"future.json":  `{"version": 2}`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := NewPersistentStore(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -3,20 +3,119 @@ package server
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"math"
+	"reflect"
+	"sync"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 	"github.com/Moonlight-Companies/gomodbus/transport"
 )
 
 // serverProtocolHandler processes Modbus requests and generates responses
-type serverProtocolHandler struct{}
+type serverProtocolHandler struct {
+	// legacyWrites disables write staging: writes go straight to the store,
+	// which may apply part of a request before failing
+	legacyWrites bool
+
+	// commitLocks serialize the commits to each backend store, so the read
+	// and write of a request such as Mask Write Register are not
+	// interleaved with other writes even when the backend is not
+	// transactional. Commits to different backends do not wait for each
+	// other. commitMu guards the map.
+	commitMu    sync.Mutex
+	commitLocks map[common.DataStore]*sync.Mutex
+}
 
 // newServerProtocolHandler creates a new protocol handler for server
 func newServerProtocolHandler() *serverProtocolHandler {
 	return &serverProtocolHandler{}
 }
 
+// storeError converts a data store error into a Modbus exception.
+// Stores may return a *common.ModbusError to choose the exception code
+// themselves; otherwise ErrInvalidQuantity maps to Illegal Data Value,
+// ErrInvalidAddress to Illegal Data Address and anything else to Server
+// Device Failure.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Codes)
+func storeError(functionCode common.FunctionCode, err error) error {
+	var modbusErr *common.ModbusError
+	switch {
+	case errors.As(err, &modbusErr):
+		return common.NewModbusError(functionCode, modbusErr.ExceptionCode)
+	case errors.Is(err, common.ErrInvalidQuantity):
+		return common.NewModbusError(functionCode, common.ExceptionInvalidDataValue)
+	case errors.Is(err, common.ErrInvalidAddress):
+		return common.NewModbusError(functionCode, common.ExceptionDataAddressNotAvailable)
+	default:
+		return common.NewModbusError(functionCode, common.ExceptionServerDeviceFailure)
+	}
+}
+
+// validateRange checks that a whole request range can be served before any
+// of it is written. Ranges running past address 0xFFFF are always rejected;
+// stores implementing common.RangeValidator also check their own bounds.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7.2 (ILLEGAL DATA ADDRESS)
+func validateRange(ctx context.Context, store common.DataStore, table common.Table, address common.Address, quantity common.Quantity) error {
+	if int(address)+int(quantity) > 0x10000 {
+		return common.ErrInvalidAddress
+	}
+	if validator, ok := store.(common.RangeValidator); ok {
+		return validator.ValidateRange(ctx, table, address, quantity)
+	}
+	return nil
+}
+
+// commitWrite stages a write: the whole range is validated first, then write
+// is applied through common.TransactionalDataStore.Atomically when the store
+// supports it, so a failing request leaves the store unchanged. Commits to
+// the same backend store are serialized by the handler either way.
+// In legacy mode write is applied directly to the store, unserialized.
+func (h *serverProtocolHandler) commitWrite(ctx context.Context, store common.DataStore, table common.Table,
+	address common.Address, quantity common.Quantity, write func(common.DataStore) error) error {
+	if h.legacyWrites {
+		return write(store)
+	}
+	if err := validateRange(ctx, store, table, address, quantity); err != nil {
+		return err
+	}
+	lock := h.commitLock(backendOf(store))
+	lock.Lock()
+	defer lock.Unlock()
+	return atomicallyOn(ctx, store, write)
+}
+
+// commitLock returns the lock serializing commits to store, the innermost
+// store of the decorators of this package, so decorators sharing a backend
+// share its lock. Stores that are not pointers cannot safely be told apart,
+// so they share one lock.
+func (h *serverProtocolHandler) commitLock(store common.DataStore) *sync.Mutex {
+	h.commitMu.Lock()
+	defer h.commitMu.Unlock()
+	if reflect.ValueOf(store).Kind() != reflect.Pointer {
+		store = nil
+	}
+	lock, ok := h.commitLocks[store]
+	if !ok {
+		if h.commitLocks == nil {
+			h.commitLocks = make(map[common.DataStore]*sync.Mutex)
+		}
+		lock = &sync.Mutex{}
+		h.commitLocks[store] = lock
+	}
+	return lock
+}
+
+// atomicallyOn calls fn in a transaction of store when it implements
+// common.TransactionalDataStore, and with store itself otherwise, in which
+// case writes made before fn fails are kept
+func atomicallyOn(ctx context.Context, store common.DataStore, fn func(tx common.DataStore) error) error {
+	if txStore, ok := store.(common.TransactionalDataStore); ok {
+		return txStore.Atomically(ctx, fn)
+	}
+	return fn(store)
+}
+
 // handleReadBitValues is a helper function for handling bit value read requests (coils, discrete inputs)
 // This handles both Read Coils (0x01) and Read Discrete Inputs (0x02) functions
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.1 and 6.2 (Read Coils/Discrete Inputs)
@@ -51,10 +150,7 @@ func (h *serverProtocolHandler) handleReadBitValues(
 	// Read values from data store
 	values, err := readFunc(ctx, address, quantity)
 	if err != nil {
-		if err == common.ErrInvalidQuantity {
-			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
-		}
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		return nil, storeError(req.GetPDU().FunctionCode, err)
 	}
 
 	// Calculate response data size and create response data
@@ -123,10 +219,7 @@ func (h *serverProtocolHandler) handleReadRegisterValues(
 	// Read registers from data store
 	values, err := readFunc(ctx, address, quantity)
 	if err != nil {
-		if err == common.ErrInvalidQuantity {
-			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
-		}
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		return nil, storeError(req.GetPDU().FunctionCode, err)
 	}
 
 	// Calculate response data size and create response data
@@ -236,9 +329,11 @@ func (h *serverProtocolHandler) HandleWriteSingleCoil(ctx context.Context, req c
 	}
 
 	// Write the coil value to the data store
-	err := store.WriteSingleCoil(ctx, address, coilValue)
+	err := h.commitWrite(ctx, store, common.TableCoils, address, 1, func(tx common.DataStore) error {
+		return tx.WriteSingleCoil(ctx, address, coilValue)
+	})
 	if err != nil {
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		return nil, storeError(req.GetPDU().FunctionCode, err)
 	}
 
 	// Create the response (echo the request)
@@ -272,9 +367,11 @@ func (h *serverProtocolHandler) HandleWriteSingleRegister(ctx context.Context, r
 	value := common.RegisterValue(binary.BigEndian.Uint16(req.GetPDU().Data[2:4]))
 
 	// Write the register value to the data store
-	err := store.WriteSingleRegister(ctx, address, value)
+	err := h.commitWrite(ctx, store, common.TableHoldingRegisters, address, 1, func(tx common.DataStore) error {
+		return tx.WriteSingleRegister(ctx, address, value)
+	})
 	if err != nil {
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		return nil, storeError(req.GetPDU().FunctionCode, err)
 	}
 
 	// Create the response (echo the request)
@@ -343,12 +440,11 @@ func (h *serverProtocolHandler) HandleWriteMultipleCoils(ctx context.Context, re
 	}
 
 	// Write the coil values to the data store
-	err := store.WriteMultipleCoils(ctx, address, values)
+	err := h.commitWrite(ctx, store, common.TableCoils, address, quantity, func(tx common.DataStore) error {
+		return tx.WriteMultipleCoils(ctx, address, values)
+	})
 	if err != nil {
-		if err == common.ErrInvalidQuantity {
-			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
-		}
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		return nil, storeError(req.GetPDU().FunctionCode, err)
 	}
 
 	// Create the response
@@ -420,12 +516,11 @@ func (h *serverProtocolHandler) HandleWriteMultipleRegisters(ctx context.Context
 	}
 
 	// Write the register values to the data store
-	err := store.WriteMultipleRegisters(ctx, address, values)
+	err := h.commitWrite(ctx, store, common.TableHoldingRegisters, address, quantity, func(tx common.DataStore) error {
+		return tx.WriteMultipleRegisters(ctx, address, values)
+	})
 	if err != nil {
-		if err == common.ErrInvalidQuantity {
-			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
-		}
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		return nil, storeError(req.GetPDU().FunctionCode, err)
 	}
 
 	// Create the response
@@ -447,6 +542,209 @@ func (h *serverProtocolHandler) HandleWriteMultipleRegisters(ctx context.Context
 	return response, nil
 }
 
+// HandleReadFileRecord processes a read file record request against a store
+// implementing common.FileRecordStore; other stores answer exception 0x01
+// (Illegal Function).
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+func (h *serverProtocolHandler) HandleReadFileRecord(ctx context.Context, req common.Request, store common.DataStore) (common.Response, error) {
+	functionCode := req.GetPDU().FunctionCode
+	files, ok := store.(common.FileRecordStore)
+	if !ok {
+		return nil, common.NewModbusError(functionCode, common.ExceptionFunctionCodeNotSupported)
+	}
+
+	// Parse request PDU data
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Request PDU)
+	// Request format:
+	// - Byte Count (1 byte) - 0x07 to 0xF5
+	// - Sub-requests of 7 bytes: Reference Type (6), File Number, Record Number, Record Length
+	data := req.GetPDU().Data
+	if len(data) < 1 || int(data[0]) != len(data)-1 || data[0] < 7 || data[0] > common.MaxFileRecordByteCount || data[0]%7 != 0 {
+		return nil, common.NewModbusError(functionCode, common.ExceptionInvalidDataValue)
+	}
+
+	var requests []common.FileRecordRequest
+	responseLength := 0
+	for offset := 1; offset < len(data); offset += 7 {
+		request := common.FileRecordRequest{
+			FileNumber:   binary.BigEndian.Uint16(data[offset+1:]),
+			RecordNumber: binary.BigEndian.Uint16(data[offset+3:]),
+			Length:       common.Quantity(binary.BigEndian.Uint16(data[offset+5:])),
+		}
+		if exception := checkFileRecordRequest(data[offset], request.RecordNumber, int(request.Length)); exception != 0 {
+			return nil, common.NewModbusError(functionCode, exception)
+		}
+		responseLength += 2 + int(request.Length)*2
+		requests = append(requests, request)
+	}
+	if responseLength > common.MaxFileRecordByteCount {
+		return nil, common.NewModbusError(functionCode, common.ExceptionInvalidDataValue)
+	}
+
+	// Response format:
+	// - Resp. Data Length (1 byte)
+	// - Sub-responses: File Resp. Length (1 byte), Reference Type (6), Record Data
+	responseData := make([]byte, 1, 1+responseLength)
+	responseData[0] = byte(responseLength)
+	for _, request := range requests {
+		values, err := files.ReadFileRecord(ctx, request.FileNumber, request.RecordNumber, request.Length)
+		if err != nil {
+			return nil, storeError(functionCode, err)
+		}
+		if len(values) != int(request.Length) {
+			return nil, common.NewModbusError(functionCode, common.ExceptionServerDeviceFailure)
+		}
+		responseData = append(responseData, byte(1+len(values)*2), common.FileRecordReferenceType)
+		for _, value := range values {
+			responseData = binary.BigEndian.AppendUint16(responseData, value)
+		}
+	}
+
+	response := transport.NewResponse(
+		req.GetTransactionID(),
+		req.GetUnitID(),
+		functionCode,
+		responseData,
+	)
+
+	return response, nil
+}
+
+// HandleWriteFileRecord processes a write file record request against a
+// store implementing common.FileRecordStore; other stores answer exception
+// 0x01 (Illegal Function). Every sub-request is validated before any is
+// written.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Write File Record)
+func (h *serverProtocolHandler) HandleWriteFileRecord(ctx context.Context, req common.Request, store common.DataStore) (common.Response, error) {
+	functionCode := req.GetPDU().FunctionCode
+	files, ok := store.(common.FileRecordStore)
+	if !ok {
+		return nil, common.NewModbusError(functionCode, common.ExceptionFunctionCodeNotSupported)
+	}
+
+	// Parse request PDU data
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Request PDU)
+	// Request format:
+	// - Request Data Length (1 byte) - 0x09 to 0xFB
+	// - Sub-requests: Reference Type (6), File Number, Record Number,
+	//   Record Length (N), Record Data (N * 2 bytes)
+	data := req.GetPDU().Data
+	if len(data) < 1 || int(data[0]) != len(data)-1 || data[0] < 9 || data[0] > common.MaxWriteFileRecordByteCount {
+		return nil, common.NewModbusError(functionCode, common.ExceptionInvalidDataValue)
+	}
+
+	var records []common.FileRecord
+	for offset := 1; offset < len(data); {
+		if len(data) < offset+7 {
+			return nil, common.NewModbusError(functionCode, common.ExceptionInvalidDataValue)
+		}
+		// Check the record length against the data before allocating
+		length := int(binary.BigEndian.Uint16(data[offset+5:]))
+		if len(data) < offset+7+length*2 {
+			return nil, common.NewModbusError(functionCode, common.ExceptionInvalidDataValue)
+		}
+		record := common.FileRecord{
+			FileNumber:   binary.BigEndian.Uint16(data[offset+1:]),
+			RecordNumber: binary.BigEndian.Uint16(data[offset+3:]),
+			Values:       make([]common.RegisterValue, length),
+		}
+		if exception := checkFileRecordRequest(data[offset], record.RecordNumber, len(record.Values)); exception != 0 {
+			return nil, common.NewModbusError(functionCode, exception)
+		}
+		offset += 7
+		for i := range record.Values {
+			record.Values[i] = binary.BigEndian.Uint16(data[offset+i*2:])
+		}
+		offset += len(record.Values) * 2
+		records = append(records, record)
+	}
+
+	for _, record := range records {
+		if err := files.WriteFileRecord(ctx, record.FileNumber, record.RecordNumber, record.Values); err != nil {
+			return nil, storeError(functionCode, err)
+		}
+	}
+
+	// Create the response (echo the request)
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15 (Response PDU)
+	response := transport.NewResponse(
+		req.GetTransactionID(),
+		req.GetUnitID(),
+		functionCode,
+		data,
+	)
+
+	return response, nil
+}
+
+// checkFileRecordRequest returns the exception a file record sub-request
+// must get, or 0 if it is valid
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14 (Read File Record)
+func checkFileRecordRequest(referenceType byte, recordNumber uint16, length int) common.ExceptionCode {
+	switch {
+	case referenceType != common.FileRecordReferenceType:
+		return common.ExceptionDataAddressNotAvailable
+	case length == 0:
+		return common.ExceptionInvalidDataValue
+	case int(recordNumber)+length-1 > common.MaxFileRecordNumber:
+		return common.ExceptionDataAddressNotAvailable
+	}
+	return 0
+}
+
+// HandleMaskWriteRegister processes a mask write register request. The
+// register is read and written back in one commit, so no other write request
+// to the same backend store can interleave with the update; with a
+// common.TransactionalDataStore such as MemoryStore neither can writes made
+// outside the server. Commits through decorators from outside this package
+// are not known to share a backend, so with a non-transactional backend they
+// may interleave.
+// With WithServerLegacyWrites commits are not serialized and concurrent
+// writes may interleave.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16 (Mask Write Register)
+func (h *serverProtocolHandler) HandleMaskWriteRegister(ctx context.Context, req common.Request, store common.DataStore) (common.Response, error) {
+	// Parse request PDU data
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16 (Request PDU)
+	// Request format:
+	// - Reference Address (2 bytes)
+	// - And_Mask (2 bytes)
+	// - Or_Mask (2 bytes)
+	if len(req.GetPDU().Data) != 6 {
+		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
+	}
+
+	address := common.Address(binary.BigEndian.Uint16(req.GetPDU().Data[0:2]))
+	andMask := binary.BigEndian.Uint16(req.GetPDU().Data[2:4])
+	orMask := binary.BigEndian.Uint16(req.GetPDU().Data[4:6])
+
+	// Result = (Current Contents AND And_Mask) OR (Or_Mask AND (NOT And_Mask))
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16 (Function Description)
+	err := h.commitWrite(ctx, store, common.TableHoldingRegisters, address, 1, func(tx common.DataStore) error {
+		values, err := tx.ReadHoldingRegisters(ctx, address, 1)
+		if err != nil {
+			return err
+		}
+		if len(values) != 1 {
+			return common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		}
+		return tx.WriteSingleRegister(ctx, address, values[0]&andMask|orMask&^andMask)
+	})
+	if err != nil {
+		return nil, storeError(req.GetPDU().FunctionCode, err)
+	}
+
+	// Create the response (echo the request)
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16 (Response PDU)
+	response := transport.NewResponse(
+		req.GetTransactionID(),
+		req.GetUnitID(),
+		req.GetPDU().FunctionCode,
+		req.GetPDU().Data,
+	)
+
+	return response, nil
+}
+
 // HandleReadWriteMultipleRegisters processes a read/write multiple registers request
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.17 (Read/Write Multiple Registers)
 func (h *serverProtocolHandler) HandleReadWriteMultipleRegisters(ctx context.Context, req common.Request, store common.DataStore) (common.Response, error) {
@@ -502,24 +800,28 @@ func (h *serverProtocolHandler) HandleReadWriteMultipleRegisters(ctx context.Con
 		writeValues[i] = common.RegisterValue(binary.BigEndian.Uint16(req.GetPDU().Data[9+i*2 : 9+i*2+2]))
 	}
 
-	// Write the register values to the data store
-	err := store.WriteMultipleRegisters(ctx, writeAddress, writeValues)
-	if err != nil {
-		if err == common.ErrInvalidQuantity {
-			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
+	// Validate the read range up front too, so a bad read address does not
+	// leave the write applied
+	if !h.legacyWrites {
+		if err := validateRange(ctx, store, common.TableHoldingRegisters, readAddress, readQuantity); err != nil {
+			return nil, storeError(req.GetPDU().FunctionCode, err)
 		}
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
 	}
 
-	// Read the register values from the data store
+	// Write the register values, then read the register values, as one unit
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.17 (Query Processing)
 	// "The write operation is performed before the read operation."
-	readValues, err := store.ReadHoldingRegisters(ctx, readAddress, readQuantity)
-	if err != nil {
-		if err == common.ErrInvalidQuantity {
-			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
+	var readValues []common.RegisterValue
+	err := h.commitWrite(ctx, store, common.TableHoldingRegisters, writeAddress, writeQuantity, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, writeAddress, writeValues); err != nil {
+			return err
 		}
-		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		var err error
+		readValues, err = tx.ReadHoldingRegisters(ctx, readAddress, readQuantity)
+		return err
+	})
+	if err != nil {
+		return nil, storeError(req.GetPDU().FunctionCode, err)
 	}
 
 	// Calculate response data size and create response data
@@ -548,6 +850,75 @@ func (h *serverProtocolHandler) HandleReadWriteMultipleRegisters(ctx context.Con
 	return response, nil
 }
 
+// HandleReadFIFOQueue processes a read FIFO queue request. Stores
+// implementing common.FIFOQueueStore serve the queue; for others the
+// register at the pointer address holds the count of queued values, which
+// follow it.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18 (Read FIFO Queue)
+func (h *serverProtocolHandler) HandleReadFIFOQueue(ctx context.Context, req common.Request, store common.DataStore) (common.Response, error) {
+	// Parse request PDU data
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18 (Request PDU)
+	// Request format:
+	// - FIFO Pointer Address (2 bytes)
+	if len(req.GetPDU().Data) != 2 {
+		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
+	}
+	pointerAddress := common.Address(binary.BigEndian.Uint16(req.GetPDU().Data[0:2]))
+
+	values, err := readFIFOQueue(ctx, store, pointerAddress)
+	if err != nil {
+		return nil, storeError(req.GetPDU().FunctionCode, err)
+	}
+
+	// "If the queue count exceeds 31, an exception response is returned
+	// with an error code of 03 (Illegal Data Value)."
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+	if len(values) > common.MaxFIFOCount {
+		return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionInvalidDataValue)
+	}
+
+	// Response format:
+	// - Byte Count (2 bytes) - bytes to follow, FIFO count included
+	// - FIFO Count (2 bytes)
+	// - FIFO Value Register (FIFO Count * 2 bytes)
+	responseData := make([]byte, 4+len(values)*2)
+	binary.BigEndian.PutUint16(responseData[0:2], uint16(2+len(values)*2))
+	binary.BigEndian.PutUint16(responseData[2:4], uint16(len(values)))
+	for i, value := range values {
+		binary.BigEndian.PutUint16(responseData[4+i*2:], value)
+	}
+
+	response := transport.NewResponse(
+		req.GetTransactionID(),
+		req.GetUnitID(),
+		req.GetPDU().FunctionCode,
+		responseData,
+	)
+
+	return response, nil
+}
+
+// readFIFOQueue returns the values queued at pointerAddress
+func readFIFOQueue(ctx context.Context, store common.DataStore, pointerAddress common.Address) ([]common.RegisterValue, error) {
+	if fifo, ok := store.(common.FIFOQueueStore); ok {
+		return fifo.ReadFIFOQueue(ctx, pointerAddress)
+	}
+	count, err := store.ReadHoldingRegisters(ctx, pointerAddress, 1)
+	if err != nil {
+		return nil, err
+	}
+	if count[0] == 0 {
+		return nil, nil
+	}
+	if count[0] > common.MaxFIFOCount {
+		return nil, common.NewModbusError(common.FuncReadFIFOQueue, common.ExceptionInvalidDataValue)
+	}
+	if int(pointerAddress)+1+int(count[0]) > 0x10000 {
+		return nil, common.ErrInvalidAddress
+	}
+	return store.ReadHoldingRegisters(ctx, pointerAddress+1, common.Quantity(count[0]))
+}
+
 // HandleReadDeviceIdentification processes a read device identification request
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Read Device Identification)
 func (h *serverProtocolHandler) HandleReadDeviceIdentification(ctx context.Context, req common.Request, store common.DataStore) (common.Response, error) {
@@ -630,11 +1001,12 @@ func (h *serverProtocolHandler) HandleReadDeviceIdentification(ctx context.Conte
 		common.DeviceIDObjectCode(0x80): "Extended Object Example",
 	}
 
-	// Add objects to response
+	// Collect the objects this server has
+	objects := make([]common.DeviceIDObject, 0, len(objectsToInclude))
 	for _, id := range objectsToInclude {
 		value, exists := objectValues[id]
 		if exists {
-			deviceID.Objects = append(deviceID.Objects, common.DeviceIDObject{
+			objects = append(objects, common.DeviceIDObject{
 				ID:     id,
 				Length: byte(len(value)),
 				Value:  value,
@@ -642,46 +1014,29 @@ func (h *serverProtocolHandler) HandleReadDeviceIdentification(ctx context.Conte
 		}
 	}
 
+	if readDeviceIDCode == common.ReadDeviceIDSpecificObject {
+		// Individual access returns the one object, which must fit alone
+		if _, err := PaginateObjects(objects, objectID, DefaultObjectBudget); err != nil {
+			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		}
+		deviceID.Objects = objects
+	} else {
+		// Stream access continues at the requested object and is split into
+		// several responses when the objects exceed one PDU
+		page, err := PaginateObjects(objects, objectID, DefaultObjectBudget)
+		if err != nil {
+			return nil, common.NewModbusError(req.GetPDU().FunctionCode, common.ExceptionServerDeviceFailure)
+		}
+		deviceID.Objects = page.Objects
+		deviceID.MoreFollows = page.MoreFollows
+		deviceID.NextObjectID = page.NextObjectID
+	}
+
 	deviceID.NumberOfObjects = byte(len(deviceID.Objects))
 
 	// Encode response
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Response PDU)
-	// Response format:
-	// Byte 0: MEI Type (0x0E)
-	// Byte 1: ReadDeviceID code
-	// Byte 2: Conformity level
-	// Byte 3: More follows (0 or 1)
-	// Byte 4: Next object ID
-	// Byte 5: Number of objects
-	// For each object:
-	//   Byte n+0: Object ID
-	//   Byte n+1: Object length
-	//   Byte n+2..n+1+length: Object value
-
-	// Calculate response size
-	responseSize := 6 // Fixed header
-	for _, obj := range deviceID.Objects {
-		responseSize += 2 + int(obj.Length) // ID + length + value
-	}
-
-	responseData := make([]byte, responseSize)
-	responseData[0] = byte(common.MEIReadDeviceID)
-	responseData[1] = byte(deviceID.ReadDeviceIDCode)
-	responseData[2] = byte(deviceID.ConformityLevel)
-	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21 (Response PDU)
-	// MoreFollows: 0x00 = no more objects, 0xFF = more objects available (request again with NextObjectID)
-	responseData[3] = byte(deviceID.MoreFollows)
-	responseData[4] = byte(deviceID.NextObjectID)
-	responseData[5] = deviceID.NumberOfObjects
-
-	// Add objects
-	offset := 6
-	for _, obj := range deviceID.Objects {
-		responseData[offset] = byte(obj.ID)
-		responseData[offset+1] = obj.Length
-		copy(responseData[offset+2:offset+2+int(obj.Length)], []byte(obj.Value))
-		offset += 2 + int(obj.Length)
-	}
+	responseData := EncodeDeviceIdentification(deviceID)
 
 	// Create the response
 	response := transport.NewResponse(
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 	"github.com/Moonlight-Companies/gomodbus/common/test"
@@ -354,4 +357,356 @@ func TestHandleWriteMultipleRegisters(t *testing.T) {
 	if err == nil {
 		t.Error("HandleWriteMultipleRegisters with mismatched byte count should return error")
 	}
-}
\ No newline at end of file
+}
+
+// writeRegistersRequest builds a Write Multiple Registers request
+func writeRegistersRequest(address common.Address, values ...common.RegisterValue) common.Request {
+	reqData := make([]byte, 5+2*len(values))
+	binary.BigEndian.PutUint16(reqData[0:2], uint16(address))
+	binary.BigEndian.PutUint16(reqData[2:4], uint16(len(values)))
+	reqData[4] = byte(2 * len(values))
+	for i, value := range values {
+		binary.BigEndian.PutUint16(reqData[5+2*i:], value)
+	}
+	return test.NewMockRequest(1, 1, common.FuncWriteMultipleRegisters, reqData)
+}
+
+func TestHandleWriteMultipleRegisters_PartiallyOutOfRange(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+
+	store := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+
+	// Registers 98-101: the last two are outside the store
+	_, err := handler.HandleWriteMultipleRegisters(ctx, writeRegistersRequest(98, 1, 2, 3, 4), store)
+	if !common.IsDataAddressNotAvailableError(err) {
+		t.Fatalf("Expected Illegal Data Address exception, got %v", err)
+	}
+
+	for addr := common.Address(98); addr <= 101; addr++ {
+		if _, ok := store.GetHoldingRegister(addr); ok {
+			t.Errorf("Register %d was written by a rejected request", addr)
+		}
+	}
+}
+
+func TestHandleWriteMultipleRegisters_AddressOverflow(t *testing.T) {
+	ctx := context.Background()
+
+	// Registers 0xFFFF and 0x10000 do not both exist
+	req := writeRegistersRequest(0xFFFF, 1, 2)
+
+	store := test.NewMockDataStore()
+	_, err := newServerProtocolHandler().HandleWriteMultipleRegisters(ctx, req, store)
+	if !common.IsDataAddressNotAvailableError(err) {
+		t.Fatalf("Expected Illegal Data Address exception, got %v", err)
+	}
+	if _, ok := store.GetHoldingRegister(0xFFFF); ok {
+		t.Error("Register 0xFFFF was written by a rejected request")
+	}
+
+	// Legacy mode hands the request to the store unchecked
+	legacy := &serverProtocolHandler{legacyWrites: true}
+	if _, err := legacy.HandleWriteMultipleRegisters(ctx, req, store); err != nil {
+		t.Fatalf("Expected legacy write to reach the store, got %v", err)
+	}
+	if value, _ := store.GetHoldingRegister(0xFFFF); value != 1 {
+		t.Errorf("Expected register 0xFFFF to be 1, got %d", value)
+	}
+}
+
+func TestHandleReadWriteMultipleRegisters_ReadOutOfRange(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+
+	store := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+
+	// Write register 10, read registers 99-100
+	reqData := []byte{0x00, 0x63, 0x00, 0x02, 0x00, 0x0A, 0x00, 0x01, 0x02, 0x12, 0x34}
+	req := test.NewMockRequest(1, 1, common.FuncReadWriteMultipleRegisters, reqData)
+
+	_, err := handler.HandleReadWriteMultipleRegisters(ctx, req, store)
+	if !common.IsDataAddressNotAvailableError(err) {
+		t.Fatalf("Expected Illegal Data Address exception, got %v", err)
+	}
+	if _, ok := store.GetHoldingRegister(10); ok {
+		t.Error("Write was applied although the read range was invalid")
+	}
+}
+
+func TestHandleMaskWriteRegister(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+	store := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+
+	// Concurrent requests each setting one bit of register 10 must not
+	// lose each other's updates
+	var wg sync.WaitGroup
+	for bit := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mask := uint16(1) << bit
+			reqData := []byte{0x00, 0x0A, byte(^mask >> 8), byte(^mask), byte(mask >> 8), byte(mask)}
+			if _, err := handler.HandleMaskWriteRegister(ctx, test.NewMockRequest(1, 1, common.FuncMaskWriteRegister, reqData), store); err != nil {
+				t.Errorf("HandleMaskWriteRegister returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if value, _ := store.GetHoldingRegister(10); value != 0xFFFF {
+		t.Errorf("Expected every bit set, got 0x%04X", value)
+	}
+
+	_, err := handler.HandleMaskWriteRegister(ctx, test.NewMockRequest(1, 1, common.FuncMaskWriteRegister, []byte{0x00, 0x64, 0x00, 0x00, 0x00, 0x01}), store)
+	if !common.IsDataAddressNotAvailableError(err) {
+		t.Errorf("Expected Illegal Data Address exception, got %v", err)
+	}
+	_, err = handler.HandleMaskWriteRegister(ctx, test.NewMockRequest(1, 1, common.FuncMaskWriteRegister, []byte{0x00, 0x0A, 0x00, 0x00}), store)
+	if !common.IsInvalidDataValueError(err) {
+		t.Errorf("Expected Illegal Data Value exception, got %v", err)
+	}
+}
+
+// slowReadStore is a store without Atomically whose register reads take a
+// while, widening the window between the read and write of a mask write
+type slowReadStore struct {
+	common.DataStore
+}
+
+func (s slowReadStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	values, err := s.DataStore.ReadHoldingRegisters(ctx, address, quantity)
+	time.Sleep(time.Millisecond)
+	return values, err
+}
+
+func TestHandleMaskWriteRegister_NonTransactionalStore(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+	memory := NewMemoryStore()
+	store := slowReadStore{memory}
+
+	var wg sync.WaitGroup
+	for bit := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mask := uint16(1) << bit
+			reqData := []byte{0x00, 0x0A, byte(^mask >> 8), byte(^mask), byte(mask >> 8), byte(mask)}
+			if _, err := handler.HandleMaskWriteRegister(ctx, test.NewMockRequest(1, 1, common.FuncMaskWriteRegister, reqData), store); err != nil {
+				t.Errorf("HandleMaskWriteRegister returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if value, _ := memory.GetHoldingRegister(10); value != 0xFFFF {
+		t.Errorf("Expected every bit set, got 0x%04X", value)
+	}
+}
+
+func TestHandleMaskWriteRegister_DecoratorsShareBackend(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+	memory := NewMemoryStore()
+	backend := &slowReadStore{memory}
+	var decorators []common.DataStore
+	for range 2 {
+		decorator, err := NewScalingDataStore(backend)
+		if err != nil {
+			t.Fatalf("NewScalingDataStore failed: %v", err)
+		}
+		decorators = append(decorators, decorator)
+	}
+
+	// Mask writes through either decorator are serialized on the backend
+	var wg sync.WaitGroup
+	for bit := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mask := uint16(1) << bit
+			reqData := []byte{0x00, 0x0A, byte(^mask >> 8), byte(^mask), byte(mask >> 8), byte(mask)}
+			if _, err := handler.HandleMaskWriteRegister(ctx, test.NewMockRequest(1, 1, common.FuncMaskWriteRegister, reqData), decorators[bit%2]); err != nil {
+				t.Errorf("HandleMaskWriteRegister returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if value, _ := memory.GetHoldingRegister(10); value != 0xFFFF {
+		t.Errorf("Expected every bit set, got 0x%04X", value)
+	}
+}
+
+// emptyReadStore answers register reads with no values
+type emptyReadStore struct {
+	common.DataStore
+}
+
+func (s emptyReadStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	return nil, nil
+}
+
+func TestHandleMaskWriteRegister_EmptyRead(t *testing.T) {
+	handler := newServerProtocolHandler()
+	reqData := []byte{0x00, 0x0A, 0xFF, 0x00, 0x00, 0x12}
+	_, err := handler.HandleMaskWriteRegister(context.Background(), test.NewMockRequest(1, 1, common.FuncMaskWriteRegister, reqData), emptyReadStore{NewMemoryStore()})
+	if !common.IsExceptionError(err, common.ExceptionServerDeviceFailure) {
+		t.Errorf("Expected Server Device Failure exception, got %v", err)
+	}
+}
+
+// blockingStore is a store without Atomically whose register writes wait
+// until released
+type blockingStore struct {
+	common.DataStore
+	release chan struct{}
+}
+
+func (s *blockingStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	<-s.release
+	return s.DataStore.WriteSingleRegister(ctx, address, value)
+}
+
+func TestHandleWriteSingleRegister_CommitsPerStore(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+	slow := &blockingStore{DataStore: NewMemoryStore(), release: make(chan struct{})}
+	fast := NewMemoryStore()
+	request := func() common.Request {
+		return test.NewMockRequest(1, 1, common.FuncWriteSingleRegister, []byte{0x00, 0x0A, 0x12, 0x34})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.HandleWriteSingleRegister(ctx, request(), slow)
+	}()
+
+	// A commit stuck in one store does not hold up writes to another
+	written := make(chan error, 1)
+	go func() {
+		_, err := handler.HandleWriteSingleRegister(ctx, request(), fast)
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			t.Errorf("HandleWriteSingleRegister returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected a write to another store not to wait for the blocked commit")
+	}
+	close(slow.release)
+	<-done
+}
+
+// fifoStore serves one queue of values at address 7
+type fifoStore struct {
+	*MemoryStore
+	queue []common.RegisterValue
+}
+
+func (s *fifoStore) ReadFIFOQueue(ctx context.Context, pointerAddress common.Address) ([]common.RegisterValue, error) {
+	if pointerAddress != 7 {
+		return nil, common.ErrInvalidAddress
+	}
+	return s.queue, nil
+}
+
+func TestHandleReadFIFOQueue(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+	read := func(store common.DataStore, address uint16) (common.Response, error) {
+		return handler.HandleReadFIFOQueue(ctx, test.NewMockRequest(1, 1, common.FuncReadFIFOQueue, []byte{byte(address >> 8), byte(address)}), store)
+	}
+
+	// Without a FIFOQueueStore the count register is followed by the values
+	store := NewMemoryStore()
+	store.SetHoldingRegister(100, 2)
+	store.SetHoldingRegister(101, 0x1234)
+	store.SetHoldingRegister(102, 0x5678)
+	resp, err := read(store, 100)
+	expected := []byte{0x00, 0x06, 0x00, 0x02, 0x12, 0x34, 0x56, 0x78}
+	if err != nil || !bytes.Equal(resp.GetPDU().Data, expected) {
+		t.Errorf("Expected % X, got %v (%v)", expected, resp, err)
+	}
+
+	resp, err = read(store, 200)
+	if err != nil || !bytes.Equal(resp.GetPDU().Data, []byte{0x00, 0x02, 0x00, 0x00}) {
+		t.Errorf("Expected an empty queue, got %v (%v)", resp, err)
+	}
+
+	store.SetHoldingRegister(300, 32)
+	if _, err := read(store, 300); !common.IsInvalidDataValueError(err) {
+		t.Errorf("Expected Illegal Data Value for a count over 31, got %v", err)
+	}
+
+	queued := &fifoStore{MemoryStore: NewMemoryStore(), queue: []common.RegisterValue{0x0102}}
+	resp, err = read(queued, 7)
+	if err != nil || !bytes.Equal(resp.GetPDU().Data, []byte{0x00, 0x04, 0x00, 0x01, 0x01, 0x02}) {
+		t.Errorf("Expected the store's queue, got %v (%v)", resp, err)
+	}
+	if _, err := read(queued, 8); !common.IsDataAddressNotAvailableError(err) {
+		t.Errorf("Expected Illegal Data Address, got %v", err)
+	}
+	queued.queue = make([]common.RegisterValue, 32)
+	if _, err := read(queued, 7); !common.IsInvalidDataValueError(err) {
+		t.Errorf("Expected Illegal Data Value for a queue over 31, got %v", err)
+	}
+}
+
+func TestHandleFileRecord(t *testing.T) {
+	handler := newServerProtocolHandler()
+	ctx := context.Background()
+	store := NewMemoryStore()
+	read := func(store common.DataStore, data ...byte) (common.Response, error) {
+		return handler.HandleReadFileRecord(ctx, test.NewMockRequest(1, 1, common.FuncReadFileRecord, data), store)
+	}
+	write := func(store common.DataStore, data ...byte) (common.Response, error) {
+		return handler.HandleWriteFileRecord(ctx, test.NewMockRequest(1, 1, common.FuncWriteFileRecord, data), store)
+	}
+
+	// Records never written read as zero
+	written := []byte{0x09, 0x06, 0x00, 0x02, 0x00, 0x10, 0x00, 0x01, 0xBE, 0xEF}
+	resp, err := write(store, written...)
+	if err != nil || !bytes.Equal(resp.GetPDU().Data, written) {
+		t.Fatalf("Expected the request echoed, got %v (%v)", resp, err)
+	}
+	resp, err = read(store, 0x07, 0x06, 0x00, 0x02, 0x00, 0x10, 0x00, 0x02)
+	expected := []byte{0x06, 0x05, 0x06, 0xBE, 0xEF, 0x00, 0x00}
+	if err != nil || !bytes.Equal(resp.GetPDU().Data, expected) {
+		t.Errorf("Expected % X, got %v (%v)", expected, resp, err)
+	}
+
+	// Stores without file records do not support the functions
+	if _, err := read(test.NewMockDataStore(), 0x07, 0x06, 0x00, 0x02, 0x00, 0x10, 0x00, 0x01); !common.IsFunctionNotSupportedError(err) {
+		t.Errorf("Expected Illegal Function, got %v", err)
+	}
+	if _, err := write(test.NewMockDataStore(), written...); !common.IsFunctionNotSupportedError(err) {
+		t.Errorf("Expected Illegal Function, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		handle  func(common.DataStore, ...byte) (common.Response, error)
+		data    []byte
+		address bool
+	}{
+		{"read with the wrong reference type", read, []byte{0x07, 0x07, 0x00, 0x02, 0x00, 0x10, 0x00, 0x01}, true},
+		{"read past the last record", read, []byte{0x07, 0x06, 0x00, 0x02, 0x27, 0x0F, 0x00, 0x02}, true},
+		{"read with a wrong byte count", read, []byte{0x08, 0x06, 0x00, 0x02, 0x00, 0x10, 0x00, 0x01}, false},
+		{"read of no registers", read, []byte{0x07, 0x06, 0x00, 0x02, 0x00, 0x10, 0x00, 0x00}, false},
+		{"write with the wrong reference type", write, []byte{0x09, 0x07, 0x00, 0x02, 0x00, 0x10, 0x00, 0x01, 0xBE, 0xEF}, true},
+		{"write past the last record", write, []byte{0x09, 0x06, 0x00, 0x02, 0x27, 0x10, 0x00, 0x01, 0xBE, 0xEF}, true},
+		{"write with short record data", write, []byte{0x09, 0x06, 0x00, 0x02, 0x00, 0x10, 0x00, 0x02, 0xBE, 0xEF}, false},
+	}
+	for _, tc := range tests {
+		_, err := tc.handle(store, tc.data...)
+		if tc.address && !common.IsDataAddressNotAvailableError(err) {
+			t.Errorf("%s: expected Illegal Data Address, got %v", tc.name, err)
+		}
+		if !tc.address && !common.IsInvalidDataValueError(err) {
+			t.Errorf("%s: expected Illegal Data Value, got %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Quality tells whether a value served by a CachingDataStore has been
+// confirmed by the backend
+type Quality int
+
+const (
+	// QualityGood is a value read from the backend
+	QualityGood Quality = iota
+
+	// QualityUnconfirmed is a value written through the cache that no
+	// backend read has returned yet, see WithReadYourWrites
+	QualityUnconfirmed
+)
+
+// String returns the name of the quality
+func (q Quality) String() string {
+	switch q {
+	case QualityGood:
+		return "Good"
+	case QualityUnconfirmed:
+		return "Unconfirmed"
+	default:
+		return "Unknown"
+	}
+}
+
+// writtenKey identifies one written coil or holding register
+type writtenKey struct {
+	table   common.Table
+	address common.Address
+}
+
+// writtenValue is a value served in place of backend reads until confirmed
+type writtenValue struct {
+	value   any
+	expires time.Time
+}
+
+// recordWritten remembers values written through the cache when
+// read-your-writes is enabled
+func recordWritten[T comparable](c *CachingDataStore, table common.Table, address common.Address, values []T) {
+	if c.hold <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.hold)
+	for i, value := range values {
+		c.written[writtenKey{table, address + common.Address(i)}] = writtenValue{value: value, expires: expires}
+	}
+}
+
+// overlayWritten replaces values read at address with values written but not
+// yet confirmed. With confirm set, values is a fresh backend read: written
+// values it matches are confirmed and forgotten. c.mu must be held.
+func overlayWritten[T comparable](c *CachingDataStore, table common.Table, address common.Address, values []T, confirm bool) []T {
+	if len(c.written) == 0 {
+		return values
+	}
+	now := c.now()
+	for i := range values {
+		key := writtenKey{table, address + common.Address(i)}
+		written, ok := c.written[key]
+		if !ok {
+			continue
+		}
+		value := written.value.(T)
+		switch {
+		case !now.Before(written.expires):
+			delete(c.written, key)
+		case confirm && values[i] == value:
+			delete(c.written, key)
+		default:
+			values[i] = value
+		}
+	}
+	return values
+}
+
+// Quality returns the quality of the value at address: QualityUnconfirmed
+// while a value written through the cache is served in place of the
+// backend's, QualityGood otherwise
+func (c *CachingDataStore) Quality(table common.Table, address common.Address) Quality {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if written, ok := c.written[writtenKey{table, address}]; ok && c.now().Before(written.expires) {
+		return QualityUnconfirmed
+	}
+	return QualityGood
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// RecordedRequest is a single request captured by a Recorder.
+type RecordedRequest struct {
+	// RemoteAddr is the remote address of the client that sent the request.
+	RemoteAddr string
+
+	// ReceivedAt is the time the request was read from the connection.
+	ReceivedAt time.Time
+
+	// TransactionID is the MBAP transaction ID of the request.
+	TransactionID common.TransactionID
+
+	// UnitID is the unit ID the request was addressed to.
+	UnitID common.UnitID
+
+	// PDU is a copy of the request PDU (function code and data).
+	PDU common.PDU
+}
+
+// String returns the transcript line for the request, e.g.
+// "unit=1 fc=0x03 data=07d00002". Transaction IDs and timestamps are
+// omitted so transcripts are stable across runs.
+func (r RecordedRequest) String() string {
+	return formatTranscriptLine(r.UnitID, r.PDU)
+}
+
+// Recorder captures every request received by a TCPServer in arrival order.
+// It is intended for golden-transaction tests: a test drives a client against
+// the server and then asserts the exact ordered list of PDUs that reached the
+// wire, so client refactors can prove they remain wire compatible.
+// A Recorder is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	requests []RecordedRequest
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// WithServerRecorder attaches a Recorder to the server. Every request read
+// from any connection is recorded before it is dispatched.
+func WithServerRecorder(recorder *Recorder) TCPServerOption {
+	return func(s *TCPServer) {
+		s.recorder = recorder
+	}
+}
+
+// record appends a copy of the request, received at receivedAt, to the
+// recording.
+func (r *Recorder) record(remoteAddr string, request common.Request, receivedAt time.Time) {
+	pdu := request.GetPDU()
+	data := make([]byte, len(pdu.Data))
+	copy(data, pdu.Data)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, RecordedRequest{
+		RemoteAddr:    remoteAddr,
+		ReceivedAt:    receivedAt,
+		TransactionID: request.GetTransactionID(),
+		UnitID:        request.GetUnitID(),
+		PDU:           common.PDU{FunctionCode: pdu.FunctionCode, Data: data},
+	})
+}
+
+// Requests returns a copy of all recorded requests in arrival order.
+func (r *Recorder) Requests() []RecordedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	requests := make([]RecordedRequest, len(r.requests))
+	copy(requests, r.requests)
+	return requests
+}
+
+// PDUs returns the recorded request PDUs in arrival order.
+func (r *Recorder) PDUs() []common.PDU {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pdus := make([]common.PDU, len(r.requests))
+	for i, req := range r.requests {
+		pdus[i] = req.PDU
+	}
+	return pdus
+}
+
+// Len returns the number of recorded requests.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// Reset discards all recorded requests.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = nil
+}
+
+// ExpectSequence checks that the recorded PDUs exactly match the expected
+// PDUs, in order. It returns nil on a match, or an error describing the
+// first difference.
+func (r *Recorder) ExpectSequence(expected ...common.PDU) error {
+	actual := r.PDUs()
+
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if expected[i].FunctionCode != actual[i].FunctionCode || !bytes.Equal(expected[i].Data, actual[i].Data) {
+			return fmt.Errorf("request %d: expected fc=0x%02X data=%x, got fc=0x%02X data=%x",
+				i, byte(expected[i].FunctionCode), expected[i].Data, byte(actual[i].FunctionCode), actual[i].Data)
+		}
+	}
+
+	if len(expected) != len(actual) {
+		return fmt.Errorf("expected %d requests, got %d", len(expected), len(actual))
+	}
+	return nil
+}
+
+// Transcript returns the recording as a golden transcript: one line per
+// request in the format produced by RecordedRequest.String.
+func (r *Recorder) Transcript() string {
+	requests := r.Requests()
+
+	var b strings.Builder
+	for _, req := range requests {
+		b.WriteString(req.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ExpectTranscript compares the recording against a golden transcript as
+// produced by Transcript. Blank lines and surrounding whitespace are ignored.
+func (r *Recorder) ExpectTranscript(golden string) error {
+	expected := transcriptLines(golden)
+	actual := transcriptLines(r.Transcript())
+
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if expected[i] != actual[i] {
+			return fmt.Errorf("transcript line %d: expected %q, got %q", i+1, expected[i], actual[i])
+		}
+	}
+
+	if len(expected) != len(actual) {
+		return fmt.Errorf("expected %d transcript lines, got %d", len(expected), len(actual))
+	}
+	return nil
+}
+
+// formatTranscriptLine formats a unit ID and PDU as a transcript line
+func formatTranscriptLine(unitID common.UnitID, pdu common.PDU) string {
+	return fmt.Sprintf("unit=%d fc=0x%02X data=%x", unitID, byte(pdu.FunctionCode), pdu.Data)
+}
+
+// transcriptLines splits a transcript into trimmed, non-empty lines
+func transcriptLines(transcript string) []string {
+	var lines []string
+	for _, line := range strings.Split(transcript, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// sendRawRequest writes a single MBAP-framed request on conn and returns the
+// response PDU (function code followed by data).
+func sendRawRequest(t *testing.T, conn net.Conn, txID uint16, unitID byte, fc common.FunctionCode, data []byte) []byte {
+	t.Helper()
+	writeRawRequest(t, conn, txID, unitID, fc, data)
+	return readRawResponse(t, conn, txID)
+}
+
+// writeRawRequest writes a request frame without waiting for its response
+func writeRawRequest(t *testing.T, conn net.Conn, txID uint16, unitID byte, fc common.FunctionCode, data []byte) {
+	t.Helper()
+
+	frame := make([]byte, common.TCPHeaderLength+1+len(data))
+	binary.BigEndian.PutUint16(frame[0:2], txID)
+	binary.BigEndian.PutUint16(frame[4:6], uint16(2+len(data)))
+	frame[6] = unitID
+	frame[7] = byte(fc)
+	copy(frame[8:], data)
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("Failed to write request: %v", err)
+	}
+}
+
+// readRawResponse reads a response frame and returns its PDU
+func readRawResponse(t *testing.T, conn net.Conn, txID uint16) []byte {
+	t.Helper()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	header := make([]byte, common.TCPHeaderLength)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("Failed to read response header: %v", err)
+	}
+	if got := binary.BigEndian.Uint16(header[0:2]); got != txID {
+		t.Fatalf("Expected response txID %d, got %d", txID, got)
+	}
+
+	pdu := make([]byte, int(binary.BigEndian.Uint16(header[4:6]))-1)
+	if _, err := io.ReadFull(conn, pdu); err != nil {
+		t.Fatalf("Failed to read response PDU: %v", err)
+	}
+	return pdu
+}
+
+func TestRecorder_ExpectSequence(t *testing.T) {
+	recorder := NewRecorder()
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerRecorder(recorder))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x64, 0x00, 0x02})
+	sendRawRequest(t, conn, 2, 1, common.FuncWriteSingleCoil, []byte{0x00, 0x0A, 0xFF, 0x00})
+
+	err = recorder.ExpectSequence(
+		common.PDU{FunctionCode: common.FuncReadHoldingRegisters, Data: []byte{0x00, 0x64, 0x00, 0x02}},
+		common.PDU{FunctionCode: common.FuncWriteSingleCoil, Data: []byte{0x00, 0x0A, 0xFF, 0x00}},
+	)
+	if err != nil {
+		t.Fatalf("ExpectSequence failed: %v", err)
+	}
+
+	// A differing PDU must be reported
+	err = recorder.ExpectSequence(
+		common.PDU{FunctionCode: common.FuncReadHoldingRegisters, Data: []byte{0x00, 0x64, 0x00, 0x03}},
+		common.PDU{FunctionCode: common.FuncWriteSingleCoil, Data: []byte{0x00, 0x0A, 0xFF, 0x00}},
+	)
+	if err == nil || !strings.Contains(err.Error(), "request 0") {
+		t.Errorf("Expected mismatch on request 0, got: %v", err)
+	}
+
+	// A length mismatch must be reported
+	err = recorder.ExpectSequence(
+		common.PDU{FunctionCode: common.FuncReadHoldingRegisters, Data: []byte{0x00, 0x64, 0x00, 0x02}},
+	)
+	if err == nil {
+		t.Error("Expected error for shorter expected sequence")
+	}
+
+	requests := recorder.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 recorded requests, got %d", len(requests))
+	}
+	if requests[1].TransactionID != 2 || requests[1].UnitID != 1 {
+		t.Errorf("Unexpected recorded metadata: %+v", requests[1])
+	}
+
+	recorder.Reset()
+	if recorder.Len() != 0 {
+		t.Errorf("Expected empty recorder after Reset, got %d", recorder.Len())
+	}
+}
+
+func TestRecorder_ReceivedAtUsesServerClock(t *testing.T) {
+	clock := test.NewManualClock(time.Unix(1000, 0))
+	recorder := NewRecorder()
+	_, conn := startTimeoutServer(t, WithServerClock(clock), WithServerRecorder(recorder))
+
+	sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+	clock.Advance(time.Minute)
+	sendRawRequest(t, conn, 2, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+
+	requests := recorder.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 recorded requests, got %d", len(requests))
+	}
+	if !requests[0].ReceivedAt.Equal(time.Unix(1000, 0)) {
+		t.Errorf("Expected the first request at %s, got %s", time.Unix(1000, 0), requests[0].ReceivedAt)
+	}
+	if !requests[1].ReceivedAt.Equal(time.Unix(1060, 0)) {
+		t.Errorf("Expected the second request at %s, got %s", time.Unix(1060, 0), requests[1].ReceivedAt)
+	}
+}
+
+func TestRecorder_Transcript(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.record("test", test.NewMockRequest(1, 1, common.FuncReadCoils, []byte{0x00, 0x00, 0x00, 0x08}), time.Now())
+	recorder.record("test", test.NewMockRequest(2, 2, common.FuncWriteSingleRegister, []byte{0x00, 0x01, 0x12, 0x34}), time.Now())
+
+	golden := `
+		unit=1 fc=0x01 data=00000008
+		unit=2 fc=0x06 data=00011234
+	`
+	if err := recorder.ExpectTranscript(golden); err != nil {
+		t.Fatalf("ExpectTranscript failed: %v\ntranscript:\n%s", err, recorder.Transcript())
+	}
+
+	if err := recorder.ExpectTranscript("unit=1 fc=0x01 data=00000008"); err == nil {
+		t.Error("Expected error for truncated golden transcript")
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// WithServerResponseDelay delays the responses to the given function codes,
+// to emulate devices that are slow but correct. Exception responses are
+// delayed like normal ones; function codes not in the map answer at once.
+// Requests on one connection are answered in order, so a delayed response
+// also holds back the requests pipelined behind it, as on a real device
+// processing one request at a time.
+func WithServerResponseDelay(delays map[common.FunctionCode]time.Duration) TCPServerOption {
+	return func(s *TCPServer) {
+		s.responseDelays = make(map[common.FunctionCode]time.Duration, len(delays))
+		for fc, delay := range delays {
+			s.responseDelays[fc] = delay
+		}
+	}
+}
+
+// WithServerResponseJitter adds a random duration in [0, jitter) to every
+// response delayed by WithServerResponseDelay
+func WithServerResponseJitter(jitter time.Duration) TCPServerOption {
+	return func(s *TCPServer) {
+		s.responseJitter = jitter
+	}
+}
+
+// delayResponse waits for the configured response delay of the function
+// code. It returns early when the server stops.
+func (s *TCPServer) delayResponse(functionCode common.FunctionCode) {
+	delay, ok := s.responseDelays[functionCode]
+	if !ok {
+		return
+	}
+	if s.responseJitter > 0 {
+		delay += rand.N(s.responseJitter)
+	}
+	if delay <= 0 {
+		return
+	}
+
+	select {
+	case <-s.clock.After(delay):
+	case <-s.stopChan:
+	}
+}
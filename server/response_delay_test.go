@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestTCPServer_ResponseDelay(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0),
+		WithServerResponseDelay(map[common.FunctionCode]time.Duration{
+			common.FuncReadHoldingRegisters: 100 * time.Millisecond,
+		}),
+		WithServerResponseJitter(20*time.Millisecond),
+	)
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the response to be delayed by at least 100ms, took %s", elapsed)
+	}
+
+	// Exception responses are delayed too
+	start = time.Now()
+	pdu := sendRawRequest(t, conn, 2, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x00})
+	if pdu[0] != byte(common.FuncReadHoldingRegisters)|common.ExceptionBit {
+		t.Fatalf("Expected an exception response, got % X", pdu)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the exception to be delayed by at least 100ms, took %s", elapsed)
+	}
+
+	// Function codes without a delay answer at once
+	start = time.Now()
+	sendRawRequest(t, conn, 3, 1, common.FuncReadCoils, []byte{0x00, 0x00, 0x00, 0x01})
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected an immediate response, took %s", elapsed)
+	}
+}
+
+func TestTCPServer_ResponseDelayUsesServerClock(t *testing.T) {
+	clock := test.NewManualClock(time.Unix(1000, 0))
+	_, conn := startTimeoutServer(t,
+		WithServerClock(clock),
+		WithServerResponseDelay(map[common.FunctionCode]time.Duration{
+			common.FuncReadHoldingRegisters: time.Hour,
+		}),
+	)
+
+	writeRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for clock.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the server to delay the response")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Real time passing does not release the response
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Expected the response to be held until the clock advances")
+	}
+
+	clock.Advance(time.Hour)
+	readRawResponse(t, conn, 1)
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ErrRevisionMismatch is returned by CompareAndWrite when the store was
+// written since the caller read its revision
+var ErrRevisionMismatch = errors.New("store revision changed")
+
+// Revision returns the store's revision, which every write increments:
+// Modbus writes, Set* calls and writes made in Atomically, including ones
+// rolled back. Tools editing the store outside Modbus read it before
+// computing a change and pass it to CompareAndWrite.
+func (s *MemoryStore) Revision() uint64 {
+	return s.revision.Load()
+}
+
+// CompareAndWrite writes value to the holding register at address only if
+// the store's revision still equals expectedRev, so an external tool does
+// not clobber a value a Modbus client wrote after the tool read the store.
+// It returns the revision after the write, or the current revision and
+// ErrRevisionMismatch if the store has changed.
+func (s *MemoryStore) CompareAndWrite(address common.Address, expectedRev uint64, value common.RegisterValue) (uint64, error) {
+	if err := s.ValidateRange(context.Background(), common.TableHoldingRegisters, address, 1); err != nil {
+		return s.Revision(), err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Writers bump the revision while holding s.mu, so it cannot change here
+	if current := s.revision.Load(); current != expectedRev {
+		return current, ErrRevisionMismatch
+	}
+	s.holdingRegisters[address] = value
+	return s.revision.Add(1), nil
+}
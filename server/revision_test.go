@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestMemoryStore_CompareAndWrite(t *testing.T) {
+	store := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+	ctx := context.Background()
+
+	rev := store.Revision()
+	store.SetHoldingRegister(1, 10)
+	store.WriteMultipleRegisters(ctx, 2, []uint16{1, 2})
+	if store.Revision() != rev+2 {
+		t.Fatalf("Expected two writes to add 2 to the revision, got %d -> %d", rev, store.Revision())
+	}
+
+	// A tool reads the revision, then a Modbus client writes
+	rev = store.Revision()
+	store.WriteSingleRegister(ctx, 1, 20)
+
+	current, err := store.CompareAndWrite(1, rev, 30)
+	if !errors.Is(err, ErrRevisionMismatch) || current != rev+1 {
+		t.Fatalf("Expected a mismatch at revision %d, got %d, %v", rev+1, current, err)
+	}
+	if v, _ := store.GetHoldingRegister(1); v != 20 {
+		t.Errorf("Expected the client's value to survive, got %d", v)
+	}
+
+	next, err := store.CompareAndWrite(1, current, 30)
+	if err != nil || next != current+1 {
+		t.Fatalf("Expected the write to succeed at revision %d, got %d, %v", current+1, next, err)
+	}
+	if v, _ := store.GetHoldingRegister(1); v != 30 {
+		t.Errorf("Expected 30, got %d", v)
+	}
+
+	if _, err := store.CompareAndWrite(100, next, 1); err == nil {
+		t.Error("Expected an error outside the store's range")
+	}
+
+	// Writes made atomically count too
+	rev = store.Revision()
+	store.Atomically(ctx, func(tx common.DataStore) error {
+		return tx.WriteSingleRegister(ctx, 5, 5)
+	})
+	if store.Revision() != rev+1 {
+		t.Errorf("Expected Atomically writes to bump the revision")
+	}
+}
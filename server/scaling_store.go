@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// ScaledRange converts the registers of a range between raw counts on the
+// wire and engineering units in the store: engineering = raw*Scale + Offset.
+// For example a store holding a level in percent exposed as the counts of a
+// 0-27648 analog input has a Scale of 100.0/27648.
+type ScaledRange struct {
+	Table    common.Table // TableHoldingRegisters, TableInputRegisters or both
+	Address  common.Address
+	Quantity common.Quantity
+	Scale    float64 // engineering units per raw count, not 0
+	Offset   float64 // engineering value of raw count 0
+
+	// RawSigned and Signed interpret the wire registers and the store's
+	// registers as int16 rather than uint16
+	RawSigned bool
+	Signed    bool
+}
+
+// contains reports whether the range covers an address of table
+func (r ScaledRange) contains(table common.Table, address int) bool {
+	return r.Table&table != 0 && address >= int(r.Address) && address < int(r.Address)+int(r.Quantity)
+}
+
+// registerRange returns the bounds of a register interpreted as signed or not
+func registerRange(signed bool) (float64, float64) {
+	if signed {
+		return math.MinInt16, math.MaxInt16
+	}
+	return 0, math.MaxUint16
+}
+
+// toFloat interprets a register as signed or not
+func toFloat(value uint16, signed bool) float64 {
+	if signed {
+		return float64(int16(value))
+	}
+	return float64(value)
+}
+
+// toRaw converts an engineering value from the store to a wire register,
+// saturating at the limits of the register as analog modules do
+func (r ScaledRange) toRaw(value uint16) uint16 {
+	low, high := registerRange(r.RawSigned)
+	raw := math.Round((toFloat(value, r.Signed) - r.Offset) / r.Scale)
+	return uint16(int32(min(max(raw, low), high)))
+}
+
+// toStore converts a wire register to the engineering value stored, failing
+// with Illegal Data Value when the store's register cannot hold it
+func (r ScaledRange) toStore(raw uint16) (uint16, error) {
+	low, high := registerRange(r.Signed)
+	value := math.Round(toFloat(raw, r.RawSigned)*r.Scale + r.Offset)
+	if value < low || value > high {
+		return 0, fmt.Errorf("%w: %v is out of range for the store", common.ErrInvalidValue, value)
+	}
+	return uint16(int32(value)), nil
+}
+
+// ScalingDataStore wraps a DataStore holding engineering values and exposes
+// the registers of its ScaledRanges as raw counts on the wire, so a
+// simulator can keep its data in engineering units while serving the
+// integer registers a real device would. Registers outside the ranges, and
+// coils and discrete inputs, pass through unchanged.
+type ScalingDataStore struct {
+	store  common.DataStore
+	ranges []ScaledRange
+}
+
+// NewScalingDataStore creates a scaling decorator around store. Ranges must
+// not overlap and must be in the register tables.
+func NewScalingDataStore(store common.DataStore, ranges ...ScaledRange) (*ScalingDataStore, error) {
+	for i, r := range ranges {
+		if r.Scale == 0 || math.IsNaN(r.Scale) || math.IsInf(r.Scale, 0) {
+			return nil, fmt.Errorf("scaled range %d: scale %v: %w", i, r.Scale, common.ErrInvalidValue)
+		}
+		if r.Table == 0 || r.Table&^(common.TableHoldingRegisters|common.TableInputRegisters) != 0 {
+			return nil, fmt.Errorf("scaled range %d: table %s: %w", i, r.Table, common.ErrInvalidValue)
+		}
+		if r.Quantity == 0 || int(r.Address)+int(r.Quantity) > 0x10000 {
+			return nil, fmt.Errorf("scaled range %d: %w", i, common.ErrInvalidQuantity)
+		}
+		for _, other := range ranges[:i] {
+			if other.Table&r.Table != 0 && int(r.Address) < int(other.Address)+int(other.Quantity) &&
+				int(other.Address) < int(r.Address)+int(r.Quantity) {
+				return nil, fmt.Errorf("scaled range %d overlaps another: %w", i, common.ErrInvalidAddress)
+			}
+		}
+	}
+	return &ScalingDataStore{store: store, ranges: ranges}, nil
+}
+
+// rangeOf returns the range covering an address of table
+func (s *ScalingDataStore) rangeOf(table common.Table, address int) (ScaledRange, bool) {
+	for _, r := range s.ranges {
+		if r.contains(table, address) {
+			return r, true
+		}
+	}
+	return ScaledRange{}, false
+}
+
+// toWire converts registers read from the store
+func (s *ScalingDataStore) toWire(table common.Table, address common.Address, values []uint16) []uint16 {
+	values = copyValues(values)
+	for i := range values {
+		if r, ok := s.rangeOf(table, int(address)+i); ok {
+			values[i] = r.toRaw(values[i])
+		}
+	}
+	return values
+}
+
+// fromWire converts registers to be written to the store
+func (s *ScalingDataStore) fromWire(table common.Table, address common.Address, values []uint16) ([]uint16, error) {
+	converted := copyValues(values)
+	for i := range converted {
+		if r, ok := s.rangeOf(table, int(address)+i); ok {
+			value, err := r.toStore(values[i])
+			if err != nil {
+				return nil, common.NewModbusError(common.FuncWriteMultipleRegisters, common.ExceptionInvalidDataValue)
+			}
+			converted[i] = value
+		}
+	}
+	return converted, nil
+}
+
+// ReadCoils reads coils from the wrapped store
+func (s *ScalingDataStore) ReadCoils(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.CoilValue, error) {
+	return s.store.ReadCoils(ctx, address, quantity)
+}
+
+// ReadDiscreteInputs reads discrete inputs from the wrapped store
+func (s *ScalingDataStore) ReadDiscreteInputs(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.DiscreteInputValue, error) {
+	return s.store.ReadDiscreteInputs(ctx, address, quantity)
+}
+
+// ReadHoldingRegisters reads holding registers from the wrapped store,
+// converting scaled ones to raw counts
+func (s *ScalingDataStore) ReadHoldingRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.RegisterValue, error) {
+	values, err := s.store.ReadHoldingRegisters(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return s.toWire(common.TableHoldingRegisters, address, values), nil
+}
+
+// ReadInputRegisters reads input registers from the wrapped store,
+// converting scaled ones to raw counts
+func (s *ScalingDataStore) ReadInputRegisters(ctx context.Context, address common.Address, quantity common.Quantity) ([]common.InputRegisterValue, error) {
+	values, err := s.store.ReadInputRegisters(ctx, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return s.toWire(common.TableInputRegisters, address, values), nil
+}
+
+// WriteSingleCoil writes to the wrapped store
+func (s *ScalingDataStore) WriteSingleCoil(ctx context.Context, address common.Address, value common.CoilValue) error {
+	return s.store.WriteSingleCoil(ctx, address, value)
+}
+
+// WriteSingleRegister writes to the wrapped store, converting a scaled
+// register to engineering units
+func (s *ScalingDataStore) WriteSingleRegister(ctx context.Context, address common.Address, value common.RegisterValue) error {
+	if r, ok := s.rangeOf(common.TableHoldingRegisters, int(address)); ok {
+		converted, err := r.toStore(value)
+		if err != nil {
+			return common.NewModbusError(common.FuncWriteSingleRegister, common.ExceptionInvalidDataValue)
+		}
+		value = converted
+	}
+	return s.store.WriteSingleRegister(ctx, address, value)
+}
+
+// WriteMultipleCoils writes to the wrapped store
+func (s *ScalingDataStore) WriteMultipleCoils(ctx context.Context, address common.Address, values []common.CoilValue) error {
+	return s.store.WriteMultipleCoils(ctx, address, values)
+}
+
+// WriteMultipleRegisters writes to the wrapped store, converting scaled
+// registers to engineering units. Nothing is written if any of them is out
+// of range for the store.
+func (s *ScalingDataStore) WriteMultipleRegisters(ctx context.Context, address common.Address, values []common.RegisterValue) error {
+	converted, err := s.fromWire(common.TableHoldingRegisters, address, values)
+	if err != nil {
+		return err
+	}
+	return s.store.WriteMultipleRegisters(ctx, address, converted)
+}
+
+// wrapped returns the wrapped store
+func (s *ScalingDataStore) wrapped() common.DataStore {
+	return s.store
+}
+
+// ValidateRange delegates to the wrapped store if it validates ranges
+func (s *ScalingDataStore) ValidateRange(ctx context.Context, table common.Table, address common.Address, quantity common.Quantity) error {
+	if validator, ok := s.store.(common.RangeValidator); ok {
+		return validator.ValidateRange(ctx, table, address, quantity)
+	}
+	return nil
+}
+
+// Atomically calls fn with a scaling view of a transaction of the wrapped
+// store. Writes are only rolled back if the wrapped store implements
+// common.TransactionalDataStore.
+func (s *ScalingDataStore) Atomically(ctx context.Context, fn func(tx common.DataStore) error) error {
+	return atomicallyOn(ctx, s.store, func(tx common.DataStore) error {
+		return fn(&ScalingDataStore{store: tx, ranges: s.ranges})
+	})
+}
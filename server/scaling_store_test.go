@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestScalingDataStore(t *testing.T) {
+	backing := NewMemoryStore()
+	store, err := NewScalingDataStore(backing,
+		// Level in percent as the counts of a 0-27648 analog input
+		ScaledRange{Table: common.TableInputRegisters, Address: 0, Quantity: 2, Scale: 100.0 / 27648},
+		// Setpoint in degrees as signed tenths of a degree, offset by 10
+		ScaledRange{Table: common.TableHoldingRegisters, Address: 10, Quantity: 1, Scale: 0.1, Offset: 10, RawSigned: true, Signed: true},
+		// Energy in Wh as counts of 10 Wh
+		ScaledRange{Table: common.TableHoldingRegisters, Address: 20, Quantity: 1, Scale: 10},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	ctx := context.Background()
+	backing.SetInputRegister(0, 50)
+	backing.SetInputRegister(1, 300) // saturates
+	backing.SetInputRegister(2, 7)   // not scaled
+	values, err := store.ReadInputRegisters(ctx, 0, 3)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if want := []common.InputRegisterValue{13824, 65535, 7}; !slices.Equal(values, want) {
+		t.Errorf("Expected %v, got %v", want, values)
+	}
+
+	// Raw -50 is 10 + -5.0 = 5 degrees
+	if err := store.WriteMultipleRegisters(ctx, 9, []common.RegisterValue{1, 0xFFCE}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got, _ := backing.GetHoldingRegister(10); got != 5 {
+		t.Errorf("Expected 5 degrees in the store, got %d", got)
+	}
+	if got, _ := backing.GetHoldingRegister(9); got != 1 {
+		t.Errorf("Expected the unscaled register to pass through, got %d", got)
+	}
+	holding, _ := store.ReadHoldingRegisters(ctx, 10, 1)
+	if holding[0] != 0xFFCE {
+		t.Errorf("Expected raw -50, got %d", int16(holding[0]))
+	}
+
+	// Values the store cannot hold are rejected without writing anything
+	err = store.WriteMultipleRegisters(ctx, 19, []common.RegisterValue{1, 6554})
+	if !common.IsInvalidDataValueError(err) {
+		t.Errorf("Expected Illegal Data Value, got %v", err)
+	}
+	if got, _ := backing.GetHoldingRegister(19); got != 0 {
+		t.Errorf("Expected nothing written, got %d", got)
+	}
+	if err := store.WriteSingleRegister(ctx, 20, 6554); !common.IsInvalidDataValueError(err) {
+		t.Errorf("Expected Illegal Data Value, got %v", err)
+	}
+}
+
+func TestNewScalingDataStore_Invalid(t *testing.T) {
+	for name, ranges := range map[string][]ScaledRange{
+		"zero scale": {{Table: common.TableHoldingRegisters, Quantity: 1}},
+		"coils":      {{Table: common.TableCoils, Quantity: 1, Scale: 1}},
+		"empty":      {{Table: common.TableHoldingRegisters, Scale: 1}},
+		"overlap": {
+			{Table: common.TableHoldingRegisters, Address: 0, Quantity: 10, Scale: 1},
+			{Table: common.TableHoldingRegisters | common.TableInputRegisters, Address: 9, Quantity: 1, Scale: 1},
+		},
+	} {
+		if _, err := NewScalingDataStore(NewMemoryStore(), ranges...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if !errors.Is(err, common.ErrInvalidValue) && !errors.Is(err, common.ErrInvalidQuantity) && !errors.Is(err, common.ErrInvalidAddress) {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestScalingDataStore_AtomicallyRollsBack(t *testing.T) {
+	backing := NewMemoryStore(WithMemoryStoreRange(common.TableHoldingRegisters, 0, 99))
+	backing.SetHoldingRegister(0, 1000)
+	store, err := NewScalingDataStore(backing, ScaledRange{Table: common.TableHoldingRegisters, Address: 0, Quantity: 2, Scale: 10})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	err = store.Atomically(ctx, func(tx common.DataStore) error {
+		if err := tx.WriteMultipleRegisters(ctx, 0, []common.RegisterValue{5, 6}); err != nil {
+			return err
+		}
+		// The view scales like the store
+		if values, _ := tx.ReadHoldingRegisters(ctx, 0, 2); !slices.Equal(values, []common.RegisterValue{5, 6}) {
+			t.Errorf("Expected scaled values inside the transaction, got %v", values)
+		}
+		return tx.WriteMultipleRegisters(ctx, 99, []common.RegisterValue{1, 2})
+	})
+	if !errors.Is(err, common.ErrInvalidAddress) {
+		t.Fatalf("Expected ErrInvalidAddress, got %v", err)
+	}
+	if value, _ := backing.GetHoldingRegister(0); value != 1000 {
+		t.Errorf("Expected register 0 restored to 1000, got %d", value)
+	}
+	if _, ok := backing.GetHoldingRegister(1); ok {
+		t.Error("Expected register 1 to be removed again")
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestTCPServer_SwapDataStore(t *testing.T) {
+	oldStore := NewMemoryStore()
+	oldStore.SetHoldingRegister(0, 1)
+	oldStore.SetHoldingRegister(1, 42)
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerDataStore(oldStore))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	readRegister := func(txID uint16, address byte) uint16 {
+		pdu := sendRawRequest(t, conn, txID, 1, common.FuncReadHoldingRegisters, []byte{0x00, address, 0x00, 0x01})
+		if pdu[0] != byte(common.FuncReadHoldingRegisters) {
+			t.Fatalf("Read failed: % X", pdu)
+		}
+		return binary.BigEndian.Uint16(pdu[2:])
+	}
+	if v := readRegister(1, 0); v != 1 {
+		t.Fatalf("Expected 1 from the old store, got %d", v)
+	}
+
+	// A failed migration keeps the old store
+	newStore := NewMemoryStore()
+	newStore.SetHoldingRegister(0, 2)
+	failed := errors.New("bad image")
+	err = srv.SwapDataStore(newStore, func(old, new common.DataStore) error { return failed })
+	if !errors.Is(err, failed) {
+		t.Fatalf("Expected the migration error, got %v", err)
+	}
+	if v := readRegister(2, 0); v != 1 {
+		t.Errorf("Expected the old store after a failed migration, got %d", v)
+	}
+
+	// The same session sees the new store, with migrated state
+	err = srv.SwapDataStore(newStore, func(old, new common.DataStore) error {
+		values, err := old.ReadHoldingRegisters(ctx, 1, 1)
+		if err != nil {
+			return err
+		}
+		return new.WriteSingleRegister(ctx, 1, values[0])
+	})
+	if err != nil {
+		t.Fatalf("SwapDataStore failed: %v", err)
+	}
+	if v := readRegister(3, 0); v != 2 {
+		t.Errorf("Expected 2 from the new store, got %d", v)
+	}
+	if v := readRegister(4, 1); v != 42 {
+		t.Errorf("Expected the migrated value 42, got %d", v)
+	}
+}
+
+func TestTCPServer_SwapDataStoreConcurrent(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0))
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Swap continuously while the session keeps reading
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				srv.SwapDataStore(NewMemoryStore(), nil)
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+
+	for n := 0; n < 200; n++ {
+		pdu := sendRawRequest(t, conn, uint16(n), 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+		if pdu[0] != byte(common.FuncReadHoldingRegisters) {
+			t.Fatalf("Read failed during swaps: % X", pdu)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
@@ -2,16 +2,20 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"iter"
 	"net"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
 	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/protocol"
 	"github.com/Moonlight-Companies/gomodbus/transport"
 )
 
@@ -28,8 +32,10 @@ type TCPServer struct {
 	// Function code handlers map
 	handlers     map[common.FunctionCode]common.HandlerFunc
 
-	// Data storage
+	// Data storage; handlers run under storeMutex.RLock so SwapDataStore can
+	// replace the store between requests
 	defaultStore common.DataStore
+	storeMutex   sync.RWMutex
 
 	// Server state
 	running      bool
@@ -42,6 +48,56 @@ type TCPServer struct {
 	// Client lifecycle callbacks
 	onClientConnect    func(ConnectedClient)
 	onClientDisconnect func(ConnectedClient)
+	onClientsChanged   func([]ConnectedClient)
+	clientsChangedMu   sync.Mutex
+
+	// Lifecycle events for Events()
+	events common.EventStream
+
+	// Optional request recorder for golden-transaction testing
+	recorder *Recorder
+
+	// Emulated processing time per function code
+	responseDelays map[common.FunctionCode]time.Duration
+	responseJitter time.Duration
+
+	// Retransmitted write detection, see WithRequestDedup
+	dedupWindow time.Duration
+	dedupSize   int
+
+	// Store check interval of watches, see WithChangeNotifications
+	watchInterval time.Duration
+
+	// Data visibility per unit ID, see WithServerUnitPolicy
+	unitPolicies map[common.UnitID]*UnitPolicy
+
+	// Stores of emulated units, and the exception answering other units,
+	// see WithUnitDataStore
+	unitStores  map[common.UnitID]common.DataStore
+	unknownUnit common.ExceptionCode
+
+	// Forwards every request when set, see WithServerGateway
+	gateway *Gateway
+
+	// Counters for WithMetricsListener and WriteMetrics
+	metrics       serverMetrics
+	metricsServer *metricsServer
+
+	// Hosts banned for protocol violations, see WithViolationBan
+	bans *banList
+
+	// Handling of frames longer than a PDU, see WithServerOversizedFramePolicy
+	oversized common.OversizedFramePolicy
+
+	// Time source and connection timeouts, see WithServerClock
+	clock         common.Clock
+	readTimeout   time.Duration
+	idleTimeout   time.Duration
+	shutdownGrace time.Duration
+
+	// Modbus/TCP Security, see WithServerTLSConfig and WithServerAuthorizer
+	tlsConfig  *tls.Config
+	authorizer Authorizer
 
 	// Protocol handler for processing requests
 	protocol     *serverProtocolHandler
@@ -84,6 +140,20 @@ func WithServerListener(listener net.Listener) TCPServerOption {
 	}
 }
 
+// WithServerLegacyWrites disables write staging. By default a write request is
+// validated against the whole address range before anything is written and
+// committed atomically when the data store implements
+// common.TransactionalDataStore. In legacy mode writes go straight to the
+// store, which may apply part of a request before failing, and are not
+// serialized, so the read and write of a Mask Write Register may interleave
+// with other writes.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12 (Write Multiple Registers)
+func WithServerLegacyWrites() TCPServerOption {
+	return func(s *TCPServer) {
+		s.protocol.legacyWrites = true
+	}
+}
+
 // WithOnClientConnect sets a callback that fires when a new client connects.
 // The callback receives a ConnectedClient snapshot with RemoteAddr and ConnectedAt.
 func WithOnClientConnect(fn func(ConnectedClient)) TCPServerOption {
@@ -100,6 +170,16 @@ func WithOnClientDisconnect(fn func(ConnectedClient)) TCPServerOption {
 	}
 }
 
+// WithOnClientsChanged sets a callback that fires after a client connects or
+// disconnects with a snapshot of all connected clients, so a management UI
+// can keep a live connection table without polling ConnectedClients. Calls
+// are serialized and the last call always reflects the current clients.
+func WithOnClientsChanged(fn func([]ConnectedClient)) TCPServerOption {
+	return func(s *TCPServer) {
+		s.onClientsChanged = fn
+	}
+}
+
 // NewTCPServer creates a new Modbus TCP server
 func NewTCPServer(address string, options ...TCPServerOption) *TCPServer {
 	server := &TCPServer{
@@ -110,6 +190,9 @@ func NewTCPServer(address string, options ...TCPServerOption) *TCPServer {
 		logger:       logging.NewLogger(),
 		clients:      make(map[string]*clientConn),
 		protocol:     newServerProtocolHandler(),
+		clock:        common.SystemClock{},
+		readTimeout:  DefaultServerReadTimeout,
+		unknownUnit:  common.ExceptionGatewayTargetNoResponse,
 	}
 
 	// Apply options
@@ -133,13 +216,35 @@ func (s *TCPServer) WithLogger(logger common.LoggerInterface) common.Server {
 
 // WithDataStore sets the data store for the server
 func (s *TCPServer) WithDataStore(dataStore common.DataStore) common.Server {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	s.storeMutex.Lock()
 	s.defaultStore = dataStore
+	s.storeMutex.Unlock()
+
+	// SetHandler takes the mutex itself
 	s.setupDefaultHandlers()
 	return s
 }
 
+// SwapDataStore replaces the server's data store without stopping the server
+// or dropping client connections, for example to reload a simulator image.
+// It waits for the requests being processed to finish, holding new ones back,
+// then calls migrate (if not nil) to carry state from the old store to the
+// new one. If migrate fails, the old store stays active and its error is
+// returned. Requests see either the old or the new store, never both.
+func (s *TCPServer) SwapDataStore(newStore common.DataStore, migrate func(old, new common.DataStore) error) error {
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	if migrate != nil {
+		if err := migrate(s.defaultStore, newStore); err != nil {
+			return fmt.Errorf("migrating data store: %w", err)
+		}
+	}
+	s.defaultStore = newStore
+	s.logger.Info(context.Background(), "Data store swapped")
+	return nil
+}
+
 // setupDefaultHandlers configures handlers for standard Modbus functions
 // Sets up handlers for all supported Modbus function codes as defined in the specification
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (Function Codes)
@@ -150,62 +255,90 @@ func (s *TCPServer) setupDefaultHandlers() {
 	// Read Coils (0x01)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.1
 	s.SetHandler(common.FuncReadCoils, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleReadCoils(ctx, req, s.defaultStore)
+		return s.protocol.HandleReadCoils(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Read Discrete Inputs (0x02)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.2
 	s.SetHandler(common.FuncReadDiscreteInputs, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleReadDiscreteInputs(ctx, req, s.defaultStore)
+		return s.protocol.HandleReadDiscreteInputs(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Read Holding Registers (0x03)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.3
 	s.SetHandler(common.FuncReadHoldingRegisters, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleReadHoldingRegisters(ctx, req, s.defaultStore)
+		return s.protocol.HandleReadHoldingRegisters(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Read Input Registers (0x04)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.4
 	s.SetHandler(common.FuncReadInputRegisters, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleReadInputRegisters(ctx, req, s.defaultStore)
+		return s.protocol.HandleReadInputRegisters(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Write Single Coil (0x05)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.5
 	s.SetHandler(common.FuncWriteSingleCoil, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleWriteSingleCoil(ctx, req, s.defaultStore)
+		return s.protocol.HandleWriteSingleCoil(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Write Single Register (0x06)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.6
 	s.SetHandler(common.FuncWriteSingleRegister, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleWriteSingleRegister(ctx, req, s.defaultStore)
+		return s.protocol.HandleWriteSingleRegister(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Write Multiple Coils (0x0F)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.11
 	s.SetHandler(common.FuncWriteMultipleCoils, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleWriteMultipleCoils(ctx, req, s.defaultStore)
+		return s.protocol.HandleWriteMultipleCoils(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Write Multiple Registers (0x10)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.12
 	s.SetHandler(common.FuncWriteMultipleRegisters, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleWriteMultipleRegisters(ctx, req, s.defaultStore)
+		return s.protocol.HandleWriteMultipleRegisters(ctx, req, s.storeFor(req.GetUnitID()))
+	})
+
+	// Read File Record (0x14)
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.14
+	s.SetHandler(common.FuncReadFileRecord, func(ctx context.Context, req common.Request) (common.Response, error) {
+		return s.protocol.HandleReadFileRecord(ctx, req, s.storeFor(req.GetUnitID()))
+	})
+
+	// Write File Record (0x15)
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.15
+	s.SetHandler(common.FuncWriteFileRecord, func(ctx context.Context, req common.Request) (common.Response, error) {
+		return s.protocol.HandleWriteFileRecord(ctx, req, s.storeFor(req.GetUnitID()))
+	})
+
+	// Mask Write Register (0x16)
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.16
+	s.SetHandler(common.FuncMaskWriteRegister, func(ctx context.Context, req common.Request) (common.Response, error) {
+		return s.protocol.HandleMaskWriteRegister(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Read/Write Multiple Registers (0x17)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.17
 	s.SetHandler(common.FuncReadWriteMultipleRegisters, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleReadWriteMultipleRegisters(ctx, req, s.defaultStore)
+		return s.protocol.HandleReadWriteMultipleRegisters(ctx, req, s.storeFor(req.GetUnitID()))
+	})
+
+	// Read FIFO Queue (0x18)
+	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+	s.SetHandler(common.FuncReadFIFOQueue, func(ctx context.Context, req common.Request) (common.Response, error) {
+		return s.protocol.HandleReadFIFOQueue(ctx, req, s.storeFor(req.GetUnitID()))
 	})
 
 	// Read Device Identification (0x2B)
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21
 	s.SetHandler(common.FuncReadDeviceIdentification, func(ctx context.Context, req common.Request) (common.Response, error) {
-		return s.protocol.HandleReadDeviceIdentification(ctx, req, s.defaultStore)
+		return s.protocol.HandleReadDeviceIdentification(ctx, req, s.storeFor(req.GetUnitID()))
 	})
+
+	if s.gateway != nil {
+		s.setupGatewayHandlers()
+	}
 }
 
 // SetHandler sets the handler for a specific Modbus function code
@@ -223,11 +356,17 @@ func (s *TCPServer) Start(ctx context.Context) error {
 		return fmt.Errorf("server already running")
 	}
 
+	if err := s.startMetrics(); err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+
 	// If no listener was provided via WithServerListener, create one
 	if s.listener == nil {
 		addr := fmt.Sprintf("%s:%d", s.address, s.port)
 		listener, err := net.Listen("tcp", addr)
 		if err != nil {
+			s.stopMetrics(ctx)
 			s.mutex.Unlock()
 			return err
 		}
@@ -252,16 +391,16 @@ func (s *TCPServer) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops the server
+// Stop stops the server. With WithServerShutdownGrace, requests being
+// processed are answered before their connections are closed.
 func (s *TCPServer) Stop(ctx context.Context) error {
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
 	if !s.running {
+		s.mutex.Unlock()
 		return nil // Already stopped
 	}
 
-	// Signal accept loop to stop
+	// Signal accept loop and connections to stop
 	close(s.stopChan)
 
 	// Close listener and nil it so Start() creates a fresh one
@@ -270,15 +409,23 @@ func (s *TCPServer) Stop(ctx context.Context) error {
 		s.listener = nil
 	}
 
-	// Close all client connections
+	s.stopMetrics(ctx)
+	s.running = false
+
 	s.clientsMutex.Lock()
-	for _, client := range s.clients {
-		client.conn.Close()
-	}
+	clients := s.clients
 	s.clients = make(map[string]*clientConn)
 	s.clientsMutex.Unlock()
+	s.mutex.Unlock()
+
+	// Handlers take s.mutex, so in-flight requests drain without it
+	s.drain(ctx, clients)
+
+	// Close all client connections
+	for _, client := range clients {
+		client.conn.Close()
+	}
 
-	s.running = false
 	s.logger.Info(ctx, "Modbus TCP server stopped")
 	return nil
 }
@@ -299,17 +446,29 @@ func (s *TCPServer) ConnectedClients() []ConnectedClient {
 
 	clients := make([]ConnectedClient, 0, len(s.clients))
 	for _, c := range s.clients {
-		clients = append(clients, ConnectedClient{
-			RemoteAddr:        c.remoteAddr,
-			ConnectedAt:       c.connectedAt,
-			RxTransactions:    c.rxCount.Load(),
-			TxTransactions:    c.txCount.Load(),
-			FunctionCodeStats: fcSnapshot(c),
-		})
+		clients = append(clients, c.snapshot())
 	}
+	sort.Slice(clients, func(a, b int) bool {
+		if !clients[a].ConnectedAt.Equal(clients[b].ConnectedAt) {
+			return clients[a].ConnectedAt.Before(clients[b].ConnectedAt)
+		}
+		return clients[a].RemoteAddr < clients[b].RemoteAddr
+	})
 	return clients
 }
 
+// clientsChanged reports the connected clients to the WithOnClientsChanged
+// callback. The snapshot is taken under clientsChangedMu so that concurrent
+// changes are reported in order.
+func (s *TCPServer) clientsChanged() {
+	if s.onClientsChanged == nil {
+		return
+	}
+	s.clientsChangedMu.Lock()
+	defer s.clientsChangedMu.Unlock()
+	s.onClientsChanged(s.ConnectedClients())
+}
+
 // acceptLoop accepts incoming connections
 func (s *TCPServer) acceptLoop(ctx context.Context) {
 	for {
@@ -342,53 +501,74 @@ func (s *TCPServer) acceptLoop(ctx context.Context) {
 		}
 
 		remoteAddr := conn.RemoteAddr().String()
-		s.logger.Info(ctx, "New client connected: %s", remoteAddr)
-
-		// Add client to tracked connections
-		client := &clientConn{
-			remoteAddr:  remoteAddr,
-			connectedAt: time.Now(),
-			conn:        conn,
+		if s.bans.banned(hostOf(remoteAddr)) {
+			s.logger.Warn(ctx, "Refusing connection from banned host %s", remoteAddr)
+			conn.Close()
+			continue
 		}
-		s.clientsMutex.Lock()
-		s.clients[remoteAddr] = client
-		s.clientsMutex.Unlock()
 
-		if s.onClientConnect != nil {
-			s.onClientConnect(ConnectedClient{
-				RemoteAddr:        remoteAddr,
-				ConnectedAt:       client.connectedAt,
-				FunctionCodeStats: make(map[common.FunctionCode]uint64),
-			})
+		// The handshake must not hold up accepting other clients
+		if s.tlsConfig != nil {
+			go s.acceptTLS(ctx, conn)
+			continue
 		}
+		s.addClient(ctx, conn, nil)
+	}
+}
 
-		// Handle the client connection
-		go s.handleConnection(client)
+// addClient tracks an accepted connection and starts serving it; identity
+// is set for TLS clients
+func (s *TCPServer) addClient(ctx context.Context, conn net.Conn, identity *TLSClient) {
+	remoteAddr := conn.RemoteAddr().String()
+	s.logger.Info(ctx, "New client connected: %s", remoteAddr)
+
+	// Add client to tracked connections
+	client := &clientConn{
+		remoteAddr:  remoteAddr,
+		connectedAt: s.clock.Now(),
+		conn:        conn,
+		dedup:       s.newDedupCache(),
+		tls:         identity,
 	}
+	s.clientsMutex.Lock()
+	s.clients[remoteAddr] = client
+	s.clientsMutex.Unlock()
+	s.metrics.connections.Add(1)
+
+	if s.onClientConnect != nil {
+		s.onClientConnect(client.snapshot())
+	}
+	s.events.Emit(common.Event{Type: common.EventConnected, RemoteAddr: remoteAddr})
+	s.clientsChanged()
+
+	// Handle the client connection
+	go s.handleConnection(client)
 }
 
 // handleConnection handles a client connection
 // Implements the Modbus TCP message handling as defined in the specification
 // Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3 (Message Processing)
 func (s *TCPServer) handleConnection(client *clientConn) {
-	ctx := context.Background()
+	// Cancelled on disconnect, ending the connection's watches
+	ctx, cancel := context.WithCancel(ContextWithRemoteAddr(context.Background(), client.remoteAddr))
+	defer cancel()
 	conn := client.conn
 	remoteAddr := client.remoteAddr
+	s.mutex.RLock()
+	stop := s.stopChan
+	s.mutex.RUnlock()
 	defer func() {
+		client.busy.Store(false)
+		s.events.Emit(common.Event{Type: common.EventDisconnected, RemoteAddr: remoteAddr})
 		if s.onClientDisconnect != nil {
-			s.onClientDisconnect(ConnectedClient{
-				RemoteAddr:        remoteAddr,
-				ConnectedAt:       client.connectedAt,
-				RxTransactions:    client.rxCount.Load(),
-				TxTransactions:    client.txCount.Load(),
-				FunctionCodeStats: fcSnapshot(client),
-			})
+			s.onClientDisconnect(client.snapshot())
 		}
 
 		// Remove client from tracked connections
 		s.clientsMutex.Lock()
 		delete(s.clients, remoteAddr)
 		s.clientsMutex.Unlock()
+		s.clientsChanged()
 
 		// Close the connection
 		conn.Close()
@@ -397,8 +577,20 @@ func (s *TCPServer) handleConnection(client *clientConn) {
 
 	// Create request timeout for long-running connections
 	for {
-		// Set a read deadline to prevent hanging forever
-		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		client.busy.Store(false)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if s.idle(client) {
+			s.logger.Info(ctx, "Closing idle connection from %s", remoteAddr)
+			return
+		}
+
+		// Set a read deadline so the idle timeout and stop are checked
+		// regularly; the socket needs real time, not the server's clock
+		conn.SetReadDeadline(time.Now().Add(s.readTimeout))
 
 		// Read the Modbus TCP header (7 bytes)
 		// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1 (MBAP Header)
@@ -432,14 +624,30 @@ func (s *TCPServer) handleConnection(client *clientConn) {
 
 		// Validate protocol ID
 		if protocolID != common.TCPProtocolIdentifier {
-			s.logger.Error(ctx, "Invalid protocol ID from %s: %d", remoteAddr, protocolID)
+			detail := fmt.Sprintf("protocol ID %d", protocolID)
+			if s.protocolViolation(ctx, client, common.ViolationProtocolID, header, 0, detail) {
+				return
+			}
 			continue
 		}
 
+		client.busy.Store(true)
+		client.touch(s.clock.Now())
+
 		// Read the PDU (length - 1 bytes, already read unitID)
 		dataLength := int(length) - 1
-		if dataLength <= 0 {
-			s.logger.Error(ctx, "Invalid data length from %s: %d", remoteAddr, length)
+		if dataLength <= 0 || dataLength > common.MaxPDULength {
+			detail := fmt.Sprintf("length %d", length)
+			if s.protocolViolation(ctx, client, common.ViolationLength, header, 0, detail) {
+				return
+			}
+			if dataLength <= 0 {
+				continue
+			}
+			// Never buffer more than a PDU, whatever the length field claims
+			if !s.skipOversizedFrame(ctx, client, stop, dataLength) {
+				return
+			}
 			continue
 		}
 
@@ -458,49 +666,139 @@ func (s *TCPServer) handleConnection(client *clientConn) {
 		functionCode := common.FunctionCode(data[0])
 		pduData := data[1:]
 
+		// A bad byte count is still answered by the handler's exception
+		if detail := checkByteCount(functionCode, pduData); detail != "" {
+			if s.protocolViolation(ctx, client, common.ViolationByteCount, header, functionCode, detail) {
+				return
+			}
+		}
+
 		// Create a request
 		request := transport.NewRequest(unitID, functionCode, pduData)
 		request.SetTransactionID(transactionID)
 
+		// Tag the request's logs and events, and the handler's ctx, so they
+		// can be correlated
+		reqCtx := common.WithCorrelationID(ctx, fmt.Sprintf("%s#%d", remoteAddr, transactionID))
+
+		if s.recorder != nil {
+			s.recorder.record(remoteAddr, request, s.clock.Now())
+		}
+
 		// Count received transaction
-		client.rxCount.Add(1)
-		client.fcCount[functionCode].Add(1)
+		client.request(functionCode, len(data))
+		s.metrics.request(unitID, functionCode)
 
-		s.logger.Debug(ctx, "Received request from %s: txID=%d, unit=%d, function=%s",
+		s.logger.Debug(reqCtx, "Received request from %s: txID=%d, unit=%d, function=%s",
 			remoteAddr, transactionID, unitID, functionCode)
 
-		// Handle the request
-		response, err := s.dispatchRequest(ctx, request)
-		if err != nil {
-			// If it's a Modbus error, create an exception response
-			// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Responses)
-			if modbusErr, ok := err.(*common.ModbusError); ok {
-				exceptionCode := modbusErr.ExceptionCode
-				s.logger.Debug(ctx, "Modbus exception: %s", err.Error())
-
-				// Create an exception response
-				// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Response PDU)
-				// Exception responses set the high bit (0x80) in the function code
-				exceptionResponse := transport.NewResponse(
-					transactionID,
-					unitID,
-					functionCode|common.FunctionCode(common.ExceptionBit), // Set the high bit for exception response
-					[]byte{byte(exceptionCode)},
-				)
-				s.sendResponse(conn, exceptionResponse)
-				client.txCount.Add(1)
-			} else {
-				// For other errors, log and disconnect
-				s.logger.Error(ctx, "Error processing request from %s: %v", remoteAddr, err)
+		// Deny what the TLS client's role does not allow before anything else
+		// Ref: MB-TCP-Security-v21_2018-07-24.pdf (Role-Based Client Authorization)
+		if !s.authorized(reqCtx, client, request) {
+			s.logger.Warn(reqCtx, "Denied function %s to %s", functionCode, remoteAddr)
+			if !s.respond(reqCtx, client, request, data, nil, common.NewModbusError(functionCode, common.ExceptionFunctionCodeNotSupported)) {
 				return
 			}
 			continue
 		}
 
-		// Send the response
-		s.sendResponse(conn, response)
-		client.txCount.Add(1)
+		// Answer a retransmitted write without applying it again
+		if cached, ok := client.dedup.lookup(unitID, transactionID, data, s.clock.Now()); ok {
+			s.logger.Info(reqCtx, "Answering retransmitted request from %s from cache: txID=%d, unit=%d, function=%s",
+				remoteAddr, transactionID, unitID, functionCode)
+			client.duplicates.Add(1)
+			s.sendResponse(client, cached)
+			client.txCount.Add(1)
+			continue
+		}
+
+		// Hold a watch open without blocking the connection's other requests
+		if functionCode == common.FuncWatchRegisters && s.watchInterval > 0 {
+			s.startWatch(reqCtx, client, request, data)
+			continue
+		}
+
+		// Handle the request
+		response, err := s.dispatchRequest(reqCtx, request)
+		s.delayResponse(functionCode)
+		if !s.respond(reqCtx, client, request, data, response, err) {
+			return
+		}
+	}
+}
+
+// respond sends the response to a request, or the exception for a Modbus
+// error; data is the request PDU. It returns false for any other error,
+// after which the connection is closed.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Responses)
+func (s *TCPServer) respond(reqCtx context.Context, client *clientConn, request common.Request, data []byte, response common.Response, err error) bool {
+	remoteAddr := client.remoteAddr
+	transactionID := request.GetTransactionID()
+	unitID := request.GetUnitID()
+	functionCode := request.GetPDU().FunctionCode
+	if err != nil {
+		detail := describeRequest(request.GetPDU())
+		s.events.Emit(common.Event{
+			Type:          common.EventRequestFailed,
+			RemoteAddr:    remoteAddr,
+			CorrelationID: common.CorrelationID(reqCtx),
+			UnitID:        unitID,
+			FunctionCode:  functionCode,
+			Detail:        detail,
+			Err:           err,
+		})
+
+		// If it's a Modbus error, create an exception response
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Responses)
+		if modbusErr, ok := err.(*common.ModbusError); ok {
+			exceptionCode := modbusErr.ExceptionCode
+			s.logger.Debug(reqCtx, "Modbus exception for %s: unit=%d %s: %s",
+				remoteAddr, unitID, detail, err.Error())
+			s.metrics.exception(unitID, functionCode, exceptionCode)
+
+			// Create an exception response
+			// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Response PDU)
+			// Exception responses set the high bit (0x80) in the function code
+			exceptionResponse := transport.NewResponse(
+				transactionID,
+				unitID,
+				functionCode|common.FunctionCode(common.ExceptionBit), // Set the high bit for exception response
+				[]byte{byte(exceptionCode)},
+			)
+			s.sendResponse(client, exceptionResponse)
+			client.txCount.Add(1)
+			client.dedup.store(unitID, transactionID, data, exceptionResponse, s.clock.Now())
+			client.exceptions.Add(1)
+			return true
+		}
+
+		// For other errors, log and disconnect
+		s.metrics.processingErrors.Add(1)
+		s.logger.Error(reqCtx, "Error processing request from %s (%s): %v", remoteAddr, detail, err)
+		return false
 	}
+
+	// Send the response
+	s.sendResponse(client, response)
+	client.txCount.Add(1)
+	client.dedup.store(unitID, transactionID, data, response, s.clock.Now())
+	return true
+}
+
+// Events returns a channel of client lifecycle events: EventConnected and
+// EventDisconnected for every client connection, and EventRequestFailed for
+// every request answered with an exception or dropped because of a
+// processing error, and EventProtocolViolation for every malformed frame.
+// Events are only collected once Events has been called, and are dropped
+// rather than blocking the server when the channel is full.
+func (s *TCPServer) Events() <-chan common.Event {
+	return s.events.Channel()
+}
+
+// AllEvents returns an iterator over the events of Events, ending when ctx
+// is done or the loop breaks
+func (s *TCPServer) AllEvents(ctx context.Context) iter.Seq[common.Event] {
+	return s.events.All(ctx)
 }
 
 // dispatchRequest dispatches a request to the appropriate handler
@@ -525,14 +823,41 @@ func (s *TCPServer) dispatchRequest(ctx context.Context, request common.Request)
 		}
 	}
 
-	// Call the handler
-	return handler(ctx, request)
+	// Answer for units the server does not emulate
+	if exception := s.unknownUnitException(request.GetUnitID()); exception != 0 {
+		s.logger.Debug(ctx, "No data store for unit %d: exception %d", request.GetUnitID(), exception)
+		return nil, common.NewModbusError(functionCode, exception)
+	}
+
+	// Enforce the addressed unit's data visibility before any handler runs
+	if policy, ok := s.unitPolicies[request.GetUnitID()]; ok {
+		if exception := policy.check(request.GetPDU()); exception != 0 {
+			s.logger.Debug(ctx, "Unit %d policy rejected function %s: exception %d",
+				request.GetUnitID(), functionCode, exception)
+			return nil, common.NewModbusError(functionCode, exception)
+		}
+	}
+
+	// Reject malformed requests before a handler trusts their fields; a
+	// gateway forwards them unchanged for the target to judge
+	if s.gateway == nil {
+		if err := protocol.Validate(request.GetPDU()); err != nil {
+			s.logger.Debug(ctx, "Rejecting request: %v", err)
+			return nil, common.NewModbusError(functionCode, common.ExceptionInvalidDataValue)
+		}
+	}
+
+	// Call the handler, exposing the addressed unit to data stores
+	s.storeMutex.RLock()
+	defer s.storeMutex.RUnlock()
+	return handler(ContextWithUnitID(ctx, request.GetUnitID()), request)
 }
 
 // sendResponse sends a response back to the client
-// Encodes the Modbus Application Protocol response and sends it over the TCP connection
+// Encodes the Modbus Application Protocol response and sends it over the TCP connection.
+// Watches answer from their own goroutines, so writes are serialized.
 // Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3 (Message Encoding)
-func (s *TCPServer) sendResponse(conn net.Conn, response common.Response) {
+func (s *TCPServer) sendResponse(client *clientConn, response common.Response) {
 	ctx := context.Background()
 	// Encode the full Modbus TCP message (MBAP Header + PDU)
 	// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1 (MBAP Header)
@@ -543,7 +868,9 @@ func (s *TCPServer) sendResponse(conn net.Conn, response common.Response) {
 	}
 
 	// Send the encoded response to the client
-	_, err = conn.Write(data)
+	client.writeMu.Lock()
+	_, err = client.conn.Write(data)
+	client.writeMu.Unlock()
 	if err != nil {
 		s.logger.Error(ctx, "Error sending response: %v", err)
 		return
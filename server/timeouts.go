@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// DefaultServerReadTimeout is how long a connection blocks reading before it
+// checks its idle timeout and whether the server is stopping
+const DefaultServerReadTimeout = 30 * time.Second
+
+// drainPollInterval is how often Stop checks for in-flight requests while
+// draining, see WithServerShutdownGrace
+const drainPollInterval = 5 * time.Millisecond
+
+// WithServerClock sets the clock used for idle timeouts, the shutdown grace
+// period, watch waits (see WithChangeNotifications) and connection times
+// (default common.SystemClock). Socket
+// deadlines still use real time: they only bound how long a read blocks
+// before the clock is consulted, see WithServerReadTimeout.
+func WithServerClock(clock common.Clock) TCPServerOption {
+	return func(s *TCPServer) {
+		s.clock = clock
+	}
+}
+
+// WithServerReadTimeout sets how long a connection blocks reading before it
+// checks its idle timeout and whether the server is stopping (default
+// DefaultServerReadTimeout). Shorter timeouts make idle connections close
+// closer to their deadline.
+func WithServerReadTimeout(timeout time.Duration) TCPServerOption {
+	return func(s *TCPServer) {
+		if timeout > 0 {
+			s.readTimeout = timeout
+		}
+	}
+}
+
+// WithServerIdleTimeout closes connections that sent no request for timeout,
+// as measured by the server's clock. Zero, the default, keeps idle
+// connections open.
+func WithServerIdleTimeout(timeout time.Duration) TCPServerOption {
+	return func(s *TCPServer) {
+		s.idleTimeout = timeout
+	}
+}
+
+// WithServerShutdownGrace makes Stop wait up to grace, as measured by the
+// server's clock, for requests being processed to be answered before
+// closing connections. Stop returns early when its context is done. Zero,
+// the default, closes connections immediately.
+func WithServerShutdownGrace(grace time.Duration) TCPServerOption {
+	return func(s *TCPServer) {
+		s.shutdownGrace = grace
+	}
+}
+
+// idle reports whether the connection has been idle past the idle timeout
+func (s *TCPServer) idle(client *clientConn) bool {
+	return s.idleTimeout > 0 && s.clock.Now().Sub(client.lastActivity()) >= s.idleTimeout
+}
+
+// drain waits for in-flight requests on clients to be answered, until the
+// shutdown grace period or ctx ends
+func (s *TCPServer) drain(ctx context.Context, clients map[string]*clientConn) {
+	if s.shutdownGrace <= 0 {
+		return
+	}
+	deadline := s.clock.Now().Add(s.shutdownGrace)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		busy := 0
+		for _, client := range clients {
+			if client.busy.Load() {
+				busy++
+			}
+		}
+		if busy == 0 {
+			return
+		}
+		if !s.clock.Now().Before(deadline) {
+			s.logger.Warn(ctx, "Stopping with %d requests in flight after %v grace", busy, s.shutdownGrace)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			s.logger.Warn(ctx, "Stopping with %d requests in flight: %v", busy, ctx.Err())
+			return
+		case <-ticker.C:
+		}
+	}
+}
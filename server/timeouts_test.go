@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+// readHoldingFrame is a Read Holding Registers request for one register
+var readHoldingFrame = []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x00, 0x00, 0x01}
+
+func startTimeoutServer(t *testing.T, options ...TCPServerOption) (*TCPServer, net.Conn) {
+	t.Helper()
+	srv := NewTCPServer("127.0.0.1", append([]TCPServerOption{WithServerPort(0)}, options...)...)
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return srv, conn
+}
+
+func TestTCPServer_IdleTimeout(t *testing.T) {
+	clock := test.NewManualClock(time.Unix(1000, 0))
+	_, conn := startTimeoutServer(t,
+		WithServerClock(clock),
+		WithServerReadTimeout(10*time.Millisecond),
+		WithServerIdleTimeout(time.Minute))
+
+	sendRawRequest(t, conn, 1, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+
+	// Real time passing does not make the connection idle
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err == nil || err == io.EOF {
+		t.Fatalf("Expected the connection to stay open, got %v", err)
+	}
+
+	clock.Advance(30 * time.Second)
+	sendRawRequest(t, conn, 2, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+
+	// The second request restarted the idle timeout
+	clock.Advance(59 * time.Second)
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err == nil || err == io.EOF {
+		t.Fatalf("Expected the connection to stay open, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the idle connection to be closed, got %v", err)
+	}
+}
+
+func TestTCPServer_ShutdownGrace(t *testing.T) {
+	clock := test.NewManualClock(time.Unix(1000, 0))
+	srv, conn := startTimeoutServer(t,
+		WithServerClock(clock),
+		WithServerReadTimeout(10*time.Millisecond),
+		WithServerShutdownGrace(time.Minute))
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defaultHandler := srv.handlers[common.FuncReadHoldingRegisters]
+	srv.SetHandler(common.FuncReadHoldingRegisters, func(ctx context.Context, request common.Request) (common.Response, error) {
+		started <- struct{}{}
+		<-release
+		return defaultHandler(ctx, request)
+	})
+
+	if _, err := conn.Write(readHoldingFrame); err != nil {
+		t.Fatalf("Failed to write request: %v", err)
+	}
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.Stop(context.Background())
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned with a request in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The in-flight request is answered before the connection closes
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return after the request was answered")
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	response, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if len(response) != 11 || response[7] != byte(common.FuncReadHoldingRegisters) {
+		t.Errorf("Expected a Read Holding Registers response, got % X", response)
+	}
+}
+
+func TestTCPServer_ShutdownGraceExpires(t *testing.T) {
+	clock := test.NewManualClock(time.Unix(1000, 0))
+	srv, conn := startTimeoutServer(t,
+		WithServerClock(clock),
+		WithServerShutdownGrace(time.Minute))
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	srv.SetHandler(common.FuncReadHoldingRegisters, func(ctx context.Context, request common.Request) (common.Response, error) {
+		started <- struct{}{}
+		<-release
+		return nil, common.NewModbusError(common.FuncReadHoldingRegisters, common.ExceptionServerDeviceFailure)
+	})
+
+	if _, err := conn.Write(readHoldingFrame); err != nil {
+		t.Fatalf("Failed to write request: %v", err)
+	}
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.Stop(context.Background())
+		close(stopped)
+	}()
+
+	// Stop gives up on the request once the grace period has passed
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-stopped:
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+				t.Errorf("Expected the connection to be closed, got %v", err)
+			}
+			return
+		case <-timeout:
+			t.Fatal("Stop did not return after the grace period")
+		case <-time.After(10 * time.Millisecond):
+			clock.Advance(time.Minute)
+		}
+	}
+}
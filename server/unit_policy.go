@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/binary"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// UnitPolicy restricts which data a unit exposes, so that one server can
+// emulate devices with different register maps behind one address (for
+// example unit 2 exposing only input registers 0-99). Policies are enforced
+// in dispatch, before any handler or data store sees the request.
+//
+// A table with no visible range is reported as unsupported (exception 0x01,
+// Illegal Function), the way a device without that table answers. A request
+// reaching outside the visible ranges of a table, or writing to a read-only
+// range, fails with exception 0x02 (Illegal Data Address).
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.3 (MODBUS Data model)
+type UnitPolicy struct {
+	visible  map[common.Table][]addressRange
+	readOnly map[common.Table][]addressRange
+}
+
+// UnitPolicyOption is a function that configures a UnitPolicy
+type UnitPolicyOption func(*UnitPolicy)
+
+// addRanges appends [start, end] to every table in tables
+func addRanges(ranges map[common.Table][]addressRange, tables common.Table, start, end common.Address) {
+	for _, table := range []common.Table{common.TableCoils, common.TableDiscreteInputs, common.TableHoldingRegisters, common.TableInputRegisters} {
+		if tables&table != 0 {
+			ranges[table] = append(ranges[table], addressRange{start: int(start), end: int(end)})
+		}
+	}
+}
+
+// WithPolicyRange makes [start, end] (inclusive) of the given tables visible
+func WithPolicyRange(tables common.Table, start, end common.Address) UnitPolicyOption {
+	return func(p *UnitPolicy) {
+		addRanges(p.visible, tables, start, end)
+	}
+}
+
+// WithPolicyReadOnlyRange makes [start, end] (inclusive) of the given tables
+// visible to reads only
+func WithPolicyReadOnlyRange(tables common.Table, start, end common.Address) UnitPolicyOption {
+	return func(p *UnitPolicy) {
+		addRanges(p.visible, tables, start, end)
+		addRanges(p.readOnly, tables, start, end)
+	}
+}
+
+// NewUnitPolicy creates a policy exposing only the ranges given by options
+func NewUnitPolicy(options ...UnitPolicyOption) *UnitPolicy {
+	p := &UnitPolicy{
+		visible:  make(map[common.Table][]addressRange),
+		readOnly: make(map[common.Table][]addressRange),
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// WithServerUnitPolicy restricts requests addressed to unitID to the data
+// exposed by policy. Units without a policy are unrestricted.
+func WithServerUnitPolicy(unitID common.UnitID, policy *UnitPolicy) TCPServerOption {
+	return func(s *TCPServer) {
+		if s.unitPolicies == nil {
+			s.unitPolicies = make(map[common.UnitID]*UnitPolicy)
+		}
+		s.unitPolicies[unitID] = policy
+	}
+}
+
+// covered reports whether every address of [address, address+quantity) lies
+// in one of ranges
+func covered(ranges []addressRange, address, quantity int) bool {
+	for addr := address; addr < address+quantity; {
+		next := addr
+		for _, r := range ranges {
+			if addr >= r.start && addr <= r.end {
+				next = r.end + 1
+				break
+			}
+		}
+		if next == addr {
+			return false
+		}
+		addr = next
+	}
+	return true
+}
+
+// overlaps reports whether any address of [address, address+quantity) lies
+// in one of ranges
+func overlaps(ranges []addressRange, address, quantity int) bool {
+	for _, r := range ranges {
+		if address <= r.end && address+quantity > r.start {
+			return true
+		}
+	}
+	return false
+}
+
+// tableAccess is one table range touched by a request
+type tableAccess struct {
+	table    common.Table
+	address  int
+	quantity int
+	write    bool
+}
+
+// requestAccesses returns the table ranges a request touches. Requests too
+// short to decode yield none and are left for the handler to reject.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (Function Code Descriptions)
+func requestAccesses(pdu *common.PDU) []tableAccess {
+	data := pdu.Data
+	word := func(i int) int { return int(binary.BigEndian.Uint16(data[i:])) }
+
+	switch pdu.FunctionCode {
+	case common.FuncReadCoils, common.FuncReadDiscreteInputs,
+		common.FuncReadHoldingRegisters, common.FuncReadInputRegisters,
+		common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters:
+		if len(data) < 4 {
+			return nil
+		}
+		tables := map[common.FunctionCode]common.Table{
+			common.FuncReadCoils:              common.TableCoils,
+			common.FuncReadDiscreteInputs:     common.TableDiscreteInputs,
+			common.FuncReadHoldingRegisters:   common.TableHoldingRegisters,
+			common.FuncReadInputRegisters:     common.TableInputRegisters,
+			common.FuncWriteMultipleCoils:     common.TableCoils,
+			common.FuncWriteMultipleRegisters: common.TableHoldingRegisters,
+		}
+		write := pdu.FunctionCode == common.FuncWriteMultipleCoils || pdu.FunctionCode == common.FuncWriteMultipleRegisters
+		return []tableAccess{{table: tables[pdu.FunctionCode], address: word(0), quantity: word(2), write: write}}
+
+	case common.FuncWriteSingleCoil:
+		if len(data) < 2 {
+			return nil
+		}
+		return []tableAccess{{table: common.TableCoils, address: word(0), quantity: 1, write: true}}
+
+	case common.FuncWriteSingleRegister, common.FuncMaskWriteRegister:
+		if len(data) < 2 {
+			return nil
+		}
+		return []tableAccess{{table: common.TableHoldingRegisters, address: word(0), quantity: 1, write: true}}
+
+	case common.FuncReadFIFOQueue:
+		if len(data) < 2 {
+			return nil
+		}
+		return []tableAccess{{table: common.TableHoldingRegisters, address: word(0), quantity: 1}}
+
+	case common.FuncReadWriteMultipleRegisters:
+		if len(data) < 8 {
+			return nil
+		}
+		return []tableAccess{
+			{table: common.TableHoldingRegisters, address: word(0), quantity: word(2)},
+			{table: common.TableHoldingRegisters, address: word(4), quantity: word(6), write: true},
+		}
+	}
+	return nil
+}
+
+// check returns the exception a request violating the policy must get, or
+// 0 if the request is allowed
+func (p *UnitPolicy) check(pdu *common.PDU) common.ExceptionCode {
+	for _, access := range requestAccesses(pdu) {
+		visible := p.visible[access.table]
+		if len(visible) == 0 {
+			return common.ExceptionFunctionCodeNotSupported
+		}
+		if !covered(visible, access.address, access.quantity) {
+			return common.ExceptionDataAddressNotAvailable
+		}
+		if access.write && overlaps(p.readOnly[access.table], access.address, access.quantity) {
+			return common.ExceptionDataAddressNotAvailable
+		}
+	}
+	return 0
+}
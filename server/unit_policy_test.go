@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestTCPServer_UnitPolicy(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0),
+		WithServerUnitPolicy(2, NewUnitPolicy(
+			WithPolicyRange(common.TableInputRegisters, 0, 99),
+			WithPolicyRange(common.TableHoldingRegisters, 0, 9),
+			WithPolicyReadOnlyRange(common.TableHoldingRegisters, 10, 19),
+		)),
+	)
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	cases := []struct {
+		name      string
+		unitID    byte
+		fc        common.FunctionCode
+		data      []byte
+		exception common.ExceptionCode // 0 for success
+	}{
+		{"visible input registers", 2, common.FuncReadInputRegisters, []byte{0x00, 0x00, 0x00, 0x64}, 0},
+		{"past the visible range", 2, common.FuncReadInputRegisters, []byte{0x00, 0x60, 0x00, 0x05}, common.ExceptionDataAddressNotAvailable},
+		{"hidden table", 2, common.FuncReadCoils, []byte{0x00, 0x00, 0x00, 0x01}, common.ExceptionFunctionCodeNotSupported},
+		{"read across adjacent ranges", 2, common.FuncReadHoldingRegisters, []byte{0x00, 0x05, 0x00, 0x0A}, 0},
+		{"write to read-only range", 2, common.FuncWriteSingleRegister, []byte{0x00, 0x0A, 0x12, 0x34}, common.ExceptionDataAddressNotAvailable},
+		{"write to writable range", 2, common.FuncWriteSingleRegister, []byte{0x00, 0x09, 0x12, 0x34}, 0},
+		{"read/write into read-only range", 2, common.FuncReadWriteMultipleRegisters,
+			[]byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x09, 0x00, 0x02, 0x04, 0x00, 0x01, 0x00, 0x02}, common.ExceptionDataAddressNotAvailable},
+		{"unit without a policy", 1, common.FuncReadCoils, []byte{0x00, 0x00, 0x00, 0x01}, 0},
+	}
+
+	for i, tc := range cases {
+		pdu := sendRawRequest(t, conn, uint16(i+1), tc.unitID, tc.fc, tc.data)
+		if tc.exception == 0 {
+			if pdu[0] != byte(tc.fc) {
+				t.Errorf("%s: expected success, got % X", tc.name, pdu)
+			}
+			continue
+		}
+		if pdu[0] != byte(tc.fc)|common.ExceptionBit || common.ExceptionCode(pdu[1]) != tc.exception {
+			t.Errorf("%s: expected exception %d, got % X", tc.name, tc.exception, pdu)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// WithUnitDataStore serves requests addressed to unitID from store, so one
+// server can emulate several devices with distinct register maps. Once any
+// unit has its own store, requests for units without one are answered with
+// exception 0x0B (Gateway Target Device Failed To Respond), the way a
+// gateway reports an absent device; see WithUnknownUnitException. Units
+// with their own store are not affected by WithServerDataStore or
+// SwapDataStore.
+// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+func WithUnitDataStore(unitID common.UnitID, store common.DataStore) TCPServerOption {
+	return func(s *TCPServer) {
+		if s.unitStores == nil {
+			s.unitStores = make(map[common.UnitID]common.DataStore)
+		}
+		s.unitStores[unitID] = store
+	}
+}
+
+// WithUnknownUnitException sets the exception answered to requests for units
+// without a store of their own when WithUnitDataStore is used (default 0x0B,
+// Gateway Target Device Failed To Respond). An exception of 0 serves such
+// requests from the server's data store instead.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 7 (Exception Codes 0x0A, 0x0B)
+func WithUnknownUnitException(exception common.ExceptionCode) TCPServerOption {
+	return func(s *TCPServer) {
+		s.unknownUnit = exception
+	}
+}
+
+// storeFor returns the data store serving unitID
+func (s *TCPServer) storeFor(unitID common.UnitID) common.DataStore {
+	if store, ok := s.unitStores[unitID]; ok {
+		return store
+	}
+	return s.defaultStore
+}
+
+// unknownUnitException returns the exception answering a request for unitID,
+// or 0 if the server serves the unit
+func (s *TCPServer) unknownUnitException(unitID common.UnitID) common.ExceptionCode {
+	if len(s.unitStores) == 0 || s.gateway != nil {
+		return 0
+	}
+	if _, ok := s.unitStores[unitID]; ok {
+		return 0
+	}
+	return s.unknownUnit
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestTCPServer_UnitDataStores(t *testing.T) {
+	meter := NewMemoryStore()
+	meter.SetHoldingRegister(0, 0x0101)
+	drive := NewMemoryStore()
+	drive.SetHoldingRegister(0, 0x0202)
+	fallback := NewMemoryStore()
+	fallback.SetHoldingRegister(0, 0x0F0F)
+
+	readRegister := []byte{0x00, 0x00, 0x00, 0x01}
+	tests := []struct {
+		name    string
+		options []TCPServerOption
+		want    map[byte][]byte // response PDU by unit ID
+	}{
+		{
+			name: "unknown units fail",
+			options: []TCPServerOption{
+				WithUnitDataStore(1, meter),
+				WithUnitDataStore(2, drive),
+			},
+			want: map[byte][]byte{
+				1: {0x03, 0x02, 0x01, 0x01},
+				2: {0x03, 0x02, 0x02, 0x02},
+				3: {0x83, byte(common.ExceptionGatewayTargetNoResponse)},
+			},
+		},
+		{
+			name: "unknown units served by the data store",
+			options: []TCPServerOption{
+				WithUnitDataStore(1, meter),
+				WithUnknownUnitException(0),
+			},
+			want: map[byte][]byte{
+				1: {0x03, 0x02, 0x01, 0x01},
+				3: {0x03, 0x02, 0x0F, 0x0F},
+			},
+		},
+		{
+			name: "custom exception",
+			options: []TCPServerOption{
+				WithUnitDataStore(1, meter),
+				WithUnknownUnitException(common.ExceptionGatewayPathUnavailable),
+			},
+			want: map[byte][]byte{
+				3: {0x83, byte(common.ExceptionGatewayPathUnavailable)},
+			},
+		},
+		{
+			name: "no unit stores",
+			want: map[byte][]byte{
+				1: {0x03, 0x02, 0x0F, 0x0F},
+				7: {0x03, 0x02, 0x0F, 0x0F},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]TCPServerOption{WithServerPort(0), WithServerDataStore(fallback)}, tt.options...)
+			srv := NewTCPServer("127.0.0.1", options...)
+			ctx := context.Background()
+			if err := srv.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			defer srv.Stop(ctx)
+
+			conn, err := net.Dial("tcp", srv.listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Failed to connect: %v", err)
+			}
+			defer conn.Close()
+
+			for unitID, want := range tt.want {
+				pdu := sendRawRequest(t, conn, uint16(unitID), unitID, common.FuncReadHoldingRegisters, readRegister)
+				if string(pdu) != string(want) {
+					t.Errorf("Unit %d: expected % X, got % X", unitID, want, pdu)
+				}
+			}
+		})
+	}
+}
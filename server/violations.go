@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// WithViolationBan bans a client host for duration once it sends limit
+// malformed frames within window: its connections are closed and new ones
+// from the same host are refused until the ban expires. Without this option
+// violations are only logged, counted and emitted as events.
+func WithViolationBan(limit int, window, duration time.Duration) TCPServerOption {
+	return func(s *TCPServer) {
+		s.bans = &banList{
+			limit:    max(limit, 1),
+			window:   window,
+			duration: duration,
+			hosts:    make(map[string]*banState),
+			now:      time.Now,
+		}
+	}
+}
+
+// WithServerOversizedFramePolicy sets what happens to a request whose MBAP
+// length field announces more than a PDU, which is reported as a
+// common.ViolationLength: by default (common.OversizedFrameDrop) its body is
+// skipped without buffering it and the request is not answered,
+// common.OversizedFrameClose closes the connection.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (Protocol Description)
+func WithServerOversizedFramePolicy(policy common.OversizedFramePolicy) TCPServerOption {
+	return func(s *TCPServer) {
+		s.oversized = policy
+	}
+}
+
+// skipOversizedFrame applies the oversized frame policy to a body of length
+// bytes and reports whether the connection stays open. The body is read on
+// through read deadlines, so a body arriving slowly is skipped to the next
+// frame boundary rather than closing the connection.
+func (s *TCPServer) skipOversizedFrame(ctx context.Context, client *clientConn, stop <-chan struct{}, length int) bool {
+	if s.oversized == common.OversizedFrameClose {
+		s.logger.Warn(ctx, "Closing connection from %s after an oversized frame", client.remoteAddr)
+		return false
+	}
+	for n := int64(length); n > 0; {
+		skipped, err := io.CopyN(io.Discard, client.conn, n)
+		n -= skipped
+		if err == nil {
+			continue
+		}
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			s.logger.Error(ctx, "Error skipping oversized frame from %s: %v", client.remoteAddr, err)
+			return false
+		}
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+		client.conn.SetReadDeadline(time.Now().Add(s.readTimeout))
+	}
+	return true
+}
+
+// banList tracks recent violations and bans by client host
+type banList struct {
+	limit    int
+	window   time.Duration
+	duration time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*banState
+}
+
+// banState is the violation history of one host
+type banState struct {
+	violations []time.Time
+	until      time.Time
+}
+
+// record counts a violation by host and reports whether the host is banned
+func (b *banList) record(host string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state := b.hosts[host]
+	if state == nil {
+		state = &banState{}
+		b.hosts[host] = state
+	}
+	recent := state.violations[:0]
+	for _, t := range state.violations {
+		if now.Sub(t) < b.window {
+			recent = append(recent, t)
+		}
+	}
+	state.violations = append(recent, now)
+	if len(state.violations) >= b.limit {
+		state.violations = state.violations[:0]
+		state.until = now.Add(b.duration)
+	}
+	return now.Before(state.until)
+}
+
+// banned reports whether host is currently banned
+func (b *banList) banned(host string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.hosts[host]
+	return state != nil && b.now().Before(state.until)
+}
+
+// BannedHosts returns the client hosts currently banned by WithViolationBan,
+// sorted
+func (s *TCPServer) BannedHosts() []string {
+	b := s.bans
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	var hosts []string
+	for host, state := range b.hosts {
+		if now.Before(state.until) {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Unban lifts the ban of host and forgets its violations
+func (s *TCPServer) Unban(host string) {
+	if s.bans == nil {
+		return
+	}
+	s.bans.mu.Lock()
+	defer s.bans.mu.Unlock()
+	delete(s.bans.hosts, host)
+}
+
+// hostOf returns the host part of a remote address
+func hostOf(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// protocolViolation logs, counts and emits a malformed frame from client,
+// and reports whether its host is now banned and the connection must close
+func (s *TCPServer) protocolViolation(ctx context.Context, client *clientConn, kind common.ViolationKind, header []byte, functionCode common.FunctionCode, detail string) bool {
+	violation := &common.ProtocolViolationError{Kind: kind, Header: header, Detail: detail}
+	s.logger.Warn(ctx, "Malformed frame from %s: %v", client.remoteAddr, violation)
+
+	client.violations.Add(1)
+	s.metrics.violations[kind].Add(1)
+	s.events.Emit(common.Event{
+		Type:         common.EventProtocolViolation,
+		RemoteAddr:   client.remoteAddr,
+		UnitID:       common.UnitID(header[6]),
+		FunctionCode: functionCode,
+		Err:          violation,
+	})
+
+	if !s.bans.record(hostOf(client.remoteAddr)) {
+		return false
+	}
+	s.logger.Warn(ctx, "Banning %s after repeated protocol violations", hostOf(client.remoteAddr))
+	return true
+}
+
+// checkByteCount returns a description of the mismatch when the byte count
+// field of a request disagrees with the data that follows it, or ""
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Sections 6.11, 6.12, 6.14, 6.15 and 6.17
+func checkByteCount(functionCode common.FunctionCode, data []byte) string {
+	offset := -1
+	switch functionCode {
+	case common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters:
+		offset = 4
+	case common.FuncReadWriteMultipleRegisters:
+		offset = 8
+	case common.FuncReadFileRecord, common.FuncWriteFileRecord:
+		offset = 0
+	}
+	if offset < 0 || len(data) <= offset {
+		return ""
+	}
+	if byteCount, actual := int(data[offset]), len(data)-offset-1; byteCount != actual {
+		return fmt.Sprintf("byte count %d with %d data bytes", byteCount, actual)
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestTCPServer_ProtocolViolations(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithViolationBan(3, time.Minute, time.Minute))
+	events := srv.Events()
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+	addr := srv.listener.Addr().String()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// An MBAP header with protocol ID 1 is skipped
+	badHeader := []byte{0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x07}
+	if _, err := conn.Write(badHeader); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	// A byte count disagreeing with the data is still answered
+	pdu := sendRawRequest(t, conn, 2, 1, common.FuncWriteMultipleRegisters, []byte{0x00, 0x00, 0x00, 0x01, 0x04, 0x00, 0x01})
+	if pdu[0] != byte(common.FuncWriteMultipleRegisters)|common.ExceptionBit {
+		t.Errorf("Expected an exception response, got % X", pdu)
+	}
+
+	want := []common.ViolationKind{common.ViolationProtocolID, common.ViolationByteCount}
+	for i, kind := range want {
+		event := nextEvent(t, events, common.EventProtocolViolation)
+		var violation *common.ProtocolViolationError
+		if !errors.As(event.Err, &violation) || !errors.Is(event.Err, common.ErrProtocolViolation) {
+			t.Fatalf("Expected a ProtocolViolationError, got %v", event.Err)
+		}
+		if violation.Kind != kind {
+			t.Errorf("Violation %d: expected %s, got %s", i, kind, violation.Kind)
+		}
+		if kind == common.ViolationProtocolID && !slices.Equal(violation.Header, badHeader) {
+			t.Errorf("Expected the raw header % X, got % X", badHeader, violation.Header)
+		}
+	}
+
+	var metrics strings.Builder
+	srv.WriteMetrics(&metrics)
+	for _, line := range []string{
+		`modbus_server_protocol_violations_total{kind="protocol_id"} 1`,
+		`modbus_server_protocol_violations_total{kind="byte_count"} 1`,
+	} {
+		if !strings.Contains(metrics.String(), line+"\n") {
+			t.Errorf("Expected %q in metrics:\n%s", line, metrics.String())
+		}
+	}
+
+	// The third violation within the window bans the host
+	if _, err := conn.Write(badHeader); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the connection to be closed, got %v", err)
+	}
+	if got := srv.BannedHosts(); !slices.Equal(got, []string{"127.0.0.1"}) {
+		t.Errorf("Expected 127.0.0.1 to be banned, got %v", got)
+	}
+
+	// New connections from the host are refused until unbanned
+	refused, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer refused.Close()
+	refused.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := refused.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the banned connection to be closed, got %v", err)
+	}
+
+	srv.Unban("127.0.0.1")
+	again, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer again.Close()
+	sendRawRequest(t, again, 3, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+}
+
+// nextEvent returns the next event of type want, skipping others
+func nextEvent(t *testing.T, events <-chan common.Event, want common.EventType) common.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type == want {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for a %s event", want)
+		}
+	}
+}
+
+func TestTCPServer_OversizedFrames(t *testing.T) {
+	// An MBAP length of 0x00FF announces a PDU of 254 bytes, one too many
+	oversized := append([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0xFF, 0x01, byte(common.FuncWriteMultipleRegisters)}, make([]byte, 253)...)
+
+	cases := []struct {
+		name    string
+		options []TCPServerOption
+		pause   time.Duration
+		closed  bool
+	}{
+		{"drop", nil, 0, false},
+		// The body arrives across several read deadlines of the read loop
+		{"drop split", []TCPServerOption{WithServerReadTimeout(50 * time.Millisecond)}, 150 * time.Millisecond, false},
+		{"close", []TCPServerOption{WithServerOversizedFramePolicy(common.OversizedFrameClose)}, 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := NewTCPServer("127.0.0.1", append([]TCPServerOption{WithServerPort(0)}, tc.options...)...)
+			events := srv.Events()
+
+			ctx := context.Background()
+			if err := srv.Start(ctx); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			defer srv.Stop(ctx)
+
+			conn, err := net.Dial("tcp", srv.listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Failed to connect: %v", err)
+			}
+			defer conn.Close()
+
+			if _, err := conn.Write(oversized[:100]); err != nil {
+				t.Fatalf("Failed to write: %v", err)
+			}
+			time.Sleep(tc.pause)
+			if _, err := conn.Write(oversized[100:]); err != nil {
+				t.Fatalf("Failed to write: %v", err)
+			}
+			event := nextEvent(t, events, common.EventProtocolViolation)
+			var violation *common.ProtocolViolationError
+			if !errors.As(event.Err, &violation) || violation.Kind != common.ViolationLength {
+				t.Errorf("Expected a length violation, got %v", event.Err)
+			}
+
+			// The unread body may make the close a reset
+			if tc.closed {
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				_, err := conn.Read(make([]byte, 1))
+				if netErr, ok := err.(net.Error); err == nil || ok && netErr.Timeout() {
+					t.Errorf("Expected the connection to be closed, got %v", err)
+				}
+				return
+			}
+
+			// The body was skipped, so the next frame is read from its start
+			pdu := sendRawRequest(t, conn, 2, 1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+			if pdu[0] != byte(common.FuncReadHoldingRegisters) {
+				t.Errorf("Expected a normal response after the dropped frame, got % X", pdu)
+			}
+		})
+	}
+}
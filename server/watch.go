@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// Settings of WithChangeNotifications
+const (
+	DefaultWatchInterval    = 50 * time.Millisecond
+	maxWatchesPerConnection = 16
+)
+
+// WithChangeNotifications serves common.FuncWatchRegisters, the long-poll
+// extension gomodbus clients use instead of polling: a watch request carries
+// the registers the client last saw and is answered as soon as they differ,
+// or with the unchanged values once the client's wait is over. The server
+// checks the store every interval, through the normal read handlers, so
+// unit policies and custom handlers apply. Watches are served in their own
+// goroutines and a connection keeps serving other requests meanwhile; each
+// connection may hold 16 watches, more are answered with Server Device Busy.
+// An interval of 0 or less uses DefaultWatchInterval.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 5 (Function Code Categories, user defined)
+func WithChangeNotifications(interval time.Duration) TCPServerOption {
+	return func(s *TCPServer) {
+		if interval <= 0 {
+			interval = DefaultWatchInterval
+		}
+		s.watchInterval = interval
+	}
+}
+
+// watchRequest is a parsed FuncWatchRegisters request:
+// read function (1 byte, 0x03 or 0x04), address (2), quantity (2),
+// wait in milliseconds (2) and the last seen values (2 per register)
+type watchRequest struct {
+	read     common.Request
+	wait     time.Duration
+	lastSeen []byte // byte count and values, as in the read response
+}
+
+// parseWatchRequest parses a watch request into the read it repeats
+func parseWatchRequest(request common.Request) (*watchRequest, error) {
+	data := request.GetPDU().Data
+	invalid := common.NewModbusError(common.FuncWatchRegisters, common.ExceptionInvalidDataValue)
+	if len(data) < 7 {
+		return nil, invalid
+	}
+	readFunction := common.FunctionCode(data[0])
+	if readFunction != common.FuncReadHoldingRegisters && readFunction != common.FuncReadInputRegisters {
+		return nil, invalid
+	}
+	quantity := binary.BigEndian.Uint16(data[3:5])
+	if quantity == 0 || quantity > common.MaxWatchRegisterCount || len(data) != 7+2*int(quantity) {
+		return nil, invalid
+	}
+
+	read := transport.NewRequest(request.GetUnitID(), readFunction, data[1:5])
+	read.SetTransactionID(request.GetTransactionID())
+	return &watchRequest{
+		read:     read,
+		wait:     time.Duration(binary.BigEndian.Uint16(data[5:7])) * time.Millisecond,
+		lastSeen: append([]byte{byte(2 * quantity)}, data[7:]...),
+	}, nil
+}
+
+// startWatch serves a watch request, whose PDU is data, in its own goroutine
+func (s *TCPServer) startWatch(ctx context.Context, client *clientConn, request common.Request, data []byte) {
+	watch, err := parseWatchRequest(request)
+	if err == nil && client.watches.Add(1) > maxWatchesPerConnection {
+		client.watches.Add(-1)
+		err = common.NewModbusError(common.FuncWatchRegisters, common.ExceptionServerDeviceBusy)
+	}
+	if err != nil {
+		s.respond(ctx, client, request, data, nil, err)
+		return
+	}
+
+	go func() {
+		defer client.watches.Add(-1)
+		response, err := s.watch(ctx, watch)
+		if ctx.Err() != nil {
+			return // disconnected
+		}
+		if err != nil {
+			if modbusErr, ok := err.(*common.ModbusError); ok {
+				err = common.NewModbusError(common.FuncWatchRegisters, modbusErr.ExceptionCode)
+			}
+		} else {
+			response = transport.NewResponse(request.GetTransactionID(), request.GetUnitID(),
+				common.FuncWatchRegisters, response.GetPDU().Data)
+		}
+		if !s.respond(ctx, client, request, data, response, err) {
+			client.conn.Close()
+		}
+	}()
+}
+
+// watch repeats the read of a watch until its values differ from the last
+// seen ones or the wait, measured by the server's clock, is over, and
+// returns the last read response
+func (s *TCPServer) watch(ctx context.Context, watch *watchRequest) (common.Response, error) {
+	deadline := s.clock.Now().Add(watch.wait)
+	for {
+		response, err := s.dispatchRequest(ctx, watch.read)
+		if err != nil || !bytes.Equal(response.GetPDU().Data, watch.lastSeen) {
+			return response, err
+		}
+		remaining := deadline.Sub(s.clock.Now())
+		if remaining <= 0 {
+			return response, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.clock.After(min(s.watchInterval, remaining)):
+		}
+	}
+}
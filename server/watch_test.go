@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/common/test"
+)
+
+func TestTCPServer_Watch(t *testing.T) {
+	store := NewMemoryStore()
+	store.SetHoldingRegister(100, 7)
+	clock := test.NewManualClock(time.Unix(0, 0))
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0), WithServerDataStore(store),
+		WithServerClock(clock), WithChangeNotifications(time.Second))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Holding register 100 last seen as 0, waiting up to 1s
+	watch := []byte{byte(common.FuncReadHoldingRegisters), 0x00, 0x64, 0x00, 0x01, 0x03, 0xE8, 0x00, 0x00}
+
+	// A change since the last seen value is answered at once
+	pdu := sendRawRequest(t, conn, 1, 1, common.FuncWatchRegisters, watch)
+	if want := []byte{byte(common.FuncWatchRegisters), 0x02, 0x00, 0x07}; !bytes.Equal(pdu, want) {
+		t.Errorf("Expected % X, got % X", want, pdu)
+	}
+
+	// Without a change the unchanged value is returned after the 3s wait
+	watch[5], watch[6], watch[8] = 0x0B, 0xB8, 0x07
+	writeRawRequest(t, conn, 2, 1, common.FuncWatchRegisters, watch)
+	for range 2 {
+		awaitClockWaiter(t, clock)
+		clock.Advance(time.Second)
+	}
+	awaitClockWaiter(t, clock)
+	conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Expected the watch to be held for the wait")
+	}
+	clock.Advance(time.Second)
+	pdu = readRawResponse(t, conn, 2)
+	if want := []byte{byte(common.FuncWatchRegisters), 0x02, 0x00, 0x07}; !bytes.Equal(pdu, want) {
+		t.Errorf("Expected % X, got % X", want, pdu)
+	}
+
+	// A change during the wait is answered at the next re-check
+	writeRawRequest(t, conn, 3, 1, common.FuncWatchRegisters, watch)
+	awaitClockWaiter(t, clock)
+	store.SetHoldingRegister(100, 8)
+	clock.Advance(time.Second)
+	pdu = readRawResponse(t, conn, 3)
+	if want := []byte{byte(common.FuncWatchRegisters), 0x02, 0x00, 0x08}; !bytes.Equal(pdu, want) {
+		t.Errorf("Expected % X, got % X", want, pdu)
+	}
+
+	for name, data := range map[string][]byte{
+		"coils":          {byte(common.FuncReadCoils), 0x00, 0x64, 0x00, 0x01, 0x00, 0x14, 0x00, 0x00},
+		"missing values": watch[:7],
+		"too many":       append([]byte{byte(common.FuncReadHoldingRegisters), 0x00, 0x00, 0x00, 0x7B, 0x00, 0x14}, make([]byte, 244)...),
+		"zero registers": {byte(common.FuncReadHoldingRegisters), 0x00, 0x64, 0x00, 0x00, 0x00, 0x14},
+	} {
+		pdu := sendRawRequest(t, conn, 4, 1, common.FuncWatchRegisters, data)
+		if want := []byte{byte(common.FuncWatchRegisters) | common.ExceptionBit, byte(common.ExceptionInvalidDataValue)}; !bytes.Equal(pdu, want) {
+			t.Errorf("%s: expected % X, got % X", name, want, pdu)
+		}
+	}
+
+	// Read errors are reported with the watch function code
+	watch[1], watch[2] = 0xFF, 0xFF
+	watch = append(watch[:3], 0x00, 0x02, 0x00, 0x14, 0x00, 0x00, 0x00, 0x00)
+	pdu = sendRawRequest(t, conn, 5, 1, common.FuncWatchRegisters, watch)
+	if want := []byte{byte(common.FuncWatchRegisters) | common.ExceptionBit, byte(common.ExceptionDataAddressNotAvailable)}; !bytes.Equal(pdu, want) {
+		t.Errorf("Expected % X, got % X", want, pdu)
+	}
+}
+
+// awaitClockWaiter waits until the server waits on the manual clock
+func awaitClockWaiter(t *testing.T, clock *test.ManualClock) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for clock.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the server to wait on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTCPServer_WatchDisabled(t *testing.T) {
+	srv := NewTCPServer("127.0.0.1", WithServerPort(0))
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	watch := []byte{byte(common.FuncReadHoldingRegisters), 0x00, 0x64, 0x00, 0x01, 0x03, 0xE8, 0x00, 0x00}
+	pdu := sendRawRequest(t, conn, 1, 1, common.FuncWatchRegisters, watch)
+	if want := []byte{byte(common.FuncWatchRegisters) | common.ExceptionBit, byte(common.ExceptionFunctionCodeNotSupported)}; !bytes.Equal(pdu, want) {
+		t.Errorf("Expected % X, got % X", want, pdu)
+	}
+}
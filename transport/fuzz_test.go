@@ -0,0 +1,39 @@
+package transport
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzDecode feeds Request.Decode and Response.Decode the frames a hostile
+// or buggy peer could send: they must fail with an error, never panic, and
+// whatever decodes must encode back to the same frame. Run it with:
+//
+//	go test ./transport -run '^$' -fuzz FuzzDecode
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x00, 0x00, 0x0A})
+	f.Add([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x01, 0x03, 0x02, 0x12, 0x34})
+	f.Add([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x01, 0x03})
+	f.Add([]byte{0x00, 0x01, 0x00, 0x00, 0xFF, 0xFF, 0x01, 0x03, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var request Request
+		if err := request.Decode(data); err == nil {
+			if len(request.PDU.Data) > len(data) {
+				t.Fatalf("Decoded %d data bytes from a %d byte frame", len(request.PDU.Data), len(data))
+			}
+		}
+
+		var response Response
+		if err := response.Decode(data); err != nil {
+			return
+		}
+		encoded, err := response.Encode()
+		if err != nil {
+			t.Fatalf("Failed to encode decoded response: %v", err)
+		}
+		if !bytes.HasPrefix(data, encoded) {
+			t.Fatalf("Response % X encodes to % X", data, encoded)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// startOversizingServer starts a server that answers the first request of
+// every connection with a frame announcing a 300 byte PDU, whose second
+// half follows after pause, and echoes the others, and returns its port
+func startOversizingServer(t *testing.T, pause time.Duration) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for first := true; ; first = false {
+					header := make([]byte, 7)
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					pdu := make([]byte, binary.BigEndian.Uint16(header[4:6])-1)
+					if _, err := io.ReadFull(conn, pdu); err != nil {
+						return
+					}
+					frame := append(header, pdu...)
+					if first {
+						binary.BigEndian.PutUint16(frame[4:6], 301)
+						frame = append(frame[:8], make([]byte, 299)...)
+						if _, err := conn.Write(frame[:150]); err != nil {
+							return
+						}
+						time.Sleep(pause)
+						frame = frame[150:]
+					}
+					if _, err := conn.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestOversizedFramePolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cases := []struct {
+		name      string
+		options   []TCPTransportOption
+		pause     time.Duration
+		connected bool
+	}{
+		{"drop", nil, 0, true},
+		// The body arrives across several read deadlines of the read loop
+		{"drop split", nil, 350 * time.Millisecond, true},
+		{"close", []TCPTransportOption{WithOversizedFramePolicy(common.OversizedFrameClose)}, 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := NewTCPTransport("127.0.0.1", append(tc.options, WithPort(startOversizingServer(t, tc.pause)))...)
+			if err := transport.Connect(ctx); err != nil {
+				t.Fatalf("Failed to connect: %v", err)
+			}
+			defer transport.Disconnect(ctx)
+
+			request := createTestRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+			if _, err := transport.Send(ctx, request); !errors.Is(err, common.ErrResponseTooLarge) {
+				t.Fatalf("Expected ErrResponseTooLarge, got %v", err)
+			}
+
+			if !tc.connected {
+				deadline := time.Now().Add(time.Second)
+				for transport.IsConnected() && time.Now().Before(deadline) {
+					time.Sleep(10 * time.Millisecond)
+				}
+				if transport.IsConnected() {
+					t.Error("Expected the transport to disconnect")
+				}
+				return
+			}
+
+			// The body was skipped, so the next response is read from its start
+			request = createTestRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x02})
+			response, err := transport.Send(ctx, request)
+			if err != nil {
+				t.Fatalf("Expected a response after the dropped frame, got %v", err)
+			}
+			if data := response.GetPDU().Data; len(data) != 4 || data[3] != 0x02 {
+				t.Errorf("Expected the echoed request, got % X", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// TestReconnect checks that requests succeed after Disconnect and Connect,
+// which requires the new connection's reader and writer to be used and the
+// previous connection's loops not to tear the new one down
+func TestReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The echo server keeps accepting so each Connect gets a new connection
+	port := startRewritingServer(t, 1)
+	transport := NewTCPTransport("127.0.0.1", WithPort(port))
+	defer transport.Disconnect(ctx)
+
+	for i := 0; i < 3; i++ {
+		if err := transport.Connect(ctx); err != nil {
+			t.Fatalf("Connect %d failed: %v", i, err)
+		}
+		request := createTestRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+		if _, err := transport.Send(ctx, request); err != nil {
+			t.Fatalf("Send after connect %d failed: %v", i, err)
+		}
+		if err := transport.Disconnect(ctx); err != nil {
+			t.Fatalf("Disconnect %d failed: %v", i, err)
+		}
+	}
+}
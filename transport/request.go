@@ -131,8 +131,14 @@ func (r *Request) Decode(data []byte) error {
 	}
 
 	// Read PDU - Data (variable)
-	// Length field includes Unit ID (1) and Function Code (1)
-	pduData := make([]byte, length-2) // -2 for UnitID and FunctionCode
+	// Length field includes Unit ID (1) and Function Code (1); check it
+	// against the data before allocating
+	pduDataLength := int(length) - 2 // -2 for UnitID and FunctionCode
+	if pduDataLength < 0 || pduDataLength > buffer.Len() {
+		return common.ErrInvalidResponseLength
+	}
+
+	pduData := make([]byte, pduDataLength)
 	if _, err := io.ReadFull(buffer, pduData); err != nil {
 		return err
 	}
@@ -121,9 +121,10 @@ func (r *Response) Decode(data []byte) error {
 	}
 
 	// Read PDU - Data (variable)
-	// Length field includes Unit ID (1) and Function Code (1)
+	// Length field includes Unit ID (1) and Function Code (1); check it
+	// against the data before allocating
 	pduDataLength := int(length) - 2 // -2 for UnitID and FunctionCode
-	if pduDataLength < 0 {
+	if pduDataLength < 0 || pduDataLength > buffer.Len() {
 		return common.ErrInvalidResponseLength
 	}
 
@@ -0,0 +1,290 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Framing is the frame format a TCPTransport uses on its connection
+type Framing int
+
+const (
+	// FramingTCP frames requests with an MBAP header, as Modbus TCP does
+	FramingTCP Framing = iota
+
+	// FramingRTU sends raw RTU frames, unit ID first and CRC last, as
+	// serial-to-Ethernet converters tunneling a serial line do
+	FramingRTU
+)
+
+// String returns the name of the framing
+func (f Framing) String() string {
+	switch f {
+	case FramingTCP:
+		return "tcp"
+	case FramingRTU:
+		return "rtu"
+	default:
+		return "unknown"
+	}
+}
+
+// maxRTUFrameLength is the largest RTU frame: unit ID, PDU and CRC
+// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.5.1.1 (MODBUS Message RTU Framing)
+const maxRTUFrameLength = 1 + common.MaxPDULength + 2
+
+// WithFraming sets the frame format used on the connection. With FramingRTU
+// frames carry no transaction ID, so requests are sent one at a time, each
+// once the previous one is answered or given up; a response arriving after
+// its request was given up is discarded when its function code differs from
+// the request in flight.
+func WithFraming(framing Framing) TCPTransportOption {
+	return func(t *TCPTransport) {
+		t.framing = framing
+	}
+}
+
+// NewRTUOverTCPTransport creates a transport sending RTU frames over a TCP
+// connection, for converters that tunnel a serial line without adding an
+// MBAP header
+func NewRTUOverTCPTransport(host string, options ...TCPTransportOption) *TCPTransport {
+	return NewTCPTransport(host, append(options, WithFraming(FramingRTU))...)
+}
+
+// crc16 computes the Modbus CRC of data
+// Ref: Modbus_over_serial_line_V1_02.pdf, Section 6.2.2 (CRC Generation)
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// encodeRTU encodes a request as an RTU frame; the CRC is sent low byte first
+// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.5.1.2 (CRC Checking)
+func encodeRTU(request common.Request) []byte {
+	pdu := request.GetPDU()
+	frame := make([]byte, 0, 2+len(pdu.Data)+2)
+	frame = append(frame, byte(request.GetUnitID()), byte(pdu.FunctionCode))
+	frame = append(frame, pdu.Data...)
+	return binary.LittleEndian.AppendUint16(frame, crc16(frame))
+}
+
+// encode encodes a request in the framing of the transport
+func (t *TCPTransport) encode(request common.Request) ([]byte, error) {
+	if t.framing == FramingRTU {
+		return encodeRTU(request), nil
+	}
+	return request.Encode()
+}
+
+// rtuResponseLength returns the length of the RTU response frame starting
+// pending, or 0 if more bytes are needed to tell. RTU frames are delimited by
+// silence on the serial line, which a TCP stream does not preserve, so the
+// length is worked out from the function code and byte counts.
+func rtuResponseLength(pending []byte) (int, error) {
+	if len(pending) < 2 {
+		return 0, nil
+	}
+	functionCode := common.FunctionCode(pending[1])
+	if common.IsException(byte(functionCode)) {
+		return 5, nil // unit ID, function code, exception code, CRC
+	}
+	switch functionCode {
+	case common.FuncReadCoils, common.FuncReadDiscreteInputs, common.FuncReadHoldingRegisters,
+		common.FuncReadInputRegisters, common.FuncReadWriteMultipleRegisters, common.FuncWatchRegisters,
+		common.FuncReadFileRecord, common.FuncWriteFileRecord:
+		if len(pending) < 3 {
+			return 0, nil
+		}
+		return 3 + int(pending[2]) + 2, nil
+	case common.FuncWriteSingleCoil, common.FuncWriteSingleRegister,
+		common.FuncWriteMultipleCoils, common.FuncWriteMultipleRegisters:
+		return 8, nil
+	case common.FuncMaskWriteRegister:
+		return 10, nil
+	case common.FuncReadExceptionStatus:
+		return 5, nil
+	case common.FuncReadFIFOQueue:
+		// The byte count takes two bytes
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.18
+		if len(pending) < 4 {
+			return 0, nil
+		}
+		return 4 + int(binary.BigEndian.Uint16(pending[2:4])) + 2, nil
+	case common.FuncReadDeviceIdentification:
+		// MEI type, code, conformity level, more follows, next object ID and
+		// number of objects, then the objects as ID, length and value
+		// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6.21
+		if len(pending) < 8 {
+			return 0, nil
+		}
+		length := 8
+		for range int(pending[7]) {
+			if len(pending) < length+2 {
+				return 0, nil
+			}
+			length += 2 + int(pending[length+1])
+		}
+		return length + 2, nil
+	default:
+		return 0, fmt.Errorf("%w: cannot frame RTU response to function %s", common.ErrInvalidFunction, functionCode)
+	}
+}
+
+// awaitRTU waits until the request in flight is answered or given up, and
+// reports whether the connection is still open
+func (t *TCPTransport) awaitRTU(s connState, tx *Transaction) bool {
+	defer t.rtuInflight.CompareAndSwap(tx, nil)
+	select {
+	case <-tx.Context().Done():
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// readRTUConn is the read loop of one connection with RTU framing
+func (t *TCPTransport) readRTUConn(s connState) {
+	ctx := context.Background()
+	t.logger.Debug(ctx, "Starting RTU read loop")
+
+	defer func() {
+		t.logger.Debug(ctx, "Exiting RTU read loop")
+		t.setDisconnected(s.done, fmt.Errorf("read loop exited"))
+	}()
+
+	// Short deadlines let the loop notice the done channel
+	readTimeout := 100 * time.Millisecond
+
+	var pending []byte
+	chunk := make([]byte, maxRTUFrameLength)
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		if !t.isCurrent(s.done) {
+			return
+		}
+
+		if deadline, ok := s.conn.(interface{ SetReadDeadline(time.Time) error }); ok {
+			deadline.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+		n, err := s.reader.Read(chunk)
+		pending = append(pending, chunk[:n]...)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			select {
+			case <-s.done:
+				return
+			default:
+				t.logger.Error(ctx, "Error reading RTU frame: %v", err)
+				t.setDisconnected(s.done, fmt.Errorf("read error: %w", err))
+				return
+			}
+		}
+		pending = t.processRTU(ctx, s, pending)
+	}
+}
+
+// processRTU handles the complete frames in pending and returns the bytes
+// of a frame still being received
+func (t *TCPTransport) processRTU(ctx context.Context, s connState, pending []byte) []byte {
+	for len(pending) > 0 {
+		length, err := rtuResponseLength(pending)
+		if err != nil {
+			// The rest of the stream cannot be framed; drop it and fail the
+			// request in flight
+			t.logger.Error(ctx, "%v", err)
+			t.failRTU(err)
+			return nil
+		}
+		if length == 0 || len(pending) < length {
+			return pending
+		}
+		if !t.completeRTU(ctx, s, pending[:length]) {
+			// Framing is lost; drop what was received
+			return nil
+		}
+		pending = pending[length:]
+	}
+	return pending
+}
+
+// failRTU completes the request in flight with err
+func (t *TCPTransport) failRTU(err error) {
+	if tx := t.rtuInflight.Load(); tx != nil {
+		t.processError(tx.Request.GetTransactionID(), err)
+	}
+}
+
+// completeRTU completes the request in flight with an RTU response frame,
+// and reports whether the frame's CRC was valid
+func (t *TCPTransport) completeRTU(ctx context.Context, s connState, frame []byte) bool {
+	if hexLogger, ok := t.logger.(common.LoggerInterfaceHexdump); ok {
+		hexLogger.Hexdump(ctx, frame)
+	}
+	if len(t.taps) > 0 {
+		t.tap(s, DirectionRx, frame)
+	}
+
+	body := frame[:len(frame)-2]
+	if crc := binary.LittleEndian.Uint16(frame[len(frame)-2:]); crc != crc16(body) {
+		t.logger.Error(ctx, "Invalid CRC %04X in RTU response", crc)
+		t.failRTU(common.ErrInvalidCRC)
+		return false
+	}
+	unitID := common.UnitID(body[0])
+	functionCode := common.FunctionCode(body[1])
+
+	// Without a transaction ID only the function code tells a response to
+	// a request given up from one to the request in flight
+	inflight := t.rtuInflight.Load()
+	if inflight == nil || functionCode&^common.FunctionCode(common.ExceptionBit) != inflight.Request.GetPDU().FunctionCode {
+		t.lateResponses.Add(1)
+		t.logger.Warn(ctx, "Received RTU response to function %s with no request in flight", functionCode)
+		return true
+	}
+	transactionID := inflight.Request.GetTransactionID()
+	if !t.transactionPool.Discard(inflight) {
+		t.lateResponses.Add(1)
+		t.logger.Warn(ctx, "Received RTU response for transaction %d after it was given up", transactionID)
+		return true
+	}
+	tx := inflight
+
+	// The server must answer with the unit ID of the request
+	// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.2 (MODBUS Master / Slaves protocol principle)
+	if expected := tx.Request.GetUnitID(); unitID != expected {
+		t.unitIDMismatches.Add(1)
+		mismatch := &common.UnitIDMismatchError{TransactionID: transactionID, Expected: expected, Received: unitID}
+		if !t.unitIDTolerant {
+			t.logger.Error(tx.Context(), "%v", mismatch)
+			tx.Complete(nil, mismatch)
+			return true
+		}
+		t.logger.Warn(tx.Context(), "%v (tolerated)", mismatch)
+	}
+
+	t.logger.Debug(tx.Context(), "Completing transaction %d", transactionID)
+	tx.Complete(NewResponse(transactionID, unitID, functionCode, bytes.Clone(body[2:])), nil)
+	return true
+}
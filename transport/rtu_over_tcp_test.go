@@ -0,0 +1,199 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+)
+
+func TestCRC16(t *testing.T) {
+	// Read one holding register of unit 1
+	frame := encodeRTU(NewRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}))
+	expected := []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01, 0x84, 0x0A}
+	if string(frame) != string(expected) {
+		t.Errorf("Expected % X, got % X", expected, frame)
+	}
+}
+
+func TestRTUResponseLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending []byte
+		length  int
+	}{
+		{"empty", nil, 0},
+		{"read without byte count", []byte{0x01, 0x03}, 0},
+		{"read", []byte{0x01, 0x03, 0x04}, 9},
+		{"exception", []byte{0x01, 0x83}, 5},
+		{"write", []byte{0x01, 0x10}, 8},
+		{"exception status", []byte{0x01, 0x07}, 5},
+		{"fifo without byte count", []byte{0x01, 0x18, 0x00}, 0},
+		{"fifo", []byte{0x01, 0x18, 0x00, 0x06}, 12},
+		{"file record", []byte{0x01, 0x14, 0x0C}, 17},
+		{"device id header", []byte{0x01, 0x2B, 0x0E, 0x01, 0x01, 0x00, 0x00}, 0},
+		{"device id object", []byte{0x01, 0x2B, 0x0E, 0x01, 0x01, 0x00, 0x00, 0x02, 0x00, 0x03, 'a', 'b', 'c', 0x01}, 0},
+		{"device id", []byte{0x01, 0x2B, 0x0E, 0x01, 0x01, 0x00, 0x00, 0x02, 0x00, 0x03, 'a', 'b', 'c', 0x01, 0x00}, 17},
+	}
+	for _, tc := range tests {
+		length, err := rtuResponseLength(tc.pending)
+		if err != nil || length != tc.length {
+			t.Errorf("%s: expected %d, got %d (%v)", tc.name, tc.length, length, err)
+		}
+	}
+	if _, err := rtuResponseLength([]byte{0x01, 0x55}); !errors.Is(err, common.ErrInvalidFunction) {
+		t.Errorf("Expected an unknown function to fail, got %v", err)
+	}
+}
+
+// startRTUGateway starts a converter answering 8-byte RTU requests for
+// holding registers with respond, writing its answers a byte at a time
+func startRTUGateway(t *testing.T, respond func(request []byte) []byte) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				request := make([]byte, 8)
+				for {
+					if _, err := io.ReadFull(conn, request); err != nil {
+						return
+					}
+					for _, b := range respond(request) {
+						if _, err := conn.Write([]byte{b}); err != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// rtuFrame appends the CRC to a frame
+func rtuFrame(frame ...byte) []byte {
+	return binary.LittleEndian.AppendUint16(frame, crc16(frame))
+}
+
+func TestRTUOverTCPTransport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	port := startRTUGateway(t, func(request []byte) []byte {
+		if crc16(request[:6]) != binary.LittleEndian.Uint16(request[6:]) {
+			t.Errorf("Invalid request CRC: % X", request)
+		}
+		address := binary.BigEndian.Uint16(request[2:4])
+		switch address {
+		case 1:
+			return rtuFrame(request[0], 0x83, 0x02)
+		case 2:
+			return []byte{request[0], 0x03, 0x02, 0x00, 0x07, 0x00, 0x00}
+		default:
+			return rtuFrame(request[0], 0x03, 0x02, byte(address>>8), byte(address))
+		}
+	})
+	tr := NewRTUOverTCPTransport("127.0.0.1", WithPort(port), WithTransportLogger(logging.NewNoopLogger()))
+	if err := tr.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer tr.Disconnect(ctx)
+
+	read := func(address uint16) (common.Response, error) {
+		data := binary.BigEndian.AppendUint16(nil, address)
+		return tr.Send(ctx, NewRequest(5, common.FuncReadHoldingRegisters, binary.BigEndian.AppendUint16(data, 1)))
+	}
+
+	// Concurrent requests are answered one at a time, each with its own value
+	errs := make(chan error, 10)
+	for i := range 10 {
+		go func() {
+			address := uint16(0x100 + i)
+			response, err := read(address)
+			if err == nil && binary.BigEndian.Uint16(response.GetPDU().Data[1:]) != address {
+				err = errors.New("wrong response")
+			}
+			if err == nil && response.GetUnitID() != 5 {
+				err = errors.New("wrong unit ID")
+			}
+			errs <- err
+		}()
+	}
+	for range 10 {
+		if err := <-errs; err != nil {
+			t.Errorf("Read failed: %v", err)
+		}
+	}
+
+	response, err := read(1)
+	if err != nil || !response.IsException() || response.GetException() != common.ExceptionDataAddressNotAvailable {
+		t.Errorf("Expected an exception response, got %v, %v", response, err)
+	}
+
+	if _, err := read(2); !errors.Is(err, common.ErrInvalidCRC) {
+		t.Errorf("Expected a CRC error, got %v", err)
+	}
+
+	// The transport is still usable after the bad frame
+	if _, err := read(3); err != nil {
+		t.Errorf("Read after the CRC error failed: %v", err)
+	}
+}
+
+func TestRTUOverTCPTransport_Broadcast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan []byte, 2)
+	port := startRTUGateway(t, func(request []byte) []byte {
+		received <- append([]byte(nil), request...)
+		if request[0] == byte(common.BroadcastUnitID) {
+			return nil // devices do not answer broadcasts
+		}
+		return rtuFrame(request[0], 0x03, 0x02, 0x00, 0x2A)
+	})
+	tr := NewRTUOverTCPTransport("127.0.0.1", WithPort(port), WithTransportLogger(logging.NewNoopLogger()))
+	if err := tr.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer tr.Disconnect(ctx)
+
+	turnaround := 50 * time.Millisecond
+	start := time.Now()
+	write := NewRequest(common.BroadcastUnitID, common.FuncWriteSingleRegister, []byte{0x00, 0x01, 0x00, 0x03})
+	if err := tr.Broadcast(ctx, write, turnaround); err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < turnaround {
+		t.Errorf("Broadcast returned after %v, before the turnaround delay", elapsed)
+	}
+	if frame := <-received; !bytes.Equal(frame, rtuFrame(0x00, 0x06, 0x00, 0x01, 0x00, 0x03)) {
+		t.Errorf("Expected the broadcast frame, got % X", frame)
+	}
+	if tr.PendingTransactions() != 0 {
+		t.Errorf("Expected no pending transactions, got %d", tr.PendingTransactions())
+	}
+
+	// The line is free for the next request at once
+	response, err := tr.Send(ctx, NewRequest(5, common.FuncReadHoldingRegisters, []byte{0x00, 0x01, 0x00, 0x01}))
+	if err != nil || response.GetPDU().Data[2] != 0x2A {
+		t.Errorf("Expected a read after the broadcast, got %v, %v", response, err)
+	}
+}
@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of a transport's health. It puts the Modbus-level
+// request latency next to what the kernel knows about the socket, to tell a
+// slow network apart from a slow device: when the latency is high but the
+// round-trip time is low, the time is spent in the device.
+type Stats struct {
+	Endpoint string // the server address, host:port
+
+	Responses uint64 // requests answered, including exception responses
+	Errors    uint64 // requests that failed without a response, e.g. timeouts
+	Pending   int    // requests awaiting a response
+
+	// Request latency, from sending a request to receiving its response
+	LastLatency    time.Duration
+	AverageLatency time.Duration
+	MaxLatency     time.Duration
+
+	UnitIDMismatches uint64 // see UnitIDMismatches
+	LateResponses    uint64 // see LateResponses
+
+	// Socket holds TCP_INFO statistics of the current connection. It is nil
+	// when not connected or when the platform does not provide them (only
+	// Linux does).
+	Socket *SocketStats
+}
+
+// SocketStats are kernel statistics of a TCP connection
+type SocketStats struct {
+	RTT              time.Duration // smoothed round-trip time
+	RTTVariance      time.Duration // round-trip time variance
+	RTO              time.Duration // current retransmission timeout
+	Retransmits      uint32        // retransmissions of the unacknowledged segment
+	TotalRetransmits uint32        // retransmitted segments over the connection's life
+	Lost             uint32        // segments presumed lost
+	Unacked          uint32        // segments sent but not acknowledged
+	CongestionWindow uint32        // send congestion window, in segments
+}
+
+// DeviceTime estimates how much of the average request latency is spent
+// outside the network, in the device or a gateway, by subtracting the socket
+// round-trip time. It returns the average latency when no socket statistics
+// are available.
+func (s Stats) DeviceTime() time.Duration {
+	if s.Socket == nil {
+		return s.AverageLatency
+	}
+	return max(s.AverageLatency-s.Socket.RTT, 0)
+}
+
+// latencyStats accumulates request outcomes for Stats
+type latencyStats struct {
+	mu        sync.Mutex
+	responses uint64
+	errors    uint64
+	total     time.Duration
+	last      time.Duration
+	max       time.Duration
+}
+
+// response records a request answered after latency
+func (l *latencyStats) response(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.responses++
+	l.total += latency
+	l.last = latency
+	l.max = max(l.max, latency)
+}
+
+// failure records a request that ended without a response
+func (l *latencyStats) failure() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors++
+}
+
+// fill copies the accumulated values into s
+func (l *latencyStats) fill(s *Stats) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s.Responses = l.responses
+	s.Errors = l.errors
+	s.LastLatency = l.last
+	s.MaxLatency = l.max
+	if l.responses > 0 {
+		s.AverageLatency = l.total / time.Duration(l.responses)
+	}
+}
+
+// Stats returns a snapshot of the transport's request and socket statistics
+func (t *TCPTransport) Stats() Stats {
+	s := Stats{Endpoint: t.Address()}
+	t.latency.fill(&s)
+	s.Pending = t.PendingTransactions()
+	s.UnitIDMismatches = t.UnitIDMismatches()
+	s.LateResponses = t.LateResponses()
+
+	t.mutex.Lock()
+	conn, connected := t.conn, t.connected
+	t.mutex.Unlock()
+	if connected && conn != nil {
+		s.Socket = socketStats(conn)
+	}
+	return s
+}
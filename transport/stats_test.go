@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestTCPTransport_Stats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	port := startRewritingServer(t, 1)
+	transport := NewTCPTransport("127.0.0.1", WithPort(port))
+	if transport.RemoteAddr() != "" {
+		t.Errorf("Expected no resolved address before connecting, got %q", transport.RemoteAddr())
+	}
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer transport.Disconnect(ctx)
+	if got, want := transport.RemoteAddr(), fmt.Sprintf("127.0.0.1:%d", port); got != want {
+		t.Errorf("Expected resolved address %s, got %s", want, got)
+	}
+
+	for i := 0; i < 3; i++ {
+		request := createTestRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+		if _, err := transport.Send(ctx, request); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	stats := transport.Stats()
+	if stats.Responses != 3 || stats.Errors != 0 || stats.Pending != 0 {
+		t.Errorf("Expected 3 responses, no errors and nothing pending, got %+v", stats)
+	}
+	if stats.AverageLatency <= 0 || stats.MaxLatency < stats.AverageLatency {
+		t.Errorf("Expected positive latencies, got average %v max %v", stats.AverageLatency, stats.MaxLatency)
+	}
+	if runtime.GOOS == "linux" && stats.Socket == nil {
+		t.Error("Expected TCP_INFO socket statistics on Linux")
+	}
+	if stats.DeviceTime() > stats.AverageLatency {
+		t.Errorf("Device time %v exceeds average latency %v", stats.DeviceTime(), stats.AverageLatency)
+	}
+
+	transport.Disconnect(ctx)
+	if transport.Stats().Socket != nil {
+		t.Error("Expected no socket statistics after disconnecting")
+	}
+}
+
+func TestTCPTransport_EndpointLabel(t *testing.T) {
+	transport := NewTCPTransport("127.0.0.1", WithPort(1502))
+	if got := transport.Stats().Endpoint; got != "127.0.0.1:1502" {
+		t.Errorf("Expected stats for 127.0.0.1:1502, got %q", got)
+	}
+
+	_, err := transport.Send(context.Background(), createTestRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01}))
+	var labeled *common.EndpointError
+	if !errors.As(err, &labeled) || labeled.Endpoint != "127.0.0.1:1502" || !errors.Is(err, common.ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected labeled 127.0.0.1:1502, got %v", err)
+	}
+}
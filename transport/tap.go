@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Direction tells whether a tapped frame was sent or received
+type Direction int
+
+const (
+	// DirectionTx is a request written to the connection
+	DirectionTx Direction = iota
+
+	// DirectionRx is a response read from the connection
+	DirectionRx
+)
+
+// String returns the name of the direction
+func (d Direction) String() string {
+	switch d {
+	case DirectionTx:
+		return "tx"
+	case DirectionRx:
+		return "rx"
+	default:
+		return "unknown"
+	}
+}
+
+// Meta describes a tapped frame, parsed from its MBAP header
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (MBAP Header)
+type Meta struct {
+	TransactionID common.TransactionID
+	UnitID        common.UnitID
+	FunctionCode  common.FunctionCode
+	Time          time.Time
+	RemoteAddr    string
+}
+
+// TapFunc receives every frame written or read by a TCPTransport. adu is the
+// complete frame, MBAP header included (with FramingRTU the RTU frame, CRC
+// included, and Meta has no transaction ID), and is only valid for the duration
+// of the call: a tap that keeps it must copy it. Taps run on the read and
+// write loops, so they must return quickly.
+type TapFunc func(direction Direction, adu []byte, meta Meta)
+
+// WithTap registers a tap called for every frame the transport writes or
+// reads, the common hook for capture, metrics and debugging decorators.
+// Several taps are called in registration order. Without a tap, frames are
+// neither copied nor parsed for it.
+func WithTap(tap TapFunc) TCPTransportOption {
+	return func(t *TCPTransport) {
+		t.taps = append(t.taps, tap)
+	}
+}
+
+// tap calls the registered taps with a frame
+func (t *TCPTransport) tap(s connState, direction Direction, adu []byte) {
+	meta := Meta{Time: time.Now()}
+	if t.framing == FramingRTU && len(adu) >= 2 {
+		meta.UnitID = common.UnitID(adu[0])
+		meta.FunctionCode = common.FunctionCode(adu[1])
+	} else if t.framing != FramingRTU && len(adu) >= common.TCPHeaderLength+1 {
+		meta.TransactionID = common.TransactionID(uint16(adu[0])<<8 | uint16(adu[1]))
+		meta.UnitID = common.UnitID(adu[6])
+		meta.FunctionCode = common.FunctionCode(adu[7])
+	}
+	if s.conn != nil && s.conn.RemoteAddr() != nil {
+		meta.RemoteAddr = s.conn.RemoteAddr().String()
+	}
+	for _, tap := range t.taps {
+		tap(direction, adu, meta)
+	}
+}
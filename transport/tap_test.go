@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestWithTap(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	type frame struct {
+		direction Direction
+		adu       []byte
+		meta      Meta
+	}
+	frames := make(chan frame, 4)
+	port := startRewritingServer(t, 1)
+	transport := NewTCPTransport("127.0.0.1", WithPort(port), WithTap(func(direction Direction, adu []byte, meta Meta) {
+		frames <- frame{direction, bytes.Clone(adu), meta}
+	}))
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer transport.Disconnect(ctx)
+
+	request := createTestRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x0A, 0x00, 0x02})
+	if _, err := transport.Send(ctx, request); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	got := map[Direction]frame{}
+	for len(got) < 2 {
+		select {
+		case f := <-frames:
+			got[f.direction] = f
+		case <-ctx.Done():
+			t.Fatalf("Expected a tx and an rx frame, got %d frames", len(got))
+		}
+	}
+
+	// The server echoes the request, so both frames carry the same bytes
+	want := []byte{0x00, 0x06, 0x01, 0x03, 0x00, 0x0A, 0x00, 0x02}
+	for _, direction := range []Direction{DirectionTx, DirectionRx} {
+		f := got[direction]
+		if len(f.adu) != common.TCPHeaderLength+5 || !bytes.Equal(f.adu[4:], want) {
+			t.Errorf("%s: unexpected frame % X", direction, f.adu)
+		}
+		if f.meta.TransactionID != request.GetTransactionID() {
+			t.Errorf("%s: expected transaction %d, got %d", direction, request.GetTransactionID(), f.meta.TransactionID)
+		}
+		if f.meta.UnitID != 1 || f.meta.FunctionCode != common.FuncReadHoldingRegisters {
+			t.Errorf("%s: unexpected meta %+v", direction, f.meta)
+		}
+		if f.meta.RemoteAddr == "" || f.meta.Time.IsZero() {
+			t.Errorf("%s: expected remote address and time, got %+v", direction, f.meta)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package transport
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Moonlight-Companies/gomodbus/common"
@@ -29,6 +32,21 @@ type TCPTransport struct {
 	transactionPool *TransactionPool       // Manages transaction IDs and responses
 	writeChan       chan *Transaction      // Channel for queuing write operations
 	done            chan struct{}          // Signals shutdown of goroutines
+
+	unitIDTolerant   bool          // Accept responses whose unit ID differs from the request
+	unitIDMismatches atomic.Uint64 // Responses whose unit ID differed from the request
+	lateResponses    atomic.Uint64 // Responses for no pending transaction, see LateResponses
+	latency          latencyStats  // Request outcomes and latency, see Stats
+	taps             []TapFunc     // Called for every frame, see WithTap
+
+	framing     Framing                     // Frame format on the connection, see WithFraming
+	rtuInflight atomic.Pointer[Transaction] // The request awaiting its RTU response
+
+	onConnectionLost []func(error) // Called when the connection fails, see OnConnectionLost
+
+	tlsConfig *tls.Config // Modbus/TCP Security, see WithTLSConfig
+
+	oversized common.OversizedFramePolicy // Handling of frames longer than a PDU, see WithOversizedFramePolicy
 }
 
 // TCPTransportOption is a function that configures a TCPTransport
@@ -69,6 +87,55 @@ func WithTransportLogger(logger common.LoggerInterface) TCPTransportOption {
 	}
 }
 
+// WithUnitIDTolerance accepts responses whose unit ID differs from the
+// request, for gateways that rewrite unit IDs. Mismatches are still logged
+// and counted. By default such responses fail with a
+// *common.UnitIDMismatchError.
+// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+func WithUnitIDTolerance() TCPTransportOption {
+	return func(t *TCPTransport) {
+		t.unitIDTolerant = true
+	}
+}
+
+// WithOversizedFramePolicy sets what happens to a response whose length
+// field announces more than a PDU: by default (common.OversizedFrameDrop)
+// its body is skipped without buffering it, common.OversizedFrameClose
+// drops the connection. Either way the request it answers fails with
+// common.ErrResponseTooLarge.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (Protocol Description)
+func WithOversizedFramePolicy(policy common.OversizedFramePolicy) TCPTransportOption {
+	return func(t *TCPTransport) {
+		t.oversized = policy
+	}
+}
+
+// connState is the state of one connection. The read and write loops run
+// against the connState they were started with, so loops left over from a
+// previous connection never touch the current one.
+type connState struct {
+	done      chan struct{}
+	conn      net.Conn
+	reader    io.Reader
+	writer    io.Writer
+	writeChan chan *Transaction
+}
+
+// snapshot returns the state of the current connection
+func (t *TCPTransport) snapshot() connState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return connState{done: t.done, conn: t.conn, reader: t.reader, writer: t.writer, writeChan: t.writeChan}
+}
+
+// isCurrent reports whether the transport is connected and done belongs to
+// the current connection
+func (t *TCPTransport) isCurrent(done chan struct{}) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.connected && t.done == done
+}
+
 // NewTCPTransport creates a new TCPTransport
 func NewTCPTransport(host string, options ...TCPTransportOption) *TCPTransport {
 	t := &TCPTransport{
@@ -85,18 +152,48 @@ func NewTCPTransport(host string, options ...TCPTransportOption) *TCPTransport {
 	for _, option := range options {
 		option(t)
 	}
+	t.logger = t.labelLogger(t.logger)
 
 	return t
 }
 
 // WithLogger sets the logger for the transport and returns the modified transport
 func (t *TCPTransport) WithLogger(logger common.LoggerInterface) common.Transport {
-	t.logger = logger
+	t.logger = t.labelLogger(logger)
 	return t
 }
 
-// Connect establishes a connection to the Modbus TCP server
+// labelLogger adds the transport's endpoint to the log fields of logger
+func (t *TCPTransport) labelLogger(logger common.LoggerInterface) common.LoggerInterface {
+	return logger.WithFields(map[string]interface{}{"endpoint": t.Address()})
+}
+
+// Endpoint returns the server address as host:port; it labels the
+// transport's log fields and errors
+func (t *TCPTransport) Endpoint() string {
+	return t.Address()
+}
+
+// RemoteAddr returns the resolved address, ip:port, of the current or last
+// connection, or "" before the first one. The host is resolved again on
+// every Connect, so the address follows DNS changes.
+func (t *TCPTransport) RemoteAddr() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.conn == nil || t.conn.RemoteAddr() == nil {
+		return ""
+	}
+	return t.conn.RemoteAddr().String()
+}
+
+// Connect establishes a connection to the Modbus TCP server. Errors are
+// *common.EndpointError values labeled with the server address.
 func (t *TCPTransport) Connect(ctx context.Context) error {
+	return common.WithEndpoint(t.connect(ctx), t.Address())
+}
+
+// connect establishes a connection to the Modbus TCP server
+func (t *TCPTransport) connect(ctx context.Context) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
@@ -120,10 +217,9 @@ func (t *TCPTransport) Connect(ctx context.Context) error {
 	t.transactionPool.unsafeReset()
 	t.transactionPool.transactionsMu.Unlock()
 
-	// Re-initialize write channel if needed
-	if t.writeChan == nil {
-		t.writeChan = make(chan *Transaction, 100)
-	}
+	// Each connection gets its own write queue, so a write loop left over
+	// from a previous connection cannot take requests meant for this one
+	t.writeChan = make(chan *Transaction, 100)
 
 	// Get deadline from context or use default timeout
 	deadline, ok := ctx.Deadline()
@@ -137,19 +233,22 @@ func (t *TCPTransport) Connect(ctx context.Context) error {
 	}
 
 	addr := fmt.Sprintf("%s:%d", t.host, t.port)
-	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	conn, err := t.dial(ctx, &dialer, addr)
 	if err != nil {
 		t.logger.Error(ctx, "Failed to connect to %s: %v", addr, err)
 		return err
 	}
 
+	previous := t.conn
 	t.conn = conn
 
-	// If no custom reader/writer was provided, use the connection
-	if t.reader == nil {
+	// If no custom reader/writer was provided, use the connection. On
+	// reconnect the reader and writer still refer to the previous, closed
+	// connection and must be replaced too.
+	if t.reader == nil || (previous != nil && t.reader == io.Reader(previous)) {
 		t.reader = t.conn
 	}
-	if t.writer == nil {
+	if t.writer == nil || (previous != nil && t.writer == io.Writer(previous)) {
 		t.writer = t.conn
 	}
 
@@ -158,11 +257,16 @@ func (t *TCPTransport) Connect(ctx context.Context) error {
 
 	t.connected = true
 
-	t.logger.Info(ctx, "Connected to Modbus TCP server at %s:%d", t.host, t.port)
+	t.logger.Info(ctx, "Connected to Modbus TCP server at %s:%d (%s)", t.host, t.port, conn.RemoteAddr())
 
 	// Start the read and write goroutines
-	go t.readLoop()
-	go t.writeLoop()
+	state := connState{done: t.done, conn: t.conn, reader: t.reader, writer: t.writer, writeChan: t.writeChan}
+	if t.framing == FramingRTU {
+		go t.readRTUConn(state)
+	} else {
+		go t.readConn(state)
+	}
+	go t.writeConn(state)
 
 	return nil
 }
@@ -233,12 +337,17 @@ func (t *TCPTransport) ResetTransactions(ctx context.Context) {
 // This implements the client side of the Modbus TCP protocol
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4 (MODBUS Data Model)
 func (t *TCPTransport) readLoop() {
+	t.readConn(t.snapshot())
+}
+
+// readConn is the read loop of one connection
+func (t *TCPTransport) readConn(s connState) {
 	ctx := context.Background()
 	t.logger.Debug(ctx, "Starting read loop")
 
 	defer func() {
 		t.logger.Debug(ctx, "Exiting read loop")
-		t.setDisconnected(fmt.Errorf("read loop exited"))
+		t.setDisconnected(s.done, fmt.Errorf("read loop exited"))
 	}()
 
 	// Set a read deadline to ensure we don't block too long on read operations
@@ -247,16 +356,16 @@ func (t *TCPTransport) readLoop() {
 
 	for {
 		select {
-		case <-t.done:
+		case <-s.done:
 			return
 		default:
 			// Check if we're still connected
-			if !t.IsConnected() {
+			if !t.isCurrent(s.done) {
 				return
 			}
 
 			// Set a deadline for this read operation
-			if deadline, ok := t.conn.(interface{ SetReadDeadline(time.Time) error }); ok {
+			if deadline, ok := s.conn.(interface{ SetReadDeadline(time.Time) error }); ok {
 				deadline.SetReadDeadline(time.Now().Add(readTimeout))
 			}
 
@@ -264,13 +373,13 @@ func (t *TCPTransport) readLoop() {
 			// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (MBAP Header)
 			// MBAP Header is 7 bytes: Transaction ID (2), Protocol ID (2), Length (2), Unit ID (1)
 			header := make([]byte, common.TCPHeaderLength)
-			_, err := io.ReadFull(t.reader, header)
+			_, err := io.ReadFull(s.reader, header)
 			if err != nil {
 				// Check if this is a timeout error (which is expected during shutdown)
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 					// This is a timeout, check if we should exit
 					select {
-					case <-t.done:
+					case <-s.done:
 						return
 					default:
 						// Continue the loop and try again
@@ -280,12 +389,12 @@ func (t *TCPTransport) readLoop() {
 
 				// If we're already disconnected or shutting down, just exit
 				select {
-				case <-t.done:
+				case <-s.done:
 					return
 				default:
 					// Otherwise, log and report the error
 					t.logger.Error(ctx, "Error reading header: %v", err)
-					t.setDisconnected(fmt.Errorf("read error: %w", err))
+					t.setDisconnected(s.done, fmt.Errorf("read error: %w", err))
 					return
 				}
 			}
@@ -326,16 +435,37 @@ func (t *TCPTransport) readLoop() {
 				continue
 			}
 
+			// Never buffer more than a PDU, whatever the length field claims
+			// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (Protocol Description)
+			if bodyLength > common.MaxPDULength {
+				t.logger.Error(ctx, "Response length %d exceeds the maximum of %d", length, common.MaxPDULength+1)
+				t.processError(transactionID, common.ErrResponseTooLarge)
+				if t.oversized == common.OversizedFrameClose {
+					t.setDisconnected(s.done, fmt.Errorf("%w: length %d", common.ErrResponseTooLarge, length))
+					return
+				}
+				if err := t.skipBody(s, int64(bodyLength), readTimeout); err != nil {
+					select {
+					case <-s.done:
+					default:
+						t.logger.Error(ctx, "Error skipping body: %v", err)
+						t.setDisconnected(s.done, err)
+					}
+					return
+				}
+				continue
+			}
+
 			// Read the function code and data (PDU)
 			// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (MODBUS Function Codes)
 			body := make([]byte, bodyLength)
-			_, err = io.ReadFull(t.reader, body)
+			_, err = io.ReadFull(s.reader, body)
 			if err != nil {
 				// Check if this is a timeout or if we're shutting down
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 					// This is a timeout, check if we should exit
 					select {
-					case <-t.done:
+					case <-s.done:
 						return
 					default:
 						// Otherwise continue
@@ -345,13 +475,13 @@ func (t *TCPTransport) readLoop() {
 
 				// If we're shutting down, just exit
 				select {
-				case <-t.done:
+				case <-s.done:
 					return
 				default:
 					// Otherwise, log and report the error
 					t.logger.Error(ctx, "Error reading body: %v", err)
 					t.processError(transactionID, fmt.Errorf("read body error: %w", err))
-					t.setDisconnected(err)
+					t.setDisconnected(s.done, err)
 					return
 				}
 			}
@@ -361,6 +491,10 @@ func (t *TCPTransport) readLoop() {
 				hexLogger.Hexdump(ctx, body)
 			}
 
+			if len(t.taps) > 0 {
+				t.tap(s, DirectionRx, append(header, body...))
+			}
+
 			// Create a response
 			// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (MODBUS Function Codes)
 			// The first byte of the PDU is the function code
@@ -372,11 +506,25 @@ func (t *TCPTransport) readLoop() {
 			// Find and complete the transaction
 			tx, ok := t.transactionPool.Release(transactionID)
 			if !ok {
+				t.lateResponses.Add(1)
 				t.logger.Warn(ctx, "Received response for unknown transaction ID: %d", transactionID)
 				continue
 			}
 
-			t.logger.Debug(ctx, "Completing transaction %d", transactionID)
+			// The server must echo the unit ID of the request
+			// Ref: Modbus_Messaging_Implementation_Guide_V1_0b.pdf, Section 3.1.3 (Unit Identifier)
+			if expected := tx.Request.GetUnitID(); unitID != expected {
+				t.unitIDMismatches.Add(1)
+				mismatch := &common.UnitIDMismatchError{TransactionID: transactionID, Expected: expected, Received: unitID}
+				if !t.unitIDTolerant {
+					t.logger.Error(tx.Context(), "%v", mismatch)
+					tx.Complete(nil, mismatch)
+					continue
+				}
+				t.logger.Warn(tx.Context(), "%v (tolerated)", mismatch)
+			}
+
+			t.logger.Debug(tx.Context(), "Completing transaction %d", transactionID)
 			// Complete the transaction with the response
 			tx.Complete(response, nil)
 		}
@@ -387,31 +535,36 @@ func (t *TCPTransport) readLoop() {
 // This implements the client side of sending Modbus TCP requests
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4 (MODBUS Data Model)
 func (t *TCPTransport) writeLoop() {
+	t.writeConn(t.snapshot())
+}
+
+// writeConn is the write loop of one connection
+func (t *TCPTransport) writeConn(s connState) {
 	ctx := context.Background()
 	t.logger.Debug(ctx, "Starting write loop")
 
 	defer func() {
 		t.logger.Debug(ctx, "Exiting write loop")
-		t.setDisconnected(fmt.Errorf("write loop exited"))
+		t.setDisconnected(s.done, fmt.Errorf("write loop exited"))
 	}()
 
 	for {
 		// First check if we're still connected
-		if !t.IsConnected() {
+		if !t.isCurrent(s.done) {
 			return
 		}
 
 		select {
-		case <-t.done:
+		case <-s.done:
 			return
-		case tx, ok := <-t.writeChan:
+		case tx, ok := <-s.writeChan:
 			// Check if the channel was closed
 			if !ok {
 				return
 			}
 
 			// Check if we're still connected
-			if !t.IsConnected() {
+			if !t.isCurrent(s.done) {
 				tx.Complete(nil, common.ErrNotConnected)
 				return
 			}
@@ -422,7 +575,7 @@ func (t *TCPTransport) writeLoop() {
 				t.logger.Debug(ctx, "Transaction %d was cancelled before writing",
 					tx.Request.GetTransactionID())
 				continue
-			case <-t.done:
+			case <-s.done:
 				// Transport is shutting down
 				tx.Complete(nil, common.ErrTransportClosing)
 				return
@@ -430,56 +583,142 @@ func (t *TCPTransport) writeLoop() {
 				// Transaction is still valid
 			}
 
-			t.logger.Debug(ctx, "Writing request for transaction %d",
+			// Log with the request's context, for its correlation ID
+			txCtx := tx.Context()
+			t.logger.Debug(txCtx, "Writing request for transaction %d",
 				tx.Request.GetTransactionID())
 
 			// Encode the request
 			// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.1 (MBAP Header)
 			// This will create the MBAP header and PDU according to the Modbus specification
-			data, err := tx.Request.Encode()
+			data, err := t.encode(tx.Request)
 			if err != nil {
-				t.logger.Error(ctx, "Error encoding request: %v", err)
+				t.logger.Error(txCtx, "Error encoding request: %v", err)
 				tx.Complete(nil, err)
 				continue
 			}
 
 			// If logger implements Hexdump and we're at trace level, log the encoded request
 			if hexLogger, ok := t.logger.(common.LoggerInterfaceHexdump); ok {
-				hexLogger.Hexdump(ctx, data)
+				hexLogger.Hexdump(txCtx, data)
 			}
 
 			// Check again if we should exit before writing
 			select {
-			case <-t.done:
+			case <-s.done:
 				tx.Complete(nil, common.ErrTransportClosing)
 				return
 			default:
 				// Continue with the write
 			}
 
+			if t.framing == FramingRTU && !tx.broadcast {
+				t.rtuInflight.Store(tx)
+			}
+
 			// Write the request
-			_, err = t.writer.Write(data)
+			_, err = s.writer.Write(data)
 			if err != nil {
 				// If we're shutting down, don't report the error
 				select {
-				case <-t.done:
+				case <-s.done:
 					tx.Complete(nil, common.ErrTransportClosing)
 					return
 				default:
 					// Otherwise, log and report the error
 					t.logger.Error(ctx, "Error writing request: %v", err)
 					tx.Complete(nil, err)
-					t.setDisconnected(fmt.Errorf("write error: %w", err))
+					t.setDisconnected(s.done, fmt.Errorf("write error: %w", err))
 					return
 				}
 			}
 
-			t.logger.Debug(ctx, "Wrote request for transaction %d",
+			if len(t.taps) > 0 {
+				t.tap(s, DirectionTx, data)
+			}
+
+			t.logger.Debug(txCtx, "Wrote request for transaction %d",
 				tx.Request.GetTransactionID())
+
+			if tx.broadcast {
+				if !t.holdLine(s, tx.turnaround) {
+					tx.Complete(nil, common.ErrTransportClosing)
+					return
+				}
+				tx.Complete(nil, nil)
+				continue
+			}
+
+			if t.framing == FramingRTU && !t.awaitRTU(s, tx) {
+				return
+			}
 		}
 	}
 }
 
+// holdLine sends nothing for the turnaround delay after a broadcast, and
+// reports whether the connection is still open
+// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.4.1 (Master State diagram)
+func (t *TCPTransport) holdLine(s connState, turnaround time.Duration) bool {
+	if turnaround <= 0 {
+		return true
+	}
+	timer := time.NewTimer(turnaround)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Address returns the server address as host:port
+func (t *TCPTransport) Address() string {
+	return net.JoinHostPort(t.host, strconv.Itoa(t.port))
+}
+
+// PendingTransactions returns the number of transactions awaiting a response
+func (t *TCPTransport) PendingTransactions() int {
+	return t.transactionPool.GetCount()
+}
+
+// UnitIDMismatches returns the number of responses whose unit ID differed
+// from the request, including those accepted with WithUnitIDTolerance
+func (t *TCPTransport) UnitIDMismatches() uint64 {
+	return t.unitIDMismatches.Load()
+}
+
+// LateResponses returns the number of responses received for no pending
+// transaction, typically answers to requests that had already timed out
+func (t *TCPTransport) LateResponses() uint64 {
+	return t.lateResponses.Load()
+}
+
+// skipBody discards the next n bytes of the connection, reading on through
+// read deadlines so the next header is read from a frame boundary
+func (t *TCPTransport) skipBody(s connState, n int64, readTimeout time.Duration) error {
+	for n > 0 {
+		skipped, err := io.CopyN(io.Discard, s.reader, n)
+		n -= skipped
+		if err == nil {
+			continue
+		}
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			return err
+		}
+		select {
+		case <-s.done:
+			return net.ErrClosed
+		default:
+		}
+		if deadline, ok := s.conn.(interface{ SetReadDeadline(time.Time) error }); ok {
+			deadline.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+	}
+	return nil
+}
+
 // processError handles errors for a specific transaction
 func (t *TCPTransport) processError(txID common.TransactionID, err error) {
 	ctx := context.Background()
@@ -492,31 +731,121 @@ func (t *TCPTransport) processError(txID common.TransactionID, err error) {
 	}
 }
 
-// setDisconnected marks the transport as disconnected
-func (t *TCPTransport) setDisconnected(err error) {
+// setDisconnected marks the transport as disconnected if done still belongs
+// to the current connection; loops of an earlier connection that exit late
+// must not tear down a newer one
+func (t *TCPTransport) setDisconnected(done chan struct{}, err error) {
 	ctx := context.Background()
 	t.mutex.Lock()
+	if t.done != done {
+		t.mutex.Unlock()
+		return
+	}
 	wasConnected := t.connected
 	t.connected = false
+	conn := t.conn
+	handlers := t.onConnectionLost
+	if wasConnected {
+		// Stop the other loop of this connection
+		close(t.done)
+	}
 	t.mutex.Unlock()
 
 	if wasConnected {
 		t.logger.Error(ctx, "Transport disconnected: %v", err)
 
+		// The connection is unusable; close it so it is not leaked when the
+		// transport reconnects
+		t.closeOnce.Do(func() {
+			if conn != nil {
+				conn.Close()
+			}
+		})
+
 		// Reset the transaction pool to clean state for next reconnection
 		t.transactionPool.transactionsMu.Lock()
 		t.transactionPool.unsafeReset() // This will cancel all transactions
 		t.transactionPool.transactionsMu.Unlock()
+
+		for _, handler := range handlers {
+			handler(err)
+		}
+	}
+}
+
+// OnConnectionLost registers fn to be called with the cause when the
+// connection fails, but not on Disconnect. fn runs on the transport's read
+// or write loop, so it must return quickly; reconnect from another goroutine.
+func (t *TCPTransport) OnConnectionLost(fn func(err error)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.onConnectionLost = append(t.onConnectionLost, fn)
+}
+
+// Send sends a request and returns the response. Errors are
+// *common.EndpointError values labeled with the server address.
+func (t *TCPTransport) Send(ctx context.Context, request common.Request) (common.Response, error) {
+	response, err := t.send(ctx, request)
+	return response, common.WithEndpoint(err, t.Address())
+}
+
+// Broadcast sends a request no device answers, such as a write to
+// common.BroadcastUnitID, and returns once it is written and turnaround has
+// passed. Requests of other callers wait meanwhile, giving the devices time
+// to carry out the broadcast; a response arriving anyway is discarded.
+// Ref: Modbus_over_serial_line_V1_02.pdf, Section 2.1 (Protocol description)
+func (t *TCPTransport) Broadcast(ctx context.Context, request common.Request, turnaround time.Duration) error {
+	return common.WithEndpoint(t.broadcast(ctx, request, turnaround), t.Address())
+}
+
+// broadcast queues a broadcast and waits for the write loop to complete it
+func (t *TCPTransport) broadcast(ctx context.Context, request common.Request, turnaround time.Duration) error {
+	t.mutex.Lock()
+	connected, writeChan, done := t.connected, t.writeChan, t.done
+	t.mutex.Unlock()
+	if !connected {
+		return common.ErrNotConnected
+	}
+
+	tx, err := t.transactionPool.Place(ctx, request)
+	if err != nil {
+		t.logger.Error(ctx, "Failed to create transaction: %v", err)
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	defer t.transactionPool.Discard(tx)
+	tx.broadcast, tx.turnaround = true, turnaround
+
+	t.logger.Debug(ctx, "Broadcasting function=%d as transaction %d",
+		request.GetPDU().FunctionCode, request.GetTransactionID())
+	select {
+	case writeChan <- tx:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return common.ErrTransportClosing
+	}
+
+	select {
+	case <-tx.ResponseCh:
+		return nil
+	case err := <-tx.ErrCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// Send sends a request and returns the response
+// send sends a request and returns the response
 // This implements the client-side request/response pattern for Modbus TCP
 // Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4 (MODBUS Data Model)
-func (t *TCPTransport) Send(ctx context.Context, request common.Request) (common.Response, error) {
-	if !t.IsConnected() {
+func (t *TCPTransport) send(ctx context.Context, request common.Request) (common.Response, error) {
+	t.mutex.Lock()
+	connected, writeChan, done := t.connected, t.writeChan, t.done
+	t.mutex.Unlock()
+	if !connected {
 		return nil, common.ErrNotConnected
 	}
+	start := time.Now()
 
 	// Log the function code being sent
 	// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 6 (MODBUS Function Codes)
@@ -535,7 +864,7 @@ func (t *TCPTransport) Send(ctx context.Context, request common.Request) (common
 
 	// Send the transaction to the write loop
 	select {
-	case t.writeChan <- tx:
+	case writeChan <- tx:
 		t.logger.Debug(ctx, "Queued transaction %d for writing", request.GetTransactionID())
 	case <-ctx.Done():
 		// Context cancelled before we could queue
@@ -543,7 +872,7 @@ func (t *TCPTransport) Send(ctx context.Context, request common.Request) (common
 			request.GetTransactionID())
 		t.transactionPool.Release(request.GetTransactionID())
 		return nil, ctx.Err()
-	case <-t.done:
+	case <-done:
 		// Transport is shutting down
 		t.logger.Debug(ctx, "Transport shutting down, cancelling transaction %d",
 			request.GetTransactionID())
@@ -555,16 +884,20 @@ func (t *TCPTransport) Send(ctx context.Context, request common.Request) (common
 	select {
 	case response := <-tx.ResponseCh:
 		t.logger.Debug(ctx, "Received response for transaction %d", request.GetTransactionID())
+		t.latency.response(time.Since(start))
 		return response, nil
 	case err := <-tx.ErrCh:
 		t.logger.Debug(ctx, "Received error for transaction %d: %v",
 			request.GetTransactionID(), err)
+		t.latency.failure()
 		return nil, err
 	case <-ctx.Done():
 		// Context cancelled while waiting for response
 		t.logger.Debug(ctx, "Context cancelled while waiting for transaction %d",
 			request.GetTransactionID())
-		// Transaction will be cleaned up by timeout monitor
+		t.latency.failure()
+		// Free the transaction ID now rather than at the pool timeout
+		t.transactionPool.Discard(tx)
 		return nil, ctx.Err()
 	}
 }
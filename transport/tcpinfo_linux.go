@@ -0,0 +1,46 @@
+//go:build linux
+
+package transport
+
+import (
+	"net"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// socketStats reads TCP_INFO for conn, or returns nil if it cannot
+func socketStats(conn net.Conn) *SocketStats {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return nil
+	}
+
+	var info syscall.TCPInfo
+	var sysErr syscall.Errno
+	err = raw.Control(func(fd uintptr) {
+		size := uint32(unsafe.Sizeof(info))
+		_, _, sysErr = syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd,
+			syscall.IPPROTO_TCP, syscall.TCP_INFO,
+			uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&size)), 0)
+	})
+	if err != nil || sysErr != 0 {
+		return nil
+	}
+
+	// The kernel reports times in microseconds
+	return &SocketStats{
+		RTT:              time.Duration(info.Rtt) * time.Microsecond,
+		RTTVariance:      time.Duration(info.Rttvar) * time.Microsecond,
+		RTO:              time.Duration(info.Rto) * time.Microsecond,
+		Retransmits:      uint32(info.Retransmits),
+		TotalRetransmits: info.Total_retrans,
+		Lost:             info.Lost,
+		Unacked:          info.Unacked,
+		CongestionWindow: info.Snd_cwnd,
+	}
+}
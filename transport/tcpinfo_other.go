@@ -0,0 +1,10 @@
+//go:build !linux
+
+package transport
+
+import "net"
+
+// socketStats returns nil: TCP_INFO is only read on Linux
+func socketStats(conn net.Conn) *SocketStats {
+	return nil
+}
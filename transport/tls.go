@@ -0,0 +1,28 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// WithTLSConfig secures the connection with TLS, as Modbus/TCP Security
+// devices require, typically on port common.DefaultTLSPort (set it with
+// WithPort). For mutual TLS the config carries the client certificate, whose
+// role extension the server uses to authorize requests. The server name is
+// taken from the host when config does not set it.
+// Ref: MB-TCP-Security-v21_2018-07-24.pdf
+func WithTLSConfig(config *tls.Config) TCPTransportOption {
+	return func(t *TCPTransport) {
+		t.tlsConfig = config
+	}
+}
+
+// dial connects to addr, completing the TLS handshake with WithTLSConfig
+func (t *TCPTransport) dial(ctx context.Context, dialer *net.Dialer, addr string) (net.Conn, error) {
+	if t.tlsConfig == nil {
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	tlsDialer := tls.Dialer{NetDialer: dialer, Config: t.tlsConfig}
+	return tlsDialer.DialContext(ctx, "tcp", addr)
+}
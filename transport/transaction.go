@@ -17,6 +17,11 @@ type Transaction struct {
 	ctx        context.Context     // Context for cancellation
 	cancelFunc context.CancelFunc  // Function to cancel the context
 	createTime time.Time           // Time when the transaction was created, used for timeout detection
+
+	// Set for broadcasts, which get no response: the write loop completes
+	// them once written and turnaround has passed
+	broadcast  bool
+	turnaround time.Duration
 }
 
 // NewTransaction creates a new transaction with a given request and context
@@ -137,13 +137,12 @@ func (tp *TransactionPool) timeoutMonitor() {
 
 // checkTimeouts looks for timed out transactions and cancels them
 func (tp *TransactionPool) checkTimeouts() {
-	ctx := context.Background()
 	tp.transactionsMu.Lock()
 	defer tp.transactionsMu.Unlock()
 
 	for txID, tx := range tp.transactions {
 		if tx.GetLifetime() > tp.timeoutDuration {
-			tp.logger.Warn(ctx, "Transaction %d timed out after %v", txID, tx.GetLifetime())
+			tp.logger.Warn(tx.Context(), "Transaction %d timed out after %v", txID, tx.GetLifetime())
 			tp.unsafeRelease(txID)
 
 			// Cancel the transaction with timeout error
@@ -222,6 +221,21 @@ func (tp *TransactionPool) Release(txID common.TransactionID) (result *Transacti
 	return
 }
 
+// Discard removes tx from the pool if it still holds its transaction ID,
+// returning the ID to the free list, and reports whether it did. A response
+// arriving later for the ID is treated as unknown.
+func (tp *TransactionPool) Discard(tx *Transaction) bool {
+	tp.transactionsMu.Lock()
+	defer tp.transactionsMu.Unlock()
+
+	txID := tx.Request.GetTransactionID()
+	if current, ok := tp.transactions[txID]; !ok || current != tx {
+		return false
+	}
+	tp.unsafeRelease(txID)
+	return true
+}
+
 func (tp *TransactionPool) unsafeRelease(txID common.TransactionID) {
 	// Caller must hold mu
 	delete(tp.transactions, txID)
@@ -0,0 +1,329 @@
+// Package transporttest is a conformance suite for implementations of
+// common.Transport. It checks that a transport behaves like the built-in
+// TCPTransport as documented on common.Transport, so third-party transports
+// (bridges to other buses, experimental protocols) can be used with clients
+// in its place:
+//
+//	func TestMyTransport(t *testing.T) {
+//		transporttest.Run(t, func(t *testing.T, address string) common.Transport {
+//			return mybus.NewBridge(address)
+//		})
+//	}
+package transporttest
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/server"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+// UnitID is the unit ID of the requests the suite sends
+const UnitID common.UnitID = 1
+
+// Registers is the number of holding registers the device serves, from
+// address 0. Register i initially holds RegisterValue(i); addresses beyond
+// are answered with exception 0x02 (Illegal Data Address).
+const Registers = 100
+
+// SlowDelay is how long the device takes to answer Read Input Registers,
+// so the suite can cancel requests in flight
+const SlowDelay = 500 * time.Millisecond
+
+// RegisterValue returns the initial value of holding register i
+func RegisterValue(i int) common.RegisterValue {
+	return common.RegisterValue(1000 + i)
+}
+
+// Factory creates the transport under test, not yet connected. address is
+// the host:port of a Modbus TCP server started by the suite; requests sent
+// through the transport must reach it, directly or through a bridge.
+type Factory func(t *testing.T, address string) common.Transport
+
+// Run runs the conformance suite against transports created by factory, as
+// subtests of t
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, tr common.Transport)
+	}{
+		{"ConnectIdempotent", testConnectIdempotent},
+		{"DisconnectIdempotent", testDisconnectIdempotent},
+		{"SendNotConnected", testSendNotConnected},
+		{"ReadWrite", testReadWrite},
+		{"Exception", testException},
+		{"ConcurrentSend", testConcurrentSend},
+		{"Cancel", testCancel},
+		{"Deadline", testDeadline},
+		{"Reconnect", testReconnect},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := factory(t, startDevice(t))
+			t.Cleanup(func() { tr.Disconnect(context.Background()) })
+			test.fn(t, tr)
+		})
+	}
+}
+
+// startDevice starts the server the transports under test talk to and
+// returns its address
+func startDevice(t *testing.T) string {
+	t.Helper()
+
+	store := server.NewMemoryStore(server.WithMemoryStoreRange(common.TableHoldingRegisters, 0, Registers-1))
+	for i := 0; i < Registers; i++ {
+		store.SetHoldingRegister(common.Address(i), RegisterValue(i))
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("transporttest: failed to listen: %v", err)
+	}
+	srv := server.NewTCPServer("127.0.0.1",
+		server.WithServerListener(listener),
+		server.WithServerDataStore(store),
+		server.WithServerLogger(logging.NewNoopLogger()),
+		server.WithServerResponseDelay(map[common.FunctionCode]time.Duration{
+			common.FuncReadInputRegisters: SlowDelay,
+		}))
+	if err := srv.Start(context.Background()); err != nil {
+		listener.Close()
+		t.Fatalf("transporttest: failed to start server: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+	return listener.Addr().String()
+}
+
+// connect connects tr, failing the test on error
+func connect(t *testing.T, tr common.Transport) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tr.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if !tr.IsConnected() {
+		t.Fatal("IsConnected is false after Connect")
+	}
+}
+
+// readRequest returns a Read Holding Registers request
+func readRequest(address, quantity int) common.Request {
+	data := binary.BigEndian.AppendUint16(nil, uint16(address))
+	data = binary.BigEndian.AppendUint16(data, uint16(quantity))
+	return transport.NewRequest(UnitID, common.FuncReadHoldingRegisters, data)
+}
+
+// slowRequest returns a request the device answers after SlowDelay
+func slowRequest() common.Request {
+	return transport.NewRequest(UnitID, common.FuncReadInputRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+}
+
+// send sends request with a deadline, failing the test on error
+func send(t *testing.T, tr common.Transport, request common.Request) common.Response {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	response, err := tr.Send(ctx, request)
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	return response
+}
+
+// checkRead verifies a response to readRequest(address, quantity)
+func checkRead(response common.Response, address, quantity int) error {
+	if response.IsException() {
+		return fmt.Errorf("unexpected exception %v", response.ToError())
+	}
+	pdu := response.GetPDU()
+	if pdu.FunctionCode != common.FuncReadHoldingRegisters {
+		return fmt.Errorf("expected function %s, got %s", common.FuncReadHoldingRegisters, pdu.FunctionCode)
+	}
+	if len(pdu.Data) != 1+2*quantity || int(pdu.Data[0]) != 2*quantity {
+		return fmt.Errorf("expected %d registers, got data % X", quantity, pdu.Data)
+	}
+	for i := 0; i < quantity; i++ {
+		value := common.RegisterValue(binary.BigEndian.Uint16(pdu.Data[1+2*i:]))
+		if want := RegisterValue(address + i); value != want {
+			return fmt.Errorf("register %d: expected %d, got %d", address+i, want, value)
+		}
+	}
+	return nil
+}
+
+// testConnectIdempotent: Connect on a connected transport returns nil or an
+// error matching common.ErrAlreadyConnected and keeps the connection usable
+func testConnectIdempotent(t *testing.T, tr common.Transport) {
+	connect(t, tr)
+	if err := tr.Connect(context.Background()); err != nil && !errors.Is(err, common.ErrAlreadyConnected) {
+		t.Errorf("Second Connect: expected nil or ErrAlreadyConnected, got %v", err)
+	}
+	if !tr.IsConnected() {
+		t.Fatal("IsConnected is false after a second Connect")
+	}
+	if err := checkRead(send(t, tr, readRequest(0, 1)), 0, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+// testDisconnectIdempotent: Disconnect on a transport that is not connected
+// returns nil
+func testDisconnectIdempotent(t *testing.T, tr common.Transport) {
+	if err := tr.Disconnect(context.Background()); err != nil {
+		t.Errorf("Disconnect before Connect: %v", err)
+	}
+	connect(t, tr)
+	if err := tr.Disconnect(context.Background()); err != nil {
+		t.Errorf("Disconnect: %v", err)
+	}
+	if tr.IsConnected() {
+		t.Error("IsConnected is true after Disconnect")
+	}
+	if err := tr.Disconnect(context.Background()); err != nil {
+		t.Errorf("Second Disconnect: %v", err)
+	}
+}
+
+// testSendNotConnected: Send before Connect and after Disconnect fails with
+// an error matching common.ErrNotConnected, without blocking
+func testSendNotConnected(t *testing.T, tr common.Transport) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := tr.Send(ctx, readRequest(0, 1)); !errors.Is(err, common.ErrNotConnected) {
+		t.Errorf("Send before Connect: expected ErrNotConnected, got %v", err)
+	}
+	connect(t, tr)
+	tr.Disconnect(ctx)
+	if _, err := tr.Send(ctx, readRequest(0, 1)); !errors.Is(err, common.ErrNotConnected) {
+		t.Errorf("Send after Disconnect: expected ErrNotConnected, got %v", err)
+	}
+}
+
+// testReadWrite: requests reach the device and responses carry its PDU
+func testReadWrite(t *testing.T, tr common.Transport) {
+	connect(t, tr)
+	if err := checkRead(send(t, tr, readRequest(10, 5)), 10, 5); err != nil {
+		t.Error(err)
+	}
+
+	write := transport.NewRequest(UnitID, common.FuncWriteSingleRegister, []byte{0x00, 0x14, 0xAB, 0xCD})
+	response := send(t, tr, write)
+	if response.IsException() || response.GetPDU().FunctionCode != common.FuncWriteSingleRegister {
+		t.Fatalf("Unexpected write response %v", response.GetPDU())
+	}
+	read := send(t, tr, readRequest(20, 1))
+	if data := read.GetPDU().Data; len(data) != 3 || data[1] != 0xAB || data[2] != 0xCD {
+		t.Errorf("Expected the written value back, got % X", data)
+	}
+	if read.GetUnitID() != UnitID {
+		t.Errorf("Expected unit ID %d in the response, got %d", UnitID, read.GetUnitID())
+	}
+}
+
+// testException: exception responses are returned as responses, not errors
+func testException(t *testing.T, tr common.Transport) {
+	connect(t, tr)
+	response := send(t, tr, readRequest(Registers, 1))
+	if !response.IsException() || response.GetException() != common.ExceptionDataAddressNotAvailable {
+		t.Fatalf("Expected exception 0x02, got %v", response.GetPDU())
+	}
+	if !common.IsDataAddressNotAvailableError(response.ToError()) {
+		t.Errorf("Expected ToError to report an illegal data address, got %v", response.ToError())
+	}
+	if err := checkRead(send(t, tr, readRequest(0, 1)), 0, 1); err != nil {
+		t.Errorf("After an exception: %v", err)
+	}
+}
+
+// testConcurrentSend: Send is safe for concurrent use and every caller gets
+// the response to its own request
+func testConcurrentSend(t *testing.T, tr common.Transport) {
+	connect(t, tr)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const senders = 16
+	errs := make(chan error, senders)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			address, quantity := i*5, 1+i%5
+			response, err := tr.Send(ctx, readRequest(address, quantity))
+			if err == nil {
+				err = checkRead(response, address, quantity)
+			}
+			if err != nil {
+				errs <- fmt.Errorf("sender %d: %w", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// testCancel: cancelling the context of a request in flight makes Send
+// return promptly with an error matching context.Canceled, and the
+// transport stays usable
+func testCancel(t *testing.T, tr common.Transport) {
+	connect(t, tr)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := tr.Send(ctx, slowRequest())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= SlowDelay {
+		t.Errorf("Send returned after %v, not when cancelled", elapsed)
+	}
+
+	// A late response to the cancelled request must not be delivered to
+	// the next one
+	time.Sleep(SlowDelay)
+	if err := checkRead(send(t, tr, readRequest(3, 2)), 3, 2); err != nil {
+		t.Errorf("After cancel: %v", err)
+	}
+}
+
+// testDeadline: a request outliving its context deadline fails with an
+// error matching context.DeadlineExceeded or common.ErrTimeout
+func testDeadline(t *testing.T, tr common.Transport) {
+	connect(t, tr)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := tr.Send(ctx, slowRequest())
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, common.ErrTimeout) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+}
+
+// testReconnect: a disconnected transport can be connected again
+func testReconnect(t *testing.T, tr common.Transport) {
+	for i := 0; i < 2; i++ {
+		connect(t, tr)
+		if err := checkRead(send(t, tr, readRequest(i, 1)), i, 1); err != nil {
+			t.Errorf("Connection %d: %v", i+1, err)
+		}
+		if err := tr.Disconnect(context.Background()); err != nil {
+			t.Errorf("Disconnect: %v", err)
+		}
+	}
+}
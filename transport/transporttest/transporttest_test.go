@@ -0,0 +1,101 @@
+package transporttest
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+	"github.com/Moonlight-Companies/gomodbus/logging"
+	"github.com/Moonlight-Companies/gomodbus/transport"
+)
+
+func TestTCPTransport(t *testing.T) {
+	Run(t, func(t *testing.T, address string) common.Transport {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ := strconv.Atoi(port)
+		return transport.NewTCPTransport(host, transport.WithPort(n), transport.WithTransportLogger(logging.NewNoopLogger()))
+	})
+}
+
+func TestRTUOverTCPTransport(t *testing.T) {
+	Run(t, func(t *testing.T, address string) common.Transport {
+		port := startRTUBridge(t, address)
+		return transport.NewRTUOverTCPTransport("127.0.0.1", transport.WithPort(port), transport.WithTransportLogger(logging.NewNoopLogger()))
+	})
+}
+
+// startRTUBridge starts a converter between RTU over TCP and the Modbus TCP
+// server at address, answering one request at a time like a serial line
+func startRTUBridge(t *testing.T, address string) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go bridgeRTU(conn, address)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// bridgeRTU forwards the Read Holding and Input Registers requests of an
+// RTU over TCP connection to a Modbus TCP server
+func bridgeRTU(conn net.Conn, address string) {
+	defer conn.Close()
+	upstream, err := net.Dial("tcp", address)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+
+	request := make([]byte, 8) // unit ID, function code, address, quantity, CRC
+	for {
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+		adu := []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06}
+		if _, err := upstream.Write(append(adu, request[:6]...)); err != nil {
+			return
+		}
+		header := make([]byte, 6)
+		if _, err := io.ReadFull(upstream, header); err != nil {
+			return
+		}
+		body := make([]byte, binary.BigEndian.Uint16(header[4:]))
+		if _, err := io.ReadFull(upstream, body); err != nil {
+			return
+		}
+		if _, err := conn.Write(binary.LittleEndian.AppendUint16(body, crc16(body))); err != nil {
+			return
+		}
+	}
+}
+
+// crc16 computes the Modbus CRC of data
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// startRewritingServer starts a server that answers every request with an
+// echo of its PDU, rewriting the unit ID to unitID, and returns its port
+func startRewritingServer(t *testing.T, unitID byte) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go echoRewritten(conn, unitID)
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// echoRewritten answers requests on conn until it is closed
+func echoRewritten(conn net.Conn, unitID byte) {
+	defer conn.Close()
+
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		pdu := make([]byte, binary.BigEndian.Uint16(header[4:6])-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+		header[6] = unitID
+		if _, err := conn.Write(append(header, pdu...)); err != nil {
+			return
+		}
+	}
+}
+
+func TestUnitIDMismatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cases := []struct {
+		name     string
+		options  []TCPTransportOption
+		wantFail bool
+	}{
+		{"strict", nil, true},
+		{"tolerant", []TCPTransportOption{WithUnitIDTolerance()}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			port := startRewritingServer(t, 9)
+			transport := NewTCPTransport("127.0.0.1", append(tc.options, WithPort(port))...)
+			if err := transport.Connect(ctx); err != nil {
+				t.Fatalf("Failed to connect: %v", err)
+			}
+			defer transport.Disconnect(ctx)
+
+			request := createTestRequest(1, common.FuncReadHoldingRegisters, []byte{0x00, 0x00, 0x00, 0x01})
+			response, err := transport.Send(ctx, request)
+
+			if tc.wantFail {
+				var mismatch *common.UnitIDMismatchError
+				if !errors.As(err, &mismatch) || !errors.Is(err, common.ErrUnitIDMismatch) {
+					t.Fatalf("Expected a UnitIDMismatchError, got %v", err)
+				}
+				if mismatch.Expected != 1 || mismatch.Received != 9 {
+					t.Errorf("Expected unit 1, got 9; error reports %d and %d", mismatch.Expected, mismatch.Received)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Expected the response to be accepted, got %v", err)
+				}
+				if response.GetUnitID() != 9 {
+					t.Errorf("Expected response from unit 9, got %d", response.GetUnitID())
+				}
+			}
+
+			if got := transport.UnitIDMismatches(); got != 1 {
+				t.Errorf("Expected 1 mismatch to be counted, got %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package values packs and unpacks values spanning several Modbus registers:
+// 32- and 64-bit integers, IEEE 754 floats and ASCII strings. Modbus only
+// defines 16-bit registers, sent high byte first, so devices differ in the
+// order of the words and bytes of larger values; every function takes the
+// Order the device uses.
+// Ref: Modbus_Application_Protocol_V1_1b3.pdf, Section 4.2 (Data Encoding)
+package values
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+// Order is the order of the words and bytes of a multi-register value,
+// named by the position of the bytes ABCD of its big-endian form. The names
+// match the order option of the client's struct tags.
+type Order string
+
+const (
+	// BigEndian is ABCD: high word first, high byte first. The zero Order
+	// is big-endian too.
+	BigEndian Order = "be"
+
+	// LittleEndian is DCBA: low word first, low byte first
+	LittleEndian Order = "le"
+
+	// WordSwap is CDAB: low word first, high byte first
+	WordSwap Order = "cdab"
+
+	// ByteSwap is BADC: high word first, low byte first
+	ByteSwap Order = "badc"
+)
+
+// Validate returns an error wrapping common.ErrInvalidValue for unsupported
+// orders
+func (o Order) Validate() error {
+	switch o {
+	case "", BigEndian, LittleEndian, WordSwap, ByteSwap:
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported word order %q", common.ErrInvalidValue, string(o))
+}
+
+// Reorder converts b between big-endian (ABCD) byte order and the order in
+// place. Every order is its own inverse.
+func (o Order) Reorder(b []byte) {
+	switch o {
+	case LittleEndian:
+		slices.Reverse(b)
+	case WordSwap:
+		for i, j := 0, len(b)-2; i < j; i, j = i+2, j-2 {
+			b[i], b[i+1], b[j], b[j+1] = b[j], b[j+1], b[i], b[i+1]
+		}
+	case ByteSwap:
+		for i := 0; i+1 < len(b); i += 2 {
+			b[i], b[i+1] = b[i+1], b[i]
+		}
+	}
+}
+
+// swapsBytes reports whether the order stores the low byte of each word
+// first, which is all that applies to strings
+func (o Order) swapsBytes() bool {
+	return o == LittleEndian || o == ByteSwap
+}
+
+// decode joins words registers into a big-endian integer
+func decode(registers []common.RegisterValue, words int, order Order) (uint64, error) {
+	if err := order.Validate(); err != nil {
+		return 0, err
+	}
+	if len(registers) < words {
+		return 0, fmt.Errorf("%d-register value decoded from %d: %w", words, len(registers), common.ErrInvalidQuantity)
+	}
+	b := make([]byte, 2*words)
+	for i, register := range registers[:words] {
+		b[2*i], b[2*i+1] = byte(register>>8), byte(register)
+	}
+	order.Reorder(b)
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// encode splits a big-endian integer into words registers
+func encode(n uint64, words int, order Order) ([]common.RegisterValue, error) {
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+	b := make([]byte, 2*words)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = byte(n)
+		n >>= 8
+	}
+	order.Reorder(b)
+	registers := make([]common.RegisterValue, words)
+	for i := range registers {
+		registers[i] = common.RegisterValue(b[2*i])<<8 | common.RegisterValue(b[2*i+1])
+	}
+	return registers, nil
+}
+
+// Uint32 decodes an unsigned 32-bit integer from the first two registers
+func Uint32(registers []common.RegisterValue, order Order) (uint32, error) {
+	n, err := decode(registers, 2, order)
+	return uint32(n), err
+}
+
+// Int32 decodes a two's complement 32-bit integer from the first two
+// registers
+func Int32(registers []common.RegisterValue, order Order) (int32, error) {
+	n, err := decode(registers, 2, order)
+	return int32(uint32(n)), err
+}
+
+// Float32 decodes an IEEE 754 single precision float from the first two
+// registers
+func Float32(registers []common.RegisterValue, order Order) (float32, error) {
+	n, err := decode(registers, 2, order)
+	return math.Float32frombits(uint32(n)), err
+}
+
+// Uint64 decodes an unsigned 64-bit integer from the first four registers
+func Uint64(registers []common.RegisterValue, order Order) (uint64, error) {
+	return decode(registers, 4, order)
+}
+
+// Int64 decodes a two's complement 64-bit integer from the first four
+// registers
+func Int64(registers []common.RegisterValue, order Order) (int64, error) {
+	n, err := decode(registers, 4, order)
+	return int64(n), err
+}
+
+// Float64 decodes an IEEE 754 double precision float from the first four
+// registers
+func Float64(registers []common.RegisterValue, order Order) (float64, error) {
+	n, err := decode(registers, 4, order)
+	return math.Float64frombits(n), err
+}
+
+// FromUint32 encodes an unsigned 32-bit integer as two registers
+func FromUint32(value uint32, order Order) ([]common.RegisterValue, error) {
+	return encode(uint64(value), 2, order)
+}
+
+// FromInt32 encodes a 32-bit integer as two registers, in two's complement
+func FromInt32(value int32, order Order) ([]common.RegisterValue, error) {
+	return encode(uint64(uint32(value)), 2, order)
+}
+
+// FromFloat32 encodes a float as two registers, in IEEE 754 single precision
+func FromFloat32(value float32, order Order) ([]common.RegisterValue, error) {
+	return encode(uint64(math.Float32bits(value)), 2, order)
+}
+
+// FromUint64 encodes an unsigned 64-bit integer as four registers
+func FromUint64(value uint64, order Order) ([]common.RegisterValue, error) {
+	return encode(value, 4, order)
+}
+
+// FromInt64 encodes a 64-bit integer as four registers, in two's complement
+func FromInt64(value int64, order Order) ([]common.RegisterValue, error) {
+	return encode(uint64(value), 4, order)
+}
+
+// FromFloat64 encodes a float as four registers, in IEEE 754 double
+// precision
+func FromFloat64(value float64, order Order) ([]common.RegisterValue, error) {
+	return encode(math.Float64bits(value), 4, order)
+}
+
+// String decodes an ASCII string stored two characters per register. The
+// string ends at the first NUL or at the end of the registers. Only the byte
+// order within a register applies: LittleEndian and ByteSwap store the
+// second character of each pair in the high byte. For other string formats
+// see client.StringEncoding.
+func String(registers []common.RegisterValue, order Order) (string, error) {
+	if err := order.Validate(); err != nil {
+		return "", err
+	}
+	b := make([]byte, 0, 2*len(registers))
+	for _, register := range registers {
+		high, low := byte(register>>8), byte(register)
+		if order.swapsBytes() {
+			high, low = low, high
+		}
+		b = append(b, high, low)
+	}
+	for i, c := range b {
+		if c == 0 {
+			b = b[:i]
+			break
+		}
+		if c > 0x7F {
+			return "", fmt.Errorf("%w: non-ASCII byte 0x%02X in string", common.ErrInvalidValue, c)
+		}
+	}
+	return string(b), nil
+}
+
+// FromString encodes an ASCII string in the given number of registers, two
+// characters per register, padded with NULs. See String for the order.
+func FromString(s string, registers int, order Order) ([]common.RegisterValue, error) {
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+	if registers < 1 {
+		return nil, fmt.Errorf("string of %d registers: %w", registers, common.ErrInvalidQuantity)
+	}
+	if len(s) > 2*registers {
+		return nil, fmt.Errorf("%w: string of %d bytes does not fit in %d registers", common.ErrInvalidValue, len(s), registers)
+	}
+	b := make([]byte, 2*registers)
+	for i := 0; i < len(s); i++ {
+		if s[i] > 0x7F {
+			return nil, fmt.Errorf("%w: non-ASCII byte 0x%02X in string", common.ErrInvalidValue, s[i])
+		}
+		b[i] = s[i]
+	}
+	encoded := make([]common.RegisterValue, registers)
+	for i := range encoded {
+		high, low := b[2*i], b[2*i+1]
+		if order.swapsBytes() {
+			high, low = low, high
+		}
+		encoded[i] = common.RegisterValue(high)<<8 | common.RegisterValue(low)
+	}
+	return encoded, nil
+}
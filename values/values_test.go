@@ -0,0 +1,114 @@
+package values
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Moonlight-Companies/gomodbus/common"
+)
+
+func TestOrders(t *testing.T) {
+	// 0x41200000 is 10.0 as a float32
+	tests := []struct {
+		order     Order
+		registers []common.RegisterValue
+	}{
+		{"", []common.RegisterValue{0x4120, 0x0000}},
+		{BigEndian, []common.RegisterValue{0x4120, 0x0000}},
+		{LittleEndian, []common.RegisterValue{0x0000, 0x2041}},
+		{WordSwap, []common.RegisterValue{0x0000, 0x4120}},
+		{ByteSwap, []common.RegisterValue{0x2041, 0x0000}},
+	}
+	for _, tc := range tests {
+		registers, err := FromFloat32(10, tc.order)
+		if err != nil || !slices.Equal(registers, tc.registers) {
+			t.Errorf("%q: expected %04X, got %04X (%v)", tc.order, tc.registers, registers, err)
+		}
+		value, err := Float32(tc.registers, tc.order)
+		if err != nil || value != 10 {
+			t.Errorf("%q: expected 10, got %v (%v)", tc.order, value, err)
+		}
+	}
+
+	if _, err := Float32([]common.RegisterValue{0, 0}, "dcab"); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for an unknown order, got %v", err)
+	}
+	if _, err := FromUint32(1, "dcab"); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for an unknown order, got %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, order := range []Order{BigEndian, LittleEndian, WordSwap, ByteSwap} {
+		check := func(name string, registers []common.RegisterValue, words int, err error, decodeErr error, equal bool) {
+			t.Helper()
+			if err != nil || decodeErr != nil || len(registers) != words || !equal {
+				t.Errorf("%s %s: round trip failed: %04X (%v, %v)", order, name, registers, err, decodeErr)
+			}
+		}
+
+		r, err := FromUint32(0xDEADBEEF, order)
+		u32, derr := Uint32(r, order)
+		check("uint32", r, 2, err, derr, u32 == 0xDEADBEEF)
+
+		r, err = FromInt32(-123456, order)
+		i32, derr := Int32(r, order)
+		check("int32", r, 2, err, derr, i32 == -123456)
+
+		r, err = FromUint64(0x0102030405060708, order)
+		u64, derr := Uint64(r, order)
+		check("uint64", r, 4, err, derr, u64 == 0x0102030405060708)
+
+		r, err = FromInt64(math.MinInt64+1, order)
+		i64, derr := Int64(r, order)
+		check("int64", r, 4, err, derr, i64 == math.MinInt64+1)
+
+		r, err = FromFloat64(-273.15, order)
+		f64, derr := Float64(r, order)
+		check("float64", r, 4, err, derr, f64 == -273.15)
+	}
+
+	// Big-endian is the order of the spec
+	r, _ := FromUint64(0x0102030405060708, BigEndian)
+	if !slices.Equal(r, []common.RegisterValue{0x0102, 0x0304, 0x0506, 0x0708}) {
+		t.Errorf("Expected big-endian registers, got %04X", r)
+	}
+	r, _ = FromUint64(0x0102030405060708, WordSwap)
+	if !slices.Equal(r, []common.RegisterValue{0x0708, 0x0506, 0x0304, 0x0102}) {
+		t.Errorf("Expected word-swapped registers, got %04X", r)
+	}
+
+	if _, err := Float64([]common.RegisterValue{1, 2, 3}, BigEndian); !errors.Is(err, common.ErrInvalidQuantity) {
+		t.Errorf("Expected ErrInvalidQuantity for three registers, got %v", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	registers, err := FromString("ABC", 3, BigEndian)
+	if err != nil || !slices.Equal(registers, []common.RegisterValue{0x4142, 0x4300, 0x0000}) {
+		t.Fatalf("Expected NUL-padded registers, got %04X (%v)", registers, err)
+	}
+	if s, err := String(registers, BigEndian); err != nil || s != "ABC" {
+		t.Errorf("Expected ABC, got %q (%v)", s, err)
+	}
+
+	registers, err = FromString("ABCD", 2, ByteSwap)
+	if err != nil || !slices.Equal(registers, []common.RegisterValue{0x4241, 0x4443}) {
+		t.Fatalf("Expected byte-swapped registers, got %04X (%v)", registers, err)
+	}
+	if s, err := String(registers, ByteSwap); err != nil || s != "ABCD" {
+		t.Errorf("Expected ABCD, got %q (%v)", s, err)
+	}
+
+	if _, err := FromString("ABCDE", 2, BigEndian); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for a string too long, got %v", err)
+	}
+	if _, err := FromString("é", 2, BigEndian); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for a non-ASCII string, got %v", err)
+	}
+	if _, err := String([]common.RegisterValue{0x41FF}, BigEndian); !errors.Is(err, common.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for a non-ASCII byte, got %v", err)
+	}
+}